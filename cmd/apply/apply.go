@@ -0,0 +1,296 @@
+// Package apply provides the apply command for converging a machine to a declarative bootstrap.yaml
+package apply
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/apply"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/remote"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+	"github.com/spf13/cobra"
+)
+
+var logger *log.Logger
+
+// NewApplyCmd creates the apply command
+func NewApplyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Converge this machine to the state described in a bootstrap.yaml",
+		Long: `Read a declarative bootstrap.yaml listing the desired tools, languages,
+fonts, shell and dotfiles repository, and install whatever is missing.
+Items that are already present are left alone, so re-running against an
+unchanged file does nothing. With --prune, tools recorded as installed by
+bootstrap-cli that the file no longer lists are removed.
+
+Example bootstrap.yaml:
+
+  tools: [git, ripgrep, fzf]
+  languages: [Go@1.22.3, Python]
+  fonts: [JetBrains Mono]
+  shell: zsh
+  prompt: starship
+  plugin_manager: oh-my-zsh
+  dotfiles: https://github.com/me/dotfiles.git
+
+With --target user@host the file is applied on another machine instead:
+bootstrap-cli and the file are copied over SSH into a temporary directory in
+that user's account, apply runs there, and its result is reported here.`,
+		RunE: runApply,
+	}
+	cmd.Flags().StringP("file", "f", apply.DefaultSpecFile, "Desired state file")
+	cmd.Flags().Bool("prune", false, "Remove tools recorded in the manifest that the file no longer lists")
+	cmd.Flags().Bool("watch", false, "Keep running and re-apply whenever the file changes")
+	cmd.Flags().Duration("interval", 2*time.Second, "How often --watch checks the file for changes")
+	cmd.Flags().BoolP("yes", "y", false, "Change the login shell to the file's shell without asking")
+	cmd.Flags().String("target", "", "Apply on a remote machine over SSH, as user@host[:port] or user@[ipv6-host][:port]")
+	cmd.Flags().String("remote-binary", "", "bootstrap-cli build to upload with --target (default: this binary, if the platforms match)")
+	cmd.Flags().String("result-file", "", "Write the outcome of the run as JSON to this file")
+	return cmd
+}
+
+func runApply(cmd *cobra.Command, _ []string) error {
+	logger = log.New(log.InfoLevel)
+	if debug, _ := cmd.Flags().GetBool("debug"); debug {
+		logger.SetLevel(log.DebugLevel)
+	}
+
+	path, _ := cmd.Flags().GetString("file")
+	prune, _ := cmd.Flags().GetBool("prune")
+	watch, _ := cmd.Flags().GetBool("watch")
+	interval, _ := cmd.Flags().GetDuration("interval")
+	resultFile, _ := cmd.Flags().GetString("result-file")
+
+	if target, _ := cmd.Flags().GetString("target"); target != "" {
+		if watch {
+			return fmt.Errorf("--watch cannot be combined with --target")
+		}
+		return applyRemote(cmd, target, path, prune)
+	}
+
+	if err := convergeAndReport(cmd, path, prune, resultFile); err != nil {
+		return err
+	}
+	if !watch {
+		return nil
+	}
+
+	logger.Info("Watching %s for changes...", path)
+	last := modTime(path)
+	for {
+		time.Sleep(interval)
+		if mod := modTime(path); !mod.Equal(last) {
+			last = mod
+			if err := convergeAndReport(cmd, path, prune, resultFile); err != nil {
+				// Keep watching so a fixed file is picked up on the next change
+				logger.Error("Apply failed: %v", err)
+			}
+		}
+	}
+}
+
+// convergeAndReport converges once and, with a result file, records the outcome there
+func convergeAndReport(cmd *cobra.Command, path string, prune bool, resultFile string) error {
+	result := apply.NewResult()
+	err := converge(cmd, path, prune, result)
+	if resultFile == "" {
+		return err
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	if writeErr := result.WriteFile(resultFile); writeErr != nil && err == nil {
+		return writeErr
+	}
+	return err
+}
+
+// applyRemote applies the spec at path on target over SSH and prints the result
+func applyRemote(cmd *cobra.Command, target, path string, prune bool) error {
+	t, err := remote.ParseTarget(target)
+	if err != nil {
+		return err
+	}
+	binary, _ := cmd.Flags().GetString("remote-binary")
+	var args []string
+	if prune {
+		args = append(args, "--prune")
+	}
+	if os.Getenv(shell.DryRunEnvVar) != "" {
+		args = append(args, "--dry-run")
+	}
+	if debug, _ := cmd.Flags().GetBool("debug"); debug {
+		args = append(args, "--debug")
+	}
+
+	logger.Info("Applying %s on %s...", path, t)
+	p := &remote.Provisioner{Target: t, Binary: binary, Args: args, Output: cmd.ErrOrStderr()}
+	result, err := p.Apply(path)
+	if err != nil {
+		return err
+	}
+	result.Print(cmd.OutOrStdout())
+	if resultFile, _ := cmd.Flags().GetString("result-file"); resultFile != "" {
+		if err := result.WriteFile(resultFile); err != nil {
+			return err
+		}
+	}
+	if result.Failed() {
+		return fmt.Errorf("apply on %s failed", t)
+	}
+	logger.Success("Converged %s to %s", t, path)
+	return nil
+}
+
+// converge brings the machine in line with the spec at path once, filling in result
+func converge(cmd *cobra.Command, path string, prune bool, result *apply.Result) error {
+	spec, err := apply.LoadSpec(path)
+	if err != nil {
+		return err
+	}
+
+	configPath := os.Getenv("BOOTSTRAP_CLI_CONFIG")
+	if configPath == "" {
+		if configPath, err = config.UserConfigDir(); err != nil {
+			return err
+		}
+	}
+	catalog, err := config.NewLoader(configPath).LoadAll()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	manifestPath, err := manifest.DefaultPath()
+	if err != nil {
+		return err
+	}
+	m, err := manifest.Load(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	home, err := system.UserHome()
+	if err != nil {
+		return err
+	}
+	settingsDir, _ := cmd.Flags().GetString("config")
+	settingsPath, err := config.SettingsPath(settingsDir)
+	if err != nil {
+		return err
+	}
+	settings, err := config.LoadSettings(settingsPath)
+	if err != nil {
+		return err
+	}
+
+	planner := &apply.Planner{
+		DotfilesDir: settings.DotfilesPath(home),
+		Prune:       prune,
+	}
+	plan, err := planner.Plan(spec, catalog, m)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	plan.Print(out)
+	if plan.Converged() {
+		result.Converged = true
+		return nil
+	}
+	if os.Getenv(shell.DryRunEnvVar) != "" {
+		fmt.Fprintln(out, "Dry run: no changes made")
+		return nil
+	}
+
+	platform, hostPM, err := pipeline.DetectHost()
+	if err != nil {
+		return err
+	}
+	pm := pipeline.NewPackageManagerAdapter(hostPM)
+
+	passwordStdin, _ := cmd.Flags().GetBool("sudo-password-stdin")
+	sudo, err := system.PrimeSudo(passwordStdin, os.Stdin, os.Stderr)
+	if err != nil {
+		return err
+	}
+	defer sudo.Stop()
+
+	if len(plan.Install) > 0 || len(plan.Languages) > 0 || len(plan.Fonts) > 0 || plan.Shell != nil || plan.Dotfiles != "" {
+		installer, err := newInstaller(platform, pm)
+		if err != nil {
+			return err
+		}
+		installer.Context.ToolManagers = settings.ToolManagers
+		installer.Context.NeovimConfig = settings.NeovimConfig
+		installer.Catalog = catalog.Tools
+		installer.DotfilesDir, installer.Dotfiles = settings.DotfilesPath(home), catalog.Dotfiles
+		installer.Context.PromptStyle = plan.PromptStyle
+		if plan.Shell != nil {
+			yes, _ := cmd.Flags().GetBool("yes")
+			installer.Context.LoginShellChange = shell.RequestLoginShellChange(plan.Shell, yes, os.Stdin, os.Stdout, logger)
+		}
+		err = installer.InstallSelections(plan.Install, plan.Dotfiles != "", plan.Dotfiles, plan.Fonts, plan.Languages, plan.Shell)
+		if installer.Pipeline != nil {
+			result.Groups = installer.Pipeline.Summary().Groups()
+			for _, group := range result.Groups {
+				logger.Info("%s", group.String())
+			}
+		}
+		if installer.DiskUsage != nil && installer.DiskUsage.Total() > 0 {
+			logger.Info("%s", installer.DiskUsage.String())
+		}
+		if err != nil {
+			return fmt.Errorf("apply failed: %w", err)
+		}
+	}
+
+	if err := plan.InstallPluginManager(); err != nil {
+		return err
+	}
+
+	for _, tool := range plan.Remove {
+		logger.Info("Removing %s...", tool.Name)
+		installer, err := newInstaller(platform, pm)
+		if err != nil {
+			return err
+		}
+		if err := installer.Uninstall(tool); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", tool.Name, err)
+		}
+		result.Removed = append(result.Removed, tool.Name)
+	}
+
+	logger.Success("Converged to %s", path)
+	return nil
+}
+
+// newInstaller creates an installer whose progress events are discarded.
+// Each pipeline run closes its progress channel, so use a fresh one per run.
+func newInstaller(platform *pipeline.Platform, pm pipeline.PackageManager) (*pipeline.Installer, error) {
+	installer, err := pipeline.NewInstaller(platform, pm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create installer: %w", err)
+	}
+	go func() {
+		for range installer.ProgressChan {
+		}
+	}()
+	return installer, nil
+}
+
+// modTime returns the file's modification time, or the zero time if it cannot be read
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
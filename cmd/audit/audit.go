@@ -0,0 +1,56 @@
+// Package audit provides the audit command for reporting what bootstrap-cli has modified
+package audit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/audit"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+	"github.com/spf13/cobra"
+)
+
+// NewAuditCmd creates the audit command
+func NewAuditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Report everything bootstrap-cli has modified on this machine",
+		Long: `Scan this machine for changes made by bootstrap-cli, including:
+- Marker blocks written to shell rc files
+- Installed catalog tools
+- Framework and version manager directories
+- The history of recorded runs`,
+		RunE: runAudit,
+	}
+	return cmd
+}
+
+func runAudit(cmd *cobra.Command, _ []string) error {
+	configPath := os.Getenv("BOOTSTRAP_CLI_CONFIG")
+	if configPath == "" {
+		home, err := system.UserHome()
+		if err != nil {
+			return err
+		}
+		configPath = filepath.Join(home, ".config", "bootstrap-cli")
+	}
+
+	tools, err := config.NewLoader(configPath).LoadTools()
+	if err != nil {
+		return fmt.Errorf("failed to load tools: %w", err)
+	}
+
+	auditor, err := audit.NewAuditor()
+	if err != nil {
+		return err
+	}
+	report, err := auditor.Run(tools)
+	if err != nil {
+		return fmt.Errorf("audit failed: %w", err)
+	}
+
+	report.Print(cmd.OutOrStdout())
+	return nil
+}
@@ -0,0 +1,214 @@
+// Package cache provides the cache command for managing the bootstrap-cli download cache
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	dlcache "github.com/YitzhakMizrahi/bootstrap-cli/internal/cache"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/offline"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/release"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+	"github.com/spf13/cobra"
+)
+
+// NewCacheCmd creates the cache command
+func NewCacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the download cache",
+		Long: `Manage the cache of downloaded binaries and archives.
+Downloads are cached under ~/.cache/bootstrap-cli/downloads (or $` + dlcache.DirEnvVar + `)
+and reused across runs when their checksum still matches. The cache's
+index.json maps each URL to its file and checksum.`,
+	}
+
+	cmd.AddCommand(newClearCmd())
+	cmd.AddCommand(newWarmCmd())
+	return cmd
+}
+
+func newClearCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "clear",
+		Short: "Remove all cached downloads",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			logger := log.New(log.InfoLevel)
+
+			c, err := dlcache.NewDefault()
+			if err != nil {
+				return fmt.Errorf("failed to locate cache: %w", err)
+			}
+			if err := c.Clear(); err != nil {
+				return err
+			}
+
+			logger.Success("Cleared download cache at %s", c.Dir())
+			return nil
+		},
+	}
+}
+
+func newWarmCmd() *cobra.Command {
+	var tools, fonts, frameworks []string
+	var goos, goarch, dir string
+	cmd := &cobra.Command{
+		Use:   "warm",
+		Short: "Pre-download what a selection installs, for offline runs",
+		Long: `Download the release archives, font archives and install scripts the
+selected tools, fonts and shell frameworks need into the download cache, so a
+machine without internet access can install them with --offline (or
+` + dlcache.OfflineEnvVar + `=1). Without a selection, everything in the catalog that
+downloads something is fetched. "latest" releases are resolved now and the
+tag is recorded in the cache index.
+
+Copy the cache directory to the offline machine and point
+` + dlcache.DirEnvVar + ` at it if it is not in the default location. Items installed
+with the system package manager cannot be cached and are listed at the end.`,
+		Example: `  bootstrap-cli cache warm --tools lsd,fd --fonts "JetBrains Mono Nerd Font" --dir ./bootstrap-cache
+  BOOTSTRAP_CLI_CACHE_DIR=./bootstrap-cache bootstrap-cli up --offline`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			logger := log.New(log.InfoLevel)
+			if dlcache.Offline() {
+				return fmt.Errorf("cache warm downloads files, so it cannot run with --offline")
+			}
+			if dir != "" {
+				abs, err := filepath.Abs(dir)
+				if err != nil {
+					return fmt.Errorf("invalid --dir: %w", err)
+				}
+				os.Setenv(dlcache.DirEnvVar, abs)
+			}
+			releases, err := release.NewInstaller()
+			if err != nil {
+				return err
+			}
+			// Warming only makes sense with the cache on
+			releases.Downloads.SetEnabled(true)
+
+			sel, err := selection(tools, fonts, frameworks)
+			if err != nil {
+				return err
+			}
+			sel.Platform = &pipeline.Platform{OS: goos, Arch: goarch}
+			if pm, err := pipeline.DetectPackageManager(); err == nil {
+				sel.Platform.PackageManager = pm.GetName()
+			}
+			items, err := offline.Items(sel)
+			if err != nil {
+				return err
+			}
+
+			stale, err := releases.Downloads.Stale()
+			if err != nil {
+				return err
+			}
+			for _, entry := range stale {
+				logger.Warn("Cached %s is missing or changed on disk; downloading it again", entry.URL)
+			}
+
+			failed := offline.Warm(items, releases, goos, goarch)
+			names := make([]string, 0, len(failed))
+			for name := range failed {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				logger.Error("%s: %v", name, failed[name])
+			}
+
+			cached, network := offline.Report(items, releases.Downloads, goos, goarch)
+			if len(cached) > 0 {
+				logger.Success("Cached for %s/%s in %s: %s", goos, goarch, releases.Downloads.Dir(), offline.Names(cached))
+			}
+			for _, reason := range network {
+				logger.Warn("Still needs the network: %s", reason)
+			}
+			if len(failed) > 0 {
+				return fmt.Errorf("failed to download %d item(s): %s", len(failed), strings.Join(names, ", "))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringSliceVar(&tools, "tools", nil, "Tools (or groups) to download for")
+	cmd.Flags().StringSliceVar(&fonts, "fonts", nil, "Fonts to download")
+	cmd.Flags().StringSliceVar(&frameworks, "shell-framework", nil, "Shell frameworks whose install scripts to download (e.g. oh-my-zsh)")
+	cmd.Flags().StringVar(&goos, "os", runtime.GOOS, "Operating system of the offline machine, for release assets")
+	cmd.Flags().StringVar(&goarch, "arch", runtime.GOARCH, "Architecture of the offline machine, for release assets")
+	cmd.Flags().StringVar(&dir, "dir", "", "Cache directory to download into (default: the download cache)")
+	return cmd
+}
+
+// selection looks up the named tools, fonts and frameworks in the catalog.
+// With no names at all, every tool with a release and every font with a
+// source archive is selected.
+func selection(tools, fonts, frameworks []string) (offline.Selection, error) {
+	configDir := os.Getenv("BOOTSTRAP_CLI_CONFIG")
+	if configDir == "" {
+		home, err := system.UserHome()
+		if err != nil {
+			return offline.Selection{}, err
+		}
+		configDir = filepath.Join(home, ".config", "bootstrap-cli")
+	}
+	loader := config.NewLoader(configDir)
+	catalog, err := loader.LoadTools()
+	if err != nil {
+		return offline.Selection{}, fmt.Errorf("failed to load tools: %w", err)
+	}
+	allFonts, err := loader.LoadFonts()
+	if err != nil {
+		return offline.Selection{}, fmt.Errorf("failed to load fonts: %w", err)
+	}
+	sel := offline.Selection{Catalog: catalog, Frameworks: frameworks}
+
+	if len(tools) == 0 && len(fonts) == 0 && len(frameworks) == 0 {
+		for _, tool := range catalog {
+			if tool.Release != nil {
+				sel.Tools = append(sel.Tools, tool)
+			}
+		}
+		for _, font := range allFonts {
+			if font.Source != "" {
+				sel.Fonts = append(sel.Fonts, font)
+			}
+		}
+		return sel, nil
+	}
+
+	for _, name := range tools {
+		var found *pipeline.Tool
+		for _, tool := range catalog {
+			if tool.Matches(name) {
+				found = tool
+				break
+			}
+		}
+		if found == nil {
+			return offline.Selection{}, fmt.Errorf("unknown tool: %s", name)
+		}
+		sel.Tools = append(sel.Tools, found)
+	}
+	for _, name := range fonts {
+		var found *interfaces.Font
+		for _, font := range allFonts {
+			if strings.EqualFold(font.Name, name) {
+				found = font
+				break
+			}
+		}
+		if found == nil {
+			return offline.Selection{}, fmt.Errorf("unknown font: %s", name)
+		}
+		sel.Fonts = append(sel.Fonts, found)
+	}
+	return sel, nil
+}
@@ -0,0 +1,105 @@
+// Package config provides the config command for checking bootstrap-cli configuration
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	cfg "github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	"github.com/spf13/cobra"
+)
+
+// NewConfigCmd creates the config command
+func NewConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the bootstrap-cli configuration",
+		Long: `Inspect the configuration bootstrap-cli installs from: the built-in
+defaults merged with the user config (~/.config/bootstrap-cli) and the
+selected overlay. generate writes a setup file for unattended init runs.`,
+	}
+
+	cmd.AddCommand(newValidateCmd())
+	cmd.AddCommand(newDumpCmd())
+	cmd.AddCommand(newGenerateCmd())
+	return cmd
+}
+
+// loadCatalog loads the merged configuration from the config directory
+func loadCatalog() (*cfg.Catalog, error) {
+	configPath := os.Getenv("BOOTSTRAP_CLI_CONFIG")
+	if configPath == "" {
+		var err error
+		if configPath, err = cfg.UserConfigDir(); err != nil {
+			return nil, err
+		}
+	}
+	catalog, err := cfg.NewLoader(configPath).LoadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	return catalog, nil
+}
+
+func newValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Check that the merged configuration loads and is consistent",
+		Long: `Load every tool, language, font, shell and dotfile definition and check
+the references between them, such as the tools a tool declares it conflicts
+with or requires, and that no tools require each other in a cycle. Exits
+non-zero when a problem is found.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			catalog, err := loadCatalog()
+			if err != nil {
+				return err
+			}
+
+			issues := pipeline.CatalogConflictIssues(catalog.Tools)
+			issues = append(issues, pipeline.CatalogRequiresIssues(catalog.Tools)...)
+			if len(issues) > 0 {
+				return fmt.Errorf("configuration has %d problem(s):\n  - %s", len(issues), strings.Join(issues, "\n  - "))
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Configuration is valid: %d tools, %d languages, %d fonts, %d shells, %d dotfiles\n",
+				len(catalog.Tools), len(catalog.Languages), len(catalog.Fonts), len(catalog.Shells), len(catalog.Dotfiles))
+			return nil
+		},
+	}
+}
+
+func newDumpCmd() *cobra.Command {
+	var configType, name string
+	cmd := &cobra.Command{
+		Use:   "dump",
+		Short: "Print the effective configuration after all merges",
+		Long: `Print, as YAML, the definitions bootstrap-cli installs from once the
+built-in defaults, the user config and the overlay are merged, to see which
+value of an override is used. Empty fields are left out and items are sorted
+by name.`,
+		Example: `  bootstrap-cli config dump --type tools --name ripgrep
+  bootstrap-cli config dump --overlay work --type shells`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if configType != "" && !slices.Contains(cfg.DumpTypes, configType) {
+				return fmt.Errorf("invalid --type %q: must be one of %s", configType, strings.Join(cfg.DumpTypes, ", "))
+			}
+			catalog, err := loadCatalog()
+			if err != nil {
+				return err
+			}
+			out, err := catalog.DumpEffective(configType, name)
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(out)
+			return err
+		},
+	}
+	cmd.Flags().StringVar(&configType, "type", "", "Only print this type: "+strings.Join(cfg.DumpTypes, ", "))
+	cmd.Flags().StringVar(&name, "name", "", "Only print the item with this name (or tool alias)")
+	return cmd
+}
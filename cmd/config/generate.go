@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/apply"
+	cfg "github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/ui/app"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// generateHeader starts every generated setup file
+const generateHeader = "# Generated by 'bootstrap-cli config generate'.\n# Provision a machine from it with: bootstrap-cli init --config <this file> --yes\n"
+
+func newGenerateCmd() *cobra.Command {
+	var output, promptStyle, pluginManager string
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Write a setup file from the selections of an interactive session",
+		Long: `Run the selection screens of 'bootstrap-cli up' without installing
+anything, and write what was selected as a setup file for
+'bootstrap-cli init --config FILE --yes'. The selection screens do not ask for
+a prompt or plugin manager, so --prompt-style and --plugin-manager add them.
+
+Without --output the file is printed; the plain prompts shown when the
+full-screen UI is unavailable go to stderr, so stdout can be redirected.`,
+		Example: `  bootstrap-cli config generate --prompt-style starship -o my-setup.yaml`,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			configPath := os.Getenv("BOOTSTRAP_CLI_CONFIG")
+			if configPath == "" {
+				var err error
+				if configPath, err = cfg.UserConfigDir(); err != nil {
+					return err
+				}
+			}
+			spec, err := selectSpec(cfg.NewLoader(configPath))
+			if err != nil {
+				return err
+			}
+			spec.Prompt, spec.PluginManager = promptStyle, pluginManager
+			if err := shell.ValidatePromptStyle(spec.Prompt, spec.Shell); err != nil {
+				return err
+			}
+			if spec.PluginManager != "" {
+				var shells []string
+				if spec.Shell != "" {
+					shells = []string{spec.Shell}
+				}
+				if err := shell.ValidateShells(shells, spec.PluginManager); err != nil {
+					return err
+				}
+			}
+
+			data, err := yaml.Marshal(spec)
+			if err != nil {
+				return fmt.Errorf("failed to encode the setup file: %w", err)
+			}
+			data = append([]byte(generateHeader), data...)
+			if output == "" {
+				_, err = cmd.OutOrStdout().Write(data)
+				return err
+			}
+			if err := os.WriteFile(output, data, 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", output, err)
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Wrote %s\n", output)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write the setup file here instead of printing it")
+	cmd.Flags().StringVar(&promptStyle, "prompt-style", "", "Prompt style to add to the setup file")
+	cmd.Flags().StringVar(&pluginManager, "plugin-manager", "", "Shell plugin manager to add to the setup file (e.g. oh-my-zsh)")
+	return cmd
+}
+
+// selectSpec runs the selection screens, installing nothing, and returns what
+// was selected as a spec
+func selectSpec(loader *cfg.Loader) (*apply.Spec, error) {
+	model := app.New(loader)
+	model.SetInstallOutsideUI(true)
+	if ok, _ := app.FullScreenSupported(os.Getenv, os.Stdout); ok {
+		if _, err := tea.NewProgram(model, tea.WithAltScreen()).Run(); err != nil {
+			return nil, fmt.Errorf("failed to run the selection screens: %w", err)
+		}
+	} else if err := model.RunPlain(os.Stdin, os.Stderr); err != nil {
+		return nil, err
+	}
+	model.StopInstall()
+
+	spec := &apply.Spec{}
+	for _, tool := range model.SelectedTools() {
+		spec.Tools = append(spec.Tools, tool.Name)
+	}
+	for _, lang := range model.SelectedLanguages() {
+		if lang.Version != "" {
+			spec.Languages = append(spec.Languages, lang.Name+"@"+lang.Version)
+			continue
+		}
+		spec.Languages = append(spec.Languages, lang.Name)
+	}
+	for _, font := range model.SelectedFonts() {
+		spec.Fonts = append(spec.Fonts, font.Name)
+	}
+	if sh := model.GetSelectedShell(); sh != nil {
+		spec.Shell = sh.Name
+	}
+	if model.GetManageDotfiles() {
+		spec.Dotfiles = model.GetDotfilesRepoURL()
+	}
+	return spec, nil
+}
@@ -0,0 +1,63 @@
+// Package doctor provides the doctor command for checking that what bootstrap-cli installed still works
+package doctor
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/audit"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// NewDoctorCmd creates the doctor command
+func NewDoctorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check that everything bootstrap-cli installed and configured still works",
+		Long: `Re-run the install checks for everything in ~/.bootstrap-cli/installed.json
+and check the shell setup, printing a pass/fail table with a hint for each failure:
+- Each tool, language and shell is on PATH, and its verify command (or
+  <name> --version) runs and reports the pinned version, if any
+- The bootstrap-cli blocks in shell rc files are closed and the files they
+  source still exist
+- ~/.local/bin, ~/.cargo/bin and ~/go/bin are on PATH when they exist
+
+The command exits non-zero when a check fails.`,
+		RunE: runDoctor,
+	}
+	return cmd
+}
+
+func runDoctor(cmd *cobra.Command, _ []string) error {
+	configPath := os.Getenv("BOOTSTRAP_CLI_CONFIG")
+	if configPath == "" {
+		var err error
+		if configPath, err = config.UserConfigDir(); err != nil {
+			return err
+		}
+	}
+	catalog, err := config.NewLoader(configPath).LoadAll()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	doctor, err := audit.NewDoctor(catalog.Tools, catalog.Languages)
+	if err != nil {
+		return err
+	}
+	checks, err := doctor.Run()
+	if err != nil {
+		return fmt.Errorf("doctor failed: %w", err)
+	}
+	if len(checks) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "Nothing to check yet; run `bootstrap-cli up` to install tools")
+		return nil
+	}
+	if failed := audit.PrintDoctorChecks(cmd.OutOrStdout(), checks); failed > 0 {
+		// The table explains the failures; Execute reports the error once
+		cmd.SilenceUsage, cmd.SilenceErrors = true, true
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	return nil
+}
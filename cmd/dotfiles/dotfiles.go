@@ -0,0 +1,270 @@
+// Package dotfiles provides the dotfiles command for inspecting the dotfiles bootstrap-cli links
+package dotfiles
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/dotfiles"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+	"github.com/spf13/cobra"
+)
+
+// NewDotfilesCmd creates the dotfiles command
+func NewDotfilesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dotfiles",
+		Short: "Inspect the dotfiles linked into your home directory",
+		Long: `Dotfiles are cloned by ` + "`bootstrap-cli up`" + ` (or ` + "`apply`" + `) from a git URL, a GitHub
+user/repo or a local path into ~/.dotfiles (dotfiles_dir in settings.yaml) and
+symlinked into your home directory: by default every file or directory at the
+top of the repository becomes ~/.<name>, or the symlink files of a dotfiles
+config whose source_repo is the repository say what goes where. Files in the
+way are moved to ~/.bootstrap-cli/backups/dotfiles first.
+
+A repository laid out as GNU stow packages (nvim/, zsh/, tmux/, each mirroring
+$HOME) can instead be linked one package at a time with ` + "`dotfiles apply`" + `
+and ` + "`unapply`" + `, which work the same whether stow is installed or not.`,
+	}
+	cmd.PersistentFlags().String("dir", "", "Dotfiles repository (default: the one last linked, or dotfiles_dir in settings.yaml)")
+	cmd.AddCommand(newStatusCmd())
+	cmd.AddCommand(newApplyCmd())
+	cmd.AddCommand(newUnapplyCmd())
+	return cmd
+}
+
+func newApplyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply <package>...",
+		Short: "Link the files of stow packages in the dotfiles repository into your home directory",
+		Long: `Walk each package directory of the dotfiles repository, create its
+directories under your home directory and symlink its files into them, like
+` + "`stow <package>`" + `: nvim/.config/nvim/init.lua becomes ~/.config/nvim/init.lua.
+
+Files already in the way are never overwritten: the package is not linked and
+they are listed, unless --adopt is passed, which moves each of them into the
+package (replacing the package's copy, so review the change with git) and links
+it. Links that already point at the package are left as they are.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runApply,
+	}
+	cmd.Flags().Bool("adopt", false, "Move files in the way into the package before linking them")
+	return cmd
+}
+
+func newUnapplyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unapply <package>...",
+		Short: "Remove the links to stow packages from your home directory",
+		Long: `Remove the symlinks into each package from your home directory, like
+` + "`stow -D <package>`" + `, along with the directories that are left empty. Files
+that are not links into the package are left alone.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runUnapply,
+	}
+}
+
+// newStow returns the stow for the dotfiles repository the command works on,
+// with the state recording the links of its packages
+func newStow(cmd *cobra.Command) (*dotfiles.Stow, *dotfiles.State, string, error) {
+	statePath, err := dotfiles.DefaultStatePath()
+	if err != nil {
+		return nil, nil, "", err
+	}
+	state, err := dotfiles.LoadState(statePath)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	home, err := system.UserHome()
+	if err != nil {
+		return nil, nil, "", err
+	}
+	dir, _ := cmd.Flags().GetString("dir")
+	switch {
+	case dir != "":
+		dir = dotfiles.ExpandHome(dir, home)
+	case state.Dir != "":
+		dir = state.Dir
+	default:
+		settingsDir, _ := cmd.Flags().GetString("config")
+		settingsPath, err := config.SettingsPath(settingsDir)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		settings, err := config.LoadSettings(settingsPath)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		dir = settings.DotfilesPath(home)
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		return nil, nil, "", fmt.Errorf("failed to resolve the dotfiles directory: %w", err)
+	}
+	if state.Dir == "" {
+		state.Dir = dir
+	}
+	stow := &dotfiles.Stow{Dir: dir, Home: home, DryRun: os.Getenv(shell.DryRunEnvVar) != ""}
+	return stow, state, statePath, nil
+}
+
+func runApply(cmd *cobra.Command, packages []string) error {
+	stow, state, statePath, err := newStow(cmd)
+	if err != nil {
+		return err
+	}
+	stow.Adopt, _ = cmd.Flags().GetBool("adopt")
+	// Usage is no help for a conflict or an unknown package
+	cmd.SilenceUsage = true
+	out := cmd.OutOrStdout()
+	var failed error
+	for _, pkg := range packages {
+		links, report, err := stow.Apply(pkg)
+		if report != nil {
+			printReport(out, pkg, report, stow.DryRun)
+		}
+		if len(links) > 0 {
+			if state.Packages == nil {
+				state.Packages = make(map[string][]dotfiles.Link)
+			}
+			state.Packages[pkg] = links
+		}
+		var conflict *dotfiles.ConflictError
+		if errors.As(err, &conflict) {
+			err = fmt.Errorf("%w; move them aside or pass --adopt to move them into the package", err)
+		}
+		if err != nil {
+			failed = err
+			break
+		}
+	}
+	return saveState(state, statePath, stow.DryRun, failed)
+}
+
+func runUnapply(cmd *cobra.Command, packages []string) error {
+	stow, state, statePath, err := newStow(cmd)
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+	out := cmd.OutOrStdout()
+	var failed error
+	for _, pkg := range packages {
+		report, err := stow.Unapply(pkg)
+		if report != nil {
+			printReport(out, pkg, report, stow.DryRun)
+		}
+		if err != nil {
+			failed = err
+			break
+		}
+		delete(state.Packages, pkg)
+	}
+	return saveState(state, statePath, stow.DryRun, failed)
+}
+
+// saveState records the packages linked so far, also when one of them failed,
+// and returns that failure
+func saveState(state *dotfiles.State, path string, dryRun bool, failed error) error {
+	if dryRun {
+		return failed
+	}
+	if err := state.Save(path); err != nil {
+		return err
+	}
+	return failed
+}
+
+// printReport lists what applying or unapplying pkg changed
+func printReport(out io.Writer, pkg string, report *dotfiles.LinkReport, dryRun bool) {
+	verb := func(done, would string) string {
+		if dryRun {
+			return would
+		}
+		return done
+	}
+	for _, target := range report.Adopted {
+		fmt.Fprintf(out, "%s %s into %s\n", verb("Adopted", "Would adopt"), target, pkg)
+	}
+	for _, target := range report.Linked {
+		fmt.Fprintf(out, "%s %s\n", verb("Linked", "Would link"), target)
+	}
+	for _, target := range report.Removed {
+		fmt.Fprintf(out, "%s %s\n", verb("Removed", "Would remove"), target)
+	}
+	if len(report.Linked) == 0 && len(report.Removed) == 0 {
+		fmt.Fprintf(out, "%s: nothing to change (%d links already in place)\n", pkg, len(report.Unchanged))
+	}
+}
+
+func newStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "List each managed dotfile link and whether it is intact, modified or missing",
+		Long: `Check every link recorded in ~/.bootstrap-cli/dotfiles.json by the last run:
+intact links still point at their file in the dotfiles repository, modified
+ones were replaced by another file or point somewhere else, and missing ones
+were removed or point at a file the repository no longer has. Run
+` + "`bootstrap-cli up`" + ` again to relink them.`,
+		RunE: runStatus,
+	}
+	cmd.Flags().Bool("json", false, "Print the links and their state as JSON")
+	return cmd
+}
+
+func runStatus(cmd *cobra.Command, _ []string) error {
+	path, err := dotfiles.DefaultStatePath()
+	if err != nil {
+		return err
+	}
+	state, err := dotfiles.LoadState(path)
+	if err != nil {
+		return err
+	}
+	links := dotfiles.CheckLinks(state)
+
+	out := cmd.OutOrStdout()
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(links)
+	}
+	if len(links) == 0 {
+		fmt.Fprintln(out, "No dotfiles are linked yet; pick a dotfiles repository in `bootstrap-cli up` or run `bootstrap-cli dotfiles apply <package>`")
+		return nil
+	}
+	printLinks(out, state, links)
+	return nil
+}
+
+// printLinks renders the links as a table under the repository they came from
+func printLinks(out io.Writer, state *dotfiles.State, links []dotfiles.LinkStatus) {
+	if state.Repo != "" {
+		fmt.Fprintf(out, "Dotfiles from %s in %s\n\n", state.Repo, state.Dir)
+	} else {
+		fmt.Fprintf(out, "Dotfiles in %s\n\n", state.Dir)
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LINK\tSTATE\tPACKAGE\tSOURCE")
+	broken := 0
+	for _, link := range links {
+		if link.State != dotfiles.LinkIntact {
+			broken++
+		}
+		pkg := link.Package
+		if pkg == "" {
+			pkg = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", link.Target, link.State, pkg, link.Source)
+	}
+	w.Flush()
+	if broken > 0 {
+		fmt.Fprintf(out, "\n%d of %d links are not intact; run `bootstrap-cli up` with the same repository, or `dotfiles apply` for a package, to relink them\n", broken, len(links))
+	}
+}
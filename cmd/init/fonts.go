@@ -0,0 +1,73 @@
+package init
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/ui/app"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/ui/screens"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fontSelection runs the font screen on its own, quitting once it is confirmed
+type fontSelection struct {
+	screen *screens.FontScreen
+}
+
+func (m *fontSelection) Init() tea.Cmd { return m.screen.Init() }
+
+func (m *fontSelection) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	_, cmd := m.screen.Update(msg)
+	if m.screen.Finished() {
+		return m, tea.Quit
+	}
+	return m, cmd
+}
+
+func (m *fontSelection) View() string { return m.screen.View() }
+
+// selectFonts offers the fonts in the catalog, such as the Nerd Fonts the
+// prompts need for their icons, and installs the ones selected showing the
+// progress of each
+func selectFonts(configLoader *config.Loader) error {
+	if ok, reason := app.FullScreenSupported(os.Getenv, os.Stdout); !ok {
+		logger.Info("Skipping font selection: full-screen UI unavailable (%s)", reason)
+		return nil
+	}
+	fonts, err := configLoader.LoadFonts()
+	if err != nil {
+		return fmt.Errorf("failed to load fonts: %w", err)
+	}
+	if len(fonts) == 0 {
+		return nil
+	}
+
+	selection := &fontSelection{screen: screens.NewFontScreen("Select fonts to install (space toggles, enter confirms, q skips)", fonts, nil)}
+	if _, err := tea.NewProgram(selection, tea.WithAltScreen()).Run(); err != nil {
+		return fmt.Errorf("failed to run font selection: %w", err)
+	}
+	selected := selection.screen.GetSelected()
+	if len(selected) == 0 {
+		logger.Info("No fonts selected for installation.")
+		return nil
+	}
+	return installFonts(selected)
+}
+
+// installFonts installs fonts through the installation pipeline
+func installFonts(fonts []*interfaces.Font) error {
+	installer, err := newPipelineInstaller()
+	if err != nil {
+		return err
+	}
+	err = runInstallScreen(installer, func() error {
+		return installer.InstallSelections(nil, false, "", fonts, nil, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to install fonts: %w", err)
+	}
+	logger.Success("Installed %d fonts; select one in your terminal's settings to see prompt icons", len(fonts))
+	return nil
+}
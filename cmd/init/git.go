@@ -0,0 +1,158 @@
+package init
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/gitconfig"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/ui"
+)
+
+// gitEditors are the editors offered for core.editor when they are installed
+var gitEditors = []string{"nvim", "vim", "hx", "micro", "nano", "emacs", "code --wait"}
+
+// setupGit asks for the git identity and defaults and writes them to the
+// global git config, keeping everything else in it, then offers an SSH key and
+// github.com's host key. Every question can be skipped; with dryRun only the
+// changes are printed.
+func setupGit(in io.Reader, out io.Writer, dryRun bool) error {
+	r := bufio.NewReader(in)
+	fmt.Fprintln(out)
+	if !ui.Confirm(r, out, "Set up git (name, email, editor, default branch)?", true) {
+		return nil
+	}
+	home, err := system.UserHome()
+	if err != nil {
+		return err
+	}
+	path := gitconfig.GlobalPath(home)
+	cfg, err := gitconfig.Load(path)
+	if err != nil {
+		return err
+	}
+	before := cfg.String()
+	current := func(key, fallback string) string {
+		if value, ok := cfg.Get(key); ok && value != "" {
+			return value
+		}
+		return fallback
+	}
+
+	// A declined option is only written when it was set before
+	option := func(key, question string) string {
+		_, set := cfg.Get(key)
+		if on := ui.Confirm(r, out, question, current(key, "false") == "true"); on || set {
+			return fmt.Sprint(on)
+		}
+		return ""
+	}
+
+	editorLabel, defaultEditor := "Editor", ""
+	if editors := installedEditors(); len(editors) > 0 {
+		editorLabel = fmt.Sprintf("Editor (installed: %s)", strings.Join(editors, ", "))
+		defaultEditor = editors[0]
+	}
+	values := map[string]string{
+		"user.name":          ask(r, out, "Name", current("user.name", "")),
+		"user.email":         ask(r, out, "Email", current("user.email", "")),
+		"core.editor":        ask(r, out, editorLabel, current("core.editor", defaultEditor)),
+		"init.defaultBranch": ask(r, out, "Default branch name", current("init.defaultBranch", "main")),
+	}
+	values["rerere.enabled"] = option("rerere.enabled", "Enable rerere (reuse recorded conflict resolutions)?")
+	values["pull.rebase"] = option("pull.rebase", "Rebase instead of merging on git pull?")
+	for _, key := range []string{"user.name", "user.email", "core.editor", "init.defaultBranch", "rerere.enabled", "pull.rebase"} {
+		if values[key] == "" {
+			continue
+		}
+		if err := cfg.Set(key, values[key]); err != nil {
+			return err
+		}
+	}
+
+	switch diff := shell.UnifiedDiff(path, before, cfg.String()); {
+	case diff == "":
+		fmt.Fprintf(out, "%s already has these settings\n", path)
+	case dryRun:
+		fmt.Fprintf(out, "Would change %s:\n%s", path, diff)
+	default:
+		if err := cfg.Save(path); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Updated %s\n", path)
+	}
+	return setupSSH(r, out, home, values["user.email"], dryRun)
+}
+
+// setupSSH offers an ed25519 key when ~/.ssh has none, and github.com's host
+// key when known_hosts does not have it
+func setupSSH(r *bufio.Reader, out io.Writer, home, email string, dryRun bool) error {
+	if len(gitconfig.ExistingSSHKeys(home)) == 0 && ui.Confirm(r, out, "Generate an ed25519 SSH key?", true) {
+		if dryRun {
+			fmt.Fprintf(out, "Would run ssh-keygen -t ed25519 -C %q -f %s\n", email, gitconfig.SSHKeyPath(home))
+		} else {
+			public, err := gitconfig.GenerateSSHKey(home, email)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Your public key (add it at https://github.com/settings/ssh/new):\n\n%s\n\n", public)
+		}
+	}
+	if !gitconfig.KnowsGitHub(home) && ui.Confirm(r, out, "Add github.com's host key to ~/.ssh/known_hosts?", true) {
+		if dryRun {
+			fmt.Fprintf(out, "Would add to ~/.ssh/known_hosts: %s\n", gitconfig.GitHubHostKey)
+			return nil
+		}
+		if err := gitconfig.AddGitHubHostKey(home); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Added github.com to ~/.ssh/known_hosts")
+	}
+	return nil
+}
+
+// installedEditors returns the gitEditors on PATH, e.g. ones just installed
+func installedEditors() []string {
+	var found []string
+	for _, editor := range gitEditors {
+		if _, err := exec.LookPath(strings.Fields(editor)[0]); err == nil {
+			found = append(found, editor)
+		}
+	}
+	return found
+}
+
+// ask reads an answer to label, returning def for an empty one
+func ask(r *bufio.Reader, out io.Writer, label, def string) string {
+	if def != "" {
+		fmt.Fprintf(out, "%s [%s]: ", label, def)
+	} else {
+		fmt.Fprintf(out, "%s (empty to skip): ", label)
+	}
+	answer, _ := r.ReadString('\n')
+	if answer = strings.TrimSpace(answer); answer != "" {
+		return answer
+	}
+	return def
+}
+
+// offerGitSetup runs the git setup unless --no-git was passed or stdin cannot
+// answer it
+func offerGitSetup(dryRun bool) error {
+	if noGit {
+		return nil
+	}
+	if !ui.StdinIsTerminal() {
+		logger.Info("Skipping git setup: stdin is not a terminal")
+		return nil
+	}
+	if err := setupGit(os.Stdin, os.Stdout, dryRun); err != nil {
+		return fmt.Errorf("git setup failed: %w", err)
+	}
+	return nil
+}
@@ -6,14 +6,35 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/install"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/ui/app"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/ui/screens"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
 )
 
 var (
-	logger *log.Logger
+	logger   *log.Logger
+	noSelect bool
+	noGit    bool
+	noFonts  bool
+
+	// Selections for the --dry-run plan
+	planTools         []string
+	planLanguages     []string
+	planShell         string
+	planPromptStyle   string
+	planPluginManager string
+	planStrategy      string
 )
 
 // NewInitCmd creates the init command
@@ -24,9 +45,61 @@ func NewInitCmd() *cobra.Command {
 		Long: `Initialize bootstrap-cli by:
 - Creating configuration directory
 - Extracting default configurations
-- Setting up environment variables`,
+- Setting up environment variables
+- Selecting tools from the catalog and installing them
+
+The tool selection lists the catalog by category (space toggles a tool, /
+filters, r selects the recommended ones, enter confirms). It is saved to
+settings.yaml and checked again the next time init runs; --no-select skips it.
+
+Next comes the font selection: the fonts in the catalog, like the Nerd Fonts
+powerlevel10k and starship need for their icons, are downloaded and installed
+into ~/.local/share/fonts (~/Library/Fonts on macOS, the user fonts folder on
+Windows), the font cache is refreshed with fc-cache and fc-list is checked for
+each one. --no-fonts skips it.
+
+Then init offers to set up git: your name and email, the editor (from the ones
+installed), the default branch and whether to enable rerere and pull.rebase are
+written to ~/.gitconfig, keeping the settings already in it. When ~/.ssh has no
+key it offers to generate an ed25519 one and prints the public key, and to add
+github.com's published host key to known_hosts. Every question can be skipped,
+and --no-git skips the step.
+
+With --dry-run nothing is created: init prints the execution plan for the
+selected tools, languages and shell instead, with the package each tool
+resolves to and every shell rc file that would be created or appended to. The
+git setup still asks its questions, then prints the changes to ~/.gitconfig and
+the SSH files instead of making them.
+
+With --config FILE, init provisions unattended from a setup file, for
+cloud-init or a fresh VM: no screen is shown and nothing is asked. The file
+lists what to install, every name is checked against the catalogs before
+anything starts, and what is already present is skipped. --yes installs
+without confirming, which is required when stdin is not a terminal. The git
+setup is skipped; 'bootstrap-cli config generate' writes a setup file from
+the selections of an interactive session.
+
+  shell: zsh
+  prompt: starship
+  plugin_manager: oh-my-zsh
+  tools: [git, ripgrep, fzf, neovim]
+  languages: [Go@1.22.3, Python]
+  fonts: [JetBrains Mono]
+  dotfiles: https://github.com/me/dotfiles.git`,
+		Example: `  bootstrap-cli init --dry-run --tools fd,ripgrep --languages Python --shell zsh --prompt-style starship
+  bootstrap-cli init --config my-setup.yaml --yes`,
 		RunE: runInit,
 	}
+	cmd.Flags().BoolVar(&noSelect, "no-select", false, "Skip the tool selection screen")
+	cmd.Flags().BoolVar(&noGit, "no-git", false, "Skip the git and SSH key setup")
+	cmd.Flags().BoolVar(&noFonts, "no-fonts", false, "Skip the font selection screen")
+	cmd.Flags().BoolP("yes", "y", false, "Install from the --config setup file without confirming")
+	cmd.Flags().StringSliceVar(&planTools, "tools", nil, "Tools to include in the --dry-run plan")
+	cmd.Flags().StringSliceVar(&planLanguages, "languages", nil, "Languages to include in the --dry-run plan")
+	cmd.Flags().StringVar(&planShell, "shell", "", "Shell to include in the --dry-run plan (default: $SHELL)")
+	cmd.Flags().StringVar(&planPromptStyle, "prompt-style", "", "Prompt style to include in the --dry-run plan")
+	cmd.Flags().StringVar(&planPluginManager, "plugin-manager", "", "Shell plugin manager to include in the --dry-run plan (e.g. oh-my-zsh)")
+	cmd.Flags().StringVar(&planStrategy, "language-strategy", "", "Default language install strategy for the --dry-run plan (version-manager or system)")
 	return cmd
 }
 
@@ -35,12 +108,21 @@ func runInit(cmd *cobra.Command, _ []string) error {
 	if debug, _ := cmd.Flags().GetBool("debug"); debug {
 		logger.SetLevel(log.DebugLevel)
 	}
+	if yes, _ := cmd.Flags().GetBool("yes"); yes && setupFile(cmd) == "" {
+		return fmt.Errorf("--yes needs a setup file passed with --config")
+	}
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		if err := printPlan(); err != nil {
+			return err
+		}
+		return offerGitSetup(true)
+	}
 	logger.Info("Initializing Bootstrap CLI...")
 
 	// Get home directory
-	home, err := os.UserHomeDir()
+	home, err := system.UserHome()
 	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
+		return err
 	}
 
 	// Create config directory
@@ -61,7 +143,243 @@ func runInit(cmd *cobra.Command, _ []string) error {
 	}
 
 	logger.Success("Bootstrap CLI initialized successfully!")
+
+	if path := setupFile(cmd); path != "" {
+		return runUnattended(cmd, configLoader, configDir, path)
+	}
+
+	if !noSelect {
+		if err := selectTools(configLoader, configDir); err != nil {
+			return err
+		}
+	}
+	if !noFonts {
+		if err := selectFonts(configLoader); err != nil {
+			return err
+		}
+	}
+	if err := offerGitSetup(false); err != nil {
+		return err
+	}
 	logger.Info("Run 'bootstrap-cli up' to start configuring your development environment")
 
 	return nil
-} 
\ No newline at end of file
+}
+
+// selectTools shows the tool selection screen with the saved selection
+// checked, saves what is confirmed and installs it
+func selectTools(configLoader *config.Loader, configDir string) error {
+	if ok, reason := app.FullScreenSupported(os.Getenv, os.Stdout); !ok {
+		logger.Info("Skipping tool selection: full-screen UI unavailable (%s)", reason)
+		return nil
+	}
+	settingsPath, err := config.SettingsPath(configDir)
+	if err != nil {
+		return err
+	}
+	settings, err := config.LoadSettings(settingsPath)
+	if err != nil {
+		return err
+	}
+	tools, err := configLoader.LoadToolDefinitions()
+	if err != nil {
+		return fmt.Errorf("failed to load tools: %w", err)
+	}
+	categories, err := configLoader.GetCategories("tools")
+	if err != nil {
+		return fmt.Errorf("failed to load tool categories: %w", err)
+	}
+	sort.Strings(categories)
+
+	screen := screens.NewToolSelectScreen(categories, tools, settings.Tools)
+	if _, err := tea.NewProgram(screen, tea.WithAltScreen()).Run(); err != nil {
+		return fmt.Errorf("failed to run tool selection: %w", err)
+	}
+	if !screen.Confirmed() {
+		logger.Info("Tool selection cancelled; nothing installed")
+		return nil
+	}
+	if err := config.SaveToolSelection(settingsPath, screen.Selected()); err != nil {
+		return err
+	}
+	selected := screen.SelectedTools()
+	install.SetSelectedTools(selected)
+	if len(selected) == 0 {
+		logger.Info("No tools selected for installation.")
+		return nil
+	}
+
+	builtin, packaged, err := splitBuiltinTools(configLoader, selected)
+	if err != nil {
+		return err
+	}
+	if len(packaged) > 0 {
+		pm, err := pipeline.DetectPackageManager()
+		if err != nil {
+			return err
+		}
+		statePath, err := manifest.DefaultInstalledPath()
+		if err != nil {
+			return err
+		}
+		if _, err := install.InstallToolsWithReport(&install.Options{
+			Logger:          logger,
+			PackageManager:  pm,
+			Tools:           packaged,
+			StatePath:       statePath,
+			AdditionalPaths: []string{"/usr/bin", "/usr/local/bin"},
+			Catalog:         withoutBuiltin(tools, builtin),
+			Reconcile:       true,
+		}); err != nil {
+			return fmt.Errorf("failed to install selected tools: %w", err)
+		}
+	}
+	if len(builtin) > 0 {
+		if err := installBuiltinTools(builtin, settings); err != nil {
+			return err
+		}
+	}
+	logger.Success("Installed %d selected tools", len(selected))
+	return nil
+}
+
+// withoutBuiltin drops the selected built-in tools from the catalog, so
+// reconciling the package installs leaves their integrations alone
+func withoutBuiltin(catalog []*interfaces.Tool, builtin []*pipeline.Tool) []*interfaces.Tool {
+	skip := make(map[string]bool, len(builtin))
+	for _, tool := range builtin {
+		skip[tool.Name] = true
+	}
+	var kept []*interfaces.Tool
+	for _, tool := range catalog {
+		if !skip[tool.Name] {
+			kept = append(kept, tool)
+		}
+	}
+	return kept
+}
+
+// splitBuiltinTools separates the selected tools that have one of the
+// pipeline's own installers (docker, neovim), which the package installer
+// cannot install, from the rest
+func splitBuiltinTools(configLoader *config.Loader, selected []*interfaces.Tool) ([]*pipeline.Tool, []*interfaces.Tool, error) {
+	catalog, err := configLoader.LoadTools()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load tools: %w", err)
+	}
+	var builtin []*pipeline.Tool
+	var packaged []*interfaces.Tool
+	for _, tool := range selected {
+		if found := pipeline.FindTool(catalog, tool.Name); found != nil && found.Builtin != "" {
+			builtin = append(builtin, found)
+			continue
+		}
+		packaged = append(packaged, tool)
+	}
+	return builtin, packaged, nil
+}
+
+// installBuiltinTools installs tools through the installation pipeline, whose
+// summary reports the version of each
+func installBuiltinTools(tools []*pipeline.Tool, settings *config.Settings) error {
+	installer, err := newPipelineInstaller()
+	if err != nil {
+		return err
+	}
+	installer.Context.ToolManagers = settings.ToolManagers
+	installer.Context.NeovimConfig = settings.NeovimConfig
+	err = runInstallScreen(installer, func() error {
+		return installer.InstallSelections(tools, false, "", nil, nil, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to install selected tools: %w", err)
+	}
+	return nil
+}
+
+// printPlan prints what init and an installation of the selections would do,
+// without creating, writing or running anything
+func printPlan() error {
+	configDir := os.Getenv("BOOTSTRAP_CLI_CONFIG")
+	if configDir == "" {
+		home, err := system.UserHome()
+		if err != nil {
+			return err
+		}
+		configDir = filepath.Join(home, ".config", "bootstrap-cli")
+	}
+	loader := config.NewLoader(configDir)
+
+	pm, err := pipeline.DetectPackageManager()
+	if err != nil {
+		return err
+	}
+
+	sel := install.Selections{
+		Shell:            filepath.Base(planShell),
+		PromptStyle:      planPromptStyle,
+		PluginManager:    planPluginManager,
+		LanguageStrategy: planStrategy,
+	}
+	if sel.Shell == "." {
+		sel.Shell = filepath.Base(os.Getenv("SHELL"))
+	}
+	if sel.Tools, err = findTools(loader, planTools); err != nil {
+		return err
+	}
+	if sel.Catalog, err = loader.LoadToolDefinitions(); err != nil {
+		return fmt.Errorf("failed to load tools: %w", err)
+	}
+	if sel.Languages, err = findLanguages(loader, planLanguages); err != nil {
+		return err
+	}
+
+	installer := install.NewInstaller(pm)
+	installer.Logger = logger
+	plan, err := installer.BuildPlan(sel)
+	if err != nil {
+		return fmt.Errorf("failed to build the execution plan: %w", err)
+	}
+
+	if _, err := os.Stat(configDir); os.IsNotExist(err) {
+		fmt.Printf("Would create %s and extract the default configurations\n", configDir)
+	} else {
+		fmt.Printf("Would extract the default configurations into %s\n", configDir)
+	}
+	plan.Print(os.Stdout)
+	return nil
+}
+
+// findTools looks up the named tools, falling back to the tools already selected
+func findTools(loader *config.Loader, names []string) ([]*interfaces.Tool, error) {
+	if len(names) == 0 {
+		return install.GetSelectedTools(), nil
+	}
+	return loader.FindToolDefinitions(names)
+}
+
+// findLanguages looks up the named languages
+func findLanguages(loader *config.Loader, names []string) ([]*interfaces.Language, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	all, err := loader.LoadLanguages()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load languages: %w", err)
+	}
+	var languages []*interfaces.Language
+	for _, name := range names {
+		var found *interfaces.Language
+		for _, lang := range all {
+			if strings.EqualFold(lang.Name, name) {
+				found = lang
+				break
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("unknown language: %s", name)
+		}
+		languages = append(languages, found)
+	}
+	return languages, nil
+}
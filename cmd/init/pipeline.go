@@ -0,0 +1,70 @@
+package init
+
+import (
+	"fmt"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/ui/screens"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// installedMsg is sent when the installation has returned
+type installedMsg struct{ err error }
+
+// installScreen shows the installation screen while install runs
+type installScreen struct {
+	screen  *screens.InstallationScreen
+	install func() error
+	err     error
+}
+
+func (m *installScreen) Init() tea.Cmd {
+	return tea.Batch(m.screen.Init(), func() tea.Msg {
+		return installedMsg{err: m.install()}
+	})
+}
+
+func (m *installScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if done, ok := msg.(installedMsg); ok {
+		m.err = done.err
+		// Without a pipeline there is no completion to show and wait on
+		if !m.screen.Finished() && m.err != nil {
+			return m, tea.Quit
+		}
+		return m, nil
+	}
+	_, cmd := m.screen.Update(msg)
+	return m, cmd
+}
+
+func (m *installScreen) View() string { return m.screen.View() }
+
+// newPipelineInstaller creates an installer for this machine, for what init
+// installs through the installation pipeline rather than package by package
+func newPipelineInstaller() (*pipeline.Installer, error) {
+	platform, pm, err := pipeline.DetectHost()
+	if err != nil {
+		return nil, err
+	}
+	installer, err := pipeline.NewInstaller(platform, pipeline.NewPackageManagerAdapter(pm))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create installer: %w", err)
+	}
+	return installer, nil
+}
+
+// runInstallScreen runs install, which runs installer's pipeline, showing the
+// progress of each step in the installation screen until it is dismissed
+func runInstallScreen(installer *pipeline.Installer, install func() error) error {
+	model := &installScreen{
+		screen:  screens.NewInstallationScreen(installer.ProgressChan),
+		install: install,
+	}
+	if _, err := tea.NewProgram(model, tea.WithAltScreen()).Run(); err != nil {
+		installer.Cancel()
+		installer.Wait()
+		return fmt.Errorf("failed to run the installation screen: %w", err)
+	}
+	installer.Wait()
+	return model.err
+}
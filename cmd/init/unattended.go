@@ -0,0 +1,118 @@
+package init
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/apply"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// setupFile returns the setup file passed with --config, or "" when --config
+// is a config directory or not given
+func setupFile(cmd *cobra.Command) string {
+	path, _ := cmd.Flags().GetString("config")
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		return ""
+	}
+	return path
+}
+
+// runUnattended installs what the setup file at path declares without
+// showing a screen or asking a question, for cloud-init and fresh VMs. Every
+// name in the file is checked against the catalogs, and the file rejected,
+// before anything is installed.
+func runUnattended(cmd *cobra.Command, configLoader *config.Loader, configDir, path string) error {
+	spec, err := apply.LoadSpec(path)
+	if err != nil {
+		return err
+	}
+	catalog, err := configLoader.LoadAll()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	home, err := system.UserHome()
+	if err != nil {
+		return err
+	}
+	settingsPath, err := config.SettingsPath(configDir)
+	if err != nil {
+		return err
+	}
+	settings, err := config.LoadSettings(settingsPath)
+	if err != nil {
+		return err
+	}
+
+	planner := &apply.Planner{DotfilesDir: settings.DotfilesPath(home)}
+	plan, err := planner.Plan(spec, catalog, nil)
+	if err != nil {
+		return fmt.Errorf("invalid setup file %s: %w", path, err)
+	}
+	plan.Print(os.Stdout)
+	if plan.Converged() {
+		return nil
+	}
+
+	if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+		if !ui.StdinIsTerminal() {
+			return fmt.Errorf("pass --yes to install from %s without a terminal to confirm on", path)
+		}
+		if !ui.Confirm(os.Stdin, os.Stdout, "Install the above?", false) {
+			logger.Info("Nothing installed")
+			return nil
+		}
+	}
+
+	passwordStdin, _ := cmd.Flags().GetBool("sudo-password-stdin")
+	sudo, err := system.PrimeSudo(passwordStdin, os.Stdin, os.Stderr)
+	if err != nil {
+		return err
+	}
+	defer sudo.Stop()
+
+	if len(plan.Install) > 0 || len(plan.Languages) > 0 || len(plan.Fonts) > 0 || plan.Shell != nil || plan.Dotfiles != "" {
+		installer, err := newPipelineInstaller()
+		if err != nil {
+			return err
+		}
+		// There is no installation screen to show the progress on
+		go func() {
+			for range installer.ProgressChan {
+			}
+		}()
+		installer.Context.ToolManagers = settings.ToolManagers
+		installer.Context.NeovimConfig = settings.NeovimConfig
+		installer.Context.PromptStyle = plan.PromptStyle
+		installer.Catalog = catalog.Tools
+		installer.DotfilesDir, installer.Dotfiles = settings.DotfilesPath(home), catalog.Dotfiles
+		if plan.Shell != nil {
+			installer.Context.LoginShellChange = shell.RequestLoginShellChange(plan.Shell, true, os.Stdin, os.Stdout, logger)
+		}
+		err = installer.InstallSelections(plan.Install, plan.Dotfiles != "", plan.Dotfiles, plan.Fonts, plan.Languages, plan.Shell)
+		if installer.Pipeline != nil {
+			for _, group := range installer.Pipeline.Summary().Groups() {
+				logger.Info("%s", group.String())
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to install from %s: %w", path, err)
+		}
+	}
+	if err := plan.InstallPluginManager(); err != nil {
+		return err
+	}
+
+	if len(spec.Tools) > 0 {
+		if err := config.SaveToolSelection(settingsPath, spec.Tools); err != nil {
+			return err
+		}
+	}
+	logger.Info("Skipping git setup: run 'bootstrap-cli init' interactively to set it up")
+	logger.Success("Installed everything %s declares", path)
+	return nil
+}
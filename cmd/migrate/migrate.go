@@ -0,0 +1,109 @@
+// Package migrate provides the migrate command for upgrading stored configs to the current schema
+package migrate
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// NewMigrateCmd creates the migrate command
+func NewMigrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade your config files to the current bootstrap-cli schema",
+		Long: `Upgrade the config files in ~/.config/bootstrap-cli (or --config) that were
+written by an older bootstrap-cli. Each file records its schema_version; files
+without one are treated as version 1. Migrations are applied in order and the
+originals are copied to backups/migrate-<timestamp> before anything is
+rewritten. Set DRY_RUN=1 to list the files that would change.
+
+Shell rc blocks left behind by tools that are no longer in the catalog are
+listed too, with an offer to remove them (--yes removes them without asking).`,
+		RunE: runMigrate,
+	}
+	cmd.Flags().BoolP("yes", "y", false, "Remove rc blocks of tools no longer in the catalog without asking")
+	return cmd
+}
+
+func runMigrate(cmd *cobra.Command, _ []string) error {
+	logger := log.New(log.InfoLevel)
+
+	dir := os.Getenv("BOOTSTRAP_CLI_CONFIG")
+	if dir == "" {
+		var err error
+		if dir, err = config.UserConfigDir(); err != nil {
+			return err
+		}
+	}
+
+	migrator := &config.Migrator{Dir: dir, DryRun: os.Getenv(shell.DryRunEnvVar) != ""}
+	report, err := migrator.Run()
+	if err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+	out := cmd.OutOrStdout()
+	switch {
+	case len(report.Migrated) == 0:
+		logger.Info("Configs in %s are already at schema version %d", dir, config.SchemaVersion)
+	default:
+		for _, f := range report.Migrated {
+			fmt.Fprintf(out, "  %s: v%d -> v%d\n", f.Path, f.From, f.To)
+		}
+		if migrator.DryRun {
+			fmt.Fprintln(out, "Dry run: no changes made")
+		} else {
+			logger.Success("Migrated %d config file(s); originals saved to %s", len(report.Migrated), report.BackupDir)
+		}
+	}
+
+	yes, _ := cmd.Flags().GetBool("yes")
+	return removeOrphanedBlocks(cmd, dir, yes, logger)
+}
+
+// removeOrphanedBlocks removes the rc blocks of tools the catalog no longer
+// has, after asking unless yes is set
+func removeOrphanedBlocks(cmd *cobra.Command, dir string, yes bool, logger *log.Logger) error {
+	tools, err := config.NewLoader(dir).LoadTools()
+	if err != nil {
+		return fmt.Errorf("failed to load tools: %w", err)
+	}
+	home, err := system.UserHome()
+	if err != nil {
+		return err
+	}
+	orphans, err := shell.FindOrphanedBlocks(home, pipeline.ToolNames(tools))
+	if err != nil || len(orphans) == 0 {
+		return err
+	}
+
+	if !yes {
+		if !ui.StdinIsTerminal() {
+			for _, o := range orphans {
+				fmt.Fprintf(cmd.OutOrStdout(), "  orphaned rc block %s\n", o)
+			}
+			logger.Warn("Re-run with --yes to remove %d orphaned rc block(s)", len(orphans))
+			return nil
+		}
+		if !shell.PromptRemoveOrphanedBlocks(orphans, os.Stdin, cmd.OutOrStdout()) {
+			return nil
+		}
+	}
+	w := shell.NewRCWriter()
+	w.Out = cmd.OutOrStdout()
+	if err := shell.RemoveOrphanedBlocks(w, orphans); err != nil {
+		return err
+	}
+	if w.DryRun {
+		return nil
+	}
+	logger.Success("Removed %d orphaned rc block(s)", len(orphans))
+	return nil
+}
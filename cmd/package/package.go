@@ -7,7 +7,7 @@ import (
 	"fmt"
 
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
-	"github.com/YitzhakMizrahi/bootstrap-cli/internal/packages/factory"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
 	"github.com/spf13/cobra"
 )
 
@@ -53,10 +53,9 @@ func newInstallCmd() *cobra.Command {
 		Long:  `Install packages using the system's package manager.`,
 		Args:  cobra.MinimumNArgs(1),
 		RunE: func(_ *cobra.Command, args []string) error {
-			f := factory.NewPackageManagerFactory()
-			pm, err := f.GetPackageManager()
+			pm, err := pipeline.DetectPackageManager()
 			if err != nil {
-				return fmt.Errorf("failed to create package manager: %w", err)
+				return err
 			}
 
 			for _, pkg := range args {
@@ -78,10 +77,9 @@ func newRemoveCmd() *cobra.Command {
 		Long:  `Remove packages using the system's package manager.`,
 		Args:  cobra.MinimumNArgs(1),
 		RunE: func(_ *cobra.Command, args []string) error {
-			f := factory.NewPackageManagerFactory()
-			pm, err := f.GetPackageManager()
+			pm, err := pipeline.DetectPackageManager()
 			if err != nil {
-				return fmt.Errorf("failed to create package manager: %w", err)
+				return err
 			}
 
 			for _, pkg := range args {
@@ -108,11 +106,9 @@ func newListCmd() *cobra.Command {
 func runList(cmd *cobra.Command, args []string) error {
 	logger.Info("Listing installed packages...")
 
-	// Get package manager
-	f := factory.NewPackageManagerFactory()
-	pm, err := f.GetPackageManager()
+	pm, err := pipeline.DetectPackageManager()
 	if err != nil {
-		return fmt.Errorf("failed to get package manager: %w", err)
+		return err
 	}
 
 	// List installed packages
@@ -140,10 +136,9 @@ func newUpdateCmd() *cobra.Command {
 		Short: "Update package list",
 		Long:  `Update the package list using the system's package manager.`,
 		RunE: func(_ *cobra.Command, _ []string) error {
-			f := factory.NewPackageManagerFactory()
-			pm, err := f.GetPackageManager()
+			pm, err := pipeline.DetectPackageManager()
 			if err != nil {
-				return fmt.Errorf("failed to create package manager: %w", err)
+				return err
 			}
 
 			if err := pm.Update(); err != nil {
@@ -162,10 +157,9 @@ func newUpgradeCmd() *cobra.Command {
 		Short: "Upgrade all packages",
 		Long:  `Upgrade all installed packages using the system's package manager.`,
 		RunE: func(_ *cobra.Command, _ []string) error {
-			f := factory.NewPackageManagerFactory()
-			pm, err := f.GetPackageManager()
+			pm, err := pipeline.DetectPackageManager()
 			if err != nil {
-				return fmt.Errorf("failed to create package manager: %w", err)
+				return err
 			}
 
 			if err := pm.Upgrade(); err != nil {
@@ -0,0 +1,232 @@
+// Package profile provides the profile command for sharing a machine's setup
+package profile
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/apply"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/profile"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var logger *log.Logger
+
+// NewProfileCmd creates the profile command
+func NewProfileCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Export this machine's setup as a profile, or apply one",
+		Long: `A profile lists the tools and languages bootstrap-cli manages on a
+machine with their versions, the shell, prompt and plugin manager, and the
+dotfiles repository. Export one after a successful run and apply it on another
+machine to set it up the same way.
+
+Profiles record a schema_version; profiles from older releases are upgraded
+when they are read, and ones from newer releases are refused.`,
+	}
+	cmd.AddCommand(newExportCmd())
+	cmd.AddCommand(newApplyCmd())
+	return cmd
+}
+
+func newExportCmd() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Print a profile of what bootstrap-cli manages on this machine",
+		Long: `Print a profile built from the installed state (~/.bootstrap-cli/installed.json),
+the run history and the shell frameworks bootstrap-cli installed.`,
+		Example: `  bootstrap-cli profile export > team-backend.yaml`,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			installedPath, err := manifest.DefaultInstalledPath()
+			if err != nil {
+				return err
+			}
+			installed, err := manifest.LoadInstalled(installedPath)
+			if err != nil {
+				return err
+			}
+			manifestPath, err := manifest.DefaultPath()
+			if err != nil {
+				return err
+			}
+			m, err := manifest.Load(manifestPath)
+			if err != nil {
+				return err
+			}
+			var frameworks []string
+			if installer, err := shell.NewFrameworkInstaller(); err == nil {
+				list, err := installer.List()
+				if err != nil {
+					return err
+				}
+				for _, fw := range list {
+					frameworks = append(frameworks, fw.Name)
+				}
+			}
+
+			p := profile.Export(installed, m, frameworks)
+			if output == "" {
+				return p.Write(cmd.OutOrStdout())
+			}
+			f, err := os.Create(output)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %w", output, err)
+			}
+			defer f.Close()
+			return p.Write(f)
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write the profile here instead of printing it")
+	return cmd
+}
+
+func newApplyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply FILE",
+		Short: "Install what a profile lists and this machine is missing",
+		Long: `Compare the profile with this machine and print each item as already
+installed, will install, or version mismatch, then install the missing ones
+once confirmed. Languages are installed at the profile's version; a tool at a
+different version is reported and left alone. Set DRY_RUN=1 (or pass
+--dry-run) to stop after the comparison.`,
+		Example: `  bootstrap-cli profile apply team-backend.yaml`,
+		Args:    cobra.ExactArgs(1),
+		RunE:    runApply,
+	}
+	cmd.Flags().BoolP("yes", "y", false, "Install without confirming, and change the login shell without asking")
+	return cmd
+}
+
+func runApply(cmd *cobra.Command, args []string) error {
+	logger = log.New(log.InfoLevel)
+	if debug, _ := cmd.Flags().GetBool("debug"); debug {
+		logger.SetLevel(log.DebugLevel)
+	}
+	path := args[0]
+	p, err := profile.Load(path)
+	if err != nil {
+		return err
+	}
+
+	configPath := os.Getenv("BOOTSTRAP_CLI_CONFIG")
+	if configPath == "" {
+		if configPath, err = config.UserConfigDir(); err != nil {
+			return err
+		}
+	}
+	catalog, err := config.NewLoader(configPath).LoadAll()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	installedPath, err := manifest.DefaultInstalledPath()
+	if err != nil {
+		return err
+	}
+	installed, err := manifest.LoadInstalled(installedPath)
+	if err != nil {
+		return err
+	}
+	home, err := system.UserHome()
+	if err != nil {
+		return err
+	}
+	settingsDir, _ := cmd.Flags().GetString("config")
+	settingsPath, err := config.SettingsPath(settingsDir)
+	if err != nil {
+		return err
+	}
+	settings, err := config.LoadSettings(settingsPath)
+	if err != nil {
+		return err
+	}
+
+	planner := &apply.Planner{DotfilesDir: settings.DotfilesPath(home)}
+	plan, err := planner.Plan(p.Spec(), catalog, nil)
+	if err != nil {
+		return fmt.Errorf("cannot apply %s: %w", path, err)
+	}
+	out := cmd.OutOrStdout()
+	profile.PrintDiff(out, p.Diff(plan, installed))
+	if plan.Converged() {
+		logger.Success("Nothing to install: this machine already matches %s", path)
+		return nil
+	}
+	if os.Getenv(shell.DryRunEnvVar) != "" {
+		fmt.Fprintln(out, "Dry run: no changes made")
+		return nil
+	}
+
+	yes, _ := cmd.Flags().GetBool("yes")
+	if !yes {
+		if !ui.StdinIsTerminal() {
+			return fmt.Errorf("pass --yes to apply %s without a terminal to confirm on", path)
+		}
+		if !ui.Confirm(os.Stdin, out, "Install the missing items?", false) {
+			logger.Info("Nothing installed")
+			return nil
+		}
+	}
+
+	passwordStdin, _ := cmd.Flags().GetBool("sudo-password-stdin")
+	sudo, err := system.PrimeSudo(passwordStdin, os.Stdin, os.Stderr)
+	if err != nil {
+		return err
+	}
+	defer sudo.Stop()
+
+	if len(plan.Install) > 0 || len(plan.Languages) > 0 || plan.Shell != nil || plan.Dotfiles != "" {
+		installer, err := newInstaller()
+		if err != nil {
+			return err
+		}
+		installer.Context.ToolManagers = settings.ToolManagers
+		installer.Context.NeovimConfig = settings.NeovimConfig
+		installer.Context.PromptStyle = plan.PromptStyle
+		installer.Catalog = catalog.Tools
+		installer.DotfilesDir, installer.Dotfiles = settings.DotfilesPath(home), catalog.Dotfiles
+		if plan.Shell != nil {
+			installer.Context.LoginShellChange = shell.RequestLoginShellChange(plan.Shell, yes, os.Stdin, os.Stdout, logger)
+		}
+		err = installer.InstallSelections(plan.Install, plan.Dotfiles != "", plan.Dotfiles, nil, plan.Languages, plan.Shell)
+		if installer.Pipeline != nil {
+			for _, group := range installer.Pipeline.Summary().Groups() {
+				logger.Info("%s", group.String())
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to apply %s: %w", path, err)
+		}
+	}
+	if err := plan.InstallPluginManager(); err != nil {
+		return err
+	}
+	logger.Success("Applied %s", path)
+	return nil
+}
+
+// newInstaller creates an installer for this machine whose progress events are discarded
+func newInstaller() (*pipeline.Installer, error) {
+	platform, pm, err := pipeline.DetectHost()
+	if err != nil {
+		return nil, err
+	}
+	installer, err := pipeline.NewInstaller(platform, pipeline.NewPackageManagerAdapter(pm))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create installer: %w", err)
+	}
+	go func() {
+		for range installer.ProgressChan {
+		}
+	}()
+	return installer, nil
+}
@@ -0,0 +1,171 @@
+// Package restore provides the restore-config command for putting back the shell
+// rc files bootstrap-cli backed up before changing them
+package restore
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// NewRestoreConfigCmd creates the restore-config command
+func NewRestoreConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore-config",
+		Short: "Restore a shell rc file from a backup taken before bootstrap-cli changed it",
+		Long: `Before the first change to a shell rc file (.bashrc, .zshrc, config.fish and
+the other shell startup files) in a run, bootstrap-cli copies it to
+~/.bootstrap-cli/backups/<filename>.<timestamp>, keeping the last 10 copies of
+each file. Dry runs take no backups.
+
+restore-config lists the backups, newest first, with a diff of what restoring
+each one would change in the current file, and restores the one you pick. The
+current file is backed up before it is replaced, so a restore can be undone the
+same way. --file limits the backups to one rc file, --list only lists them, and
+--yes restores the newest backup of --file without asking.`,
+		RunE: runRestoreConfig,
+	}
+	cmd.Flags().String("file", "", "Only the backups of this rc file, e.g. ~/.zshrc")
+	cmd.Flags().Bool("list", false, "List the backups and their diffs without restoring")
+	cmd.Flags().BoolP("yes", "y", false, "Restore the newest backup of --file without asking")
+	return cmd
+}
+
+// candidate is a backup and the rc file it restores
+type candidate struct {
+	backup shell.Backup
+	target string
+	diff   string
+}
+
+func runRestoreConfig(cmd *cobra.Command, _ []string) error {
+	home, err := system.UserHome()
+	if err != nil {
+		return err
+	}
+	targets := shell.RCFiles(home)
+	if file, _ := cmd.Flags().GetString("file"); file != "" {
+		if strings.HasPrefix(file, "~/") {
+			file = filepath.Join(home, file[2:])
+		}
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", file, err)
+		}
+		targets = []string{abs}
+	}
+
+	backups := shell.DefaultBackups()
+	var candidates []candidate
+	for _, target := range targets {
+		list, err := backups.List(filepath.Base(target))
+		if err != nil {
+			return err
+		}
+		current, err := os.ReadFile(target)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read %s: %w", target, err)
+		}
+		for _, backup := range list {
+			data, err := os.ReadFile(backup.Path)
+			if err != nil {
+				return fmt.Errorf("failed to read backup %s: %w", backup.Path, err)
+			}
+			candidates = append(candidates, candidate{backup: backup, target: target, diff: shell.UnifiedDiff(target, string(current), string(data))})
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	if len(candidates) == 0 {
+		fmt.Fprintln(out, "No rc file backups found")
+		return nil
+	}
+	list, _ := cmd.Flags().GetBool("list")
+	printCandidates(out, candidates, list)
+	if list {
+		return nil
+	}
+
+	var chosen candidate
+	if yes, _ := cmd.Flags().GetBool("yes"); yes {
+		if len(targets) != 1 {
+			return fmt.Errorf("--yes restores the newest backup of one file; pass --file too")
+		}
+		chosen = candidates[0]
+	} else {
+		if !ui.StdinIsTerminal() {
+			return fmt.Errorf("refusing to restore without confirmation; pass --file and --yes to restore the newest backup")
+		}
+		n, ok := choose(os.Stdin, out, len(candidates))
+		if !ok {
+			fmt.Fprintln(out, "Nothing restored")
+			return nil
+		}
+		chosen = candidates[n]
+		if chosen.diff != "" {
+			fmt.Fprint(out, chosen.diff)
+		}
+	}
+
+	if chosen.diff == "" {
+		fmt.Fprintf(out, "%s already matches the backup from %s\n", chosen.target, chosen.backup.Time.Format("2006-01-02 15:04:05"))
+		return nil
+	}
+	if err := backups.Restore(chosen.backup, chosen.target); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", chosen.target, err)
+	}
+	fmt.Fprintf(out, "Restored %s from the backup taken %s\n", chosen.target, chosen.backup.Time.Format("2006-01-02 15:04:05"))
+	return nil
+}
+
+// printCandidates numbers the backups with their rc file and timestamp, and the
+// diff of restoring each when full is set or a summary of it otherwise
+func printCandidates(out io.Writer, candidates []candidate, full bool) {
+	for i, c := range candidates {
+		fmt.Fprintf(out, "%2d. %s  %s\n", i+1, c.target, c.backup.Time.Format("2006-01-02 15:04:05"))
+		switch {
+		case c.diff == "":
+			fmt.Fprintln(out, "    same as the current file")
+		case full:
+			fmt.Fprint(out, c.diff)
+		default:
+			added, removed := diffStat(c.diff)
+			fmt.Fprintf(out, "    restoring adds %d line(s) and removes %d\n", added, removed)
+		}
+	}
+}
+
+// diffStat counts the lines a unified diff adds and removes
+func diffStat(diff string) (added, removed int) {
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// choose asks which of n backups to restore, reading the answer from in; an
+// empty or invalid answer restores nothing
+func choose(in io.Reader, out io.Writer, n int) (int, bool) {
+	fmt.Fprintf(out, "Restore which backup? [1-%d, empty to cancel] ", n)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	i, err := strconv.Atoi(strings.TrimSpace(answer))
+	if err != nil || i < 1 || i > n {
+		return 0, false
+	}
+	return i - 1, true
+}
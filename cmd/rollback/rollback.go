@@ -0,0 +1,106 @@
+// Package rollback provides the rollback command for undoing what a failed run changed
+package rollback
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/ui"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/uninstall"
+	"github.com/spf13/cobra"
+)
+
+// NewRollbackCmd creates the rollback command
+func NewRollbackCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "Undo the changes of an install run that failed",
+		Long: `Read the journal of the last install run that did not finish
+(~/.bootstrap-cli/journal.json) and undo what it changed, most recent first:
+the managed blocks it added to shell rc files are removed, the files it created
+are deleted and the packages it installed are uninstalled.
+
+The journal is written as each action completes, so a run killed by a crash or
+reboot can be rolled back too. It only records things that did not exist before
+the run: packages, files and rc blocks that were already there are never
+touched. A successful run removes the journal, leaving nothing to roll back.
+Everything to be undone is listed and confirmed first; --dry-run only lists it.`,
+		RunE: runRollback,
+	}
+	cmd.Flags().BoolP("yes", "y", false, "Roll back without asking for confirmation")
+	return cmd
+}
+
+func runRollback(cmd *cobra.Command, _ []string) error {
+	logger := log.New(log.InfoLevel)
+	if debug, _ := cmd.Flags().GetBool("debug"); debug {
+		logger.SetLevel(log.DebugLevel)
+	}
+
+	journalPath, err := manifest.DefaultJournalPath()
+	if err != nil {
+		return err
+	}
+	journal, err := manifest.LoadJournal(journalPath)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	var entries []manifest.JournalEntry
+	if journal != nil {
+		entries = journal.Entries
+	}
+	uninstall.PrintRollback(out, entries)
+	if len(entries) == 0 {
+		if journal != nil {
+			return manifest.RemoveJournal(journalPath)
+		}
+		return nil
+	}
+	if os.Getenv(shell.DryRunEnvVar) != "" {
+		fmt.Fprintln(out, "Dry run: nothing rolled back")
+		return nil
+	}
+	if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+		if !ui.StdinIsTerminal() {
+			return fmt.Errorf("refusing to roll back without confirmation; pass --yes to undo the changes above")
+		}
+		if !ui.Confirm(os.Stdin, out, "Roll back all of the above?", false) {
+			fmt.Fprintln(out, "Nothing rolled back")
+			return nil
+		}
+	}
+
+	pm, err := pipeline.DetectPackageManager()
+	if err != nil {
+		return err
+	}
+	passwordStdin, _ := cmd.Flags().GetBool("sudo-password-stdin")
+	sudo, err := system.PrimeSudo(passwordStdin, os.Stdin, os.Stderr)
+	if err != nil {
+		return err
+	}
+	defer sudo.Stop()
+
+	statePath, err := manifest.DefaultInstalledPath()
+	if err != nil {
+		return err
+	}
+	u := &uninstall.Uninstaller{
+		PackageManager: pm,
+		RCWriter:       shell.NewRCWriter(),
+		StatePath:      statePath,
+		Logger:         logger,
+	}
+	if err := u.Rollback(journalPath); err != nil {
+		return fmt.Errorf("rollback incomplete: %w", err)
+	}
+	logger.Success("Rolled back the changes of the failed run")
+	return nil
+}
@@ -3,21 +3,53 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
+	applycmd "github.com/YitzhakMizrahi/bootstrap-cli/cmd/apply"
+	auditcmd "github.com/YitzhakMizrahi/bootstrap-cli/cmd/audit"
+	cachecmd "github.com/YitzhakMizrahi/bootstrap-cli/cmd/cache"
+	configcmd "github.com/YitzhakMizrahi/bootstrap-cli/cmd/config"
+	doctorcmd "github.com/YitzhakMizrahi/bootstrap-cli/cmd/doctor"
+	dotfilescmd "github.com/YitzhakMizrahi/bootstrap-cli/cmd/dotfiles"
 	initcmd "github.com/YitzhakMizrahi/bootstrap-cli/cmd/init"
+	migratecmd "github.com/YitzhakMizrahi/bootstrap-cli/cmd/migrate"
 	packagecmd "github.com/YitzhakMizrahi/bootstrap-cli/cmd/package"
+	profilecmd "github.com/YitzhakMizrahi/bootstrap-cli/cmd/profile"
+	restorecmd "github.com/YitzhakMizrahi/bootstrap-cli/cmd/restore"
+	rollbackcmd "github.com/YitzhakMizrahi/bootstrap-cli/cmd/rollback"
+	shellcmd "github.com/YitzhakMizrahi/bootstrap-cli/cmd/shell"
+	statuscmd "github.com/YitzhakMizrahi/bootstrap-cli/cmd/status"
 	"github.com/YitzhakMizrahi/bootstrap-cli/cmd/tools"
+	uninstallcmd "github.com/YitzhakMizrahi/bootstrap-cli/cmd/uninstall"
 	upcmd "github.com/YitzhakMizrahi/bootstrap-cli/cmd/up"
+	versioncmd "github.com/YitzhakMizrahi/bootstrap-cli/cmd/version"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cache"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/packages/detector"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/packages/factory"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
 	"github.com/spf13/cobra"
 )
 
 var (
-	debug      bool
-	logger     *log.Logger
-	configPath string
+	debug           bool
+	logger          *log.Logger
+	configPath      string
+	overlay         string
+	noCache         bool
+	skipChecksums   bool
+	offline         bool
+	proxy           string
+	githubMirror    string
+	goMirror        string
+	allowHosts      []string
+	dryRun          bool
+	managerPriority string
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -31,38 +63,198 @@ It helps you install and configure:
 - Shell configurations and plugins
 - Programming language environments
 - Dotfiles management`,
-	PersistentPreRun: func(_ *cobra.Command, _ []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
 		// Set up logging based on debug flag
 		if debug {
 			logger = log.New(log.DebugLevel)
 		} else {
 			logger = log.New(log.InfoLevel)
 		}
+		logger.Debug("%s", versioncmd.Current())
 		
+		// init --config also takes a setup file to provision from; it is not a config directory
+		configDir := configPath
+		if info, err := os.Stat(configPath); err == nil && !info.IsDir() {
+			if cmd.Name() != "init" {
+				return fmt.Errorf("--config %s is a file: only init provisions from a setup file, other commands take a config directory", configPath)
+			}
+			configDir = ""
+		}
+
 		// Set config path in environment for child processes
-		if configPath != "" {
-			os.Setenv("BOOTSTRAP_CLI_CONFIG", configPath)
+		if configDir != "" {
+			os.Setenv("BOOTSTRAP_CLI_CONFIG", configDir)
+		}
+
+		// Merge an overlay (e.g. work or personal) over the user config
+		if overlay != "" {
+			dir, err := config.ResolveOverlay(overlay)
+			if err != nil {
+				return err
+			}
+			os.Setenv(config.OverlayEnvVar, dir)
+		}
+
+		// Disable the download cache for this run and its child processes
+		if noCache {
+			os.Setenv(cache.DisableEnvVar, "1")
+		}
+
+		// Serve downloads from the pre-seeded cache only
+		if offline {
+			os.Setenv(cache.OfflineEnvVar, "1")
+		}
+
+		// Accept downloads whose SHA-256 does not match the pinned checksum
+		if skipChecksums {
+			os.Setenv(cache.SkipChecksumsEnvVar, "1")
+		}
+
+		// Preview shell rc edits as diffs instead of writing them
+		if dryRun {
+			os.Setenv(shell.DryRunEnvVar, "1")
+		}
+
+		// Re-extract default configs left damaged by an interrupted extraction
+		repairDefaults(configDir)
+
+		// Choose which package manager wins when several are installed
+		if err := applyManagerPriority(configDir); err != nil {
+			return err
+		}
+
+		// Route downloads through a proxy and/or mirrors for restricted networks
+		if proxy != "" {
+			if err := cache.SetProxy(proxy); err != nil {
+				return err
+			}
+		}
+		if len(allowHosts) > 0 {
+			if err := cache.AllowHosts(allowHosts); err != nil {
+				return err
+			}
+		}
+		if githubMirror != "" {
+			os.Setenv(cache.GitHubMirrorEnvVar, githubMirror)
+		}
+		if goMirror != "" {
+			os.Setenv(cache.GoMirrorEnvVar, goMirror)
+		}
+		for _, err := range cache.ApplyMirrors() {
+			logger.Warn("Ignoring mirror: %v", err)
 		}
+		return nil
 	},
 }
 
+// repairDefaults verifies the defaults extracted to the user config directory
+// against the embedded ones, replacing truncated or unparseable copies
+func repairDefaults(dir string) {
+	if dir == "" {
+		var err error
+		if dir, err = config.UserConfigDir(); err != nil {
+			return
+		}
+	}
+	repaired, err := config.NewLoader(dir).RepairDefaults()
+	if err != nil {
+		logger.Warn("Failed to verify default configs in %s: %v", dir, err)
+	}
+	for _, file := range repaired {
+		if file.Backup != "" {
+			logger.Warn("Restored damaged config %s from the defaults (previous copy saved to %s)", file.Path, file.Backup)
+		} else {
+			logger.Warn("Restored truncated config %s from the defaults", file.Path)
+		}
+	}
+}
+
+// applyManagerPriority exports the package manager preference for this run and
+// its child processes: --manager-priority first, then manager_priority in settings.
+// Unknown managers are skipped with a warning.
+func applyManagerPriority(configDir string) error {
+	if managerPriority != "" {
+		return setManagerPriority(managerPriority, "--manager-priority")
+	}
+	if os.Getenv(factory.ManagerPriorityEnvVar) != "" {
+		return nil
+	}
+
+	path, err := config.SettingsPath(configDir)
+	if err != nil {
+		return nil
+	}
+	settings, err := config.LoadSettings(path)
+	if err != nil {
+		return err
+	}
+	if len(settings.ManagerPriority) == 0 {
+		return nil
+	}
+	return setManagerPriority(strings.Join(settings.ManagerPriority, ","), "manager_priority in "+path)
+}
+
+// setManagerPriority exports the package managers in list that bootstrap-cli
+// supports, warning about the others; source names where list came from
+func setManagerPriority(list, source string) error {
+	priority, unknown := detector.SplitPriority(list)
+	for _, name := range unknown {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring unknown package manager %q in %s\n", name, source)
+	}
+	if len(priority) == 0 {
+		return nil
+	}
+	return os.Setenv(factory.ManagerPriorityEnvVar, strings.Join(priority, ","))
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
-func Execute() {
+// It returns the process exit code so the caller can clean up before exiting.
+func Execute() int {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		var unsupported *system.UnsupportedPlatformError
+		if errors.As(err, &unsupported) {
+			return unsupported.ExitCode()
+		}
+		return 1
 	}
+	return 0
 }
 
 func init() {
 	// Add flags
 	rootCmd.PersistentFlags().BoolVarP(&debug, "debug", "d", false, "Enable debug logging")
-	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", "Path to config directory")
+	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", "Path to config directory (init also takes a setup file to provision from)")
+	rootCmd.PersistentFlags().StringVar(&overlay, "overlay", "", "Config overlay merged over the user config: a name in ~/.bootstrap-cli/overlays or a directory (env: "+config.OverlayEnvVar+")")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Do not reuse or store cached downloads")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "Take downloads only from the cache filled by cache warm, never the network (env: "+cache.OfflineEnvVar+")")
+	rootCmd.PersistentFlags().BoolVar(&skipChecksums, "skip-checksums", false, "Run install scripts and archives even when their SHA-256 does not match (env: "+cache.SkipChecksumsEnvVar+")")
+	rootCmd.PersistentFlags().Bool("sudo-password-stdin", false, "Read the sudo password from the first line of stdin instead of prompting (a "+system.SudoAskpassEnvVar+" helper is used when set)")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Print a diff of shell rc file changes instead of writing them (env: "+shell.DryRunEnvVar+")")
+	rootCmd.PersistentFlags().StringVar(&managerPriority, "manager-priority", "", "Comma-separated package manager preference, e.g. brew,apt (default: manager_priority in "+config.SettingsFileName+")")
+	rootCmd.PersistentFlags().StringVar(&proxy, "proxy", "", "HTTP(S) proxy for downloads and install commands (default: $HTTPS_PROXY)")
+	rootCmd.PersistentFlags().StringVar(&githubMirror, "github-mirror", "", "Base URL replacing https://github.com for release downloads (env: "+cache.GitHubMirrorEnvVar+")")
+	rootCmd.PersistentFlags().StringVar(&goMirror, "go-mirror", "", "Base URL replacing https://go.dev/dl for Go downloads (env: "+cache.GoMirrorEnvVar+")")
+	rootCmd.PersistentFlags().StringSliceVar(&allowHosts, "allow-host", nil, "Extra host install scripts, archives and mirrors may be downloaded from; *.example.com allows subdomains (env: "+cache.AllowHostsEnvVar+")")
 
 	// Add commands
+	rootCmd.AddCommand(applycmd.NewApplyCmd())
+	rootCmd.AddCommand(auditcmd.NewAuditCmd())
+	rootCmd.AddCommand(cachecmd.NewCacheCmd())
+	rootCmd.AddCommand(doctorcmd.NewDoctorCmd())
+	rootCmd.AddCommand(dotfilescmd.NewDotfilesCmd())
 	rootCmd.AddCommand(initcmd.NewInitCmd())
+	rootCmd.AddCommand(migratecmd.NewMigrateCmd())
 	rootCmd.AddCommand(packagecmd.NewPackageCmd())
+	rootCmd.AddCommand(profilecmd.NewProfileCmd())
+	rootCmd.AddCommand(shellcmd.NewShellCmd())
+	rootCmd.AddCommand(statuscmd.NewStatusCmd())
+	rootCmd.AddCommand(configcmd.NewConfigCmd())
+	rootCmd.AddCommand(versioncmd.NewVersionCmd())
 	rootCmd.AddCommand(tools.NewToolsCmd())
+	rootCmd.AddCommand(uninstallcmd.NewUninstallCmd())
+	rootCmd.AddCommand(rollbackcmd.NewRollbackCmd())
+	rootCmd.AddCommand(restorecmd.NewRestoreConfigCmd())
 	rootCmd.AddCommand(upcmd.NewUpCmd())
 } 
\ No newline at end of file
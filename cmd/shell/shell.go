@@ -0,0 +1,240 @@
+// Package shell provides the shell command for listing, switching and reverting
+// the shells bootstrap-cli manages
+package shell
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/ui/components"
+	"github.com/spf13/cobra"
+)
+
+// NewShellCmd creates the shell command
+func NewShellCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "shell",
+		Short: "List, switch and revert the shells bootstrap-cli manages",
+	}
+	cmd.AddCommand(newListCmd(), newUseCmd(), newRevertCmd())
+	return cmd
+}
+
+func newListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "Show installed shells, the login shell and which can be installed",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			catalog, err := loadShells()
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "SHELL\tSTATUS\tPATH")
+			for _, sh := range shell.ListShells(catalog, os.Getenv("SHELL"), exec.LookPath) {
+				status := "not installed"
+				switch {
+				case sh.Default:
+					status = "login shell"
+				case sh.Path != "":
+					status = "installed"
+				case sh.Installable:
+					status = "installable"
+				}
+				path := sh.Path
+				if path == "" {
+					path = "-"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\n", sh.Name, status, path)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newUseCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "use <shell>",
+		Short: "Install a shell if needed, configure it and make it the login shell",
+		Long: `Install the shell when it is missing, write its prompt config with
+--prompt-style, and switch the login shell after showing what will change and
+asking for confirmation. The previous login shell is recorded so
+'bootstrap-cli shell revert' can restore it.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runUse,
+	}
+	cmd.Flags().BoolP("yes", "y", false, "Change the login shell without asking")
+	cmd.Flags().String("prompt-style", "", "Write a default prompt config and load it from the shell's rc file: "+strings.Join(shell.PromptStyles(), ", "))
+	cmd.Flags().Bool("force", false, "Replace an existing prompt config with the --prompt-style default")
+	cmd.Flags().Bool("launch-shell", false, "Start the shell when done instead of asking you to open a new terminal")
+	return cmd
+}
+
+func runUse(cmd *cobra.Command, args []string) error {
+	logger := log.New(log.InfoLevel)
+	name := strings.ToLower(args[0])
+	if err := shell.ValidateShells([]string{name}); err != nil {
+		return err
+	}
+	promptStyle, _ := cmd.Flags().GetString("prompt-style")
+	if err := shell.ValidatePromptStyle(promptStyle, name); err != nil {
+		return fmt.Errorf("invalid --prompt-style: %w", err)
+	}
+
+	catalog, err := loadShells()
+	if err != nil {
+		return err
+	}
+	sh := findShell(catalog, name)
+	if sh == nil {
+		return fmt.Errorf("shell %s is not in the catalog", name)
+	}
+
+	if os.Getenv(shell.DryRunEnvVar) != "" {
+		logger.Info("Dry run: would install %s if missing and make it the login shell", sh.Name)
+		return nil
+	}
+
+	platform, hostPM, err := pipeline.DetectHost()
+	if err != nil {
+		return err
+	}
+	pm := pipeline.NewPackageManagerAdapter(hostPM)
+	passwordStdin, _ := cmd.Flags().GetBool("sudo-password-stdin")
+	sudo, err := system.PrimeSudo(passwordStdin, os.Stdin, os.Stderr)
+	if err != nil {
+		return err
+	}
+	defer sudo.Stop()
+
+	// Install and configure the shell first: the login shell can only change to
+	// an installed one
+	installer, err := pipeline.NewInstaller(platform, pm)
+	if err != nil {
+		return fmt.Errorf("failed to create installer: %w", err)
+	}
+	go func() {
+		for range installer.ProgressChan {
+		}
+	}()
+	installer.Context.PromptStyle = promptStyle
+	installer.Context.ForcePromptConfig, _ = cmd.Flags().GetBool("force")
+	if err := installer.InstallSelections(nil, false, "", nil, nil, sh); err != nil {
+		return fmt.Errorf("failed to set up %s: %w", sh.Name, err)
+	}
+
+	// Switching the login shell is the one risky step, so it needs explicit consent
+	change := shell.PlanLoginShellChange(sh)
+	if change != nil {
+		if err := change.Resolve(); err != nil {
+			return err
+		}
+	}
+	switch {
+	case change == nil:
+		logger.Info("%s is already the login shell", sh.Name)
+	default:
+		yes, _ := cmd.Flags().GetBool("yes")
+		approved, err := shell.ApproveLoginShellChange(change, yes, os.Stdin, os.Stdout)
+		if err != nil {
+			return err
+		}
+		if !approved {
+			logger.Info("Keeping %s as the login shell", change.Current)
+			return nil
+		}
+		logger.Info("Changing login shell: %s", change.Command)
+		if err := shell.ApplyLoginShellChange(change); err != nil {
+			return err
+		}
+		logger.Success("Login shell changed to %s; log in again for it to take effect", change.Target)
+	}
+
+	if launch, _ := cmd.Flags().GetBool("launch-shell"); launch {
+		shellPath, err := shell.ResolveShellPath(sh.Name)
+		if err != nil {
+			return err
+		}
+		return shell.LaunchInteractive(shellPath)
+	}
+	components.NewNotificationManager(os.Stdout).Show(components.NotifyInfo, "Open a new shell",
+		fmt.Sprintf("Run `exec %s` or open a new terminal to start using it.", sh.Name))
+	return nil
+}
+
+// loadShells returns the shells in the merged configuration
+func loadShells() ([]*interfaces.Shell, error) {
+	configPath := os.Getenv("BOOTSTRAP_CLI_CONFIG")
+	if configPath == "" {
+		var err error
+		if configPath, err = config.UserConfigDir(); err != nil {
+			return nil, err
+		}
+	}
+	shells, err := config.NewLoader(configPath).LoadShells()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load shells: %w", err)
+	}
+	return shells, nil
+}
+
+// findShell returns the catalog entry named name, ignoring case
+func findShell(shells []*interfaces.Shell, name string) *interfaces.Shell {
+	for _, sh := range shells {
+		if strings.EqualFold(sh.Name, name) {
+			return sh
+		}
+	}
+	return nil
+}
+
+func newRevertCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "revert",
+		Short: "Restore the login shell bootstrap-cli last replaced",
+		Long: `Restore the login shell recorded when bootstrap-cli last changed it.
+The previous shell is kept in ~/.bootstrap-cli/previous-shell.json.`,
+		RunE: runRevert,
+	}
+}
+
+func runRevert(_ *cobra.Command, _ []string) error {
+	logger := log.New(log.InfoLevel)
+
+	path, err := shell.LoginShellStatePath()
+	if err != nil {
+		return err
+	}
+	state, err := shell.LoadLoginShellState(path)
+	if err != nil {
+		return err
+	}
+	if state.Previous == "" {
+		return fmt.Errorf("the previous login shell was not known when it was changed")
+	}
+	if os.Getenv(shell.DryRunEnvVar) != "" {
+		logger.Info("Dry run: would run chsh -s %s", state.Previous)
+		return nil
+	}
+
+	chsh := exec.Command("chsh", "-s", state.Previous)
+	chsh.Stdin, chsh.Stdout, chsh.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := chsh.Run(); err != nil {
+		return fmt.Errorf("failed to restore login shell: %w", err)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		logger.Warn("Failed to remove %s: %v", path, err)
+	}
+	logger.Success("Login shell restored to %s; log in again for it to take effect", state.Previous)
+	return nil
+}
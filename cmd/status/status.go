@@ -0,0 +1,92 @@
+// Package status provides the status command for reporting what bootstrap-cli manages
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/spf13/cobra"
+)
+
+// NewStatusCmd creates the status command
+func NewStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the tools, languages and shells bootstrap-cli manages and whether they are on PATH",
+		Long: `Read the snapshot of bootstrap-managed tools, languages and shells
+(~/.bootstrap-cli/installed.json, updated after every install, upgrade and
+removal) and check each one is still on PATH. Items recorded as installed but
+no longer found are listed as drift; ` + "`bootstrap-cli up`" + ` reinstalls them.
+
+With --json the reconciled snapshot is printed for other programs, such as
+status bars or dotfiles scripts, to consume.`,
+		RunE: runStatus,
+	}
+	cmd.Flags().Bool("json", false, "Print the reconciled snapshot as JSON")
+	return cmd
+}
+
+func runStatus(cmd *cobra.Command, _ []string) error {
+	path, err := manifest.DefaultInstalledPath()
+	if err != nil {
+		return err
+	}
+	installed, err := manifest.LoadInstalled(path)
+	if err != nil {
+		return err
+	}
+	status := installed.Reconcile(exec.LookPath)
+
+	out := cmd.OutOrStdout()
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(status)
+	}
+	if len(status.Tools) == 0 && len(status.Languages) == 0 && len(status.Shells) == 0 {
+		fmt.Fprintln(out, "Nothing is managed by bootstrap-cli yet; run `bootstrap-cli up` to install tools")
+		return nil
+	}
+	printStatus(out, status)
+	return nil
+}
+
+// printStatus renders the reconciled snapshot as a table per section
+func printStatus(out io.Writer, status *manifest.Status) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, section := range []struct {
+		title string
+		items []manifest.ItemStatus
+	}{{"Tools", status.Tools}, {"Languages", status.Languages}, {"Shells", status.Shells}} {
+		if len(section.items) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s\tVERSION\tMANAGER\tINSTALLED\tON PATH\n", section.title)
+		for _, item := range section.items {
+			onPath := "missing"
+			if item.OnPath {
+				onPath = item.Path
+			}
+			version := item.Version
+			if version == "" {
+				version = "-"
+			}
+			installedAt := "-"
+			if !item.InstalledAt.IsZero() {
+				installedAt = item.InstalledAt.Local().Format("2006-01-02")
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", item.Name, version, item.Manager, installedAt, onPath)
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+
+	if drifted := status.Drifted(); len(drifted) > 0 {
+		fmt.Fprintf(out, "Drift: %s recorded as installed but no longer on PATH; run `bootstrap-cli up` to reinstall\n", strings.Join(drifted, ", "))
+	}
+}
@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/audit"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+	"github.com/spf13/cobra"
+)
+
+func newRepairCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "repair",
+		Short: "Re-check installed tools and reinstall broken ones",
+		Long: `Re-check every catalog tool recorded as installed in the run manifest.
+Tools whose binary is missing from PATH or fails to run with --version are
+reinstalled, and each tool is reported as healthy, repaired or unrepairable.`,
+		RunE: runRepair,
+	}
+
+	return cmd
+}
+
+func runRepair(cmd *cobra.Command, _ []string) error {
+	logger = log.New(log.InfoLevel)
+	if debug, _ := cmd.Flags().GetBool("debug"); debug {
+		logger.SetLevel(log.DebugLevel)
+	}
+
+	configPath := os.Getenv("BOOTSTRAP_CLI_CONFIG")
+	if configPath == "" {
+		home, err := system.UserHome()
+		if err != nil {
+			return err
+		}
+		configPath = filepath.Join(home, ".config", "bootstrap-cli")
+	}
+	catalog, err := config.NewLoader(configPath).LoadTools()
+	if err != nil {
+		return fmt.Errorf("failed to load tools: %w", err)
+	}
+
+	manifestPath, err := manifest.DefaultPath()
+	if err != nil {
+		return err
+	}
+	m, err := manifest.Load(manifestPath)
+	if err != nil {
+		return err
+	}
+	tools, unknown := audit.ExpectedTools(m, catalog)
+	for _, name := range unknown {
+		logger.Warn("%s is recorded as installed but is no longer in the catalog", name)
+	}
+	if len(tools) == 0 {
+		logger.Info("No installed tools recorded in %s.", manifestPath)
+		return nil
+	}
+
+	platform, hostPM, err := pipeline.DetectHost()
+	if err != nil {
+		return err
+	}
+	pm := pipeline.NewPackageManagerAdapter(hostPM)
+
+	repairer := &audit.Repairer{
+		Install: func(tool *pipeline.Tool) error {
+			logger.Info("Reinstalling %s...", tool.Name)
+			// Each pipeline run closes its progress channel, so use a fresh installer per tool
+			installer, err := pipeline.NewInstaller(platform, pm)
+			if err != nil {
+				return fmt.Errorf("failed to create installer: %w", err)
+			}
+			go func() {
+				for range installer.ProgressChan {
+				}
+			}()
+			return installer.Install(tool)
+		},
+	}
+
+	results := repairer.Repair(tools)
+	audit.PrintRepairResults(cmd.OutOrStdout(), results)
+	for _, res := range results {
+		if res.Status == audit.Unrepairable {
+			return fmt.Errorf("some tools could not be repaired")
+		}
+	}
+	return nil
+}
@@ -4,17 +4,26 @@ package tools
 
 import (
 	"fmt"
+	"os"
 	"os/user"
+	"path/filepath"
 
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/install"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
-	"github.com/YitzhakMizrahi/bootstrap-cli/internal/packages/factory"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
 	"github.com/spf13/cobra"
 )
 
 var (
 	skipVerification bool
+	shells           string
+	output           string
+	reinstall        bool
 	logger          *log.Logger
 )
 
@@ -34,35 +43,55 @@ func NewToolsCmd() *cobra.Command {
 		Short: "Manage development tools",
 		Long: `Manage development tools.
 This command is used internally by the init command to install selected tools.
-It provides functionality for installing and verifying development tools.`,
+It provides functionality for installing, verifying and repairing development tools.`,
 	}
 
 	cmd.AddCommand(newInstallCmd())
 	cmd.AddCommand(newVerifyCmd())
+	cmd.AddCommand(newRepairCmd())
 
 	return cmd
 }
 
 func newInstallCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "install",
+		Use:   "install [TOOL...]",
 		Short: "Install core development tools",
 		Long: `Install core development tools.
-This command is used internally by the init command to install selected tools.`,
-		RunE: runInstall,
+This command is used internally by the init command to install selected tools.
+Tools named on the command line, by name or alias (e.g. rg for ripgrep), are
+installed instead of the saved selection.`,
+		Example: `  bootstrap-cli tools install rg fd`,
+		RunE:    runInstall,
 	}
 
 	// Add flags
 	cmd.Flags().BoolVar(&skipVerification, "skip-verify", false, "Skip verification after installation")
+	cmd.Flags().StringVar(&shells, "shells", "", "Comma-separated shells to configure tools for, primary first (default: $SHELL)")
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "Output format: text, or json for a per-tool report on stdout (progress goes to stderr)")
+	cmd.Flags().BoolVar(&reinstall, "reinstall", false, "Install every selected tool again, even the ones already recorded in ~/.bootstrap-cli/"+manifest.InstalledFileName)
 
 	return cmd
 }
 
-func runInstall(cmd *cobra.Command, _ []string) error {
+func runInstall(cmd *cobra.Command, args []string) error {
 	logger = log.New(log.InfoLevel)
 	if debug, _ := cmd.Flags().GetBool("debug"); debug {
 		logger.SetLevel(log.DebugLevel)
 	}
+	if output != "text" && output != "json" {
+		return fmt.Errorf("invalid --output %q: must be text or json", output)
+	}
+	if output == "json" {
+		// Keep stdout for the report
+		logger.SetOutput(os.Stderr)
+	}
+
+	// Validate the shells to configure before installing anything
+	targetShells := shell.ParseShells(shells)
+	if err := shell.ValidateShells(targetShells); err != nil {
+		return fmt.Errorf("invalid --shells: %w", err)
+	}
 
 	// Configure needrestart to run in automatic mode
 	if err := configureNeedrestart(); err != nil {
@@ -76,35 +105,53 @@ func runInstall(cmd *cobra.Command, _ []string) error {
 		return fmt.Errorf("failed to detect system info: %w", err)
 	}
 
-	// Use the factory to get the package manager
-	f := factory.NewPackageManagerFactory()
-	pm, err := f.GetPackageManager()
+	pm, err := pipeline.DetectPackageManager()
 	if err != nil {
-		return fmt.Errorf("failed to detect package manager: %w", err)
+		return err
 	}
 
 	logger.Info("System: %s %s (%s)", sysInfo.Distro, sysInfo.Version, sysInfo.OS)
 	logger.Info("Package Manager: %s", pm.GetName())
 
 	// Get selected tools
-	selectedTools := install.GetSelectedTools()
+	selectedTools, err := loadSelectedTools(args)
+	if err != nil {
+		return err
+	}
 	if len(selectedTools) == 0 {
 		logger.Info("No tools selected for installation.")
+		if output == "json" {
+			return (&install.Report{Tools: []*install.ToolReport{}}).WriteJSON(cmd.OutOrStdout())
+		}
 		return nil
 	}
 
+	statePath, err := manifest.DefaultInstalledPath()
+	if err != nil {
+		return err
+	}
+
 	// Initialize installation options
 	opts := &install.Options{
 		Logger:           logger,
 		PackageManager:   pm,
 		Tools:            selectedTools,
 		SkipVerification: skipVerification,
+		Shells:           targetShells,
+		StatePath:        statePath,
+		Reinstall:        reinstall,
 		// Add PATH to binary locations for verification
 		AdditionalPaths: []string{"/usr/bin", "/usr/local/bin"},
 	}
 
 	// Install core tools
-	if err := install.CoreTools(opts); err != nil {
+	report, err := install.InstallToolsWithReport(opts)
+	if output == "json" {
+		if writeErr := report.WriteJSON(cmd.OutOrStdout()); writeErr != nil {
+			return writeErr
+		}
+	}
+	if err != nil {
 		return fmt.Errorf("failed to install core tools: %w", err)
 	}
 
@@ -143,18 +190,19 @@ func runVerify(cmd *cobra.Command, _ []string) error {
 		return fmt.Errorf("failed to detect system info: %w", err)
 	}
 
-	// Use the factory to get the package manager
-	f := factory.NewPackageManagerFactory()
-	pm, err := f.GetPackageManager()
+	pm, err := pipeline.DetectPackageManager()
 	if err != nil {
-		return fmt.Errorf("failed to detect package manager: %w", err)
+		return err
 	}
 
 	logger.Info("System: %s %s (%s)", sysInfo.Distro, sysInfo.Version, sysInfo.OS)
 	logger.Info("Package Manager: %s", pm.GetName())
 
 	// Get selected tools
-	selectedTools := install.GetSelectedTools()
+	selectedTools, err := loadSelectedTools(nil)
+	if err != nil {
+		return err
+	}
 	if len(selectedTools) == 0 {
 		logger.Info("No tools selected for verification.")
 		return nil
@@ -173,4 +221,34 @@ func runVerify(cmd *cobra.Command, _ []string) error {
 	}
 
 	return nil
-} 
\ No newline at end of file
+} 
+// loadSelectedTools returns the named tools, or else the tools selected in this
+// run, or else the selection last saved by `init`
+func loadSelectedTools(names []string) ([]*interfaces.Tool, error) {
+	if tools := install.GetSelectedTools(); len(tools) > 0 && len(names) == 0 {
+		return tools, nil
+	}
+	configDir := os.Getenv("BOOTSTRAP_CLI_CONFIG")
+	if configDir == "" {
+		home, err := system.UserHome()
+		if err != nil {
+			return nil, err
+		}
+		configDir = filepath.Join(home, ".config", "bootstrap-cli")
+	}
+	path, err := config.SettingsPath(configDir)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := config.LoadSettings(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		names = settings.Tools
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	return config.NewLoader(configDir).FindToolDefinitions(names)
+}
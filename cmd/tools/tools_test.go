@@ -17,18 +17,20 @@ func TestNewToolsCmd(t *testing.T) {
 
 	// Test subcommands
 	subCmds := cmd.Commands()
-	if len(subCmds) != 2 {
-		t.Errorf("Expected 2 subcommands, got %d", len(subCmds))
+	if len(subCmds) != 3 {
+		t.Errorf("Expected 3 subcommands, got %d", len(subCmds))
 	}
 
-	// Find install and verify commands
-	var installCmd, verifyCmd *cobra.Command
+	// Find install, verify and repair commands
+	var installCmd, verifyCmd, repairCmd *cobra.Command
 	for _, sub := range subCmds {
-		switch sub.Use {
+		switch sub.Name() {
 		case "install":
 			installCmd = sub
 		case "verify":
 			verifyCmd = sub
+		case "repair":
+			repairCmd = sub
 		}
 	}
 
@@ -51,6 +53,11 @@ func TestNewToolsCmd(t *testing.T) {
 	if verifyCmd == nil {
 		t.Error("Verify command not found")
 	}
+
+	// Test repair command
+	if repairCmd == nil {
+		t.Error("Repair command not found")
+	}
 }
 
 func TestCommandHelp(t *testing.T) {
@@ -0,0 +1,112 @@
+// Package uninstall provides the uninstall command for undoing what bootstrap-cli set up
+package uninstall
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/ui"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/uninstall"
+	"github.com/spf13/cobra"
+)
+
+// NewUninstallCmd creates the uninstall command
+func NewUninstallCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Remove the tools, languages and shell configuration bootstrap-cli installed",
+		Long: `Read the snapshot of what bootstrap-cli installed
+(~/.bootstrap-cli/installed.json) and undo it: tools and languages are removed
+with the package manager, shell frameworks and prompt configs bootstrap-cli
+wrote are deleted, and its managed blocks (including legacy "# Added by
+bootstrap-cli" ones) are stripped from .bashrc, .zshrc, config.fish and the
+other shell startup files, and from ~/.tmux.conf.
+
+Anything that was already installed before bootstrap-cli first ran is left in
+place. Everything to be removed is listed and confirmed first; --dry-run only
+lists it.`,
+		RunE: runUninstall,
+	}
+	cmd.Flags().BoolP("yes", "y", false, "Remove without asking for confirmation")
+	return cmd
+}
+
+func runUninstall(cmd *cobra.Command, _ []string) error {
+	logger := log.New(log.InfoLevel)
+	if debug, _ := cmd.Flags().GetBool("debug"); debug {
+		logger.SetLevel(log.DebugLevel)
+	}
+
+	statePath, err := manifest.DefaultInstalledPath()
+	if err != nil {
+		return err
+	}
+	installed, err := manifest.LoadInstalled(statePath)
+	if err != nil {
+		return err
+	}
+	frameworks, err := shell.NewFrameworkInstaller()
+	if err != nil {
+		return err
+	}
+	recorded, err := frameworks.List()
+	if err != nil {
+		return err
+	}
+	home, err := system.UserHome()
+	if err != nil {
+		return err
+	}
+	plan, err := uninstall.NewPlan(home, installed, recorded)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	plan.Print(out)
+	if plan.Empty() {
+		return nil
+	}
+	if os.Getenv(shell.DryRunEnvVar) != "" {
+		fmt.Fprintln(out, "Dry run: nothing removed")
+		return nil
+	}
+	if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+		if !ui.StdinIsTerminal() {
+			return fmt.Errorf("refusing to uninstall without confirmation; pass --yes to remove the items above")
+		}
+		if !ui.Confirm(os.Stdin, out, "Remove all of the above?", false) {
+			fmt.Fprintln(out, "Nothing removed")
+			return nil
+		}
+	}
+
+	pm, err := pipeline.DetectPackageManager()
+	if err != nil {
+		return err
+	}
+	passwordStdin, _ := cmd.Flags().GetBool("sudo-password-stdin")
+	sudo, err := system.PrimeSudo(passwordStdin, os.Stdin, os.Stderr)
+	if err != nil {
+		return err
+	}
+	defer sudo.Stop()
+
+	u := &uninstall.Uninstaller{
+		PackageManager: pm,
+		Frameworks:     frameworks,
+		RCWriter:       shell.NewRCWriter(),
+		StatePath:      statePath,
+		Logger:         logger,
+	}
+	if err := u.Run(plan); err != nil {
+		return fmt.Errorf("uninstall incomplete: %w", err)
+	}
+	logger.Success("Removed everything bootstrap-cli installed")
+	return nil
+}
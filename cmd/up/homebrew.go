@@ -0,0 +1,78 @@
+package up
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/packages/detector"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/packages/homebrew"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+)
+
+// ensureHomebrew installs Homebrew on a Mac without it, and on Linux without a
+// supported package manager when linuxbrew is set, so package manager
+// detection finds it afterwards. A brew that is installed but not on PATH is
+// only set up. Without a terminal or with --yes the install script runs
+// unattended; a Mac without a terminal needs --yes to install it.
+func ensureHomebrew(sysInfo *system.Info, yes, linuxbrew bool) error {
+	switch sysInfo.OS {
+	case "darwin":
+		if homebrew.OnPath() {
+			return nil
+		}
+	case "linux":
+		if !linuxbrew || homebrew.OnPath() {
+			return nil
+		}
+		if manager, _ := detector.DetectPackageManager(); manager != "" {
+			logger.Info("Using %s, not installing Homebrew", manager)
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	home, err := system.UserHome()
+	if err != nil {
+		return err
+	}
+	rc := shell.NewRCWriter()
+	rc.Warn = logger.Warn
+	bootstrap := &homebrew.Bootstrap{
+		GOOS:           sysInfo.OS,
+		GOARCH:         sysInfo.Arch,
+		Home:           home,
+		Shell:          filepath.Base(sysInfo.Shell),
+		NonInteractive: !canPrompt(yes),
+		RCWriter:       rc,
+		Stdin:          os.Stdin,
+		Stdout:         os.Stdout,
+		Stderr:         os.Stderr,
+	}
+	if brew, ok := homebrew.Find(sysInfo.OS, sysInfo.Arch, home); ok {
+		logger.Info("Found Homebrew at %s; adding it to PATH", brew)
+		return bootstrap.Setup(brew)
+	}
+
+	if sysInfo.OS == "darwin" && !yes {
+		if !canPrompt(yes) {
+			return fmt.Errorf("Homebrew is not installed; install it from https://brew.sh or run again with --yes to install it")
+		}
+		fmt.Print("Homebrew is not installed and bootstrap-cli installs everything with it. Install it now? [Y/n] ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "" && answer != "y" && answer != "yes" {
+			return fmt.Errorf("Homebrew is required on macOS; install it from https://brew.sh")
+		}
+	}
+	logger.Info("Installing Homebrew with its install script (%s)", homebrew.InstallScript)
+	brew, err := bootstrap.Install()
+	if err != nil {
+		return err
+	}
+	logger.Success("Installed Homebrew at %s", brew)
+	return nil
+}
@@ -0,0 +1,127 @@
+package up
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/ui/components"
+)
+
+// offerResume looks for the install queue of an interrupted run and offers to
+// finish it; resume finishes it without asking. It returns the queue and the
+// remaining selections when the run is resumed, and a nil queue otherwise.
+func offerResume(catalog *config.Catalog, manager string, resume, yes bool) (*manifest.Queue, selections, error) {
+	path, err := manifest.DefaultQueuePath()
+	if err != nil {
+		return nil, selections{}, err
+	}
+	queue, err := manifest.LoadQueue(path)
+	if err != nil {
+		logger.Warn("Ignoring the install queue: %v", err)
+		return nil, selections{}, nil
+	}
+	if queue == nil || len(queue.Remaining()) == 0 {
+		if resume {
+			logger.Info("No interrupted run to resume")
+		}
+		return nil, selections{}, nil
+	}
+	if queue.PackageManager != "" && queue.PackageManager != manager {
+		logger.Warn("Ignoring the install queue: it was planned for %s, but this system uses %s", queue.PackageManager, manager)
+		return nil, selections{}, nil
+	}
+
+	sel, missing := resumeSelections(queue, catalog)
+	for _, name := range missing {
+		logger.Warn("Cannot resume %s: it is no longer in the catalog", name)
+	}
+
+	if !resume {
+		if !canPrompt(yes) {
+			logger.Warn("An interrupted run left %d item(s) in the install queue; run bootstrap-cli up --resume to finish them", len(queue.Remaining()))
+			return nil, selections{}, nil
+		}
+		actions := []components.NotificationAction{
+			{Key: "r", Label: "Resume", Run: func() error {
+				resume = true
+				return nil
+			}},
+			{Key: "s", Label: "Start over"},
+		}
+		if err := components.NewNotificationManager(os.Stdout).Prompt(components.NotifyWarning, "Unfinished installation", queueMessage(queue), actions, os.Stdin); err != nil {
+			return nil, selections{}, err
+		}
+		if !resume {
+			return nil, selections{}, nil
+		}
+	}
+	logger.Info("Resuming the run started %s", queue.StartedAt.Format("2006-01-02 15:04"))
+	return queue, sel, nil
+}
+
+// resumeSelections maps the queue's remaining items back to the catalog,
+// returning the names of those it no longer has
+func resumeSelections(queue *manifest.Queue, catalog *config.Catalog) (selections, []string) {
+	var sel selections
+	var missing []string
+	for _, item := range queue.Remaining() {
+		found := false
+		switch item.Kind {
+		case manifest.QueueTool:
+			for _, tool := range catalog.Tools {
+				if tool.Name == item.Name {
+					sel.tools, found = append(sel.tools, tool), true
+					break
+				}
+			}
+		case manifest.QueueFont:
+			for _, font := range catalog.Fonts {
+				if font.Name == item.Name {
+					sel.fonts, found = append(sel.fonts, font), true
+					break
+				}
+			}
+		case manifest.QueueLanguage:
+			for _, lang := range catalog.Languages {
+				if lang.Name == item.Name {
+					// Keep a language picked to install through asdf or mise on it
+					if (item.Manager == interfaces.LanguageInstallerAsdf || item.Manager == interfaces.LanguageInstallerMise) && lang.RuntimeManager() != item.Manager {
+						picked := *lang
+						picked.Installer = item.Manager
+						lang = &picked
+					}
+					sel.languages, found = append(sel.languages, lang), true
+					break
+				}
+			}
+		case manifest.QueueDotfiles:
+			sel.manageDotfiles, sel.dotfilesRepo, found = true, item.Name, true
+		case manifest.QueueShell:
+			for _, sh := range catalog.Shells {
+				if sh.Name == item.Name {
+					sel.shell, found = sh, true
+					break
+				}
+			}
+		}
+		if !found {
+			missing = append(missing, item.Name)
+		}
+	}
+	return sel, missing
+}
+
+// queueMessage says how far the interrupted run got and what is left
+func queueMessage(queue *manifest.Queue) string {
+	rest := queue.Remaining()
+	names := make([]string, 0, len(rest))
+	for _, item := range rest {
+		names = append(names, item.Name)
+	}
+	return fmt.Sprintf("A run started %s stopped with %d of %d items left: %s.",
+		queue.StartedAt.Format("2006-01-02 15:04"), len(rest), len(queue.Items), strings.Join(names, ", "))
+}
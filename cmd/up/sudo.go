@@ -0,0 +1,102 @@
+package up
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+	"github.com/spf13/cobra"
+)
+
+// privilegedItem returns the first selection that installs with a system
+// package or otherwise runs a command as root, or "" when none does. Groups
+// are checked by their members from catalog.
+func (s selections) privilegedItem(catalog []*pipeline.Tool, strategy string, platform *pipeline.Platform) string {
+	tools, _, err := pipeline.ExpandGroups(s.tools, catalog)
+	if err != nil {
+		tools = s.tools
+	}
+	for _, tool := range tools {
+		if tool.NeedsPrivilege(platform) {
+			return tool.Name
+		}
+	}
+	for _, lang := range s.languages {
+		if pipeline.LanguageNeedsPrivilege(lang, strategy, platform) {
+			return lang.Name
+		}
+	}
+	if s.shell != nil && pipeline.ShellNeedsPrivilege(s.shell, platform) {
+		return s.shell.Name
+	}
+	return ""
+}
+
+// primeSudo asks for the sudo password once, before the UI owns the terminal,
+// when installing plan (or, before selection, anything offered) runs a command
+// as root. It fails fast when such a command could not run at all: sudo is
+// missing, or it needs a password and there is no terminal to ask on.
+func primeSudo(cmd *cobra.Command, plan *selections, catalog *config.Catalog, strategy string, platform *pipeline.Platform) (*system.SudoSession, error) {
+	if !system.NeedsSudo() {
+		return &system.SudoSession{}, nil
+	}
+	var item string
+	if plan != nil {
+		item = plan.privilegedItem(catalog.Tools, strategy, platform)
+	} else {
+		// Before selection, everything the screens offer stands in for the plan
+		item = selections{tools: catalog.Tools, languages: catalog.Languages}.privilegedItem(catalog.Tools, strategy, platform)
+		for _, sh := range catalog.Shells {
+			if item == "" && pipeline.ShellNeedsPrivilege(sh, platform) {
+				item = sh.Name
+			}
+		}
+	}
+	if item == "" {
+		logger.Debug("Nothing to install needs sudo")
+		return &system.SudoSession{}, nil
+	}
+	if !system.SudoAvailable() {
+		return nil, fmt.Errorf("installing %s needs root, but sudo is not installed: run bootstrap-cli as root or pass --no-sudo", item)
+	}
+
+	passwordStdin, _ := cmd.Flags().GetBool("sudo-password-stdin")
+	sudo, err := system.PrimeSudo(passwordStdin, os.Stdin, os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("%w (installing %s needs it; pass --no-sudo to skip what does)", err, item)
+	}
+	return sudo, nil
+}
+
+// withoutSudo narrows the selections to what installs without root for
+// --no-sudo, warning about each one skipped. Tools with a GitHub release are
+// kept and installed from it into ~/.local/bin.
+func (s selections) withoutSudo(catalog []*pipeline.Tool, strategy string, platform *pipeline.Platform) selections {
+	tools, _, err := pipeline.ExpandGroups(s.tools, catalog)
+	if err != nil {
+		tools = s.tools
+	}
+	var issues []pipeline.PreflightIssue
+	s.tools, issues = pipeline.WithoutSudo(tools, platform)
+	for _, issue := range issues {
+		logger.Warn("Skipping %s", issue.Error())
+	}
+
+	languages := s.languages[:0:0]
+	for _, lang := range s.languages {
+		if pipeline.LanguageNeedsPrivilege(lang, strategy, platform) {
+			logger.Warn("Skipping %s: it is only installed with system packages here, which need sudo", lang.Name)
+			continue
+		}
+		languages = append(languages, lang)
+	}
+	s.languages = languages
+
+	if s.shell != nil && pipeline.ShellNeedsPrivilege(s.shell, platform) {
+		logger.Warn("Skipping the %s shell: it is not installed, and installing it needs sudo", s.shell.Name)
+		s.shell = nil
+	}
+	return s
+}
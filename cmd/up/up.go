@@ -2,17 +2,28 @@
 package up
 
 import (
+	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cache"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/install"
 	base_iface "github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces" // Base interfaces (like for UI selections)
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
-	"github.com/YitzhakMizrahi/bootstrap-cli/internal/packages/factory"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/offline"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline" // Pipeline interfaces defined in pipeline package itself
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/ui"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/ui/app"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/ui/components"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/ui/styles"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/uninstall"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
 )
@@ -33,9 +44,47 @@ for your development environment, including:
 - Programming languages
 - Fonts
 - Shell setup
-- Dotfiles management`,
+- Dotfiles management
+
+On a Mac without Homebrew, up offers to install it first with the official
+install script (unattended with --yes), adds "brew shellenv" to your shell's
+rc file and then detects it as the package manager. On Linux the same happens
+with --linuxbrew when no supported package manager is installed.
+
+When something selected installs with sudo, the password is asked for once
+before installing starts and kept cached until the run ends. Without a
+terminal to ask on, pass --sudo-password-stdin or set SUDO_ASKPASS, or use
+--no-sudo: tools with a GitHub release are then installed into ~/.local/bin
+instead of their system package, languages use version managers, and
+anything else that needs root is skipped with a warning.
+
+With --batch, the selected tools that are plain packages of apt, dnf, pacman,
+zypper, pkg or Homebrew install in one package manager command before the rest, and
+each is then verified on its own; if that command fails they install one at
+a time.`,
 		RunE: runUp,
 	}
+	cmd.Flags().Int("jobs", 0, "Install up to this many tools at once; apt, dnf, pacman and zypper still install one package at a time (default: one per CPU)")
+	cmd.Flags().Duration("tool-timeout", pipeline.DefaultToolTimeout, "Fail a tool's install as timed out once it has run this long, unless the tool sets its own timeout")
+	cmd.Flags().Bool("verbose", false, "Stream install command output live instead of showing the installation screen")
+	cmd.Flags().String("language-strategy", "", "Install languages with \"version-manager\" or \"system\" packages (default: system in containers/WSL)")
+	cmd.Flags().String("version-manager", "", "Install languages with this existing version manager ("+strings.Join(system.DefaultVersionManagerOrder, ", ")+"), or \""+system.VersionManagerNone+"\" to always set up nvm, pyenv, goenv and rustup (default: the first one found, see version_manager_order in settings.yaml)")
+	cmd.Flags().Bool("locked", false, "Install the exact tool and language versions recorded in the lock file, failing if one is unavailable")
+	cmd.Flags().String("lockfile", "", "Lock file to write after a successful run, or to read with --locked (default: ~/.bootstrap-cli/"+manifest.LockFileName+")")
+	cmd.Flags().Bool("reinstall", false, "Install every selection again, even tools and languages already recorded in ~/.bootstrap-cli/"+manifest.InstalledFileName+" and still on PATH")
+	cmd.Flags().Bool("resume", false, "Finish the install queue left by an interrupted run without asking (default: ask on a terminal)")
+	cmd.Flags().Bool("smoke-test", false, "After installing, check each language works in a fresh shell that only has the updated rc file")
+	cmd.Flags().Bool("launch-shell", false, "Start a fresh interactive shell when setup finishes so new configuration takes effect")
+	cmd.Flags().BoolP("yes", "y", false, "Change the login shell to the selected shell without asking")
+	cmd.Flags().String("on-conflict", "", "How to handle tools already installed by another manager: "+strings.Join(pipeline.ConflictResolutions, ", ")+" (default: ask, or keep with --yes)")
+	cmd.Flags().String("prompt-style", "", "Write a default prompt config and load it from the shell's rc file: "+strings.Join(shell.PromptStyles(), ", "))
+	cmd.Flags().String("shells", "", "Comma-separated shells to write tool completions and the prompt for, selected shell first (default: shells in settings.yaml)")
+	cmd.Flags().Bool("force", false, "Replace an existing prompt config with the --prompt-style default")
+	cmd.Flags().Bool("linuxbrew", false, "On Linux without apt, dnf or pacman, install Homebrew and use it (Homebrew is installed on macOS without asking for this)")
+	cmd.Flags().Bool("no-sudo", false, "Never run sudo: install tools from their GitHub releases into ~/.local/bin where they have one and skip what needs root")
+	cmd.Flags().Bool("batch", false, "Install the selected tools that are plain system packages with one package manager command")
+	cmd.Flags().String("theme", "", "UI color theme: "+strings.Join(styles.ThemeNames(), ", ")+" (default: theme in settings.yaml or $"+styles.ThemeEnvVar+")")
+	cmd.Flags().StringP("output", "o", "text", "Output format: text, or json for a per-item install report on stdout once the UI exits (progress goes to stderr)")
 	return cmd
 }
 
@@ -46,13 +95,59 @@ func runUp(cmd *cobra.Command, _ []string) error {
 	}
 	logger.Info("Starting Bootstrap CLI TUI...")
 
+	// Validate flags before the TUI takes over the terminal
+	output, _ := cmd.Flags().GetString("output")
+	if output != "text" && output != "json" {
+		return fmt.Errorf("invalid --output %q: must be text or json", output)
+	}
+	if output == "json" {
+		// Keep stdout for the report
+		logger.SetOutput(os.Stderr)
+	}
+	languageStrategy, _ := cmd.Flags().GetString("language-strategy")
+	if languageStrategy != "" && languageStrategy != base_iface.LanguageStrategySystem && languageStrategy != base_iface.LanguageStrategyVersionManager {
+		return fmt.Errorf("invalid --language-strategy %q: must be %q or %q", languageStrategy, base_iface.LanguageStrategyVersionManager, base_iface.LanguageStrategySystem)
+	}
+	noSudo, _ := cmd.Flags().GetBool("no-sudo")
+	if noSudo && languageStrategy == base_iface.LanguageStrategySystem {
+		return fmt.Errorf("--language-strategy %s installs system packages, which --no-sudo rules out", languageStrategy)
+	}
+	versionManager, _ := cmd.Flags().GetString("version-manager")
+	if err := system.ValidVersionManager(versionManager); err != nil {
+		return fmt.Errorf("invalid --version-manager: %w", err)
+	}
+	promptStyle, _ := cmd.Flags().GetString("prompt-style")
+	if err := shell.ValidatePromptStyle(promptStyle, ""); err != nil {
+		return fmt.Errorf("invalid --prompt-style: %w", err)
+	}
+	shellsFlag, _ := cmd.Flags().GetString("shells")
+	shellList := shell.ParseShells(shellsFlag)
+	if err := shell.ValidateShells(shellList); err != nil {
+		return fmt.Errorf("invalid --shells: %w", err)
+	}
+
+	lockPath, _ := cmd.Flags().GetString("lockfile")
+	if lockPath == "" {
+		var err error
+		if lockPath, err = manifest.DefaultLockPath(); err != nil {
+			return err
+		}
+	}
+	var lock *manifest.Lock
+	if locked, _ := cmd.Flags().GetBool("locked"); locked {
+		var err error
+		if lock, err = manifest.LoadLock(lockPath); err != nil {
+			return fmt.Errorf("--locked requires a lock file from a previous run: %w", err)
+		}
+	}
+
 	// Get config path from environment
 	configPath := os.Getenv("BOOTSTRAP_CLI_CONFIG")
 	if configPath == "" {
 		// Try default location if env var is not set
-		home, err := os.UserHomeDir()
+		home, err := system.UserHome()
 		if err != nil {
-			return fmt.Errorf("failed to get home directory: %w", err)
+			return err
 		}
 		configPath = filepath.Join(home, ".config", "bootstrap-cli")
 		logger.Debug("BOOTSTRAP_CLI_CONFIG not set, using default: %s", configPath)
@@ -65,51 +160,166 @@ func runUp(cmd *cobra.Command, _ []string) error {
 
 	// Initialize config loader with the correct path
 	configLoader := config.NewLoader(configPath)
+	// Load every config once up front; the TUI screens then read from the cache
+	catalog, err := configLoader.LoadAll()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	yes, _ := cmd.Flags().GetBool("yes")
+	if err := cleanOrphanedBlocks(catalog.Tools, yes); err != nil {
+		return err
+	}
 
-	// --- Run the TUI Application --- 
-	appModel := app.New(configLoader)
-	p := tea.NewProgram(appModel, tea.WithAltScreen())
+	// User settings live in the --config directory or ~/.config/bootstrap-cli
+	settingsDir, _ := cmd.Flags().GetString("config")
+	settingsPath, err := config.SettingsPath(settingsDir)
+	if err != nil {
+		return err
+	}
+	settings, err := config.LoadSettings(settingsPath)
+	if err != nil {
+		return err
+	}
+	// --shells wins over settings.yaml
+	if shellsFlag == "" {
+		shellList = settings.Shells
+		if err := shell.ValidateShells(shellList); err != nil {
+			return fmt.Errorf("invalid shells in %s: %w", settingsPath, err)
+		}
+	}
 
-	finalModelInterface, err := p.Run()
+	// --theme wins over settings.yaml, which wins over the environment
+	themeName, _ := cmd.Flags().GetString("theme")
+	if themeName == "" {
+		themeName = settings.Theme
+	}
+	if themeName == "" {
+		themeName = os.Getenv(styles.ThemeEnvVar)
+	}
+	theme, err := styles.LookupTheme(themeName)
 	if err != nil {
-		// Ensure terminal state is reset even on error
-		_ = tea.ClearScreen() // Attempt to clear screen
-		return fmt.Errorf("application error: %w", err)
+		return err
 	}
-	logger.Info("TUI finished. Processing selections...")
+	styles.ApplyTheme(theme)
 
-	// --- Process Selections and Run Installation --- 
-	m, ok := finalModelInterface.(*app.Model)
-	if !ok {
-		return fmt.Errorf("internal error: could not cast final model to *app.Model")
+	// Fall back to 256/16-color styles on terminals without truecolor
+	styles.UseProfile(styles.DetectProfile(os.Getenv))
+
+	// Fail fast, before any selection or install, on a platform bootstrap-cli cannot set up
+	sysInfo, err := system.Detect()
+	if err != nil {
+		return fmt.Errorf("failed to detect system info for installation: %w", err)
+	}
+	linuxbrew, _ := cmd.Flags().GetBool("linuxbrew")
+	if err := ensureHomebrew(sysInfo, yes, linuxbrew); err != nil {
+		return err
+	}
+	pkgManagerImpl, pmErr := pipeline.DetectPackageManager() // base_iface.PackageManager
+	managerName := ""
+	if pmErr == nil {
+		managerName = pkgManagerImpl.GetName()
+	}
+	if issues := system.PlatformIssues(sysInfo.OS, managerName); len(issues) > 0 {
+		return &system.UnsupportedPlatformError{Platform: sysInfo.OS + "/" + sysInfo.Arch, Issues: issues}
+	}
+	if pmErr != nil {
+		return pmErr
+	}
+	pipelinePlatform := pipeline.PlatformFor(sysInfo, pkgManagerImpl.GetName())
+
+	// A run cut short by a crash or reboot left its queue behind; offer to finish it
+	// exactly as it was planned instead of starting over
+	resume, _ := cmd.Flags().GetBool("resume")
+	queue, resumed, err := offerResume(catalog, managerName, resume, yes)
+	if err != nil {
+		return err
+	}
+	if queue != nil {
+		if languageStrategy == "" {
+			languageStrategy = queue.LanguageStrategy
+		}
+		if versionManager == "" {
+			versionManager = queue.VersionManager
+		}
+		if promptStyle == "" {
+			promptStyle = queue.PromptStyle
+		}
+		if queue.Locked && lock == nil {
+			lock = queue.Lock()
+		}
 	}
 
-	// Gather selections (selectedTools is now []*pipeline.Tool)
-	selectedPipelineTools := m.SelectedTools()      
-	manageDotfiles := m.GetManageDotfiles() 
-	dotfilesRepoURL := m.GetDotfilesRepoURL() 
-	selectedFonts := m.SelectedFonts()        
-	selectedLanguages := m.SelectedLanguages() 
-	selectedShell := m.GetSelectedShell()     // Get selected shell
+	strategy := sysInfo.DefaultLanguageStrategy()
+	if languageStrategy != "" {
+		strategy = languageStrategy
+	}
+	if noSudo {
+		// Version managers install into $HOME
+		strategy = base_iface.LanguageStrategyVersionManager
+	}
+
+	// Ask for the sudo password now; a prompt from a later sudo would garble the
+	// UI, or wait unseen behind it
+	sudo := &system.SudoSession{}
+	if !noSudo {
+		var plan *selections
+		if queue != nil {
+			plan = &resumed
+		}
+		if sudo, err = primeSudo(cmd, plan, catalog, strategy, pipelinePlatform); err != nil {
+			return err
+		}
+	}
+	defer sudo.Stop()
+
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	sel := resumed
+	if queue == nil {
+		// With --no-sudo the selections are narrowed before anything installs, and
+		// the --output json report covers the one install run after the UI exits
+		if sel, err = runSelection(configLoader, verbose || noSudo || output == "json", shellList); err != nil {
+			return err
+		}
+		// Nothing chosen needs the credentials asked for up front
+		if sel.privilegedItem(catalog.Tools, strategy, pipelinePlatform) == "" {
+			sudo.Stop()
+		}
+	}
+	if noSudo {
+		sel = sel.withoutSudo(catalog.Tools, strategy, pipelinePlatform)
+	}
+	selectedPipelineTools := sel.tools
+	manageDotfiles := sel.manageDotfiles
+	dotfilesRepoURL := sel.dotfilesRepo
+	selectedFonts := sel.fonts
+	selectedLanguages := sel.languages
+	selectedShell := sel.shell
+	selectedShells := sel.shells
+	if queue != nil && selectedShell != nil {
+		selectedShells = shell.WithPrimary(selectedShell.Name, shellList)
+	}
 
 	// Early exit if nothing was selected
 	if len(selectedPipelineTools) == 0 && !manageDotfiles && len(selectedFonts) == 0 && len(selectedLanguages) == 0 && selectedShell == nil {
 		logger.Info("No items selected for installation or configuration. Exiting.")
+		if output == "json" {
+			return (&install.Report{Tools: []*install.ToolReport{}}).WriteJSON(cmd.OutOrStdout())
+		}
 		return nil
 	}
 
 	// Tool definitions are now correctly loaded in selectedPipelineTools from the UI model.
 	// No extra loading/filtering needed here.
 
-	// Detect system platform and package manager
-	sysInfo, err := system.Detect()
-	if err != nil {
-		return fmt.Errorf("failed to detect system info for installation: %w", err)
+	// Version managers only ship binaries for some architectures
+	var versionManaged []string
+	for _, lang := range selectedLanguages {
+		if lang.ResolveStrategy(strategy) == base_iface.LanguageStrategyVersionManager {
+			versionManaged = append(versionManaged, lang.Name)
+		}
 	}
-	pkgManagerFactory := factory.NewPackageManagerFactory()
-	pkgManagerImpl, err := pkgManagerFactory.GetPackageManager() // base_iface.PackageManager
-	if err != nil {
-		return fmt.Errorf("failed to detect package manager for installation: %w", err)
+	if issues := system.LanguageIssues(sysInfo.Arch, versionManaged); len(issues) > 0 {
+		return &system.UnsupportedPlatformError{Platform: sysInfo.OS + "/" + sysInfo.Arch, Issues: issues}
 	}
 
 	// Adapt the base PackageManager to the pipeline's PackageManager interface
@@ -117,30 +327,139 @@ func runUp(cmd *cobra.Command, _ []string) error {
 	pipelinePackageManager = &packageManagerAdapter{impl: pkgManagerImpl} 
 	// fmt.Println("TODO: Verify and complete PackageManager adapter implementation for pipeline.") // Remove TODO Print
 
-	pipelinePlatform := &pipeline.Platform{
-		OS:             sysInfo.OS,
-		Arch:           sysInfo.Arch,
-		PackageManager: pkgManagerImpl.GetName(), // Get name from the base implementation before adapting
-		Shell:          sysInfo.Shell,
-	}
 	// fmt.Println("TODO: Ensure this is the correct way to set PackageManager for the pipeline context") // Remove TODO Print
 
+	// Drop tools that cannot be installed with the active package manager before starting
+	selectedPipelineTools, preflightIssues := pipeline.Preflight(selectedPipelineTools, pipelinePlatform)
+	for _, issue := range preflightIssues {
+		logger.Warn("Skipping %s", issue.Error())
+	}
+
+	// Locked versions are only meaningful for the package manager that resolved them
+	if lock != nil && lock.PackageManager != "" && lock.PackageManager != pipelinePlatform.PackageManager {
+		return fmt.Errorf("lock file %s was generated with %s, but this system uses %s", lockPath, lock.PackageManager, pipelinePlatform.PackageManager)
+	}
+
 	// Create the installer
 	installer, err := pipeline.NewInstaller(pipelinePlatform, pipelinePackageManager)
 	if err != nil {
 		return fmt.Errorf("failed to create installer: %w", err)
 	}
+	installer.LockPath = lockPath
+	installer.Context.Lock = lock
+	installer.Context.Verbose = verbose
+	installer.Context.Concurrency, _ = cmd.Flags().GetInt("jobs")
+	installer.Context.ToolTimeout, _ = cmd.Flags().GetDuration("tool-timeout")
+	installer.Reinstall, _ = cmd.Flags().GetBool("reinstall")
+	installer.Context.ToolManagers = settings.ToolManagers
+	installer.Context.NeovimConfig = settings.NeovimConfig
+	installer.Context.NoSudo = noSudo
+	installer.Context.BatchPackages, _ = cmd.Flags().GetBool("batch")
+	if queue != nil {
+		installer.Context.ToolManagers = queue.ToolManagers(settings.ToolManagers)
+	}
+	// Groups selected in the TUI expand to members from the full catalog
+	if installer.Catalog, err = configLoader.LoadTools(); err != nil {
+		return fmt.Errorf("failed to load tools: %w", err)
+	}
+	if cache.Offline() {
+		if err := warnOffline(selectedPipelineTools, installer.Catalog, selectedFonts, selectedLanguages, pipelinePlatform); err != nil {
+			return err
+		}
+	}
+	installer.Dotfiles = catalog.Dotfiles
+	if home, err := system.UserHome(); err == nil {
+		installer.DotfilesDir = settings.DotfilesPath(home)
+	}
+	installer.Context.LanguageStrategy = strategy
+	installer.Context.VersionManager = versionManager
+	installer.Context.VersionManagerOrder = settings.VersionManagerOrder
+
+	installer.Context.ForcePromptConfig, _ = cmd.Flags().GetBool("force")
+	installer.Context.Shells = selectedShells
+	switch {
+	case promptStyle == "":
+	case selectedShell == nil:
+		logger.Warn("Not writing a %s prompt config: no shell was selected", promptStyle)
+	default:
+		// Each configured shell must be one the prompt works in, or it needs
+		if err := shell.ValidateShells(selectedShells, promptStyle); err != nil {
+			logger.Warn("Not writing a prompt config: %v", err)
+		} else {
+			installer.Context.PromptStyle = promptStyle
+		}
+	}
+
+	// Switching the login shell is the one risky step, so it needs explicit consent
+	if selectedShell != nil {
+		yes, _ := cmd.Flags().GetBool("yes")
+		installer.Context.LoginShellChange = shell.RequestLoginShellChange(selectedShell, yes, os.Stdin, os.Stdout, logger)
+	}
+
+	// Tools that must not coexist are narrowed to one of each pair
+	if conflicts := pipeline.FindToolConflicts(selectedPipelineTools); len(conflicts) > 0 {
+		yes, _ := cmd.Flags().GetBool("yes")
+		if !canPrompt(yes) {
+			return &pipeline.ToolConflictError{Conflicts: conflicts}
+		}
+		selectedPipelineTools = pipeline.ResolveToolConflicts(selectedPipelineTools, conflicts, func(c pipeline.ToolConflict) *pipeline.Tool {
+			return pipeline.PromptToolConflict(c, os.Stdin, os.Stdout)
+		})
+	}
+
+	// Tools already installed by another manager are kept, reinstalled or skipped
+	if conflicts := pipeline.FindManagerConflicts(selectedPipelineTools, installer.Context); len(conflicts) > 0 {
+		onConflict, _ := cmd.Flags().GetString("on-conflict")
+		if onConflict != "" {
+			if err := pipeline.ValidateConflictResolution(onConflict); err != nil {
+				return err
+			}
+		}
+		yes, _ := cmd.Flags().GetBool("yes")
+		selectedPipelineTools = installer.ResolveManagerConflicts(selectedPipelineTools, conflicts, conflictResolver(onConflict, yes))
+	}
 
 	// Pass the selections to InstallSelections
 	if len(selectedPipelineTools) > 0 || manageDotfiles || len(selectedFonts) > 0 || len(selectedLanguages) > 0 || selectedShell != nil { // Updated condition
 		logger.Info("Starting installation process...")
 		// Pass all selections to the installer
-		if err := installer.InstallSelections(selectedPipelineTools, manageDotfiles, dotfilesRepoURL, selectedFonts, selectedLanguages, selectedShell); err != nil { // Pass selectedShell
-			return fmt.Errorf("installation failed: %w", err)
+		sel := selections{
+			tools: selectedPipelineTools, manageDotfiles: manageDotfiles, dotfilesRepo: dotfilesRepoURL,
+			fonts: selectedFonts, languages: selectedLanguages, shell: selectedShell, shells: selectedShells,
+		}
+		if output == "json" {
+			installer.Logger, installer.Context.Logger = logger, logger
+		}
+		events := pipeline.NewRunReport()
+		installErr := events.Run(installer.ProgressChan, func() error { return sel.install(installer) })
+		if output == "json" {
+			if err := events.Report(installer, installErr).WriteJSON(cmd.OutOrStdout()); err != nil {
+				return err
+			}
+		}
+		if installErr != nil {
+			// Interactive runs can retry what failed, read the log or carry on
+			if yes, _ := cmd.Flags().GetBool("yes"); output == "text" && canPrompt(yes) {
+				installErr = offerRetry(installer, sel, installErr, pkgManagerImpl)
+			}
+		}
+		if installErr != nil {
+			return fmt.Errorf("installation failed: %w", installErr)
 		}
 		logger.Info("Installation phase complete.")
+
+		if smoke, _ := cmd.Flags().GetBool("smoke-test"); smoke && len(selectedLanguages) > 0 {
+			if err := smokeTest(selectedShell, selectedLanguages); err != nil {
+				return err
+			}
+		}
 	} else {
 		logger.Info("No items selected for installation.") // Updated log
+		if output == "json" {
+			if err := (&install.Report{Tools: []*install.ToolReport{}}).WriteJSON(cmd.OutOrStdout()); err != nil {
+				return err
+			}
+		}
 	}
 
 	// Shell configuration is now handled within InstallSelections
@@ -150,9 +469,342 @@ func runUp(cmd *cobra.Command, _ []string) error {
 	// }
 
 	logger.Info("Bootstrap setup process finished.")
+
+	launch, _ := cmd.Flags().GetBool("launch-shell")
+	if !launch && (len(selectedLanguages) > 0 || selectedShell != nil) {
+		notices := os.Stdout
+		if output == "json" {
+			notices = os.Stderr
+		}
+		components.NewNotificationManager(notices).Show(components.NotifyInfo, "Open a new shell",
+			"Your shell configuration changed. Run `exec $SHELL` or open a new terminal so the new PATH and settings take effect.")
+	}
+	if launch {
+		shellName := ""
+		if selectedShell != nil {
+			shellName = selectedShell.Name
+		}
+		shellPath, err := shell.ResolveShellPath(shellName)
+		if err != nil {
+			return err
+		}
+		return shell.LaunchInteractive(shellPath)
+	}
 	return nil
 } 
 
+// runSelection runs the TUI, or plain prompts where it cannot run, and returns
+// what the user chose to install; shells are configured beside the selected shell
+func runSelection(configLoader *config.Loader, verbose bool, shells []string) (selections, error) {
+	appModel := app.New(configLoader)
+	appModel.SetShells(shells)
+	// Verbose output owns the terminal, so the TUI is only used for selection
+	appModel.SetInstallOutsideUI(verbose)
+
+	// Dumb, unknown or non-terminal output gets plain prompts instead of the
+	// full-screen UI, and installation prints plain progress lines
+	var finalModelInterface tea.Model = appModel
+	if ok, reason := app.FullScreenSupported(os.Getenv, os.Stdout); !ok {
+		logger.Info("Full-screen UI unavailable (%s); using plain prompts", reason)
+		if err := appModel.RunPlain(os.Stdin, os.Stdout); err != nil {
+			return selections{}, err
+		}
+	} else {
+		p := tea.NewProgram(appModel, tea.WithAltScreen())
+		var err error
+		if finalModelInterface, err = p.Run(); err != nil {
+			// The terminal could not drive bubbletea after all; ask again in plain mode
+			_ = tea.ClearScreen()
+			logger.Warn("Full-screen UI failed (%v); falling back to plain prompts", err)
+			finalModelInterface = appModel
+			if err := appModel.RunPlain(os.Stdin, os.Stdout); err != nil {
+				return selections{}, err
+			}
+		}
+		logger.Info("TUI finished. Processing selections...")
+	}
+
+	// --- Process Selections and Run Installation --- 
+	m, ok := finalModelInterface.(*app.Model)
+	if !ok {
+		return selections{}, fmt.Errorf("internal error: could not cast final model to *app.Model")
+	}
+	// After a Ctrl+C during installation, wait for its commands to be killed
+	m.StopInstall()
+
+	return selections{
+		tools:          m.SelectedTools(),
+		manageDotfiles: m.GetManageDotfiles(),
+		dotfilesRepo:   m.GetDotfilesRepoURL(),
+		fonts:          m.SelectedFonts(),
+		languages:      m.SelectedLanguages(),
+		shell:          m.GetSelectedShell(),
+		shells:         m.SelectedShells(),
+	}, nil
+
+}
+
+// smokeTest runs each language's smoke test in a fresh selected shell (or $SHELL) and
+// reports the results, failing if any language is not usable from a new shell
+func smokeTest(sh *base_iface.Shell, languages []*base_iface.Language) error {
+	shellName := ""
+	if sh != nil {
+		shellName = sh.Name
+	}
+	shellPath, err := shell.ResolveShellPath(shellName)
+	if err != nil {
+		return fmt.Errorf("failed to run smoke tests: %w", err)
+	}
+
+	logger.Info("Running smoke tests in a fresh %s...", filepath.Base(shellPath))
+	var failed []string
+	for _, result := range shell.RunSmokeTests(shellPath, shell.SmokeTestsFor(languages)) {
+		if result.Passed {
+			logger.Success("%s", result.String())
+			continue
+		}
+		logger.Error("%s", result.String())
+		failed = append(failed, result.Name)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("smoke tests failed for %s: open a new shell or check the rc file changes", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// conflictResolver answers manager conflicts with onConflict when given, by asking
+// on a terminal otherwise, and keeps the existing install with --yes or no terminal
+func conflictResolver(onConflict string, yes bool) func(pipeline.ManagerConflict) string {
+	return func(c pipeline.ManagerConflict) string {
+		if onConflict != "" {
+			logger.Info("%s; using --on-conflict %s", c, onConflict)
+			return onConflict
+		}
+		if !canPrompt(yes) {
+			logger.Info("%s; keeping the existing install", c)
+			return pipeline.ConflictKeep
+		}
+		return pipeline.PromptConflictResolution(c, os.Stdin, os.Stdout)
+	}
+}
+
+// selections is what the user chose to install in one run
+type selections struct {
+	tools          []*pipeline.Tool
+	manageDotfiles bool
+	dotfilesRepo   string
+	fonts          []*base_iface.Font
+	languages      []*base_iface.Language
+	shell          *base_iface.Shell
+	// shells are the shells to configure, the selected one first
+	shells []string
+}
+
+// warnOffline reports which selections an offline run installs from the
+// download cache and which still need the network
+func warnOffline(tools, catalog []*pipeline.Tool, fonts []*base_iface.Font, languages []*base_iface.Language, platform *pipeline.Platform) error {
+	downloads, err := cache.NewDefault()
+	if err != nil {
+		return fmt.Errorf("failed to open download cache: %w", err)
+	}
+	items, err := offline.Items(offline.Selection{Tools: tools, Catalog: catalog, Fonts: fonts, Languages: languages, Platform: platform})
+	if err != nil {
+		return err
+	}
+	arch := platform.Arch
+	if arch == "" {
+		arch = runtime.GOARCH
+	}
+	cached, network := offline.Report(items, downloads, platform.OS, arch)
+	if len(cached) > 0 {
+		logger.Info("Offline: installing from the download cache: %s", offline.Names(cached))
+	}
+	for _, reason := range network {
+		logger.Warn("Offline: %s needs the network and will likely fail", reason)
+	}
+	return nil
+}
+
+// install runs the selections with installer and logs the per-group summary
+func (s selections) install(installer *pipeline.Installer) error {
+	err := installer.InstallSelections(s.tools, s.manageDotfiles, s.dotfilesRepo, s.fonts, s.languages, s.shell)
+	if installer.Pipeline != nil {
+		for _, group := range installer.Pipeline.Summary().Groups() {
+			logger.Info("%s", group.String())
+			for _, item := range group.Failed {
+				if path := group.Logs[item]; path != "" {
+					logger.Info("  %s output: %s", item, path)
+				}
+			}
+		}
+	}
+	if installer.DiskUsage != nil && installer.DiskUsage.Total() > 0 {
+		logger.Info("%s", installer.DiskUsage.String())
+	}
+	return err
+}
+
+// remaining returns the selections that are not in done, which includes items
+// that never ran because an earlier step failed. A group stays while any of its
+// members is missing.
+func (s selections) remaining(done map[string]bool) selections {
+	rest := selections{dotfilesRepo: s.dotfilesRepo}
+	for _, tool := range s.tools {
+		missing := !done[tool.Name]
+		if len(tool.Group) > 0 {
+			missing = false
+			for _, member := range tool.Group {
+				missing = missing || !done[member]
+			}
+		}
+		if missing {
+			rest.tools = append(rest.tools, tool)
+		}
+	}
+	for _, font := range s.fonts {
+		if !done[font.Name] {
+			rest.fonts = append(rest.fonts, font)
+		}
+	}
+	for _, lang := range s.languages {
+		if !done[lang.Name] {
+			rest.languages = append(rest.languages, lang)
+		}
+	}
+	rest.manageDotfiles = s.manageDotfiles && !done[s.dotfilesRepo]
+	if s.shell != nil && !done[s.shell.Name] {
+		rest.shell = s.shell
+	}
+	return rest
+}
+
+// offerRetry reports a failed run as a notification whose actions retry what
+// did not install, print the failure log, or dismiss it so the rest of the
+// setup carries on. It returns the error of the last run, or nil once a retry
+// succeeds or the failure is ignored.
+func offerRetry(installer *pipeline.Installer, sel selections, installErr error, pm base_iface.PackageManager) error {
+	notifications := components.NewNotificationManager(os.Stdout)
+	for installErr != nil {
+		current, retried := installer, false
+		actions := []components.NotificationAction{
+			{Key: "r", Label: "Retry failed", Run: func() error {
+				next, err := current.NewRun()
+				if err != nil {
+					return fmt.Errorf("failed to create installer: %w", err)
+				}
+				sel = sel.remaining(current.Succeeded())
+				installer, retried = next, true
+				logger.Info("Retrying...")
+				installErr = sel.install(next)
+				return nil
+			}},
+			{Key: "l", Label: "Show log", KeepOpen: true, Run: func() error {
+				fmt.Fprint(os.Stdout, current.FailureLog())
+				return nil
+			}},
+			{Key: "i", Label: "Ignore"},
+		}
+		if err := notifications.Prompt(components.NotifyError, "Installation failed", failureMessage(current, installErr), actions, os.Stdin); err != nil {
+			return err
+		}
+		if !retried {
+			rolledBack, err := offerRollback(current, pm)
+			if err != nil {
+				return err
+			}
+			if rolledBack {
+				return fmt.Errorf("%w; the changes it made were rolled back", installErr)
+			}
+			logger.Warn("Continuing despite the failed installation: %v", installErr)
+			return nil
+		}
+	}
+	notifications.Show(components.NotifySuccess, "Retry succeeded", "Everything that failed is now installed.")
+	return nil
+}
+
+// offerRollback lists what the failed runs changed and asks whether to undo it;
+// the default is to keep it, and bootstrap-cli rollback can still undo it later
+func offerRollback(installer *pipeline.Installer, pm base_iface.PackageManager) (bool, error) {
+	entries := installer.Journaled()
+	if len(entries) == 0 {
+		return false, nil
+	}
+	fmt.Fprintln(os.Stdout, "This run made these changes:")
+	uninstall.PrintRollback(os.Stdout, entries)
+	fmt.Fprint(os.Stdout, "Roll back the changes this run made? [y/N] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+		fmt.Fprintln(os.Stdout, "Keeping them; run bootstrap-cli rollback to undo them later")
+		return false, nil
+	}
+
+	journalPath, err := manifest.DefaultJournalPath()
+	if err != nil {
+		return false, err
+	}
+	statePath, err := manifest.DefaultInstalledPath()
+	if err != nil {
+		return false, err
+	}
+	u := &uninstall.Uninstaller{
+		PackageManager: pm,
+		RCWriter:       shell.NewRCWriter(),
+		StatePath:      statePath,
+		Logger:         logger,
+	}
+	if err := u.Rollback(journalPath); err != nil {
+		return false, fmt.Errorf("rollback incomplete: %w; run bootstrap-cli rollback to try again", err)
+	}
+	logger.Success("Rolled back the changes of the failed run")
+	return true, nil
+}
+
+// failureMessage names the items that failed in the last run
+func failureMessage(installer *pipeline.Installer, err error) string {
+	var failed []string
+	if installer.Pipeline != nil {
+		for _, group := range installer.Pipeline.Summary().Groups() {
+			failed = append(failed, group.Failed...)
+		}
+	}
+	if len(failed) == 0 {
+		return err.Error()
+	}
+	return fmt.Sprintf("Failed: %s. Retrying also installs the selections the run did not reach.", strings.Join(failed, ", "))
+}
+
+// cleanOrphanedBlocks offers to remove the rc blocks of tools an upgrade
+// dropped from the catalog; without a terminal it only points at migrate
+func cleanOrphanedBlocks(tools []*pipeline.Tool, yes bool) error {
+	home, err := system.UserHome()
+	if err != nil {
+		return err
+	}
+	orphans, err := shell.FindOrphanedBlocks(home, pipeline.ToolNames(tools))
+	if err != nil || len(orphans) == 0 {
+		return err
+	}
+	if !canPrompt(yes) {
+		logger.Warn("%d shell rc block(s) belong to tools no longer in the catalog; run bootstrap-cli migrate to remove them", len(orphans))
+		return nil
+	}
+	if !shell.PromptRemoveOrphanedBlocks(orphans, os.Stdin, os.Stdout) {
+		return nil
+	}
+	if err := shell.RemoveOrphanedBlocks(shell.NewRCWriter(), orphans); err != nil {
+		return err
+	}
+	logger.Info("Removed %d orphaned rc block(s)", len(orphans))
+	return nil
+}
+
+// canPrompt reports whether questions can be asked on stdin: it is a terminal
+// and --yes was not given
+func canPrompt(yes bool) bool {
+	return !yes && ui.StdinIsTerminal()
+}
+
 // Placeholder adapter - NEEDS REAL IMPLEMENTATION and matching interfaces defined
 // Adapter implementation to bridge interfaces.PackageManager and pipeline.PackageManager
 type packageManagerAdapter struct {
@@ -178,5 +830,6 @@ func (a *packageManagerAdapter) GetName() string {
 	// Now call the method from the base interface
 	return a.impl.GetName() 
 }
+func (a *packageManagerAdapter) GetVersion(pkg string) (string, error) { return a.impl.GetVersion(pkg) }
 
-// mapUIToolToPipelineTool removed as we now load pipeline.Tool directly via configLoader 
\ No newline at end of file
+// mapUIToolToPipelineTool removed as we now load pipeline.Tool directly via configLoader 
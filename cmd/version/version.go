@@ -0,0 +1,73 @@
+// Package version provides the version command for reporting which build is running
+package version
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/version"
+	"github.com/spf13/cobra"
+)
+
+// NewVersionCmd creates the version command
+func NewVersionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Show the bootstrap-cli version and the versions of its components",
+		Long: `Print the CLI version, the commit and date it was built from, the
+version of the embedded default configs, and the Go version and platform.
+Include this output in bug reports.
+
+With --json the report is printed for scripts; --short prints only the
+CLI version.`,
+		Args: cobra.NoArgs,
+		RunE: runVersion,
+	}
+	cmd.Flags().Bool("json", false, "Print the version report as JSON")
+	cmd.Flags().Bool("short", false, "Print only the CLI version")
+	return cmd
+}
+
+// Current returns the running build's information, embedded configs included
+func Current() version.Info {
+	info := version.Get()
+	info.ConfigVersion = config.EmbeddedConfigVersion()
+	return info
+}
+
+func runVersion(cmd *cobra.Command, _ []string) error {
+	info := Current()
+	out := cmd.OutOrStdout()
+	if short, _ := cmd.Flags().GetBool("short"); short {
+		fmt.Fprintln(out, info.Version)
+		return nil
+	}
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	}
+	printVersion(out, info)
+	return nil
+}
+
+// printVersion renders the report with one component per line
+func printVersion(out io.Writer, info version.Info) {
+	orUnknown := func(s string) string {
+		if s == "" {
+			return "unknown"
+		}
+		return s
+	}
+	fmt.Fprintf(out, "bootstrap-cli %s\n", info.Version)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  commit:\t%s\n", orUnknown(info.Commit))
+	fmt.Fprintf(w, "  built:\t%s\n", orUnknown(info.Date))
+	fmt.Fprintf(w, "  configs:\t%s\n", orUnknown(info.ConfigVersion))
+	fmt.Fprintf(w, "  go:\t%s\n", info.GoVersion)
+	fmt.Fprintf(w, "  platform:\t%s\n", info.Platform)
+	w.Flush()
+}
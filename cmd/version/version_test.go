@@ -0,0 +1,47 @@
+package version
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/version"
+)
+
+func TestVersionCmd(t *testing.T) {
+	orig := version.Version
+	version.Version = "v1.2.3"
+	t.Cleanup(func() { version.Version = orig })
+
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := NewVersionCmd()
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("version %v error = %v", args, err)
+		}
+		return out.String()
+	}
+
+	if got := run("--short"); got != "v1.2.3\n" {
+		t.Errorf("version --short = %q", got)
+	}
+
+	report := run()
+	for _, want := range []string{"bootstrap-cli v1.2.3", "configs:", "go:", "platform:"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("version output missing %q:\n%s", want, report)
+		}
+	}
+
+	var info version.Info
+	if err := json.Unmarshal([]byte(run("--json")), &info); err != nil {
+		t.Fatalf("version --json is not JSON: %v", err)
+	}
+	if info.Version != "v1.2.3" || info.ConfigVersion == "" || info.GoVersion == "" {
+		t.Errorf("unexpected version report %+v", info)
+	}
+}
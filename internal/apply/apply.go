@@ -0,0 +1,365 @@
+// Package apply converges a machine to the state described in a declarative
+// bootstrap.yaml: it works out which tools, languages, fonts, shell, prompt,
+// plugin manager and dotfiles are missing (and optionally which recorded tools to remove) so that re-running
+// against an unchanged file does nothing.
+package apply
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/audit"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+)
+
+// DefaultSpecFile is the file apply reads when none is given
+const DefaultSpecFile = "bootstrap.yaml"
+
+// Spec is the desired state of a machine
+type Spec struct {
+	Tools []string `yaml:"tools,omitempty"`
+	// Languages are language names, optionally pinned to a version as Name@version
+	Languages []string `yaml:"languages,omitempty"`
+	Fonts     []string `yaml:"fonts,omitempty"`
+	// Shell is the login shell to configure (e.g. zsh)
+	Shell string `yaml:"shell,omitempty"`
+	// Prompt is the prompt style whose default config is written (see shell.PromptStyles)
+	Prompt string `yaml:"prompt,omitempty"`
+	// PluginManager is the shell framework to install (e.g. oh-my-zsh)
+	PluginManager string `yaml:"plugin_manager,omitempty"`
+	// Dotfiles is a git repository cloned into ~/.dotfiles
+	Dotfiles string `yaml:"dotfiles,omitempty"`
+}
+
+// LoadSpec reads the spec file at path
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var spec Spec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &spec, nil
+}
+
+// Plan is what has to change for the machine to match a spec
+type Plan struct {
+	Install   []*pipeline.Tool
+	Remove    []*pipeline.Tool
+	Languages []*interfaces.Language
+	Fonts     []*interfaces.Font
+	Shell     *interfaces.Shell
+	// PromptStyle is the prompt whose config is missing; it is written with Shell
+	PromptStyle string
+	// PluginManager is the shell framework to install
+	PluginManager *interfaces.ShellFramework
+	Dotfiles      string
+}
+
+// Converged reports whether there is nothing to do
+func (p *Plan) Converged() bool {
+	return len(p.Install) == 0 && len(p.Remove) == 0 && len(p.Languages) == 0 &&
+		len(p.Fonts) == 0 && p.Shell == nil && p.PromptStyle == "" && p.PluginManager == nil && p.Dotfiles == ""
+}
+
+// Print writes the plan to w
+func (p *Plan) Print(w io.Writer) {
+	if p.Converged() {
+		fmt.Fprintln(w, "Already converged: nothing to do")
+		return
+	}
+	for _, tool := range p.Install {
+		fmt.Fprintf(w, "  + tool %s\n", tool.Name)
+	}
+	for _, lang := range p.Languages {
+		if lang.Version != "" {
+			fmt.Fprintf(w, "  + language %s@%s\n", lang.Name, lang.Version)
+			continue
+		}
+		fmt.Fprintf(w, "  + language %s\n", lang.Name)
+	}
+	for _, font := range p.Fonts {
+		fmt.Fprintf(w, "  + font %s\n", font.Name)
+	}
+	if p.Shell != nil {
+		fmt.Fprintf(w, "  ~ shell %s\n", p.Shell.Name)
+	}
+	if p.PromptStyle != "" {
+		fmt.Fprintf(w, "  + prompt %s\n", p.PromptStyle)
+	}
+	if p.PluginManager != nil {
+		fmt.Fprintf(w, "  + plugin manager %s\n", p.PluginManager.Name)
+	}
+	if p.Dotfiles != "" {
+		fmt.Fprintf(w, "  + dotfiles %s\n", p.Dotfiles)
+	}
+	for _, tool := range p.Remove {
+		fmt.Fprintf(w, "  - tool %s\n", tool.Name)
+	}
+}
+
+// Planner compares a spec with the current state of the machine
+type Planner struct {
+	// LookPath resolves a binary on PATH (defaults to exec.LookPath)
+	LookPath func(string) (string, error)
+	// Verify runs a verify command, returning nil when it succeeds (defaults to sh -c)
+	Verify func(command string) error
+	// CurrentShell is the user's login shell (defaults to $SHELL)
+	CurrentShell string
+	// DotfilesDir is where dotfiles are cloned (defaults to ~/.dotfiles)
+	DotfilesDir string
+	// Prune removes tools recorded in the manifest that the spec no longer lists
+	Prune bool
+	// Home is where prompt configs are looked for (defaults to the user's home)
+	Home string
+	// FrameworkInstalled reports whether a shell framework is installed
+	// (defaults to the frameworks bootstrap-cli recorded)
+	FrameworkInstalled func(name string) bool
+}
+
+// InstallPluginManager installs the plan's shell framework, if it has one
+func (p *Plan) InstallPluginManager() error {
+	if p.PluginManager == nil {
+		return nil
+	}
+	installer, err := shell.NewFrameworkInstaller()
+	if err != nil {
+		return err
+	}
+	if err := installer.Install(p.PluginManager); err != nil {
+		return fmt.Errorf("failed to install %s: %w", p.PluginManager.Name, err)
+	}
+	return nil
+}
+
+// Plan works out what has to change for the machine to match spec. Every name
+// in spec must exist in the catalog.
+func (p *Planner) Plan(spec *Spec, catalog *config.Catalog, m *manifest.Manifest) (*Plan, error) {
+	lookPath := p.LookPath
+	if lookPath == nil {
+		lookPath = exec.LookPath
+	}
+	verify := p.Verify
+	if verify == nil {
+		verify = runVerify
+	}
+
+	plan := &Plan{}
+	var unknown []string
+	wanted := make(map[string]bool)
+
+	var tools []*pipeline.Tool
+	for _, name := range spec.Tools {
+		tool := pipeline.FindTool(catalog.Tools, name)
+		if tool == nil {
+			unknown = append(unknown, "tool "+name)
+			continue
+		}
+		tools = append(tools, tool)
+	}
+	// Groups are planned member by member so an installed member is not reinstalled
+	tools, _, err := pipeline.ExpandGroups(tools, catalog.Tools)
+	if err != nil {
+		return nil, err
+	}
+	// A spec is not interactive, so conflicting tools cannot be narrowed down here
+	if conflicts := pipeline.FindToolConflicts(tools); len(conflicts) > 0 {
+		return nil, &pipeline.ToolConflictError{Conflicts: conflicts}
+	}
+	for _, tool := range tools {
+		wanted[tool.Name] = true
+		if _, err := audit.FindBinary(lookPath, tool); err != nil {
+			plan.Install = append(plan.Install, tool)
+		}
+	}
+
+	for _, entry := range spec.Languages {
+		name, version, _ := strings.Cut(entry, "@")
+		lang := findLanguage(catalog.Languages, name)
+		if lang == nil {
+			unknown = append(unknown, "language "+name)
+			continue
+		}
+		if version != "" {
+			pinned := *lang
+			pinned.Version = version
+			lang = &pinned
+		}
+		if lang.VerifyCommand == "" || verify(lang.VerifyCommand) != nil {
+			plan.Languages = append(plan.Languages, lang)
+		}
+	}
+
+	for _, name := range spec.Fonts {
+		font := findFont(catalog.Fonts, name)
+		if font == nil {
+			unknown = append(unknown, "font "+name)
+			continue
+		}
+		if !fontInstalled(font, verify) {
+			plan.Fonts = append(plan.Fonts, font)
+		}
+	}
+
+	var sh *interfaces.Shell
+	if spec.Shell != "" {
+		sh = findShell(catalog.Shells, spec.Shell)
+		if sh == nil {
+			unknown = append(unknown, "shell "+spec.Shell)
+		} else if filepath.Base(p.currentShell()) != sh.Name {
+			plan.Shell = sh
+		}
+	}
+
+	var invalid []string
+	if spec.Prompt != "" {
+		if err := shell.ValidatePromptStyle(spec.Prompt, spec.Shell); err != nil {
+			invalid = append(invalid, err.Error())
+		} else if spec.Shell == "" {
+			invalid = append(invalid, fmt.Sprintf("prompt %s needs a shell to be configured in", spec.Prompt))
+		} else if sh != nil && !fileExists(shell.PromptConfigPath(p.home(), spec.Prompt)) {
+			// The prompt is written while the shell is configured, even one already in use
+			plan.PromptStyle = spec.Prompt
+			plan.Shell = sh
+		}
+	}
+	if spec.PluginManager != "" {
+		var shells []string
+		if spec.Shell != "" {
+			shells = []string{spec.Shell}
+		}
+		if err := shell.ValidateShells(shells, spec.PluginManager); err != nil {
+			invalid = append(invalid, err.Error())
+		} else if !p.frameworkInstalled(spec.PluginManager) {
+			plan.PluginManager = &interfaces.ShellFramework{Name: spec.PluginManager}
+		}
+	}
+
+	if len(unknown) > 0 {
+		invalid = append([]string{"not in the catalog: " + strings.Join(unknown, ", ")}, invalid...)
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(invalid, "; "))
+	}
+
+	if spec.Dotfiles != "" && !dirExists(p.DotfilesDir) {
+		plan.Dotfiles = spec.Dotfiles
+	}
+
+	if p.Prune && m != nil {
+		recorded, _ := audit.ExpectedTools(m, catalog.Tools)
+		for _, tool := range recorded {
+			if wanted[tool.Name] {
+				continue
+			}
+			if _, err := audit.FindBinary(lookPath, tool); err == nil {
+				plan.Remove = append(plan.Remove, tool)
+			}
+		}
+	}
+	return plan, nil
+}
+
+// currentShell returns the shell to compare the spec's shell with
+func (p *Planner) currentShell() string {
+	if p.CurrentShell != "" {
+		return p.CurrentShell
+	}
+	return os.Getenv("SHELL")
+}
+
+// home returns the directory prompt configs are looked for under
+func (p *Planner) home() string {
+	if p.Home != "" {
+		return p.Home
+	}
+	home, _ := system.UserHome()
+	return home
+}
+
+// frameworkInstalled reports whether the shell framework name is installed
+func (p *Planner) frameworkInstalled(name string) bool {
+	if p.FrameworkInstalled != nil {
+		return p.FrameworkInstalled(name)
+	}
+	installer, err := shell.NewFrameworkInstaller()
+	if err != nil {
+		return false
+	}
+	_, err = installer.Installed(name)
+	return err == nil
+}
+
+// fontInstalled reports whether every verify command for the font succeeds
+func fontInstalled(font *interfaces.Font, verify func(string) error) bool {
+	commands := font.GetVerifyCommands()
+	if len(commands) == 0 {
+		return false
+	}
+	for _, cmd := range commands {
+		if verify(cmd) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// dirExists reports whether path is an existing directory
+func dirExists(path string) bool {
+	if path == "" {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// fileExists reports whether path exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// runVerify runs command through the shell, discarding its output
+func runVerify(command string) error {
+	return exec.Command("sh", "-c", command).Run()
+}
+
+func findLanguage(langs []*interfaces.Language, name string) *interfaces.Language {
+	for _, lang := range langs {
+		if strings.EqualFold(lang.Name, name) {
+			return lang
+		}
+	}
+	return nil
+}
+
+func findFont(fonts []*interfaces.Font, name string) *interfaces.Font {
+	for _, font := range fonts {
+		if strings.EqualFold(font.Name, name) {
+			return font
+		}
+	}
+	return nil
+}
+
+func findShell(shells []*interfaces.Shell, name string) *interfaces.Shell {
+	for _, sh := range shells {
+		if strings.EqualFold(sh.Name, name) {
+			return sh
+		}
+	}
+	return nil
+}
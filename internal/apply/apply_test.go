@@ -0,0 +1,205 @@
+package apply
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+)
+
+func testCatalog() *config.Catalog {
+	ripgrep := pipeline.NewTool("ripgrep", pipeline.CategoryDevelopment)
+	ripgrep.Aliases = []string{"rg"}
+	bat := pipeline.NewTool("bat", pipeline.CategoryDevelopment)
+	fzf := pipeline.NewTool("fzf", pipeline.CategoryDevelopment)
+	return &config.Catalog{
+		Tools:     []*pipeline.Tool{ripgrep, bat, fzf},
+		Languages: []*interfaces.Language{{Name: "Go", VerifyCommand: "go version"}},
+		Shells:    []*interfaces.Shell{{Name: "zsh"}},
+	}
+}
+
+func stubPlanner(binaries ...string) *Planner {
+	return &Planner{
+		LookPath: func(file string) (string, error) {
+			for _, b := range binaries {
+				if b == file {
+					return "/usr/bin/" + file, nil
+				}
+			}
+			return "", fmt.Errorf("%s not found", file)
+		},
+		Verify: func(command string) error {
+			for _, b := range binaries {
+				if strings.HasPrefix(command, b+" ") {
+					return nil
+				}
+			}
+			return fmt.Errorf("%s failed", command)
+		},
+		CurrentShell: "/bin/bash",
+	}
+}
+
+func TestPlanner_Plan(t *testing.T) {
+	spec := &Spec{Tools: []string{"rg", "bat"}, Languages: []string{"go"}, Shell: "zsh"}
+
+	plan, err := stubPlanner("rg").Plan(spec, testCatalog(), nil)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(plan.Install) != 1 || plan.Install[0].Name != "bat" {
+		t.Errorf("Expected only bat to be installed, got %v", plan.Install)
+	}
+	if len(plan.Languages) != 1 || plan.Shell == nil || plan.Shell.Name != "zsh" {
+		t.Errorf("Expected Go and the zsh shell in the plan, got %+v", plan)
+	}
+
+	planner := stubPlanner("rg", "bat", "go")
+	planner.CurrentShell = "/usr/bin/zsh"
+	plan, err = planner.Plan(spec, testCatalog(), nil)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if !plan.Converged() {
+		t.Errorf("Expected a converged plan, got %+v", plan)
+	}
+}
+
+func TestPlanner_Prune(t *testing.T) {
+	m := &manifest.Manifest{Runs: []manifest.Run{{Tools: []string{"ripgrep", "bat", "fzf"}}}}
+	spec := &Spec{Tools: []string{"ripgrep"}}
+
+	planner := stubPlanner("rg", "bat")
+	plan, err := planner.Plan(spec, testCatalog(), m)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(plan.Remove) != 0 {
+		t.Errorf("Expected nothing to be removed without Prune, got %v", plan.Remove)
+	}
+
+	planner.Prune = true
+	plan, err = planner.Plan(spec, testCatalog(), m)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	// fzf is recorded but already gone, so only bat is removed
+	if len(plan.Remove) != 1 || plan.Remove[0].Name != "bat" {
+		t.Errorf("Expected only bat to be removed, got %v", plan.Remove)
+	}
+}
+
+func TestPlanner_UnknownNames(t *testing.T) {
+	spec := &Spec{Tools: []string{"nope"}, Shell: "tcsh"}
+	_, err := stubPlanner().Plan(spec, testCatalog(), nil)
+	if err == nil || !strings.Contains(err.Error(), "tool nope") || !strings.Contains(err.Error(), "shell tcsh") {
+		t.Errorf("Expected unknown names to be reported, got %v", err)
+	}
+}
+
+func TestLoadSpec(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultSpecFile)
+	data := []byte("tools: [ripgrep, bat]\nlanguages: [Go]\nshell: zsh\ndotfiles: https://github.com/me/dotfiles.git\n")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+	spec, err := LoadSpec(path)
+	if err != nil {
+		t.Fatalf("LoadSpec() error = %v", err)
+	}
+	if len(spec.Tools) != 2 || spec.Shell != "zsh" || spec.Dotfiles == "" {
+		t.Errorf("Unexpected spec: %+v", spec)
+	}
+}
+
+func TestPlanner_PlanExpandsGroups(t *testing.T) {
+	catalog := testCatalog()
+	suite := pipeline.NewTool("search-suite", pipeline.CategoryDevelopment)
+	suite.Group = []string{"rg", "fzf"}
+	catalog.Tools = append(catalog.Tools, suite)
+
+	plan, err := stubPlanner("rg").Plan(&Spec{Tools: []string{"search-suite", "fzf"}}, catalog, nil)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(plan.Install) != 1 || plan.Install[0].Name != "fzf" {
+		t.Errorf("Expected only the missing member fzf to be installed, got %v", plan.Install)
+	}
+}
+
+func TestPlanner_PlanRejectsConflicts(t *testing.T) {
+	catalog := testCatalog()
+	lsd := pipeline.NewTool("lsd", pipeline.CategoryDevelopment)
+	lsd.Conflicts = []string{"bat"}
+	catalog.Tools = append(catalog.Tools, lsd)
+
+	_, err := stubPlanner().Plan(&Spec{Tools: []string{"bat", "lsd"}}, catalog, nil)
+	var conflictErr *pipeline.ToolConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("Expected a ToolConflictError, got %v", err)
+	}
+}
+
+func TestPlanner_PlanPromptAndPluginManager(t *testing.T) {
+	spec := &Spec{Shell: "zsh", Prompt: "starship", PluginManager: "oh-my-zsh", Languages: []string{"Go@1.22.3"}}
+	planner := stubPlanner()
+	planner.CurrentShell = "/usr/bin/zsh"
+	planner.Home = t.TempDir()
+	planner.FrameworkInstalled = func(string) bool { return false }
+
+	plan, err := planner.Plan(spec, testCatalog(), nil)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	// The shell is already the login shell, but the prompt is written while configuring it
+	if plan.PromptStyle != "starship" || plan.Shell == nil {
+		t.Errorf("Expected the starship prompt with the zsh shell, got %+v", plan)
+	}
+	if plan.PluginManager == nil || plan.PluginManager.Name != "oh-my-zsh" {
+		t.Errorf("Expected oh-my-zsh to be installed, got %+v", plan.PluginManager)
+	}
+	if len(plan.Languages) != 1 || plan.Languages[0].Version != "1.22.3" {
+		t.Errorf("Expected Go pinned to 1.22.3, got %+v", plan.Languages)
+	}
+	if catalog := testCatalog(); catalog.Languages[0].Version != "" {
+		t.Error("Expected pinning a version to leave the catalog alone")
+	}
+
+	config := filepath.Join(planner.Home, ".config", "starship.toml")
+	if err := os.MkdirAll(filepath.Dir(config), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(config, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	planner.FrameworkInstalled = func(string) bool { return true }
+	planner.Verify = func(string) error { return nil }
+	plan, err = planner.Plan(spec, testCatalog(), nil)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if !plan.Converged() {
+		t.Errorf("Expected a converged plan, got %+v", plan)
+	}
+}
+
+func TestPlanner_PlanInvalidPromptAndPluginManager(t *testing.T) {
+	spec := &Spec{Tools: []string{"nope"}, Shell: "zsh", Prompt: "spaceship", PluginManager: "bash-it"}
+	_, err := stubPlanner().Plan(spec, testCatalog(), nil)
+	if err == nil {
+		t.Fatal("Expected an invalid spec to be an error")
+	}
+	for _, want := range []string{"tool nope", "spaceship", "bash-it"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in the error, got %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package apply
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/version"
+)
+
+// Result is the machine-readable outcome of an apply run. apply --result-file
+// writes it so a caller that started the run elsewhere (apply --target) can
+// report what happened.
+type Result struct {
+	// Host is filled in by the caller for remote runs
+	Host       string `json:"host,omitempty"`
+	CLIVersion string `json:"cli_version"`
+	// Converged reports that the machine already matched the spec
+	Converged bool                     `json:"converged"`
+	Groups    []*pipeline.GroupSummary `json:"groups,omitempty"`
+	Removed   []string                 `json:"removed,omitempty"`
+	Error     string                   `json:"error,omitempty"`
+}
+
+// NewResult creates an empty result stamped with this CLI's version
+func NewResult() *Result {
+	return &Result{CLIVersion: version.Version}
+}
+
+// Failed reports whether the run failed or any item in it did
+func (r *Result) Failed() bool {
+	if r.Error != "" {
+		return true
+	}
+	for _, g := range r.Groups {
+		if len(g.Failed) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Print writes the result as summary lines to w
+func (r *Result) Print(w io.Writer) {
+	prefix := ""
+	if r.Host != "" {
+		prefix = r.Host + ": "
+	}
+	for _, g := range r.Groups {
+		fmt.Fprintf(w, "%s%s\n", prefix, g.String())
+	}
+	if len(r.Removed) > 0 {
+		fmt.Fprintf(w, "%sRemoved: %s\n", prefix, strings.Join(r.Removed, ", "))
+	}
+	switch {
+	case r.Error != "":
+		fmt.Fprintf(w, "%sfailed: %s\n", prefix, r.Error)
+	case r.Converged:
+		fmt.Fprintf(w, "%salready converged\n", prefix)
+	}
+}
+
+// WriteFile writes the result as JSON to path
+func (r *Result) WriteFile(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode apply result: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write apply result: %w", err)
+	}
+	return nil
+}
+
+// ParseResult decodes a result written by WriteFile
+func ParseResult(data []byte) (*Result, error) {
+	var r Result
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse apply result: %w", err)
+	}
+	return &r, nil
+}
@@ -0,0 +1,259 @@
+// Package audit reports everything bootstrap-cli has touched on this machine:
+// marker blocks in shell rc files, installed catalog tools, framework
+// directories and the run history recorded in the manifest.
+package audit
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+)
+
+// frameworkDirs are directories created by frameworks and version managers bootstrap-cli installs,
+// relative to the home directory
+var frameworkDirs = []string{
+	".oh-my-zsh",
+	".bash_it",
+	".nvm",
+	".pyenv",
+	".goenv",
+	".asdf",
+	".local/share/mise",
+	".gvm",
+	".rustup",
+	".cargo",
+	".zsh",
+	".dotfiles",
+}
+
+// RCFileReport lists the bootstrap-cli markers found in an rc file
+type RCFileReport struct {
+	Path    string
+	Markers []shell.MarkerLine
+	Blocks  []shell.ManagedBlock
+}
+
+// ToolReport describes whether a catalog tool is present on the system
+type ToolReport struct {
+	Name      string
+	Category  string
+	Path      string
+	Installed bool
+}
+
+// Report is everything bootstrap-cli has modified on this machine
+type Report struct {
+	RCFiles []RCFileReport
+	// Orphans are rc blocks of tools that are no longer in the catalog
+	Orphans []shell.OrphanedBlock
+	Tools   []ToolReport
+	// Uncatalogued are tools bootstrap-cli manages that the catalog no longer has
+	Uncatalogued []string
+	Frameworks   []string
+	History      []manifest.Run
+}
+
+// Auditor builds audit reports
+type Auditor struct {
+	// HomeDir is the home directory to scan
+	HomeDir string
+	// ManifestPath is the location of the run manifest
+	ManifestPath string
+	// InstalledPath is the installed.json snapshot; empty skips it
+	InstalledPath string
+	// LookPath resolves a binary on PATH (defaults to exec.LookPath)
+	LookPath func(string) (string, error)
+}
+
+// NewAuditor creates an auditor for the current user
+func NewAuditor() (*Auditor, error) {
+	home, err := system.UserHome()
+	if err != nil {
+		return nil, err
+	}
+	manifestPath, err := manifest.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	installedPath, err := manifest.DefaultInstalledPath()
+	if err != nil {
+		return nil, err
+	}
+	return &Auditor{
+		HomeDir:       home,
+		ManifestPath:  manifestPath,
+		InstalledPath: installedPath,
+		LookPath:      exec.LookPath,
+	}, nil
+}
+
+// Run builds a report covering the given catalog tools
+func (a *Auditor) Run(tools []*pipeline.Tool) (*Report, error) {
+	report := &Report{}
+
+	for _, rc := range shell.RCFiles(a.HomeDir) {
+		markers, err := shell.ScanMarkers(rc)
+		if err != nil {
+			return nil, err
+		}
+		if len(markers) == 0 {
+			continue
+		}
+		blocks, err := shell.ListManagedBlocks(rc)
+		if err != nil {
+			return nil, err
+		}
+		report.RCFiles = append(report.RCFiles, RCFileReport{Path: rc, Markers: markers, Blocks: blocks})
+	}
+	orphans, err := shell.FindOrphanedBlocks(a.HomeDir, pipeline.ToolNames(tools))
+	if err != nil {
+		return nil, err
+	}
+	report.Orphans = orphans
+
+	lookPath := a.LookPath
+	if lookPath == nil {
+		lookPath = exec.LookPath
+	}
+	for _, tool := range tools {
+		tr := ToolReport{Name: tool.Name, Category: string(tool.Category)}
+		if path, err := FindBinary(lookPath, tool); err == nil {
+			tr.Path = path
+			tr.Installed = true
+		}
+		report.Tools = append(report.Tools, tr)
+	}
+
+	for _, dir := range frameworkDirs {
+		path := filepath.Join(a.HomeDir, dir)
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			report.Frameworks = append(report.Frameworks, path)
+		}
+	}
+
+	if a.InstalledPath != "" {
+		installed, err := manifest.LoadInstalled(a.InstalledPath)
+		if err != nil {
+			return nil, err
+		}
+		for name := range installed.Tools {
+			if pipeline.FindTool(tools, name) == nil {
+				report.Uncatalogued = append(report.Uncatalogued, name)
+			}
+		}
+		sort.Strings(report.Uncatalogued)
+	}
+
+	m, err := manifest.Load(a.ManifestPath)
+	if err != nil {
+		return nil, err
+	}
+	report.History = m.Runs
+
+	return report, nil
+}
+
+// toolBinary returns the binary name used to detect a tool
+func toolBinary(tool *pipeline.Tool) string {
+	if len(tool.Verify.BinaryPaths) > 0 {
+		return tool.Verify.BinaryPaths[0]
+	}
+	return strings.ToLower(tool.Name)
+}
+
+// FindBinary resolves the tool's binary on PATH, trying its aliases when the primary
+// binary name is missing (e.g. "rg" for ripgrep, "fdfind" for fd on Debian)
+func FindBinary(lookPath func(string) (string, error), tool *pipeline.Tool) (string, error) {
+	path, err := lookPath(toolBinary(tool))
+	if err == nil {
+		return path, nil
+	}
+	for _, alias := range tool.Aliases {
+		if p, aliasErr := lookPath(alias); aliasErr == nil {
+			return p, nil
+		}
+	}
+	return "", err
+}
+
+// Print writes a human-readable report to w
+func (r *Report) Print(w io.Writer) {
+	fmt.Fprintln(w, "Shell configuration:")
+	if len(r.RCFiles) == 0 {
+		fmt.Fprintln(w, "  No bootstrap-cli blocks found")
+	}
+	for _, rc := range r.RCFiles {
+		fmt.Fprintf(w, "  %s (%d markers, %d blocks)\n", rc.Path, len(rc.Markers), len(rc.Blocks))
+		for _, b := range rc.Blocks {
+			note := ""
+			if b.Legacy {
+				note = " (legacy)"
+			} else if b.Unterminated {
+				note = " (unterminated)"
+			}
+			fmt.Fprintf(w, "    lines %d-%d: %s%s\n", b.StartLine, b.EndLine, b.Key, note)
+		}
+	}
+	if len(r.Orphans) > 0 {
+		fmt.Fprintln(w, "\nOrphaned blocks (tool no longer in the catalog; bootstrap-cli migrate removes them):")
+		for _, o := range r.Orphans {
+			fmt.Fprintf(w, "  %s\n", o)
+		}
+	}
+
+	fmt.Fprintln(w, "\nInstalled catalog tools:")
+	installed := 0
+	for _, t := range r.Tools {
+		if t.Installed {
+			installed++
+			fmt.Fprintf(w, "  %-16s %s\n", t.Name, t.Path)
+		}
+	}
+	if installed == 0 {
+		fmt.Fprintln(w, "  None")
+	}
+	if len(r.Uncatalogued) > 0 {
+		fmt.Fprintf(w, "\nManaged tools no longer in the catalog:\n  %s\n", strings.Join(r.Uncatalogued, ", "))
+	}
+
+	fmt.Fprintln(w, "\nFramework directories:")
+	if len(r.Frameworks) == 0 {
+		fmt.Fprintln(w, "  None")
+	}
+	for _, dir := range r.Frameworks {
+		fmt.Fprintf(w, "  %s\n", dir)
+	}
+
+	fmt.Fprintln(w, "\nRun history:")
+	if len(r.History) == 0 {
+		fmt.Fprintln(w, "  No recorded runs")
+	}
+	for _, run := range r.History {
+		var parts []string
+		if len(run.Tools) > 0 {
+			parts = append(parts, "tools: "+strings.Join(run.Tools, ", "))
+		}
+		if len(run.Languages) > 0 {
+			parts = append(parts, "languages: "+strings.Join(run.Languages, ", "))
+		}
+		if len(run.Fonts) > 0 {
+			parts = append(parts, "fonts: "+strings.Join(run.Fonts, ", "))
+		}
+		if run.Shell != "" {
+			parts = append(parts, "shell: "+run.Shell)
+		}
+		if run.DotfilesRepo != "" {
+			parts = append(parts, "dotfiles: "+run.DotfilesRepo)
+		}
+		fmt.Fprintf(w, "  %s  %s\n", run.Timestamp.Format("2006-01-02 15:04:05"), strings.Join(parts, "; "))
+	}
+}
@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditorRun(t *testing.T) {
+	home := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".zshrc"),
+		[]byte("# Added by bootstrap-cli\nsource ~/.zsh/bat.zsh\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".bashrc"), []byte("export A=1\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".nvm"), 0755))
+
+	manifestPath := filepath.Join(home, ".bootstrap-cli", "manifest.json")
+	require.NoError(t, manifest.Record(manifestPath, manifest.Run{Tools: []string{"bat"}, Shell: "zsh"}))
+
+	a := &Auditor{
+		HomeDir:      home,
+		ManifestPath: manifestPath,
+		LookPath: func(name string) (string, error) {
+			if name == "bat" {
+				return "/usr/bin/bat", nil
+			}
+			return "", errors.New("not found")
+		},
+	}
+
+	report, err := a.Run([]*pipeline.Tool{
+		pipeline.NewTool("bat", pipeline.CategoryShell),
+		pipeline.NewTool("fzf", pipeline.CategoryShell),
+	})
+	require.NoError(t, err)
+
+	require.Len(t, report.RCFiles, 1)
+	assert.Equal(t, filepath.Join(home, ".zshrc"), report.RCFiles[0].Path)
+	assert.Equal(t, 1, report.RCFiles[0].Markers[0].Line)
+
+	require.Len(t, report.Tools, 2)
+	assert.True(t, report.Tools[0].Installed)
+	assert.False(t, report.Tools[1].Installed)
+
+	assert.Equal(t, []string{filepath.Join(home, ".nvm")}, report.Frameworks)
+	require.Len(t, report.History, 1)
+
+	var buf bytes.Buffer
+	report.Print(&buf)
+	assert.Contains(t, buf.String(), "/usr/bin/bat")
+	assert.Contains(t, buf.String(), "tools: bat; shell: zsh")
+}
+
+func TestAuditorRunReportsOrphans(t *testing.T) {
+	home := t.TempDir()
+	zshrc := "# >>> bootstrap-cli bat >>>\nsource ~/.zsh/bat.zsh\n# <<< bootstrap-cli bat <<<\n" +
+		"# >>> bootstrap-cli exa >>>\nsource ~/.zsh/exa.zsh\n# <<< bootstrap-cli exa <<<\n"
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".zshrc"), []byte(zshrc), 0644))
+
+	installedPath := filepath.Join(home, ".bootstrap-cli", manifest.InstalledFileName)
+	require.NoError(t, manifest.UpdateInstalled(installedPath, func(s *manifest.Installed) {
+		s.Tools["bat"] = manifest.InstalledItem{}
+		s.Tools["exa"] = manifest.InstalledItem{}
+	}))
+
+	a := &Auditor{
+		HomeDir:       home,
+		ManifestPath:  filepath.Join(home, ".bootstrap-cli", "manifest.json"),
+		InstalledPath: installedPath,
+		LookPath:      func(string) (string, error) { return "", errors.New("not found") },
+	}
+	report, err := a.Run([]*pipeline.Tool{pipeline.NewTool("bat", pipeline.CategoryShell)})
+	require.NoError(t, err)
+
+	require.Len(t, report.Orphans, 1)
+	assert.Equal(t, "exa", report.Orphans[0].Tool)
+	assert.Equal(t, []string{"exa"}, report.Uncatalogued)
+
+	var buf bytes.Buffer
+	report.Print(&buf)
+	assert.Contains(t, buf.String(), "Orphaned blocks")
+	assert.Contains(t, buf.String(), "exa (lines 4-6)")
+}
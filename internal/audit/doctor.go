@@ -0,0 +1,336 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+)
+
+// Kinds of doctor checks
+const (
+	CheckTool     = "tool"
+	CheckLanguage = "language"
+	CheckShell    = "shell"
+	CheckRCFile   = "rc file"
+	CheckPath     = "PATH"
+)
+
+// DoctorCheck is the outcome of one doctor check
+type DoctorCheck struct {
+	Kind string
+	Name string
+	OK   bool
+	// Via is how a tool, language or shell was installed, e.g. apt or
+	// github_release
+	Via string
+	// Detail is the version found, or what is wrong
+	Detail string
+	// Hint says how to fix a failed check
+	Hint string
+}
+
+// Doctor re-checks what the installed snapshot records, the managed blocks of
+// the shell rc files and the PATH entries bootstrap-cli relies on
+type Doctor struct {
+	// HomeDir is the home directory to check
+	HomeDir string
+	// InstalledPath is the installed.json snapshot
+	InstalledPath string
+	// Tools and Languages are the catalog, for their verify commands and pinned versions
+	Tools     []*pipeline.Tool
+	Languages []*interfaces.Language
+	// LookPath resolves a binary on PATH (defaults to exec.LookPath)
+	LookPath func(string) (string, error)
+	// RunCommand runs a version command through the shell and returns its
+	// output (defaults to sh -c with a timeout)
+	RunCommand func(command string) (string, error)
+	// Path is the PATH to check (defaults to $PATH)
+	Path string
+}
+
+// NewDoctor creates a doctor for the current user checking against the catalog
+func NewDoctor(tools []*pipeline.Tool, languages []*interfaces.Language) (*Doctor, error) {
+	home, err := system.UserHome()
+	if err != nil {
+		return nil, err
+	}
+	installedPath, err := manifest.DefaultInstalledPath()
+	if err != nil {
+		return nil, err
+	}
+	return &Doctor{
+		HomeDir:       home,
+		InstalledPath: installedPath,
+		Tools:         tools,
+		Languages:     languages,
+		LookPath:      exec.LookPath,
+		RunCommand:    runShellCommand,
+		Path:          os.Getenv("PATH"),
+	}, nil
+}
+
+// Run performs every check: the recorded tools, languages and shells, then the
+// rc files and PATH
+func (d *Doctor) Run() ([]DoctorCheck, error) {
+	installed, err := manifest.LoadInstalled(d.InstalledPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var checks []DoctorCheck
+	for _, name := range sortedNames(installed.Tools) {
+		command, pinned := "", ""
+		if tool := pipeline.FindTool(d.Tools, name); tool != nil {
+			command, pinned = tool.Verify.Command.Command, tool.Version
+		}
+		checks = append(checks, d.checkItem(CheckTool, name, installed.Tools[name], command, pinned))
+	}
+	for _, name := range sortedNames(installed.Languages) {
+		command, pinned := "", ""
+		for _, lang := range d.Languages {
+			if lang.Name == name {
+				command, pinned = lang.VerifyCommand, lang.Version
+				break
+			}
+		}
+		checks = append(checks, d.checkItem(CheckLanguage, name, installed.Languages[name], command, pinned))
+	}
+	for _, name := range sortedNames(installed.Shells) {
+		checks = append(checks, d.checkItem(CheckShell, name, installed.Shells[name], "", ""))
+	}
+
+	rcChecks, err := d.checkRCFiles()
+	if err != nil {
+		return nil, err
+	}
+	checks = append(checks, rcChecks...)
+	return append(checks, d.checkPath()...), nil
+}
+
+// checkItem checks that a recorded item is on PATH and that its version
+// command runs and reports the pinned version, if any
+func (d *Doctor) checkItem(kind, name string, item manifest.InstalledItem, command, pinned string) DoctorCheck {
+	check := DoctorCheck{Kind: kind, Name: name, Via: item.Manager}
+	binary := item.Command
+	if binary == "" {
+		binary = name
+	}
+	lookPath := d.LookPath
+	if lookPath == nil {
+		lookPath = exec.LookPath
+	}
+	if _, err := lookPath(binary); err != nil {
+		check.Detail = fmt.Sprintf("%s not found on PATH", binary)
+		check.Hint = reinstallHint(kind)
+		return check
+	}
+
+	if command == "" {
+		command = binary + " --version"
+	}
+	run := d.RunCommand
+	if run == nil {
+		run = runShellCommand
+	}
+	output, err := run(command)
+	if err != nil {
+		check.Detail = fmt.Sprintf("%s failed: %v", command, err)
+		check.Hint = reinstallHint(kind)
+		return check
+	}
+	version, ok := pipeline.ParseVersion(output)
+	if !ok {
+		check.OK, check.Detail = true, "runs, no version reported"
+		return check
+	}
+	if pipeline.PinnedVersion(pinned) && !pipeline.VersionMatches(version, pinned) {
+		check.Detail = fmt.Sprintf("%s is installed, but %s is pinned", version, pinned)
+		check.Hint = reinstallHint(kind)
+		return check
+	}
+	check.OK, check.Detail = true, version
+	return check
+}
+
+// reinstallHint says how to reinstall a broken item of kind
+func reinstallHint(kind string) string {
+	if kind == CheckTool {
+		return "run `bootstrap-cli tools repair` to reinstall it"
+	}
+	return "open a new shell, or run `bootstrap-cli up --reinstall`"
+}
+
+// checkRCFiles checks every rc file with managed blocks: each block is closed
+// and every file it sources still exists
+func (d *Doctor) checkRCFiles() ([]DoctorCheck, error) {
+	var checks []DoctorCheck
+	for _, rc := range shell.RCFiles(d.HomeDir) {
+		blocks, err := shell.ListManagedBlocks(rc)
+		if err != nil {
+			return nil, err
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+		var problems []string
+		for _, block := range blocks {
+			if block.Unterminated {
+				problems = append(problems, fmt.Sprintf("block %s (line %d) has no end marker", block.Key, block.StartLine))
+			}
+			for _, file := range sourcedFiles(block.Body, d.HomeDir) {
+				if _, err := os.Stat(file); err != nil {
+					problems = append(problems, fmt.Sprintf("block %s sources missing %s", block.Key, file))
+				}
+			}
+		}
+		check := DoctorCheck{Kind: CheckRCFile, Name: rc, OK: len(problems) == 0}
+		if check.OK {
+			check.Detail = fmt.Sprintf("%d managed blocks", len(blocks))
+		} else {
+			check.Detail = strings.Join(problems, "; ")
+			check.Hint = "run `bootstrap-cli up --reinstall` to rewrite the blocks, or reinstall what they source"
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+// sourcedFiles returns the files the lines of a block source with "source" or
+// ".", with $HOME, ~ and variables exported earlier in the block expanded.
+// Paths using other variables or command substitution are skipped.
+func sourcedFiles(lines []string, home string) []string {
+	vars := map[string]string{"HOME": home}
+	expand := func(s string) (string, bool) {
+		known := true
+		expanded := os.Expand(s, func(name string) string {
+			v, ok := vars[name]
+			known = known && ok
+			return v
+		})
+		if strings.HasPrefix(expanded, "~/") {
+			expanded = filepath.Join(home, expanded[2:])
+		}
+		return expanded, known && !strings.ContainsAny(s, "`(")
+	}
+
+	var files []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if rest, ok := strings.CutPrefix(line, "export "); ok {
+			if name, value, ok := strings.Cut(rest, "="); ok {
+				if v, known := expand(strings.Trim(value, `"'`)); known {
+					vars[name] = v
+				}
+			}
+			continue
+		}
+		// Only the command after a guard such as [ -s "$NVM_DIR/nvm.sh" ] &&
+		if i := strings.LastIndex(line, "&&"); i >= 0 {
+			line = strings.TrimSpace(line[i+2:])
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		if cmd := strings.TrimPrefix(fields[0], `\`); cmd != "source" && cmd != "." {
+			continue
+		}
+		if file, known := expand(strings.Trim(fields[1], `"'`)); known && filepath.IsAbs(file) {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// pathDirs are the directories installs put binaries in, relative to the home
+// directory; each one that exists should be on PATH
+var pathDirs = []string{
+	filepath.Join(".local", "bin"),
+	filepath.Join(".cargo", "bin"),
+	filepath.Join("go", "bin"),
+}
+
+// checkPath checks that the binary directories that exist are on PATH
+func (d *Doctor) checkPath() []DoctorCheck {
+	onPath := make(map[string]bool)
+	for _, dir := range filepath.SplitList(d.Path) {
+		onPath[filepath.Clean(dir)] = true
+	}
+	var checks []DoctorCheck
+	for _, rel := range pathDirs {
+		dir := filepath.Join(d.HomeDir, rel)
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+		check := DoctorCheck{Kind: CheckPath, Name: dir, OK: onPath[dir]}
+		if check.OK {
+			check.Detail = "on PATH"
+		} else {
+			check.Detail = "not on PATH"
+			check.Hint = fmt.Sprintf("open a new shell, or add export PATH=\"%s:$PATH\" to your shell rc file", dir)
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// runShellCommand runs command through sh with a timeout and returns its output
+func runShellCommand(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), versionTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "sh", "-c", command).CombinedOutput()
+	return string(output), err
+}
+
+func sortedNames(items map[string]manifest.InstalledItem) []string {
+	names := make([]string, 0, len(items))
+	for name := range items {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PrintDoctorChecks writes a pass/fail table of checks to w, with the hints of
+// the failed ones below it, and returns how many failed
+func PrintDoctorChecks(w io.Writer, checks []DoctorCheck) int {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tNAME\tVIA\tRESULT\tDETAIL")
+	failed := 0
+	for _, check := range checks {
+		result := "pass"
+		if !check.OK {
+			result = "FAIL"
+			failed++
+		}
+		via := check.Via
+		if via == "" {
+			via = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", check.Kind, check.Name, via, result, check.Detail)
+	}
+	tw.Flush()
+
+	if failed > 0 {
+		fmt.Fprintln(w, "\nTo fix:")
+		for _, check := range checks {
+			if !check.OK && check.Hint != "" {
+				fmt.Fprintf(w, "  %s %s: %s\n", check.Kind, check.Name, check.Hint)
+			}
+		}
+	}
+	fmt.Fprintf(w, "\n%d passed, %d failed\n", len(checks)-failed, failed)
+	return failed
+}
@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+)
+
+func TestDoctorRun(t *testing.T) {
+	home := t.TempDir()
+	installedPath := filepath.Join(home, ".bootstrap-cli", manifest.InstalledFileName)
+	installed := manifest.NewInstalled()
+	installed.Tools["bat"] = manifest.InstalledItem{Command: "bat", Manager: manifest.ManagerGitHubRelease, Path: "/usr/bin/bat"}
+	installed.Tools["fd"] = manifest.InstalledItem{Command: "fdfind"}
+	installed.Languages["Go"] = manifest.InstalledItem{Command: "go"}
+	if err := installed.Save(installedPath); err != nil {
+		t.Fatal(err)
+	}
+
+	nvm := filepath.Join(home, ".nvm", "nvm.sh")
+	for _, dir := range []string{filepath.Dir(nvm), filepath.Join(home, ".local", "bin"), filepath.Join(home, ".cargo", "bin")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(nvm, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	rc := shell.UpsertBlock("", "nvm", "export NVM_DIR=\"$HOME/.nvm\"\n[ -s \"$NVM_DIR/nvm.sh\" ] && \\. \"$NVM_DIR/nvm.sh\"")
+	rc = shell.UpsertBlock(rc, "pyenv", "source ~/.pyenv/completions/pyenv.bash\n. \"$PYENV_ROOT/libexec/init\"")
+	if err := os.WriteFile(filepath.Join(home, ".bashrc"), []byte(rc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bat := pipeline.NewTool("bat", pipeline.CategoryDevelopment)
+	bat.Verify.Command.Command = "bat --version"
+	d := &Doctor{
+		HomeDir:       home,
+		InstalledPath: installedPath,
+		Tools:         []*pipeline.Tool{bat},
+		Languages:     []*interfaces.Language{{Name: "Go", Version: "1.22", VerifyCommand: "go version"}},
+		LookPath: func(name string) (string, error) {
+			if name == "fdfind" {
+				return "", fmt.Errorf("not found")
+			}
+			return "/usr/bin/" + name, nil
+		},
+		RunCommand: func(command string) (string, error) {
+			switch command {
+			case "bat --version":
+				return "bat 0.24.0", nil
+			case "go version":
+				return "go version go1.21.6 linux/amd64", nil
+			}
+			return "", fmt.Errorf("unexpected command %q", command)
+		},
+		Path: filepath.Join(home, ".local", "bin") + ":/usr/bin",
+	}
+	checks, err := d.Run()
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	results := make(map[string]DoctorCheck)
+	for _, check := range checks {
+		results[check.Kind+" "+filepath.Base(check.Name)] = check
+	}
+	if c := results["tool bat"]; !c.OK || c.Detail != "0.24.0" || c.Via != "github_release" {
+		t.Errorf("Expected bat to pass with its version and provenance, got %+v", c)
+	}
+	if c := results["tool fd"]; c.OK || !strings.Contains(c.Detail, "fdfind not found") {
+		t.Errorf("Expected fd to fail as missing, got %+v", c)
+	}
+	if c := results["language Go"]; c.OK || !strings.Contains(c.Detail, "1.22 is pinned") {
+		t.Errorf("Expected Go to fail on the pinned version, got %+v", c)
+	}
+	c := results["rc file .bashrc"]
+	if c.OK || !strings.Contains(c.Detail, filepath.Join(home, ".pyenv", "completions", "pyenv.bash")) || strings.Contains(c.Detail, "nvm") {
+		t.Errorf("Expected only the missing pyenv completions to be reported, got %+v", c)
+	}
+	var paths []string
+	for _, check := range checks {
+		if check.Kind == CheckPath {
+			paths = append(paths, fmt.Sprintf("%s %v", check.Name, check.OK))
+		}
+	}
+	// ~/go/bin does not exist, so it is not checked
+	if want := []string{filepath.Join(home, ".local", "bin") + " true", filepath.Join(home, ".cargo", "bin") + " false"}; strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected PATH checks %v, got %v", want, paths)
+	}
+
+	var buf bytes.Buffer
+	if failed := PrintDoctorChecks(&buf, checks); failed != 4 {
+		t.Errorf("Expected 4 failed checks, got %d:\n%s", failed, buf.String())
+	}
+	if !strings.Contains(buf.String(), "tools repair") || !strings.Contains(buf.String(), "export PATH=") {
+		t.Errorf("Expected remediation hints, got:\n%s", buf.String())
+	}
+}
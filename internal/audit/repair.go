@@ -0,0 +1,147 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+)
+
+// HealthStatus is the outcome of checking and repairing a tool
+type HealthStatus string
+
+const (
+	// Healthy means the tool's binary was found and ran
+	Healthy HealthStatus = "healthy"
+	// Repaired means the tool was broken and a reinstall fixed it
+	Repaired HealthStatus = "repaired"
+	// Unrepairable means the tool is still broken after a reinstall, or could not be reinstalled
+	Unrepairable HealthStatus = "unrepairable"
+)
+
+// versionTimeout bounds how long a tool's --version may take
+const versionTimeout = 10 * time.Second
+
+// RepairResult describes the health of one tool the manifest expects
+type RepairResult struct {
+	Tool    string
+	Status  HealthStatus
+	Problem string // What was wrong before repairing, if anything
+	Err     error  // Why the tool could not be repaired
+}
+
+// Repairer re-checks tools recorded in the manifest and reinstalls broken ones
+type Repairer struct {
+	// LookPath resolves a binary on PATH (defaults to exec.LookPath)
+	LookPath func(string) (string, error)
+	// RunVersion runs the binary with --version (defaults to executing it)
+	RunVersion func(path string) error
+	// Install reinstalls a tool
+	Install func(tool *pipeline.Tool) error
+}
+
+// ExpectedTools returns the catalog tools the manifest records as installed, and
+// the recorded names that are no longer in the catalog
+func ExpectedTools(m *manifest.Manifest, catalog []*pipeline.Tool) ([]*pipeline.Tool, []string) {
+	seen := make(map[string]bool)
+	var names []string
+	for _, run := range m.Runs {
+		for _, name := range run.Tools {
+			key := strings.ToLower(name)
+			if !seen[key] {
+				seen[key] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	var tools []*pipeline.Tool
+	var unknown []string
+	resolved := make(map[string]bool)
+	for _, name := range names {
+		if tool := pipeline.FindTool(catalog, name); tool != nil && !resolved[tool.Name] {
+			resolved[tool.Name] = true
+			tools = append(tools, tool)
+		} else if tool == nil {
+			unknown = append(unknown, name)
+		}
+	}
+	return tools, unknown
+}
+
+// Check reports what is wrong with a tool, or nil if its binary runs
+func (r *Repairer) Check(tool *pipeline.Tool) error {
+	lookPath := r.LookPath
+	if lookPath == nil {
+		lookPath = exec.LookPath
+	}
+	runVersion := r.RunVersion
+	if runVersion == nil {
+		runVersion = runVersionFlag
+	}
+
+	path, err := FindBinary(lookPath, tool)
+	if err != nil {
+		return fmt.Errorf("%s not found on PATH", toolBinary(tool))
+	}
+	if err := runVersion(path); err != nil {
+		return fmt.Errorf("%s --version failed: %w", path, err)
+	}
+	return nil
+}
+
+// Repair checks every tool and reinstalls those that are missing or failing
+func (r *Repairer) Repair(tools []*pipeline.Tool) []RepairResult {
+	results := make([]RepairResult, 0, len(tools))
+	for _, tool := range tools {
+		problem := r.Check(tool)
+		if problem == nil {
+			results = append(results, RepairResult{Tool: tool.Name, Status: Healthy})
+			continue
+		}
+
+		result := RepairResult{Tool: tool.Name, Problem: problem.Error()}
+		if r.Install == nil {
+			result.Status, result.Err = Unrepairable, fmt.Errorf("no installer available")
+		} else if err := r.Install(tool); err != nil {
+			result.Status, result.Err = Unrepairable, fmt.Errorf("reinstall failed: %w", err)
+		} else if err := r.Check(tool); err != nil {
+			result.Status, result.Err = Unrepairable, fmt.Errorf("still broken after reinstall: %w", err)
+		} else {
+			result.Status = Repaired
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// runVersionFlag runs path --version with a timeout
+func runVersionFlag(path string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), versionTimeout)
+	defer cancel()
+	return exec.CommandContext(ctx, path, "--version").Run()
+}
+
+// PrintRepairResults writes the repair outcome of each tool and a totals line to w
+func PrintRepairResults(w io.Writer, results []RepairResult) {
+	counts := make(map[HealthStatus]int)
+	for _, res := range results {
+		counts[res.Status]++
+		switch res.Status {
+		case Healthy:
+			fmt.Fprintf(w, "  %-16s healthy\n", res.Tool)
+		case Repaired:
+			fmt.Fprintf(w, "  %-16s repaired (%s)\n", res.Tool, res.Problem)
+		case Unrepairable:
+			fmt.Fprintf(w, "  %-16s unrepairable: %v\n", res.Tool, res.Err)
+		}
+	}
+	fmt.Fprintf(w, "\n%d healthy, %d repaired, %d unrepairable\n", counts[Healthy], counts[Repaired], counts[Unrepairable])
+}
@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpectedTools(t *testing.T) {
+	m := &manifest.Manifest{Runs: []manifest.Run{
+		{Tools: []string{"Git", "bat"}},
+		{Tools: []string{"bat", "exa"}},
+	}}
+	catalog := []*pipeline.Tool{
+		pipeline.NewTool("bat", pipeline.CategoryShell),
+		pipeline.NewTool("git", pipeline.CategoryEssential),
+		pipeline.NewTool("fzf", pipeline.CategoryShell),
+	}
+
+	tools, unknown := ExpectedTools(m, catalog)
+	require.Len(t, tools, 2)
+	assert.Equal(t, "git", tools[0].Name)
+	assert.Equal(t, "bat", tools[1].Name)
+	assert.Equal(t, []string{"exa"}, unknown)
+}
+
+func TestExpectedToolsResolvesAliases(t *testing.T) {
+	m := &manifest.Manifest{Runs: []manifest.Run{
+		{Tools: []string{"rg", "ripgrep", "fd-find"}},
+	}}
+	ripgrep := pipeline.NewTool("ripgrep", pipeline.CategoryShell)
+	ripgrep.Aliases = []string{"rg"}
+	fd := pipeline.NewTool("fd", pipeline.CategoryShell)
+	fd.Aliases = []string{"fd-find"}
+
+	tools, unknown := ExpectedTools(m, []*pipeline.Tool{ripgrep, fd})
+	require.Len(t, tools, 2)
+	assert.Equal(t, "fd", tools[0].Name)
+	assert.Equal(t, "ripgrep", tools[1].Name)
+	assert.Empty(t, unknown)
+}
+
+func TestCheckFallsBackToAliasBinary(t *testing.T) {
+	fd := pipeline.NewTool("fd", pipeline.CategoryShell)
+	fd.Aliases = []string{"fdfind"}
+	r := &Repairer{
+		LookPath: func(name string) (string, error) {
+			if name == "fdfind" {
+				return "/usr/bin/fdfind", nil
+			}
+			return "", errors.New("not found")
+		},
+		RunVersion: func(string) error { return nil },
+	}
+	assert.NoError(t, r.Check(fd))
+}
+
+func TestRepair(t *testing.T) {
+	onPath := map[string]bool{"bat": true, "fd": true}
+	installed := []string{}
+	r := &Repairer{
+		LookPath: func(name string) (string, error) {
+			if onPath[name] {
+				return "/usr/bin/" + name, nil
+			}
+			return "", errors.New("not found")
+		},
+		RunVersion: func(path string) error {
+			if path == "/usr/bin/fd" {
+				return errors.New("exit status 127")
+			}
+			return nil
+		},
+		Install: func(tool *pipeline.Tool) error {
+			installed = append(installed, tool.Name)
+			switch tool.Name {
+			case "fzf":
+				onPath["fzf"] = true // reinstall restores the binary
+				return nil
+			case "fd":
+				return nil // reinstall "succeeds" but the binary still fails
+			}
+			return errors.New("package not found")
+		},
+	}
+
+	results := r.Repair([]*pipeline.Tool{
+		pipeline.NewTool("bat", pipeline.CategoryShell),
+		pipeline.NewTool("fzf", pipeline.CategoryShell),
+		pipeline.NewTool("fd", pipeline.CategoryShell),
+		pipeline.NewTool("rg", pipeline.CategoryShell),
+	})
+
+	require.Len(t, results, 4)
+	assert.Equal(t, Healthy, results[0].Status)
+	assert.Equal(t, Repaired, results[1].Status)
+	assert.Contains(t, results[1].Problem, "not found on PATH")
+	assert.Equal(t, Unrepairable, results[2].Status)
+	assert.ErrorContains(t, results[2].Err, "still broken after reinstall")
+	assert.Equal(t, Unrepairable, results[3].Status)
+	assert.ErrorContains(t, results[3].Err, "reinstall failed")
+	assert.Equal(t, []string{"fzf", "fd", "rg"}, installed)
+
+	var buf bytes.Buffer
+	PrintRepairResults(&buf, results)
+	assert.Contains(t, buf.String(), "1 healthy, 1 repaired, 2 unrepairable")
+}
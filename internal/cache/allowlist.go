@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// AllowHostsEnvVar holds extra comma-separated download hosts for this run and
+// its child processes. It is set by the --allow-host flag.
+const AllowHostsEnvVar = "BOOTSTRAP_CLI_ALLOW_HOSTS"
+
+// DefaultAllowedHosts are the hosts install scripts, release archives and
+// repositories are fetched from. A "*." prefix also allows subdomains.
+var DefaultAllowedHosts = []string{
+	"github.com",
+	"api.github.com",
+	"codeload.github.com",
+	"raw.githubusercontent.com",
+	// GitHub release downloads redirect here
+	"objects.githubusercontent.com",
+	"release-assets.githubusercontent.com",
+	"git.io",
+	"starship.rs",
+	"pyenv.run",
+	"sh.rustup.rs",
+	"go.dev",
+	"dl.google.com",
+	"download.docker.com",
+}
+
+// scriptURL finds the http(s) URLs in a shell command, capturing the host
+var scriptURL = regexp.MustCompile(`(?i)\bhttps?://([^\s'"<>|;&()/:?#` + "`" + `]*)[^\s'"<>|;&()` + "`" + `]*`)
+
+// HostNotAllowedError is returned for a URL whose host is not on the allowlist
+type HostNotAllowedError struct {
+	URL  string
+	Host string
+}
+
+func (e *HostNotAllowedError) Error() string {
+	return fmt.Sprintf("download host %q is not allowed (%s); pass --allow-host %s if you trust it", e.Host, e.URL, e.Host)
+}
+
+// AllowedHosts returns the default hosts plus those in AllowHostsEnvVar
+func AllowedHosts() []string {
+	hosts := append([]string{}, DefaultAllowedHosts...)
+	for _, host := range strings.Split(os.Getenv(AllowHostsEnvVar), ",") {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// AllowHosts adds hosts to the allowlist for this process and every command it
+// runs. Hosts are bare names (example.com) or "*." wildcards, without scheme,
+// port or path.
+func AllowHosts(hosts []string) error {
+	var extra []string
+	if current := os.Getenv(AllowHostsEnvVar); current != "" {
+		extra = append(extra, current)
+	}
+	for _, host := range hosts {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host == "" || strings.ContainsAny(host, ":/@ ") || strings.Trim(strings.TrimPrefix(host, "*."), ".") == "" {
+			return fmt.Errorf("invalid --allow-host %q: give a host name such as mirror.example.com or *.example.com", host)
+		}
+		extra = append(extra, host)
+	}
+	return os.Setenv(AllowHostsEnvVar, strings.Join(extra, ","))
+}
+
+// HostAllowed reports whether host (without port) is on the allowlist
+func HostAllowed(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, allowed := range AllowedHosts() {
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckURL returns a HostNotAllowedError unless rawURL is an http(s) URL on an
+// allowlisted host
+func CheckURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid download URL %q", rawURL)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("download URL %q must use http or https", rawURL)
+	}
+	if !HostAllowed(u.Hostname()) {
+		return &HostNotAllowedError{URL: rawURL, Host: u.Hostname()}
+	}
+	return nil
+}
+
+// CheckCommand checks every http(s) URL in a shell command before it runs, so a
+// command cannot fetch (and pipe to a shell) from a host that is not allowlisted
+func CheckCommand(command string) error {
+	for _, match := range scriptURL.FindAllStringSubmatch(command, -1) {
+		// Hosts built from variables ($MIRROR) cannot be verified and are rejected
+		if !HostAllowed(match[1]) {
+			return &HostNotAllowedError{URL: match[0], Host: match[1]}
+		}
+	}
+	return nil
+}
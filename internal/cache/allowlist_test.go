@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostAllowed(t *testing.T) {
+	t.Setenv(AllowHostsEnvVar, "mirror.example.com,*.corp.example")
+
+	assert.True(t, HostAllowed("raw.githubusercontent.com"))
+	assert.True(t, HostAllowed("STARSHIP.RS"))
+	assert.True(t, HostAllowed("mirror.example.com"))
+	assert.True(t, HostAllowed("cdn.corp.example"))
+	assert.False(t, HostAllowed("corp.example"), "a wildcard only allows subdomains")
+	assert.False(t, HostAllowed("example.com"))
+	assert.False(t, HostAllowed("github.com.evil.example"))
+}
+
+func TestCheckURL(t *testing.T) {
+	t.Setenv(AllowHostsEnvVar, "")
+
+	assert.NoError(t, CheckURL("https://sh.rustup.rs"))
+	assert.NoError(t, CheckURL("https://github.com:443/starship/starship/releases"))
+	assert.Error(t, CheckURL("ftp://github.com/file"))
+	assert.Error(t, CheckURL("not a url"))
+
+	err := CheckURL("https://evil.example.com/install.sh")
+	var notAllowed *HostNotAllowedError
+	require.ErrorAs(t, err, &notAllowed)
+	assert.Equal(t, "evil.example.com", notAllowed.Host)
+	assert.Contains(t, err.Error(), "--allow-host evil.example.com")
+}
+
+func TestCheckCommand(t *testing.T) {
+	t.Setenv(AllowHostsEnvVar, "")
+
+	assert.NoError(t, CheckCommand("curl -sS https://starship.rs/install.sh | sh -s -- -y"))
+	assert.NoError(t, CheckCommand(`sh -c "$(curl -fsSL https://raw.githubusercontent.com/ohmyzsh/ohmyzsh/master/tools/install.sh)"`))
+	assert.NoError(t, CheckCommand("sudo apt-get install -y ripgrep"))
+
+	var notAllowed *HostNotAllowedError
+	require.ErrorAs(t, CheckCommand("curl https://pyenv.run | bash && curl -L https://get.example.net/x.sh | sh"), &notAllowed)
+	assert.Equal(t, "get.example.net", notAllowed.Host)
+	assert.Error(t, CheckCommand("curl -fsSL https://$MIRROR/install.sh | sh"), "hosts from variables cannot be verified")
+}
+
+func TestAllowHosts(t *testing.T) {
+	t.Setenv(AllowHostsEnvVar, "first.example.com")
+
+	require.NoError(t, AllowHosts([]string{" Mirror.Example.com ", "*.corp.example"}))
+	assert.Equal(t, "first.example.com,mirror.example.com,*.corp.example", os.Getenv(AllowHostsEnvVar))
+	assert.True(t, HostAllowed("mirror.example.com"))
+
+	for _, host := range []string{"https://example.com", "example.com/path", "example.com:8080", "*.", ""} {
+		assert.Error(t, AllowHosts([]string{host}), host)
+	}
+}
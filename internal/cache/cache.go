@@ -0,0 +1,369 @@
+// Package cache provides a content-addressed download cache for the bootstrap-cli,
+// allowing binaries and archives fetched during installation to be reused across runs.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+)
+
+// DisableEnvVar is the environment variable that disables the download cache
+// when set to a non-empty value. It is set by the --no-cache flag.
+const DisableEnvVar = "BOOTSTRAP_CLI_NO_CACHE"
+
+// SkipChecksumsEnvVar disables checksum verification of downloads when set to a
+// non-empty value. It is set by the --skip-checksums flag.
+const SkipChecksumsEnvVar = "BOOTSTRAP_CLI_SKIP_CHECKSUMS"
+
+// OfflineEnvVar makes downloads come only from the cache when set to a
+// non-empty value. It is set by the --offline flag.
+const OfflineEnvVar = "BOOTSTRAP_CLI_OFFLINE"
+
+// DirEnvVar overrides the download cache directory, e.g. with one pre-seeded by
+// `bootstrap-cli cache warm` on another machine
+const DirEnvVar = "BOOTSTRAP_CLI_CACHE_DIR"
+
+// Offline reports whether downloads must be served from the cache
+func Offline() bool {
+	return os.Getenv(OfflineEnvVar) != ""
+}
+
+// NotCachedError is returned for a download that an offline run does not have cached
+type NotCachedError struct {
+	URL string
+}
+
+func (e *NotCachedError) Error() string {
+	return fmt.Sprintf("%s is not in the download cache and downloads are disabled offline; run `bootstrap-cli cache warm` while online", e.URL)
+}
+
+// ChecksumMismatchError is returned when a download does not match its expected SHA-256
+type ChecksumMismatchError struct {
+	URL      string
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("checksum mismatch for %s: expected sha256 %s, got %s (pass --skip-checksums to use it anyway)", e.URL, e.Expected, e.Actual)
+}
+
+// Cache stores downloaded files keyed by URL and version
+type Cache struct {
+	dir      string
+	disabled bool
+	client   *http.Client
+}
+
+// New creates a cache rooted at dir
+func New(dir string) *Cache {
+	return &Cache{
+		dir:      dir,
+		disabled: os.Getenv(DisableEnvVar) != "",
+		client:   NewClient(),
+	}
+}
+
+// NewDefault creates a cache rooted at the default cache directory
+func NewDefault() (*Cache, error) {
+	dir, err := DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+	return New(dir), nil
+}
+
+// DefaultDir returns the default download cache directory, honouring
+// DirEnvVar and then XDG_CACHE_HOME when they are set.
+func DefaultDir() (string, error) {
+	if dir := os.Getenv(DirEnvVar); dir != "" {
+		return dir, nil
+	}
+	if xdg := os.Getenv("XDG_CACHE_HOME"); xdg != "" {
+		return filepath.Join(xdg, "bootstrap-cli", "downloads"), nil
+	}
+	home, err := system.UserHome()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".cache", "bootstrap-cli", "downloads"), nil
+}
+
+// Dir returns the cache directory
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Enabled reports whether cached entries are reused and stored
+func (c *Cache) Enabled() bool {
+	return !c.disabled
+}
+
+// SetEnabled enables or disables the cache
+func (c *Cache) SetEnabled(enabled bool) {
+	c.disabled = !enabled
+}
+
+// Key returns the cache key for a URL and version
+func Key(url, version string) string {
+	sum := sha256.Sum256([]byte(url + "@" + version))
+	return hex.EncodeToString(sum[:])
+}
+
+// Path returns the path of the cache entry for a URL and version
+func (c *Cache) Path(url, version string) string {
+	return filepath.Join(c.dir, Key(url, version), filepath.Base(url))
+}
+
+// Fetch places the file at url into dest, reusing a cached copy when one exists
+// and matches checksum. An empty checksum accepts whatever was recorded when the
+// entry was stored. The downloaded file is verified before it is cached.
+// SkipChecksumsEnvVar ignores checksum. Offline, the cached copy is used even
+// when the cache is disabled, and a missing one is a NotCachedError.
+func (c *Cache) Fetch(url, version, checksum, dest string) error {
+	if os.Getenv(SkipChecksumsEnvVar) != "" {
+		checksum = ""
+	}
+	offline := Offline()
+	if !c.Enabled() && !offline {
+		return c.download(url, checksum, dest)
+	}
+
+	entry := c.Path(url, version)
+	if c.valid(entry, checksum) {
+		return copyFile(entry, dest)
+	}
+	if offline {
+		return &NotCachedError{URL: url}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(entry), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := c.download(url, checksum, entry); err != nil {
+		return err
+	}
+	sum, err := fileChecksum(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(entry+".sha256", []byte(sum+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to record checksum: %w", err)
+	}
+	if err := c.record(url, version, sum); err != nil {
+		return err
+	}
+	return copyFile(entry, dest)
+}
+
+// Clear removes every cached download
+func (c *Cache) Clear() error {
+	if err := os.RemoveAll(c.dir); err != nil {
+		return fmt.Errorf("failed to clear cache: %w", err)
+	}
+	return nil
+}
+
+// valid reports whether entry exists and matches the expected or recorded checksum
+func (c *Cache) valid(entry, checksum string) bool {
+	sum, err := fileChecksum(entry)
+	if err != nil {
+		return false
+	}
+	if checksum != "" {
+		return strings.EqualFold(sum, checksum)
+	}
+	recorded, err := os.ReadFile(entry + ".sha256")
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(recorded)) == sum
+}
+
+// PartSuffix marks an incomplete download kept next to its destination so an
+// interrupted transfer can be resumed
+const PartSuffix = ".part"
+
+// validatorSuffix marks the file next to a part that records the ETag or
+// Last-Modified of the response it came from
+const validatorSuffix = ".validator"
+
+// downloadAttempts bounds how often an interrupted transfer is resumed before giving up
+const downloadAttempts = 3
+
+// errInterrupted marks a transfer that stopped part-way and can be resumed
+var errInterrupted = errors.New("download interrupted")
+
+// download fetches url into dest, trying a configured mirror before the upstream URL.
+// Interrupted transfers are resumed from where they stopped. A checksum mismatch
+// is reported over a later failure to reach the upstream URL.
+func (c *Cache) download(url, checksum, dest string) error {
+	var err error
+	var mismatch *ChecksumMismatchError
+	for _, candidate := range MirrorURLs(url) {
+		if err = CheckURL(candidate); err != nil {
+			return err
+		}
+		for attempt := 0; attempt < downloadAttempts; attempt++ {
+			if err = c.downloadFrom(candidate, checksum, dest); err == nil {
+				return nil
+			}
+			if !errors.Is(err, errInterrupted) {
+				break
+			}
+		}
+		if mismatch == nil {
+			errors.As(err, &mismatch)
+		}
+	}
+	if mismatch != nil {
+		return mismatch
+	}
+	return err
+}
+
+// downloadFrom fetches url into dest, verifying checksum when one is given. Data
+// is written to dest.part, and a part left by an earlier attempt is continued
+// with a range request. The range is conditional on the ETag or Last-Modified
+// the part was downloaded with, so a file that changed upstream, or a server
+// that ignores the range, sends the whole file again. A part without a recorded
+// validator is not resumed.
+func (c *Cache) downloadFrom(url, checksum, dest string) error {
+	part := dest + PartSuffix
+	validatorPath := part + validatorSuffix
+	var offset int64
+	if info, err := os.Stat(part); err == nil {
+		offset = info.Size()
+	}
+	validator, _ := os.ReadFile(validatorPath)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if offset > 0 && len(validator) > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", string(validator))
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		// The whole file, either because nothing could be resumed or because it
+		// changed since the part was downloaded; start over from scratch
+		flags |= os.O_TRUNC
+		os.Remove(validatorPath)
+		if v := responseValidator(resp); v != "" {
+			if err := os.WriteFile(validatorPath, []byte(v), 0644); err != nil {
+				return fmt.Errorf("failed to record download validator: %w", err)
+			}
+		}
+	case resp.StatusCode == http.StatusPartialContent && req.Header.Get("Range") != "" && rangeStart(resp) == offset:
+		flags |= os.O_APPEND
+	case offset > 0 && (resp.StatusCode == http.StatusPartialContent || resp.StatusCode == http.StatusRequestedRangeNotSatisfiable):
+		// The server cannot continue this part; start over
+		os.Remove(part)
+		os.Remove(validatorPath)
+		return fmt.Errorf("failed to resume %s: %w", url, errInterrupted)
+	default:
+		return fmt.Errorf("failed to download %s: unexpected status %s", url, resp.Status)
+	}
+
+	out, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create partial download: %w", err)
+	}
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to download %s: %w: %v", url, errInterrupted, err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to write download: %w", err)
+	}
+
+	if checksum != "" {
+		sum, err := fileChecksum(part)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(sum, checksum) {
+			// A corrupt part must not be resumed
+			os.Remove(part)
+			os.Remove(validatorPath)
+			return &ChecksumMismatchError{URL: url, Expected: strings.ToLower(checksum), Actual: sum}
+		}
+	}
+
+	if err := os.Rename(part, dest); err != nil {
+		return fmt.Errorf("failed to move download into place: %w", err)
+	}
+	os.Remove(validatorPath)
+	return nil
+}
+
+// responseValidator returns what an If-Range header can name resp's content by:
+// its strong ETag, else its Last-Modified date, or empty when it has neither
+func responseValidator(resp *http.Response) string {
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return resp.Header.Get("Last-Modified")
+}
+
+// rangeStart returns the first byte offset of a 206 response's Content-Range, or -1
+func rangeStart(resp *http.Response) int64 {
+	var start, end int64
+	var total string
+	if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-%d/%s", &start, &end, &total); err != nil {
+		return -1
+	}
+	return start
+}
+
+// fileChecksum returns the hex-encoded SHA-256 of the file at path
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", fmt.Errorf("failed to checksum %s: %w", path, err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// copyFile copies src to dst, replacing dst if it exists
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open cached file: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy cached file: %w", err)
+	}
+	return out.Close()
+}
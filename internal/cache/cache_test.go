@@ -0,0 +1,219 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, body string) (*httptest.Server, *int) {
+	t.Setenv(AllowHostsEnvVar, "127.0.0.1")
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits++
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestFetchReusesCachedEntry(t *testing.T) {
+	t.Setenv(DisableEnvVar, "")
+	srv, hits := newTestServer(t, "archive-contents")
+	c := New(t.TempDir())
+	destDir := t.TempDir()
+	url := srv.URL + "/tool.tar.gz"
+
+	require.NoError(t, c.Fetch(url, "1.0.0", "", filepath.Join(destDir, "first")))
+	require.NoError(t, c.Fetch(url, "1.0.0", "", filepath.Join(destDir, "second")))
+	assert.Equal(t, 1, *hits)
+
+	data, err := os.ReadFile(filepath.Join(destDir, "second"))
+	require.NoError(t, err)
+	assert.Equal(t, "archive-contents", string(data))
+
+	// A different version is a different entry
+	require.NoError(t, c.Fetch(url, "2.0.0", "", filepath.Join(destDir, "third")))
+	assert.Equal(t, 2, *hits)
+}
+
+func TestFetchChecksumMismatch(t *testing.T) {
+	t.Setenv(DisableEnvVar, "")
+	srv, _ := newTestServer(t, "archive-contents")
+	c := New(t.TempDir())
+	url := srv.URL + "/tool.tar.gz"
+
+	err := c.Fetch(url, "1.0.0", "deadbeef", filepath.Join(t.TempDir(), "out"))
+	var mismatch *ChecksumMismatchError
+	require.ErrorAs(t, err, &mismatch)
+	assert.Equal(t, "deadbeef", mismatch.Expected)
+	assert.Equal(t, sha256Hex("archive-contents"), mismatch.Actual)
+	assert.Contains(t, err.Error(), "--skip-checksums")
+	assert.NoFileExists(t, c.Path(url, "1.0.0"))
+
+	t.Setenv(SkipChecksumsEnvVar, "1")
+	require.NoError(t, c.Fetch(url, "1.0.0", "deadbeef", filepath.Join(t.TempDir(), "out")))
+}
+
+func TestFetchRedownloadsCorruptEntry(t *testing.T) {
+	t.Setenv(DisableEnvVar, "")
+	srv, hits := newTestServer(t, "archive-contents")
+	c := New(t.TempDir())
+	url := srv.URL + "/tool.tar.gz"
+	dest := filepath.Join(t.TempDir(), "out")
+
+	require.NoError(t, c.Fetch(url, "1.0.0", "", dest))
+	require.NoError(t, os.WriteFile(c.Path(url, "1.0.0"), []byte("corrupt"), 0644))
+	require.NoError(t, c.Fetch(url, "1.0.0", "", dest))
+	assert.Equal(t, 2, *hits)
+}
+
+func TestFetchDisabled(t *testing.T) {
+	t.Setenv(DisableEnvVar, "1")
+	srv, hits := newTestServer(t, "archive-contents")
+	c := New(t.TempDir())
+	assert.False(t, c.Enabled())
+	url := srv.URL + "/tool.tar.gz"
+	dest := filepath.Join(t.TempDir(), "out")
+
+	require.NoError(t, c.Fetch(url, "1.0.0", "", dest))
+	require.NoError(t, c.Fetch(url, "1.0.0", "", dest))
+	assert.Equal(t, 2, *hits)
+	assert.NoFileExists(t, c.Path(url, "1.0.0"))
+}
+
+func TestClear(t *testing.T) {
+	t.Setenv(DisableEnvVar, "")
+	srv, _ := newTestServer(t, "archive-contents")
+	c := New(filepath.Join(t.TempDir(), "downloads"))
+	url := srv.URL + "/tool.tar.gz"
+
+	require.NoError(t, c.Fetch(url, "1.0.0", "", filepath.Join(t.TempDir(), "out")))
+	require.NoError(t, c.Clear())
+	assert.NoDirExists(t, c.Dir())
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestFetchResumesPartialDownload(t *testing.T) {
+	t.Setenv(DisableEnvVar, "")
+	t.Setenv(AllowHostsEnvVar, "127.0.0.1")
+	const body = "archive-contents"
+	var ranges, ifRanges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		ifRanges = append(ifRanges, r.Header.Get("If-Range"))
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "tool.tar.gz", time.Time{}, strings.NewReader(body))
+	}))
+	t.Cleanup(srv.Close)
+	c := New(t.TempDir())
+	url := srv.URL + "/tool.tar.gz"
+
+	entry := c.Path(url, "1.0.0")
+	require.NoError(t, os.MkdirAll(filepath.Dir(entry), 0755))
+	require.NoError(t, os.WriteFile(entry+PartSuffix, []byte(body[:8]), 0644))
+	require.NoError(t, os.WriteFile(entry+PartSuffix+validatorSuffix, []byte(`"v1"`), 0644))
+
+	dest := filepath.Join(t.TempDir(), "out")
+	require.NoError(t, c.Fetch(url, "1.0.0", sha256Hex(body), dest))
+	assert.Equal(t, []string{"bytes=8-"}, ranges)
+	assert.Equal(t, []string{`"v1"`}, ifRanges)
+	data, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(data))
+	assert.NoFileExists(t, entry+PartSuffix)
+	assert.NoFileExists(t, entry+PartSuffix+validatorSuffix)
+}
+
+func TestFetchRestartsWhenFileChanged(t *testing.T) {
+	t.Setenv(DisableEnvVar, "")
+	t.Setenv(AllowHostsEnvVar, "127.0.0.1")
+	const body = "archive-contents-v2"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v2"`)
+		http.ServeContent(w, r, "tool.tar.gz", time.Time{}, strings.NewReader(body))
+	}))
+	t.Cleanup(srv.Close)
+	c := New(t.TempDir())
+	url := srv.URL + "/tool.tar.gz"
+
+	// The part came from an earlier version of the file
+	entry := c.Path(url, "1.0.0")
+	require.NoError(t, os.MkdirAll(filepath.Dir(entry), 0755))
+	require.NoError(t, os.WriteFile(entry+PartSuffix, []byte("ARCHIVE-"), 0644))
+	require.NoError(t, os.WriteFile(entry+PartSuffix+validatorSuffix, []byte(`"v1"`), 0644))
+
+	dest := filepath.Join(t.TempDir(), "out")
+	require.NoError(t, c.Fetch(url, "1.0.0", sha256Hex(body), dest))
+	data, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(data))
+}
+
+func TestFetchRestartsWhenRangesUnsupported(t *testing.T) {
+	t.Setenv(DisableEnvVar, "")
+	srv, _ := newTestServer(t, "archive-contents")
+	c := New(t.TempDir())
+	url := srv.URL + "/tool.tar.gz"
+
+	entry := c.Path(url, "1.0.0")
+	require.NoError(t, os.MkdirAll(filepath.Dir(entry), 0755))
+	require.NoError(t, os.WriteFile(entry+PartSuffix, []byte("stale"), 0644))
+
+	dest := filepath.Join(t.TempDir(), "out")
+	require.NoError(t, c.Fetch(url, "1.0.0", sha256Hex("archive-contents"), dest))
+	data, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, "archive-contents", string(data))
+}
+
+func TestFetchRetriesInterruptedTransfer(t *testing.T) {
+	t.Setenv(DisableEnvVar, "")
+	t.Setenv(AllowHostsEnvVar, "127.0.0.1")
+	const body = "archive-contents"
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", `"v1"`)
+		if requests == 1 {
+			// Promise the whole file but drop the connection half way
+			w.Header().Set("Content-Length", "16")
+			_, _ = w.Write([]byte(body[:8]))
+			return
+		}
+		assert.Equal(t, "bytes=8-", r.Header.Get("Range"))
+		http.ServeContent(w, r, "tool.tar.gz", time.Time{}, strings.NewReader(body))
+	}))
+	t.Cleanup(srv.Close)
+	c := New(t.TempDir())
+
+	dest := filepath.Join(t.TempDir(), "out")
+	require.NoError(t, c.Fetch(srv.URL+"/tool.tar.gz", "1.0.0", sha256Hex(body), dest))
+	assert.Equal(t, 2, requests)
+	data, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(data))
+}
+
+func TestFetchChecksumMismatchDiscardsPart(t *testing.T) {
+	t.Setenv(DisableEnvVar, "")
+	srv, _ := newTestServer(t, "archive-contents")
+	c := New(t.TempDir())
+	url := srv.URL + "/tool.tar.gz"
+
+	require.Error(t, c.Fetch(url, "1.0.0", "deadbeef", filepath.Join(t.TempDir(), "out")))
+	assert.NoFileExists(t, c.Path(url, "1.0.0")+PartSuffix)
+}
@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// IndexFileName is the file in the cache directory that lists the cached downloads
+const IndexFileName = "index.json"
+
+// indexMu serializes index updates from concurrent installs
+var indexMu sync.Mutex
+
+// IndexEntry records a cached download
+type IndexEntry struct {
+	URL     string `json:"url"`
+	Version string `json:"version,omitempty"`
+	// File is the entry's path relative to the cache directory
+	File      string    `json:"file"`
+	SHA256    string    `json:"sha256"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// Index maps cached downloads, by Key, to their files and checksums. Tags
+// records the release tag "latest" resolved to for each repository, so an
+// offline run installs the release that was downloaded.
+type Index struct {
+	Entries map[string]IndexEntry `json:"entries"`
+	Tags    map[string]string     `json:"tags,omitempty"`
+}
+
+// LoadIndex reads the cache index; a missing index is empty
+func (c *Cache) LoadIndex() (*Index, error) {
+	index := &Index{Entries: make(map[string]IndexEntry), Tags: make(map[string]string)}
+	data, err := os.ReadFile(filepath.Join(c.dir, IndexFileName))
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache index: %w", err)
+	}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("failed to parse cache index: %w", err)
+	}
+	if index.Entries == nil {
+		index.Entries = make(map[string]IndexEntry)
+	}
+	if index.Tags == nil {
+		index.Tags = make(map[string]string)
+	}
+	return index, nil
+}
+
+// updateIndex applies fn to the cache index and writes it back
+func (c *Cache) updateIndex(fn func(*Index)) error {
+	indexMu.Lock()
+	defer indexMu.Unlock()
+	index, err := c.LoadIndex()
+	if err != nil {
+		return err
+	}
+	fn(index)
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cache index: %w", err)
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	path := filepath.Join(c.dir, IndexFileName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache index: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write cache index: %w", err)
+	}
+	return nil
+}
+
+// record adds the entry stored for url and version to the index
+func (c *Cache) record(url, version, sum string) error {
+	file, err := filepath.Rel(c.dir, c.Path(url, version))
+	if err != nil {
+		return fmt.Errorf("failed to index %s: %w", url, err)
+	}
+	return c.updateIndex(func(index *Index) {
+		index.Entries[Key(url, version)] = IndexEntry{URL: url, Version: version, File: file, SHA256: sum, FetchedAt: time.Now()}
+	})
+}
+
+// RecordTag remembers the tag "latest" resolved to for repo
+func (c *Cache) RecordTag(repo, tag string) error {
+	return c.updateIndex(func(index *Index) {
+		index.Tags[repo] = tag
+	})
+}
+
+// Tag returns the tag last recorded for repo with RecordTag
+func (c *Cache) Tag(repo string) (string, bool) {
+	index, err := c.LoadIndex()
+	if err != nil {
+		return "", false
+	}
+	tag, ok := index.Tags[repo]
+	return tag, ok
+}
+
+// Has reports whether a download for url and version is cached and matches
+// checksum, or the checksum it was stored with when checksum is empty
+func (c *Cache) Has(url, version, checksum string) bool {
+	if os.Getenv(SkipChecksumsEnvVar) != "" {
+		checksum = ""
+	}
+	return c.valid(c.Path(url, version), checksum)
+}
+
+// Stale returns the indexed entries whose file is missing or no longer
+// matches the checksum it was stored with, sorted by URL
+func (c *Cache) Stale() ([]IndexEntry, error) {
+	index, err := c.LoadIndex()
+	if err != nil {
+		return nil, err
+	}
+	var stale []IndexEntry
+	for _, entry := range index.Entries {
+		sum, err := fileChecksum(filepath.Join(c.dir, entry.File))
+		if err != nil || sum != entry.SHA256 {
+			stale = append(stale, entry)
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].URL < stale[j].URL })
+	return stale, nil
+}
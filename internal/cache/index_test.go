@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchRecordsIndex(t *testing.T) {
+	t.Setenv(DisableEnvVar, "")
+	srv, _ := newTestServer(t, "archive-contents")
+	c := New(t.TempDir())
+	url := srv.URL + "/tool.tar.gz"
+
+	require.NoError(t, c.Fetch(url, "1.0.0", "", filepath.Join(t.TempDir(), "out")))
+	index, err := c.LoadIndex()
+	require.NoError(t, err)
+	entry, ok := index.Entries[Key(url, "1.0.0")]
+	require.True(t, ok)
+	assert.Equal(t, url, entry.URL)
+	assert.Equal(t, sha256Hex("archive-contents"), entry.SHA256)
+	assert.Equal(t, c.Path(url, "1.0.0"), filepath.Join(c.Dir(), entry.File))
+	assert.True(t, c.Has(url, "1.0.0", ""))
+
+	stale, err := c.Stale()
+	require.NoError(t, err)
+	assert.Empty(t, stale)
+
+	require.NoError(t, os.WriteFile(c.Path(url, "1.0.0"), []byte("tampered"), 0644))
+	stale, err = c.Stale()
+	require.NoError(t, err)
+	require.Len(t, stale, 1)
+	assert.Equal(t, url, stale[0].URL)
+	assert.False(t, c.Has(url, "1.0.0", ""))
+}
+
+func TestFetchOffline(t *testing.T) {
+	t.Setenv(DisableEnvVar, "")
+	srv, hits := newTestServer(t, "archive-contents")
+	c := New(t.TempDir())
+	url := srv.URL + "/tool.tar.gz"
+	require.NoError(t, c.Fetch(url, "1.0.0", "", filepath.Join(t.TempDir(), "out")))
+
+	t.Setenv(OfflineEnvVar, "1")
+	// The cached copy is used even when the cache is disabled for the run
+	c.SetEnabled(false)
+	dest := filepath.Join(t.TempDir(), "offline")
+	require.NoError(t, c.Fetch(url, "1.0.0", "", dest))
+	data, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, "archive-contents", string(data))
+
+	err = c.Fetch(url, "2.0.0", "", filepath.Join(t.TempDir(), "missing"))
+	var notCached *NotCachedError
+	assert.True(t, errors.As(err, &notCached))
+	assert.Equal(t, 1, *hits)
+}
+
+func TestRecordTag(t *testing.T) {
+	c := New(t.TempDir())
+	_, ok := c.Tag("lsd-rs/lsd")
+	assert.False(t, ok)
+
+	require.NoError(t, c.RecordTag("lsd-rs/lsd", "v1.1.5"))
+	tag, ok := c.Tag("lsd-rs/lsd")
+	assert.True(t, ok)
+	assert.Equal(t, "v1.1.5", tag)
+}
+
+func TestDefaultDirOverride(t *testing.T) {
+	t.Setenv(DirEnvVar, "/srv/bootstrap-cache")
+	dir, err := DefaultDir()
+	require.NoError(t, err)
+	assert.Equal(t, "/srv/bootstrap-cache", dir)
+}
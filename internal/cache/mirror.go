@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	// GitHubMirrorEnvVar holds a base URL that replaces https://github.com for release downloads,
+	// e.g. https://mirror.example.com/github or https://proxy.example.com/https://github.com
+	GitHubMirrorEnvVar = "BOOTSTRAP_CLI_GITHUB_MIRROR"
+	// GoMirrorEnvVar holds a base URL that replaces https://go.dev/dl for Go toolchain downloads,
+	// e.g. https://golang.google.cn/dl
+	GoMirrorEnvVar = "BOOTSTRAP_CLI_GO_MIRROR"
+)
+
+// mirrorSources maps each mirror variable to the upstream URL prefixes it replaces
+var mirrorSources = []struct {
+	envVar    string
+	upstreams []string
+}{
+	{envVar: GitHubMirrorEnvVar, upstreams: []string{"https://github.com"}},
+	{envVar: GoMirrorEnvVar, upstreams: []string{"https://go.dev/dl", "https://dl.google.com/go"}},
+}
+
+// ValidateMirror checks that a mirror base URL is an absolute http(s) URL
+func ValidateMirror(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid mirror URL %q: %w", raw, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid mirror URL %q: must be an absolute http or https URL", raw)
+	}
+	return nil
+}
+
+// checkMirror validates a mirror and checks its host against the allowlist, so
+// a mirror override cannot redirect downloads to an arbitrary host
+func checkMirror(mirror string) error {
+	if err := ValidateMirror(mirror); err != nil {
+		return err
+	}
+	return CheckURL(mirror)
+}
+
+// ValidateProxy checks that a proxy URL is usable by both the native client and child processes
+func ValidateProxy(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL %q: %w", raw, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("invalid proxy URL %q: scheme must be http, https or socks5", raw)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid proxy URL %q: missing host", raw)
+	}
+	return nil
+}
+
+// SetProxy exports proxy for this process and every command it runs (curl, git, package managers)
+func SetProxy(proxy string) error {
+	if err := ValidateProxy(proxy); err != nil {
+		return err
+	}
+	for _, name := range []string{"HTTP_PROXY", "HTTPS_PROXY", "http_proxy", "https_proxy"} {
+		os.Setenv(name, proxy)
+	}
+	return nil
+}
+
+// ApplyMirrors validates the configured mirrors and exports the derived variables
+// install scripts rely on. Invalid mirrors are unset so nothing tries to use them,
+// and are returned so the caller can warn about them.
+func ApplyMirrors() []error {
+	var invalid []error
+	for _, source := range mirrorSources {
+		mirror := os.Getenv(source.envVar)
+		if mirror == "" {
+			continue
+		}
+		if err := checkMirror(mirror); err != nil {
+			os.Unsetenv(source.envVar)
+			invalid = append(invalid, fmt.Errorf("%s: %w; using upstream", source.envVar, err))
+		}
+	}
+
+	// goenv builds download toolchains from GO_BUILD_MIRROR_URL
+	if mirror := os.Getenv(GoMirrorEnvVar); mirror != "" && os.Getenv("GO_BUILD_MIRROR_URL") == "" {
+		os.Setenv("GO_BUILD_MIRROR_URL", strings.TrimSuffix(mirror, "/"))
+	}
+	return invalid
+}
+
+// MirrorURLs returns the URLs to try for rawURL: the mirrored URL first when a valid
+// mirror is configured for its source, then the upstream URL as a fallback
+func MirrorURLs(rawURL string) []string {
+	for _, source := range mirrorSources {
+		mirror := os.Getenv(source.envVar)
+		if mirror == "" || checkMirror(mirror) != nil {
+			continue
+		}
+		for _, upstream := range source.upstreams {
+			if rest, ok := strings.CutPrefix(rawURL, upstream+"/"); ok {
+				return []string{strings.TrimSuffix(mirror, "/") + "/" + rest, rawURL}
+			}
+		}
+	}
+	return []string{rawURL}
+}
+
+// NewClient returns an HTTP client that honours HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+// and only follows redirects to allowlisted hosts
+func NewClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	return &http.Client{
+		Transport: transport,
+		// Redirects must stay on allowlisted hosts too
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after 10 redirects")
+			}
+			return CheckURL(req.URL.String())
+		},
+	}
+}
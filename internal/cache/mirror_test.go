@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMirrorURLs(t *testing.T) {
+	t.Setenv(GitHubMirrorEnvVar, "https://mirror.example.com/github/")
+	t.Setenv(GoMirrorEnvVar, "")
+	t.Setenv(AllowHostsEnvVar, "mirror.example.com")
+
+	release := "https://github.com/Peltoche/lsd/releases/download/v1.0.0/lsd.deb"
+	assert.Equal(t, []string{
+		"https://mirror.example.com/github/Peltoche/lsd/releases/download/v1.0.0/lsd.deb",
+		release,
+	}, MirrorURLs(release))
+
+	// Sources without a mirror go straight upstream
+	assert.Equal(t, []string{"https://go.dev/dl/go1.22.0.linux-amd64.tar.gz"}, MirrorURLs("https://go.dev/dl/go1.22.0.linux-amd64.tar.gz"))
+}
+
+func TestApplyMirrorsDropsInvalid(t *testing.T) {
+	t.Setenv(GitHubMirrorEnvVar, "ftp://mirror.example.com")
+	t.Setenv(GoMirrorEnvVar, "https://golang.google.cn/dl/")
+	t.Setenv("GO_BUILD_MIRROR_URL", "")
+	t.Setenv(AllowHostsEnvVar, "golang.google.cn")
+
+	invalid := ApplyMirrors()
+	require.Len(t, invalid, 1)
+	assert.Empty(t, os.Getenv(GitHubMirrorEnvVar))
+	assert.Equal(t, "https://golang.google.cn/dl", os.Getenv("GO_BUILD_MIRROR_URL"))
+
+	release := "https://github.com/owner/repo/releases/download/v1/asset.tar.gz"
+	assert.Equal(t, []string{release}, MirrorURLs(release))
+}
+
+func TestApplyMirrorsDropsUnlistedHost(t *testing.T) {
+	t.Setenv(GitHubMirrorEnvVar, "https://evil.example.com/github")
+	t.Setenv(GoMirrorEnvVar, "")
+	t.Setenv(AllowHostsEnvVar, "")
+
+	invalid := ApplyMirrors()
+	require.Len(t, invalid, 1)
+	var notAllowed *HostNotAllowedError
+	assert.ErrorAs(t, invalid[0], &notAllowed)
+	assert.Empty(t, os.Getenv(GitHubMirrorEnvVar))
+}
+
+func TestSetProxy(t *testing.T) {
+	for _, name := range []string{"HTTP_PROXY", "HTTPS_PROXY", "http_proxy", "https_proxy"} {
+		t.Setenv(name, "")
+	}
+
+	assert.Error(t, SetProxy("proxy.example.com:8080"))
+	require.NoError(t, SetProxy("http://proxy.example.com:8080"))
+	assert.Equal(t, "http://proxy.example.com:8080", os.Getenv("HTTPS_PROXY"))
+	assert.Equal(t, "http://proxy.example.com:8080", os.Getenv("http_proxy"))
+}
@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FetchScript downloads the install script at url into a temporary file and
+// verifies it against checksum before returning its path, so nothing is piped
+// from the network into a shell. cleanup removes the file.
+func (c *Cache) FetchScript(url, version, checksum string) (script string, cleanup func(), err error) {
+	dir, err := os.MkdirTemp("", "bootstrap-script-")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+
+	script = filepath.Join(dir, "install.sh")
+	if err := c.Fetch(url, version, checksum, script); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return script, cleanup, nil
+}
+
+// LookupChecksum finds the SHA-256 of asset in a checksums file. Both the
+// sha256sum format ("<hash>  <name>", one per line) and a .sha256 file holding
+// just the hash are understood.
+func LookupChecksum(data []byte, asset string) (string, bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	var lines [][]string
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) > 0 {
+			lines = append(lines, fields)
+		}
+	}
+	for _, fields := range lines {
+		if len(fields) < 2 || !isHexSHA256(fields[0]) {
+			continue
+		}
+		// sha256sum marks binary mode with a leading "*"
+		name := strings.TrimPrefix(fields[len(fields)-1], "*")
+		if name == asset || path.Base(name) == asset {
+			return strings.ToLower(fields[0]), true
+		}
+	}
+	// A .sha256 file for a single asset may hold only the hash
+	if len(lines) == 1 && len(lines[0]) == 1 && isHexSHA256(lines[0][0]) {
+		return strings.ToLower(lines[0][0]), true
+	}
+	return "", false
+}
+
+func isHexSHA256(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, c := range strings.ToLower(s) {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
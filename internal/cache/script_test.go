@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchScript(t *testing.T) {
+	t.Setenv(DisableEnvVar, "1")
+	srv, _ := newTestServer(t, "echo installed\n")
+	c := New(t.TempDir())
+
+	_, _, err := c.FetchScript(srv.URL+"/install.sh", "", strings.Repeat("0", 64))
+	require.Error(t, err)
+
+	script, cleanup, err := c.FetchScript(srv.URL+"/install.sh", "", sha256Hex("echo installed\n"))
+	require.NoError(t, err)
+	data, err := os.ReadFile(script)
+	require.NoError(t, err)
+	assert.Equal(t, "echo installed\n", string(data))
+
+	cleanup()
+	assert.NoFileExists(t, script)
+}
+
+func TestLookupChecksum(t *testing.T) {
+	a, b := strings.Repeat("a", 64), strings.Repeat("B", 64)
+	checksums := []byte(a + "  tool-linux-amd64.tar.gz\n" + b + " *dist/tool-darwin-arm64.tar.gz\n")
+
+	sum, ok := LookupChecksum(checksums, "tool-linux-amd64.tar.gz")
+	assert.True(t, ok)
+	assert.Equal(t, a, sum)
+
+	sum, ok = LookupChecksum(checksums, "tool-darwin-arm64.tar.gz")
+	assert.True(t, ok)
+	assert.Equal(t, strings.ToLower(b), sum)
+
+	_, ok = LookupChecksum(checksums, "tool-windows-amd64.zip")
+	assert.False(t, ok)
+
+	sum, ok = LookupChecksum([]byte(a+"\n"), "tool.tar.gz")
+	assert.True(t, ok)
+	assert.Equal(t, a, sum)
+}
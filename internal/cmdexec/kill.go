@@ -0,0 +1,74 @@
+package cmdexec
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// KillWaitDelay is how long a cancelled command and the processes it started
+// get to exit after being asked to, before they are killed and their output
+// pipes closed
+const KillWaitDelay = 5 * time.Second
+
+// KillTreeOnCancel makes cmd, built with exec.CommandContext, stop the
+// processes it started along with itself once its context is done, so that
+// sh -c "sudo apt-get install ..." does not leave apt-get running. They are
+// sent SIGTERM, which sudo passes on to its child; whatever is still running
+// after KillWaitDelay is killed.
+func KillTreeOnCancel(cmd *exec.Cmd) *exec.Cmd {
+	cmd.Cancel = func() error {
+		children := descendants(cmd.Process.Pid)
+		err := cmd.Process.Signal(syscall.SIGTERM)
+		for _, pid := range children {
+			if p, findErr := os.FindProcess(pid); findErr == nil {
+				_ = p.Signal(syscall.SIGTERM)
+			}
+		}
+		if len(children) > 0 {
+			go func() {
+				time.Sleep(KillWaitDelay)
+				for _, pid := range children {
+					if p, findErr := os.FindProcess(pid); findErr == nil {
+						_ = p.Kill()
+					}
+				}
+			}()
+		}
+		return err
+	}
+	cmd.WaitDelay = KillWaitDelay
+	return cmd
+}
+
+// descendants returns the processes below pid, as listed by ps. Without ps
+// (e.g. on Windows) it returns none.
+func descendants(pid int) []int {
+	out, err := exec.Command("ps", "-A", "-o", "pid=,ppid=").Output()
+	if err != nil {
+		return nil
+	}
+	children := make(map[int][]int)
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		child, err1 := strconv.Atoi(fields[0])
+		parent, err2 := strconv.Atoi(fields[1])
+		if err1 == nil && err2 == nil {
+			children[parent] = append(children[parent], child)
+		}
+	}
+	var all []int
+	for queue := []int{pid}; len(queue) > 0; queue = queue[1:] {
+		for _, child := range children[queue[0]] {
+			all = append(all, child)
+			queue = append(queue, child)
+		}
+	}
+	return all
+}
@@ -0,0 +1,43 @@
+package cmdexec
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKillTreeOnCancel(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh and ps")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	// sh waits on a background sleep that would otherwise outlive it
+	start := time.Now()
+	cmd := KillTreeOnCancel(exec.CommandContext(ctx, "sh", "-c", "sleep 30 & echo $!; wait"))
+	out, err := cmd.Output()
+	if err == nil {
+		t.Fatal("Expected the cancelled command to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Expected the command to stop at its deadline, took %v", elapsed)
+	}
+
+	pid := strings.TrimSpace(string(out))
+	if pid == "" {
+		t.Fatal("Expected the sleep's pid on stdout")
+	}
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(50 * time.Millisecond) {
+		stat, _ := exec.Command("ps", "-o", "stat=", "-p", pid).Output()
+		if s := strings.TrimSpace(string(stat)); s == "" || strings.HasPrefix(s, "Z") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the child sleep %s to be stopped", pid)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package cmdexec
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// PrefixWriter writes each complete line it receives to the underlying writer
+// behind a fixed prefix, so output from several commands stays attributable
+type PrefixWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	prefix []byte
+	buf    []byte
+}
+
+// NewPrefixWriter creates a writer that prefixes every line written to w
+func NewPrefixWriter(w io.Writer, prefix string) *PrefixWriter {
+	return &PrefixWriter{w: w, prefix: []byte(prefix)}
+}
+
+// Write buffers p and emits every complete line with the prefix
+func (p *PrefixWriter) Write(data []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.buf = append(p.buf, data...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := p.emit(p.buf[:i+1]); err != nil {
+			return len(data), err
+		}
+		p.buf = p.buf[i+1:]
+	}
+	return len(data), nil
+}
+
+// Flush emits any trailing partial line
+func (p *PrefixWriter) Flush() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.buf) == 0 {
+		return nil
+	}
+	line := append(p.buf, '\n')
+	p.buf = nil
+	return p.emit(line)
+}
+
+func (p *PrefixWriter) emit(line []byte) error {
+	if _, err := p.w.Write(p.prefix); err != nil {
+		return err
+	}
+	_, err := p.w.Write(line)
+	return err
+}
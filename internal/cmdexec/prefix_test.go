@@ -0,0 +1,25 @@
+package cmdexec
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := NewPrefixWriter(&out, "[git] ")
+
+	// Lines split across writes are joined before prefixing
+	w.Write([]byte("Reading package"))
+	w.Write([]byte(" lists...\nBuilding dependency tree\nDone"))
+	if got := out.String(); got != "[git] Reading package lists...\n[git] Building dependency tree\n" {
+		t.Errorf("Unexpected output before flush: %q", got)
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if got := out.String(); got != "[git] Reading package lists...\n[git] Building dependency tree\n[git] Done\n" {
+		t.Errorf("Unexpected output after flush: %q", got)
+	}
+}
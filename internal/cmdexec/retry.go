@@ -0,0 +1,142 @@
+package cmdexec
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+)
+
+// RetryPolicy is how often and how patiently a failing install command is retried
+type RetryPolicy struct {
+	// Attempts is the total number of tries, including the first
+	Attempts int
+	// InitialDelay is the wait before the first retry
+	InitialDelay time.Duration
+	// MaxDelay caps the wait between retries; zero means no cap
+	MaxDelay time.Duration
+	// Multiplier grows the wait after each retry (2 doubles it)
+	Multiplier float64
+}
+
+// DefaultRetryPolicy tries three times, waiting 2s and then 4s
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:     3,
+	InitialDelay: 2 * time.Second,
+	MaxDelay:     30 * time.Second,
+	Multiplier:   2,
+}
+
+// Delay returns the wait before retry number retry, counting from 1
+func (p RetryPolicy) Delay(retry int) time.Duration {
+	delay := p.InitialDelay
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	for n := 1; n < retry; n++ {
+		delay = time.Duration(float64(delay) * multiplier)
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// Do runs op until it succeeds, fails permanently (see Retryable) or runs out
+// of attempts, and returns its last error. onRetry, if set, is called before
+// each retry with the attempt about to start.
+func (p RetryPolicy) Do(op func() error, onRetry func(attempt, attempts int, err error)) error {
+	attempts := p.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			if onRetry != nil {
+				onRetry(attempt, attempts, err)
+			}
+			time.Sleep(p.Delay(attempt - 1))
+		}
+		if err = op(); err == nil || !Retryable(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// permanentError marks an error as not worth retrying
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err so Retryable reports false for it, whatever it says
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// lockPatterns match package manager output saying another process holds its lock
+var lockPatterns = regexp.MustCompile(`(?i)` +
+	`could not get lock|unable to acquire the dpkg|unable to lock directory|is another process using it|` + // apt
+	`waiting for process with pid|another app is currently holding the (yum|dnf) lock|` + // dnf, yum
+	`unable to lock database|failed to init transaction \(unable to lock database\)|` + // pacman
+	`another active homebrew .* process|has already locked|` + // brew
+	`system management is locked by the application|zypp is locked`) // zypper
+
+// networkPatterns match transient network failures
+var networkPatterns = regexp.MustCompile(`(?i)` +
+	`temporary failure resolving|temporary failure in name resolution|could not resolve host|` +
+	`connection timed out|operation timed out|i/o timeout|tls handshake timeout|` +
+	`connection reset|connection refused|network is unreachable|` +
+	`failed to fetch|failed to download|cannot download|curl error|curl: \(\d+\)|` +
+	`hash sum mismatch|failed to synchronize|error downloading packages|` +
+	`failed to retrieve|503 service unavailable|502 bad gateway`)
+
+// permanentPatterns match failures a retry cannot fix, such as an unknown package
+var permanentPatterns = regexp.MustCompile(`(?i)` +
+	`unable to locate package|has no installation candidate|` + // apt
+	`no match for argument|unable to find a match|` + // dnf
+	`target not found|` + // pacman
+	`no available formula|no formulae or casks found|no cask with this name|` + // brew
+	`no provider of .* found|package .* not found|` + // zypper
+	`unable to find package`) // choco
+
+// IsLockHeld reports whether output says another process holds the package
+// manager's lock, e.g. apt's "Could not get lock /var/lib/dpkg/lock-frontend"
+func IsLockHeld(output string) bool {
+	return lockPatterns.MatchString(output)
+}
+
+// IsNetworkError reports whether output describes a transient network failure
+func IsNetworkError(output string) bool {
+	return networkPatterns.MatchString(output)
+}
+
+// IsPermanent reports whether output describes a failure a retry cannot fix
+func IsPermanent(output string) bool {
+	return permanentPatterns.MatchString(output)
+}
+
+// Retryable reports whether err may go away when the command is run again. A
+// held lock and network failures are retried; a missing package, a cancelled
+// run and errors marked with Permanent are not. Other failures are retried as
+// well, since a command that failed for an unknown reason may still succeed.
+func Retryable(err error) bool {
+	var permanent *permanentError
+	if err == nil || errors.Is(err, context.Canceled) || errors.As(err, &permanent) {
+		return false
+	}
+	msg := err.Error()
+	if IsLockHeld(msg) || IsNetworkError(msg) {
+		return true
+	}
+	return !IsPermanent(msg)
+}
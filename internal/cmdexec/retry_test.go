@@ -0,0 +1,110 @@
+package cmdexec
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Output captured from package managers failing on a held lock
+var lockOutputs = map[string]string{
+	"apt lock-frontend": `E: Could not get lock /var/lib/dpkg/lock-frontend. It is held by process 1234 (unattended-upgr)
+N: Be aware that removing the lock file is not a solution and may break your system.
+E: Unable to acquire the dpkg frontend lock (/var/lib/dpkg/lock-frontend), is another process using it?`,
+	"apt lock": `E: Could not get lock /var/lib/dpkg/lock - open (11: Resource temporarily unavailable)
+E: Unable to lock the administration directory (/var/lib/dpkg/), is another process using it?`,
+	"apt lists": `E: Could not get lock /var/lib/apt/lists/lock. It is held by process 4321 (apt-get)
+E: Unable to lock directory /var/lib/apt/lists/`,
+	"dnf": `Waiting for process with pid 2211 to finish.`,
+	"yum": `Existing lock /var/run/yum.pid: another copy is running as pid 3100.
+Another app is currently holding the yum lock; waiting for it to exit...`,
+	"pacman": `error: failed to init transaction (unable to lock database)
+error: could not lock database: File exists`,
+	"brew": `Error: Another active Homebrew update process is already in progress.`,
+	"zypper": `System management is locked by the application with pid 881 (zypper).
+Close this application before trying again.`,
+}
+
+func TestIsLockHeld(t *testing.T) {
+	for name, output := range lockOutputs {
+		if !IsLockHeld(output) {
+			t.Errorf("%s: expected the lock to be detected in %q", name, output)
+		}
+	}
+	for _, output := range []string{
+		"E: Unable to locate package ripgrepp",
+		"Reading package lists... Done\nBuilding dependency tree... Done",
+		"Error: Unable to find a match: fd-find",
+	} {
+		if IsLockHeld(output) {
+			t.Errorf("Expected no lock in %q", output)
+		}
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		output string
+		want   bool
+	}{
+		{lockOutputs["apt lock-frontend"], true},
+		{"Err:1 http://archive.ubuntu.com/ubuntu jammy InRelease\n  Temporary failure resolving 'archive.ubuntu.com'\nE: Failed to fetch http://archive.ubuntu.com/ubuntu/pool/main/r/ripgrep.deb", true},
+		{"Curl error (28): Timeout was reached for https://mirrors.fedoraproject.org/metalink [Connection timed out after 30000 milliseconds]\nError: Failed to download metadata for repo 'fedora'", true},
+		{"curl: (6) Could not resolve host: ghcr.io", true},
+		{"E: Unable to locate package ripgrepp", false},
+		{"E: Package 'fd' has no installation candidate", false},
+		{"No match for argument: fd\nError: Unable to find a match: fd", false},
+		{"error: target not found: ripgrepp", false},
+		{"Warning: No available formula with the name \"ripgrepp\".", false},
+		{"exit status 1", true},
+	}
+	for _, tt := range tests {
+		if got := Retryable(fmt.Errorf("package installation failed: exit status 100 (Output: %s)", tt.output)); got != tt.want {
+			t.Errorf("Retryable(%q) = %v, want %v", tt.output, got, tt.want)
+		}
+	}
+	if Retryable(nil) {
+		t.Error("Expected nil not to be retryable")
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{InitialDelay: time.Second, MaxDelay: 5 * time.Second, Multiplier: 2}
+	for retry, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second} {
+		if got := p.Delay(retry); got != want {
+			t.Errorf("Delay(%d) = %v, want %v", retry, got, want)
+		}
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	p := RetryPolicy{Attempts: 3, InitialDelay: time.Millisecond, Multiplier: 2}
+
+	calls := 0
+	var retries []string
+	err := p.Do(func() error {
+		calls++
+		if calls < 3 {
+			return errors.New(lockOutputs["apt lock"])
+		}
+		return nil
+	}, func(attempt, attempts int, _ error) {
+		retries = append(retries, fmt.Sprintf("%d/%d", attempt, attempts))
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("Expected success on the third attempt, got %v after %d", err, calls)
+	}
+	if fmt.Sprint(retries) != "[2/3 3/3]" {
+		t.Errorf("Unexpected retry notifications %v", retries)
+	}
+
+	calls = 0
+	err = p.Do(func() error {
+		calls++
+		return errors.New("E: Unable to locate package nope")
+	}, nil)
+	if err == nil || calls != 1 {
+		t.Errorf("Expected a missing package to fail without retrying, got %d calls", calls)
+	}
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+)
+
+// Catalog holds every configuration type, merged from embedded defaults and user files
+type Catalog struct {
+	Tools            []*pipeline.Tool
+	LanguageManagers []*pipeline.Tool
+	Fonts            []*interfaces.Font
+	Languages        []*interfaces.Language
+	Dotfiles         []*interfaces.Dotfile
+	Shells           []*interfaces.Shell
+}
+
+// LoadAll loads every configuration type once and caches the result, so later
+// LoadX calls on this loader return the cached catalog instead of re-walking
+// the config directories. Call Reset to pick up changes on disk.
+func (l *Loader) LoadAll() (*Catalog, error) {
+	if c := l.cached(); c != nil {
+		return c, nil
+	}
+
+	var c Catalog
+	var err error
+	if c.Tools, err = l.LoadTools(); err != nil {
+		return nil, fmt.Errorf("failed to load tools: %w", err)
+	}
+	if c.LanguageManagers, err = l.LoadLanguageManagers(); err != nil {
+		return nil, fmt.Errorf("failed to load language managers: %w", err)
+	}
+	if c.Fonts, err = l.LoadFonts(); err != nil {
+		return nil, fmt.Errorf("failed to load fonts: %w", err)
+	}
+	if c.Languages, err = l.LoadLanguages(); err != nil {
+		return nil, fmt.Errorf("failed to load languages: %w", err)
+	}
+	if c.Dotfiles, err = l.LoadDotfiles(); err != nil {
+		return nil, fmt.Errorf("failed to load dotfiles: %w", err)
+	}
+	if c.Shells, err = l.LoadShells(); err != nil {
+		return nil, fmt.Errorf("failed to load shells: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.catalog == nil {
+		l.catalog = &c
+	}
+	return l.catalog, nil
+}
+
+// Reset drops the cached catalog
+func (l *Loader) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.catalog = nil
+}
+
+// cached returns the catalog loaded by LoadAll, or nil
+func (l *Loader) cached() *Catalog {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.catalog
+}
@@ -0,0 +1,211 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadAll(t *testing.T) {
+	loader := NewLoader(t.TempDir())
+	catalog, err := loader.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll() error = %v", err)
+	}
+	if len(catalog.Tools) == 0 || len(catalog.Languages) == 0 || len(catalog.Shells) == 0 {
+		t.Fatalf("Expected embedded defaults in catalog, got %d tools, %d languages, %d shells",
+			len(catalog.Tools), len(catalog.Languages), len(catalog.Shells))
+	}
+
+	again, err := loader.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll() second call error = %v", err)
+	}
+	if again != catalog {
+		t.Errorf("Expected LoadAll to return the cached catalog")
+	}
+	tools, err := loader.LoadTools()
+	if err != nil {
+		t.Fatalf("LoadTools() error = %v", err)
+	}
+	if len(tools) != len(catalog.Tools) || tools[0] != catalog.Tools[0] {
+		t.Errorf("Expected LoadTools to be served from the cache")
+	}
+}
+
+func TestLoadAll_Reset(t *testing.T) {
+	baseDir := t.TempDir()
+	loader := NewLoader(baseDir)
+	before, err := loader.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll() error = %v", err)
+	}
+
+	toolDir := filepath.Join(baseDir, "tools")
+	if err := os.MkdirAll(toolDir, 0755); err != nil {
+		t.Fatalf("Failed to create tools dir: %v", err)
+	}
+	data := []byte("name: custom-tool\ndescription: A user tool\ncategory: custom\n")
+	if err := os.WriteFile(filepath.Join(toolDir, "custom-tool.yaml"), data, 0644); err != nil {
+		t.Fatalf("Failed to write tool config: %v", err)
+	}
+
+	cached, _ := loader.LoadAll()
+	if len(cached.Tools) != len(before.Tools) {
+		t.Errorf("Expected cached catalog to ignore new files until Reset")
+	}
+
+	loader.Reset()
+	after, err := loader.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll() after Reset error = %v", err)
+	}
+	if len(after.Tools) != len(before.Tools)+1 {
+		t.Errorf("Expected %d tools after Reset, got %d", len(before.Tools)+1, len(after.Tools))
+	}
+}
+
+// BenchmarkLoadTools measures walking and parsing the tool configs on every call
+func BenchmarkLoadTools(b *testing.B) {
+	loader := NewLoader(b.TempDir())
+	for i := 0; i < b.N; i++ {
+		if _, err := loader.LoadTools(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkLoadAll measures building the full catalog from scratch
+func BenchmarkLoadAll(b *testing.B) {
+	loader := NewLoader(b.TempDir())
+	for i := 0; i < b.N; i++ {
+		loader.Reset()
+		if _, err := loader.LoadAll(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkLoadAll_Cached measures repeated lookups against a warm catalog
+func BenchmarkLoadAll_Cached(b *testing.B) {
+	loader := NewLoader(b.TempDir())
+	if _, err := loader.LoadAll(); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := loader.LoadTools(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestLoadTools_SupportedOS(t *testing.T) {
+	tools, err := NewLoader(t.TempDir()).LoadTools()
+	if err != nil {
+		t.Fatalf("LoadTools() error = %v", err)
+	}
+	for _, tool := range tools {
+		if tool.Name != "build-essential" {
+			continue
+		}
+		if ok, _ := tool.SupportsOS("darwin"); ok {
+			t.Errorf("Expected build-essential to be Linux-only, got supported_os %v", tool.SupportedOS)
+		}
+		return
+	}
+	t.Fatal("build-essential not found in the default catalog")
+}
+
+func TestUnmarshalTool_MinVersion(t *testing.T) {
+	tool, err := unmarshalTool([]byte("name: rg\nverify_command: rg --version\nmin_version: \"13.0\"\n"))
+	if err != nil {
+		t.Fatalf("unmarshalTool() error = %v", err)
+	}
+	if tool.MinVersion != "13.0" {
+		t.Errorf("Expected min_version 13.0, got %q", tool.MinVersion)
+	}
+}
+
+func TestUnmarshalTool_Conflicts(t *testing.T) {
+	tool, err := unmarshalTool([]byte("name: lsd\nconflicts:\n  - eza\n"))
+	if err != nil {
+		t.Fatalf("unmarshalTool() error = %v", err)
+	}
+	if len(tool.Conflicts) != 1 || tool.Conflicts[0] != "eza" {
+		t.Errorf("Expected conflicts [eza], got %v", tool.Conflicts)
+	}
+}
+
+func TestUnmarshalTool_GitHubRelease(t *testing.T) {
+	tool, err := unmarshalTool([]byte("name: delta\nverify_command: delta --version\ngithub_release:\n  repo: dandavison/delta\n  asset: \"delta-{version}-{arch}-{os}.tar.gz\"\n  arch_names:\n    amd64: x86_64\n"))
+	if err != nil {
+		t.Fatalf("unmarshalTool() error = %v", err)
+	}
+	if tool.Release == nil || tool.Release.Repo != "dandavison/delta" || tool.Release.ArchNames["amd64"] != "x86_64" {
+		t.Errorf("Expected the github_release method, got %+v", tool.Release)
+	}
+
+	if _, err := unmarshalTool([]byte("name: delta\ngithub_release:\n  repo: delta\n")); err == nil {
+		t.Error("Expected an invalid github_release to be rejected")
+	}
+}
+
+func TestLoader_MergeKeepsUnsetDefaults(t *testing.T) {
+	t.Setenv(OverlayEnvVar, "")
+	baseDir := t.TempDir()
+	writeConfig(t, baseDir, "languages/go.yaml", "name: Go\nversion: \"1.22.5\"\n")
+	writeConfig(t, baseDir, "languages/python.yaml", "name: Python\ndescription: Pinned by the team\n")
+
+	languages, err := NewLoader(baseDir).LoadLanguages()
+	if err != nil {
+		t.Fatalf("LoadLanguages() error = %v", err)
+	}
+	found := make(map[string]string)
+	for _, lang := range languages {
+		found[lang.Name] = lang.Version
+		if lang.Name == "Go" && lang.VerifyCommand == "" {
+			t.Error("Expected Go to keep the default verify_command")
+		}
+	}
+	if found["Go"] != "1.22.5" {
+		t.Errorf("Expected the user's Go version to win, got %q", found["Go"])
+	}
+	if found["Python"] != "3.11" {
+		t.Errorf("Expected Python to keep the default version, got %q", found["Python"])
+	}
+}
+
+func TestUnmarshalTool_Definition(t *testing.T) {
+	tool, err := unmarshalTool([]byte("name: fd\nverify_command: fd --version\npackage_names:\n  apt: fd-find\n  zypper: fd\n  pkg: fd\nshell_config:\n  aliases:\n    find: fd\ncompletions:\n  command: fd --gen-completions {shell}\n"))
+	if err != nil {
+		t.Fatalf("unmarshalTool() error = %v", err)
+	}
+	if tool.ShellConfig.Aliases["find"] != "fd" || tool.Completions.Command == "" {
+		t.Errorf("Expected the shell config and completions in the definition, got %+v", tool.Tool)
+	}
+	for pm, want := range map[string]string{"apt": "fd-find", "zypper": "fd", "pkg": "fd", "dnf": "fd"} {
+		if got := tool.PackageFor(pm); got != want {
+			t.Errorf("PackageFor(%q) = %q, want %q", pm, got, want)
+		}
+		if got := tool.Tool.PackageFor(pm); got != want {
+			t.Errorf("definition PackageFor(%q) = %q, want %q", pm, got, want)
+		}
+	}
+	if tool.Verify.Command.Command != "fd --version" {
+		t.Errorf("Expected verify_command to be the verify command, got %q", tool.Verify.Command.Command)
+	}
+}
+
+func TestFindToolDefinitionsAliases(t *testing.T) {
+	tools, err := NewLoader(t.TempDir()).FindToolDefinitions([]string{"rg", "Ripgrep"})
+	if err != nil {
+		t.Fatalf("FindToolDefinitions() error = %v", err)
+	}
+	if tools[0].Name != "ripgrep" || tools[1].Name != "ripgrep" {
+		t.Errorf("Expected rg and Ripgrep to resolve to ripgrep, got %s and %s", tools[0].Name, tools[1].Name)
+	}
+	if _, err := NewLoader(t.TempDir()).FindToolDefinitions([]string{"nosuchtool"}); err == nil {
+		t.Error("Expected an unknown tool to be an error")
+	}
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+// LoadToolDefinitions loads the tool definitions the package installer reads,
+// the interfaces.Tool each catalog pipeline.Tool embeds. A user or overlay
+// definition replaces the default of the same name.
+func (l *Loader) LoadToolDefinitions() ([]*interfaces.Tool, error) {
+	var tools []*interfaces.Tool
+	index := make(map[string]int)
+	add := func(file string, data []byte) error {
+		tool, err := unmarshalTool(data)
+		if err != nil {
+			return fmt.Errorf("error parsing tool %s: %w", file, err)
+		}
+		if tool.Name == "" {
+			return nil
+		}
+		if n, ok := index[tool.Name]; ok {
+			tools[n] = &tool.Tool
+			return nil
+		}
+		index[tool.Name] = len(tools)
+		tools = append(tools, &tool.Tool)
+		return nil
+	}
+	isTool := func(name string) bool {
+		return strings.HasSuffix(name, ".yaml") && name != "schema.yaml"
+	}
+
+	err := fs.WalkDir(l.configFS, path.Join(l.defaultsDir, "tools"), func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !isTool(d.Name()) {
+			return err
+		}
+		data, err := l.configFS.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("error reading file %s: %w", file, err)
+		}
+		return add(file, data)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error loading default tools: %w", err)
+	}
+
+	for _, root := range l.userRoots() {
+		dir := filepath.Join(root, "tools")
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+		err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !isTool(d.Name()) {
+				return err
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("error reading file %s: %w", file, err)
+			}
+			return add(file, data)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error loading user tools from %s: %w", root, err)
+		}
+	}
+	return tools, nil
+}
+
+// FindToolDefinitions looks up the named tools among LoadToolDefinitions by
+// name or alias, ignoring case, in the order named
+func (l *Loader) FindToolDefinitions(names []string) ([]*interfaces.Tool, error) {
+	defs, err := l.LoadToolDefinitions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tools: %w", err)
+	}
+	var tools []*interfaces.Tool
+	for _, name := range names {
+		found := interfaces.FindTool(defs, name)
+		if found == nil {
+			return nil, fmt.Errorf("unknown tool: %s", name)
+		}
+		tools = append(tools, found)
+	}
+	return tools, nil
+}
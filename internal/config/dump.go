@@ -0,0 +1,176 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DumpTypes are the configuration types DumpEffective can print
+var DumpTypes = []string{"tools", "languages", "fonts", "shells", "dotfiles"}
+
+// DumpEffective returns the catalog as YAML, as bootstrap-cli uses it after the
+// defaults, user config and overlay are merged. configType limits it to one of
+// DumpTypes and name to one item (a tool may also be named by an alias); empty
+// fields are left out. Items are sorted by name so the output can be diffed.
+func (c *Catalog) DumpEffective(configType, name string) ([]byte, error) {
+	types := DumpTypes
+	if configType != "" {
+		types = []string{configType}
+	}
+
+	dump := make(map[string][]interface{})
+	for _, t := range types {
+		items, err := c.effectiveItems(t, name)
+		if err != nil {
+			return nil, err
+		}
+		if len(items) > 0 {
+			dump[t] = items
+		}
+	}
+
+	var doc interface{} = dump
+	switch {
+	case name != "" && len(dump) == 0:
+		if configType != "" {
+			return nil, fmt.Errorf("no %s named %q in the merged configuration", strings.TrimSuffix(configType, "s"), name)
+		}
+		return nil, fmt.Errorf("nothing named %q in the merged configuration", name)
+	case name != "" && len(dump) == 1:
+		for _, items := range dump {
+			doc = items[0]
+		}
+	case configType != "":
+		doc = dump[configType]
+	}
+	return encodeWithoutEmpty(doc)
+}
+
+// effectiveItems returns the merged items of configType, optionally only those
+// named name
+func (c *Catalog) effectiveItems(configType, name string) ([]interface{}, error) {
+	matches := func(itemName string, aliases ...string) bool {
+		if name == "" {
+			return true
+		}
+		for _, n := range append([]string{itemName}, aliases...) {
+			if strings.EqualFold(n, name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	byName := make(map[string]interface{})
+	switch configType {
+	case "tools":
+		for _, t := range c.Tools {
+			if matches(t.Name, t.Aliases...) {
+				byName[t.Name] = t
+			}
+		}
+	case "languages":
+		for _, l := range c.Languages {
+			if matches(l.Name) {
+				byName[l.Name] = l
+			}
+		}
+	case "fonts":
+		for _, f := range c.Fonts {
+			if matches(f.Name) {
+				byName[f.Name] = f
+			}
+		}
+	case "shells":
+		for _, s := range c.Shells {
+			if matches(s.Name) {
+				byName[s.Name] = s
+			}
+		}
+	case "dotfiles":
+		for _, d := range c.Dotfiles {
+			if matches(d.Name) {
+				byName[d.Name] = d
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown configuration type %q: must be one of %s", configType, strings.Join(DumpTypes, ", "))
+	}
+
+	names := make([]string, 0, len(byName))
+	for n := range byName {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	items := make([]interface{}, 0, len(names))
+	for _, n := range names {
+		items = append(items, byName[n])
+	}
+	return items, nil
+}
+
+// encodeWithoutEmpty encodes v as YAML, dropping empty strings, zeros, false,
+// empty lists and empty maps
+func encodeWithoutEmpty(v interface{}) ([]byte, error) {
+	var node yaml.Node
+	if err := node.Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	pruneEmpty(&node)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return nil, fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// pruneEmpty removes the mapping entries of n, at any depth, whose values are empty
+func pruneEmpty(n *yaml.Node) {
+	pruneMatching(n, isEmptyNode)
+}
+
+// pruneMatching removes the mapping entries of n, at any depth, whose values match drop
+func pruneMatching(n *yaml.Node, drop func(*yaml.Node) bool) {
+	for _, child := range n.Content {
+		pruneMatching(child, drop)
+	}
+	if n.Kind != yaml.MappingNode {
+		return
+	}
+	kept := n.Content[:0]
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if !drop(n.Content[i+1]) {
+			kept = append(kept, n.Content[i], n.Content[i+1])
+		}
+	}
+	n.Content = kept
+}
+
+func isEmptyNode(n *yaml.Node) bool {
+	switch n.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		return len(n.Content) == 0
+	case yaml.ScalarNode:
+		switch n.Tag {
+		case "!!null":
+			return true
+		case "!!str":
+			return n.Value == ""
+		case "!!bool":
+			return n.Value == "false"
+		case "!!int", "!!float":
+			return n.Value == "0"
+		}
+	}
+	return false
+}
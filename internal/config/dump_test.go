@@ -0,0 +1,75 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestDumpEffectiveShowsMergedOverride(t *testing.T) {
+	baseDir, overlayDir := t.TempDir(), t.TempDir()
+	writeConfig(t, baseDir, "tools/modern/ripgrep.yaml", "name: ripgrep\ndescription: From the user config\n")
+	writeConfig(t, overlayDir, "tools/modern/ripgrep.yaml", "name: ripgrep\ndescription: From the work overlay\npackage_manager: brew\n")
+	loader := NewLoader(baseDir)
+	loader.SetOverlay(overlayDir)
+	catalog, err := loader.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll() error = %v", err)
+	}
+
+	out, err := catalog.DumpEffective("tools", "ripgrep")
+	if err != nil {
+		t.Fatalf("DumpEffective() error = %v", err)
+	}
+	var tool map[string]interface{}
+	if err := yaml.Unmarshal(out, &tool); err != nil {
+		t.Fatalf("dump is not YAML: %v\n%s", err, out)
+	}
+	if tool["name"] != "ripgrep" || tool["description"] != "From the work overlay" || tool["preferredmanager"] != "brew" {
+		t.Errorf("Expected the overlay over the user config over the default, got:\n%s", out)
+	}
+	if _, ok := tool["homepage"]; ok {
+		t.Errorf("Expected empty fields to be left out, got:\n%s", out)
+	}
+}
+
+func TestDumpEffectiveTypes(t *testing.T) {
+	catalog, err := NewLoader(t.TempDir()).LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll() error = %v", err)
+	}
+
+	out, err := catalog.DumpEffective("shells", "")
+	if err != nil {
+		t.Fatalf("DumpEffective(shells) error = %v", err)
+	}
+	var shells []struct{ Name string }
+	if err := yaml.Unmarshal(out, &shells); err != nil || len(shells) != len(catalog.Shells) {
+		t.Fatalf("Expected a list of %d shells, got %v:\n%s", len(catalog.Shells), err, out)
+	}
+	for i := 1; i < len(shells); i++ {
+		if shells[i-1].Name > shells[i].Name {
+			t.Errorf("Expected shells sorted by name, got %s before %s", shells[i-1].Name, shells[i].Name)
+		}
+	}
+
+	out, err = catalog.DumpEffective("", "")
+	if err != nil {
+		t.Fatalf("DumpEffective() error = %v", err)
+	}
+	var all map[string][]interface{}
+	if err := yaml.Unmarshal(out, &all); err != nil || len(all["tools"]) != len(catalog.Tools) {
+		t.Errorf("Expected every type keyed by name, got %v", err)
+	}
+
+	if out, err := catalog.DumpEffective("tools", "rg"); err != nil || !strings.HasPrefix(string(out), "name: ripgrep\n") {
+		t.Errorf("Expected a tool to be found by its alias, got %v:\n%s", err, out)
+	}
+	if _, err := catalog.DumpEffective("plugins", ""); err == nil || !strings.Contains(err.Error(), "unknown configuration type") {
+		t.Errorf("Expected an unknown type error, got %v", err)
+	}
+	if _, err := catalog.DumpEffective("tools", "no-such-tool"); err == nil || !strings.Contains(err.Error(), `no tool named "no-such-tool"`) {
+		t.Errorf("Expected a missing tool error, got %v", err)
+	}
+}
@@ -4,8 +4,11 @@
 package config
 
 import (
+	"crypto/sha256"
 	"embed"
+	"encoding/hex"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 )
@@ -87,4 +90,26 @@ func (l *Loader) GetEmbeddedConfigPath(relativePath string) (string, error) {
 
 	// Return the path in the extracted directory
 	return filepath.Join(l.baseDir, "defaults", relativePath), nil
-} 
\ No newline at end of file
+} 
+// EmbeddedConfigVersion identifies the default configs built into the binary: a
+// short hash of every embedded file's path and content, so two binaries with
+// the same configs report the same version
+func EmbeddedConfigVersion() string {
+	h := sha256.New()
+	err := fs.WalkDir(defaultConfigs, "defaults", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := defaultConfigs.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", path, len(data))
+		h.Write(data)
+		return nil
+	})
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ChecksumsFileName records, in the user config directory, the checksum of each
+// default config as it was extracted
+const ChecksumsFileName = ".checksums.json"
+
+// RepairedFile is a default config that was damaged and extracted again
+type RepairedFile struct {
+	Path string
+	// Backup is where the damaged copy was kept, or empty when it was only a
+	// truncated write with nothing worth keeping
+	Backup string
+}
+
+// checksums maps a default config's slash-separated path, relative to the
+// config directory, to the hex sha256 of the content bootstrap-cli wrote
+type checksums map[string]string
+
+// loadChecksums reads the checksums recorded in dir. A missing or unreadable
+// file yields none, so every file is verified against its content instead.
+func loadChecksums(dir string) checksums {
+	sums := make(checksums)
+	data, err := os.ReadFile(filepath.Join(dir, ChecksumsFileName))
+	if err != nil {
+		return sums
+	}
+	if err := json.Unmarshal(data, &sums); err != nil || sums == nil {
+		return make(checksums)
+	}
+	return sums
+}
+
+// save writes the checksums to dir, replacing the file atomically
+func (c checksums) save(dir string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode config checksums: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(dir, ChecksumsFileName), append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write config checksums: %w", err)
+	}
+	return nil
+}
+
+// RepairDefaults re-extracts the default configs in the loader's directory that
+// were damaged, e.g. truncated by an interrupted extraction, so they don't fail
+// later with a parse error. Files the user edited are left alone: only a file
+// that is a truncated copy of its default with no checksum recorded for it, or
+// that no longer parses and has changed since it was extracted, is replaced,
+// and the latter is backed up first. Missing files are not recreated. Nothing happens when the directory
+// does not exist.
+func (l *Loader) RepairDefaults() ([]RepairedFile, error) {
+	if info, err := os.Stat(l.baseDir); err != nil || !info.IsDir() {
+		return nil, nil
+	}
+	return l.syncDefaults(false)
+}
+
+// syncDefaults verifies every embedded default config against its copy in the
+// loader's directory, writing missing ones when create is set and repairing
+// damaged ones, and records the checksum of each copy that matches a default
+func (l *Loader) syncDefaults(create bool) ([]RepairedFile, error) {
+	sums := loadChecksums(l.baseDir)
+	changed := false
+	var repaired []RepairedFile
+	backupDir := filepath.Join(l.baseDir, BackupDirName, "repair-"+time.Now().Format("20060102-150405"))
+
+	err := fs.WalkDir(l.configFS, l.defaultsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip directories and schema files
+		if d.IsDir() || strings.HasSuffix(d.Name(), "schema.yaml") {
+			return nil
+		}
+
+		data, err := l.configFS.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		relPath, err := filepath.Rel(l.defaultsDir, path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path for %s: %w", path, err)
+		}
+		key := filepath.ToSlash(relPath)
+		targetPath := filepath.Join(l.baseDir, relPath)
+
+		// The copy records the schema it was written for
+		want := stampSchemaVersion(data)
+		wantSum := checksum(want)
+
+		current, err := os.ReadFile(targetPath)
+		switch {
+		case os.IsNotExist(err):
+			if !create {
+				return nil
+			}
+			if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+				return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(targetPath), err)
+			}
+			if err := writeFileAtomic(targetPath, want); err != nil {
+				return fmt.Errorf("failed to write %s: %w", targetPath, err)
+			}
+		case err != nil:
+			return fmt.Errorf("failed to read %s: %w", targetPath, err)
+		default:
+			currentSum := checksum(current)
+			if currentSum == wantSum || currentSum == sums[key] {
+				// Identical to the default, or untouched since an older one was extracted
+				if sums[key] == "" {
+					sums[key] = currentSum
+					changed = true
+				}
+				return nil
+			}
+			// A shortened copy is only an unfinished write when no checksum was ever
+			// recorded for it: once one was, the user had the whole file and cut it
+			truncated := sums[key] == "" && len(current) < len(want) && bytes.HasPrefix(want, current)
+			if !truncated {
+				if _, err := parseConfigDoc(current); err == nil {
+					// Edited by the user
+					return nil
+				}
+			}
+			file := RepairedFile{Path: targetPath}
+			if !truncated {
+				file.Backup = filepath.Join(backupDir, relPath)
+				if err := os.MkdirAll(filepath.Dir(file.Backup), 0755); err != nil {
+					return fmt.Errorf("failed to create backup directory: %w", err)
+				}
+				if err := os.WriteFile(file.Backup, current, 0644); err != nil {
+					return fmt.Errorf("failed to back up %s: %w", targetPath, err)
+				}
+			}
+			if err := writeFileAtomic(targetPath, want); err != nil {
+				return fmt.Errorf("failed to repair %s: %w", targetPath, err)
+			}
+			repaired = append(repaired, file)
+		}
+		sums[key] = wantSum
+		changed = true
+		return nil
+	})
+	if err != nil {
+		return repaired, err
+	}
+
+	if changed {
+		if err := sums.save(l.baseDir); err != nil {
+			return repaired, err
+		}
+	}
+	return repaired, nil
+}
+
+// checksum returns the hex sha256 of data
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// over path, so an interrupted write never leaves a partial file behind
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
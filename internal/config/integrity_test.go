@@ -0,0 +1,178 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// firstDefault returns the relative path of an extracted default shell config
+func firstDefault(t *testing.T, dir string) string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, "shells", "*.yaml"))
+	if err != nil || len(matches) == 0 {
+		t.Fatalf("no extracted shell configs in %s", dir)
+	}
+	rel, err := filepath.Rel(dir, matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rel
+}
+
+func TestExtractDefaultsRecordsChecksums(t *testing.T) {
+	dir := t.TempDir()
+	if err := NewLoader(dir).ExtractDefaults(); err != nil {
+		t.Fatalf("ExtractDefaults: %v", err)
+	}
+	rel := firstDefault(t, dir)
+
+	data, err := os.ReadFile(filepath.Join(dir, ChecksumsFileName))
+	if err != nil {
+		t.Fatalf("checksums not written: %v", err)
+	}
+	var sums map[string]string
+	if err := json.Unmarshal(data, &sums); err != nil {
+		t.Fatalf("invalid checksums file: %v", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(dir, rel))
+	if got := sums[filepath.ToSlash(rel)]; got != checksum(content) {
+		t.Errorf("checksum for %s = %q, want %q", rel, got, checksum(content))
+	}
+}
+
+func TestRepairDefaultsRestoresTruncatedFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := NewLoader(dir).ExtractDefaults(); err != nil {
+		t.Fatalf("ExtractDefaults: %v", err)
+	}
+	rel := firstDefault(t, dir)
+	path := filepath.Join(dir, rel)
+	want, _ := os.ReadFile(path)
+	// The extraction was cut short before its checksums were recorded
+	if err := os.Remove(filepath.Join(dir, ChecksumsFileName)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, want[:len(want)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repaired, err := NewLoader(dir).RepairDefaults()
+	if err != nil {
+		t.Fatalf("RepairDefaults: %v", err)
+	}
+	if len(repaired) != 1 || repaired[0].Path != path || repaired[0].Backup != "" {
+		t.Fatalf("repaired = %+v, want only %s without a backup", repaired, path)
+	}
+	if got, _ := os.ReadFile(path); string(got) != string(want) {
+		t.Errorf("%s was not restored", rel)
+	}
+}
+
+func TestRepairDefaultsKeepsShortenedFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := NewLoader(dir).ExtractDefaults(); err != nil {
+		t.Fatalf("ExtractDefaults: %v", err)
+	}
+	rel := firstDefault(t, dir)
+	path := filepath.Join(dir, rel)
+	want, _ := os.ReadFile(path)
+	// The user deleted the trailing lines of a recorded default
+	shortened := want[:bytes.LastIndexByte(want[:len(want)/2], '\n')+1]
+	if err := os.WriteFile(path, shortened, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repaired, err := NewLoader(dir).RepairDefaults()
+	if err != nil {
+		t.Fatalf("RepairDefaults: %v", err)
+	}
+	if len(repaired) != 0 {
+		t.Errorf("repaired = %+v, want none", repaired)
+	}
+	if got := mustRead(t, path); string(got) != string(shortened) {
+		t.Errorf("shortened %s was overwritten", rel)
+	}
+}
+
+func TestRepairDefaultsBacksUpUnparseableFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := NewLoader(dir).ExtractDefaults(); err != nil {
+		t.Fatalf("ExtractDefaults: %v", err)
+	}
+	rel := firstDefault(t, dir)
+	path := filepath.Join(dir, rel)
+	broken := "name: [unterminated\n"
+	if err := os.WriteFile(path, []byte(broken), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repaired, err := NewLoader(dir).RepairDefaults()
+	if err != nil {
+		t.Fatalf("RepairDefaults: %v", err)
+	}
+	if len(repaired) != 1 || repaired[0].Backup == "" {
+		t.Fatalf("repaired = %+v, want one file with a backup", repaired)
+	}
+	if got, _ := os.ReadFile(repaired[0].Backup); string(got) != broken {
+		t.Errorf("backup = %q, want the damaged copy", got)
+	}
+	if _, err := parseConfigDoc(mustRead(t, path)); err != nil {
+		t.Errorf("%s still does not parse: %v", rel, err)
+	}
+}
+
+func TestRepairDefaultsKeepsUserEditsAndDeletions(t *testing.T) {
+	dir := t.TempDir()
+	if err := NewLoader(dir).ExtractDefaults(); err != nil {
+		t.Fatalf("ExtractDefaults: %v", err)
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "shells", "*.yaml"))
+	if len(matches) < 2 {
+		t.Skip("need two default shell configs")
+	}
+	edited, deleted := matches[0], matches[1]
+	custom := "name: custom\ndescription: edited by the user\n"
+	if err := os.WriteFile(edited, []byte(custom), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(deleted); err != nil {
+		t.Fatal(err)
+	}
+
+	repaired, err := NewLoader(dir).RepairDefaults()
+	if err != nil {
+		t.Fatalf("RepairDefaults: %v", err)
+	}
+	if len(repaired) != 0 {
+		t.Errorf("repaired = %+v, want none", repaired)
+	}
+	if got := string(mustRead(t, edited)); got != custom {
+		t.Errorf("user edit was overwritten: %q", got)
+	}
+	if _, err := os.Stat(deleted); !os.IsNotExist(err) {
+		t.Errorf("deleted config %s was recreated", deleted)
+	}
+}
+
+func TestRepairDefaultsMissingDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "missing")
+	repaired, err := NewLoader(dir).RepairDefaults()
+	if err != nil || len(repaired) != 0 {
+		t.Fatalf("RepairDefaults = %v, %v; want nothing", repaired, err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Error("RepairDefaults created the config directory")
+	}
+}
+
+func mustRead(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
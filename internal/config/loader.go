@@ -3,15 +3,16 @@ package config
 import (
 	"embed"
 	"fmt"
-	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"gopkg.in/yaml.v3"
 
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/release"
 )
 
 //go:embed defaults/**
@@ -20,14 +21,20 @@ var defaultConfigs embed.FS
 // Loader handles loading and parsing configuration files
 type Loader struct {
 	baseDir     string // User config directory
+	overlayDir  string // Overlay merged over the user config, if any
 	defaultsDir string // Embedded defaults directory
 	configFS    embed.FS
+
+	mu      sync.Mutex
+	catalog *Catalog // Set by LoadAll
 }
 
-// NewLoader creates a new configuration loader
+// NewLoader creates a new configuration loader. The overlay selected with
+// --overlay (see OverlayEnvVar) is merged over baseDir.
 func NewLoader(baseDir string) *Loader {
 	loader := &Loader{
 		baseDir:     baseDir,
+		overlayDir:  os.Getenv(OverlayEnvVar),
 		defaultsDir: "defaults",
 		configFS:    defaultConfigs,
 	}
@@ -46,17 +53,32 @@ func mergeConfigs[T any](defaultConfig, userConfig *T) *T {
 	
 	// Use reflection or yaml.Marshal/Unmarshal to merge structs
 	defaultYAML, _ := yaml.Marshal(defaultConfig)
-	userYAML, _ := yaml.Marshal(userConfig)
+	var userNode yaml.Node
+	_ = userNode.Encode(userConfig)
+	pruneMatching(&userNode, isUnsetNode)
 	
 	merged := *defaultConfig // Create a copy of default
 	_ = yaml.Unmarshal(defaultYAML, &merged)
-	_ = yaml.Unmarshal(userYAML, &merged) // User config overrides defaults
+	_ = userNode.Decode(&merged) // User config overrides defaults
 	
 	return &merged
 }
 
+// isUnsetNode reports whether a user config left the field of n unset, so it
+// keeps its default (e.g. version) instead of clearing it. false and 0 count as
+// set, as they may be set on purpose.
+func isUnsetNode(n *yaml.Node) bool {
+	if n.Kind == yaml.ScalarNode && n.Tag != "!!null" && n.Tag != "!!str" {
+		return false
+	}
+	return isEmptyNode(n)
+}
+
 // LoadTools loads all tool configurations as pipeline.Tool structs
 func (l *Loader) LoadTools() ([]*pipeline.Tool, error) {
+	if c := l.cached(); c != nil {
+		return c.Tools, nil
+	}
 	configs, err := l.loadConfigsFromDir("tools")
 	if err != nil {
 		return nil, err
@@ -70,6 +92,9 @@ func (l *Loader) LoadTools() ([]*pipeline.Tool, error) {
 
 // LoadFonts loads all font configurations
 func (l *Loader) LoadFonts() ([]*interfaces.Font, error) {
+	if c := l.cached(); c != nil {
+		return c.Fonts, nil
+	}
 	configs, err := l.loadConfigsFromDir("fonts")
 	if err != nil {
 		return nil, err
@@ -83,6 +108,9 @@ func (l *Loader) LoadFonts() ([]*interfaces.Font, error) {
 
 // LoadLanguages loads all language configurations
 func (l *Loader) LoadLanguages() ([]*interfaces.Language, error) {
+	if c := l.cached(); c != nil {
+		return c.Languages, nil
+	}
 	configs, err := l.loadConfigsFromDir("languages")
 	if err != nil {
 		return nil, err
@@ -96,6 +124,9 @@ func (l *Loader) LoadLanguages() ([]*interfaces.Language, error) {
 
 // LoadDotfiles loads all dotfile configurations
 func (l *Loader) LoadDotfiles() ([]*interfaces.Dotfile, error) {
+	if c := l.cached(); c != nil {
+		return c.Dotfiles, nil
+	}
 	configs, err := l.loadConfigsFromDir("dotfiles")
 	if err != nil {
 		return nil, err
@@ -109,6 +140,9 @@ func (l *Loader) LoadDotfiles() ([]*interfaces.Dotfile, error) {
 
 // LoadShells loads all shell configurations
 func (l *Loader) LoadShells() ([]*interfaces.Shell, error) {
+	if c := l.cached(); c != nil {
+		return c.Shells, nil
+	}
 	configs, err := l.loadConfigsFromDir("shells")
 	if err != nil {
 		return nil, err
@@ -122,6 +156,9 @@ func (l *Loader) LoadShells() ([]*interfaces.Shell, error) {
 
 // LoadLanguageManagers loads all language manager configurations
 func (l *Loader) LoadLanguageManagers() ([]*pipeline.Tool, error) {
+	if c := l.cached(); c != nil {
+		return c.LanguageManagers, nil
+	}
 	dir := filepath.Join(l.defaultsDir, "language_managers")
 	managers := make([]*pipeline.Tool, 0)
 
@@ -141,110 +178,118 @@ func (l *Loader) LoadLanguageManagers() ([]*pipeline.Tool, error) {
 			return nil, fmt.Errorf("error reading language manager file %s: %w", path, err)
 		}
 
-		var manager pipeline.Tool
-		if err := yaml.Unmarshal(data, &manager); err != nil {
+		manager, err := unmarshalTool(data)
+		if err != nil {
 			return nil, fmt.Errorf("error parsing language manager %s: %w", path, err)
 		}
 
-		managers = append(managers, &manager)
+		managers = append(managers, manager)
 	}
 
 	return managers, nil
 }
 
-// loadConfigsFromDir loads all configurations from both default and user directories
+// loadConfigsFromDir loads all configurations from the embedded defaults, the
+// user config directory and the overlay, each layer overriding the one before
 func (l *Loader) loadConfigsFromDir(dir string) (interface{}, error) {
-	var configs interface{}
-	
 	// Load defaults first
-	defaultConfigs, err := l.loadDefaultConfigs(dir)
+	configs, err := l.loadDefaultConfigs(dir)
 	if err != nil {
 		return nil, fmt.Errorf("error loading default configs: %w", err)
 	}
 	
-	// Load user configs
-	userConfigs, err := l.loadUserConfigs(dir)
-	if err != nil && !os.IsNotExist(err) {
-		return nil, fmt.Errorf("error loading user configs: %w", err)
+	// Load user configs, then the overlay's
+	for _, root := range l.userRoots() {
+		layer, err := l.loadUserConfigsFrom(root, dir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("error loading user configs from %s: %w", root, err)
+		}
+		if configs, err = l.mergeLayer(dir, configs, layer); err != nil {
+			return nil, err
+		}
 	}
-	
-	// Merge configs based on type
+	return l.withoutDisabled(configs)
+}
+
+// mergeLayer merges one layer of user configs over base, matching items by name
+func (l *Loader) mergeLayer(dir string, base, layer interface{}) (interface{}, error) {
+	var configs interface{}
 	switch dir {
 	case "tools":
-		defaultTools, ok := defaultConfigs.([]*pipeline.Tool)
+		defaultTools, ok := base.([]*pipeline.Tool)
 		if !ok {
-			return nil, fmt.Errorf("invalid default tools configuration type: expected []*pipeline.Tool, got %T", defaultConfigs)
+			return nil, fmt.Errorf("invalid default tools configuration type: expected []*pipeline.Tool, got %T", base)
 		}
 		var userTools []*pipeline.Tool
-		if userConfigs != nil {
-			userTools, ok = userConfigs.([]*pipeline.Tool)
+		if layer != nil {
+			userTools, ok = layer.([]*pipeline.Tool)
 			if !ok {
-				return nil, fmt.Errorf("invalid user tools configuration type: expected []*pipeline.Tool, got %T", userConfigs)
+				return nil, fmt.Errorf("invalid user tools configuration type: expected []*pipeline.Tool, got %T", layer)
 			}
 		}
 		configs = l.mergeToolConfigs(defaultTools, userTools)
 	case "fonts":
-		defaultFonts, ok := defaultConfigs.([]*interfaces.Font)
+		defaultFonts, ok := base.([]*interfaces.Font)
 		if !ok {
-			return nil, fmt.Errorf("invalid default fonts configuration type: expected []*interfaces.Font, got %T", defaultConfigs)
+			return nil, fmt.Errorf("invalid default fonts configuration type: expected []*interfaces.Font, got %T", base)
 		}
 		var userFonts []*interfaces.Font
-		if userConfigs != nil {
-			userFonts, ok = userConfigs.([]*interfaces.Font)
+		if layer != nil {
+			userFonts, ok = layer.([]*interfaces.Font)
 			if !ok {
-				return nil, fmt.Errorf("invalid user fonts configuration type: expected []*interfaces.Font, got %T", userConfigs)
+				return nil, fmt.Errorf("invalid user fonts configuration type: expected []*interfaces.Font, got %T", layer)
 			}
 		}
 		configs = l.mergeFontConfigs(defaultFonts, userFonts)
 	case "languages":
-		defaultLanguages, ok := defaultConfigs.([]*interfaces.Language)
+		defaultLanguages, ok := base.([]*interfaces.Language)
 		if !ok {
-			return nil, fmt.Errorf("invalid default languages configuration type: expected []*interfaces.Language, got %T", defaultConfigs)
+			return nil, fmt.Errorf("invalid default languages configuration type: expected []*interfaces.Language, got %T", base)
 		}
 		var userLanguages []*interfaces.Language
-		if userConfigs != nil {
-			userLanguages, ok = userConfigs.([]*interfaces.Language)
+		if layer != nil {
+			userLanguages, ok = layer.([]*interfaces.Language)
 			if !ok {
-				return nil, fmt.Errorf("invalid user languages configuration type: expected []*interfaces.Language, got %T", userConfigs)
+				return nil, fmt.Errorf("invalid user languages configuration type: expected []*interfaces.Language, got %T", layer)
 			}
 		}
 		configs = l.mergeLanguageConfigs(defaultLanguages, userLanguages)
 	case "dotfiles":
-		defaultDotfiles, ok := defaultConfigs.([]*interfaces.Dotfile)
+		defaultDotfiles, ok := base.([]*interfaces.Dotfile)
 		if !ok {
-			return nil, fmt.Errorf("invalid default dotfiles configuration type: expected []*interfaces.Dotfile, got %T", defaultConfigs)
+			return nil, fmt.Errorf("invalid default dotfiles configuration type: expected []*interfaces.Dotfile, got %T", base)
 		}
 		var userDotfiles []*interfaces.Dotfile
-		if userConfigs != nil {
-			userDotfiles, ok = userConfigs.([]*interfaces.Dotfile)
+		if layer != nil {
+			userDotfiles, ok = layer.([]*interfaces.Dotfile)
 			if !ok {
-				return nil, fmt.Errorf("invalid user dotfiles configuration type: expected []*interfaces.Dotfile, got %T", userConfigs)
+				return nil, fmt.Errorf("invalid user dotfiles configuration type: expected []*interfaces.Dotfile, got %T", layer)
 			}
 		}
 		configs = l.mergeDotfileConfigs(defaultDotfiles, userDotfiles)
 	case "shells":
-		defaultShells, ok := defaultConfigs.([]*interfaces.Shell)
+		defaultShells, ok := base.([]*interfaces.Shell)
 		if !ok {
-			return nil, fmt.Errorf("invalid default shells configuration type: expected []*interfaces.Shell, got %T", defaultConfigs)
+			return nil, fmt.Errorf("invalid default shells configuration type: expected []*interfaces.Shell, got %T", base)
 		}
 		var userShells []*interfaces.Shell
-		if userConfigs != nil {
-			userShells, ok = userConfigs.([]*interfaces.Shell)
+		if layer != nil {
+			userShells, ok = layer.([]*interfaces.Shell)
 			if !ok {
-				return nil, fmt.Errorf("invalid user shells configuration type: expected []*interfaces.Shell, got %T", userConfigs)
+				return nil, fmt.Errorf("invalid user shells configuration type: expected []*interfaces.Shell, got %T", layer)
 			}
 		}
 		configs = l.mergeShellConfigs(defaultShells, userShells)
 	case "language_managers":
-		defaultManagers, ok := defaultConfigs.([]*pipeline.Tool)
+		defaultManagers, ok := base.([]*pipeline.Tool)
 		if !ok {
-			return nil, fmt.Errorf("invalid default language managers configuration type: expected []*pipeline.Tool, got %T", defaultConfigs)
+			return nil, fmt.Errorf("invalid default language managers configuration type: expected []*pipeline.Tool, got %T", base)
 		}
 		var userManagers []*pipeline.Tool
-		if userConfigs != nil {
-			userManagers, ok = userConfigs.([]*pipeline.Tool)
+		if layer != nil {
+			userManagers, ok = layer.([]*pipeline.Tool)
 			if !ok {
-				return nil, fmt.Errorf("invalid user language managers configuration type: expected []*pipeline.Tool, got %T", userConfigs)
+				return nil, fmt.Errorf("invalid user language managers configuration type: expected []*pipeline.Tool, got %T", layer)
 			}
 		}
 		configs = l.mergeToolConfigs(defaultManagers, userManagers)
@@ -293,8 +338,8 @@ func (l *Loader) loadDefaultConfigs(dir string) (interface{}, error) {
 					return fmt.Errorf("error reading file %s: %w", path, err)
 				}
 				
-				var tool pipeline.Tool
-				if err := yaml.Unmarshal(data, &tool); err != nil {
+				tool, err := unmarshalTool(data)
+				if err != nil {
 					return fmt.Errorf("error parsing tool %s: %w", path, err)
 				}
 				
@@ -302,11 +347,11 @@ func (l *Loader) loadDefaultConfigs(dir string) (interface{}, error) {
 				if tool.Category == "" {
 					rel, _ := filepath.Rel(defaultDir, dirPath)
 					if rel != "." {
-						tool.Category = pipeline.ToolCategory(rel)
+						tool.Category = rel
 					}
 				}
 				
-				tools = append(tools, &tool)
+				tools = append(tools, tool)
 			}
 			return nil
 		}
@@ -514,11 +559,11 @@ func (l *Loader) loadDefaultConfigs(dir string) (interface{}, error) {
 					return fmt.Errorf("error reading file %s: %w", path, err)
 				}
 				
-				var tool pipeline.Tool
-				if err := yaml.Unmarshal(data, &tool); err != nil {
+				tool, err := unmarshalTool(data)
+				if err != nil {
 					return fmt.Errorf("error parsing language manager %s: %w", path, err)
 				}
-				managers = append(managers, &tool)
+				managers = append(managers, tool)
 			}
 			return nil
 		}
@@ -535,9 +580,9 @@ func (l *Loader) loadDefaultConfigs(dir string) (interface{}, error) {
 	return configs, nil
 }
 
-// loadUserConfigs loads configurations from user directory
-func (l *Loader) loadUserConfigs(dir string) (interface{}, error) {
-	userDir := filepath.Join(l.baseDir, dir)
+// loadUserConfigsFrom loads configurations from dir under a user config root
+func (l *Loader) loadUserConfigsFrom(root, dir string) (interface{}, error) {
+	userDir := filepath.Join(root, dir)
 	if _, err := os.Stat(userDir); os.IsNotExist(err) {
 		return nil, err
 	}
@@ -817,11 +862,59 @@ func (l *Loader) loadTool(path string) (*pipeline.Tool, error) {
 		return nil, fmt.Errorf("error reading file %s: %w", path, err)
 	}
 	
-	var tool pipeline.Tool
-	if err := yaml.Unmarshal(data, &tool); err != nil {
+	tool, err := unmarshalTool(data)
+	if err != nil {
 		return nil, fmt.Errorf("error unmarshaling YAML from %s: %w", path, err)
 	}
 	
+	return tool, nil
+}
+
+// catalogTool captures the snake_case catalog fields that pipeline.Tool's
+// default YAML mapping does not read
+type catalogTool struct {
+	PackageManager string        `yaml:"package_manager"`
+	SupportedOS    []string      `yaml:"supported_os"`
+	UnsupportedOS  []string      `yaml:"unsupported_os"`
+	MinVersion     string        `yaml:"min_version"`
+	GitHubRelease  *release.Spec `yaml:"github_release"`
+}
+
+// unmarshalTool parses a tool definition in the catalog format into a
+// pipeline.Tool. Its embedded interfaces.Tool is the definition the package
+// installer reads, and the install and verify strategies are derived from it.
+func unmarshalTool(data []byte) (*pipeline.Tool, error) {
+	var tool pipeline.Tool
+	if err := yaml.Unmarshal(data, &tool); err != nil {
+		return nil, err
+	}
+	var catalog catalogTool
+	if err := yaml.Unmarshal(data, &catalog); err != nil {
+		return nil, err
+	}
+
+	for manager, pkg := range tool.PackageNameMap() {
+		if tool.Install.PackageNames == nil {
+			tool.Install.PackageNames = make(map[string]string)
+		}
+		tool.Install.PackageNames[manager] = pkg
+	}
+	if tool.Verify.Command.Command == "" {
+		tool.Verify.Command.Command = tool.VerifyCommand
+	}
+	if tool.PreferredManager == "" {
+		tool.PreferredManager = catalog.PackageManager
+	}
+	tool.SupportedOS = catalog.SupportedOS
+	tool.UnsupportedOS = catalog.UnsupportedOS
+	tool.MinVersion = catalog.MinVersion
+	if catalog.GitHubRelease != nil {
+		if err := catalog.GitHubRelease.Validate(); err != nil {
+			return nil, err
+		}
+		tool.Release = catalog.GitHubRelease
+	}
+
 	return &tool, nil
 }
 
@@ -1036,15 +1129,24 @@ func (l *Loader) GetDotfiles() ([]*interfaces.Dotfile, error) {
 	return dotfiles, nil
 }
 
-// loadDotfile loads a single dotfile configuration from a YAML file
+// loadDotfile loads a single dotfile configuration from a YAML file. Dotfiles
+// written by an older bootstrap-cli are migrated in memory, so they load
+// before 'bootstrap-cli migrate' has rewritten them.
 func (l *Loader) loadDotfile(path string) (*interfaces.Dotfile, error) {
 	data, err := os.ReadFile(path)
 		if err != nil {
 		return nil, fmt.Errorf("error reading file %s: %w", path, err)
 		}
+	if doc, err := parseConfigDoc(data); err == nil {
+		if version, err := schemaVersion(doc); err == nil && version < SchemaVersion {
+			if migrated, err := MigrateConfig("dotfiles", data); err == nil {
+				data = migrated
+			}
+		}
+	}
 	var dotfile interfaces.Dotfile
 	if err := yaml.Unmarshal(data, &dotfile); err != nil {
-		return nil, fmt.Errorf("error parsing dotfile %s: %w", path, err)
+		return nil, fmt.Errorf("error parsing dotfile %s (run 'bootstrap-cli migrate' to upgrade it): %w", path, err)
 	}
 	return &dotfile, nil
 }
@@ -1072,47 +1174,9 @@ func (l *Loader) ExtractDefaults() error {
 		}
 	}
 
-	// Walk through the embedded defaults and copy to user config
-	err := fs.WalkDir(l.configFS, l.defaultsDir, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Skip directories and schema files
-		if d.IsDir() || strings.HasSuffix(d.Name(), "schema.yaml") {
-			return nil
-		}
-
-		// Read the file
-		data, err := l.configFS.ReadFile(path)
-		if err != nil {
-			return fmt.Errorf("failed to read %s: %w", path, err)
-		}
-
-		// Create the target path
-		relPath, err := filepath.Rel(l.defaultsDir, path)
-		if err != nil {
-			return fmt.Errorf("failed to get relative path for %s: %w", path, err)
-		}
-		targetPath := filepath.Join(l.baseDir, relPath)
-
-		// Ensure the target directory exists
-		targetDir := filepath.Dir(targetPath)
-		if err := os.MkdirAll(targetDir, 0755); err != nil {
-			return fmt.Errorf("failed to create directory %s: %w", targetDir, err)
-		}
-
-		// Write the file if it doesn't exist
-		if _, err := os.Stat(targetPath); os.IsNotExist(err) {
-			if err := os.WriteFile(targetPath, data, 0644); err != nil {
-				return fmt.Errorf("failed to write %s: %w", targetPath, err)
-			}
-		}
-
-		return nil
-	})
-
-	if err != nil {
+	// Write missing defaults, recording the schema each was written for and its
+	// checksum, and repair any left damaged by an earlier interrupted run
+	if _, err := l.syncDefaults(true); err != nil {
 		return fmt.Errorf("failed to extract default configurations: %w", err)
 	}
 
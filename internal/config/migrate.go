@@ -0,0 +1,352 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaVersion is the config schema this build reads. Files without a
+// schema_version key are treated as version 1.
+const SchemaVersion = 2
+
+// SchemaVersionKey records the schema version in each stored config file.
+// Tools and languages already use "version" for the version to install.
+const SchemaVersionKey = "schema_version"
+
+// BackupDirName holds the originals of migrated files, under the config directory
+const BackupDirName = "backups"
+
+// Migration upgrades a config file from one schema version to the next
+type Migration struct {
+	// From is the version the migration applies to; it produces From+1
+	From int
+	// Description says what changed
+	Description string
+	// Apply rewrites doc, the file's top-level mapping, for a file of the given
+	// kind (its top-level directory, e.g. "dotfiles", or "settings")
+	Apply func(kind string, doc *yaml.Node) error
+}
+
+// migrations are applied in order to bring files up to SchemaVersion
+var migrations = []Migration{
+	{
+		From:        1,
+		Description: "dotfile shell_config uses exports/path/aliases maps and post_install is a list of commands",
+		Apply:       migrateDotfilesV1,
+	},
+}
+
+// MigratedFile describes a config file the migrator upgraded
+type MigratedFile struct {
+	Path string
+	From int
+	To   int
+}
+
+// MigrationReport is the outcome of a migration run
+type MigrationReport struct {
+	Migrated []MigratedFile
+	// BackupDir holds the original files; empty when nothing was migrated or on a dry run
+	BackupDir string
+}
+
+// Migrator upgrades the stored configs in a user config directory
+type Migrator struct {
+	// Dir is the user config directory
+	Dir string
+	// DryRun reports what would be migrated without writing anything
+	DryRun bool
+	// Now timestamps the backup directory (defaults to time.Now)
+	Now func() time.Time
+}
+
+// Run migrates every YAML config file under Dir that is older than
+// SchemaVersion, backing up the originals first. Files newer than this build
+// are an error, since downgrading them could lose data.
+func (m *Migrator) Run() (*MigrationReport, error) {
+	type pending struct {
+		rel  string
+		from int
+		data []byte
+	}
+	var todo []pending
+
+	err := filepath.WalkDir(m.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != m.Dir && d.Name() == BackupDirName {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".yaml") || strings.HasSuffix(d.Name(), "schema.yaml") {
+			return nil
+		}
+		rel, err := filepath.Rel(m.Dir, path)
+		if err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		doc, err := parseConfigDoc(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		version, err := schemaVersion(doc)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if version > SchemaVersion {
+			return fmt.Errorf("%s uses schema version %d, newer than this bootstrap-cli supports (%d)", path, version, SchemaVersion)
+		}
+		if version < SchemaVersion {
+			todo = append(todo, pending{rel: rel, from: version, data: data})
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return &MigrationReport{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report := &MigrationReport{}
+	if len(todo) == 0 {
+		return report, nil
+	}
+	sort.Slice(todo, func(i, j int) bool { return todo[i].rel < todo[j].rel })
+
+	// Migrate everything in memory first so a failing file leaves the directory untouched
+	out := make([][]byte, len(todo))
+	for i, p := range todo {
+		data, err := MigrateConfig(configKind(p.rel), p.data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate %s: %w", filepath.Join(m.Dir, p.rel), err)
+		}
+		out[i] = data
+		report.Migrated = append(report.Migrated, MigratedFile{Path: filepath.Join(m.Dir, p.rel), From: p.from, To: SchemaVersion})
+	}
+	if m.DryRun {
+		return report, nil
+	}
+
+	now := time.Now
+	if m.Now != nil {
+		now = m.Now
+	}
+	report.BackupDir = filepath.Join(m.Dir, BackupDirName, "migrate-"+now().Format("20060102-150405"))
+	for _, p := range todo {
+		backup := filepath.Join(report.BackupDir, p.rel)
+		if err := os.MkdirAll(filepath.Dir(backup), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create backup directory: %w", err)
+		}
+		if err := os.WriteFile(backup, p.data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to back up %s: %w", p.rel, err)
+		}
+	}
+	for i, p := range todo {
+		path := filepath.Join(m.Dir, p.rel)
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, out[i], 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return nil, fmt.Errorf("failed to replace %s: %w", path, err)
+		}
+	}
+	return report, nil
+}
+
+// MigrateConfig upgrades one config file of the given kind to SchemaVersion and
+// stamps it with schema_version
+func MigrateConfig(kind string, data []byte) ([]byte, error) {
+	doc, err := parseConfigDoc(data)
+	if err != nil {
+		return nil, err
+	}
+	version, err := schemaVersion(doc)
+	if err != nil {
+		return nil, err
+	}
+	for _, mig := range migrations {
+		if mig.From < version {
+			continue
+		}
+		if err := mig.Apply(kind, doc); err != nil {
+			return nil, fmt.Errorf("migration from version %d: %w", mig.From, err)
+		}
+		version = mig.From + 1
+	}
+	setMappingValue(doc, SchemaVersionKey, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(SchemaVersion)})
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// configKind returns the config type of a file from its path relative to the config directory
+func configKind(rel string) string {
+	if rel == SettingsFileName {
+		return "settings"
+	}
+	return strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]
+}
+
+// parseConfigDoc returns the top-level mapping of a YAML config file
+func parseConfigDoc(data []byte) (*yaml.Node, error) {
+	var file yaml.Node
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if len(file.Content) == 0 {
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}, nil
+	}
+	doc := file.Content[0]
+	if doc.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("expected a mapping at the top level")
+	}
+	// Keep the document's head comment with the mapping being re-encoded
+	if doc.HeadComment == "" {
+		doc.HeadComment = file.HeadComment
+	}
+	return doc, nil
+}
+
+// schemaVersion returns the schema_version recorded in doc, or 1 if none is set
+func schemaVersion(doc *yaml.Node) (int, error) {
+	node := mappingValue(doc, SchemaVersionKey)
+	if node == nil {
+		return 1, nil
+	}
+	v, err := strconv.Atoi(node.Value)
+	if err != nil || v < 1 {
+		return 0, fmt.Errorf("invalid %s %q", SchemaVersionKey, node.Value)
+	}
+	return v, nil
+}
+
+// mappingValue returns the value of key in a mapping node, or nil
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue sets key in a mapping node, adding it at the top when missing
+func setMappingValue(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = value
+			return
+		}
+	}
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+	// Keep a leading file comment above the new key
+	if len(m.Content) > 0 {
+		keyNode.HeadComment, m.Content[0].HeadComment = m.Content[0].HeadComment, ""
+	}
+	m.Content = append([]*yaml.Node{keyNode, value}, m.Content...)
+}
+
+// renameMappingKey renames key in a mapping node if it is present
+func renameMappingKey(m *yaml.Node, from, to string) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == from {
+			m.Content[i].Value = to
+			return
+		}
+	}
+}
+
+// listToMap turns a sequence of {keyField: k, valueField: v} mappings into a k: v mapping
+func listToMap(seq *yaml.Node, keyField, valueField string) (*yaml.Node, error) {
+	out := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", HeadComment: seq.HeadComment}
+	for _, item := range seq.Content {
+		k, v := mappingValue(item, keyField), mappingValue(item, valueField)
+		if k == nil || v == nil {
+			return nil, fmt.Errorf("expected entries with %q and %q", keyField, valueField)
+		}
+		out.Content = append(out.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k.Value},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v.Value, Style: v.Style})
+	}
+	return out, nil
+}
+
+// migrateDotfilesV1 converts the list-based shell_config and post_install of
+// version 1 dotfiles to the maps and command strings interfaces.Dotfile reads
+func migrateDotfilesV1(kind string, doc *yaml.Node) error {
+	if kind != "dotfiles" {
+		return nil
+	}
+
+	if sc := mappingValue(doc, "shell_config"); sc != nil && sc.Kind == yaml.MappingNode {
+		for _, field := range []struct{ from, to, key, value string }{
+			{"env", "exports", "name", "value"},
+			{"aliases", "aliases", "name", "command"},
+		} {
+			seq := mappingValue(sc, field.from)
+			if seq == nil || seq.Kind != yaml.SequenceNode {
+				continue
+			}
+			m, err := listToMap(seq, field.key, field.value)
+			if err != nil {
+				return fmt.Errorf("shell_config.%s: %w", field.from, err)
+			}
+			setMappingValue(sc, field.from, m)
+			renameMappingKey(sc, field.from, field.to)
+		}
+		renameMappingKey(sc, "path_append", "path")
+	}
+
+	if post := mappingValue(doc, "post_install"); post != nil && post.Kind == yaml.SequenceNode {
+		for i, item := range post.Content {
+			if item.Kind != yaml.MappingNode {
+				continue
+			}
+			cmd := mappingValue(item, "command")
+			if cmd == nil {
+				return fmt.Errorf("post_install entry %d has no command", i+1)
+			}
+			entry := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: cmd.Value, Style: cmd.Style}
+			if desc := mappingValue(item, "description"); desc != nil {
+				entry.HeadComment = desc.Value
+			}
+			post.Content[i] = entry
+		}
+	}
+	return nil
+}
+
+// stampSchemaVersion prepends schema_version to a default config file that has none
+func stampSchemaVersion(data []byte) []byte {
+	if doc, err := parseConfigDoc(data); err != nil || mappingValue(doc, SchemaVersionKey) != nil {
+		return data
+	}
+	return append([]byte(fmt.Sprintf("%s: %d\n", SchemaVersionKey, SchemaVersion)), data...)
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+const v1Dotfile = `# Zsh configuration
+name: zsh
+description: Zsh shell configuration
+category: shell
+files:
+  - source: zshrc
+    destination: ~/.zshrc
+shell_config:
+  env:
+    - name: EDITOR
+      value: nvim
+  path_append:
+    - ~/.local/bin
+  aliases:
+    - name: ll
+      command: ls -la
+post_install:
+  - command: echo done
+    description: Say done
+`
+
+func TestMigrateConfig_DotfilesV1(t *testing.T) {
+	data, err := MigrateConfig("dotfiles", []byte(v1Dotfile))
+	if err != nil {
+		t.Fatalf("MigrateConfig() error = %v", err)
+	}
+
+	var dotfile interfaces.Dotfile
+	if err := yaml.Unmarshal(data, &dotfile); err != nil {
+		t.Fatalf("Migrated dotfile does not parse: %v\n%s", err, data)
+	}
+	if dotfile.ShellConfig.Exports["EDITOR"] != "nvim" {
+		t.Errorf("Expected env to become exports, got %+v", dotfile.ShellConfig.Exports)
+	}
+	if len(dotfile.ShellConfig.Path) != 1 || dotfile.ShellConfig.Path[0] != "~/.local/bin" {
+		t.Errorf("Expected path_append to become path, got %v", dotfile.ShellConfig.Path)
+	}
+	if dotfile.ShellConfig.Aliases["ll"] != "ls -la" {
+		t.Errorf("Expected aliases to become a map, got %+v", dotfile.ShellConfig.Aliases)
+	}
+	if len(dotfile.PostInstall) != 1 || dotfile.PostInstall[0] != "echo done" {
+		t.Errorf("Expected post_install commands, got %v", dotfile.PostInstall)
+	}
+
+	text := string(data)
+	if !strings.Contains(text, "schema_version: 2") {
+		t.Errorf("Expected schema_version to be stamped:\n%s", text)
+	}
+	if !strings.Contains(text, "# Zsh configuration") || !strings.Contains(text, "# Say done") {
+		t.Errorf("Expected comments to be kept:\n%s", text)
+	}
+
+	again, err := MigrateConfig("dotfiles", data)
+	if err != nil {
+		t.Fatalf("MigrateConfig() on a current file error = %v", err)
+	}
+	if string(again) != text {
+		t.Errorf("Migrating a current file changed it:\n%s", again)
+	}
+}
+
+func TestMigrator_Run(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(rel, content string) {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("dotfiles/zsh.yaml", v1Dotfile)
+	writeFile("tools/git.yaml", "schema_version: 2\nname: git\n")
+
+	now := func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	dry := &Migrator{Dir: dir, DryRun: true, Now: now}
+	report, err := dry.Run()
+	if err != nil {
+		t.Fatalf("Dry run error = %v", err)
+	}
+	if len(report.Migrated) != 1 || report.BackupDir != "" {
+		t.Fatalf("Expected one file reported and no backup on a dry run, got %+v", report)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "dotfiles", "zsh.yaml")); string(data) != v1Dotfile {
+		t.Error("Dry run modified the file")
+	}
+
+	report, err = (&Migrator{Dir: dir, Now: now}).Run()
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(report.Migrated) != 1 || report.Migrated[0].From != 1 || report.Migrated[0].To != SchemaVersion {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+	backup, err := os.ReadFile(filepath.Join(report.BackupDir, "dotfiles", "zsh.yaml"))
+	if err != nil || string(backup) != v1Dotfile {
+		t.Errorf("Expected the original in the backup directory, got %q (%v)", backup, err)
+	}
+
+	// The backup is left alone and the migrated file is current
+	report, err = (&Migrator{Dir: dir, Now: now}).Run()
+	if err != nil || len(report.Migrated) != 0 {
+		t.Errorf("Expected nothing to migrate on a second run, got %+v (%v)", report, err)
+	}
+}
+
+func TestMigrator_RejectsNewerFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "tools"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tools", "git.yaml"), []byte("schema_version: 99\nname: git\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (&Migrator{Dir: dir}).Run(); err == nil {
+		t.Error("Expected an error for a file newer than this build")
+	}
+}
+
+func TestStampSchemaVersion(t *testing.T) {
+	stamped := string(stampSchemaVersion([]byte("name: git\n")))
+	if stamped != "schema_version: 2\nname: git\n" {
+		t.Errorf("Unexpected stamped file %q", stamped)
+	}
+	current := "schema_version: 2\nname: git\n"
+	if got := string(stampSchemaVersion([]byte(current))); got != current {
+		t.Errorf("Expected an already stamped file to be unchanged, got %q", got)
+	}
+}
+
+func TestLoadAll_MigratesV1UserDotfiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "dotfiles", "shell"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "dotfiles", "shell", "zsh.yaml"), []byte(v1Dotfile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	catalog, err := NewLoader(dir).LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll() error = %v, want the v1 dotfile migrated in memory", err)
+	}
+	for _, d := range catalog.Dotfiles {
+		if d.Name == "zsh" && d.ShellConfig.Exports["EDITOR"] != "nvim" {
+			t.Errorf("Expected the user's zsh dotfile with its exports, got %+v", d.ShellConfig)
+		}
+	}
+}
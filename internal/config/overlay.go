@@ -0,0 +1,140 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+)
+
+// OverlayEnvVar holds the overlay directory for this run and its child processes
+const OverlayEnvVar = "BOOTSTRAP_CLI_OVERLAY"
+
+// OverlayFileName is the optional file in an overlay directory that disables items
+const OverlayFileName = "overlay.yaml"
+
+// Overlay is the contents of an overlay's overlay.yaml
+type Overlay struct {
+	// Disable lists tools, languages, fonts, shells or dotfiles (by name or
+	// alias) to drop from the merged configuration
+	Disable []string `yaml:"disable,omitempty"`
+}
+
+// OverlaysDir returns where named overlays live (~/.bootstrap-cli/overlays)
+func OverlaysDir() (string, error) {
+	dir, err := manifest.StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "overlays"), nil
+}
+
+// ResolveOverlay returns the directory for an overlay given by name (e.g. "work"
+// for ~/.bootstrap-cli/overlays/work) or by path
+func ResolveOverlay(overlay string) (string, error) {
+	dir := overlay
+	if !strings.ContainsRune(overlay, filepath.Separator) {
+		overlays, err := OverlaysDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(overlays, overlay)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("overlay %s not found: %w", overlay, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("overlay %s is not a directory", dir)
+	}
+	return dir, nil
+}
+
+// LoadOverlay reads overlay.yaml in dir. A missing file yields an empty overlay.
+func LoadOverlay(dir string) (*Overlay, error) {
+	var overlay Overlay
+	path := filepath.Join(dir, OverlayFileName)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &overlay, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read overlay %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, &overlay); err != nil {
+		return nil, fmt.Errorf("failed to parse overlay %s: %w", path, err)
+	}
+	return &overlay, nil
+}
+
+// SetOverlay merges the overlay in dir over the user config; "" removes it
+func (l *Loader) SetOverlay(dir string) {
+	l.overlayDir = dir
+	l.Reset()
+}
+
+// userRoots returns the user config directories in merge order: the base
+// directory, then the overlay
+func (l *Loader) userRoots() []string {
+	roots := []string{l.baseDir}
+	if l.overlayDir != "" {
+		roots = append(roots, l.overlayDir)
+	}
+	return roots
+}
+
+// withoutDisabled drops the items the overlay disables from configs
+func (l *Loader) withoutDisabled(configs interface{}) (interface{}, error) {
+	if l.overlayDir == "" {
+		return configs, nil
+	}
+	overlay, err := LoadOverlay(l.overlayDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(overlay.Disable) == 0 {
+		return configs, nil
+	}
+	disabled := func(names ...string) bool {
+		for _, name := range names {
+			for _, d := range overlay.Disable {
+				if strings.EqualFold(name, d) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	switch items := configs.(type) {
+	case []*pipeline.Tool:
+		return keep(items, func(t *pipeline.Tool) bool { return !disabled(append([]string{t.Name}, t.Aliases...)...) }), nil
+	case []*interfaces.Font:
+		return keep(items, func(f *interfaces.Font) bool { return !disabled(f.Name) }), nil
+	case []*interfaces.Language:
+		return keep(items, func(lang *interfaces.Language) bool { return !disabled(lang.Name) }), nil
+	case []*interfaces.Shell:
+		return keep(items, func(sh *interfaces.Shell) bool { return !disabled(sh.Name) }), nil
+	case []*interfaces.Dotfile:
+		return keep(items, func(d *interfaces.Dotfile) bool { return !disabled(d.Name) }), nil
+	default:
+		return configs, nil
+	}
+}
+
+// keep returns the items for which ok reports true
+func keep[T any](items []T, ok func(T) bool) []T {
+	kept := make([]T, 0, len(items))
+	for _, item := range items {
+		if ok(item) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
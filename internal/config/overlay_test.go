@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeConfig writes a config file under root, creating its directory
+func writeConfig(t *testing.T, root, rel, content string) {
+	t.Helper()
+	path := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoader_Overlay(t *testing.T) {
+	t.Setenv(OverlayEnvVar, "")
+	baseDir, overlayDir := t.TempDir(), t.TempDir()
+	writeConfig(t, baseDir, "tools/custom/jq.yaml", "name: jq\ndescription: From the base config\ncategory: custom\n")
+	writeConfig(t, overlayDir, "tools/custom/jq.yaml", "name: jq\ndescription: From the work overlay\ncategory: custom\n")
+	writeConfig(t, overlayDir, "tools/custom/vpn.yaml", "name: corp-vpn\ndescription: Corporate VPN client\ncategory: custom\n")
+	writeConfig(t, overlayDir, OverlayFileName, "disable: [docker, Rust]\n")
+
+	loader := NewLoader(baseDir)
+	loader.SetOverlay(overlayDir)
+	catalog, err := loader.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll() error = %v", err)
+	}
+
+	found := make(map[string]string)
+	for _, tool := range catalog.Tools {
+		found[tool.Name] = tool.Description
+	}
+	if found["jq"] != "From the work overlay" {
+		t.Errorf("Expected the overlay to override the base jq, got %q", found["jq"])
+	}
+	if _, ok := found["corp-vpn"]; !ok {
+		t.Error("Expected the overlay's own tool to be added")
+	}
+	if _, ok := found["docker"]; ok {
+		t.Error("Expected docker to be disabled by the overlay")
+	}
+	for _, lang := range catalog.Languages {
+		if lang.Name == "Rust" {
+			t.Error("Expected Rust to be disabled by the overlay")
+		}
+	}
+}
+
+func TestResolveOverlay(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	work := filepath.Join(home, ".bootstrap-cli", "overlays", "work")
+	if err := os.MkdirAll(work, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if dir, err := ResolveOverlay("work"); err != nil || dir != work {
+		t.Errorf("ResolveOverlay(work) = %q, %v; want %q", dir, err, work)
+	}
+	if dir, err := ResolveOverlay(work); err != nil || dir != work {
+		t.Errorf("ResolveOverlay(path) = %q, %v; want %q", dir, err, work)
+	}
+	if _, err := ResolveOverlay("personal"); err == nil {
+		t.Error("Expected a missing overlay to be an error")
+	}
+}
+
+func TestLoadSettings_Overlay(t *testing.T) {
+	baseDir, overlayDir := t.TempDir(), t.TempDir()
+	writeConfig(t, baseDir, SettingsFileName, "theme: light\ntool_managers:\n  neovim: brew\n")
+	writeConfig(t, overlayDir, SettingsFileName, "tool_managers:\n  docker: apt\n")
+	t.Setenv(OverlayEnvVar, overlayDir)
+
+	settings, err := LoadSettings(filepath.Join(baseDir, SettingsFileName))
+	if err != nil {
+		t.Fatalf("LoadSettings() error = %v", err)
+	}
+	if settings.Theme != "light" {
+		t.Errorf("Expected the base theme to survive, got %q", settings.Theme)
+	}
+	if settings.ToolManagers["neovim"] != "brew" || settings.ToolManagers["docker"] != "apt" {
+		t.Errorf("Expected tool managers from both layers, got %v", settings.ToolManagers)
+	}
+}
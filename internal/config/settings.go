@@ -0,0 +1,162 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+)
+
+// SettingsFileName is the user settings file in the config directory
+const SettingsFileName = "settings.yaml"
+
+// Settings are user preferences that apply across all configuration types
+type Settings struct {
+	// ManagerPriority orders package managers by preference; the first one
+	// available on the system is used (e.g. [brew, apt])
+	ManagerPriority []string `yaml:"manager_priority,omitempty"`
+	// ToolManagers overrides the package manager for individual tools
+	ToolManagers map[string]string `yaml:"tool_managers,omitempty"`
+	// Theme is the installer UI color preset (default, light, high-contrast, monochrome)
+	Theme string `yaml:"theme,omitempty"`
+	// VersionManagers overrides where nvm, pyenv, goenv and rustup are fetched
+	// from, by manager name (e.g. an internal mirror with a pinned ref)
+	VersionManagers map[string]interfaces.VersionManagerSource `yaml:"version_managers,omitempty"`
+	// VersionManagerOrder is the preference among version managers that are
+	// already installed (mise, asdf, fnm, volta) when installing a language
+	VersionManagerOrder []string `yaml:"version_manager_order,omitempty"`
+	// Tools is the tool selection last confirmed in `init`, checked again the
+	// next time it runs
+	Tools []string `yaml:"tools,omitempty"`
+	// Shells are the shells `up` writes tool completions and the prompt for
+	// (e.g. [zsh, bash]); the shell selected in `up` goes first and is the only
+	// one made the login shell. Empty configures just the selected shell.
+	Shells []string `yaml:"shells,omitempty"`
+	// DotfilesDir is where the dotfiles repository is cloned (default ~/.dotfiles)
+	DotfilesDir string `yaml:"dotfiles_dir,omitempty"`
+	// NeovimConfig is the starter config cloned into an empty ~/.config/nvim
+	// when neovim is installed: a git URL or GitHub user/repo, or "none"
+	// (default kickstart.nvim)
+	NeovimConfig string `yaml:"neovim_config,omitempty"`
+}
+
+// DotfilesPath returns where the dotfiles repository is cloned under home,
+// expanding a leading ~ in DotfilesDir
+func (s *Settings) DotfilesPath(home string) string {
+	dir := s.DotfilesDir
+	switch {
+	case dir == "":
+		return filepath.Join(home, ".dotfiles")
+	case dir == "~":
+		return home
+	case strings.HasPrefix(dir, "~/"):
+		return filepath.Join(home, dir[2:])
+	case !filepath.IsAbs(dir):
+		return filepath.Join(home, dir)
+	}
+	return dir
+}
+
+// UserConfigDir returns the default user configuration directory
+func UserConfigDir() (string, error) {
+	home, err := system.UserHome()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "bootstrap-cli"), nil
+}
+
+// SettingsPath returns the settings file in dir, or in the user config
+// directory when dir is empty
+func SettingsPath(dir string) (string, error) {
+	if dir == "" {
+		var err error
+		if dir, err = UserConfigDir(); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(dir, SettingsFileName), nil
+}
+
+// LoadSettings reads the settings file at path, then the selected overlay's
+// settings over it (see OverlayEnvVar). Missing files yield empty settings.
+func LoadSettings(path string) (*Settings, error) {
+	var settings Settings
+	paths := []string{path}
+	if overlay := os.Getenv(OverlayEnvVar); overlay != "" {
+		paths = append(paths, filepath.Join(overlay, SettingsFileName))
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read settings %s: %w", path, err)
+		}
+		// Fields the overlay sets replace the base ones; tool_managers entries are added
+		if err := yaml.Unmarshal(data, &settings); err != nil {
+			return nil, fmt.Errorf("failed to parse settings %s: %w", path, err)
+		}
+	}
+	for _, name := range settings.VersionManagerOrder {
+		if err := system.ValidVersionManager(name); err != nil || name == system.VersionManagerNone {
+			return nil, fmt.Errorf("invalid version_manager_order in settings: unknown version manager %q", name)
+		}
+	}
+	return &settings, nil
+}
+
+// SaveToolSelection sets tools in the settings file at path, keeping the rest
+// of the file as it is
+func SaveToolSelection(path string, tools []string) error {
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read settings %s: %w", path, err)
+	}
+	if err == nil {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("failed to parse settings %s: %w", path, err)
+		}
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("failed to update settings %s: not a mapping", path)
+	}
+
+	var value yaml.Node
+	if err := value.Encode(tools); err != nil {
+		return fmt.Errorf("failed to encode tool selection: %w", err)
+	}
+	replaced := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "tools" {
+			root.Content[i+1] = &value
+			replaced = true
+		}
+	}
+	if !replaced {
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "tools"}, &value)
+	}
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode settings: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, out, 0644); err != nil {
+		return fmt.Errorf("failed to write settings %s: %w", path, err)
+	}
+	return nil
+}
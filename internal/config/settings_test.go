@@ -0,0 +1,119 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadSettings(t *testing.T) {
+	dir := t.TempDir()
+	path, err := SettingsPath(dir)
+	if err != nil {
+		t.Fatalf("SettingsPath() error = %v", err)
+	}
+
+	settings, err := LoadSettings(path)
+	if err != nil {
+		t.Fatalf("LoadSettings() on a missing file error = %v", err)
+	}
+	if len(settings.ManagerPriority) != 0 || len(settings.ToolManagers) != 0 {
+		t.Errorf("Expected empty settings for a missing file, got %+v", settings)
+	}
+
+	data := []byte("manager_priority: [brew, apt]\ntool_managers:\n  neovim: brew\n")
+	if err := os.WriteFile(filepath.Join(dir, SettingsFileName), data, 0644); err != nil {
+		t.Fatalf("Failed to write settings: %v", err)
+	}
+	settings, err = LoadSettings(path)
+	if err != nil {
+		t.Fatalf("LoadSettings() error = %v", err)
+	}
+	if len(settings.ManagerPriority) != 2 || settings.ManagerPriority[0] != "brew" {
+		t.Errorf("Expected manager_priority [brew apt], got %v", settings.ManagerPriority)
+	}
+	if settings.ToolManagers["neovim"] != "brew" {
+		t.Errorf("Expected neovim to be installed with brew, got %v", settings.ToolManagers)
+	}
+}
+
+func TestLoadSettingsVersionManagers(t *testing.T) {
+	dir := t.TempDir()
+	data := []byte("version_managers:\n  nvm:\n    ref: v0.40.1\n    sha256: abc123\n  pyenv:\n    url: https://git.example.com/pyenv.git\n")
+	if err := os.WriteFile(filepath.Join(dir, SettingsFileName), data, 0644); err != nil {
+		t.Fatalf("Failed to write settings: %v", err)
+	}
+	settings, err := LoadSettings(filepath.Join(dir, SettingsFileName))
+	if err != nil {
+		t.Fatalf("LoadSettings() error = %v", err)
+	}
+	if nvm := settings.VersionManagers["nvm"]; nvm.Ref != "v0.40.1" || nvm.SHA256 != "abc123" {
+		t.Errorf("Expected nvm pinned to v0.40.1 with a checksum, got %+v", nvm)
+	}
+	if pyenv := settings.VersionManagers["pyenv"]; pyenv.URL != "https://git.example.com/pyenv.git" {
+		t.Errorf("Expected the pyenv mirror, got %+v", pyenv)
+	}
+}
+
+func TestLoadSettingsVersionManagerOrder(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, SettingsFileName)
+	if err := os.WriteFile(path, []byte("version_manager_order: [fnm, mise]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write settings: %v", err)
+	}
+	settings, err := LoadSettings(path)
+	if err != nil {
+		t.Fatalf("LoadSettings() error = %v", err)
+	}
+	if len(settings.VersionManagerOrder) != 2 || settings.VersionManagerOrder[0] != "fnm" {
+		t.Errorf("Expected [fnm mise], got %v", settings.VersionManagerOrder)
+	}
+
+	if err := os.WriteFile(path, []byte("version_manager_order: [nvm]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write settings: %v", err)
+	}
+	if _, err := LoadSettings(path); err == nil {
+		t.Error("Expected an unknown version manager to be rejected")
+	}
+}
+
+func TestSaveToolSelection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), SettingsFileName)
+	if err := SaveToolSelection(path, []string{"Git", "bat"}); err != nil {
+		t.Fatalf("SaveToolSelection() on a missing file error = %v", err)
+	}
+	if err := os.WriteFile(path, append([]byte("# my settings\ntheme: light\n"), mustRead(t, path)...), 0644); err != nil {
+		t.Fatalf("Failed to write settings: %v", err)
+	}
+	if err := SaveToolSelection(path, []string{"fd"}); err != nil {
+		t.Fatalf("SaveToolSelection() error = %v", err)
+	}
+
+	settings, err := LoadSettings(path)
+	if err != nil {
+		t.Fatalf("LoadSettings() error = %v", err)
+	}
+	if len(settings.Tools) != 1 || settings.Tools[0] != "fd" || settings.Theme != "light" {
+		t.Errorf("Expected tools [fd] next to theme light, got %+v", settings)
+	}
+	if data := string(mustRead(t, path)); !strings.Contains(data, "# my settings") {
+		t.Errorf("Expected the comment to be kept, got:\n%s", data)
+	}
+}
+
+func TestDotfilesPath(t *testing.T) {
+	home := filepath.Join("/home", "me")
+	tests := map[string]string{
+		"":                 filepath.Join(home, ".dotfiles"),
+		"~/src/dotfiles":   filepath.Join(home, "src", "dotfiles"),
+		"dotfiles":         filepath.Join(home, "dotfiles"),
+		"/opt/me/dotfiles": "/opt/me/dotfiles",
+	}
+	for dir, want := range tests {
+		settings := &Settings{DotfilesDir: dir}
+		if got := settings.DotfilesPath(home); got != want {
+			t.Errorf("DotfilesPath() with dotfiles_dir %q = %s, want %s", dir, got, want)
+		}
+	}
+}
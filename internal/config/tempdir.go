@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// TempDirPrefix is the name prefix of the temporary directories embedded configs are extracted to
+const TempDirPrefix = "bootstrap-cli-config-"
+
+// StaleTempDirAge is how old an extracted config directory must be before it is pruned
+const StaleTempDirAge = 24 * time.Hour
+
+// NewTempConfigDir creates a temporary directory for extracted configs
+func NewTempConfigDir() (string, error) {
+	dir, err := os.MkdirTemp("", TempDirPrefix+"*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	return dir, nil
+}
+
+// PruneTempConfigDirs removes extracted config directories in parent older than maxAge,
+// left behind by runs that were killed before they could clean up. It returns the
+// directories removed.
+func PruneTempConfigDirs(parent string, maxAge time.Duration) ([]string, error) {
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", parent, err)
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	var removed []string
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), TempDirPrefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		path := filepath.Join(parent, entry.Name())
+		if err := os.RemoveAll(path); err != nil {
+			return removed, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
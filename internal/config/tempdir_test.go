@@ -0,0 +1,39 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPruneTempConfigDirs(t *testing.T) {
+	parent := t.TempDir()
+	stale := filepath.Join(parent, TempDirPrefix+"111")
+	fresh := filepath.Join(parent, TempDirPrefix+"222")
+	other := filepath.Join(parent, "unrelated-dir")
+	for _, dir := range []string{stale, fresh, other} {
+		if err := os.MkdirAll(filepath.Join(dir, "defaults"), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+	old := time.Now().Add(-2 * StaleTempDirAge)
+	for _, dir := range []string{stale, other} {
+		if err := os.Chtimes(dir, old, old); err != nil {
+			t.Fatalf("Failed to age %s: %v", dir, err)
+		}
+	}
+
+	removed, err := PruneTempConfigDirs(parent, StaleTempDirAge)
+	if err != nil {
+		t.Fatalf("PruneTempConfigDirs() error = %v", err)
+	}
+	if len(removed) != 1 || removed[0] != stale {
+		t.Errorf("Expected only %s to be removed, got %v", stale, removed)
+	}
+	for _, dir := range []string{fresh, other} {
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("Expected %s to be kept: %v", dir, err)
+		}
+	}
+}
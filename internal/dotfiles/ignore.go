@@ -0,0 +1,180 @@
+package dotfiles
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFileName lists, in a dotfiles directory, the files bootstrap-cli must not
+// analyze or link, with gitignore-style patterns
+const IgnoreFileName = ".bootstrap-ignore"
+
+// DefaultIgnorePatterns are ignored in every dotfiles directory: repository
+// metadata and documentation that are never dotfiles. A "!" pattern in
+// .bootstrap-ignore re-includes one (e.g. !README.md).
+var DefaultIgnorePatterns = []string{".git/", ".github/", IgnoreFileName, "README*", "LICENSE*"}
+
+// Ignore decides which paths in a dotfiles directory are left alone
+type Ignore struct {
+	rules []ignoreRule
+}
+
+// ignoreRule is one gitignore-style pattern
+type ignoreRule struct {
+	segments []string
+	// negate re-includes paths an earlier pattern ignored ("!pattern")
+	negate bool
+	// dirOnly matches directories only ("pattern/")
+	dirOnly bool
+	// anchored patterns contain a slash and match from the directory root;
+	// the others match a name at any depth
+	anchored bool
+}
+
+// ParseIgnore reads gitignore-style patterns from data, after the defaults.
+// Blank lines and lines starting with # are skipped; \# and \! escape a
+// leading # or !.
+func ParseIgnore(data []byte) *Ignore {
+	ig := &Ignore{}
+	for _, pattern := range DefaultIgnorePatterns {
+		ig.add(pattern)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ig.add(line)
+	}
+	return ig
+}
+
+// LoadIgnore reads .bootstrap-ignore in dir. A missing file yields only the
+// defaults.
+func LoadIgnore(dir string) (*Ignore, error) {
+	data, err := os.ReadFile(filepath.Join(dir, IgnoreFileName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", IgnoreFileName, err)
+	}
+	return ParseIgnore(data), nil
+}
+
+func (ig *Ignore) add(pattern string) {
+	var rule ignoreRule
+	if strings.HasPrefix(pattern, "!") {
+		rule.negate, pattern = true, pattern[1:]
+	} else if strings.HasPrefix(pattern, `\!`) || strings.HasPrefix(pattern, `\#`) {
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly, pattern = true, strings.TrimRight(pattern, "/")
+	}
+	rule.anchored = strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if pattern == "" {
+		return
+	}
+	rule.segments = strings.Split(pattern, "/")
+	ig.rules = append(ig.rules, rule)
+}
+
+// Match reports whether rel, a path relative to the dotfiles directory, is
+// ignored. As with git, nothing inside an ignored directory can be re-included.
+func (ig *Ignore) Match(rel string, isDir bool) bool {
+	rel = filepath.ToSlash(filepath.Clean(rel))
+	if rel == "." || strings.HasPrefix(rel, "../") {
+		return false
+	}
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if ig.matches(parts[:i], true) {
+			return true
+		}
+	}
+	return ig.matches(parts, isDir)
+}
+
+// matches applies the rules in order; the last one that matches decides
+func (ig *Ignore) matches(parts []string, isDir bool) bool {
+	ignored := false
+	for _, rule := range ig.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.match(parts) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+func (r ignoreRule) match(parts []string) bool {
+	if !r.anchored {
+		ok, _ := path.Match(r.segments[0], parts[len(parts)-1])
+		return ok
+	}
+	return matchSegments(r.segments, parts)
+}
+
+// matchSegments matches path segments against pattern segments, where ** stands
+// for any number of segments
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], parts[1:])
+}
+
+// Files returns the files in dir that are not ignored, as slash-separated paths
+// relative to dir in lexical order. Ignored directories are not descended into.
+func Files(dir string) ([]string, error) {
+	ig, err := LoadIgnore(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		if ig.Match(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dotfiles in %s: %w", dir, err)
+	}
+	return files, nil
+}
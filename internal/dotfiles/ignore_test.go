@@ -0,0 +1,86 @@
+package dotfiles
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+func TestIgnoreMatch(t *testing.T) {
+	ig := ParseIgnore([]byte(`# repo helpers
+scripts/
+*.md
+!CHANGELOG.md
+/Makefile
+docs/**/*.png
+\#notes
+`))
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{".git", true, true},
+		{".git/config", false, true},
+		{"README.md", false, true},
+		{"LICENSE", false, true},
+		{IgnoreFileName, false, true},
+		{"scripts", true, true},
+		{"scripts/install.sh", false, true},
+		{"shell/scripts/helper.sh", false, true},
+		{"scripts", false, false}, // dir-only pattern
+		{"shell/notes.md", false, true},
+		{"CHANGELOG.md", false, false},
+		{"Makefile", false, true},
+		{"shell/Makefile", false, false}, // anchored to the root
+		{"docs/img/logo.png", false, true},
+		{"docs/logo.png", false, true},
+		{"#notes", false, true},
+		{"shell/zshrc", false, false},
+		{".zshrc", false, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ig.Match(tt.path, tt.isDir), "Match(%q, %v)", tt.path, tt.isDir)
+	}
+}
+
+func TestIgnoreCannotReincludeInsideIgnoredDir(t *testing.T) {
+	ig := ParseIgnore([]byte("scripts/\n!scripts/keep.sh\n"))
+	assert.True(t, ig.Match("scripts/keep.sh", false))
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{".git/HEAD", "README.md", "scripts/setup.sh", "shell/zshrc", "git/gitconfig", "notes.txt"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("x"), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, IgnoreFileName), []byte("scripts/\n*.txt\n"), 0644))
+
+	files, err := Files(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"git/gitconfig", "shell/zshrc"}, files)
+}
+
+func TestProcessFileSkipsIgnoredSource(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	baseDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(baseDir, "shell"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(baseDir, "shell", "install.sh"), []byte("echo hi"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(baseDir, IgnoreFileName), []byte("install.sh\n"), 0644))
+
+	manager := &Manager{baseDir: baseDir}
+	file := interfaces.DotfileFile{Source: "install.sh", Destination: ".install.sh", Operation: interfaces.Symlink}
+	require.NoError(t, manager.processFile(&interfaces.Dotfile{Category: "shell"}, file))
+
+	_, err := os.Lstat(filepath.Join(home, ".install.sh"))
+	assert.True(t, os.IsNotExist(err), "ignored source should not be linked")
+}
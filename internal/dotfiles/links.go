@@ -0,0 +1,374 @@
+package dotfiles
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+)
+
+// StateFileName records, under ~/.bootstrap-cli, the dotfiles repository last
+// linked into $HOME and every link made from it
+const StateFileName = "dotfiles.json"
+
+// DefaultDirName is where dotfiles repositories are cloned under $HOME
+const DefaultDirName = ".dotfiles"
+
+// Link states reported by CheckLinks
+const (
+	// LinkIntact is a link that still points at its source
+	LinkIntact = "intact"
+	// LinkModified is a link replaced by a file or pointed somewhere else
+	LinkModified = "modified"
+	// LinkMissing is a link that was removed, or whose source is gone
+	LinkMissing = "missing"
+)
+
+// repoMetadata are files at the top of a repository that describe the
+// repository itself and are never linked by convention
+var repoMetadata = map[string]bool{".gitignore": true, ".gitmodules": true, ".gitattributes": true}
+
+// Link is a path under $HOME symlinked to a file or directory in the dotfiles
+// repository
+type Link struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// LinkStatus is a managed link and its state (LinkIntact, LinkModified or
+// LinkMissing)
+type LinkStatus struct {
+	Link
+	// Package is the stow package the link belongs to, if any
+	Package string `json:"package,omitempty"`
+	State   string `json:"state"`
+}
+
+// State is the dotfiles repository last linked and the links made from it
+type State struct {
+	// Repo is the URL or path the dotfiles came from
+	Repo string `json:"repo"`
+	// Dir is where the repository is checked out
+	Dir      string    `json:"dir"`
+	Links    []Link    `json:"links"`
+	LinkedAt time.Time `json:"linked_at"`
+	// Packages are the links of the stow packages applied, by package
+	Packages map[string][]Link `json:"packages,omitempty"`
+}
+
+// DefaultDir returns ~/.dotfiles under home
+func DefaultDir(home string) string {
+	return filepath.Join(home, DefaultDirName)
+}
+
+// DefaultStatePath returns the default dotfiles.json location
+func DefaultStatePath() (string, error) {
+	dir, err := manifest.StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, StateFileName), nil
+}
+
+// LoadState reads the state at path; a missing file yields an empty state
+func LoadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &state, nil
+}
+
+// Save writes the state to path
+func (s *State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode dotfiles state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// IsLocalRepo reports whether repo is a path on this machine rather than a git
+// URL or a GitHub user/repo shorthand
+func IsLocalRepo(repo string) bool {
+	if strings.HasPrefix(repo, "/") || strings.HasPrefix(repo, "~") || strings.HasPrefix(repo, ".") || filepath.IsAbs(repo) {
+		return true
+	}
+	if strings.Contains(repo, "://") || strings.Contains(repo, "@") {
+		return false
+	}
+	info, err := os.Stat(repo)
+	return err == nil && info.IsDir()
+}
+
+// ExpandHome replaces a leading ~ in path with home
+func ExpandHome(path, home string) string {
+	if path == "~" {
+		return home
+	}
+	if strings.HasPrefix(path, "~/") {
+		return filepath.Join(home, path[2:])
+	}
+	return path
+}
+
+// SameRepo reports whether a and b name the same repository, ignoring the
+// scheme, user, a trailing .git and the user/repo shorthand for GitHub
+func SameRepo(a, b string) bool {
+	return normalizeRepo(a) == normalizeRepo(b)
+}
+
+func normalizeRepo(repo string) string {
+	if IsLocalRepo(repo) {
+		return filepath.Clean(repo)
+	}
+	repo = strings.TrimSuffix(strings.TrimSuffix(repo, "/"), ".git")
+	if _, rest, ok := strings.Cut(repo, "://"); ok {
+		repo = rest
+	}
+	if _, rest, ok := strings.Cut(repo, "@"); ok {
+		repo = strings.Replace(rest, ":", "/", 1)
+	}
+	if strings.Count(repo, "/") == 1 {
+		repo = "github.com/" + repo
+	}
+	return strings.ToLower(repo)
+}
+
+// PlanLinks returns the links to make from the repository checked out in dir.
+// The symlink files of the dotfile configs whose source_repo is repo map
+// sources in dir to destinations under home; without any, every entry at the
+// top of dir is linked to ~/.<name>. Entries .bootstrap-ignore excludes are
+// never linked.
+func PlanLinks(dir, home, repo string, configs []*interfaces.Dotfile) ([]Link, error) {
+	ignore, err := LoadIgnore(dir)
+	if err != nil {
+		return nil, err
+	}
+	if links := mappedLinks(dir, home, repo, configs, ignore); len(links) > 0 {
+		return links, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	var links []Link
+	for _, entry := range entries {
+		name := entry.Name()
+		if repoMetadata[name] || ignore.Match(name, entry.IsDir()) {
+			continue
+		}
+		links = append(links, Link{
+			Source: filepath.Join(dir, name),
+			Target: filepath.Join(home, "."+strings.TrimPrefix(name, ".")),
+		})
+	}
+	return links, nil
+}
+
+// mappedLinks returns the symlink files of the configs for repo
+func mappedLinks(dir, home, repo string, configs []*interfaces.Dotfile, ignore *Ignore) []Link {
+	var links []Link
+	for _, cfg := range configs {
+		if cfg.SourceRepo == "" || !SameRepo(cfg.SourceRepo, repo) {
+			continue
+		}
+		for _, file := range cfg.Files {
+			if file.Operation != interfaces.Symlink || file.Source == "" || file.Destination == "" {
+				continue
+			}
+			source := filepath.Join(dir, file.Source)
+			info, err := os.Stat(source)
+			if ignore.Match(file.Source, err == nil && info.IsDir()) {
+				continue
+			}
+			target := ExpandHome(file.Destination, home)
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(home, target)
+			}
+			links = append(links, Link{Source: source, Target: target})
+		}
+	}
+	return links
+}
+
+// Linker symlinks dotfiles into the home directory
+type Linker struct {
+	Home string
+	// BackupDir receives whatever is in the way of a link, at its path relative
+	// to Home (default ~/.bootstrap-cli/backups/dotfiles/<timestamp>)
+	BackupDir string
+}
+
+// LinkReport is what Link changed
+type LinkReport struct {
+	// Linked are the targets linked in this run
+	Linked []string
+	// Unchanged are the targets that already pointed at their source
+	Unchanged []string
+	// BackedUp maps each target moved aside to where it was moved
+	BackedUp map[string]string
+	// Removed are the links of previous runs that were cleaned up
+	Removed []string
+	// Adopted are the targets moved into a stow package before being linked
+	Adopted []string
+}
+
+// Link makes every link, moving a file or other link in the way to the backup
+// directory first. Links of the previous state that are no longer planned, and
+// broken links at the top of Home into dir, are removed.
+func (l *Linker) Link(dir string, links []Link, previous *State) (*LinkReport, error) {
+	report := &LinkReport{BackedUp: make(map[string]string)}
+	planned := make(map[string]bool)
+	for _, link := range links {
+		planned[link.Target] = true
+	}
+
+	if previous != nil {
+		for _, old := range previous.Links {
+			if planned[old.Target] {
+				continue
+			}
+			if dest, err := os.Readlink(old.Target); err == nil && dest == old.Source {
+				if err := os.Remove(old.Target); err != nil {
+					return report, fmt.Errorf("failed to remove old link %s: %w", old.Target, err)
+				}
+				report.Removed = append(report.Removed, old.Target)
+			}
+		}
+	}
+	broken, err := brokenLinks(l.Home, dir)
+	if err != nil {
+		return report, err
+	}
+	for _, path := range broken {
+		if planned[path] {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return report, fmt.Errorf("failed to remove broken link %s: %w", path, err)
+		}
+		report.Removed = append(report.Removed, path)
+	}
+
+	for _, link := range links {
+		if dest, err := os.Readlink(link.Target); err == nil && dest == link.Source {
+			report.Unchanged = append(report.Unchanged, link.Target)
+			continue
+		}
+		if _, err := os.Lstat(link.Target); err == nil {
+			backup, err := l.backup(link.Target)
+			if err != nil {
+				return report, err
+			}
+			report.BackedUp[link.Target] = backup
+		}
+		if err := os.MkdirAll(filepath.Dir(link.Target), 0755); err != nil {
+			return report, fmt.Errorf("failed to create %s: %w", filepath.Dir(link.Target), err)
+		}
+		if err := os.Symlink(link.Source, link.Target); err != nil {
+			return report, fmt.Errorf("failed to link %s: %w", link.Target, err)
+		}
+		report.Linked = append(report.Linked, link.Target)
+	}
+	return report, nil
+}
+
+// backup moves path into the backup directory and returns where it went
+func (l *Linker) backup(path string) (string, error) {
+	if l.BackupDir == "" {
+		state, err := manifest.StateDir()
+		if err != nil {
+			return "", err
+		}
+		l.BackupDir = filepath.Join(state, "backups", "dotfiles", time.Now().Format("20060102-150405"))
+	}
+	rel, err := filepath.Rel(l.Home, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = strings.TrimPrefix(filepath.Clean(path), string(filepath.Separator))
+	}
+	backup := filepath.Join(l.BackupDir, rel)
+	if err := os.MkdirAll(filepath.Dir(backup), 0700); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	if err := os.Rename(path, backup); err != nil {
+		return "", fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	return backup, nil
+}
+
+// brokenLinks returns the links at the top of home that point into dir at
+// something that no longer exists
+func brokenLinks(home, dir string) ([]string, error) {
+	entries, err := os.ReadDir(home)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", home, err)
+	}
+	var broken []string
+	for _, entry := range entries {
+		if entry.Type()&os.ModeSymlink == 0 {
+			continue
+		}
+		path := filepath.Join(home, entry.Name())
+		dest, err := os.Readlink(path)
+		if err != nil || !strings.HasPrefix(dest, dir+string(filepath.Separator)) {
+			continue
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			broken = append(broken, path)
+		}
+	}
+	return broken, nil
+}
+
+// CheckLinks returns the state of every link in state, including those of
+// stow packages, sorted by target
+func CheckLinks(state *State) []LinkStatus {
+	statuses := make([]LinkStatus, 0, len(state.Links))
+	for _, link := range state.Links {
+		statuses = append(statuses, LinkStatus{Link: link, State: checkLink(link)})
+	}
+	for pkg, links := range state.Packages {
+		for _, link := range links {
+			statuses = append(statuses, LinkStatus{Link: link, Package: pkg, State: checkLink(link)})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Target < statuses[j].Target })
+	return statuses
+}
+
+func checkLink(link Link) string {
+	info, err := os.Lstat(link.Target)
+	if err != nil {
+		return LinkMissing
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return LinkModified
+	}
+	if dest, err := os.Readlink(link.Target); err != nil || dest != link.Source {
+		return LinkModified
+	}
+	if _, err := os.Stat(link.Source); err != nil {
+		return LinkMissing
+	}
+	return LinkIntact
+}
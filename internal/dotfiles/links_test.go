@@ -0,0 +1,171 @@
+package dotfiles
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeRepo creates files (a trailing / makes a directory) under dir
+func writeRepo(t *testing.T, dir string, files ...string) {
+	t.Helper()
+	for _, file := range files {
+		path := filepath.Join(dir, file)
+		if file[len(file)-1] == '/' {
+			require.NoError(t, os.MkdirAll(path, 0755))
+			continue
+		}
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(file), 0644))
+	}
+}
+
+func targets(links []Link) []string {
+	var names []string
+	for _, link := range links {
+		names = append(names, link.Target)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestPlanLinksByConvention(t *testing.T) {
+	dir, home := t.TempDir(), t.TempDir()
+	writeRepo(t, dir, "bashrc", ".vimrc", "config/nvim/init.lua", "README.md", ".git/HEAD", ".gitignore", "scripts/setup.sh", IgnoreFileName)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, IgnoreFileName), []byte("scripts/\n"), 0644))
+
+	links, err := PlanLinks(dir, home, "me/dotfiles", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(home, ".bashrc"),
+		filepath.Join(home, ".config"),
+		filepath.Join(home, ".vimrc"),
+	}, targets(links))
+	for _, link := range links {
+		if link.Target == filepath.Join(home, ".bashrc") {
+			assert.Equal(t, filepath.Join(dir, "bashrc"), link.Source)
+		}
+	}
+}
+
+func TestPlanLinksFromMapping(t *testing.T) {
+	dir, home := t.TempDir(), t.TempDir()
+	writeRepo(t, dir, "zsh/zshrc", "nvim/", "bashrc")
+	configs := []*interfaces.Dotfile{
+		{Name: "other", SourceRepo: "someone/else", Files: []interfaces.DotfileFile{
+			{Source: "bashrc", Destination: "~/.bashrc", Operation: interfaces.Symlink},
+		}},
+		{Name: "mine", SourceRepo: "git@github.com:Me/dotfiles.git", Files: []interfaces.DotfileFile{
+			{Source: "zsh/zshrc", Destination: "~/.zshrc", Operation: interfaces.Symlink},
+			{Source: "nvim", Destination: ".config/nvim", Operation: interfaces.Symlink},
+			{Source: "ignored", Destination: "~/.ignored", Operation: interfaces.Create},
+		}},
+	}
+
+	links, err := PlanLinks(dir, home, "https://github.com/me/dotfiles", configs)
+	require.NoError(t, err)
+	assert.Equal(t, []Link{
+		{Source: filepath.Join(dir, "zsh", "zshrc"), Target: filepath.Join(home, ".zshrc")},
+		{Source: filepath.Join(dir, "nvim"), Target: filepath.Join(home, ".config", "nvim")},
+	}, links)
+}
+
+func TestSameRepo(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"me/dotfiles", "https://github.com/me/dotfiles.git", true},
+		{"git@github.com:me/dotfiles.git", "ssh://git@github.com/me/dotfiles", true},
+		{"https://gitlab.com/me/dotfiles", "me/dotfiles", false},
+		{"me/dotfiles", "me/other", false},
+		{"/home/me/dotfiles/", "/home/me/dotfiles", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, SameRepo(tt.a, tt.b), "SameRepo(%q, %q)", tt.a, tt.b)
+	}
+}
+
+func TestLinkerLink(t *testing.T) {
+	dir, home, backups := t.TempDir(), t.TempDir(), t.TempDir()
+	writeRepo(t, dir, "bashrc", "vimrc", "config/nvim/init.lua")
+	// A file in the way, a link of an earlier run no longer planned and a broken
+	// link into the repository
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".bashrc"), []byte("mine"), 0644))
+	require.NoError(t, os.Symlink(filepath.Join(dir, "vimrc"), filepath.Join(home, ".oldrc")))
+	require.NoError(t, os.Symlink(filepath.Join(dir, "gone"), filepath.Join(home, ".gone")))
+	previous := &State{Links: []Link{{Source: filepath.Join(dir, "vimrc"), Target: filepath.Join(home, ".oldrc")}}}
+
+	links := []Link{
+		{Source: filepath.Join(dir, "bashrc"), Target: filepath.Join(home, ".bashrc")},
+		{Source: filepath.Join(dir, "config", "nvim"), Target: filepath.Join(home, ".config", "nvim")},
+	}
+	linker := &Linker{Home: home, BackupDir: backups}
+	report, err := linker.Link(dir, links, previous)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{links[0].Target, links[1].Target}, report.Linked)
+	assert.ElementsMatch(t, []string{filepath.Join(home, ".oldrc"), filepath.Join(home, ".gone")}, report.Removed)
+	assert.Equal(t, map[string]string{links[0].Target: filepath.Join(backups, ".bashrc")}, report.BackedUp)
+
+	backup, err := os.ReadFile(filepath.Join(backups, ".bashrc"))
+	require.NoError(t, err)
+	assert.Equal(t, "mine", string(backup))
+	for _, link := range links {
+		dest, err := os.Readlink(link.Target)
+		require.NoError(t, err)
+		assert.Equal(t, link.Source, dest)
+	}
+
+	// Linking again changes nothing
+	report, err = linker.Link(dir, links, &State{Links: links})
+	require.NoError(t, err)
+	assert.Empty(t, report.Linked)
+	assert.Empty(t, report.Removed)
+	assert.Len(t, report.Unchanged, 2)
+}
+
+func TestCheckLinks(t *testing.T) {
+	dir, home := t.TempDir(), t.TempDir()
+	writeRepo(t, dir, "intact", "replaced", "elsewhere")
+	state := &State{Links: []Link{
+		{Source: filepath.Join(dir, "intact"), Target: filepath.Join(home, ".intact")},
+		{Source: filepath.Join(dir, "replaced"), Target: filepath.Join(home, ".replaced")},
+		{Source: filepath.Join(dir, "elsewhere"), Target: filepath.Join(home, ".elsewhere")},
+		{Source: filepath.Join(dir, "removed"), Target: filepath.Join(home, ".removed")},
+		{Source: filepath.Join(dir, "deleted"), Target: filepath.Join(home, ".deleted")},
+	}}
+	require.NoError(t, os.Symlink(filepath.Join(dir, "intact"), filepath.Join(home, ".intact")))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".replaced"), nil, 0644))
+	require.NoError(t, os.Symlink(filepath.Join(dir, "intact"), filepath.Join(home, ".elsewhere")))
+	require.NoError(t, os.Symlink(filepath.Join(dir, "deleted"), filepath.Join(home, ".deleted")))
+
+	got := make(map[string]string)
+	for _, status := range CheckLinks(state) {
+		got[filepath.Base(status.Target)] = status.State
+	}
+	assert.Equal(t, map[string]string{
+		".intact":    LinkIntact,
+		".replaced":  LinkModified,
+		".elsewhere": LinkModified,
+		".removed":   LinkMissing,
+		".deleted":   LinkMissing,
+	}, got)
+}
+
+func TestStateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), StateFileName)
+	state, err := LoadState(path)
+	require.NoError(t, err)
+	assert.Empty(t, state.Links)
+
+	state = &State{Repo: "me/dotfiles", Dir: "/home/me/.dotfiles", Links: []Link{{Source: "/a", Target: "/b"}}}
+	require.NoError(t, state.Save(path))
+	loaded, err := LoadState(path)
+	require.NoError(t, err)
+	assert.Equal(t, state, loaded)
+}
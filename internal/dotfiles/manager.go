@@ -9,27 +9,28 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
-
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
 )
 
 // Manager handles dotfiles operations
 type Manager struct {
-	configLoader *config.Loader
 	baseDir     string
+	vars        map[string]string // Template variables shared by all dotfiles
+	prompt      func(v interfaces.DotfileVariable) (string, error)
+	ignore      *Ignore // The dotfiles directory's .bootstrap-ignore, loaded on first use
 }
 
 // NewManager creates a new dotfiles manager
 func NewManager() *Manager {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		homeDir = os.Getenv("HOME")
-	}
+	// Without a home directory the dotfiles dir is relative; processFile then reports the error
+	homeDir, _ := system.UserHome()
 	
 	return &Manager{
-		configLoader: config.NewLoader("config"),
 		baseDir:     filepath.Join(homeDir, ".dotfiles"),
+		vars:        DefaultTemplateVariables(),
+		prompt:      interactivePrompt(),
 	}
 }
 
@@ -65,7 +66,21 @@ func (m *Manager) ApplyDotfile(dotfile *interfaces.Dotfile) error {
 		return fmt.Errorf("failed to create category directory: %w", err)
 	}
 
-	// Process each file in the configuration
+	// Install the shell framework at its pinned ref before writing files that source it
+	if dotfile.Framework != nil {
+		installer, err := shell.NewFrameworkInstaller()
+		if err != nil {
+			return fmt.Errorf("failed to create framework installer: %w", err)
+		}
+		if _, err := installer.Installed(dotfile.Framework.Name); err != nil {
+			if err := installer.Install(dotfile.Framework); err != nil {
+				return fmt.Errorf("failed to install %s: %w", dotfile.Framework.Name, err)
+			}
+		}
+	}
+
+	// Process each file in the configuration, rereading .bootstrap-ignore for this run
+	m.ignore = nil
 	for _, file := range dotfile.Files {
 		if err := m.processFile(dotfile, file); err != nil {
 			return fmt.Errorf("failed to process file %s: %w", file.Source, err)
@@ -90,18 +105,51 @@ func (m *Manager) processFile(dotfile *interfaces.Dotfile, file interfaces.Dotfi
 
 	destPath := file.Destination
 	if !filepath.IsAbs(destPath) {
-		homeDir, err := os.UserHomeDir()
+		homeDir, err := system.UserHome()
 		if err != nil {
-			return fmt.Errorf("failed to get home directory: %w", err)
+			return err
 		}
 		destPath = filepath.Join(homeDir, destPath)
 	}
 
+	// Sources excluded by .bootstrap-ignore are never read or linked
+	readsSource := file.Operation == interfaces.Symlink || (file.Template && file.Content == "" && file.Operation != interfaces.Delete)
+	if readsSource && !strings.HasPrefix(file.Source, "http") {
+		ignored, err := m.ignored(sourcePath)
+		if err != nil {
+			return err
+		}
+		if ignored {
+			return nil
+		}
+	}
+
 	// Create parent directories if needed
 	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
 		return fmt.Errorf("failed to create parent directories: %w", err)
 	}
 
+	// Templated files are rendered to the destination instead of linked or copied raw
+	if file.Template && file.Operation != interfaces.Delete {
+		content := file.Content
+		if content == "" {
+			raw, err := os.ReadFile(sourcePath)
+			if err != nil {
+				return fmt.Errorf("failed to read template source: %w", err)
+			}
+			content = string(raw)
+		}
+		vars, err := m.templateVariables(dotfile)
+		if err != nil {
+			return err
+		}
+		rendered, err := renderTemplate(file.Source, content, vars)
+		if err != nil {
+			return err
+		}
+		return m.WriteContentFile(rendered, destPath)
+	}
+
 	// Handle different file types
 	switch file.Operation {
 	case interfaces.Create, interfaces.Update:
@@ -118,6 +166,24 @@ func (m *Manager) processFile(dotfile *interfaces.Dotfile, file interfaces.Dotfi
 	}
 }
 
+// ignored reports whether .bootstrap-ignore in the dotfiles directory excludes
+// sourcePath; paths outside the directory are never ignored
+func (m *Manager) ignored(sourcePath string) (bool, error) {
+	if m.ignore == nil {
+		ignore, err := LoadIgnore(m.baseDir)
+		if err != nil {
+			return false, err
+		}
+		m.ignore = ignore
+	}
+	rel, err := filepath.Rel(m.baseDir, sourcePath)
+	if err != nil {
+		return false, nil
+	}
+	info, err := os.Stat(sourcePath)
+	return m.ignore.Match(rel, err == nil && info.IsDir()), nil
+}
+
 // WriteContentFile writes content to a file
 func (m *Manager) WriteContentFile(content []byte, dest string) error {
 	// Backup existing file if needed
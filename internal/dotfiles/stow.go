@@ -0,0 +1,258 @@
+package dotfiles
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Stow links the packages of a dotfiles repository into a home directory the
+// way GNU stow does, without needing stow: a package is a directory at the top
+// of the repository whose tree mirrors $HOME (nvim/.config/nvim/init.lua). Its
+// directories are created under Home and its files symlinked.
+type Stow struct {
+	// Dir is the dotfiles repository
+	Dir  string
+	Home string
+	// Adopt moves regular files in the way of a link into the package,
+	// replacing the package's copy, and links them instead of refusing
+	Adopt bool
+	// DryRun reports what would change without changing anything
+	DryRun bool
+}
+
+// ConflictError lists the files in the way of a package's links; nothing is
+// linked while there are any
+type ConflictError struct {
+	Package string
+	Paths   []string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("cannot apply %s: %d file(s) in the way: %s", e.Package, len(e.Paths), strings.Join(e.Paths, ", "))
+}
+
+// stowEntry is a file of a package and where it is linked
+type stowEntry struct {
+	Link
+	// adopt moves the file at Target into the package before linking it
+	adopt bool
+}
+
+// Apply links every file of pkg into Home, creating the directories they are
+// in. Links that already point at the package are left alone, and so are
+// broken links into the repository, which are replaced. Any other file or link
+// in the way fails the whole package with a ConflictError, unless Adopt is set
+// and it is a regular file.
+func (s *Stow) Apply(pkg string) ([]Link, *LinkReport, error) {
+	entries, err := s.entries(pkg)
+	if err != nil {
+		return nil, nil, err
+	}
+	var conflicts []string
+	for i, entry := range entries {
+		switch s.check(entry.Link) {
+		case stowLinked, stowFree:
+		case stowFile:
+			if s.Adopt {
+				entries[i].adopt = true
+				continue
+			}
+			conflicts = append(conflicts, entry.Target)
+		default:
+			conflicts = append(conflicts, entry.Target)
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, nil, &ConflictError{Package: pkg, Paths: conflicts}
+	}
+
+	report := &LinkReport{BackedUp: make(map[string]string)}
+	links := make([]Link, 0, len(entries))
+	for _, entry := range entries {
+		links = append(links, entry.Link)
+		if s.check(entry.Link) == stowLinked {
+			report.Unchanged = append(report.Unchanged, entry.Target)
+			continue
+		}
+		if s.DryRun {
+			if entry.adopt {
+				report.Adopted = append(report.Adopted, entry.Target)
+			}
+			report.Linked = append(report.Linked, entry.Target)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(entry.Target), 0755); err != nil {
+			return links, report, fmt.Errorf("failed to create %s: %w", filepath.Dir(entry.Target), err)
+		}
+		if entry.adopt {
+			if err := os.Rename(entry.Target, entry.Source); err != nil {
+				return links, report, fmt.Errorf("failed to adopt %s: %w", entry.Target, err)
+			}
+			report.Adopted = append(report.Adopted, entry.Target)
+		} else if err := os.Remove(entry.Target); err != nil && !os.IsNotExist(err) {
+			return links, report, fmt.Errorf("failed to remove broken link %s: %w", entry.Target, err)
+		}
+		if err := os.Symlink(entry.Source, entry.Target); err != nil {
+			return links, report, fmt.Errorf("failed to link %s: %w", entry.Target, err)
+		}
+		report.Linked = append(report.Linked, entry.Target)
+	}
+	return links, report, nil
+}
+
+// Unapply removes the links into pkg from Home, then the directories that
+// are left empty by it. Files that are not links into the package are never
+// touched.
+func (s *Stow) Unapply(pkg string) (*LinkReport, error) {
+	entries, err := s.entries(pkg)
+	if err != nil {
+		return nil, err
+	}
+	report := &LinkReport{}
+	dirs := make(map[string]bool)
+	for _, entry := range entries {
+		if s.check(entry.Link) != stowLinked {
+			continue
+		}
+		if s.DryRun {
+			report.Removed = append(report.Removed, entry.Target)
+			continue
+		}
+		if err := os.Remove(entry.Target); err != nil {
+			return report, fmt.Errorf("failed to remove %s: %w", entry.Target, err)
+		}
+		report.Removed = append(report.Removed, entry.Target)
+		for dir := filepath.Dir(entry.Target); dir != s.Home && strings.HasPrefix(dir, s.Home); dir = filepath.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+	// Deepest first, so a parent is only removed once its children are gone
+	ordered := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		ordered = append(ordered, dir)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return len(ordered[i]) > len(ordered[j]) })
+	for _, dir := range ordered {
+		if children, err := os.ReadDir(dir); err == nil && len(children) == 0 {
+			if err := os.Remove(dir); err != nil {
+				return report, fmt.Errorf("failed to remove %s: %w", dir, err)
+			}
+		}
+	}
+	return report, nil
+}
+
+// Packages returns the directories at the top of the repository that can be
+// applied, leaving out those .bootstrap-ignore excludes
+func (s *Stow) Packages() ([]string, error) {
+	ignore, err := LoadIgnore(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", s.Dir, err)
+	}
+	var packages []string
+	for _, entry := range entries {
+		if entry.IsDir() && !ignore.Match(entry.Name(), true) {
+			packages = append(packages, entry.Name())
+		}
+	}
+	return packages, nil
+}
+
+// entries walks pkg and returns a link for each of its files
+func (s *Stow) entries(pkg string) ([]stowEntry, error) {
+	if pkg == "" || pkg != filepath.Base(pkg) || pkg == "." || pkg == ".." {
+		return nil, fmt.Errorf("invalid package %q: it must be a directory at the top of %s", pkg, s.Dir)
+	}
+	root := filepath.Join(s.Dir, pkg)
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		if packages, err := s.Packages(); err == nil && len(packages) > 0 {
+			return nil, fmt.Errorf("package %s not found in %s (packages: %s)", pkg, s.Dir, strings.Join(packages, ", "))
+		}
+		return nil, fmt.Errorf("package %s not found in %s", pkg, s.Dir)
+	}
+	ignore, err := LoadIgnore(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []stowEntry
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(s.Dir, path)
+		if path != root && ignore.Match(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		inPackage, _ := filepath.Rel(root, path)
+		entries = append(entries, stowEntry{Link: Link{Source: path, Target: filepath.Join(s.Home, inPackage)}})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package %s: %w", pkg, err)
+	}
+	return entries, nil
+}
+
+// What is at the target of a package link
+const (
+	// stowFree has nothing, or a broken link into the repository
+	stowFree = iota
+	// stowLinked already links to the package's file
+	stowLinked
+	// stowFile is a regular file, which can be adopted
+	stowFile
+	// stowBlocked is a directory, or a link somewhere else
+	stowBlocked
+)
+
+func (s *Stow) check(link Link) int {
+	info, err := os.Lstat(link.Target)
+	if os.IsNotExist(err) {
+		// A parent in the way is a conflict too, e.g. a file where a directory goes
+		for dir := filepath.Dir(link.Target); dir != s.Home && strings.HasPrefix(dir, s.Home); dir = filepath.Dir(dir) {
+			if parent, err := os.Stat(dir); err == nil {
+				if !parent.IsDir() {
+					return stowBlocked
+				}
+				break
+			}
+		}
+		return stowFree
+	}
+	if err != nil {
+		return stowBlocked
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		dest, err := os.Readlink(link.Target)
+		switch {
+		case err != nil:
+			return stowBlocked
+		case dest == link.Source:
+			return stowLinked
+		case strings.HasPrefix(dest, s.Dir+string(filepath.Separator)):
+			if _, err := os.Stat(link.Target); os.IsNotExist(err) {
+				return stowFree
+			}
+		}
+		return stowBlocked
+	}
+	if info.Mode().IsRegular() {
+		return stowFile
+	}
+	return stowBlocked
+}
@@ -0,0 +1,181 @@
+package dotfiles
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStowRepo creates a repository with nvim, zsh and tmux packages
+func newStowRepo(t *testing.T) *Stow {
+	t.Helper()
+	dir, home := t.TempDir(), t.TempDir()
+	writeRepo(t, dir,
+		"nvim/.config/nvim/init.lua",
+		"nvim/.config/nvim/lua/plugins.lua",
+		"zsh/.zshrc",
+		"zsh/.zprofile",
+		"tmux/.tmux.conf",
+		"tmux/README.md",
+	)
+	return &Stow{Dir: dir, Home: home}
+}
+
+func assertLinked(t *testing.T, s *Stow, pkg, rel string) {
+	t.Helper()
+	dest, err := os.Readlink(filepath.Join(s.Home, rel))
+	require.NoError(t, err, "%s should be a link", rel)
+	assert.Equal(t, filepath.Join(s.Dir, pkg, rel), dest)
+}
+
+func TestStowApply(t *testing.T) {
+	s := newStowRepo(t)
+	// An existing directory is kept and filled, not replaced by a link
+	require.NoError(t, os.MkdirAll(filepath.Join(s.Home, ".config", "other"), 0755))
+
+	links, report, err := s.Apply("nvim")
+	require.NoError(t, err)
+	assert.Len(t, links, 2)
+	assert.Len(t, report.Linked, 2)
+	assertLinked(t, s, "nvim", ".config/nvim/init.lua")
+	assertLinked(t, s, "nvim", ".config/nvim/lua/plugins.lua")
+	info, err := os.Lstat(filepath.Join(s.Home, ".config", "nvim"))
+	require.NoError(t, err)
+	assert.True(t, info.IsDir(), "directories are created, not linked")
+	assert.DirExists(t, filepath.Join(s.Home, ".config", "other"))
+
+	// Applying again leaves the links alone
+	_, report, err = s.Apply("nvim")
+	require.NoError(t, err)
+	assert.Empty(t, report.Linked)
+	assert.Len(t, report.Unchanged, 2)
+
+	// Ignored files are not linked
+	_, _, err = s.Apply("tmux")
+	require.NoError(t, err)
+	assertLinked(t, s, "tmux", ".tmux.conf")
+	assert.NoFileExists(t, filepath.Join(s.Home, "README.md"))
+}
+
+func TestStowApplyReplacesBrokenLinks(t *testing.T) {
+	s := newStowRepo(t)
+	require.NoError(t, os.Symlink(filepath.Join(s.Dir, "zsh", ".zshrc.old"), filepath.Join(s.Home, ".zshrc")))
+
+	_, _, err := s.Apply("zsh")
+	require.NoError(t, err)
+	assertLinked(t, s, "zsh", ".zshrc")
+}
+
+func TestStowApplyConflict(t *testing.T) {
+	s := newStowRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(s.Home, ".zshrc"), []byte("mine"), 0644))
+	require.NoError(t, os.Symlink("/etc/zprofile", filepath.Join(s.Home, ".zprofile")))
+
+	_, _, err := s.Apply("zsh")
+	var conflict *ConflictError
+	require.True(t, errors.As(err, &conflict), "expected a ConflictError, got %v", err)
+	assert.ElementsMatch(t, []string{filepath.Join(s.Home, ".zshrc"), filepath.Join(s.Home, ".zprofile")}, conflict.Paths)
+
+	// Nothing was changed
+	data, err := os.ReadFile(filepath.Join(s.Home, ".zshrc"))
+	require.NoError(t, err)
+	assert.Equal(t, "mine", string(data))
+
+	// A file where a package needs a directory is a conflict too
+	require.NoError(t, os.WriteFile(filepath.Join(s.Home, ".config"), nil, 0644))
+	_, _, err = s.Apply("nvim")
+	require.True(t, errors.As(err, &conflict), "expected a ConflictError, got %v", err)
+	assert.Len(t, conflict.Paths, 2)
+}
+
+func TestStowApplyAdopt(t *testing.T) {
+	s := newStowRepo(t)
+	s.Adopt = true
+	require.NoError(t, os.WriteFile(filepath.Join(s.Home, ".zshrc"), []byte("mine"), 0644))
+
+	_, report, err := s.Apply("zsh")
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(s.Home, ".zshrc")}, report.Adopted)
+	assertLinked(t, s, "zsh", ".zshrc")
+	data, err := os.ReadFile(filepath.Join(s.Dir, "zsh", ".zshrc"))
+	require.NoError(t, err)
+	assert.Equal(t, "mine", string(data), "the adopted file replaces the package's copy")
+
+	// Links elsewhere are still not overwritten
+	require.NoError(t, os.Symlink("/etc/tmux.conf", filepath.Join(s.Home, ".tmux.conf")))
+	_, _, err = s.Apply("tmux")
+	var conflict *ConflictError
+	assert.True(t, errors.As(err, &conflict), "expected a ConflictError, got %v", err)
+}
+
+func TestStowUnapply(t *testing.T) {
+	s := newStowRepo(t)
+	_, _, err := s.Apply("nvim")
+	require.NoError(t, err)
+	_, _, err = s.Apply("zsh")
+	require.NoError(t, err)
+	// A file of the user's own in a package directory is kept, with its directory
+	require.NoError(t, os.MkdirAll(filepath.Join(s.Home, ".config", "other"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(s.Home, ".config", "nvim", "mine.lua"), nil, 0644))
+
+	report, err := s.Unapply("nvim")
+	require.NoError(t, err)
+	assert.Len(t, report.Removed, 2)
+	assert.NoFileExists(t, filepath.Join(s.Home, ".config", "nvim", "init.lua"))
+	assert.NoDirExists(t, filepath.Join(s.Home, ".config", "nvim", "lua"))
+	assert.FileExists(t, filepath.Join(s.Home, ".config", "nvim", "mine.lua"))
+	assert.DirExists(t, filepath.Join(s.Home, ".config", "other"))
+	assertLinked(t, s, "zsh", ".zshrc")
+
+	// Unapplying a package whose files were replaced leaves the files alone
+	require.NoError(t, os.Remove(filepath.Join(s.Home, ".zprofile")))
+	require.NoError(t, os.WriteFile(filepath.Join(s.Home, ".zprofile"), []byte("mine"), 0644))
+	report, err = s.Unapply("zsh")
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(s.Home, ".zshrc")}, report.Removed)
+	assert.FileExists(t, filepath.Join(s.Home, ".zprofile"))
+}
+
+func TestStowUnapplyRemovesEmptyDirectories(t *testing.T) {
+	s := newStowRepo(t)
+	_, _, err := s.Apply("nvim")
+	require.NoError(t, err)
+
+	_, err = s.Unapply("nvim")
+	require.NoError(t, err)
+	assert.NoDirExists(t, filepath.Join(s.Home, ".config"))
+	assert.DirExists(t, s.Home)
+}
+
+func TestStowDryRun(t *testing.T) {
+	s := newStowRepo(t)
+	s.DryRun, s.Adopt = true, true
+	require.NoError(t, os.WriteFile(filepath.Join(s.Home, ".zshrc"), []byte("mine"), 0644))
+
+	_, report, err := s.Apply("zsh")
+	require.NoError(t, err)
+	assert.Len(t, report.Linked, 2)
+	assert.Len(t, report.Adopted, 1)
+	info, err := os.Lstat(filepath.Join(s.Home, ".zshrc"))
+	require.NoError(t, err)
+	assert.True(t, info.Mode().IsRegular(), "a dry run changes nothing")
+	assert.NoFileExists(t, filepath.Join(s.Home, ".zprofile"))
+}
+
+func TestStowPackages(t *testing.T) {
+	s := newStowRepo(t)
+	writeRepo(t, s.Dir, ".git/HEAD", "install.sh")
+
+	packages, err := s.Packages()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nvim", "tmux", "zsh"}, packages)
+
+	_, _, err = s.Apply("../etc")
+	assert.Error(t, err)
+	_, _, err = s.Apply("missing")
+	assert.Error(t, err)
+}
@@ -0,0 +1,123 @@
+package dotfiles
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+	"github.com/manifoldco/promptui"
+)
+
+// DefaultTemplateVariables returns the variables every templated dotfile can use:
+// Name and Email (from the global git config), User, Home, Shell, OS and Arch
+func DefaultTemplateVariables() map[string]string {
+	vars := map[string]string{
+		"OS":   runtime.GOOS,
+		"Arch": runtime.GOARCH,
+		"User": os.Getenv("USER"),
+	}
+	if home, err := system.UserHome(); err == nil {
+		vars["Home"] = home
+	}
+	if sh := os.Getenv("SHELL"); sh != "" {
+		vars["Shell"] = filepath.Base(sh)
+	}
+	for name, key := range map[string]string{"Name": "user.name", "Email": "user.email"} {
+		if out, err := exec.Command("git", "config", "--global", key).Output(); err == nil {
+			if value := strings.TrimSpace(string(out)); value != "" {
+				vars[name] = value
+			}
+		}
+	}
+	return vars
+}
+
+// SetVariable sets a template variable, overriding detected values and defaults
+func (m *Manager) SetVariable(name, value string) {
+	if m.vars == nil {
+		m.vars = make(map[string]string)
+	}
+	m.vars[name] = value
+}
+
+// SetPrompt sets how missing required variables are asked for; nil disables prompting
+func (m *Manager) SetPrompt(prompt func(v interfaces.DotfileVariable) (string, error)) {
+	m.prompt = prompt
+}
+
+// templateVariables resolves the variables for a dotfile, prompting for required
+// variables without a value. Prompted values are remembered for later files.
+func (m *Manager) templateVariables(dotfile *interfaces.Dotfile) (map[string]string, error) {
+	resolved := make(map[string]string, len(m.vars))
+	for name, value := range m.vars {
+		resolved[name] = value
+	}
+
+	for _, v := range dotfile.Variables {
+		if resolved[v.Name] != "" {
+			continue
+		}
+		if v.Default != "" {
+			resolved[v.Name] = v.Default
+			continue
+		}
+		if !v.Required {
+			resolved[v.Name] = ""
+			continue
+		}
+		if m.prompt == nil {
+			return nil, fmt.Errorf("missing required template variable %s", v.Name)
+		}
+		value, err := m.prompt(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template variable %s: %w", v.Name, err)
+		}
+		resolved[v.Name] = value
+		m.SetVariable(v.Name, value)
+	}
+	return resolved, nil
+}
+
+// renderTemplate executes content as a text/template; unknown variables are an error
+func renderTemplate(name, content string, vars map[string]string) ([]byte, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return nil, fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// interactivePrompt asks for a variable on the terminal, or returns nil when stdin is not a terminal
+func interactivePrompt() func(v interfaces.DotfileVariable) (string, error) {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return func(v interfaces.DotfileVariable) (string, error) {
+		label := v.Name
+		if v.Description != "" {
+			label = fmt.Sprintf("%s (%s)", v.Description, v.Name)
+		}
+		prompt := promptui.Prompt{
+			Label: label,
+			Validate: func(input string) error {
+				if strings.TrimSpace(input) == "" {
+					return fmt.Errorf("%s is required", v.Name)
+				}
+				return nil
+			},
+		}
+		return prompt.Run()
+	}
+}
@@ -0,0 +1,91 @@
+package dotfiles
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessTemplatedFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := &Manager{baseDir: tmpDir}
+	manager.SetVariable("Name", "Ada Lovelace")
+	manager.SetVariable("OS", "linux")
+
+	prompted := 0
+	manager.SetPrompt(func(v interfaces.DotfileVariable) (string, error) {
+		prompted++
+		return "ada@example.com", nil
+	})
+
+	dotfile := &interfaces.Dotfile{
+		Category: "git",
+		Variables: []interfaces.DotfileVariable{
+			{Name: "Email", Required: true},
+			{Name: "Editor", Default: "vim"},
+		},
+	}
+	dest := filepath.Join(tmpDir, ".gitconfig")
+	file := interfaces.DotfileFile{
+		Source:      "gitconfig",
+		Destination: dest,
+		Operation:   interfaces.Create,
+		Template:    true,
+		Content:     "[user]\n\tname = {{.Name}}\n\temail = {{.Email}}\n[core]\n\teditor = {{.Editor}}\n{{if eq .OS \"darwin\"}}\tautocrlf = input\n{{end}}",
+	}
+
+	require.NoError(t, manager.processFile(dotfile, file))
+	data, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, "[user]\n\tname = Ada Lovelace\n\temail = ada@example.com\n[core]\n\teditor = vim\n", string(data))
+
+	// The prompted value is remembered for the next file
+	require.NoError(t, manager.processFile(dotfile, file))
+	assert.Equal(t, 1, prompted)
+}
+
+func TestProcessTemplatedSourceFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := &Manager{baseDir: tmpDir}
+	manager.SetVariable("Shell", "zsh")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "shell"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "shell", "aliases"), []byte("# aliases for {{.Shell}}\n"), 0644))
+
+	dest := filepath.Join(tmpDir, ".aliases")
+	file := interfaces.DotfileFile{Source: "aliases", Destination: dest, Operation: interfaces.Symlink, Template: true}
+	require.NoError(t, manager.processFile(&interfaces.Dotfile{Category: "shell"}, file))
+
+	// Rendered to the target rather than symlinked to the raw source
+	info, err := os.Lstat(dest)
+	require.NoError(t, err)
+	assert.Zero(t, info.Mode()&os.ModeSymlink)
+	data, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, "# aliases for zsh\n", string(data))
+}
+
+func TestTemplateMissingRequiredVariable(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := &Manager{baseDir: tmpDir}
+
+	dotfile := &interfaces.Dotfile{Variables: []interfaces.DotfileVariable{{Name: "Email", Required: true}}}
+	file := interfaces.DotfileFile{
+		Source:      "gitconfig",
+		Destination: filepath.Join(tmpDir, ".gitconfig"),
+		Operation:   interfaces.Create,
+		Template:    true,
+		Content:     "{{.Email}}",
+	}
+	err := manager.processFile(dotfile, file)
+	assert.ErrorContains(t, err, "missing required template variable Email")
+
+	// Undeclared variables are an error rather than rendering "<no value>"
+	file.Content = "{{.Undeclared}}"
+	manager.SetVariable("Email", "ada@example.com")
+	assert.Error(t, manager.processFile(dotfile, file))
+}
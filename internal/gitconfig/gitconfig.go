@@ -0,0 +1,245 @@
+// Package gitconfig edits the user's global git config in place, setting values
+// in their existing section or a new one and keeping every other line, comment
+// and include as it was, and sets up the SSH key git hosts authenticate with.
+package gitconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// File is a git config file as its lines
+type File struct {
+	lines []string
+}
+
+// GlobalPath returns the global config git reads under home: ~/.gitconfig, or
+// ~/.config/git/config when only that one exists
+func GlobalPath(home string) string {
+	path := filepath.Join(home, ".gitconfig")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		xdg := filepath.Join(home, ".config", "git", "config")
+		if _, err := os.Stat(xdg); err == nil {
+			return xdg
+		}
+	}
+	return path
+}
+
+// Load reads the config at path; a missing file is an empty config
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &File{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return Parse(string(data)), nil
+}
+
+// Parse splits config text into a File
+func Parse(content string) *File {
+	content = strings.TrimSuffix(content, "\n")
+	if content == "" {
+		return &File{}
+	}
+	return &File{lines: strings.Split(content, "\n")}
+}
+
+// String returns the config text
+func (f *File) String() string {
+	if len(f.lines) == 0 {
+		return ""
+	}
+	return strings.Join(f.lines, "\n") + "\n"
+}
+
+// Save writes the config to path, creating its directory
+func (f *File) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(f.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Get returns the value of key (e.g. user.name); like git, the last one wins
+func (f *File) Get(key string) (string, bool) {
+	section, name, err := splitKey(key)
+	if err != nil {
+		return "", false
+	}
+	var value string
+	found := false
+	current := ""
+	for _, line := range f.lines {
+		if header, ok := parseHeader(line); ok {
+			current = header
+			continue
+		}
+		if current != section {
+			continue
+		}
+		if k, v, ok := parseEntry(line); ok && strings.EqualFold(k, name) {
+			value, found = v, true
+		}
+	}
+	return value, found
+}
+
+// Set sets key (e.g. init.defaultBranch) to value: in place where it is
+// already set, otherwise at the end of its section, which is added when the
+// config has none
+func (f *File) Set(key, value string) error {
+	section, name, err := splitKey(key)
+	if err != nil {
+		return err
+	}
+	entry := "\t" + name + " = " + quote(value)
+	current := ""
+	last, end := -1, -1
+	for i, line := range f.lines {
+		if header, ok := parseHeader(line); ok {
+			current = header
+			if current == section {
+				end = i
+			}
+			continue
+		}
+		if current != section {
+			continue
+		}
+		if strings.TrimSpace(line) != "" {
+			end = i
+		}
+		if k, _, ok := parseEntry(line); ok && strings.EqualFold(k, name) {
+			last = i
+		}
+	}
+	switch {
+	case last >= 0:
+		f.lines[last] = entry
+	case end >= 0:
+		f.lines = append(f.lines[:end+1], append([]string{entry}, f.lines[end+1:]...)...)
+	default:
+		f.lines = append(f.lines, sectionHeader(section), entry)
+	}
+	return nil
+}
+
+// splitKey splits section.name or section.subsection.name into the section as
+// parseHeader returns it and the variable name
+func splitKey(key string) (section, name string, err error) {
+	first := strings.Index(key, ".")
+	dot := strings.LastIndex(key, ".")
+	if first <= 0 || dot == len(key)-1 {
+		return "", "", fmt.Errorf("invalid git config key %q: it must be section.name", key)
+	}
+	section = strings.ToLower(key[:first])
+	if dot > first {
+		section += " " + key[first+1:dot]
+	}
+	return section, key[dot+1:], nil
+}
+
+// sectionHeader writes the header of a section as splitKey returns it
+func sectionHeader(section string) string {
+	if name, sub, ok := strings.Cut(section, " "); ok {
+		return fmt.Sprintf("[%s %q]", name, sub)
+	}
+	return "[" + section + "]"
+}
+
+// parseHeader returns the section a [section] or [section "sub"] line starts,
+// with the section name lowercased as git compares it
+func parseHeader(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "[") {
+		return "", false
+	}
+	end := strings.LastIndex(line, "]")
+	if end < 0 {
+		return "", false
+	}
+	header := strings.TrimSpace(line[1:end])
+	if name, sub, ok := strings.Cut(header, " "); ok {
+		sub = strings.TrimSpace(sub)
+		sub = strings.TrimSuffix(strings.TrimPrefix(sub, `"`), `"`)
+		return strings.ToLower(name) + " " + sub, true
+	}
+	// The legacy [section.sub] form
+	if name, sub, ok := strings.Cut(header, "."); ok {
+		return strings.ToLower(name) + " " + strings.ToLower(sub), true
+	}
+	return strings.ToLower(header), true
+}
+
+// parseEntry returns the name and value of a name = value line. A bare name
+// is a boolean true.
+func parseEntry(line string) (name, value string, ok bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || line[0] == '#' || line[0] == ';' || line[0] == '[' {
+		return "", "", false
+	}
+	name, raw, hasValue := strings.Cut(line, "=")
+	name = strings.TrimSpace(name)
+	if !hasValue {
+		return name, "true", true
+	}
+	return name, unquote(strings.TrimSpace(raw)), true
+}
+
+// unquote reads a raw value: quoted parts lose their quotes and escapes, and an
+// unquoted # or ; starts a comment. Spaces are only trimmed outside quotes.
+func unquote(raw string) string {
+	var b strings.Builder
+	quoted := false
+	kept := 0
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case c == '\\' && i+1 < len(raw):
+			i++
+			switch raw[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(raw[i])
+			}
+			kept = b.Len()
+		case c == '"':
+			quoted = !quoted
+			kept = b.Len()
+		case !quoted && (c == '#' || c == ';'):
+			return trimAfter(b.String(), kept)
+		default:
+			b.WriteByte(c)
+			if quoted {
+				kept = b.Len()
+			}
+		}
+	}
+	return trimAfter(b.String(), kept)
+}
+
+// trimAfter trims the trailing spaces of value after its first kept bytes
+func trimAfter(value string, kept int) string {
+	return value[:kept] + strings.TrimRight(value[kept:], " \t")
+}
+
+// quote writes value so git reads it back unchanged, in quotes when it has
+// edge spaces or characters that would otherwise start a comment
+func quote(value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`).Replace(value)
+	if escaped != value || strings.ContainsAny(value, "#;") || strings.TrimSpace(value) != value {
+		return `"` + escaped + `"`
+	}
+	return value
+}
@@ -0,0 +1,144 @@
+package gitconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetKeepsExistingSettings(t *testing.T) {
+	cfg := Parse(`# my config
+[user]
+	name = Old Name
+	signingkey = ABC123
+
+[alias]
+	co = checkout
+[includeIf "gitdir:~/work/"]
+	path = ~/.gitconfig-work
+`)
+	for _, kv := range [][2]string{
+		{"user.name", "New Name"},
+		{"user.email", "me@example.com"},
+		{"init.defaultBranch", "main"},
+		{"core.editor", "code --wait"},
+	} {
+		if err := cfg.Set(kv[0], kv[1]); err != nil {
+			t.Fatalf("Set(%s) error = %v", kv[0], err)
+		}
+	}
+	want := `# my config
+[user]
+	name = New Name
+	signingkey = ABC123
+	email = me@example.com
+
+[alias]
+	co = checkout
+[includeIf "gitdir:~/work/"]
+	path = ~/.gitconfig-work
+[init]
+	defaultBranch = main
+[core]
+	editor = code --wait
+`
+	if got := cfg.String(); got != want {
+		t.Errorf("config =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGet(t *testing.T) {
+	cfg := Parse(`[User]
+	name = "Ada  Lovelace" ; comment
+[pull]
+	rebase
+[remote "origin"]
+	url = git@github.com:me/repo.git
+[user]
+	email = first@example.com
+	email = last@example.com # later wins
+`)
+	tests := map[string]string{
+		"user.name":         "Ada  Lovelace",
+		"pull.rebase":       "true",
+		"remote.origin.url": "git@github.com:me/repo.git",
+		"user.email":        "last@example.com",
+	}
+	for key, want := range tests {
+		if got, ok := cfg.Get(key); !ok || got != want {
+			t.Errorf("Get(%s) = %q, %v; want %q", key, got, ok, want)
+		}
+	}
+	if _, ok := cfg.Get("core.editor"); ok {
+		t.Error("Expected core.editor to be unset")
+	}
+}
+
+func TestSetQuotesAndRoundTrips(t *testing.T) {
+	cfg := Parse("")
+	values := map[string]string{
+		"user.name":         "  padded ",
+		"core.editor":       `"C:\Program Files\Vim\vim.exe"`,
+		"alias.lg":          "log --oneline # short",
+		"remote.origin.url": "git@github.com:me/repo.git",
+	}
+	for key, value := range values {
+		if err := cfg.Set(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reparsed := Parse(cfg.String())
+	for key, want := range values {
+		if got, _ := reparsed.Get(key); got != want {
+			t.Errorf("Get(%s) after Set = %q, want %q\n%s", key, got, want, cfg.String())
+		}
+	}
+	if !strings.Contains(cfg.String(), `[remote "origin"]`) {
+		t.Errorf("Expected a subsection header, got:\n%s", cfg.String())
+	}
+	if err := cfg.Set("nodot", "x"); err == nil {
+		t.Error("Expected a key without a section to be rejected")
+	}
+}
+
+func TestGlobalPath(t *testing.T) {
+	home := t.TempDir()
+	if got := GlobalPath(home); got != filepath.Join(home, ".gitconfig") {
+		t.Errorf("GlobalPath() = %s, want ~/.gitconfig when neither exists", got)
+	}
+	xdg := filepath.Join(home, ".config", "git", "config")
+	if err := os.MkdirAll(filepath.Dir(xdg), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(xdg, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := GlobalPath(home); got != xdg {
+		t.Errorf("GlobalPath() = %s, want the XDG config when only it exists", got)
+	}
+}
+
+func TestGitHubHostKey(t *testing.T) {
+	home := t.TempDir()
+	if KnowsGitHub(home) {
+		t.Fatal("Expected no known_hosts to know github.com")
+	}
+	knownHosts := filepath.Join(home, ".ssh", "known_hosts")
+	if err := os.MkdirAll(filepath.Dir(knownHosts), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(knownHosts, []byte("gitlab.com ssh-ed25519 AAAA"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddGitHubHostKey(home); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(knownHosts)
+	if string(data) != "gitlab.com ssh-ed25519 AAAA\n"+GitHubHostKey+"\n" {
+		t.Errorf("known_hosts = %q", data)
+	}
+	if !KnowsGitHub(home) {
+		t.Error("Expected github.com to be known after adding its key")
+	}
+}
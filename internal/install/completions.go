@@ -0,0 +1,202 @@
+package install
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+)
+
+// CompletionPath returns where the completion script for tool is installed for
+// shell under home. Fish loads its completions directory and nushell its
+// autoload directory on their own; bash, zsh and PowerShell scripts are sourced
+// from a managed rc block.
+func CompletionPath(home, shellName, tool string) (string, error) {
+	switch shellName {
+	case string(interfaces.BashShell):
+		return filepath.Join(home, ".local", "share", "bash-completion", "completions", tool), nil
+	case string(interfaces.ZshShell):
+		return filepath.Join(home, ".zsh", "completions", "_"+tool), nil
+	case string(interfaces.FishShell):
+		return filepath.Join(home, ".config", "fish", "completions", tool+".fish"), nil
+	case string(interfaces.NuShell):
+		return filepath.Join(home, ".config", "nushell", "autoload", tool+"-completion.nu"), nil
+	case string(interfaces.PowerShell):
+		return filepath.Join(powerShellDir(home), "completions", tool+".ps1"), nil
+	default:
+		return "", fmt.Errorf("unsupported shell: %s", shellName)
+	}
+}
+
+// IntegrationPath returns where the integration code printed by a tool's Source
+// completion command is written for shell under home; it is sourced at startup
+// like the tool's shell config, not loaded as a completion function
+func IntegrationPath(home, shellName, tool string) (string, error) {
+	return ShellConfigPath(home, shellName, tool+"-integration")
+}
+
+// completionShell maps a shell name or path to bash, zsh, fish, nu or pwsh
+func completionShell(sh string) string {
+	sh = strings.TrimSuffix(filepath.Base(sh), ".exe")
+	switch {
+	case sh == "pwsh", sh == "powershell":
+		return string(interfaces.PowerShell)
+	case strings.Contains(sh, "zsh"):
+		return string(interfaces.ZshShell)
+	case strings.Contains(sh, "bash"):
+		return string(interfaces.BashShell)
+	case strings.Contains(sh, "fish"):
+		return string(interfaces.FishShell)
+	default:
+		return sh
+	}
+}
+
+// homebrewPrefix returns where Homebrew lives: $HOMEBREW_PREFIX, which `brew
+// shellenv` sets, or the default prefix for this platform
+func homebrewPrefix() string {
+	if prefix := os.Getenv("HOMEBREW_PREFIX"); prefix != "" {
+		return prefix
+	}
+	return system.HomebrewPrefix(runtime.GOOS, runtime.GOARCH)
+}
+
+// IntegrationScripts returns the integration scripts tool's package ships for
+// shellName when it was installed with manager, with {brew_prefix} replaced by
+// brewPrefix. Only bash and zsh source them; nil means the completion command
+// is used instead.
+func IntegrationScripts(tool *interfaces.Tool, manager, shellName, brewPrefix string) []string {
+	if shellName != string(interfaces.BashShell) && shellName != string(interfaces.ZshShell) {
+		return nil
+	}
+	if manager == "pkg" {
+		// Termux's pkg wraps apt but installs under its own prefix
+		return nil
+	}
+	replacer := strings.NewReplacer("{shell}", shellName, "{brew_prefix}", brewPrefix)
+	var scripts []string
+	for _, script := range tool.Completions.Scripts[manager] {
+		scripts = append(scripts, replacer.Replace(script))
+	}
+	return scripts
+}
+
+// InstallCompletions writes tool's completions for shells through rc, for a
+// tool that manager installed outside an Installer (e.g. by the pipeline)
+func InstallCompletions(tool *interfaces.Tool, manager string, shells []string, rc *shell.RCWriter, logger *log.Logger) error {
+	i := &Installer{Logger: logger, RCWriter: rc, Shells: shells, manager: manager}
+	return i.installCompletions(tool)
+}
+
+// installCompletions generates the tool's completion script for each configured
+// shell and makes the shell load it
+func (i *Installer) installCompletions(tool *interfaces.Tool) error {
+	if tool.Completions.Command == "" && len(tool.Completions.Scripts) == 0 {
+		return nil
+	}
+
+	shells, err := i.targetShells()
+	if err != nil {
+		return err
+	}
+	for _, sh := range shells {
+		name := completionShell(sh)
+		if !tool.HasCompletions(name) {
+			continue
+		}
+		if err := i.installCompletionFor(name, tool); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// installCompletionFor writes the tool's completion script for one shell, or
+// sources the scripts its package ships on this platform
+func (i *Installer) installCompletionFor(shellName string, tool *interfaces.Tool) error {
+	home, err := i.platform().HomeDir()
+	if err != nil {
+		return err
+	}
+	i.ensureRCWriter()
+
+	manager := i.manager
+	if i.PackageManager != nil {
+		manager = i.PackageManager.GetName()
+	}
+	if scripts := IntegrationScripts(tool, manager, shellName, homebrewPrefix()); len(scripts) > 0 {
+		lines := make([]string, len(scripts))
+		for n, script := range scripts {
+			lines[n] = fmt.Sprintf("[ -f %s ] && source %s", script, script)
+		}
+		return i.sourceFromRc(home, shellName, tool.Name, strings.Join(lines, "\n"))
+	}
+	if tool.Completions.Command == "" {
+		return nil
+	}
+
+	path, err := CompletionPath(home, shellName, tool.Name)
+	if tool.Completions.Source {
+		path, err = IntegrationPath(home, shellName, tool.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if i.RCWriter.DryRun {
+		i.Logger.Info("Dry run: not writing %s completions for %s to %s", shellName, tool.Name, path)
+	} else {
+		cmd := strings.ReplaceAll(tool.Completions.Command, "{shell}", completionCommandShell(shellName))
+		script, err := i.runner().Output(cmd)
+		if err != nil {
+			// Older releases may lack the completion command; the tool itself still works
+			i.Logger.Warn("Skipping %s completions for %s: %v", shellName, tool.Name, err)
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create completion directory: %w", err)
+		}
+		if err := os.WriteFile(path, script, 0644); err != nil {
+			return fmt.Errorf("failed to write completion script: %w", err)
+		}
+	}
+
+	source := fmt.Sprintf("[ -f %s ] && source %s", path, path)
+	if shellName == string(interfaces.PowerShell) {
+		source = psSource(path)
+	}
+	return i.sourceFromRc(home, shellName, tool.Name, source)
+}
+
+// completionCommandShell is the name completion commands use for shellName;
+// cobra and most other generators call PowerShell "powershell"
+func completionCommandShell(shellName string) string {
+	if shellName == string(interfaces.PowerShell) {
+		return "powershell"
+	}
+	return shellName
+}
+
+// psSource dot-sources the PowerShell script at path when it exists
+func psSource(path string) string {
+	quoted := shell.PSQuote(path)
+	return fmt.Sprintf("if (Test-Path %s) { . %s }", quoted, quoted)
+}
+
+// sourceFromRc writes body to the tool's completion block in the bash or zsh rc
+// file or the PowerShell profile; fish and nu need no block
+func (i *Installer) sourceFromRc(home, shellName, toolName, body string) error {
+	rcFile := rcFileFor(home, shellName)
+	if rcFile == "" {
+		return nil
+	}
+	if err := i.configureRcFile(rcFile, toolName+"-completion", body); err != nil {
+		return fmt.Errorf("failed to update %s: %w", filepath.Base(rcFile), err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package install
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+)
+
+func TestInstallCompletions(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	tool := &interfaces.Tool{Name: "demo"}
+	tool.Completions.Command = "echo complete-{shell}"
+	tool.Completions.Shells = []string{"bash", "fish"}
+
+	installer := &Installer{
+		Logger:   log.New(log.InfoLevel),
+		Shells:   []string{"bash", "/usr/bin/zsh", "fish"},
+		RCWriter: &shell.RCWriter{},
+	}
+	if err := installer.installCompletions(tool); err != nil {
+		t.Fatalf("installCompletions() error = %v", err)
+	}
+
+	for sh, want := range map[string]string{"bash": "complete-bash", "fish": "complete-fish"} {
+		path, err := CompletionPath(home, sh, tool.Name)
+		if err != nil {
+			t.Fatalf("CompletionPath(%s) error = %v", sh, err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Expected %s completions at %s: %v", sh, path, err)
+		}
+		if strings.TrimSpace(string(data)) != want {
+			t.Errorf("Expected %s completions %q, got %q", sh, want, data)
+		}
+	}
+
+	zshPath, _ := CompletionPath(home, "zsh", tool.Name)
+	if _, err := os.Stat(zshPath); !os.IsNotExist(err) {
+		t.Errorf("Expected no zsh completions for a tool limited to bash and fish")
+	}
+
+	bashrc, err := os.ReadFile(filepath.Join(home, ".bashrc"))
+	if err != nil {
+		t.Fatalf("Failed to read .bashrc: %v", err)
+	}
+	if !shell.HasBlock(string(bashrc), "demo-completion") {
+		t.Errorf("Expected .bashrc to source the completion script, got:\n%s", bashrc)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".config", "fish", "config.fish")); !os.IsNotExist(err) {
+		t.Errorf("Expected fish config to be left alone")
+	}
+}
+
+func TestInstallCompletionsSource(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// Like fzf --{shell}: integration code to source, not a completion function
+	tool := &interfaces.Tool{Name: "demo"}
+	tool.Completions.Command = "echo integration-{shell}"
+	tool.Completions.Source = true
+	tool.Completions.Scripts = map[string][]string{"apt": {"/usr/share/demo/key-bindings.{shell}"}}
+
+	if err := InstallCompletions(tool, "dnf", []string{"zsh", "fish"}, &shell.RCWriter{}, log.New(log.InfoLevel)); err != nil {
+		t.Fatalf("InstallCompletions() error = %v", err)
+	}
+
+	for sh, want := range map[string]string{"zsh": "integration-zsh", "fish": "integration-fish"} {
+		path, _ := IntegrationPath(home, sh, tool.Name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Expected %s integration code at %s: %v", sh, path, err)
+		}
+		if strings.TrimSpace(string(data)) != want {
+			t.Errorf("Expected %s integration code %q, got %q", sh, want, data)
+		}
+		if completion, _ := CompletionPath(home, sh, tool.Name); completion != path {
+			if _, err := os.Stat(completion); !os.IsNotExist(err) {
+				t.Errorf("Expected nothing in the %s completion path %s", sh, completion)
+			}
+		}
+	}
+
+	zshrc, err := os.ReadFile(filepath.Join(home, ".zshrc"))
+	if err != nil {
+		t.Fatalf("Failed to read .zshrc: %v", err)
+	}
+	path, _ := IntegrationPath(home, "zsh", tool.Name)
+	if !strings.Contains(string(zshrc), "source "+path) {
+		t.Errorf("Expected .zshrc to source %s, got:\n%s", path, zshrc)
+	}
+}
+
+func TestIntegrationScripts(t *testing.T) {
+	tool := &interfaces.Tool{Name: "fzf"}
+	tool.Completions.Scripts = map[string][]string{
+		"apt":  {"/usr/share/doc/fzf/examples/key-bindings.{shell}"},
+		"brew": {"{brew_prefix}/opt/fzf/shell/key-bindings.{shell}"},
+	}
+	tests := []struct {
+		manager, shell, prefix string
+		want                   []string
+	}{
+		{"apt", "zsh", "", []string{"/usr/share/doc/fzf/examples/key-bindings.zsh"}},
+		{"brew", "bash", "/opt/homebrew", []string{"/opt/homebrew/opt/fzf/shell/key-bindings.bash"}},
+		{"brew", "zsh", "/home/linuxbrew/.linuxbrew", []string{"/home/linuxbrew/.linuxbrew/opt/fzf/shell/key-bindings.zsh"}},
+		// fish falls back to the completion command, as do managers without scripts
+		{"apt", "fish", "", nil},
+		{"dnf", "bash", "", nil},
+		{"pkg", "bash", "", nil},
+	}
+	for _, tt := range tests {
+		got := IntegrationScripts(tool, tt.manager, tt.shell, tt.prefix)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("IntegrationScripts(%s, %s) = %v, want %v", tt.manager, tt.shell, got, tt.want)
+		}
+	}
+}
@@ -8,8 +8,10 @@ import (
 	"os/exec"
 	"path/filepath"
 
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cache"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
 )
 
 // FontInstaller handles font installation
@@ -26,7 +28,11 @@ func NewFontInstaller(logger *log.Logger) *FontInstaller {
 
 // InstallFont installs a font using its configuration
 func (f *FontInstaller) InstallFont(font *interfaces.Font) error {
-	fontDir := filepath.Join(os.Getenv("HOME"), ".local", "share", "fonts")
+	home, err := system.UserHome()
+	if err != nil {
+		return err
+	}
+	fontDir := filepath.Join(home, ".local", "share", "fonts")
 	if err := os.MkdirAll(fontDir, 0755); err != nil {
 		return fmt.Errorf("failed to create font directory: %w", err)
 	}
@@ -34,7 +40,11 @@ func (f *FontInstaller) InstallFont(font *interfaces.Font) error {
 	// Download the font
 	f.logger.Info("Downloading %s...", font.Name)
 	downloadPath := filepath.Join(fontDir, filepath.Base(font.Source))
-	if err := exec.Command("curl", "-L", "-o", downloadPath, font.Source).Run(); err != nil {
+	downloads, err := cache.NewDefault()
+	if err != nil {
+		return fmt.Errorf("failed to open download cache: %w", err)
+	}
+	if err := downloads.Fetch(font.Source, "", "", downloadPath); err != nil {
 		return fmt.Errorf("failed to download font: %w", err)
 	}
 
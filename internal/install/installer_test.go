@@ -0,0 +1,356 @@
+package install
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/install/installtest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+)
+
+// newTestInstaller returns an installer wired to fakes, writing under a temporary home
+func newTestInstaller(t *testing.T, pmName, shellPath string) (*Installer, *installtest.PackageManager, *installtest.Runner, *installtest.Platform) {
+	t.Helper()
+	pm := installtest.NewPackageManager(pmName)
+	runner := installtest.NewRunner()
+	platform := installtest.NewPlatform(t, shellPath)
+	return &Installer{
+		PackageManager: pm,
+		Logger:         log.New(log.ErrorLevel),
+		MaxRetries:     1,
+		RetryDelay:     time.Millisecond,
+		RCWriter:       &shell.RCWriter{},
+		Runner:         runner,
+		Platform:       platform,
+	}, pm, runner, platform
+}
+
+func ripgrepTool(version string) *interfaces.Tool {
+	tool := &interfaces.Tool{Name: "ripgrep", Version: version}
+	tool.PackageNames.APT = "ripgrep-apt"
+	tool.PackageNames.DNF = "ripgrep-dnf"
+	tool.PackageNames.Pacman = "ripgrep-pacman"
+	tool.PackageNames.Brew = "ripgrep-brew"
+	return tool
+}
+
+func TestInstall_PackageManagerMatrix(t *testing.T) {
+	tests := []struct {
+		manager interfaces.PackageManagerType
+		version string
+		want    string
+	}{
+		{interfaces.APT, "", "ripgrep-apt"},
+		{interfaces.APT, "14.1", "ripgrep-apt=14.1"},
+		{interfaces.DNF, "14.1", "ripgrep-dnf-14.1"},
+		{interfaces.Pacman, "14.1", "ripgrep-pacman=14.1"},
+		{interfaces.Homebrew, "14.1", "ripgrep-brew@14.1"},
+		{interfaces.Homebrew, "latest", "ripgrep-brew"},
+		// Termux's pkg wraps apt, so it uses the apt package name and version syntax
+		{interfaces.Pkg, "", "ripgrep-apt"},
+		{interfaces.Pkg, "14.1", "ripgrep-apt=14.1"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.manager)+"/"+tt.version, func(t *testing.T) {
+			installer, pm, _, _ := newTestInstaller(t, string(tt.manager), "/bin/bash")
+			if err := installer.Install(ripgrepTool(tt.version)); err != nil {
+				t.Fatalf("Install() error = %v", err)
+			}
+			if got := pm.Installs(); !reflect.DeepEqual(got, []string{tt.want}) {
+				t.Errorf("installed %v, want [%s]", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstall_FallsBackToToolName(t *testing.T) {
+	for _, manager := range []interfaces.PackageManagerType{interfaces.APT, interfaces.DNF, interfaces.Pacman, interfaces.Homebrew, interfaces.Pkg} {
+		installer, pm, _, _ := newTestInstaller(t, string(manager), "/bin/bash")
+		if err := installer.Install(&interfaces.Tool{Name: "jq"}); err != nil {
+			t.Fatalf("%s: Install() error = %v", manager, err)
+		}
+		if got := pm.Installs(); !reflect.DeepEqual(got, []string{"jq"}) {
+			t.Errorf("%s: installed %v, want [jq]", manager, got)
+		}
+	}
+}
+
+func TestInstall_DependenciesBeforeTool(t *testing.T) {
+	installer, pm, _, _ := newTestInstaller(t, "apt", "/bin/bash")
+	tool := &interfaces.Tool{Name: "lazygit", SystemDependencies: []string{"git"}}
+	tool.Dependencies = append(tool.Dependencies, interfaces.Dependency{Name: "delta", Optional: true})
+	pm.Fail["delta"] = errors.New("not packaged")
+
+	if err := installer.Install(tool); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if got, want := pm.Installs(), []string{"git", "delta", "lazygit"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("installed %v, want %v", got, want)
+	}
+
+	pm.Fail["git"] = errors.New("mirror down")
+	if err := installer.Install(tool); err == nil {
+		t.Error("expected a failing system dependency to fail the install")
+	}
+}
+
+func TestInstall_RunsCommandsThroughRunner(t *testing.T) {
+	installer, _, runner, _ := newTestInstaller(t, "apt", "/bin/bash")
+	tool := &interfaces.Tool{Name: "fzf", VerifyCommand: "fzf --version"}
+	tool.PostInstall = append(tool.PostInstall, struct {
+		Command     string `yaml:"command"`
+		Description string `yaml:"description"`
+	}{Command: "fzf-setup --all"})
+
+	if err := installer.Install(tool); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if got, want := runner.Commands(), []string{"fzf-setup --all", "fzf --version"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ran %v, want %v", got, want)
+	}
+
+	runner.Errors["fzf --version"] = errors.New("exit status 127")
+	if err := installer.Install(tool); err == nil {
+		t.Error("expected a failing verify command to fail the install")
+	}
+}
+
+func TestInstall_ShellMatrix(t *testing.T) {
+	tests := []struct {
+		shell   string
+		rcFile  string
+		wantCfg string
+	}{
+		{"/bin/bash", ".bashrc", "alias ll='ls -l'"},
+		{"/usr/bin/zsh", ".zshrc", "alias ll='ls -l'"},
+		{"/usr/bin/fish", "", "alias ll 'ls -l'"},
+	}
+	for _, tt := range tests {
+		t.Run(filepath.Base(tt.shell), func(t *testing.T) {
+			installer, _, _, platform := newTestInstaller(t, "apt", tt.shell)
+			tool := &interfaces.Tool{Name: "lsd"}
+			tool.ShellConfig.Aliases = map[string]string{"ll": "ls -l"}
+
+			if err := installer.Install(tool); err != nil {
+				t.Fatalf("Install() error = %v", err)
+			}
+			cfgPath, _ := ShellConfigPath(platform.Home, filepath.Base(tt.shell), "lsd")
+			cfg, err := os.ReadFile(cfgPath)
+			if err != nil || !strings.Contains(string(cfg), tt.wantCfg) {
+				t.Errorf("expected %s to contain %q, got %q (%v)", cfgPath, tt.wantCfg, cfg, err)
+			}
+			if tt.rcFile == "" {
+				return
+			}
+			rc, _ := os.ReadFile(filepath.Join(platform.Home, tt.rcFile))
+			if !shell.HasBlock(string(rc), "lsd") || !strings.Contains(string(rc), "source "+cfgPath) {
+				t.Errorf("expected %s to source %s, got:\n%s", tt.rcFile, cfgPath, rc)
+			}
+		})
+	}
+}
+
+func TestInstall_UnsupportedShell(t *testing.T) {
+	installer, _, _, _ := newTestInstaller(t, "apt", "/bin/tcsh")
+	tool := &interfaces.Tool{Name: "lsd"}
+	tool.ShellConfig.Aliases = map[string]string{"ll": "ls -l"}
+	if err := installer.Install(tool); err == nil {
+		t.Error("expected an error configuring an unsupported shell")
+	}
+}
+
+func TestInstall_CompletionsFromRunner(t *testing.T) {
+	installer, _, runner, platform := newTestInstaller(t, "brew", "/usr/bin/zsh")
+	tool := &interfaces.Tool{Name: "gh"}
+	tool.Completions.Command = "gh completion -s {shell}"
+	runner.Outputs["gh completion -s zsh"] = "#compdef gh\n"
+
+	if err := installer.Install(tool); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	path, _ := CompletionPath(platform.Home, "zsh", "gh")
+	if data, err := os.ReadFile(path); err != nil || string(data) != "#compdef gh\n" {
+		t.Errorf("expected the runner's completion script at %s, got %q (%v)", path, data, err)
+	}
+}
+
+func TestInstall_CompletionsFromPackageScripts(t *testing.T) {
+	t.Setenv("HOMEBREW_PREFIX", "/opt/homebrew")
+	installer, _, runner, platform := newTestInstaller(t, "brew", "/bin/bash")
+	tool := &interfaces.Tool{Name: "fzf"}
+	tool.Completions.Command = "fzf --{shell}"
+	tool.Completions.Scripts = map[string][]string{
+		"apt":  {"/usr/share/doc/fzf/examples/key-bindings.{shell}"},
+		"brew": {"{brew_prefix}/opt/fzf/shell/key-bindings.{shell}"},
+	}
+
+	if err := installer.Install(tool); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	bashrc, err := os.ReadFile(filepath.Join(platform.Home, ".bashrc"))
+	if err != nil {
+		t.Fatalf("Failed to read .bashrc: %v", err)
+	}
+	want := "[ -f /opt/homebrew/opt/fzf/shell/key-bindings.bash ] && source /opt/homebrew/opt/fzf/shell/key-bindings.bash"
+	if !strings.Contains(string(bashrc), want) {
+		t.Errorf("expected .bashrc to source the brew script, got:\n%s", bashrc)
+	}
+	if strings.Contains(string(bashrc), "/usr/share") {
+		t.Errorf("expected only the brew path, got:\n%s", bashrc)
+	}
+	path, _ := CompletionPath(platform.Home, "bash", "fzf")
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected no generated completion script when the package ships one")
+	}
+	if len(runner.Commands()) != 0 {
+		t.Errorf("expected the completion command not to run, ran %v", runner.Commands())
+	}
+}
+
+func TestInstall_BufferedRCWritesOnFinish(t *testing.T) {
+	installer, _, _, platform := newTestInstaller(t, "apt", "/bin/bash")
+	installer.RCWriter = &shell.RCWriter{Buffered: true}
+	bashrc := filepath.Join(platform.Home, ".bashrc")
+
+	for _, name := range []string{"lsd", "bat", "lsd"} {
+		tool := &interfaces.Tool{Name: name}
+		tool.ShellConfig.Aliases = map[string]string{"ll": "ls -l"}
+		if err := installer.Install(tool); err != nil {
+			t.Fatalf("Install(%s) error = %v", name, err)
+		}
+	}
+	if _, err := os.Stat(bashrc); !os.IsNotExist(err) {
+		t.Fatalf("expected .bashrc to be untouched before FinishInstallation, got %v", err)
+	}
+
+	if err := installer.FinishInstallation(); err != nil {
+		t.Fatalf("FinishInstallation() error = %v", err)
+	}
+	rc, _ := os.ReadFile(bashrc)
+	if strings.Count(string(rc), shell.BlockEnd("lsd")) != 1 || !shell.HasBlock(string(rc), "bat") {
+		t.Errorf("expected one lsd and one bat block, got:\n%s", rc)
+	}
+	if changes := installer.RCWriter.Changes(); len(changes) != 1 || changes[0].Block != "lsd,bat" {
+		t.Errorf("expected a single change to .bashrc, got %+v", changes)
+	}
+}
+
+func TestInstall_FishConfigSyntax(t *testing.T) {
+	installer, _, _, platform := newTestInstaller(t, "apt", "/usr/bin/fish")
+	tool := &interfaces.Tool{Name: "fzf"}
+	tool.ShellConfig.Aliases = map[string]string{"preview": "fzf --preview 'bat {}'"}
+	tool.ShellConfig.Env = map[string]string{
+		"FZF_CTRL_T_COMMAND": "$FZF_DEFAULT_COMMAND",
+		"LS_COLORS":          "$(vivid generate molokai)",
+		"BROKEN":             "${SHELL##*/}",
+	}
+	tool.ShellConfig.Path = []string{"$HOME/.fzf/bin"}
+
+	if err := installer.Install(tool); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	cfgPath, _ := ShellConfigPath(platform.Home, "fish", "fzf")
+	cfg, err := os.ReadFile(cfgPath)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", cfgPath, err)
+	}
+	for _, want := range []string{
+		`alias preview 'fzf --preview \'bat {}\''`,
+		"set -gx FZF_CTRL_T_COMMAND $FZF_DEFAULT_COMMAND",
+		"set -gx LS_COLORS (vivid generate molokai | string collect)",
+		"fish_add_path $HOME/.fzf/bin",
+	} {
+		if !strings.Contains(string(cfg), want) {
+			t.Errorf("expected %q in fish config:\n%s", want, cfg)
+		}
+	}
+	if strings.Contains(string(cfg), "BROKEN") {
+		t.Errorf("expected the bash-only expansion to be skipped:\n%s", cfg)
+	}
+}
+
+func TestInstall_NuConfigSyntax(t *testing.T) {
+	installer, _, _, platform := newTestInstaller(t, "apt", "/usr/bin/nu")
+	tool := &interfaces.Tool{Name: "fzf"}
+	tool.ShellConfig.Aliases = map[string]string{"preview": "fzf --preview 'bat {}'", "broken": "cd $HOME && ls"}
+	tool.ShellConfig.Env = map[string]string{
+		"FZF_CTRL_T_COMMAND": "$FZF_DEFAULT_COMMAND",
+		"LS_COLORS":          "$(vivid generate molokai)",
+		"BROKEN":             "${SHELL##*/}",
+	}
+	tool.ShellConfig.Path = []string{"$HOME/.fzf/bin"}
+
+	if err := installer.Install(tool); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	cfgPath, _ := ShellConfigPath(platform.Home, "nu", "fzf")
+	cfg, err := os.ReadFile(cfgPath)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", cfgPath, err)
+	}
+	for _, want := range []string{
+		`alias preview = ^fzf --preview 'bat {}'`,
+		"$env.FZF_CTRL_T_COMMAND = $env.FZF_DEFAULT_COMMAND",
+		"$env.LS_COLORS = (vivid generate molokai | str trim)",
+		`$env.PATH = ($env.PATH | split row (char esep) | prepend $"($env.HOME)/.fzf/bin")`,
+	} {
+		if !strings.Contains(string(cfg), want) {
+			t.Errorf("expected %q in nushell config:\n%s", want, cfg)
+		}
+	}
+	if strings.Contains(string(cfg), "BROKEN") || strings.Contains(string(cfg), "broken") {
+		t.Errorf("expected the bash-only alias and expansion to be skipped:\n%s", cfg)
+	}
+	// Nushell loads its autoload directory itself, so no rc file is touched
+	if _, err := os.Stat(filepath.Join(platform.Home, ".config", "nushell", "config.nu")); !os.IsNotExist(err) {
+		t.Errorf("expected no config.nu to be written, got %v", err)
+	}
+}
+
+func TestInstall_PowerShellConfig(t *testing.T) {
+	installer, _, _, platform := newTestInstaller(t, "apt", "/usr/bin/pwsh")
+	tool := &interfaces.Tool{Name: "bat"}
+	tool.ShellConfig.Aliases = map[string]string{"cat": "bat --paging=never", "broken": "cd $HOME && ls"}
+	tool.ShellConfig.Env = map[string]string{"BAT_THEME": "Dracula", "BROKEN": "${SHELL##*/}"}
+	tool.ShellConfig.Path = []string{"$HOME/.local/bin"}
+	tool.ShellConfig.PowerShell = "Set-PSReadLineOption -EditMode Emacs"
+
+	if err := installer.Install(tool); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	cfgPath, _ := ShellConfigPath(platform.Home, "pwsh", "bat")
+	cfg, err := os.ReadFile(cfgPath)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", cfgPath, err)
+	}
+	for _, want := range []string{
+		"Remove-Item Alias:cat -Force -ErrorAction SilentlyContinue\nfunction cat { bat --paging=never @args }",
+		"$env:BAT_THEME = 'Dracula'",
+		`$env:PATH = "${env:HOME}/.local/bin" + [IO.Path]::PathSeparator + $env:PATH`,
+		"Set-PSReadLineOption -EditMode Emacs",
+	} {
+		if !strings.Contains(string(cfg), want) {
+			t.Errorf("expected %q in PowerShell config:\n%s", want, cfg)
+		}
+	}
+	if strings.Contains(string(cfg), "BROKEN") || strings.Contains(string(cfg), "broken") {
+		t.Errorf("expected the bash-only alias and expansion to be skipped:\n%s", cfg)
+	}
+
+	// The profile dot-sources the tool's script from a managed block
+	profile, err := os.ReadFile(filepath.Join(platform.Home, ".config", "powershell", "Microsoft.PowerShell_profile.ps1"))
+	if err != nil {
+		t.Fatalf("failed to read the PowerShell profile: %v", err)
+	}
+	if want := fmt.Sprintf("if (Test-Path '%s') { . '%s' }", cfgPath, cfgPath); !strings.Contains(string(profile), want) {
+		t.Errorf("expected %q in the profile:\n%s", want, profile)
+	}
+}
@@ -0,0 +1,167 @@
+// Package installtest provides fakes for testing code built on the install
+// package without touching the host: a package manager that records what it
+// was asked to install, a command runner with scripted results, and a
+// platform rooted in a temporary home directory.
+package installtest
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// PackageManager is an in-memory interfaces.PackageManager
+type PackageManager struct {
+	// Name is returned by GetName (apt, dnf, pacman, brew, pkg)
+	Name string
+	// Fail makes Install of the named package return the error
+	Fail map[string]error
+
+	mu        sync.Mutex
+	installs  []string
+	installed map[string]bool
+}
+
+// NewPackageManager returns a fake package manager called name
+func NewPackageManager(name string) *PackageManager {
+	return &PackageManager{Name: name, Fail: map[string]error{}, installed: map[string]bool{}}
+}
+
+// Installs returns every package spec passed to Install, in order
+func (m *PackageManager) Installs() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.installs...)
+}
+
+// Install records packageName and marks it installed unless Fail has an error for it
+func (m *PackageManager) Install(packageName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.installs = append(m.installs, packageName)
+	if err := m.Fail[packageName]; err != nil {
+		return err
+	}
+	m.installed[packageName] = true
+	return nil
+}
+
+// IsInstalled reports whether packageName was installed
+func (m *PackageManager) IsInstalled(packageName string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.installed[packageName], nil
+}
+
+// GetName returns Name
+func (m *PackageManager) GetName() string { return m.Name }
+
+// IsAvailable always reports true
+func (m *PackageManager) IsAvailable() bool { return true }
+
+// IsPackageAvailable always reports true
+func (m *PackageManager) IsPackageAvailable(string) bool { return true }
+
+// Update does nothing
+func (m *PackageManager) Update() error { return nil }
+
+// Upgrade does nothing
+func (m *PackageManager) Upgrade() error { return nil }
+
+// Uninstall marks packageName as not installed
+func (m *PackageManager) Uninstall(packageName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.installed, packageName)
+	return nil
+}
+
+// GetVersion returns an error for packages that are not installed
+func (m *PackageManager) GetVersion(packageName string) (string, error) {
+	if ok, _ := m.IsInstalled(packageName); !ok {
+		return "", fmt.Errorf("package %s not installed", packageName)
+	}
+	return "", nil
+}
+
+// ListInstalled returns the installed packages in sorted order
+func (m *PackageManager) ListInstalled() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pkgs := make([]string, 0, len(m.installed))
+	for pkg := range m.installed {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	return pkgs, nil
+}
+
+// SetupSpecialPackage does nothing
+func (m *PackageManager) SetupSpecialPackage(string) error { return nil }
+
+// Runner is an install.CommandRunner that records commands instead of running them
+type Runner struct {
+	// Outputs maps a command to what Output returns for it
+	Outputs map[string]string
+	// Errors maps a command to the error Run and Output return for it
+	Errors map[string]error
+
+	mu       sync.Mutex
+	commands []string
+}
+
+// NewRunner returns a runner where every command succeeds with no output
+func NewRunner() *Runner {
+	return &Runner{Outputs: map[string]string{}, Errors: map[string]error{}}
+}
+
+// Commands returns every command run so far, in order
+func (r *Runner) Commands() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.commands...)
+}
+
+// Run records command and returns its scripted error
+func (r *Runner) Run(command string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commands = append(r.commands, command)
+	return r.Errors[command]
+}
+
+// Output records command and returns its scripted output and error
+func (r *Runner) Output(command string) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commands = append(r.commands, command)
+	if err := r.Errors[command]; err != nil {
+		return nil, err
+	}
+	return []byte(r.Outputs[command]), nil
+}
+
+// Platform is an install.PlatformProvider with a fixed home and shell
+type Platform struct {
+	Home      string
+	ShellPath string
+}
+
+// NewPlatform returns a platform whose home is a fresh temporary directory and
+// whose current shell is shellPath
+func NewPlatform(t testing.TB, shellPath string) *Platform {
+	t.Helper()
+	return &Platform{Home: t.TempDir(), ShellPath: shellPath}
+}
+
+// HomeDir returns Home
+func (p *Platform) HomeDir() (string, error) {
+	if p.Home == "" {
+		return "", fmt.Errorf("no home directory")
+	}
+	return p.Home, nil
+}
+
+// Shell returns ShellPath
+func (p *Platform) Shell() string { return p.ShellPath }
@@ -0,0 +1,297 @@
+package install
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+)
+
+// Actions a plan takes on an rc file
+const (
+	// RCCreate creates a missing rc file
+	RCCreate = "create"
+	// RCAppend appends new managed blocks to an existing rc file
+	RCAppend = "append"
+	// RCUpdate only rewrites managed blocks the rc file already has
+	RCUpdate = "update"
+)
+
+// runtimeBlocks are the managed rc blocks the version managers set up by
+// RuntimeInstaller write, by language
+var runtimeBlocks = map[string]string{
+	"Node.js": "nvm",
+	"Python":  "pyenv",
+	"Go":      "goenv",
+	"Rust":    "rust",
+}
+
+// Selections are what a run installs and configures
+type Selections struct {
+	Tools []*interfaces.Tool
+	// Catalog supplies the tools Tools require but do not include
+	Catalog   []*interfaces.Tool
+	Languages []*interfaces.Language
+	// LanguageStrategy is the default strategy for languages without their own
+	LanguageStrategy string
+	// VersionManager forces an existing version manager (see SetVersionManager)
+	VersionManager string
+	Shell          string
+	PromptStyle    string
+	// PluginManager is the shell framework, e.g. oh-my-zsh
+	PluginManager string
+}
+
+// PlannedTool is a tool and the package it would be installed from
+type PlannedTool struct {
+	Name    string
+	Package string
+	// RequiredBy is the tool that pulled this one in, when it was not selected
+	RequiredBy string
+}
+
+// PlannedLanguage is a language and how it would be installed
+type PlannedLanguage struct {
+	Name     string
+	Strategy string
+	// Manager is the version manager, or the package manager for the system strategy
+	Manager string
+}
+
+// PlannedRCFile is an rc file and the managed blocks that would be written to it
+type PlannedRCFile struct {
+	Path string
+	// Action is RCCreate, RCAppend or RCUpdate
+	Action string
+	Blocks []string
+}
+
+// Plan is everything a run would install and every rc file it would modify
+type Plan struct {
+	PackageManager string
+	Tools          []PlannedTool
+	Languages      []PlannedLanguage
+	Shell          string
+	PromptStyle    string
+	PluginManager  string
+	RCFiles        []*PlannedRCFile
+}
+
+// BuildPlan works out what installing sel would do. It only reads files:
+// nothing is installed, written or executed.
+func (i *Installer) BuildPlan(sel Selections) (*Plan, error) {
+	home, err := i.platform().HomeDir()
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{
+		Shell:         sel.Shell,
+		PromptStyle:   sel.PromptStyle,
+		PluginManager: sel.PluginManager,
+	}
+	if i.PackageManager != nil {
+		plan.PackageManager = i.PackageManager.GetName()
+	}
+
+	shells := i.Shells
+	if len(shells) == 0 && sel.Shell != "" {
+		shells = []string{sel.Shell}
+	}
+	if len(shells) == 0 {
+		if shells, err = i.targetShells(); err != nil {
+			return nil, err
+		}
+	}
+	if sel.PluginManager != "" && sel.Shell != "" {
+		if err := shell.ValidateShells([]string{sel.Shell}, sel.PluginManager); err != nil {
+			return nil, err
+		}
+	}
+	tools, requiredBy, err := OrderByRequires(sel.Tools, sel.Catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	rc := newRCPlanner()
+	for _, tool := range tools {
+		plan.Tools = append(plan.Tools, PlannedTool{Name: tool.Name, Package: i.getSystemPackageName(tool), RequiredBy: requiredBy[tool.Name]})
+		hasConfig := tool.ShellConfig.Aliases != nil || tool.ShellConfig.Env != nil || len(tool.ShellConfig.Path) > 0
+		for _, sh := range shells {
+			name := completionShell(sh)
+			rcFile := rcFileFor(home, name)
+			if rcFile == "" {
+				continue
+			}
+			if hasConfig || (name == string(interfaces.PowerShell) && tool.ShellConfig.PowerShell != "") {
+				if err := rc.add(rcFile, tool.Name); err != nil {
+					return nil, err
+				}
+			}
+			if tool.HasCompletions(name) {
+				if err := rc.add(rcFile, tool.Name+"-completion"); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	for _, lang := range sel.Languages {
+		planned := PlannedLanguage{Name: lang.Name, Strategy: lang.ResolveStrategy(sel.LanguageStrategy)}
+		if planned.Strategy == interfaces.LanguageStrategySystem {
+			planned.Manager = plan.PackageManager
+			plan.Languages = append(plan.Languages, planned)
+			continue
+		}
+		if vm := system.DetectVersionManager(lang.Name, sel.VersionManager, nil); vm != nil {
+			// An existing version manager leaves rc files alone
+			planned.Manager = vm.Name
+			plan.Languages = append(plan.Languages, planned)
+			continue
+		}
+		planned.Manager = lang.GetInstaller()
+		plan.Languages = append(plan.Languages, planned)
+		if block, ok := runtimeBlocks[lang.Name]; ok {
+			// Version managers only edit the rc files that already exist
+			for _, name := range []string{".bashrc", ".zshrc"} {
+				rcFile := filepath.Join(home, name)
+				if _, err := os.Stat(rcFile); err != nil {
+					continue
+				}
+				if err := rc.add(rcFile, block); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if sel.PromptStyle != "" && sel.Shell != "" {
+		if err := shell.ValidatePromptStyle(sel.PromptStyle, sel.Shell); err != nil {
+			return nil, err
+		}
+		rcFile := rcFileFor(home, sel.Shell)
+		switch sel.Shell {
+		case string(interfaces.FishShell):
+			rcFile = filepath.Join(home, ".config", "fish", "config.fish")
+		case string(interfaces.NuShell):
+			rcFile = filepath.Join(home, ".config", "nushell", "config.nu")
+		}
+		if rcFile != "" {
+			if err := rc.add(rcFile, shell.PromptBlock); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	plan.RCFiles = rc.files
+	return plan, nil
+}
+
+// rcPlanner collects the blocks planned for each rc file, in the order the
+// files are first touched
+type rcPlanner struct {
+	files  []*PlannedRCFile
+	byPath map[string]*PlannedRCFile
+	// content caches each rc file as read from disk
+	content map[string]string
+}
+
+func newRCPlanner() *rcPlanner {
+	return &rcPlanner{byPath: make(map[string]*PlannedRCFile), content: make(map[string]string)}
+}
+
+// add plans writing block to the rc file at path
+func (p *rcPlanner) add(path, block string) error {
+	file, ok := p.byPath[path]
+	if !ok {
+		data, err := os.ReadFile(path)
+		switch {
+		case os.IsNotExist(err):
+			file = &PlannedRCFile{Path: path, Action: RCCreate}
+		case err != nil:
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		default:
+			file = &PlannedRCFile{Path: path, Action: RCUpdate}
+			p.content[path] = string(data)
+		}
+		p.byPath[path] = file
+		p.files = append(p.files, file)
+	}
+	for _, b := range file.Blocks {
+		if b == block {
+			return nil
+		}
+	}
+	file.Blocks = append(file.Blocks, block)
+	if file.Action == RCUpdate && !shell.HasBlock(p.content[path], block) {
+		file.Action = RCAppend
+	}
+	return nil
+}
+
+// Print writes the plan to w as a tree
+func (p *Plan) Print(w io.Writer) {
+	title := "Execution plan"
+	if p.PackageManager != "" {
+		title = fmt.Sprintf("Execution plan (package manager: %s)", p.PackageManager)
+	}
+	fmt.Fprintln(w, title)
+
+	var sections []treeNode
+	tools := treeNode{label: "Tools"}
+	for _, tool := range p.Tools {
+		label := tool.Name
+		if tool.Package != tool.Name {
+			label = fmt.Sprintf("%s -> %s", tool.Name, tool.Package)
+		}
+		if tool.RequiredBy != "" {
+			label = fmt.Sprintf("%s (required by %s)", label, tool.RequiredBy)
+		}
+		tools.children = append(tools.children, treeNode{label: label})
+	}
+	languages := treeNode{label: "Languages"}
+	for _, lang := range p.Languages {
+		languages.children = append(languages.children, treeNode{label: fmt.Sprintf("%s -> %s (%s)", lang.Name, lang.Manager, lang.Strategy)})
+	}
+	shellNode := treeNode{label: "Shell"}
+	for _, setting := range [][2]string{{"shell", p.Shell}, {"prompt", p.PromptStyle}, {"plugin manager", p.PluginManager}} {
+		if setting[1] != "" {
+			shellNode.children = append(shellNode.children, treeNode{label: setting[0] + ": " + setting[1]})
+		}
+	}
+	rcFiles := treeNode{label: "Shell rc files"}
+	for _, file := range p.RCFiles {
+		node := treeNode{label: fmt.Sprintf("%s (%s)", file.Path, file.Action)}
+		for _, block := range file.Blocks {
+			node.children = append(node.children, treeNode{label: block})
+		}
+		rcFiles.children = append(rcFiles.children, node)
+	}
+	for _, section := range []treeNode{tools, languages, shellNode, rcFiles} {
+		if len(section.children) == 0 {
+			section.children = []treeNode{{label: "(none)"}}
+		}
+		sections = append(sections, section)
+	}
+	printTree(w, sections, "")
+}
+
+type treeNode struct {
+	label    string
+	children []treeNode
+}
+
+// printTree writes nodes and their children with box-drawing branches
+func printTree(w io.Writer, nodes []treeNode, indent string) {
+	for n, node := range nodes {
+		branch, next := "├── ", "│   "
+		if n == len(nodes)-1 {
+			branch, next = "└── ", "    "
+		}
+		fmt.Fprintf(w, "%s%s%s\n", indent, branch, node.label)
+		printTree(w, node.children, indent+next)
+	}
+}
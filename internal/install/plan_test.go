@@ -0,0 +1,124 @@
+package install
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/install/installtest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+)
+
+func TestBuildPlan(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("PATH", t.TempDir())
+	bashrc := filepath.Join(home, ".bashrc")
+	existing := shell.UpsertBlock("", "fd", "source ~/.bash/fd.bash")
+	if err := os.WriteFile(bashrc, []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fd := &interfaces.Tool{Name: "fd"}
+	fd.PackageNames.APT = "fd-find"
+	fd.ShellConfig.Aliases = map[string]string{"find": "fd"}
+	gh := &interfaces.Tool{Name: "gh"}
+	gh.Completions.Command = "gh completion -s {shell}"
+	python := &interfaces.Language{Name: "Python", Installer: "pyenv"}
+	golang := &interfaces.Language{Name: "Go", Installer: "goenv", Strategy: interfaces.LanguageStrategySystem}
+
+	installer := &Installer{
+		PackageManager: installtest.NewPackageManager("apt"),
+		Shells:         []string{"zsh", "bash"},
+		Platform:       &installtest.Platform{Home: home},
+	}
+	plan, err := installer.BuildPlan(Selections{
+		Tools:       []*interfaces.Tool{fd, gh},
+		Languages:   []*interfaces.Language{python, golang},
+		Shell:       "zsh",
+		PromptStyle: shell.PromptStarship,
+	})
+	if err != nil {
+		t.Fatalf("BuildPlan() error = %v", err)
+	}
+
+	if got := plan.Tools; len(got) != 2 || got[0].Package != "fd-find" || got[1].Package != "gh" {
+		t.Errorf("Expected fd to resolve to fd-find and gh to gh, got %+v", got)
+	}
+	if got := plan.Languages; len(got) != 2 || got[0].Manager != "pyenv" || got[1].Manager != "apt" {
+		t.Errorf("Expected Python through pyenv and Go through apt, got %+v", got)
+	}
+
+	want := map[string]PlannedRCFile{
+		filepath.Join(home, ".zshrc"): {Action: RCCreate, Blocks: []string{"fd", "gh-completion", "prompt"}},
+		bashrc:                        {Action: RCAppend, Blocks: []string{"fd", "gh-completion", "pyenv"}},
+	}
+	if len(plan.RCFiles) != len(want) {
+		t.Fatalf("Expected %d rc files, got %+v", len(want), plan.RCFiles)
+	}
+	for _, file := range plan.RCFiles {
+		w, ok := want[file.Path]
+		if !ok {
+			t.Errorf("Unexpected rc file %s", file.Path)
+			continue
+		}
+		if file.Action != w.Action || strings.Join(file.Blocks, ",") != strings.Join(w.Blocks, ",") {
+			t.Errorf("%s: expected %s %v, got %s %v", file.Path, w.Action, w.Blocks, file.Action, file.Blocks)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(home, ".zshrc")); !os.IsNotExist(err) {
+		t.Errorf("Expected BuildPlan not to create .zshrc")
+	}
+	data, _ := os.ReadFile(bashrc)
+	if string(data) != existing {
+		t.Errorf("Expected BuildPlan not to modify .bashrc")
+	}
+}
+
+func TestBuildPlanUpdateOnly(t *testing.T) {
+	home := t.TempDir()
+	zshrc := filepath.Join(home, ".zshrc")
+	if err := os.WriteFile(zshrc, []byte(shell.UpsertBlock("", shell.PromptBlock, "old")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	installer := &Installer{Platform: &installtest.Platform{Home: home}}
+	plan, err := installer.BuildPlan(Selections{Shell: "zsh", PromptStyle: shell.PromptStarship})
+	if err != nil {
+		t.Fatalf("BuildPlan() error = %v", err)
+	}
+	if len(plan.RCFiles) != 1 || plan.RCFiles[0].Action != RCUpdate {
+		t.Errorf("Expected only the existing prompt block to be updated, got %+v", plan.RCFiles)
+	}
+}
+
+func TestPlanPrint(t *testing.T) {
+	plan := &Plan{
+		PackageManager: "apt",
+		Tools:          []PlannedTool{{Name: "fd", Package: "fd-find"}, {Name: "gh", Package: "gh"}},
+		Shell:          "zsh",
+		RCFiles:        []*PlannedRCFile{{Path: "/home/u/.zshrc", Action: RCCreate, Blocks: []string{"fd"}}},
+	}
+	var buf bytes.Buffer
+	plan.Print(&buf)
+
+	want := `Execution plan (package manager: apt)
+├── Tools
+│   ├── fd -> fd-find
+│   └── gh
+├── Languages
+│   └── (none)
+├── Shell
+│   └── shell: zsh
+└── Shell rc files
+    └── /home/u/.zshrc (create)
+        └── fd
+`
+	if buf.String() != want {
+		t.Errorf("Print() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
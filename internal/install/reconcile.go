@@ -0,0 +1,149 @@
+package install
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+)
+
+// ShellConfigPath returns the file holding tool's aliases, environment and PATH
+// entries for shell under home
+func ShellConfigPath(home, shellName, tool string) (string, error) {
+	switch shellName {
+	case string(interfaces.BashShell):
+		return filepath.Join(home, ".bash", tool+".bash"), nil
+	case string(interfaces.ZshShell):
+		return filepath.Join(home, ".zsh", tool+".zsh"), nil
+	case string(interfaces.FishShell):
+		return filepath.Join(home, ".config", "fish", "conf.d", tool+".fish"), nil
+	case string(interfaces.NuShell):
+		return filepath.Join(home, ".config", "nushell", "autoload", tool+".nu"), nil
+	case string(interfaces.PowerShell):
+		return filepath.Join(powerShellDir(home), tool+".ps1"), nil
+	default:
+		return "", fmt.Errorf("unsupported shell: %s", shellName)
+	}
+}
+
+// powerShellDir is where the per-tool PowerShell scripts live, next to $PROFILE
+func powerShellDir(home string) string {
+	return filepath.Join(filepath.Dir(shell.PowerShellProfile(home, runtime.GOOS, exec.LookPath)), "bootstrap-cli")
+}
+
+// rcFileFor returns the rc file that sources tool integrations for shell, or ""
+// for fish and nu, which load conf.d and autoload on their own
+func rcFileFor(home, shellName string) string {
+	switch shellName {
+	case string(interfaces.BashShell):
+		return filepath.Join(home, ".bashrc")
+	case string(interfaces.ZshShell):
+		return filepath.Join(home, ".zshrc")
+	case string(interfaces.PowerShell):
+		return shell.PowerShellProfile(home, runtime.GOOS, exec.LookPath)
+	default:
+		return ""
+	}
+}
+
+// ConfigureInstalledTools reconciles the tool-integration layer with selected:
+// the shell configuration and completions of every selected tool are written or
+// refreshed, and those of catalog tools that are no longer selected are removed.
+// Only blocks named after a catalog tool are touched, so the base shell block and
+// any other managed blocks are left as they are.
+func (i *Installer) ConfigureInstalledTools(selected, catalog []*interfaces.Tool) error {
+	keep := make(map[string]bool, len(selected))
+	for _, tool := range selected {
+		keep[tool.Name] = true
+		if err := i.applyShellConfig(tool); err != nil {
+			return fmt.Errorf("failed to configure %s: %w", tool.Name, err)
+		}
+		if err := i.installCompletions(tool); err != nil {
+			return fmt.Errorf("failed to install %s completions: %w", tool.Name, err)
+		}
+	}
+
+	orphaned := make(map[string]bool)
+	for _, tool := range catalog {
+		if !keep[tool.Name] {
+			orphaned[tool.Name] = true
+		}
+	}
+	if len(orphaned) == 0 {
+		return nil
+	}
+
+	home, err := i.platform().HomeDir()
+	if err != nil {
+		return err
+	}
+	shells, err := i.targetShells()
+	if err != nil {
+		return err
+	}
+	for _, sh := range shells {
+		if err := i.removeOrphanedIntegrations(home, completionShell(sh), orphaned); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeOrphanedIntegrations deletes the rc blocks, shell config files and
+// completion scripts that one shell has for the orphaned tools
+func (i *Installer) removeOrphanedIntegrations(home, shellName string, orphaned map[string]bool) error {
+	i.ensureRCWriter()
+
+	if rc := rcFileFor(home, shellName); rc != "" {
+		blocks, err := shell.ListManagedBlocks(rc)
+		if err != nil {
+			return err
+		}
+		for _, block := range blocks {
+			if block.Legacy || !orphaned[strings.TrimSuffix(block.Key, "-completion")] {
+				continue
+			}
+			change, err := i.RCWriter.RemoveBlock(rc, block.Key)
+			if err != nil {
+				return fmt.Errorf("failed to update %s: %w", filepath.Base(rc), err)
+			}
+			if change != nil && i.RCWriter.DryRun && !i.RCWriter.Buffered {
+				i.Logger.Info("Dry run: not writing changes to %s", rc)
+			}
+		}
+	}
+
+	for name := range orphaned {
+		configFile, err := ShellConfigPath(home, shellName, name)
+		if err != nil {
+			return err
+		}
+		completionFile, err := CompletionPath(home, shellName, name)
+		if err != nil {
+			return err
+		}
+		integrationFile, err := IntegrationPath(home, shellName, name)
+		if err != nil {
+			return err
+		}
+		for _, path := range []string{configFile, completionFile, integrationFile} {
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			if i.RCWriter.DryRun {
+				i.Logger.Info("Dry run: not removing %s", path)
+				continue
+			}
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+			i.Logger.Info("Removed %s integration file %s", name, path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package install
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+)
+
+func TestConfigureInstalledTools(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	fzf := &interfaces.Tool{Name: "fzf"}
+	fzf.ShellConfig.Aliases = map[string]string{"fzfh": "history | fzf"}
+	bat := &interfaces.Tool{Name: "bat"}
+	bat.ShellConfig.Aliases = map[string]string{"cat": "bat"}
+	catalog := []*interfaces.Tool{fzf, bat}
+
+	// A hand-tuned base block that reconciling must not touch
+	zshrc := filepath.Join(home, ".zshrc")
+	base := "# my settings\n" + shell.UpsertBlock("", "zsh", "setopt autocd")
+	if err := os.WriteFile(zshrc, []byte(base), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	installer := &Installer{
+		Logger:   log.New(log.InfoLevel),
+		Shells:   []string{"zsh"},
+		RCWriter: &shell.RCWriter{},
+	}
+	if err := installer.ConfigureInstalledTools(catalog, catalog); err != nil {
+		t.Fatalf("ConfigureInstalledTools() error = %v", err)
+	}
+	data, _ := os.ReadFile(zshrc)
+	if !shell.HasBlock(string(data), "fzf") || !shell.HasBlock(string(data), "bat") {
+		t.Fatalf("Expected blocks for both tools, got:\n%s", data)
+	}
+
+	// Deselect bat
+	if err := installer.ConfigureInstalledTools([]*interfaces.Tool{fzf}, catalog); err != nil {
+		t.Fatalf("ConfigureInstalledTools() error = %v", err)
+	}
+	data, _ = os.ReadFile(zshrc)
+	content := string(data)
+	if shell.HasBlock(content, "bat") {
+		t.Errorf("Expected the bat block to be removed, got:\n%s", content)
+	}
+	if !shell.HasBlock(content, "fzf") {
+		t.Errorf("Expected the fzf block to be kept, got:\n%s", content)
+	}
+	if !strings.HasPrefix(content, base) {
+		t.Errorf("Expected the base shell block to be untouched, got:\n%s", content)
+	}
+
+	batConfig, _ := ShellConfigPath(home, "zsh", "bat")
+	if _, err := os.Stat(batConfig); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed", batConfig)
+	}
+	fzfConfig, _ := ShellConfigPath(home, "zsh", "fzf")
+	if _, err := os.Stat(fzfConfig); err != nil {
+		t.Errorf("Expected %s to be kept: %v", fzfConfig, err)
+	}
+}
@@ -0,0 +1,202 @@
+package install
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+)
+
+// Statuses of a tool in a Report
+const (
+	StatusSuccess = "success"
+	// StatusSkipped means the tool was not attempted because an earlier tool failed
+	StatusSkipped = "skipped"
+	// StatusAlreadyInstalled means the installed snapshot records the tool and
+	// its package is still installed, so it was not installed again
+	StatusAlreadyInstalled = "already_installed"
+	StatusError            = "error"
+)
+
+// ToolReport is the outcome of installing one tool
+type ToolReport struct {
+	Name string `json:"name"`
+	// Package is the package name resolved for the package manager
+	Package        string `json:"package"`
+	PackageManager string `json:"package_manager"`
+	Status         string `json:"status"`
+	// DurationMS is how long the tool took to install, in milliseconds
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+	// RequiredBy is the tool that pulled this one in, when it was not selected
+	RequiredBy string `json:"required_by,omitempty"`
+}
+
+// Report is the machine-readable outcome of installing a set of tools, one
+// entry per tool in install order
+type Report struct {
+	Tools []*ToolReport `json:"tools"`
+	// Error is why the run stopped, including failures outside any one tool
+	Error string `json:"error,omitempty"`
+}
+
+// Failed returns the names of the tools that failed
+func (r *Report) Failed() []string {
+	var failed []string
+	for _, t := range r.Tools {
+		if t.Status == StatusError {
+			failed = append(failed, t.Name)
+		}
+	}
+	return failed
+}
+
+// WriteJSON writes the report to w as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode install report: %w", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write install report: %w", err)
+	}
+	return nil
+}
+
+// recordedTool reports whether the installed snapshot records tool, under its
+// name or one of its aliases
+func recordedTool(recorded *manifest.Installed, tool *interfaces.Tool) bool {
+	for name := range recorded.Tools {
+		if tool.Matches(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// InstallToolsWithReport installs opts.Tools like CoreTools and reports how each
+// one went. Installation stops at the first failure; the tools after it are
+// reported as skipped. The returned error is the one CoreTools would return;
+// the report is returned either way.
+func InstallToolsWithReport(opts *Options) (*Report, error) {
+	if opts == nil {
+		return nil, fmt.Errorf("options are nil")
+	}
+
+	installer := &Installer{
+		PackageManager: opts.PackageManager,
+		Logger:         opts.Logger,
+		Shells:         opts.Shells,
+		StatePath:      opts.StatePath,
+		// Collect every tool's rc additions and write them together at the end
+		RCWriter: shell.NewBufferedRCWriter(),
+	}
+	manager := ""
+	if opts.PackageManager != nil {
+		manager = opts.PackageManager.GetName()
+	}
+	recorded := manifest.NewInstalled()
+	if opts.StatePath != "" && !opts.Reinstall {
+		s, err := manifest.LoadInstalled(opts.StatePath)
+		if err != nil {
+			installer.Logger.Warn("Failed to read installed snapshot: %v", err)
+		} else {
+			recorded = s
+		}
+	}
+
+	report := &Report{}
+	fail := func(err error) (*Report, error) {
+		report.Error = err.Error()
+		return report, err
+	}
+
+	tools, requiredBy, err := OrderByRequires(opts.Tools, opts.Catalog)
+	if err != nil {
+		return fail(err)
+	}
+
+	var installErr error
+	for _, tool := range tools {
+		entry := &ToolReport{
+			Name:           tool.Name,
+			Package:        installer.getSystemPackageName(tool),
+			PackageManager: manager,
+			Status:         StatusSkipped,
+			RequiredBy:     requiredBy[tool.Name],
+		}
+		if entry.RequiredBy != "" {
+			installer.Logger.Info("Adding %s (required by %s)", tool.Name, entry.RequiredBy)
+		}
+		report.Tools = append(report.Tools, entry)
+		if installErr != nil {
+			continue
+		}
+		if recordedTool(recorded, tool) {
+			if present, err := opts.PackageManager.IsInstalled(entry.Package); err == nil && present {
+				installer.Logger.Info("%s is already installed, skipping", tool.Name)
+				entry.Status = StatusAlreadyInstalled
+				continue
+			}
+		}
+
+		start := time.Now()
+		err := installer.Install(tool)
+		entry.DurationMS = time.Since(start).Milliseconds()
+		if err != nil {
+			entry.Status, entry.Error = StatusError, err.Error()
+			installErr = fmt.Errorf("failed to install %s: %v", tool.Name, err)
+			continue
+		}
+		entry.Status = StatusSuccess
+	}
+
+	if installErr == nil && len(opts.Languages) > 0 {
+		runtimes := NewRuntimeInstaller(opts.PackageManager, installer.Logger)
+		runtimes.SetRCWriter(installer.RCWriter)
+		for _, lang := range opts.Languages {
+			if err := runtimes.InstallVersion(lang.Name, lang.ResolveStrategy(opts.LanguageStrategy), lang.Version); err != nil {
+				installErr = fmt.Errorf("failed to install %s: %v", lang.Name, err)
+				break
+			}
+		}
+	}
+
+	// A re-run also refreshes the tools skipped as installed and drops the
+	// integrations of the ones no longer selected
+	if opts.Reconcile && installErr == nil {
+		if err := installer.ConfigureInstalledTools(tools, opts.Catalog); err != nil {
+			installErr = err
+		}
+	}
+
+	// Keep the rc configuration of the tools installed before any failure
+	if err := installer.FinishInstallation(); err != nil {
+		if installErr != nil {
+			installer.Logger.Warn("%v", err)
+		} else {
+			return fail(err)
+		}
+	}
+	if installErr != nil {
+		return fail(installErr)
+	}
+
+	if !opts.SkipVerification {
+		for n, entry := range report.Tools {
+			tool := tools[n]
+			if tool.VerifyCommand == "" {
+				continue
+			}
+			if err := installer.verifyInstallation(tool); err != nil {
+				entry.Status, entry.Error = StatusError, fmt.Sprintf("verification failed: %v", err)
+				return fail(fmt.Errorf("verification failed for %s: %v", tool.Name, err))
+			}
+		}
+	}
+	return report, nil
+}
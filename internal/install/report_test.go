@@ -0,0 +1,186 @@
+package install
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/install/installtest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+)
+
+func TestInstallToolsWithReport(t *testing.T) {
+	pm := installtest.NewPackageManager("apt")
+	pm.Fail["fd-find"] = errors.New("unable to locate package")
+
+	fd := &interfaces.Tool{Name: "fd"}
+	fd.PackageNames.APT = "fd-find"
+	opts := &Options{
+		Logger:           log.New(log.ErrorLevel),
+		PackageManager:   pm,
+		Tools:            []*interfaces.Tool{ripgrepTool(""), fd, {Name: "bat"}},
+		SkipVerification: true,
+	}
+
+	report, err := InstallToolsWithReport(opts)
+	if err == nil {
+		t.Fatal("Expected the fd failure to be returned")
+	}
+	want := []struct{ name, pkg, status string }{
+		{"ripgrep", "ripgrep-apt", StatusSuccess},
+		{"fd", "fd-find", StatusError},
+		{"bat", "bat", StatusSkipped},
+	}
+	if len(report.Tools) != len(want) {
+		t.Fatalf("Expected %d tools in the report, got %d", len(want), len(report.Tools))
+	}
+	for n, w := range want {
+		got := report.Tools[n]
+		if got.Name != w.name || got.Package != w.pkg || got.Status != w.status || got.PackageManager != "apt" {
+			t.Errorf("tool %d = %+v, want %s/%s %s", n, got, w.name, w.pkg, w.status)
+		}
+	}
+	if report.Tools[1].Error == "" || report.Error == "" {
+		t.Errorf("Expected the failure to be recorded, got %+v", report)
+	}
+	if failed := report.Failed(); len(failed) != 1 || failed[0] != "fd" {
+		t.Errorf("Failed() = %v, want [fd]", failed)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	var decoded struct {
+		Tools []map[string]interface{} `json:"tools"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Expected valid JSON: %v", err)
+	}
+	for _, key := range []string{"name", "package", "package_manager", "status", "duration_ms", "error"} {
+		if _, ok := decoded.Tools[1][key]; !ok {
+			t.Errorf("Expected %q in the JSON report", key)
+		}
+	}
+}
+
+func TestInstallToolsRecordsState(t *testing.T) {
+	pm := installtest.NewPackageManager("apt")
+	_ = pm.Install("git")
+	statePath := filepath.Join(t.TempDir(), "installed.json")
+	opts := &Options{
+		Logger:           log.New(log.ErrorLevel),
+		PackageManager:   pm,
+		Tools:            []*interfaces.Tool{{Name: "git"}, {Name: "bat"}},
+		SkipVerification: true,
+		StatePath:        statePath,
+	}
+	if _, err := InstallToolsWithReport(opts); err != nil {
+		t.Fatalf("InstallToolsWithReport() error = %v", err)
+	}
+
+	s, err := manifest.LoadInstalled(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if git, bat := s.Tools["git"], s.Tools["bat"]; !git.PreExisting || bat.PreExisting || bat.Manager != "apt" || bat.Package != "bat" {
+		t.Errorf("Expected git to be recorded as pre-existing and bat as installed with apt, got %+v", s.Tools)
+	}
+}
+
+func TestInstallToolsSkipsRecorded(t *testing.T) {
+	pm := installtest.NewPackageManager("apt")
+	statePath := filepath.Join(t.TempDir(), "installed.json")
+	opts := &Options{
+		Logger:           log.New(log.ErrorLevel),
+		PackageManager:   pm,
+		Tools:            []*interfaces.Tool{{Name: "git"}, {Name: "bat"}},
+		SkipVerification: true,
+		StatePath:        statePath,
+	}
+	if _, err := InstallToolsWithReport(opts); err != nil {
+		t.Fatalf("InstallToolsWithReport() error = %v", err)
+	}
+	// bat was removed by hand, so only it is installed again
+	_ = pm.Uninstall("bat")
+
+	report, err := InstallToolsWithReport(opts)
+	if err != nil {
+		t.Fatalf("InstallToolsWithReport() error = %v", err)
+	}
+	if git, bat := report.Tools[0], report.Tools[1]; git.Status != StatusAlreadyInstalled || bat.Status != StatusSuccess {
+		t.Errorf("Expected git to be skipped and bat reinstalled, got %+v, %+v", git, bat)
+	}
+	if installs := pm.Installs(); len(installs) != 3 {
+		t.Errorf("Expected git once and bat twice, got %v", installs)
+	}
+
+	opts.Reinstall = true
+	if report, _ := InstallToolsWithReport(opts); report.Tools[0].Status != StatusSuccess {
+		t.Errorf("Expected --reinstall to install git again, got %+v", report.Tools[0])
+	}
+}
+
+func TestInstallToolsReconcilesIntegrations(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	fzf := &interfaces.Tool{Name: "fzf"}
+	fzf.ShellConfig.Aliases = map[string]string{"fzfh": "history | fzf"}
+	bat := &interfaces.Tool{Name: "bat"}
+	bat.ShellConfig.Aliases = map[string]string{"cat": "bat"}
+	opts := &Options{
+		Logger:           log.New(log.ErrorLevel),
+		PackageManager:   installtest.NewPackageManager("apt"),
+		Tools:            []*interfaces.Tool{fzf, bat},
+		SkipVerification: true,
+		Shells:           []string{"zsh"},
+		StatePath:        filepath.Join(home, "installed.json"),
+		Catalog:          []*interfaces.Tool{fzf, bat},
+		Reconcile:        true,
+	}
+	if _, err := InstallToolsWithReport(opts); err != nil {
+		t.Fatalf("InstallToolsWithReport() error = %v", err)
+	}
+
+	// The re-run without bat skips fzf as installed and drops bat's block
+	opts.Tools = []*interfaces.Tool{fzf}
+	report, err := InstallToolsWithReport(opts)
+	if err != nil {
+		t.Fatalf("InstallToolsWithReport() error = %v", err)
+	}
+	if report.Tools[0].Status != StatusAlreadyInstalled {
+		t.Errorf("Expected fzf to be skipped as installed, got %+v", report.Tools[0])
+	}
+	data, _ := os.ReadFile(filepath.Join(home, ".zshrc"))
+	if shell.HasBlock(string(data), "bat") || !shell.HasBlock(string(data), "fzf") {
+		t.Errorf("Expected only the fzf block to be left, got:\n%s", data)
+	}
+}
+
+func TestInstallToolsWithReportInstallsLanguages(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	pm := installtest.NewPackageManager("apt")
+	opts := &Options{
+		Logger:           log.New(log.ErrorLevel),
+		PackageManager:   pm,
+		Tools:            []*interfaces.Tool{{Name: "fd"}},
+		SkipVerification: true,
+		Shells:           []string{"zsh"},
+		Languages:        []*interfaces.Language{{Name: "Go"}},
+		LanguageStrategy: interfaces.LanguageStrategySystem,
+	}
+	if _, err := InstallToolsWithReport(opts); err != nil {
+		t.Fatalf("InstallToolsWithReport() error = %v", err)
+	}
+	if got := strings.Join(pm.Installs(), " "); got != "fd golang-go" {
+		t.Errorf("Expected the tool, then the language's packages, got %q", got)
+	}
+}
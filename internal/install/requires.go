@@ -0,0 +1,52 @@
+package install
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/requires"
+)
+
+// OrderByRequires orders tools so the tools they require come first, adding the
+// required tools they do not include from catalog. The returned map names, for
+// each tool added, the tool that required it.
+func OrderByRequires(tools, catalog []*interfaces.Tool) ([]*interfaces.Tool, map[string]string, error) {
+	find := func(name string) *interfaces.Tool {
+		if tool := interfaces.FindTool(tools, name); tool != nil {
+			return tool
+		}
+		return interfaces.FindTool(catalog, name)
+	}
+	lookup := func(name string) ([]string, bool) {
+		tool := find(name)
+		if tool == nil {
+			return nil, false
+		}
+		reqs := make([]string, len(tool.Requires))
+		for i, req := range tool.Requires {
+			alternatives := requires.Alternatives(req)
+			for j, alt := range alternatives {
+				if other := find(alt); other != nil {
+					alternatives[j] = other.Name
+				}
+			}
+			reqs[i] = strings.Join(alternatives, requires.AlternativeSep)
+		}
+		return reqs, true
+	}
+
+	names := make([]string, len(tools))
+	for i, tool := range tools {
+		names[i] = tool.Name
+	}
+	res, err := requires.Resolve(names, lookup)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve tool requirements: %w", err)
+	}
+	ordered := make([]*interfaces.Tool, len(res.Order))
+	for i, name := range res.Order {
+		ordered[i] = find(name)
+	}
+	return ordered, res.RequiredBy, nil
+}
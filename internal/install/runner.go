@@ -0,0 +1,77 @@
+package install
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cache"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+)
+
+// CommandRunner runs the shell commands the installer needs (post-install,
+// verify and completion commands), so tests can substitute them
+type CommandRunner interface {
+	// Run runs command with sh -c, streaming its output to the terminal
+	Run(command string) error
+	// Output runs command with sh -c and returns its standard output
+	Output(command string) ([]byte, error)
+}
+
+// PlatformProvider reports the machine facts the installer configures against
+type PlatformProvider interface {
+	// HomeDir returns the directory rc and tool config files are written under
+	HomeDir() (string, error)
+	// Shell returns the user's current shell, as in $SHELL
+	Shell() string
+}
+
+// execRunner runs commands on the host
+type execRunner struct{}
+
+// Run implements CommandRunner
+func (execRunner) Run(command string) error {
+	if err := cache.CheckCommand(command); err != nil {
+		return err
+	}
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Output implements CommandRunner
+func (execRunner) Output(command string) ([]byte, error) {
+	if err := cache.CheckCommand(command); err != nil {
+		return nil, err
+	}
+	return exec.Command("sh", "-c", command).Output()
+}
+
+// hostPlatform reads the platform from the running system
+type hostPlatform struct{}
+
+// HomeDir implements PlatformProvider
+func (hostPlatform) HomeDir() (string, error) {
+	return system.UserHome()
+}
+
+// Shell implements PlatformProvider
+func (hostPlatform) Shell() string {
+	return os.Getenv("SHELL")
+}
+
+// runner returns the installer's command runner, defaulting to the host
+func (i *Installer) runner() CommandRunner {
+	if i.Runner == nil {
+		return execRunner{}
+	}
+	return i.Runner
+}
+
+// platform returns the installer's platform provider, defaulting to the host
+func (i *Installer) platform() PlatformProvider {
+	if i.Platform == nil {
+		return hostPlatform{}
+	}
+	return i.Platform
+}
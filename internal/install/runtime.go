@@ -5,15 +5,19 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cache"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
 )
 
 // configureNeedrestart sets needrestart mode (can be 'a' for automatic or 'i' for interactive)
 func configureNeedrestart(mode string) error {
-	cmd := exec.Command("sudo", "sed", "-i", 
+	cmd := system.PrivilegedCommand("sed", "-i",
 		fmt.Sprintf("s/^#\\$nrconf{restart} = 'i';/\\$nrconf{restart} = '%s';/", mode),
 		"/etc/needrestart/needrestart.conf")
 	return cmd.Run()
@@ -21,20 +25,92 @@ func configureNeedrestart(mode string) error {
 
 // RuntimeInstaller handles language runtime installation
 type RuntimeInstaller struct {
-	pm     interfaces.PackageManager
-	logger *log.Logger
+	pm       interfaces.PackageManager
+	logger   *log.Logger
+	rcWriter *shell.RCWriter
+	// sources override where version managers are fetched from
+	sources map[string]interfaces.VersionManagerSource
+	// downloads fetches install scripts; scripts are not cached so upstream
+	// fixes are picked up
+	downloads *cache.Cache
+	// versionManager and versionManagerOrder select an existing version
+	// manager to install through (see system.DetectVersionManager)
+	versionManager      string
+	versionManagerOrder []string
 }
 
 // NewRuntimeInstaller creates a new runtime installer
 func NewRuntimeInstaller(pm interfaces.PackageManager, logger *log.Logger) *RuntimeInstaller {
+	downloads := cache.New("")
+	downloads.SetEnabled(false)
 	return &RuntimeInstaller{
-		pm:     pm,
-		logger: logger,
+		pm:        pm,
+		logger:    logger,
+		rcWriter:  shell.NewRCWriter(),
+		downloads: downloads,
 	}
 }
 
-// Install installs a language runtime
+// SetSources overrides where nvm, pyenv, goenv and rustup are fetched from,
+// typically settings.yaml's version_managers
+func (r *RuntimeInstaller) SetSources(sources map[string]interfaces.VersionManagerSource) error {
+	if err := ValidateSources(sources); err != nil {
+		return err
+	}
+	r.sources = sources
+	return nil
+}
+
+// SetVersionManager forces the existing version manager runtimes are installed
+// with, or disables detection with system.VersionManagerNone; order is the
+// preference when detecting one
+func (r *RuntimeInstaller) SetVersionManager(name string, order []string) error {
+	if err := system.ValidVersionManager(name); err != nil {
+		return err
+	}
+	r.versionManager, r.versionManagerOrder = name, order
+	return nil
+}
+
+// systemRuntimePackages are the distro packages installed by the system strategy
+var systemRuntimePackages = map[string]map[string][]string{
+	"Node.js": {"apt": {"nodejs", "npm"}, "dnf": {"nodejs", "npm"}, "pacman": {"nodejs", "npm"}, "zypper": {"nodejs", "npm"}, "brew": {"node"}},
+	"Python":  {"apt": {"python3", "python3-pip", "python3-venv"}, "dnf": {"python3", "python3-pip"}, "pacman": {"python", "python-pip"}, "zypper": {"python3", "python3-pip"}, "brew": {"python"}},
+	"Go":      {"apt": {"golang-go"}, "dnf": {"golang"}, "pacman": {"go"}, "zypper": {"go"}, "brew": {"go"}},
+	"Rust":    {"apt": {"rustc", "cargo"}, "dnf": {"rust", "cargo"}, "pacman": {"rust"}, "zypper": {"rust", "cargo"}, "brew": {"rust"}},
+}
+
+// Install installs a language runtime through its version manager
 func (r *RuntimeInstaller) Install(runtime string) error {
+	return r.InstallWithStrategy(runtime, interfaces.LanguageStrategyVersionManager)
+}
+
+// InstallWithStrategy installs a language runtime using the given strategy. The system
+// strategy installs distro packages and leaves shell rc files untouched.
+func (r *RuntimeInstaller) InstallWithStrategy(runtime, strategy string) error {
+	return r.InstallVersion(runtime, strategy, "")
+}
+
+// InstallVersion installs version of a language runtime (e.g. 20 or 3.11.4;
+// "" or "latest" for the newest release) using the given strategy. System
+// packages provide whatever version the distro ships.
+func (r *RuntimeInstaller) InstallVersion(runtime, strategy, version string) error {
+	if version == "latest" {
+		version = ""
+	}
+	if version != "" && !runtimeVersionPattern.MatchString(version) {
+		return fmt.Errorf("invalid %s version %q", runtime, version)
+	}
+	if strategy == interfaces.LanguageStrategySystem {
+		return r.installSystemRuntime(runtime)
+	}
+	if strategy != interfaces.LanguageStrategyVersionManager {
+		return fmt.Errorf("unknown install strategy: %s", strategy)
+	}
+	if vm := system.DetectVersionManager(runtime, r.versionManager, r.versionManagerOrder); vm != nil {
+		return r.installWithExisting(runtime, version, vm)
+	}
+
 	// Configure needrestart to automatic mode
 	if err := configureNeedrestart("a"); err != nil {
 		r.logger.Warn("Failed to configure needrestart: %v", err)
@@ -47,50 +123,105 @@ func (r *RuntimeInstaller) Install(runtime string) error {
 		}
 	}()
 
+	var err error
 	switch runtime {
 	case "Node.js":
-		return r.installNVM()
+		err = r.installNVM()
 	case "Python":
-		return r.installPyenv()
+		err = r.installPyenv()
 	case "Go":
-		return r.installGoenv()
+		err = r.installGoenv()
 	case "Rust":
-		return r.installRustup()
+		err = r.installRustup()
 	default:
 		return fmt.Errorf("unknown runtime: %s", runtime)
 	}
+	if err != nil {
+		return err
+	}
+	return r.installRuntimeVersion(runtime, version)
+}
+
+// runtimeVersionPattern is what a version may look like, as it is passed to the
+// version manager through the shell
+var runtimeVersionPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// freshVersionCommands install a runtime version, as %[1]s, with the version
+// manager just set up. It is not on PATH until a new shell starts, so the
+// commands use its install location.
+var freshVersionCommands = map[string]string{
+	"Node.js": `. "$HOME/.nvm/nvm.sh" && nvm install %[1]s && nvm alias default %[1]s`,
+	"Python":  `"$HOME/.pyenv/bin/pyenv" install --skip-existing %[1]s && "$HOME/.pyenv/bin/pyenv" global %[1]s`,
+	"Go":      `"$HOME/.goenv/bin/goenv" install --skip-existing %[1]s && "$HOME/.goenv/bin/goenv" global %[1]s`,
+	"Rust":    `"$HOME/.cargo/bin/rustup" default %[1]s`,
+}
+
+// installRuntimeVersion installs version of runtime with the version manager
+// just set up. Without a version nvm installs the newest Node.js and rustup
+// keeps the stable toolchain it installed; pyenv and goenv install nothing.
+func (r *RuntimeInstaller) installRuntimeVersion(runtime, version string) error {
+	if version == "" {
+		switch runtime {
+		case "Node.js":
+			version = "node"
+		default:
+			return nil
+		}
+	}
+	cmdStr := fmt.Sprintf(freshVersionCommands[runtime], version)
+	r.logger.Info("Installing %s %s...", runtime, version)
+	if output, err := exec.Command("bash", "-c", cmdStr).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to install %s %s: %w: %s", runtime, version, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// installWithExisting installs version of the runtime through a version manager
+// the user already has, instead of setting up nvm, pyenv, goenv or rustup next to it
+func (r *RuntimeInstaller) installWithExisting(runtime, version string, vm *system.ExistingVersionManager) error {
+	cmdStr, err := vm.InstallCommand(runtime, version)
+	if err != nil {
+		return err
+	}
+	r.logger.Info("Installing %s with the existing %s...", runtime, vm.Name)
+	if output, err := exec.Command("sh", "-c", cmdStr).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to install %s with %s: %w: %s", runtime, vm.Name, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func (r *RuntimeInstaller) installSystemRuntime(runtime string) error {
+	packages, ok := systemRuntimePackages[runtime][r.pm.GetName()]
+	if !ok {
+		return fmt.Errorf("no system packages for %s with %s", runtime, r.pm.GetName())
+	}
+	r.logger.Info("Installing %s from system packages (%s)...", runtime, strings.Join(packages, ", "))
+	if err := r.pm.Install(strings.Join(packages, " ")); err != nil {
+		return fmt.Errorf("failed to install %s: %w", runtime, err)
+	}
+	return nil
 }
 
 func (r *RuntimeInstaller) installNVM() error {
 	r.logger.Info("Installing NVM (Node Version Manager)...")
 	
-	// Download and run the NVM install script
-	cmd := exec.Command("bash", "-c", `curl -o- https://raw.githubusercontent.com/nvm-sh/nvm/v0.39.0/install.sh | bash`)
-	if err := cmd.Run(); err != nil {
+	// Download, verify and run the NVM install script
+	if err := r.runScript("nvm", "bash"); err != nil {
 		return fmt.Errorf("failed to install NVM: %w", err)
 	}
 
 	// Add NVM to shell configuration
-	homeDir, err := os.UserHomeDir()
+	homeDir, err := system.UserHome()
 	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
+		return err
 	}
 
-	nvmInit := `
-export NVM_DIR="$HOME/.nvm"
+	nvmInit := `export NVM_DIR="$HOME/.nvm"
 [ -s "$NVM_DIR/nvm.sh" ] && \. "$NVM_DIR/nvm.sh"  # This loads nvm
 [ -s "$NVM_DIR/bash_completion" ] && \. "$NVM_DIR/bash_completion"  # This loads nvm bash_completion
 `
 
-	// Append to .bashrc and .zshrc if they exist
-	for _, rc := range []string{".bashrc", ".zshrc"} {
-		rcPath := filepath.Join(homeDir, rc)
-		if _, err := os.Stat(rcPath); err == nil {
-			if err := appendToFile(rcPath, nvmInit); err != nil {
-				r.logger.Warn("Failed to update %s: %v", rc, err)
-			}
-		}
-	}
+	r.configureRcFiles(homeDir, "nvm", nvmInit)
 
 	return nil
 }
@@ -112,31 +243,23 @@ func (r *RuntimeInstaller) installPyenv() error {
 	}
 
 	// Clone pyenv
-	homeDir, err := os.UserHomeDir()
+	homeDir, err := system.UserHome()
 	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
+		return err
 	}
 
 	pyenvPath := filepath.Join(homeDir, ".pyenv")
-	if err := exec.Command("git", "clone", "https://github.com/pyenv/pyenv.git", pyenvPath).Run(); err != nil {
-		return fmt.Errorf("failed to clone pyenv: %w", err)
+	if err := r.cloneRepo("pyenv", pyenvPath); err != nil {
+		return err
 	}
 
 	// Add pyenv to shell configuration
-	pyenvInit := `
-export PYENV_ROOT="$HOME/.pyenv"
+	pyenvInit := `export PYENV_ROOT="$HOME/.pyenv"
 command -v pyenv >/dev/null || export PATH="$PYENV_ROOT/bin:$PATH"
 eval "$(pyenv init -)"
 `
 
-	for _, rc := range []string{".bashrc", ".zshrc"} {
-		rcPath := filepath.Join(homeDir, rc)
-		if _, err := os.Stat(rcPath); err == nil {
-			if err := appendToFile(rcPath, pyenvInit); err != nil {
-				r.logger.Warn("Failed to update %s: %v", rc, err)
-			}
-		}
-	}
+	r.configureRcFiles(homeDir, "pyenv", pyenvInit)
 
 	return nil
 }
@@ -145,31 +268,23 @@ func (r *RuntimeInstaller) installGoenv() error {
 	r.logger.Info("Installing goenv...")
 
 	// Clone goenv
-	homeDir, err := os.UserHomeDir()
+	homeDir, err := system.UserHome()
 	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
+		return err
 	}
 
 	goenvPath := filepath.Join(homeDir, ".goenv")
-	if err := exec.Command("git", "clone", "https://github.com/syndbg/goenv.git", goenvPath).Run(); err != nil {
-		return fmt.Errorf("failed to clone goenv: %w", err)
+	if err := r.cloneRepo("goenv", goenvPath); err != nil {
+		return err
 	}
 
 	// Add goenv to shell configuration
-	goenvInit := `
-export GOENV_ROOT="$HOME/.goenv"
+	goenvInit := `export GOENV_ROOT="$HOME/.goenv"
 export PATH="$GOENV_ROOT/bin:$PATH"
 eval "$(goenv init -)"
 `
 
-	for _, rc := range []string{".bashrc", ".zshrc"} {
-		rcPath := filepath.Join(homeDir, rc)
-		if _, err := os.Stat(rcPath); err == nil {
-			if err := appendToFile(rcPath, goenvInit); err != nil {
-				r.logger.Warn("Failed to update %s: %v", rc, err)
-			}
-		}
-	}
+	r.configureRcFiles(homeDir, "goenv", goenvInit)
 
 	return nil
 }
@@ -177,53 +292,47 @@ eval "$(goenv init -)"
 func (r *RuntimeInstaller) installRustup() error {
 	r.logger.Info("Installing Rustup...")
 
-	// Download and run the rustup install script
-	cmd := exec.Command("bash", "-c", `curl --proto '=https' --tlsv1.2 -sSf https://sh.rustup.rs | sh -s -- -y`)
-	if err := cmd.Run(); err != nil {
+	// Download, verify and run the rustup install script
+	if err := r.runScript("rustup", "sh", "-y"); err != nil {
 		return fmt.Errorf("failed to install Rustup: %w", err)
 	}
 
 	// Add Cargo to shell configuration
-	homeDir, err := os.UserHomeDir()
+	homeDir, err := system.UserHome()
 	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
+		return err
 	}
 
-	cargoInit := `
-export PATH="$HOME/.cargo/bin:$PATH"
-. "$HOME/.cargo/env"
-`
+	cargoInit := `export PATH="$HOME/.cargo/bin:$PATH"
+. "$HOME/.cargo/env"`
 
-	for _, rc := range []string{".bashrc", ".zshrc"} {
-		rcPath := filepath.Join(homeDir, rc)
-		if _, err := os.Stat(rcPath); err == nil {
-			if err := appendToFile(rcPath, cargoInit); err != nil {
-				r.logger.Warn("Failed to update %s: %v", rc, err)
-			}
-		}
-	}
+	r.configureRcFiles(homeDir, "rust", cargoInit)
 
 	return nil
 }
 
-func appendToFile(path, content string) error {
-	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	// Check if content already exists
-	existing, err := os.ReadFile(path)
-	if err != nil {
-		return err
-	}
-
-	if !strings.Contains(string(existing), content) {
-		if _, err := f.WriteString(content); err != nil {
-			return err
+// configureRcFiles sets the managed block called name in .bashrc and .zshrc, where
+// they exist, or previews the change in dry-run mode
+func (r *RuntimeInstaller) configureRcFiles(homeDir, name, body string) {
+	for _, rc := range []string{".bashrc", ".zshrc"} {
+		rcPath := filepath.Join(homeDir, rc)
+		if _, err := os.Stat(rcPath); err != nil {
+			continue
+		}
+		if _, err := r.rcWriter.UpsertBlock(rcPath, name, body); err != nil {
+			r.logger.Warn("Failed to update %s: %v", rc, err)
 		}
 	}
+}
 
-	return nil
-} 
\ No newline at end of file
+// SetRCWriter makes the runtime installer edit rc files through w, typically a
+// tool Installer's buffered RCWriter so FinishInstallation writes languages'
+// and tools' additions together
+func (r *RuntimeInstaller) SetRCWriter(w *shell.RCWriter) {
+	r.rcWriter = w
+}
+
+// RCChanges returns the rc file edits planned or applied so far
+func (r *RuntimeInstaller) RCChanges() []shell.RCChange {
+	return r.rcWriter.Changes()
+}
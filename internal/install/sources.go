@@ -0,0 +1,159 @@
+package install
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cache"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+// refPlaceholder is replaced with the source's ref in script URLs
+const refPlaceholder = "{ref}"
+
+// versionManagerSource describes how a version manager is fetched
+type versionManagerSource struct {
+	// script sources are downloaded and run; the others are git repositories
+	script   bool
+	defaults interfaces.VersionManagerSource
+}
+
+// versionManagerSources are the upstream sources by manager name, used for the
+// fields settings.yaml's version_managers leaves empty
+var versionManagerSources = map[string]versionManagerSource{
+	"nvm":    {script: true, defaults: interfaces.VersionManagerSource{URL: "https://raw.githubusercontent.com/nvm-sh/nvm/{ref}/install.sh", Ref: "v0.39.0"}},
+	"pyenv":  {defaults: interfaces.VersionManagerSource{URL: "https://github.com/pyenv/pyenv.git"}},
+	"goenv":  {defaults: interfaces.VersionManagerSource{URL: "https://github.com/syndbg/goenv.git"}},
+	"rustup": {script: true, defaults: interfaces.VersionManagerSource{URL: "https://sh.rustup.rs"}},
+}
+
+// ValidateSources checks configured version manager sources. Install scripts
+// must come over https since they are executed; repositories may also use ssh
+// or git. Refs only apply to repositories and to script URLs with a {ref}.
+func ValidateSources(sources map[string]interfaces.VersionManagerSource) error {
+	for name, src := range sources {
+		known, ok := versionManagerSources[name]
+		if !ok {
+			return fmt.Errorf("unknown version manager %q in version_managers", name)
+		}
+		if src.SHA256 != "" && !known.script {
+			return fmt.Errorf("version manager %s: sha256 only applies to install scripts; pin a ref instead", name)
+		}
+		if src.SHA256 != "" && !isHexSHA256(src.SHA256) {
+			return fmt.Errorf("version manager %s: sha256 must be 64 hex characters", name)
+		}
+		if src.URL == "" {
+			continue
+		}
+		u, err := url.Parse(src.URL)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("version manager %s: invalid URL %q", name, src.URL)
+		}
+		schemes := []string{"https"}
+		if !known.script {
+			schemes = append(schemes, "ssh", "git")
+		}
+		if !containsString(schemes, u.Scheme) {
+			return fmt.Errorf("version manager %s: URL %q must use %s", name, src.URL, strings.Join(schemes, " or "))
+		}
+		if known.script && src.Ref != "" && !strings.Contains(src.URL, refPlaceholder) {
+			return fmt.Errorf("version manager %s: ref %q needs a %s placeholder in the URL", name, src.Ref, refPlaceholder)
+		}
+	}
+	return nil
+}
+
+// resolveSource returns the source for a manager, filling unset fields from the
+// upstream default. A configured URL drops the default ref, which belongs to
+// the default URL.
+func (r *RuntimeInstaller) resolveSource(name string) interfaces.VersionManagerSource {
+	return resolveSource(r.sources, name)
+}
+
+// ScriptSource returns the install script a version manager is set up with,
+// given the version_managers sources from settings.yaml. It returns false for
+// managers cloned from a repository instead.
+func ScriptSource(name string, sources map[string]interfaces.VersionManagerSource) (interfaces.VersionManagerSource, bool) {
+	if !versionManagerSources[name].script {
+		return interfaces.VersionManagerSource{}, false
+	}
+	return resolveSource(sources, name), true
+}
+
+func resolveSource(sources map[string]interfaces.VersionManagerSource, name string) interfaces.VersionManagerSource {
+	src := sources[name]
+	defaults := versionManagerSources[name].defaults
+	if src.URL == "" {
+		src.URL = defaults.URL
+		if src.Ref == "" {
+			src.Ref = defaults.Ref
+		}
+	}
+	src.URL = strings.ReplaceAll(src.URL, refPlaceholder, src.Ref)
+	return src
+}
+
+// runScript downloads the install script for name, verifies it against the
+// configured checksum, and runs it with interpreter and args
+func (r *RuntimeInstaller) runScript(name, interpreter string, args ...string) error {
+	src := r.resolveSource(name)
+	script, cleanup, err := r.downloads.FetchScript(src.URL, src.Ref, src.SHA256)
+	if err != nil {
+		return fmt.Errorf("failed to download %s install script from %s: %w", name, src.URL, err)
+	}
+	defer cleanup()
+	cmd := exec.Command(interpreter, append([]string{script}, args...)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s install script failed: %w: %s", name, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// cloneRepo clones the repository for name into dest and checks out the pinned
+// ref. GitHub URLs are tried through the configured mirror first.
+func (r *RuntimeInstaller) cloneRepo(name, dest string) error {
+	src := r.resolveSource(name)
+	var err error
+	for _, repo := range cache.MirrorURLs(src.URL) {
+		if err = cache.CheckCommand(repo); err != nil {
+			continue
+		}
+		if err = exec.Command("git", "clone", repo, dest).Run(); err == nil {
+			break
+		}
+		os.RemoveAll(dest)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to clone %s from %s: %w", name, src.URL, err)
+	}
+	if src.Ref != "" {
+		if err := exec.Command("git", "-C", dest, "checkout", "--quiet", src.Ref).Run(); err != nil {
+			return fmt.Errorf("failed to check out %s %s: %w", name, src.Ref, err)
+		}
+	}
+	return nil
+}
+
+func isHexSHA256(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, c := range strings.ToLower(s) {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package install
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cache"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+)
+
+func TestValidateSources(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+	tests := []struct {
+		name    string
+		sources map[string]interfaces.VersionManagerSource
+		wantErr string
+	}{
+		{name: "empty"},
+		{name: "pinned nvm ref", sources: map[string]interfaces.VersionManagerSource{"nvm": {Ref: "v0.40.1", SHA256: sum}}},
+		{name: "nvm mirror", sources: map[string]interfaces.VersionManagerSource{"nvm": {URL: "https://mirror.example.com/nvm/{ref}/install.sh", Ref: "v0.40.1"}}},
+		{name: "pyenv over ssh", sources: map[string]interfaces.VersionManagerSource{"pyenv": {URL: "ssh://git@git.example.com/pyenv.git", Ref: "v2.4.0"}}},
+		{name: "unknown manager", sources: map[string]interfaces.VersionManagerSource{"sdkman": {}}, wantErr: "unknown version manager"},
+		{name: "script over http", sources: map[string]interfaces.VersionManagerSource{"rustup": {URL: "http://mirror.example.com/rustup.sh"}}, wantErr: "must use https"},
+		{name: "relative URL", sources: map[string]interfaces.VersionManagerSource{"goenv": {URL: "goenv.git"}}, wantErr: "invalid URL"},
+		{name: "bad checksum", sources: map[string]interfaces.VersionManagerSource{"nvm": {SHA256: "abc"}}, wantErr: "64 hex"},
+		{name: "checksum on a repository", sources: map[string]interfaces.VersionManagerSource{"pyenv": {SHA256: sum}}, wantErr: "only applies to install scripts"},
+		{name: "script ref without placeholder", sources: map[string]interfaces.VersionManagerSource{"rustup": {URL: "https://mirror.example.com/rustup.sh", Ref: "1.27"}}, wantErr: "placeholder"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSources(tt.sources)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateSources() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidateSources() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveSource(t *testing.T) {
+	r := NewRuntimeInstaller(nil, log.New(log.ErrorLevel))
+	if got := r.resolveSource("nvm").URL; got != "https://raw.githubusercontent.com/nvm-sh/nvm/v0.39.0/install.sh" {
+		t.Errorf("default nvm URL = %s", got)
+	}
+
+	if err := r.SetSources(map[string]interfaces.VersionManagerSource{
+		"nvm":   {Ref: "v0.40.1"},
+		"pyenv": {URL: "https://git.example.com/pyenv.git"},
+	}); err != nil {
+		t.Fatalf("SetSources() error = %v", err)
+	}
+	if got := r.resolveSource("nvm").URL; got != "https://raw.githubusercontent.com/nvm-sh/nvm/v0.40.1/install.sh" {
+		t.Errorf("pinned nvm URL = %s", got)
+	}
+	if got := r.resolveSource("pyenv"); got.URL != "https://git.example.com/pyenv.git" || got.Ref != "" {
+		t.Errorf("pyenv source = %+v", got)
+	}
+	if got := r.resolveSource("goenv").URL; got != "https://github.com/syndbg/goenv.git" {
+		t.Errorf("default goenv URL = %s", got)
+	}
+}
+
+func TestRunScriptVerifiesChecksum(t *testing.T) {
+	t.Setenv(cache.AllowHostsEnvVar, "127.0.0.1")
+	marker := filepath.Join(t.TempDir(), "ran")
+	script := fmt.Sprintf("echo \"$1\" > %s\n", marker)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, script)
+	}))
+	defer server.Close()
+
+	sum := sha256.Sum256([]byte(script))
+	r := NewRuntimeInstaller(nil, log.New(log.ErrorLevel))
+	// httptest serves plain http, which ValidateSources rejects, so set the sources directly
+	r.sources = map[string]interfaces.VersionManagerSource{"rustup": {URL: server.URL, SHA256: strings.Repeat("0", 64)}}
+	if err := r.runScript("rustup", "sh", "-y"); err == nil {
+		t.Fatal("runScript() with a mismatched checksum succeeded")
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatal("script ran despite the checksum mismatch")
+	}
+
+	r.sources["rustup"] = interfaces.VersionManagerSource{URL: server.URL, SHA256: hex.EncodeToString(sum[:])}
+	if err := r.runScript("rustup", "sh", "-y"); err != nil {
+		t.Fatalf("runScript() error = %v", err)
+	}
+	if data, err := os.ReadFile(marker); err != nil || strings.TrimSpace(string(data)) != "-y" {
+		t.Fatalf("script output = %q, %v; want -y", data, err)
+	}
+}
@@ -1,9 +1,9 @@
 package install
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -12,6 +12,9 @@ import (
 
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/packages/implementations"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
 )
 
 var (
@@ -124,6 +127,32 @@ type Installer struct {
 	MaxRetries int
 	// RetryDelay is the delay between retries
 	RetryDelay time.Duration
+	// RCWriter edits shell rc files, previewing the changes in dry-run mode
+	RCWriter *shell.RCWriter
+	// Shells are the shells to write tool configuration for; the first is the
+	// primary shell. Empty means the current $SHELL.
+	Shells []string
+	// Runner runs post-install, verify and completion commands (default: sh -c on the host)
+	Runner CommandRunner
+	// Platform supplies the home directory and current shell (default: the host)
+	Platform PlatformProvider
+	// StatePath is the installed snapshot (see manifest.Installed) that
+	// FinishInstallation records the installed tools in; empty records nothing
+	StatePath string
+
+	// installed are the tools installed since the last FinishInstallation
+	installed []installedTool
+	// manager names the package manager when PackageManager is nil (see
+	// InstallCompletions)
+	manager string
+}
+
+// installedTool is a tool Install installed and whether its package was
+// already installed beforehand
+type installedTool struct {
+	tool        *interfaces.Tool
+	pkg         string
+	preExisting bool
 }
 
 // NewInstaller creates a new installer with the given package manager
@@ -133,6 +162,7 @@ func NewInstaller(pm interfaces.PackageManager) *Installer {
 		Logger:        log.New(log.InfoLevel),
 		MaxRetries:    3,
 		RetryDelay:    time.Second * 2,
+		RCWriter:      shell.NewRCWriter(),
 	}
 }
 
@@ -142,18 +172,14 @@ func (i *Installer) getPackageWithVersion(pkg, version string) string {
 		return pkg
 	}
 	
-	switch i.PackageManager.GetName() {
-	case "apt":
-		return fmt.Sprintf("%s=%s", pkg, version)
-	case "dnf":
-		return fmt.Sprintf("%s-%s", pkg, version)
-	case "pacman":
-		return fmt.Sprintf("%s=%s", pkg, version)
-	case "brew":
-		return fmt.Sprintf("%s@%s", pkg, version)
-	default:
-		return pkg
+	commands, ok := i.PackageManager.(interfaces.PackageCommands)
+	if !ok {
+		var err error
+		if commands, err = implementations.Commands(i.PackageManager.GetName()); err != nil {
+			return pkg
+		}
 	}
+	return commands.VersionedPackage(pkg, version)
 }
 
 // getSystemPackageName returns the appropriate package name for the current system
@@ -161,31 +187,10 @@ func (i *Installer) getSystemPackageName(tool *interfaces.Tool) string {
 	if tool == nil {
 		return ""
 	}
-
-	// Try to get system-specific package name
-	if i.PackageManager != nil {
-		switch i.PackageManager.GetName() {
-		case "apt":
-			if tool.PackageNames.APT != "" {
-				return tool.PackageNames.APT
-			}
-		case "dnf":
-			if tool.PackageNames.DNF != "" {
-				return tool.PackageNames.DNF
-			}
-		case "pacman":
-			if tool.PackageNames.Pacman != "" {
-				return tool.PackageNames.Pacman
-			}
-		case "brew":
-			if tool.PackageNames.Brew != "" {
-				return tool.PackageNames.Brew
-			}
-		}
+	if i.PackageManager == nil {
+		return tool.Name
 	}
-
-	// Fall back to default package name
-	return tool.Name
+	return tool.PackageFor(i.PackageManager.GetName())
 }
 
 // Install installs a tool
@@ -202,6 +207,10 @@ func (i *Installer) Install(tool *interfaces.Tool) error {
 		return fmt.Errorf("no package name found for tool %s", tool.Name)
 	}
 
+	// A package that is already installed is never removed by uninstall
+	preExisting, _ := i.PackageManager.IsInstalled(pkgName)
+	installed := installedTool{tool: tool, pkg: pkgName, preExisting: preExisting}
+
 	// Add version if specified
 	pkgName = i.getPackageWithVersion(pkgName, tool.Version)
 
@@ -229,7 +238,10 @@ func (i *Installer) Install(tool *interfaces.Tool) error {
 
 	// Install the tool
 	err := i.PackageManager.Install(pkgName)
-	if err != nil {
+	if errors.Is(err, interfaces.ErrAlreadyInstalled) {
+		i.Logger.Info("%s is already installed, skipping", tool.Name)
+		installed.preExisting = true
+	} else if err != nil {
 		return fmt.Errorf("failed to install %s: %v", tool.Name, err)
 	}
 
@@ -264,6 +276,12 @@ func (i *Installer) Install(tool *interfaces.Tool) error {
 		return fmt.Errorf("failed to apply shell configuration: %v", err)
 	}
 
+	// Install shell completions
+	if err := i.installCompletions(tool); err != nil {
+		return fmt.Errorf("failed to install shell completions: %v", err)
+	}
+
+	i.installed = append(i.installed, installed)
 	i.Logger.Success("Successfully installed %s", tool.Name)
 	return nil
 }
@@ -275,30 +293,31 @@ type Options struct {
 	Tools            []*interfaces.Tool
 	SkipVerification bool
 	AdditionalPaths  []string
+	// Shells are the shells to configure tools for, primary first (default: $SHELL)
+	Shells []string
+	// StatePath is the installed snapshot to record the installed tools in; the
+	// tools it records that are still installed are skipped
+	StatePath string
+	// Reinstall installs every tool, even the ones StatePath records
+	Reinstall bool
+	// Catalog supplies the tools Tools require but do not include (default:
+	// required tools must be among Tools)
+	Catalog []*interfaces.Tool
+	// Reconcile is set when Tools is the whole selection: once they are
+	// installed, the integrations of all of them are refreshed and those of the
+	// Catalog tools not among them are removed (see ConfigureInstalledTools)
+	Reconcile bool
+	// Languages are installed with a RuntimeInstaller once Tools are, their rc
+	// additions written together with the tools' (see FinishInstallation)
+	Languages []*interfaces.Language
+	// LanguageStrategy is the install strategy of the Languages that set none
+	LanguageStrategy string
 }
 
 // CoreTools installs core tools
 func CoreTools(opts *Options) error {
-	if opts == nil {
-		return fmt.Errorf("options are nil")
-	}
-
-	installer := &Installer{
-		PackageManager: opts.PackageManager,
-		Logger:        opts.Logger,
-	}
-
-	for _, tool := range opts.Tools {
-		if err := installer.Install(tool); err != nil {
-			return fmt.Errorf("failed to install %s: %v", tool.Name, err)
-		}
-	}
-
-	if !opts.SkipVerification {
-		return VerifyCoreTools(opts)
-	}
-
-	return nil
+	_, err := InstallToolsWithReport(opts)
+	return err
 }
 
 // VerifyCoreTools verifies core tools are installed correctly
@@ -310,6 +329,7 @@ func VerifyCoreTools(opts *Options) error {
 	installer := &Installer{
 		PackageManager: opts.PackageManager,
 		Logger:        opts.Logger,
+		Shells:        opts.Shells,
 	}
 
 	for _, tool := range opts.Tools {
@@ -333,8 +353,7 @@ func (i *Installer) installWithRetry(pkg string) error {
 
 func (i *Installer) verifyInstallation(tool *interfaces.Tool) error {
 	return i.retryOperation(func() error {
-		cmd := exec.Command("sh", "-c", tool.VerifyCommand)
-		if err := cmd.Run(); err != nil {
+		if _, err := i.runner().Output(tool.VerifyCommand); err != nil {
 			return fmt.Errorf("verification command failed: %v", err)
 		}
 		return nil
@@ -359,10 +378,7 @@ func (i *Installer) retryOperation(op func() error) error {
 }
 
 func (i *Installer) runCommand(cmd string) error {
-	command := exec.Command("sh", "-c", cmd)
-	command.Stdout = os.Stdout
-	command.Stderr = os.Stderr
-	return command.Run()
+	return i.runner().Run(cmd)
 }
 
 func (i *Installer) setupConfigFiles(tool *interfaces.Tool) error {
@@ -375,15 +391,41 @@ func (i *Installer) setupConfigFiles(tool *interfaces.Tool) error {
 }
 
 func (i *Installer) applyShellConfig(tool *interfaces.Tool) error {
-	if tool.ShellConfig.Aliases == nil && tool.ShellConfig.Env == nil && len(tool.ShellConfig.Path) == 0 {
+	hasConfig := tool.ShellConfig.Aliases != nil || tool.ShellConfig.Env != nil || len(tool.ShellConfig.Path) > 0
+	if !hasConfig && tool.ShellConfig.PowerShell == "" {
 		return nil
 	}
 
-	shell, err := i.getCurrentShell()
+	shells, err := i.targetShells()
 	if err != nil {
-		return fmt.Errorf("failed to detect current shell: %v", err)
+		return err
 	}
+	for _, sh := range shells {
+		// A PowerShell snippet alone leaves the other shells with nothing to write
+		if !hasConfig && completionShell(sh) != string(interfaces.PowerShell) {
+			continue
+		}
+		if err := i.applyShellConfigFor(sh, tool); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
+// targetShells returns the shells to configure, falling back to $SHELL
+func (i *Installer) targetShells() ([]string, error) {
+	if len(i.Shells) > 0 {
+		return i.Shells, nil
+	}
+	current, err := i.getCurrentShell()
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect current shell: %v", err)
+	}
+	return []string{current}, nil
+}
+
+// applyShellConfigFor writes the tool's shell configuration for one shell
+func (i *Installer) applyShellConfigFor(shell string, tool *interfaces.Tool) error {
 	switch {
 	case strings.Contains(shell, "zsh"):
 		return i.applyZshConfig(tool)
@@ -391,6 +433,10 @@ func (i *Installer) applyShellConfig(tool *interfaces.Tool) error {
 		return i.applyBashConfig(tool)
 	case strings.Contains(shell, "fish"):
 		return i.applyFishConfig(tool)
+	case filepath.Base(shell) == string(interfaces.NuShell):
+		return i.applyNuConfig(tool)
+	case completionShell(shell) == string(interfaces.PowerShell):
+		return i.applyPowerShellConfig(tool)
 	default:
 		return fmt.Errorf("unsupported shell: %s", shell)
 	}
@@ -427,7 +473,7 @@ func (i *Installer) createFile(source, destination string, mode string) error {
 }
 
 func (i *Installer) getCurrentShell() (string, error) {
-	shell := os.Getenv("SHELL")
+	shell := i.platform().Shell()
 	if shell == "" {
 		return "", fmt.Errorf("SHELL environment variable not set")
 	}
@@ -435,12 +481,14 @@ func (i *Installer) getCurrentShell() (string, error) {
 }
 
 func (i *Installer) applyZshConfig(tool *interfaces.Tool) error {
-	configDir := filepath.Join(os.Getenv("HOME"), ".zsh")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	home, err := i.platform().HomeDir()
+	if err != nil {
+		return err
+	}
+	configFile, _ := ShellConfigPath(home, string(interfaces.ZshShell), tool.Name)
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
 		return fmt.Errorf("failed to create zsh config directory: %v", err)
 	}
-
-	configFile := filepath.Join(configDir, fmt.Sprintf("%s.zsh", tool.Name))
 	var config strings.Builder
 
 	// Add aliases
@@ -463,37 +511,104 @@ func (i *Installer) applyZshConfig(tool *interfaces.Tool) error {
 		return fmt.Errorf("failed to write zsh config: %v", err)
 	}
 
-	// Add source line to .zshrc if not already present
-	zshrc := filepath.Join(os.Getenv("HOME"), ".zshrc")
-	sourceLine := fmt.Sprintf("source %s", configFile)
-	
-	content, err := os.ReadFile(zshrc)
+	// Source the config file from a managed block in .zshrc
+	zshrc := filepath.Join(home, ".zshrc")
+	if err := i.configureRcFile(zshrc, tool.Name, fmt.Sprintf("source %s", configFile)); err != nil {
+		return fmt.Errorf("failed to update .zshrc: %v", err)
+	}
+
+	return nil
+}
+
+// ensureRCWriter creates the rc writer on first use, reporting the hand-edited
+// blocks it leaves alone through the installer's logger
+func (i *Installer) ensureRCWriter() {
+	if i.RCWriter == nil {
+		i.RCWriter = shell.NewRCWriter()
+	}
+	if i.RCWriter.Warn == nil && i.Logger != nil {
+		i.RCWriter.Warn = i.Logger.Warn
+	}
+}
+
+// configureRcFile sets the managed block for a tool in an rc file, or prints the
+// diff it would apply in dry-run mode
+func (i *Installer) configureRcFile(rcPath, name, body string) error {
+	i.ensureRCWriter()
+	change, err := i.RCWriter.UpsertBlock(rcPath, name, body)
 	if err != nil {
-		return fmt.Errorf("failed to read .zshrc: %v", err)
+		return err
 	}
+	if change != nil && i.RCWriter.DryRun && !i.RCWriter.Buffered {
+		i.Logger.Info("Dry run: not writing changes to %s", rcPath)
+	}
+	return nil
+}
 
-	if !strings.Contains(string(content), sourceLine) {
-		f, err := os.OpenFile(zshrc, os.O_APPEND|os.O_WRONLY, 0644)
-		if err != nil {
-			return fmt.Errorf("failed to open .zshrc: %v", err)
+// recordInstalled adds the tools installed so far to the snapshot at StatePath
+func (i *Installer) recordInstalled() error {
+	if i.StatePath == "" || len(i.installed) == 0 {
+		return nil
+	}
+	manager := i.PackageManager.GetName()
+	now := time.Now()
+	err := manifest.UpdateInstalled(i.StatePath, func(s *manifest.Installed) {
+		for _, t := range i.installed {
+			item := manifest.InstalledItem{Manager: manager, Package: t.pkg, Command: t.tool.Name, InstalledAt: now}
+			if v, err := i.PackageManager.GetVersion(t.pkg); err == nil {
+				item.Version = strings.TrimSpace(v)
+			}
+			previous, recorded := s.Tools[t.tool.Name]
+			item.PreExisting = t.preExisting
+			if recorded {
+				item.PreExisting = previous.PreExisting
+				if previous.Version == item.Version {
+					item.InstalledAt = previous.InstalledAt
+				}
+			}
+			s.Tools[t.tool.Name] = item
 		}
-		defer f.Close()
+	})
+	if err != nil {
+		return err
+	}
+	i.installed = nil
+	return nil
+}
 
-		if _, err := f.WriteString(fmt.Sprintf("\n# Added by bootstrap-cli\n%s\n", sourceLine)); err != nil {
-			return fmt.Errorf("failed to update .zshrc: %v", err)
+// FinishInstallation records the installed tools in the snapshot at StatePath,
+// writes the rc file edits buffered during the run, each file in one pass, and
+// logs what changed
+func (i *Installer) FinishInstallation() error {
+	if err := i.recordInstalled(); err != nil {
+		i.Logger.Warn("Failed to update installed snapshot: %v", err)
+	}
+	if i.RCWriter == nil || !i.RCWriter.Buffered {
+		return nil
+	}
+	changes, err := i.RCWriter.Flush()
+	for _, change := range changes {
+		if change.Applied {
+			i.Logger.Info("Updated %s (%s)", change.Path, change.Block)
+		} else {
+			i.Logger.Info("Dry run: not writing changes to %s", change.Path)
 		}
 	}
-
+	if err != nil {
+		return fmt.Errorf("failed to update shell rc files: %w", err)
+	}
 	return nil
 }
 
 func (i *Installer) applyBashConfig(tool *interfaces.Tool) error {
-	configDir := filepath.Join(os.Getenv("HOME"), ".bash")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	home, err := i.platform().HomeDir()
+	if err != nil {
+		return err
+	}
+	configFile, _ := ShellConfigPath(home, string(interfaces.BashShell), tool.Name)
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
 		return fmt.Errorf("failed to create bash config directory: %v", err)
 	}
-
-	configFile := filepath.Join(configDir, fmt.Sprintf("%s.bash", tool.Name))
 	var config strings.Builder
 
 	// Add aliases
@@ -516,58 +631,164 @@ func (i *Installer) applyBashConfig(tool *interfaces.Tool) error {
 		return fmt.Errorf("failed to write bash config: %v", err)
 	}
 
-	// Add source line to .bashrc if not already present
-	bashrc := filepath.Join(os.Getenv("HOME"), ".bashrc")
-	sourceLine := fmt.Sprintf("source %s", configFile)
-	
-	content, err := os.ReadFile(bashrc)
+	// Source the config file from a managed block in .bashrc
+	bashrc := filepath.Join(home, ".bashrc")
+	if err := i.configureRcFile(bashrc, tool.Name, fmt.Sprintf("source %s", configFile)); err != nil {
+		return fmt.Errorf("failed to update .bashrc: %v", err)
+	}
+
+	return nil
+}
+
+func (i *Installer) applyFishConfig(tool *interfaces.Tool) error {
+	home, err := i.platform().HomeDir()
 	if err != nil {
-		return fmt.Errorf("failed to read .bashrc: %v", err)
+		return err
 	}
+	configFile, _ := ShellConfigPath(home, string(interfaces.FishShell), tool.Name)
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
+		return fmt.Errorf("failed to create fish config directory: %v", err)
+	}
+	var config strings.Builder
 
-	if !strings.Contains(string(content), sourceLine) {
-		f, err := os.OpenFile(bashrc, os.O_APPEND|os.O_WRONLY, 0644)
+	// Add aliases, quoted so commands containing quotes stay one word
+	for alias, cmd := range tool.ShellConfig.Aliases {
+		config.WriteString(fmt.Sprintf("alias %s %s\n", alias, shell.FishQuote(cmd)))
+	}
+
+	// Add environment variables, keeping $VAR and $(cmd) expansions working in fish
+	for key, value := range tool.ShellConfig.Env {
+		word, err := shell.FishValue(value)
 		if err != nil {
-			return fmt.Errorf("failed to open .bashrc: %v", err)
+			i.Logger.Warn("Skipping fish env %s for %s: %v", key, tool.Name, err)
+			continue
 		}
-		defer f.Close()
+		config.WriteString(fmt.Sprintf("set -gx %s %s\n", key, word))
+	}
 
-		if _, err := f.WriteString(fmt.Sprintf("\n# Added by bootstrap-cli\n%s\n", sourceLine)); err != nil {
-			return fmt.Errorf("failed to update .bashrc: %v", err)
+	// Add PATH entries
+	for _, path := range tool.ShellConfig.Path {
+		word, err := shell.FishValue(path)
+		if err != nil {
+			i.Logger.Warn("Skipping fish PATH entry %s for %s: %v", path, tool.Name, err)
+			continue
 		}
+		config.WriteString(fmt.Sprintf("fish_add_path %s\n", word))
+	}
+
+	// Write the config file
+	if err := os.WriteFile(configFile, []byte(config.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write fish config: %v", err)
 	}
 
 	return nil
-}
+} 
 
-func (i *Installer) applyFishConfig(tool *interfaces.Tool) error {
-	configDir := filepath.Join(os.Getenv("HOME"), ".config/fish/conf.d")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return fmt.Errorf("failed to create fish config directory: %v", err)
+func (i *Installer) applyNuConfig(tool *interfaces.Tool) error {
+	home, err := i.platform().HomeDir()
+	if err != nil {
+		return err
+	}
+	configFile, _ := ShellConfigPath(home, string(interfaces.NuShell), tool.Name)
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
+		return fmt.Errorf("failed to create nushell config directory: %v", err)
 	}
+	var config strings.Builder
 
-	configFile := filepath.Join(configDir, fmt.Sprintf("%s.fish", tool.Name))
+	// Add aliases, calling the external command rather than a nushell builtin
+	for alias, cmd := range tool.ShellConfig.Aliases {
+		body, err := shell.NuAlias(cmd)
+		if err != nil {
+			i.Logger.Warn("Skipping nushell alias %s for %s: %v", alias, tool.Name, err)
+			continue
+		}
+		config.WriteString(fmt.Sprintf("alias %s = %s\n", alias, body))
+	}
+
+	// Add environment variables, keeping $VAR and $(cmd) expansions working in nushell
+	for key, value := range tool.ShellConfig.Env {
+		word, err := shell.NuValue(value)
+		if err != nil {
+			i.Logger.Warn("Skipping nushell env %s for %s: %v", key, tool.Name, err)
+			continue
+		}
+		config.WriteString(fmt.Sprintf("$env.%s = %s\n", key, word))
+	}
+
+	// Add PATH entries
+	for _, path := range tool.ShellConfig.Path {
+		word, err := shell.NuValue(path)
+		if err != nil {
+			i.Logger.Warn("Skipping nushell PATH entry %s for %s: %v", path, tool.Name, err)
+			continue
+		}
+		config.WriteString(fmt.Sprintf("$env.PATH = ($env.PATH | split row (char esep) | prepend %s)\n", word))
+	}
+
+	// Write the config file; nushell loads its autoload directory on its own
+	if err := os.WriteFile(configFile, []byte(config.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write nushell config: %v", err)
+	}
+
+	return nil
+}
+
+func (i *Installer) applyPowerShellConfig(tool *interfaces.Tool) error {
+	home, err := i.platform().HomeDir()
+	if err != nil {
+		return err
+	}
+	configFile, _ := ShellConfigPath(home, string(interfaces.PowerShell), tool.Name)
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
+		return fmt.Errorf("failed to create PowerShell config directory: %v", err)
+	}
 	var config strings.Builder
 
-	// Add aliases
+	// Add aliases, as functions when they pass arguments
 	for alias, cmd := range tool.ShellConfig.Aliases {
-		config.WriteString(fmt.Sprintf("alias %s '%s'\n", alias, cmd))
+		def, err := shell.PSAlias(alias, cmd)
+		if err != nil {
+			i.Logger.Warn("Skipping PowerShell alias %s for %s: %v", alias, tool.Name, err)
+			continue
+		}
+		config.WriteString(def + "\n")
 	}
 
-	// Add environment variables
+	// Add environment variables, keeping $VAR and $(cmd) expansions working in PowerShell
 	for key, value := range tool.ShellConfig.Env {
-		config.WriteString(fmt.Sprintf("set -gx %s '%s'\n", key, value))
+		word, err := shell.PSValue(value)
+		if err != nil {
+			i.Logger.Warn("Skipping PowerShell env %s for %s: %v", key, tool.Name, err)
+			continue
+		}
+		config.WriteString(fmt.Sprintf("$env:%s = %s\n", key, word))
 	}
 
 	// Add PATH entries
 	for _, path := range tool.ShellConfig.Path {
-		config.WriteString(fmt.Sprintf("fish_add_path '%s'\n", path))
+		word, err := shell.PSValue(path)
+		if err != nil {
+			i.Logger.Warn("Skipping PowerShell PATH entry %s for %s: %v", path, tool.Name, err)
+			continue
+		}
+		config.WriteString(fmt.Sprintf("$env:PATH = %s + [IO.Path]::PathSeparator + $env:PATH\n", word))
+	}
+
+	// Add the tool's own PowerShell code
+	if tool.ShellConfig.PowerShell != "" {
+		config.WriteString(strings.TrimRight(tool.ShellConfig.PowerShell, "\n") + "\n")
 	}
 
 	// Write the config file
 	if err := os.WriteFile(configFile, []byte(config.String()), 0644); err != nil {
-		return fmt.Errorf("failed to write fish config: %v", err)
+		return fmt.Errorf("failed to write PowerShell config: %v", err)
+	}
+
+	// Dot-source the config file from a managed block in $PROFILE
+	profile := rcFileFor(home, string(interfaces.PowerShell))
+	if err := i.configureRcFile(profile, tool.Name, psSource(configFile)); err != nil {
+		return fmt.Errorf("failed to update %s: %v", filepath.Base(profile), err)
 	}
 
 	return nil
-} 
\ No newline at end of file
+}
@@ -140,11 +140,7 @@ func TestInstaller(t *testing.T) {
 				Name:         "test-tool",
 				Description:  "Test tool",
 				Version:      "1.0.0",
-				Dependencies: []struct {
-					Name     string `yaml:"name"`
-					Type     string `yaml:"type"`
-					Optional bool   `yaml:"optional,omitempty"`
-				}{
+				Dependencies: []interfaces.Dependency{
 					{Name: "dep1", Type: "package"},
 					{Name: "dep2", Type: "package"},
 				},
@@ -170,11 +166,7 @@ func TestInstaller(t *testing.T) {
 			tool: &interfaces.Tool{
 				Name:         "retry-tool",
 				Description:  "Retry tool",
-				Dependencies: []struct {
-					Name     string `yaml:"name"`
-					Type     string `yaml:"type"`
-					Optional bool   `yaml:"optional,omitempty"`
-				}{
+				Dependencies: []interfaces.Dependency{
 					{Name: "dep1", Type: "package"},
 				},
 			},
@@ -189,11 +181,7 @@ func TestInstaller(t *testing.T) {
 			tool: &interfaces.Tool{
 				Name:         "fail-tool",
 				Description:  "Fail tool",
-				Dependencies: []struct {
-					Name     string `yaml:"name"`
-					Type     string `yaml:"type"`
-					Optional bool   `yaml:"optional,omitempty"`
-				}{
+				Dependencies: []interfaces.Dependency{
 					{Name: "dep1", Type: "package"},
 				},
 			},
@@ -214,6 +202,10 @@ func TestInstaller(t *testing.T) {
 					Brew   string `yaml:"brew"`
 					DNF    string `yaml:"dnf"`
 					Pacman string `yaml:"pacman"`
+					Zypper string `yaml:"zypper,omitempty"`
+					Pkg    string `yaml:"pkg,omitempty"`
+					Winget string `yaml:"winget,omitempty"`
+					Choco  string `yaml:"choco,omitempty"`
 				}{
 					APT:    "apt-package",
 					DNF:    "dnf-package",
@@ -248,11 +240,7 @@ func TestInstaller(t *testing.T) {
 			tool: &interfaces.Tool{
 				Name:         "post-fail-tool",
 				Description:  "Post fail tool",
-				Dependencies: []struct {
-					Name     string `yaml:"name"`
-					Type     string `yaml:"type"`
-					Optional bool   `yaml:"optional,omitempty"`
-				}{
+				Dependencies: []interfaces.Dependency{
 					{Name: "dep1", Type: "package"},
 				},
 				PostInstall: []struct {
@@ -0,0 +1,23 @@
+package interfaces
+
+// DependencyType represents the type of dependency
+type DependencyType string
+
+const (
+	// PackageDependency represents a package manager dependency
+	PackageDependency DependencyType = "package"
+	// SystemDependency represents a system-level dependency
+	SystemDependency DependencyType = "system"
+	// FileDependency represents a file dependency
+	FileDependency DependencyType = "file"
+)
+
+// Dependency represents a dependency with its requirements
+type Dependency struct {
+	Name         string         `yaml:"name"`
+	Type         DependencyType `yaml:"type"`
+	Version      string         `yaml:"version,omitempty"`
+	Optional     bool           `yaml:"optional,omitempty"`
+	Platform     []string       `yaml:"platform,omitempty"`     // Supported platforms (e.g., ["linux", "darwin"])
+	Alternatives []string       `yaml:"alternatives,omitempty"` // Alternative dependencies that can satisfy this requirement
+}
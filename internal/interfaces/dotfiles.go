@@ -61,6 +61,34 @@ type Dotfile struct {
 	SourceRepo      string   `yaml:"source_repo"` // Optional: GitHub repo URL for user's dotfiles
 	BaseDir         string   `yaml:"base_dir"` // Base directory for dotfiles (default: ~/.dotfiles)
 	SymlinkStrategy SymlinkStrategy `yaml:"symlink_strategy"`
+	// Framework optionally installs a shell framework (oh-my-zsh, bash-it) at a pinned ref
+	Framework       *ShellFramework `yaml:"framework,omitempty"`
+	// Variables declares the template variables used by templated files
+	Variables       []DotfileVariable `yaml:"variables,omitempty"`
+}
+
+// DotfileVariable declares a variable available to templated dotfile files
+type DotfileVariable struct {
+	// Name is the variable name, referenced as {{.Name}} in templates
+	Name string `yaml:"name"`
+	// Description is shown when prompting for the value
+	Description string `yaml:"description,omitempty"`
+	// Default is used when no value is set
+	Default string `yaml:"default,omitempty"`
+	// Required variables are prompted for when interactive if they have no value
+	Required bool `yaml:"required,omitempty"`
+}
+
+// ShellFramework describes a shell framework to install and the git ref to pin it to
+type ShellFramework struct {
+	// Name is the framework name (oh-my-zsh or bash-it)
+	Name string `yaml:"name"`
+	// Repo overrides the upstream repository, e.g. to install from a fork
+	Repo string `yaml:"repo,omitempty"`
+	// Ref is the branch, tag or commit to install (default branch if empty)
+	Ref string `yaml:"ref,omitempty"`
+	// SHA256 pins the framework's install script, where it has one (oh-my-zsh)
+	SHA256 string `yaml:"sha256,omitempty"`
 }
 
 // DotfileFile represents a file to be managed
@@ -77,6 +105,8 @@ type DotfileFile struct {
 	BackupSuffix string `yaml:"backup_suffix"`
 	// Content is the content to write to the file (for Create/Update operations)
 	Content string `yaml:"content"`
+	// Template renders Content (or the source file) with text/template before writing it
+	Template bool `yaml:"template,omitempty"`
 }
 
 // SymlinkStrategy defines how to handle dotfile symlinks
@@ -7,6 +7,11 @@ type Font struct {
 	Category    string   `yaml:"category"`
 	Tags        []string `yaml:"tags"`
 	Source      string   `yaml:"source"`
+	// SHA256 pins the source archive, verified when it is downloaded
+	SHA256      string   `yaml:"sha256,omitempty"`
+	// Family is the family name fc-list reports once the font is installed
+	// (default: Name)
+	Family      string   `yaml:"family,omitempty"`
 	Install     []string `yaml:"install"`
 	Verify      []string `yaml:"verify"`
 }
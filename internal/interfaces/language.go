@@ -1,5 +1,21 @@
 package interfaces
 
+import "strings"
+
+const (
+	// LanguageStrategyVersionManager installs a language through its version manager (nvm, pyenv...)
+	LanguageStrategyVersionManager = "version-manager"
+	// LanguageStrategySystem installs a language with the system package manager, skipping
+	// the version manager and its rc file changes
+	LanguageStrategySystem = "system"
+	// LanguageInstallerAsdf installs a language through asdf, which is set up
+	// first when it is not installed, instead of nvm, pyenv, goenv or rustup
+	LanguageInstallerAsdf = "asdf"
+	// LanguageInstallerMise installs a language through mise, which is
+	// downloaded first when it is not installed
+	LanguageInstallerMise = "mise"
+)
+
 // Language represents a programming language runtime
 type Language struct {
 	Name        string   `yaml:"name"`
@@ -8,7 +24,12 @@ type Language struct {
 	Tags        []string `yaml:"tags"`
 	Version     string   `yaml:"version"`
 	Installer   string   `yaml:"installer"`
+	// Strategy overrides how the language is installed (system or version-manager)
+	Strategy    string   `yaml:"strategy,omitempty"`
 	VerifyCommand string `yaml:"verify_command"`
+	// GlobalPackages are installed with the language's own package tool (npm -g,
+	// pipx, go install, cargo install, gem) once the language is set up
+	GlobalPackages []string `yaml:"global_packages,omitempty"`
 
 	// Dependencies required for installation
 	Dependencies []struct {
@@ -26,6 +47,12 @@ type Language struct {
 		Brew   string `yaml:"brew"`
 		DNF    string `yaml:"dnf"`
 		Pacman string `yaml:"pacman"`
+		Zypper string `yaml:"zypper,omitempty"`
+		// Pkg is Termux's package name, when it differs from the apt one
+		Pkg string `yaml:"pkg,omitempty"`
+		// Winget is a winget package id, e.g. OpenJS.NodeJS.LTS
+		Winget string `yaml:"winget,omitempty"`
+		Choco  string `yaml:"choco,omitempty"`
 	} `yaml:"package_names"`
 
 	// Post-installation steps
@@ -46,22 +73,64 @@ func (l *Language) GetPackageName(packageManager string) string {
 	switch packageManager {
 	case "apt":
 		return l.PackageNames.APT
+	case "pkg": // Termux's pkg uses Debian package names unless it names its own
+		if l.PackageNames.Pkg != "" {
+			return l.PackageNames.Pkg
+		}
+		return l.PackageNames.APT
 	case "brew":
 		return l.PackageNames.Brew
 	case "dnf":
 		return l.PackageNames.DNF
 	case "pacman":
 		return l.PackageNames.Pacman
+	case "zypper":
+		return l.PackageNames.Zypper
+	case "winget":
+		return l.PackageNames.Winget
+	case "choco":
+		return l.PackageNames.Choco
 	default:
 		return ""
 	}
 }
 
+// SystemPackages returns the packages installed by the system strategy; a package
+// name may list several space-separated packages (e.g. "nodejs npm")
+func (l *Language) SystemPackages(packageManager string) []string {
+	if name := l.GetPackageName(packageManager); name != "" {
+		return strings.Fields(name)
+	}
+	return []string{strings.ToLower(l.Name)}
+}
+
+// ResolveStrategy returns the language's own strategy, falling back to defaultStrategy
+// and then to the version manager
+func (l *Language) ResolveStrategy(defaultStrategy string) string {
+	if l.Strategy != "" {
+		return l.Strategy
+	}
+	if defaultStrategy != "" {
+		return defaultStrategy
+	}
+	return LanguageStrategyVersionManager
+}
+
 // GetInstaller returns the language version manager to use
 func (l *Language) GetInstaller() string {
 	return l.Installer
 }
 
+// RuntimeManager returns the multi-language manager (asdf or mise) the language
+// is installed through, or "" when it uses its own version manager
+func (l *Language) RuntimeManager() string {
+	switch l.Installer {
+	case LanguageInstallerAsdf, LanguageInstallerMise:
+		return l.Installer
+	}
+	return ""
+}
+
 // GetVersion returns the desired version of the language
 func (l *Language) GetVersion() string {
 	return l.Version
@@ -70,20 +139,12 @@ func (l *Language) GetVersion() string {
 // ToTool converts a Language to a Tool for installation
 func (l *Language) ToTool() *Tool {
 	// Convert dependencies
-	deps := make([]struct {
-		Name     string `yaml:"name"`
-		Type     string `yaml:"type"`
-		Optional bool   `yaml:"optional,omitempty"`
-	}, len(l.Dependencies))
+	deps := make([]Dependency, len(l.Dependencies))
 	
 	for i, dep := range l.Dependencies {
-		deps[i] = struct {
-			Name     string `yaml:"name"`
-			Type     string `yaml:"type"`
-			Optional bool   `yaml:"optional,omitempty"`
-		}{
+		deps[i] = Dependency{
 			Name:     dep.Name,
-			Type:     dep.Type,
+			Type:     DependencyType(dep.Type),
 			Optional: dep.Optional,
 		}
 	}
@@ -104,6 +165,7 @@ func (l *Language) ToTool() *Tool {
 			Env       map[string]string `yaml:"env,omitempty"`
 			Path      []string         `yaml:"path,omitempty"`
 			Functions map[string]string `yaml:"functions,omitempty"`
+			PowerShell string `yaml:"powershell,omitempty"`
 		}{
 			Env: l.ShellConfig.Env,
 		},
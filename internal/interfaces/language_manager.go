@@ -39,4 +39,16 @@ func (m *LanguageManager) SupportsLanguage(lang string) bool {
 		}
 	}
 	return false
-} 
\ No newline at end of file
+} 
+// VersionManagerSource is where a version manager is fetched from, overriding
+// the upstream default (e.g. a fork or an internal mirror)
+type VersionManagerSource struct {
+	// URL is the install script (nvm, rustup) or git repository (pyenv, goenv).
+	// A {ref} placeholder in a script URL is replaced with Ref.
+	URL string `yaml:"url,omitempty"`
+	// Ref pins the git tag, branch or commit to install
+	Ref string `yaml:"ref,omitempty"`
+	// SHA256 is the expected hex checksum of an install script, verified
+	// before the script runs
+	SHA256 string `yaml:"sha256,omitempty"`
+}
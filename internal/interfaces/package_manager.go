@@ -1,5 +1,12 @@
 package interfaces
 
+import "errors"
+
+// ErrAlreadyInstalled is returned by an Install whose package manager only
+// says the package is already installed, e.g. winget's exit code for it, so
+// callers can skip the package rather than fail
+var ErrAlreadyInstalled = errors.New("package is already installed")
+
 // PackageManager represents a system package manager
 type PackageManager interface {
 	// Install installs a package
@@ -36,6 +43,24 @@ type PackageManager interface {
 	SetupSpecialPackage(packageName string) error
 }
 
+// PackageCommands builds the shell commands of a package manager, so the
+// installation pipeline can run, log and journal them itself
+type PackageCommands interface {
+	// InstallCommand returns the command that installs the space-separated pkgs
+	InstallCommand(pkgs string) string
+
+	// UpgradeCommand returns the command that upgrades the installed pkgs
+	UpgradeCommand(pkgs string) string
+
+	// VersionedPackage returns the package spec asking for version of pkg, or
+	// pkg itself when the manager takes no version
+	VersionedPackage(pkg, version string) string
+
+	// Pinnable reports whether VersionedPackage installs exactly that version,
+	// so a lock file can be written and applied with the manager
+	Pinnable() bool
+}
+
 // PackageManagerType represents the type of package manager
 type PackageManagerType string
 
@@ -46,6 +71,14 @@ const (
 	DNF PackageManagerType = "dnf"
 	// Pacman package manager (Arch)
 	Pacman PackageManagerType = "pacman"
+	// Zypper package manager (openSUSE, SLES)
+	Zypper PackageManagerType = "zypper"
 	// Homebrew package manager (macOS)
 	Homebrew PackageManagerType = "brew"
+	// Pkg is Termux's apt wrapper (Android)
+	Pkg PackageManagerType = "pkg"
+	// Winget is the Windows Package Manager, shipped with Windows 10 and 11
+	Winget PackageManagerType = "winget"
+	// Chocolatey package manager (Windows)
+	Chocolatey PackageManagerType = "choco"
 ) 
\ No newline at end of file
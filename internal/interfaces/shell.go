@@ -12,11 +12,30 @@ type Shell struct {
 		Dnf    string `yaml:"dnf,omitempty"`
 		Pacman string `yaml:"pacman,omitempty"`
 	} `yaml:"install_commands"`
+	// Package is the package the shell is installed from when it is not named
+	// after the shell, e.g. nushell for nu
+	Package         string `yaml:"package,omitempty"`
+	// WingetPackage is the winget package id the shell is installed from on
+	// Windows, e.g. Microsoft.PowerShell
+	WingetPackage   string `yaml:"winget_package,omitempty"`
 	Path            string `yaml:"path"`
 	SetDefaultCommand string `yaml:"set_default_command,omitempty"`
 	VerifyCommand   string `yaml:"verify_command,omitempty"`
 }
 
+// InstallCommandFor returns the shell's install_commands entry for manager, or
+// "" when the shell is installed from its package; Termux shares the apt entry
+func (s *Shell) InstallCommandFor(manager string) string {
+	commands := map[string]string{
+		"apt":    s.InstallCommands.Apt,
+		"pkg":    s.InstallCommands.Apt,
+		"brew":   s.InstallCommands.Brew,
+		"dnf":    s.InstallCommands.Dnf,
+		"pacman": s.InstallCommands.Pacman,
+	}
+	return commands[manager]
+}
+
 // ShellType represents a shell type
 type ShellType string
 
@@ -27,6 +46,11 @@ const (
 	ZshShell ShellType = "zsh"
 	// FishShell represents the Fish shell
 	FishShell ShellType = "fish"
+	// NuShell represents nushell, whose binary is nu
+	NuShell ShellType = "nu"
+	// PowerShell represents PowerShell 7 (pwsh), or Windows PowerShell 5 where
+	// that is the only one installed
+	PowerShell ShellType = "pwsh"
 )
 
 // Error variables
@@ -89,7 +113,7 @@ type FileConfig struct {
 // IsValidShell checks if a shell type is supported
 func IsValidShell(shell string) bool {
 	switch ShellType(shell) {
-	case BashShell, ZshShell, FishShell:
+	case BashShell, ZshShell, FishShell, NuShell, PowerShell:
 		return true
 	default:
 		return false
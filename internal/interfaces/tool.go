@@ -10,10 +10,14 @@ import (
 // Tool represents a development tool that can be installed
 type Tool struct {
 	Name        string   `yaml:"name"`
+	// DisplayName is shown in the selection instead of Name when set
+	DisplayName string   `yaml:"display_name,omitempty"`
 	Description string   `yaml:"description"`
 	Category    string   `yaml:"category"`
 	Tags        []string `yaml:"tags,omitempty"`
-	Languages   []string `yaml:"languages,omitempty"`  // List of supported languages
+	// Aliases are other names the tool is known by (e.g. "rg" for ripgrep)
+	Aliases   []string `yaml:"aliases,omitempty"`
+	Languages []string `yaml:"languages,omitempty"` // List of supported languages
 	
 	// Package management
 	PackageNames struct {
@@ -21,16 +25,20 @@ type Tool struct {
 		Brew   string `yaml:"brew"`
 		DNF    string `yaml:"dnf"`
 		Pacman string `yaml:"pacman"`
+		Zypper string `yaml:"zypper,omitempty"`
+		// Pkg is Termux's package name, when it differs from the apt one
+		Pkg string `yaml:"pkg,omitempty"`
+		// Winget is a winget package id, e.g. BurntSushi.ripgrep.MSVC
+		Winget string `yaml:"winget,omitempty"`
+		Choco  string `yaml:"choco,omitempty"`
 	} `yaml:"package_names"`
 
 	Version            string   `yaml:"version"`
 	SystemDependencies []string `yaml:"system_dependencies,omitempty"`
-	Dependencies       []struct {
-		Name     string `yaml:"name"`
-		Type     string `yaml:"type"`
-		Optional bool   `yaml:"optional,omitempty"`
-	} `yaml:"dependencies,omitempty"`
-	VerifyCommand string `yaml:"verify_command"`
+	Dependencies       []Dependency `yaml:"dependencies,omitempty"`
+	// Requires names tools to install before this one; "a|b" is met by either
+	Requires      []string `yaml:"requires,omitempty"`
+	VerifyCommand string   `yaml:"verify_command"`
 	PostInstall   []struct {
 		Command     string `yaml:"command"`
 		Description string `yaml:"description"`
@@ -41,8 +49,14 @@ type Tool struct {
 		Env       map[string]string `yaml:"env,omitempty"`
 		Path      []string         `yaml:"path,omitempty"`
 		Functions map[string]string `yaml:"functions,omitempty"`
+		// PowerShell is extra PowerShell code for the profile (e.g. PSReadLine
+		// key bindings), for integrations with no POSIX equivalent to convert
+		PowerShell string `yaml:"powershell,omitempty"`
 	} `yaml:"shell_config,omitempty"`
 
+	// Completions describes how to generate the tool's shell completion scripts
+	Completions Completions `yaml:"completions,omitempty"`
+
 	RequiresRestart bool   `yaml:"requires_restart,omitempty"`
 	InstallPath     string `yaml:"install_path,omitempty"`
 	ConfigFiles     []struct {
@@ -53,6 +67,122 @@ type Tool struct {
 	} `yaml:"config_files,omitempty"`
 }
 
+// Completions describes how to generate a tool's shell completion scripts
+type Completions struct {
+	// Command prints the completion script for a shell; {shell} is replaced
+	// with bash, zsh, fish, nu or powershell (e.g. "gh completion -s {shell}")
+	Command string `yaml:"command,omitempty"`
+	// Source marks a Command that prints shell integration code rather than a
+	// completion function (e.g. fzf's key bindings and completion setup), which
+	// the shell sources at startup instead of loading from its completion path
+	Source bool `yaml:"source,omitempty"`
+	// Shells limits generation to these shells (default: bash, zsh and fish;
+	// nu and pwsh only when listed, since fewer tools can print completions
+	// for them)
+	Shells []string `yaml:"shells,omitempty"`
+	// Scripts are the integration scripts the tool's own package ships, by
+	// package manager, sourced for bash and zsh instead of running Command.
+	// {shell} is replaced with bash or zsh and {brew_prefix} with Homebrew's
+	// prefix. They are fixed per platform, so nothing is probed at shell start.
+	Scripts map[string][]string `yaml:"scripts,omitempty"`
+}
+
+// PackageFor returns the package name for the given package manager, falling back
+// to the tool name when no manager-specific name is set
+func (t *Tool) PackageFor(packageManager string) string {
+	if name, ok := ResolvePackageName(t.PackageNameMap(), packageManager); ok {
+		return name
+	}
+	return t.Name
+}
+
+// PackageNameMap returns the tool's package names keyed by package manager,
+// leaving out the managers it names no package for
+func (t *Tool) PackageNameMap() map[string]string {
+	names := make(map[string]string)
+	for manager, name := range map[string]string{
+		"apt":    t.PackageNames.APT,
+		"brew":   t.PackageNames.Brew,
+		"dnf":    t.PackageNames.DNF,
+		"pacman": t.PackageNames.Pacman,
+		"zypper": t.PackageNames.Zypper,
+		"pkg":    t.PackageNames.Pkg,
+		"winget": t.PackageNames.Winget,
+		"choco":  t.PackageNames.Choco,
+	} {
+		if name != "" {
+			names[manager] = name
+		}
+	}
+	return names
+}
+
+// ResolvePackageName picks the package for manager from names: its own entry,
+// the apt one for Termux's pkg, which follows Debian naming, or "default"
+func ResolvePackageName(names map[string]string, manager string) (string, bool) {
+	if name, ok := names[manager]; ok {
+		return name, true
+	}
+	if name, ok := names["apt"]; ok && manager == "pkg" {
+		return name, true
+	}
+	name, ok := names["default"]
+	return name, ok
+}
+
+// Matches reports whether name refers to the tool, by its name or one of its aliases
+func (t *Tool) Matches(name string) bool {
+	if strings.EqualFold(t.Name, name) {
+		return true
+	}
+	for _, alias := range t.Aliases {
+		if strings.EqualFold(alias, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindTool resolves a tool name or alias to the tool, or nil if none matches.
+// Exact names take precedence over aliases.
+func FindTool(tools []*Tool, name string) *Tool {
+	for _, tool := range tools {
+		if strings.EqualFold(tool.Name, name) {
+			return tool
+		}
+	}
+	for _, tool := range tools {
+		if tool.Matches(name) {
+			return tool
+		}
+	}
+	return nil
+}
+
+// Label returns the name to show for the tool
+func (t *Tool) Label() string {
+	if t.DisplayName != "" {
+		return t.DisplayName
+	}
+	return t.Name
+}
+
+// HasCompletions reports whether the tool can generate completions for shell
+func (t *Tool) HasCompletions(shell string) bool {
+	if t.Completions.Command == "" && len(t.Completions.Scripts) == 0 {
+		return false
+	}
+	if len(t.Completions.Shells) == 0 {
+		return shell != string(NuShell) && shell != string(PowerShell)
+	}
+	for _, s := range t.Completions.Shells {
+		if s == shell {
+			return true
+		}
+	}
+	return false
+}
+
 // runCommand executes a shell command
 func runCommand(cmd string) error {
 	parts := strings.Fields(cmd)
@@ -0,0 +1,215 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/version"
+)
+
+// InstalledFileName is the snapshot of what bootstrap-cli currently manages
+const InstalledFileName = "installed.json"
+
+// InstalledSchemaVersion is bumped on incompatible changes to installed.json
+const InstalledSchemaVersion = 1
+
+// Installed is the current set of bootstrap-managed tools and languages, updated
+// after every install, upgrade and removal. Unlike the manifest, which is the run
+// history, it only says what is managed now; other programs may read it.
+type Installed struct {
+	SchemaVersion int       `json:"schema_version"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	// CLIVersion is the bootstrap-cli version that last wrote the snapshot
+	CLIVersion string                   `json:"cli_version,omitempty"`
+	Tools      map[string]InstalledItem `json:"tools"`
+	Languages  map[string]InstalledItem `json:"languages"`
+	// Shells are the shells installed or configured, by name
+	Shells map[string]InstalledItem `json:"shells,omitempty"`
+	// Prompts are the prompt styles configured, by style
+	Prompts map[string]InstalledItem `json:"prompts,omitempty"`
+}
+
+// ManagerGitHubRelease is the Manager of a tool installed from its GitHub
+// release rather than a package; its Path is the binary
+const ManagerGitHubRelease = "github_release"
+
+// InstalledItem describes one managed tool or language
+type InstalledItem struct {
+	Version string `json:"version,omitempty"`
+	// Manager is the package manager it was installed with, or
+	// ManagerGitHubRelease
+	Manager string `json:"manager,omitempty"`
+	// Package is the package it was installed from, for removing it again
+	Package string `json:"package,omitempty"`
+	// Command is the executable that shows it is present on PATH
+	Command string `json:"command,omitempty"`
+	// Path is the file bootstrap-cli wrote for it, e.g. a prompt config or a
+	// release binary
+	Path string `json:"path,omitempty"`
+	// PreExisting is set when it was already on the system before bootstrap-cli
+	// first installed it; uninstall leaves it in place
+	PreExisting bool      `json:"pre_existing,omitempty"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// NewInstalled creates an empty snapshot
+func NewInstalled() *Installed {
+	return &Installed{
+		SchemaVersion: InstalledSchemaVersion,
+		Tools:         make(map[string]InstalledItem),
+		Languages:     make(map[string]InstalledItem),
+		Shells:        make(map[string]InstalledItem),
+		Prompts:       make(map[string]InstalledItem),
+	}
+}
+
+// DefaultInstalledPath returns the default installed.json location
+func DefaultInstalledPath() (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, InstalledFileName), nil
+}
+
+// LoadInstalled reads the snapshot at path. A missing file yields an empty snapshot.
+func LoadInstalled(path string) (*Installed, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return NewInstalled(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read installed snapshot: %w", err)
+	}
+
+	s := NewInstalled()
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse installed snapshot %s: %w", path, err)
+	}
+	if s.Tools == nil {
+		s.Tools = make(map[string]InstalledItem)
+	}
+	if s.Languages == nil {
+		s.Languages = make(map[string]InstalledItem)
+	}
+	if s.Shells == nil {
+		s.Shells = make(map[string]InstalledItem)
+	}
+	if s.Prompts == nil {
+		s.Prompts = make(map[string]InstalledItem)
+	}
+	return s, nil
+}
+
+// Save writes the snapshot to path, replacing it atomically so readers never see
+// a partial file
+func (s *Installed) Save(path string) error {
+	s.SchemaVersion = InstalledSchemaVersion
+	s.UpdatedAt = time.Now()
+	s.CLIVersion = version.Version
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create installed snapshot directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode installed snapshot: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write installed snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write installed snapshot: %w", err)
+	}
+	return nil
+}
+
+// UpdateInstalled loads the snapshot at path, applies update and saves it
+func UpdateInstalled(path string, update func(*Installed)) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	s, err := LoadInstalled(path)
+	if err != nil {
+		return err
+	}
+	update(s)
+	return s.Save(path)
+}
+
+// ItemStatus is a managed item reconciled against PATH
+type ItemStatus struct {
+	Name string `json:"name"`
+	InstalledItem
+	// OnPath reports whether Command was found; Path is where
+	OnPath bool   `json:"on_path"`
+	Path   string `json:"path,omitempty"`
+}
+
+// Status is the snapshot reconciled against what is actually on PATH
+type Status struct {
+	UpdatedAt time.Time    `json:"updated_at"`
+	Tools     []ItemStatus `json:"tools"`
+	Languages []ItemStatus `json:"languages"`
+	Shells    []ItemStatus `json:"shells,omitempty"`
+}
+
+// Drifted returns the items recorded as installed that are no longer on PATH,
+// e.g. because a binary was removed by hand
+func (s *Status) Drifted() []string {
+	var names []string
+	for _, items := range [][]ItemStatus{s.Tools, s.Languages, s.Shells} {
+		for _, item := range items {
+			if !item.OnPath {
+				names = append(names, item.Name)
+			}
+		}
+	}
+	return names
+}
+
+// Reconcile checks each item's command with lookPath, sorted by name
+func (s *Installed) Reconcile(lookPath func(string) (string, error)) *Status {
+	return &Status{
+		UpdatedAt: s.UpdatedAt,
+		Tools:     reconcileItems(s.Tools, lookPath),
+		Languages: reconcileItems(s.Languages, lookPath),
+		Shells:    reconcileItems(s.Shells, lookPath),
+	}
+}
+
+// Has reports whether item is recorded in items and its command is still on
+// PATH, so installing it again can be skipped
+func Has(items map[string]InstalledItem, name string, lookPath func(string) (string, error)) bool {
+	item, ok := items[name]
+	if !ok {
+		return false
+	}
+	command := item.Command
+	if command == "" {
+		command = name
+	}
+	_, err := lookPath(command)
+	return err == nil
+}
+
+func reconcileItems(items map[string]InstalledItem, lookPath func(string) (string, error)) []ItemStatus {
+	statuses := make([]ItemStatus, 0, len(items))
+	for name, item := range items {
+		status := ItemStatus{Name: name, InstalledItem: item}
+		command := item.Command
+		if command == "" {
+			command = name
+		}
+		if path, err := lookPath(command); err == nil {
+			status.OnPath, status.Path = true, path
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
@@ -0,0 +1,94 @@
+package manifest
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestUpdateInstalled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", InstalledFileName)
+
+	if s, err := LoadInstalled(path); err != nil || len(s.Tools) != 0 {
+		t.Fatalf("Expected an empty snapshot for a missing file, got %+v, %v", s, err)
+	}
+
+	err := UpdateInstalled(path, func(s *Installed) {
+		s.Tools["ripgrep"] = InstalledItem{Version: "13.0.0-4", Manager: "apt", Command: "rg"}
+		s.Tools["fd"] = InstalledItem{Manager: "apt", Command: "fdfind"}
+	})
+	if err != nil {
+		t.Fatalf("UpdateInstalled() error = %v", err)
+	}
+	if err := UpdateInstalled(path, func(s *Installed) { delete(s.Tools, "fd") }); err != nil {
+		t.Fatalf("UpdateInstalled() error = %v", err)
+	}
+
+	s, err := LoadInstalled(path)
+	if err != nil {
+		t.Fatalf("LoadInstalled() error = %v", err)
+	}
+	if s.SchemaVersion != InstalledSchemaVersion || s.UpdatedAt.IsZero() {
+		t.Errorf("Unexpected snapshot header: %+v", s)
+	}
+	if len(s.Tools) != 1 || s.Tools["ripgrep"].Version != "13.0.0-4" {
+		t.Errorf("Expected only ripgrep to remain, got %+v", s.Tools)
+	}
+}
+
+func TestInstalledReconcile(t *testing.T) {
+	s := NewInstalled()
+	s.Tools["ripgrep"] = InstalledItem{Command: "rg"}
+	s.Tools["bat"] = InstalledItem{}
+	s.Languages["Go"] = InstalledItem{Command: "go"}
+
+	lookPath := func(file string) (string, error) {
+		if file == "rg" || file == "go" {
+			return "/usr/bin/" + file, nil
+		}
+		return "", fmt.Errorf("%s not found", file)
+	}
+	status := s.Reconcile(lookPath)
+
+	if len(status.Tools) != 2 || status.Tools[0].Name != "bat" || status.Tools[0].OnPath {
+		t.Errorf("Expected bat first and missing from PATH, got %+v", status.Tools)
+	}
+	if !status.Tools[1].OnPath || status.Tools[1].Path != "/usr/bin/rg" {
+		t.Errorf("Expected ripgrep to be found as rg, got %+v", status.Tools[1])
+	}
+	if len(status.Languages) != 1 || !status.Languages[0].OnPath {
+		t.Errorf("Expected Go on PATH, got %+v", status.Languages)
+	}
+	if drifted := status.Drifted(); len(drifted) != 1 || drifted[0] != "bat" {
+		t.Errorf("Drifted() = %v, want [bat]", drifted)
+	}
+	if !Has(s.Tools, "ripgrep", lookPath) || Has(s.Tools, "bat", lookPath) || Has(s.Tools, "fd", lookPath) {
+		t.Error("Expected Has to report only recorded items that are on PATH")
+	}
+}
+
+func TestUpdateInstalledConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), InstalledFileName)
+	var wg sync.WaitGroup
+	for n := 0; n < 20; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			if err := UpdateInstalled(path, func(s *Installed) {
+				s.Tools[fmt.Sprintf("tool-%d", n)] = InstalledItem{Manager: "apt"}
+			}); err != nil {
+				t.Errorf("UpdateInstalled() error = %v", err)
+			}
+		}(n)
+	}
+	wg.Wait()
+
+	s, err := LoadInstalled(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Tools) != 20 {
+		t.Errorf("Expected every concurrent update to be kept, got %d tools", len(s.Tools))
+	}
+}
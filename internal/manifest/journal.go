@@ -0,0 +1,159 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// JournalFileName is the file recording what a run in progress changed
+const JournalFileName = "journal.json"
+
+// Kinds of journaled actions
+const (
+	// JournalPackage is a package the run installed
+	JournalPackage = "package"
+	// JournalFile is a file the run created
+	JournalFile = "file"
+	// JournalDir is a directory the run created, such as a git clone
+	JournalDir = "dir"
+	// JournalRCBlock is a managed block the run added to an rc file
+	JournalRCBlock = "rc_block"
+)
+
+// Journal records every action a run completed, saved as each one completes so
+// the run can be rolled back even after a crash. Only changes to things that did
+// not exist before the run are recorded, so a rollback never touches anything
+// that was already there. It is removed when the run succeeds.
+type Journal struct {
+	StartedAt      time.Time      `json:"started_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	PackageManager string         `json:"package_manager,omitempty"`
+	Entries        []JournalEntry `json:"entries"`
+}
+
+// JournalEntry is one completed action
+type JournalEntry struct {
+	Kind string `json:"kind"`
+	// Item is the tool, language, shell or prompt the action was for
+	Item string `json:"item"`
+	// Manager and Package are the package manager and package installed
+	Manager string `json:"manager,omitempty"`
+	Package string `json:"package,omitempty"`
+	// Path is the file or directory created, or the rc file a block was added to
+	Path string `json:"path,omitempty"`
+	// Block is the name of the managed block added
+	Block string    `json:"block,omitempty"`
+	At    time.Time `json:"at"`
+}
+
+// String describes the action, e.g. "package fd-find (fd via apt)"
+func (e JournalEntry) String() string {
+	switch e.Kind {
+	case JournalPackage:
+		return fmt.Sprintf("package %s (%s via %s)", e.Package, e.Item, e.Manager)
+	case JournalFile:
+		return fmt.Sprintf("file %s (%s)", e.Path, e.Item)
+	case JournalDir:
+		return fmt.Sprintf("directory %s (%s)", e.Path, e.Item)
+	case JournalRCBlock:
+		return fmt.Sprintf("rc block %s in %s", e.Block, e.Path)
+	default:
+		return fmt.Sprintf("%s %s", e.Kind, e.Item)
+	}
+}
+
+// DefaultJournalPath returns the default journal.json location
+func DefaultJournalPath() (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, JournalFileName), nil
+}
+
+// LoadJournal reads the journal at path. A missing file yields nil: there is
+// nothing to roll back.
+func LoadJournal(path string) (*Journal, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read install journal: %w", err)
+	}
+
+	var j Journal
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, fmt.Errorf("failed to parse install journal %s: %w", path, err)
+	}
+	return &j, nil
+}
+
+// Record appends entry to the journal and saves it to path. Steps running in
+// parallel may record at the same time.
+func (j *Journal) Record(path string, entry JournalEntry) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if entry.At.IsZero() {
+		entry.At = time.Now()
+	}
+	j.Entries = append(j.Entries, entry)
+	return j.save(path)
+}
+
+// Save writes the journal to path, replacing the file atomically and flushing it
+// to disk so it survives a crash or power loss
+func (j *Journal) Save(path string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	return j.save(path)
+}
+
+func (j *Journal) save(path string) error {
+	j.UpdatedAt = time.Now()
+	if j.StartedAt.IsZero() {
+		j.StartedAt = j.UpdatedAt
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create install journal directory: %w", err)
+	}
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode install journal: %w", err)
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to write install journal: %w", err)
+	}
+	_, err = f.Write(append(data, '\n'))
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write install journal: %w", err)
+	}
+	return nil
+}
+
+// RemoveJournal deletes the journal at path; a missing file is not an error
+func RemoveJournal(path string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove install journal: %w", err)
+	}
+	return nil
+}
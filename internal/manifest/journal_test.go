@@ -0,0 +1,57 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournalRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", JournalFileName)
+	if j, err := LoadJournal(path); err != nil || j != nil {
+		t.Fatalf("LoadJournal() of a missing file = %v, %v; want nil, nil", j, err)
+	}
+
+	j := &Journal{PackageManager: "apt"}
+	entries := []JournalEntry{
+		{Kind: JournalPackage, Item: "fd", Manager: "apt", Package: "fd-find"},
+		{Kind: JournalFile, Item: "starship", Path: "/home/me/.config/starship.toml"},
+		{Kind: JournalRCBlock, Item: "starship", Path: "/home/me/.bashrc", Block: "prompt"},
+	}
+	for _, entry := range entries {
+		if err := j.Record(path, entry); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Error("Expected no temporary file to be left behind")
+	}
+
+	// Every entry is on disk as soon as it is recorded
+	loaded, err := LoadJournal(path)
+	if err != nil {
+		t.Fatalf("LoadJournal() error = %v", err)
+	}
+	if loaded.StartedAt.IsZero() || loaded.PackageManager != "apt" || len(loaded.Entries) != 3 {
+		t.Fatalf("Unexpected journal: %+v", loaded)
+	}
+	if loaded.Entries[0].At.IsZero() {
+		t.Error("Expected entries to be timestamped")
+	}
+	for i, want := range []string{
+		"package fd-find (fd via apt)",
+		"file /home/me/.config/starship.toml (starship)",
+		"rc block prompt in /home/me/.bashrc",
+	} {
+		if got := loaded.Entries[i].String(); got != want {
+			t.Errorf("Entries[%d].String() = %q, want %q", i, got, want)
+		}
+	}
+
+	if err := RemoveJournal(path); err != nil {
+		t.Fatalf("RemoveJournal() error = %v", err)
+	}
+	if err := RemoveJournal(path); err != nil {
+		t.Errorf("RemoveJournal() of a missing file error = %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LockFileName is the name of the file recording the exact versions a run installed
+const LockFileName = "bootstrap.lock"
+
+// Lock records the exact resolved version of every tool and language installed by
+// a successful run. The manifest says what was installed; the lock says which version.
+type Lock struct {
+	GeneratedAt    time.Time         `json:"generated_at"`
+	PackageManager string            `json:"package_manager,omitempty"`
+	Tools          map[string]string `json:"tools,omitempty"`
+	Languages      map[string]string `json:"languages,omitempty"`
+}
+
+// NewLock creates an empty lock for the given package manager
+func NewLock(packageManager string) *Lock {
+	return &Lock{
+		PackageManager: packageManager,
+		Tools:          make(map[string]string),
+		Languages:      make(map[string]string),
+	}
+}
+
+// DefaultLockPath returns the default lock file location
+func DefaultLockPath() (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, LockFileName), nil
+}
+
+// LoadLock reads the lock file at path. Unlike the manifest, a missing lock file is an error.
+func LoadLock(path string) (*Lock, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lock file: %w", err)
+	}
+
+	var l Lock
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("failed to parse lock file %s: %w", path, err)
+	}
+	return &l, nil
+}
+
+// Save writes the lock file to path
+func (l *Lock) Save(path string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if l.GeneratedAt.IsZero() {
+		l.GeneratedAt = time.Now()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create lock file directory: %w", err)
+	}
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode lock file: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write lock file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write lock file: %w", err)
+	}
+	return nil
+}
+
+// ToolVersion returns the locked version of a tool
+func (l *Lock) ToolVersion(name string) (string, bool) {
+	version, ok := l.Tools[name]
+	return version, ok && version != ""
+}
+
+// LanguageVersion returns the locked version of a language
+func (l *Lock) LanguageVersion(name string) (string, bool) {
+	version, ok := l.Languages[name]
+	return version, ok && version != ""
+}
@@ -0,0 +1,43 @@
+package manifest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLockRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", LockFileName)
+
+	lock := NewLock("apt")
+	lock.Tools["ripgrep"] = "13.0.0-4"
+	lock.Languages["Python"] = "3.11.2-1"
+	if err := lock.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := LoadLock(path)
+	if err != nil {
+		t.Fatalf("LoadLock() error = %v", err)
+	}
+	if loaded.PackageManager != "apt" || loaded.GeneratedAt.IsZero() {
+		t.Errorf("Unexpected lock header: %+v", loaded)
+	}
+	if v, ok := loaded.ToolVersion("ripgrep"); !ok || v != "13.0.0-4" {
+		t.Errorf("ToolVersion(ripgrep) = %q, %v", v, ok)
+	}
+	if v, ok := loaded.LanguageVersion("Python"); !ok || v != "3.11.2-1" {
+		t.Errorf("LanguageVersion(Python) = %q, %v", v, ok)
+	}
+	if _, ok := loaded.ToolVersion("fd"); ok {
+		t.Error("Expected fd to be missing from the lock")
+	}
+}
+
+func TestLoadLockMissing(t *testing.T) {
+	_, err := LoadLock(filepath.Join(t.TempDir(), LockFileName))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a not-exist error, got %v", err)
+	}
+}
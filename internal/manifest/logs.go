@@ -0,0 +1,42 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LogsDirName is the directory in the state directory holding the output of
+// failed installs
+const LogsDirName = "logs"
+
+// DefaultLogDir returns the default failure log directory (~/.bootstrap-cli/logs)
+func DefaultLogDir() (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, LogsDirName), nil
+}
+
+// WriteFailureLog writes the output an item's failed install captured to
+// <item>-<timestamp>.log in dir and returns its path
+func WriteFailureLog(dir, item string, output []byte, at time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create log directory: %w", err)
+	}
+	// Items such as a dotfiles repository URL are not file names
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r == ' ' {
+			return '-'
+		}
+		return r
+	}, item)
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.log", name, at.Format("20060102-150405")))
+	if err := os.WriteFile(path, output, 0644); err != nil {
+		return "", fmt.Errorf("failed to write failure log: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,25 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteFailureLog(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), LogsDirName)
+	at := time.Date(2026, 5, 4, 13, 2, 1, 0, time.UTC)
+
+	path, err := WriteFailureLog(dir, "https://github.com/me/dotfiles", []byte("E: Unable to locate package\n"), at)
+	if err != nil {
+		t.Fatalf("WriteFailureLog() error = %v", err)
+	}
+	if want := filepath.Join(dir, "https---github.com-me-dotfiles-20260504-130201.log"); path != want {
+		t.Errorf("path = %s, want %s", path, want)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "E: Unable to locate package\n" {
+		t.Errorf("log = %q, %v", data, err)
+	}
+}
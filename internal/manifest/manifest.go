@@ -0,0 +1,120 @@
+// Package manifest records the history of bootstrap-cli runs, so later commands
+// can report and act on what was installed and configured on this machine.
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/version"
+)
+
+// Run describes a single completed bootstrap-cli run
+type Run struct {
+	Timestamp      time.Time `json:"timestamp"`
+	Tools          []string  `json:"tools,omitempty"`
+	Fonts          []string  `json:"fonts,omitempty"`
+	Languages      []string  `json:"languages,omitempty"`
+	Shell          string    `json:"shell,omitempty"`
+	DotfilesRepo   string    `json:"dotfiles_repo,omitempty"`
+	PackageManager string    `json:"package_manager,omitempty"`
+	// CLIVersion is the bootstrap-cli version that made the run
+	CLIVersion string `json:"cli_version,omitempty"`
+	// Provenance maps each tool to the manager it was installed with, or found
+	// installed by when the existing install was kept
+	Provenance map[string]string `json:"provenance,omitempty"`
+}
+
+// Manifest is the ordered history of runs
+type Manifest struct {
+	Runs []Run `json:"runs"`
+}
+
+// mu serialises writers within a process
+var mu sync.Mutex
+
+// StateDir returns the directory bootstrap-cli keeps its state in (~/.bootstrap-cli)
+func StateDir() (string, error) {
+	home, err := system.UserHome()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".bootstrap-cli"), nil
+}
+
+// DefaultPath returns the default manifest location
+func DefaultPath() (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "manifest.json"), nil
+}
+
+// Load reads the manifest at path. A missing file yields an empty manifest.
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Manifest{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// Save writes the manifest to path
+func (m *Manifest) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create manifest directory: %w", err)
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// Last returns the most recent run, or nil if there is none
+func (m *Manifest) Last() *Run {
+	if len(m.Runs) == 0 {
+		return nil
+	}
+	return &m.Runs[len(m.Runs)-1]
+}
+
+// Record appends run to the manifest at path
+func Record(path string, run Run) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	m, err := Load(path)
+	if err != nil {
+		return err
+	}
+	if run.Timestamp.IsZero() {
+		run.Timestamp = time.Now()
+	}
+	if run.CLIVersion == "" {
+		run.CLIVersion = version.Version
+	}
+	m.Runs = append(m.Runs, run)
+	return m.Save(path)
+}
@@ -0,0 +1,43 @@
+package manifest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMissing(t *testing.T) {
+	m, err := Load(filepath.Join(t.TempDir(), "manifest.json"))
+	require.NoError(t, err)
+	assert.Empty(t, m.Runs)
+	assert.Nil(t, m.Last())
+}
+
+func TestRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "manifest.json")
+
+	require.NoError(t, Record(path, Run{Tools: []string{"git", "curl"}}))
+	require.NoError(t, Record(path, Run{Tools: []string{"bat"}, Shell: "zsh"}))
+
+	m, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, m.Runs, 2)
+	assert.Equal(t, []string{"git", "curl"}, m.Runs[0].Tools)
+	assert.False(t, m.Runs[0].Timestamp.IsZero())
+	assert.Equal(t, "zsh", m.Last().Shell)
+}
+
+func TestRecordStampsCLIVersion(t *testing.T) {
+	orig := version.Version
+	version.Version = "v1.2.3"
+	t.Cleanup(func() { version.Version = orig })
+
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	require.NoError(t, Record(path, Run{Tools: []string{"git"}}))
+	m, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.3", m.Last().CLIVersion)
+}
@@ -0,0 +1,187 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// QueueFileName is the file holding the install queue of a run in progress
+const QueueFileName = "queue.json"
+
+// Kinds of queued items
+const (
+	QueueTool     = "tool"
+	QueueFont     = "font"
+	QueueLanguage = "language"
+	QueueDotfiles = "dotfiles"
+	QueueShell    = "shell"
+)
+
+// Queue is the install queue of a run, saved as each item completes so a run cut
+// short by a crash or reboot can be resumed exactly where it stopped. It is
+// removed when the run succeeds.
+type Queue struct {
+	StartedAt      time.Time `json:"started_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	PackageManager string    `json:"package_manager,omitempty"`
+	// Locked runs install exactly the queued versions
+	Locked bool `json:"locked,omitempty"`
+	// LanguageStrategy, VersionManager and PromptStyle are the run's settings,
+	// applied again on resume
+	LanguageStrategy string      `json:"language_strategy,omitempty"`
+	VersionManager   string      `json:"version_manager,omitempty"`
+	PromptStyle      string      `json:"prompt_style,omitempty"`
+	Items            []QueueItem `json:"items"`
+}
+
+// QueueItem is one tool, font, language, dotfiles repository or shell in the queue
+type QueueItem struct {
+	Kind string `json:"kind"`
+	// Name is the catalog name, or the repository URL for dotfiles
+	Name string `json:"name"`
+	// Package is the resolved package name
+	Package string `json:"package,omitempty"`
+	// Version is the pinned version, when the run installs a specific one
+	Version string `json:"version,omitempty"`
+	// Manager is the package manager chosen for the item
+	Manager string `json:"manager,omitempty"`
+	// Method is how the item is installed, e.g. package_manager or custom
+	Method string `json:"method,omitempty"`
+	Done   bool   `json:"done,omitempty"`
+}
+
+// DefaultQueuePath returns the default queue.json location
+func DefaultQueuePath() (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, QueueFileName), nil
+}
+
+// LoadQueue reads the queue at path. A missing file yields nil: no run was left
+// incomplete.
+func LoadQueue(path string) (*Queue, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read install queue: %w", err)
+	}
+
+	var q Queue
+	if err := json.Unmarshal(data, &q); err != nil {
+		return nil, fmt.Errorf("failed to parse install queue %s: %w", path, err)
+	}
+	return &q, nil
+}
+
+// Save writes the queue to path, replacing the file atomically so it is never
+// left half-written, and flushes it to disk so it survives a power loss
+func (q *Queue) Save(path string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	q.UpdatedAt = time.Now()
+	if q.StartedAt.IsZero() {
+		q.StartedAt = q.UpdatedAt
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create install queue directory: %w", err)
+	}
+	data, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode install queue: %w", err)
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to write install queue: %w", err)
+	}
+	_, err = f.Write(append(data, '\n'))
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write install queue: %w", err)
+	}
+	return nil
+}
+
+// RemoveQueue deletes the queue at path; a missing file is not an error
+func RemoveQueue(path string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove install queue: %w", err)
+	}
+	return nil
+}
+
+// Remaining returns the items that have not completed, in queue order
+func (q *Queue) Remaining() []QueueItem {
+	var rest []QueueItem
+	for _, item := range q.Items {
+		if !item.Done {
+			rest = append(rest, item)
+		}
+	}
+	return rest
+}
+
+// SetDone marks the item of kind named name as completed, or as pending again
+// when done is false (e.g. after it was rolled back). It reports whether the
+// item is queued.
+func (q *Queue) SetDone(kind, name string, done bool) bool {
+	for i := range q.Items {
+		if q.Items[i].Kind == kind && q.Items[i].Name == name {
+			q.Items[i].Done = done
+			return true
+		}
+	}
+	return false
+}
+
+// Lock returns the queued versions as a lock, for resuming a locked run
+func (q *Queue) Lock() *Lock {
+	lock := NewLock(q.PackageManager)
+	for _, item := range q.Items {
+		if item.Version == "" {
+			continue
+		}
+		switch item.Kind {
+		case QueueTool:
+			lock.Tools[item.Name] = item.Version
+		case QueueLanguage:
+			lock.Languages[item.Name] = item.Version
+		}
+	}
+	return lock
+}
+
+// ToolManagers returns base with each queued tool's package manager added, so a
+// resumed run installs tools with the managers the original run chose
+func (q *Queue) ToolManagers(base map[string]string) map[string]string {
+	managers := make(map[string]string, len(base))
+	for name, manager := range base {
+		managers[name] = manager
+	}
+	for _, item := range q.Items {
+		if item.Kind == QueueTool && item.Manager != "" {
+			managers[item.Name] = item.Manager
+		}
+	}
+	return managers
+}
@@ -0,0 +1,58 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestQueueRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", QueueFileName)
+
+	q := &Queue{PackageManager: "apt", Locked: true, Items: []QueueItem{
+		{Kind: QueueTool, Name: "ripgrep", Package: "ripgrep", Version: "13.0.0-4", Manager: "apt", Method: "package_manager"},
+		{Kind: QueueLanguage, Name: "Python", Package: "python3", Version: "3.11.2-1", Manager: "apt", Method: "system"},
+		{Kind: QueueShell, Name: "zsh"},
+	}}
+	if !q.SetDone(QueueTool, "ripgrep", true) || q.SetDone(QueueFont, "ripgrep", true) {
+		t.Fatal("SetDone() should match on kind and name")
+	}
+	if err := q.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Error("Expected no temporary file to be left behind")
+	}
+
+	loaded, err := LoadQueue(path)
+	if err != nil {
+		t.Fatalf("LoadQueue() error = %v", err)
+	}
+	if loaded.StartedAt.IsZero() || loaded.UpdatedAt.IsZero() || !loaded.Locked {
+		t.Errorf("Unexpected queue header: %+v", loaded)
+	}
+	rest := loaded.Remaining()
+	if len(rest) != 2 || rest[0].Name != "Python" || rest[1].Name != "zsh" {
+		t.Errorf("Remaining() = %+v, want Python then zsh", rest)
+	}
+	lock := loaded.Lock()
+	if v, ok := lock.ToolVersion("ripgrep"); !ok || v != "13.0.0-4" {
+		t.Errorf("Lock().ToolVersion(ripgrep) = %q, %v", v, ok)
+	}
+	if v, ok := lock.LanguageVersion("Python"); !ok || v != "3.11.2-1" {
+		t.Errorf("Lock().LanguageVersion(Python) = %q, %v", v, ok)
+	}
+	if managers := loaded.ToolManagers(map[string]string{"bat": "brew"}); managers["bat"] != "brew" || managers["ripgrep"] != "apt" {
+		t.Errorf("ToolManagers() = %v", managers)
+	}
+
+	if err := RemoveQueue(path); err != nil {
+		t.Fatalf("RemoveQueue() error = %v", err)
+	}
+	if q, err := LoadQueue(path); q != nil || err != nil {
+		t.Errorf("LoadQueue() after removal = %+v, %v; want nil, nil", q, err)
+	}
+	if err := RemoveQueue(path); err != nil {
+		t.Errorf("RemoveQueue() of a missing queue error = %v", err)
+	}
+}
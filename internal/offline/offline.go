@@ -0,0 +1,202 @@
+// Package offline prepares air-gapped runs: it works out which downloads a
+// selection needs, pre-downloads them into the download cache, and reports
+// which items an offline run can install from the cache and which still need
+// the network
+package offline
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cache"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/release"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+)
+
+// Kinds of selected items
+const (
+	KindTool      = "tool"
+	KindFont      = "font"
+	KindLanguage  = "language"
+	KindFramework = "shell framework"
+)
+
+// Artifact is a file an item downloads while it is installed
+type Artifact struct {
+	// URL is the download; it is empty for a release, whose URL is only known
+	// once its tag is (see Resolve)
+	URL     string
+	Version string
+	// Checksum is the pinned SHA-256, if any
+	Checksum string
+	// Release is set for GitHub release assets, whose URL depends on the tag
+	// "latest" resolves to and on the platform
+	Release *release.Spec
+}
+
+// Item is a selected tool, font, language or shell framework and what it downloads
+type Item struct {
+	Kind      string
+	Name      string
+	Artifacts []Artifact
+	// Network says why the item needs the network even with its artifacts
+	// cached, e.g. a package manager install; empty when the cache is enough
+	Network string
+}
+
+// Selection is what to pre-download or check
+type Selection struct {
+	// Tools may include groups, which are expanded from Catalog
+	Tools      []*pipeline.Tool
+	Catalog    []*pipeline.Tool
+	Fonts      []*interfaces.Font
+	Languages  []*interfaces.Language
+	Frameworks []string
+	Platform   *pipeline.Platform
+}
+
+// Items lists the selected items with their downloads
+func Items(sel Selection) ([]Item, error) {
+	tools, _, err := pipeline.ExpandGroups(sel.Tools, sel.Catalog)
+	if err != nil {
+		return nil, err
+	}
+	manager := "the package manager"
+	if sel.Platform != nil && sel.Platform.PackageManager != "" {
+		manager = sel.Platform.PackageManager
+	}
+
+	var items []Item
+	for _, tool := range tools {
+		item := Item{Kind: KindTool, Name: tool.Name}
+		switch {
+		case tool.Release != nil:
+			// Offline, a tool with a release is installed from it (see pipeline)
+			item.Artifacts = []Artifact{{Version: tool.Version, Checksum: tool.Release.Checksum, Release: tool.Release}}
+		case len(tool.Install.CustomInstall) > 0:
+			item.Network = "runs its own install commands"
+		default:
+			item.Network = fmt.Sprintf("installed with %s", manager)
+		}
+		items = append(items, item)
+	}
+	for _, font := range sel.Fonts {
+		item := Item{Kind: KindFont, Name: font.Name}
+		if font.Source != "" {
+			item.Artifacts = []Artifact{{URL: font.Source}}
+		} else {
+			item.Network = "has no source archive to download"
+		}
+		items = append(items, item)
+	}
+	for _, lang := range sel.Languages {
+		items = append(items, Item{Kind: KindLanguage, Name: lang.Name, Network: fmt.Sprintf("installed with %s", manager)})
+	}
+	for _, name := range sel.Frameworks {
+		item := Item{Kind: KindFramework, Name: name, Network: "cloned from its git repository"}
+		if url, ok := shell.FrameworkScript(name); ok {
+			item.Artifacts = []Artifact{{URL: url}}
+			item.Network = "its install script clones the git repository"
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Resolve returns the URL and cache version of an artifact on a platform. A
+// release's "latest" is the tag recorded in downloads; ok is false when none is.
+func (a Artifact) Resolve(downloads *cache.Cache, goos, goarch string) (url, version string, ok bool) {
+	if a.Release == nil {
+		return a.URL, a.Version, true
+	}
+	tag := a.Version
+	if tag == "" || tag == release.Latest {
+		if tag, ok = downloads.Tag(a.Release.Repo); !ok {
+			return "", "", false
+		}
+	}
+	return a.Release.URL(tag, goos, goarch), tag, true
+}
+
+// Cached reports whether every artifact of the item is in downloads
+func (it Item) Cached(downloads *cache.Cache, goos, goarch string) bool {
+	for _, a := range it.Artifacts {
+		url, version, ok := a.Resolve(downloads, goos, goarch)
+		checksum := a.Checksum
+		if a.Release != nil && (a.Version == "" || a.Version == release.Latest) {
+			checksum = ""
+		}
+		if !ok || !downloads.Has(url, version, checksum) {
+			return false
+		}
+		// Without a pinned checksum the release's checksums asset is needed too
+		if a.Release != nil && checksum == "" && a.Release.Checksums != "" && !downloads.Has(a.Release.ChecksumsURL(version, goos, goarch), version, "") {
+			return false
+		}
+	}
+	return true
+}
+
+// Warm downloads the artifacts of items into the cache of releases for the
+// platform, resolving "latest" releases to their current tag. Every artifact is
+// attempted; the errors are returned per item name.
+func Warm(items []Item, releases *release.Installer, goos, goarch string) map[string]error {
+	failed := make(map[string]error)
+	for _, item := range items {
+		for _, a := range item.Artifacts {
+			if err := warm(item.Name, a, releases, goos, goarch); err != nil {
+				failed[item.Name] = err
+				break
+			}
+		}
+	}
+	return failed
+}
+
+func warm(name string, a Artifact, releases *release.Installer, goos, goarch string) error {
+	dir, err := os.MkdirTemp("", "bootstrap-warm-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if a.Release != nil {
+		_, _, err := releases.Download(name, a.Release, a.Version, goos, goarch, dir)
+		return err
+	}
+	if err := releases.Downloads.Fetch(a.URL, a.Version, a.Checksum, filepath.Join(dir, path.Base(a.URL))); err != nil {
+		return fmt.Errorf("failed to download %s: %w", a.URL, err)
+	}
+	return nil
+}
+
+// Report splits items into those an offline run installs from the cache and
+// those that still need the network, with the reason for each
+func Report(items []Item, downloads *cache.Cache, goos, goarch string) (cached []Item, network []string) {
+	for _, item := range items {
+		label := item.Kind + " " + item.Name
+		switch {
+		case item.Network != "":
+			network = append(network, fmt.Sprintf("%s: %s", label, item.Network))
+		case !item.Cached(downloads, goos, goarch):
+			network = append(network, fmt.Sprintf("%s: not in the download cache", label))
+		default:
+			cached = append(cached, item)
+		}
+	}
+	return cached, network
+}
+
+// Names joins the names of items for a one-line summary
+func Names(items []Item) string {
+	names := make([]string, len(items))
+	for i, item := range items {
+		names[i] = item.Name
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,70 @@
+package offline
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cache"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/release"
+)
+
+func TestWarmThenReportOffline(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/lsd-rs/lsd/releases/latest", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"tag_name": "v1.1.5"}`))
+	})
+	mux.HandleFunc("/lsd-rs/lsd/releases/download/v1.1.5/lsd", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("#!/bin/sh\necho lsd 1.1.5\n"))
+	})
+	mux.HandleFunc("/fonts/JetBrainsMono.zip", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("font archive"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	t.Setenv(cache.AllowHostsEnvVar, "127.0.0.1")
+	t.Setenv(cache.GitHubMirrorEnvVar, srv.URL)
+	t.Setenv(cache.DisableEnvVar, "")
+
+	lsd := pipeline.NewTool("lsd", pipeline.CategoryDevelopment)
+	lsd.Version = release.Latest
+	lsd.Release = &release.Spec{Repo: "lsd-rs/lsd", Asset: "lsd"}
+	fd := pipeline.NewTool("fd", pipeline.CategoryDevelopment)
+	sel := Selection{
+		Tools:     []*pipeline.Tool{lsd, fd},
+		Fonts:     []*interfaces.Font{{Name: "JetBrains Mono", Source: srv.URL + "/fonts/JetBrainsMono.zip"}},
+		Languages: []*interfaces.Language{{Name: "Python"}},
+		Platform:  &pipeline.Platform{OS: "linux", Arch: "amd64", PackageManager: "apt"},
+	}
+	items, err := Items(sel)
+	if err != nil {
+		t.Fatalf("Items() error = %v", err)
+	}
+	releases := &release.Installer{Downloads: cache.New(t.TempDir()), APIURL: srv.URL, BinDir: t.TempDir()}
+
+	if cached, _ := Report(items, releases.Downloads, "linux", "amd64"); len(cached) != 0 {
+		t.Errorf("Expected nothing cached before warming, got %s", Names(cached))
+	}
+	if failed := Warm(items, releases, "linux", "amd64"); len(failed) != 0 {
+		t.Fatalf("Warm() failed: %v", failed)
+	}
+
+	srv.Close()
+	t.Setenv(cache.OfflineEnvVar, "1")
+	cached, network := Report(items, releases.Downloads, "linux", "amd64")
+	if Names(cached) != "lsd, JetBrains Mono" {
+		t.Errorf("Expected lsd and the font to be cached, got %s", Names(cached))
+	}
+	if got := strings.Join(network, "\n"); !strings.Contains(got, "tool fd: installed with apt") || !strings.Contains(got, "language Python: installed with apt") {
+		t.Errorf("Expected fd and Python to need the network, got:\n%s", got)
+	}
+
+	// The server is gone, so the install can only come from the cache
+	tag, _, err := releases.Install("lsd", lsd.Release, release.Latest, "linux", "amd64")
+	if err != nil || tag != "v1.1.5" {
+		t.Errorf("Expected the cached lsd v1.1.5 to install offline, got %s, %v", tag, err)
+	}
+}
@@ -4,32 +4,128 @@
 package detector
 
 import (
+	"fmt"
+	"os"
 	"os/exec"
+	"strings"
 
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
 )
 
-// DetectPackageManager determines the system's package manager type
-func DetectPackageManager() (interfaces.PackageManagerType, error) {
-	// Check for apt (Debian/Ubuntu)
-	if _, err := exec.LookPath("apt"); err == nil {
-		return interfaces.APT, nil
+// DefaultPriority is the order package managers are tried in when no preference
+// is set; on Windows winget comes before choco, which manager_priority can swap
+var DefaultPriority = []interfaces.PackageManagerType{
+	interfaces.APT,
+	interfaces.DNF,
+	interfaces.Pacman,
+	interfaces.Zypper,
+	interfaces.Homebrew,
+	interfaces.Winget,
+	interfaces.Chocolatey,
+}
+
+// supported lists every package manager type ParseType accepts
+var supported = []interfaces.PackageManagerType{
+	interfaces.APT,
+	interfaces.DNF,
+	interfaces.Pacman,
+	interfaces.Zypper,
+	interfaces.Homebrew,
+	interfaces.Pkg,
+	interfaces.Winget,
+	interfaces.Chocolatey,
+}
+
+// lookPath and getenv are swapped out in tests
+var (
+	lookPath = exec.LookPath
+	getenv   = os.Getenv
+)
+
+// ParseType returns the package manager type for name ("homebrew" is accepted
+// for brew and "chocolatey" for choco)
+func ParseType(name string) (interfaces.PackageManagerType, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch name {
+	case "homebrew":
+		name = string(interfaces.Homebrew)
+	case "chocolatey":
+		name = string(interfaces.Chocolatey)
+	}
+	for _, t := range supported {
+		if string(t) == name {
+			return t, nil
+		}
 	}
+	return "", fmt.Errorf("unsupported package manager %q", name)
+}
 
-	// Check for dnf (Fedora)
-	if _, err := exec.LookPath("dnf"); err == nil {
-		return interfaces.DNF, nil
+// SplitPriority parses a comma-separated list of package manager names into
+// the supported managers, in order, and the names it does not know (e.g. snap)
+func SplitPriority(list string) (priority, unknown []string) {
+	for _, name := range strings.Split(list, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		t, err := ParseType(name)
+		if err != nil {
+			unknown = append(unknown, strings.TrimSpace(name))
+			continue
+		}
+		priority = append(priority, string(t))
 	}
+	return priority, unknown
+}
 
-	// Check for pacman (Arch)
-	if _, err := exec.LookPath("pacman"); err == nil {
-		return interfaces.Pacman, nil
+// ParsePriority parses a comma-separated list of package manager names
+func ParsePriority(list string) ([]string, error) {
+	var priority []string
+	for _, name := range strings.Split(list, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		t, err := ParseType(name)
+		if err != nil {
+			return nil, err
+		}
+		priority = append(priority, string(t))
 	}
+	return priority, nil
+}
 
-	// Check for Homebrew
-	if _, err := exec.LookPath("brew"); err == nil {
-		return interfaces.Homebrew, nil
+// IsAvailable reports whether the package manager's binary is on PATH
+func IsAvailable(t interfaces.PackageManagerType) bool {
+	_, err := lookPath(string(t))
+	return err == nil
+}
+
+// DetectPackageManager determines the system's package manager type
+func DetectPackageManager() (interfaces.PackageManagerType, error) {
+	return DetectWithPriority(nil)
+}
+
+// DetectWithPriority returns the first available package manager in priority,
+// then in DefaultPriority for managers the list leaves out. On Termux, pkg is
+// tried before the defaults since its apt is not the system apt.
+func DetectWithPriority(priority []string) (interfaces.PackageManagerType, error) {
+	order := make([]interfaces.PackageManagerType, 0, len(priority)+len(DefaultPriority)+1)
+	for _, name := range priority {
+		t, err := ParseType(name)
+		if err != nil {
+			return "", fmt.Errorf("invalid manager priority: %w", err)
+		}
+		order = append(order, t)
+	}
+	if system.IsTermux(getenv) {
+		order = append(order, interfaces.Pkg)
 	}
+	order = append(order, DefaultPriority...)
 
+	for _, t := range order {
+		if IsAvailable(t) {
+			return t, nil
+		}
+	}
 	return "", nil
-} 
\ No newline at end of file
+}
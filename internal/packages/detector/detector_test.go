@@ -0,0 +1,112 @@
+package detector
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+func stubLookPath(t *testing.T, available ...string) {
+	t.Helper()
+	orig := lookPath
+	t.Cleanup(func() { lookPath = orig })
+	lookPath = func(file string) (string, error) {
+		for _, name := range available {
+			if name == file {
+				return "/usr/bin/" + file, nil
+			}
+		}
+		return "", fmt.Errorf("%s not found", file)
+	}
+}
+
+func TestDetectWithPriority(t *testing.T) {
+	stubLookPath(t, "apt", "brew")
+
+	tests := []struct {
+		name     string
+		priority []string
+		want     interfaces.PackageManagerType
+		wantErr  bool
+	}{
+		{name: "default order", want: interfaces.APT},
+		{name: "preferred manager wins", priority: []string{"brew", "apt"}, want: interfaces.Homebrew},
+		{name: "unavailable preference falls through", priority: []string{"pacman", "homebrew"}, want: interfaces.Homebrew},
+		{name: "unlisted managers are still tried", priority: []string{"dnf"}, want: interfaces.APT},
+		{name: "unsupported manager", priority: []string{"snap"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectWithPriority(tt.priority)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DetectWithPriority() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DetectWithPriority() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectZypper(t *testing.T) {
+	stubLookPath(t, "zypper", "brew")
+
+	got, err := DetectWithPriority(nil)
+	if err != nil || got != interfaces.Zypper {
+		t.Errorf("DetectWithPriority() = %q, %v; want zypper", got, err)
+	}
+	if got, err := ParseType("Zypper"); err != nil || got != interfaces.Zypper {
+		t.Errorf("ParseType() = %q, %v; want zypper", got, err)
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	got, err := ParsePriority("Homebrew, apt,,dnf")
+	if err != nil {
+		t.Fatalf("ParsePriority() error = %v", err)
+	}
+	want := []string{"brew", "apt", "dnf"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("ParsePriority() = %v, want %v", got, want)
+	}
+	if _, err := ParsePriority("apt,snap"); err == nil {
+		t.Errorf("Expected an error for an unsupported manager")
+	}
+
+	priority, unknown := SplitPriority("brew, apt, snap")
+	if fmt.Sprint(priority) != "[brew apt]" || fmt.Sprint(unknown) != "[snap]" {
+		t.Errorf("SplitPriority() = %v, %v; want [brew apt], [snap]", priority, unknown)
+	}
+}
+
+func TestDetectWithPriority_Termux(t *testing.T) {
+	stubLookPath(t, "apt", "pkg")
+	orig := getenv
+	t.Cleanup(func() { getenv = orig })
+	getenv = func(key string) string {
+		if key == "PREFIX" {
+			return "/data/data/com.termux/files/usr"
+		}
+		return ""
+	}
+
+	got, err := DetectWithPriority(nil)
+	if err != nil || got != interfaces.Pkg {
+		t.Errorf("DetectWithPriority() on Termux = %q, %v; want pkg", got, err)
+	}
+	if got, _ := DetectWithPriority([]string{"apt"}); got != interfaces.APT {
+		t.Errorf("Expected an explicit preference to win on Termux, got %q", got)
+	}
+}
+
+func TestDetectWithPriority_Windows(t *testing.T) {
+	stubLookPath(t, "winget", "choco")
+
+	if got, _ := DetectWithPriority(nil); got != interfaces.Winget {
+		t.Errorf("DetectWithPriority() = %q, want winget before choco", got)
+	}
+	if got, _ := DetectWithPriority([]string{"chocolatey"}); got != interfaces.Chocolatey {
+		t.Errorf("DetectWithPriority(chocolatey) = %q, want choco", got)
+	}
+}
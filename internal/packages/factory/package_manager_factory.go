@@ -2,104 +2,162 @@ package factory
 
 import (
 	"fmt"
+	"os"
 	"time"
 
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cmdexec"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/packages/detector"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/packages/implementations"
 )
 
+// ManagerPriorityEnvVar holds a comma-separated package manager preference order
+const ManagerPriorityEnvVar = "BOOTSTRAP_CLI_MANAGER_PRIORITY"
+
 // PackageManagerFactory creates package managers based on system type
 type PackageManagerFactory struct {
 	maxRetries int
 	retryDelay time.Duration
+	// maxRetryDelay caps the exponential backoff between retries
+	maxRetryDelay time.Duration
+	priority   []string
+	// priorityErr is reported by GetPackageManager when the env preference is invalid
+	priorityErr error
 }
 
-// NewPackageManagerFactory creates a new package manager factory
+// NewPackageManagerFactory creates a new package manager factory. The manager
+// preference order is read from BOOTSTRAP_CLI_MANAGER_PRIORITY when set.
 func NewPackageManagerFactory() *PackageManagerFactory {
-	return &PackageManagerFactory{
-		maxRetries: 3,
-		retryDelay: 5 * time.Second,
+	f := &PackageManagerFactory{
+		maxRetries:    cmdexec.DefaultRetryPolicy.Attempts,
+		retryDelay:    cmdexec.DefaultRetryPolicy.InitialDelay,
+		maxRetryDelay: cmdexec.DefaultRetryPolicy.MaxDelay,
+	}
+	if list := os.Getenv(ManagerPriorityEnvVar); list != "" {
+		f.priority, f.priorityErr = detector.ParsePriority(list)
 	}
+	return f
 }
 
-// SetRetryConfig configures retry behavior
+// SetPriority sets the package manager preference order; the first available wins
+func (f *PackageManagerFactory) SetPriority(priority []string) {
+	f.priority = priority
+	f.priorityErr = nil
+}
+
+// SetRetryConfig configures retry behavior: maxRetries attempts in all, the
+// first retry after retryDelay and each later one waiting twice as long
 func (f *PackageManagerFactory) SetRetryConfig(maxRetries int, retryDelay time.Duration) {
 	f.maxRetries = maxRetries
 	f.retryDelay = retryDelay
 }
 
+// SetRetryPolicy configures retry behavior from policy; its multiplier is
+// always 2
+func (f *PackageManagerFactory) SetRetryPolicy(policy cmdexec.RetryPolicy) {
+	f.maxRetries = policy.Attempts
+	f.retryDelay = policy.InitialDelay
+	f.maxRetryDelay = policy.MaxDelay
+}
+
 // GetPackageManager returns the appropriate package manager for the current system
 func (f *PackageManagerFactory) GetPackageManager() (interfaces.PackageManager, error) {
-	pmType, err := detector.DetectPackageManager()
+	if f.priorityErr != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ManagerPriorityEnvVar, f.priorityErr)
+	}
+	pmType, err := detector.DetectWithPriority(f.priority)
 	if err != nil {
 		return nil, fmt.Errorf("failed to detect package manager: %w", err)
 	}
 
-	var pm interfaces.PackageManager
-	var pmErr error
+	pm, err := newPackageManager(pmType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create package manager: %w", err)
+	}
+
+	return f.wrap(pm), nil
+}
+
+// wrap adds the factory's retry behaviour to pm
+func (f *PackageManagerFactory) wrap(pm interfaces.PackageManager) interfaces.PackageManager {
+	return &retryPackageManager{
+		PackageManager: pm,
+		maxRetries:    f.maxRetries,
+		retryDelay:    f.retryDelay,
+		maxRetryDelay: f.maxRetryDelay,
+	}
+}
 
+// newPackageManager creates the implementation for pmType
+func newPackageManager(pmType interfaces.PackageManagerType) (interfaces.PackageManager, error) {
 	switch pmType {
 	case interfaces.APT:
-		pm, pmErr = implementations.NewAptPackageManager()
+		return implementations.NewAptPackageManager()
 	case interfaces.DNF:
-		pm, pmErr = implementations.NewDnfPackageManager()
+		return implementations.NewDnfPackageManager()
 	case interfaces.Pacman:
-		pm, pmErr = implementations.NewPacmanPackageManager()
+		return implementations.NewPacmanPackageManager()
+	case interfaces.Zypper:
+		return implementations.NewZypperPackageManager()
 	case interfaces.Homebrew:
-		pm, pmErr = implementations.NewHomebrewPackageManager()
+		return implementations.NewHomebrewPackageManager()
+	case interfaces.Pkg:
+		return implementations.NewPkgPackageManager()
+	case interfaces.Winget:
+		return implementations.NewWingetPackageManager()
+	case interfaces.Chocolatey:
+		return implementations.NewChocoPackageManager()
 	default:
 		return nil, fmt.Errorf("unsupported package manager type: %s", pmType)
 	}
-
-	if pmErr != nil {
-		return nil, fmt.Errorf("failed to create package manager: %w", pmErr)
-	}
-
-	return &retryPackageManager{
-		PackageManager: pm,
-		maxRetries:    f.maxRetries,
-		retryDelay:    f.retryDelay,
-	}, nil
 }
 
-// retryPackageManager wraps a PackageManager with retry logic
+// retryPackageManager wraps a PackageManager with retry logic, backing off
+// exponentially and giving up at once on failures a retry cannot fix
 type retryPackageManager struct {
 	interfaces.PackageManager
-	maxRetries int
-	retryDelay time.Duration
+	maxRetries    int
+	retryDelay    time.Duration
+	maxRetryDelay time.Duration
+}
+
+// policy returns the retry policy of r
+func (r *retryPackageManager) policy() cmdexec.RetryPolicy {
+	return cmdexec.RetryPolicy{
+		Attempts:     r.maxRetries,
+		InitialDelay: r.retryDelay,
+		MaxDelay:     r.maxRetryDelay,
+		Multiplier:   2,
+	}
 }
 
 // Install installs a package with retries
 func (r *retryPackageManager) Install(packageName string) error {
-	var lastErr error
-	for i := 0; i < r.maxRetries; i++ {
-		if err := r.PackageManager.Install(packageName); err != nil {
-			lastErr = err
-			if i < r.maxRetries-1 {
-				time.Sleep(r.retryDelay)
-				continue
-			}
-		} else {
-			return nil
-		}
+	attempts := 0
+	err := r.policy().Do(func() error {
+		attempts++
+		return r.PackageManager.Install(packageName)
+	}, nil)
+	if err != nil && attempts > 1 {
+		return fmt.Errorf("failed to install package after %d retries: %w", attempts, err)
 	}
-	return fmt.Errorf("failed to install package after %d retries: %w", r.maxRetries, lastErr)
+	return err
 }
 
-// Remove with retry logic
-func (r *retryPackageManager) Remove(pkg string) error {
-	var lastErr error
-	for i := 0; i < r.maxRetries; i++ {
-		if err := r.PackageManager.Uninstall(pkg); err != nil {
-			lastErr = err
-			if i < r.maxRetries-1 {
-				time.Sleep(r.retryDelay)
-				continue
-			}
-		} else {
-			return nil
-		}
+// Uninstall removes a package with retries
+func (r *retryPackageManager) Uninstall(pkg string) error {
+	attempts := 0
+	err := r.policy().Do(func() error {
+		attempts++
+		return r.PackageManager.Uninstall(pkg)
+	}, nil)
+	if err != nil && attempts > 1 {
+		return fmt.Errorf("failed to remove package after %d retries: %w", attempts, err)
 	}
-	return fmt.Errorf("failed to remove package after %d retries: %w", r.maxRetries, lastErr)
-} 
\ No newline at end of file
+	return err
+}
+
+// Remove removes a package with retries
+func (r *retryPackageManager) Remove(pkg string) error {
+	return r.Uninstall(pkg)
+}
@@ -71,7 +71,7 @@ func TestNewPackageManagerFactory(t *testing.T) {
 				assert.NotNil(t, pm, "GetPackageManager() returned nil PM unexpectedly")
 				if pm != nil {
 					// Optional: Check if the returned name is one of the known valid types
-					assert.Contains(t, []string{"apt", "dnf", "pacman", "brew"}, pm.GetName(), "Returned PM has unexpected name")
+					assert.Contains(t, []string{"apt", "dnf", "pacman", "zypper", "brew"}, pm.GetName(), "Returned PM has unexpected name")
 				}
 			}
 		})
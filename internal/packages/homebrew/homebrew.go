@@ -0,0 +1,157 @@
+// Package homebrew installs Homebrew on a machine that has no package manager
+// yet, such as a fresh Mac, with the official install script
+package homebrew
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cache"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+)
+
+// InstallScript is Homebrew's official install script
+const InstallScript = "https://raw.githubusercontent.com/Homebrew/install/HEAD/install.sh"
+
+// lookPath is swapped out in tests
+var lookPath = exec.LookPath
+
+// Candidates returns where brew is installed when it is not on PATH: under
+// the default prefix for goos/goarch (/opt/homebrew on Apple silicon,
+// /usr/local on Intel Macs), and on Linux also under ~/.linuxbrew
+func Candidates(goos, goarch, home string) []string {
+	paths := []string{filepath.Join(system.HomebrewPrefix(goos, goarch), "bin", "brew")}
+	if goos == "linux" {
+		paths = append(paths, filepath.Join(home, ".linuxbrew", "bin", "brew"))
+	}
+	return paths
+}
+
+// Find returns the brew executable, on PATH or at one of its Candidates
+func Find(goos, goarch, home string) (string, bool) {
+	if path, err := lookPath("brew"); err == nil {
+		return path, true
+	}
+	for _, path := range Candidates(goos, goarch, home) {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// OnPath reports whether brew is found on PATH, so package manager detection
+// sees it
+func OnPath() bool {
+	_, err := lookPath("brew")
+	return err == nil
+}
+
+// Bootstrap installs Homebrew and sets it up for this run and later shells
+type Bootstrap struct {
+	GOOS, GOARCH string
+	Home         string
+	// Shell is the shell whose rc file gets the shellenv block; empty skips it
+	Shell string
+	// NonInteractive runs the install script with NONINTERACTIVE=1, which
+	// skips its confirmation prompt, for unattended runs
+	NonInteractive bool
+	RCWriter       *shell.RCWriter
+	Stdin          io.Reader
+	Stdout, Stderr io.Writer
+
+	// fetch downloads the install script; swapped out in tests
+	fetch func(url string) (string, func(), error)
+}
+
+// Install runs the Homebrew install script, checks brew works and sets it up
+// (see Setup). It returns the brew executable.
+func (b *Bootstrap) Install() (string, error) {
+	if err := cache.CheckURL(InstallScript); err != nil {
+		return "", err
+	}
+	fetch := b.fetch
+	if fetch == nil {
+		fetch = fetchScript
+	}
+	script, cleanup, err := fetch(InstallScript)
+	if err != nil {
+		return "", fmt.Errorf("failed to download the Homebrew install script: %w", err)
+	}
+	defer cleanup()
+
+	cmd := exec.Command("/bin/bash", script)
+	cmd.Env = os.Environ()
+	if b.NonInteractive {
+		cmd.Env = append(cmd.Env, "NONINTERACTIVE=1")
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = b.Stdin, b.Stdout, b.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("the Homebrew install script failed: %w", err)
+	}
+
+	brew, ok := Find(b.GOOS, b.GOARCH, b.Home)
+	if !ok {
+		return "", fmt.Errorf("brew was not found in %s after the Homebrew install", strings.Join(Candidates(b.GOOS, b.GOARCH, b.Home), " or "))
+	}
+	if err := Verify(brew); err != nil {
+		return "", err
+	}
+	return brew, b.Setup(brew)
+}
+
+// Verify checks the brew executable runs
+func Verify(brew string) error {
+	out, err := exec.Command(brew, "--version").Output()
+	if err != nil {
+		return fmt.Errorf("failed to run %s --version: %w", brew, err)
+	}
+	if !strings.HasPrefix(strings.TrimSpace(string(out)), "Homebrew") {
+		return fmt.Errorf("%s --version printed %q, not a Homebrew version", brew, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Setup puts brew on this process's PATH, so detection and the installs after
+// it find it, and loads its shellenv from a managed block in the rc file of
+// Shell
+func (b *Bootstrap) Setup(brew string) error {
+	Activate(brew)
+	if b.Shell == "" || b.RCWriter == nil {
+		return nil
+	}
+	if err := shell.EnsureBrewShellenv(b.Home, b.Shell, brew, b.RCWriter); err != nil {
+		return fmt.Errorf("failed to add Homebrew to the shell rc file: %w", err)
+	}
+	return nil
+}
+
+// Activate sets this process's environment the way brew shellenv does for the
+// brew executable at brew
+func Activate(brew string) {
+	prefix := filepath.Dir(filepath.Dir(brew))
+	path := []string{filepath.Join(prefix, "bin"), filepath.Join(prefix, "sbin")}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir != path[0] && dir != path[1] {
+			path = append(path, dir)
+		}
+	}
+	os.Setenv("PATH", strings.Join(path, string(os.PathListSeparator)))
+	os.Setenv("HOMEBREW_PREFIX", prefix)
+}
+
+// fetchScript downloads the install script to a temporary file; it tracks
+// HEAD, so it is never cached
+func fetchScript(url string) (string, func(), error) {
+	downloads, err := cache.NewDefault()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to open download cache: %w", err)
+	}
+	downloads.SetEnabled(false)
+	return downloads.FetchScript(url, "", "")
+}
@@ -0,0 +1,135 @@
+package homebrew
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+)
+
+func TestFind(t *testing.T) {
+	home := t.TempDir()
+	noBrewOnPath(t)
+
+	if exists(Candidates("linux", "amd64", home)[0]) {
+		t.Skip("Homebrew is installed in its default prefix")
+	}
+	if _, ok := Find("linux", "amd64", home); ok {
+		t.Fatal("Expected no brew in an empty home")
+	}
+	brew := filepath.Join(home, ".linuxbrew", "bin", "brew")
+	writeBrew(t, brew)
+	if got, ok := Find("linux", "amd64", home); !ok || got != brew {
+		t.Errorf("Find() = %s, %v, want %s", got, ok, brew)
+	}
+
+	if got := Candidates("darwin", "arm64", home); got[0] != "/opt/homebrew/bin/brew" || len(got) != 1 {
+		t.Errorf("Candidates(darwin/arm64) = %v", got)
+	}
+	if got := Candidates("darwin", "amd64", home); got[0] != "/usr/local/bin/brew" {
+		t.Errorf("Candidates(darwin/amd64) = %v", got)
+	}
+}
+
+func TestInstall(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("PATH", os.Getenv("PATH"))
+	t.Setenv("HOMEBREW_PREFIX", "")
+	t.Setenv(shell.DryRunEnvVar, "")
+	noBrewOnPath(t)
+	if exists(Candidates("linux", "amd64", home)[0]) {
+		t.Skip("Homebrew is installed in its default prefix")
+	}
+
+	// The fake install script records NONINTERACTIVE and installs a brew
+	brew := filepath.Join(home, ".linuxbrew", "bin", "brew")
+	marker := filepath.Join(home, "noninteractive")
+	script := filepath.Join(t.TempDir(), "install.sh")
+	body := "mkdir -p " + filepath.Dir(brew) + "\n" +
+		"printf '#!/bin/sh\\necho Homebrew 4.4.0\\n' > " + brew + "\n" +
+		"chmod +x " + brew + "\n" +
+		"echo \"$NONINTERACTIVE\" > " + marker + "\n"
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	b := &Bootstrap{
+		GOOS:           "linux",
+		GOARCH:         "amd64",
+		Home:           home,
+		Shell:          "bash",
+		NonInteractive: true,
+		RCWriter:       &shell.RCWriter{},
+		fetch:          func(string) (string, func(), error) { return script, func() {}, nil },
+	}
+	got, err := b.Install()
+	if err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if got != brew {
+		t.Errorf("Install() = %s, want %s", got, brew)
+	}
+	if data, _ := os.ReadFile(marker); strings.TrimSpace(string(data)) != "1" {
+		t.Errorf("Expected the script to run with NONINTERACTIVE=1, got %q", data)
+	}
+	if path := filepath.SplitList(os.Getenv("PATH")); path[0] != filepath.Dir(brew) {
+		t.Errorf("Expected brew first on PATH, got %v", path[:2])
+	}
+	if prefix := os.Getenv("HOMEBREW_PREFIX"); prefix != filepath.Join(home, ".linuxbrew") {
+		t.Errorf("HOMEBREW_PREFIX = %s", prefix)
+	}
+	bashrc, _ := os.ReadFile(filepath.Join(home, ".bashrc"))
+	if want := `eval "$(` + brew + ` shellenv)"`; !strings.Contains(string(bashrc), want) || !shell.HasBlock(string(bashrc), shell.BrewBlock) {
+		t.Errorf("Expected a brew block running %s in .bashrc, got:\n%s", want, bashrc)
+	}
+
+	// A script that installs nothing is an error
+	b.fetch = func(string) (string, func(), error) {
+		empty := filepath.Join(t.TempDir(), "install.sh")
+		return empty, func() {}, os.WriteFile(empty, []byte("true\n"), 0755)
+	}
+	os.RemoveAll(filepath.Join(home, ".linuxbrew"))
+	if _, err := b.Install(); err == nil {
+		t.Error("Expected an error when the script leaves no brew")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	brew := filepath.Join(t.TempDir(), "brew")
+	writeBrew(t, brew)
+	if err := Verify(brew); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+	if err := os.WriteFile(brew, []byte("#!/bin/sh\necho something else\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(brew); err == nil {
+		t.Error("Expected a brew that is not Homebrew to fail verification")
+	}
+}
+
+// noBrewOnPath makes brew missing from PATH for the test
+func noBrewOnPath(t *testing.T) {
+	orig := lookPath
+	lookPath = func(string) (string, error) { return "", errors.New("not found") }
+	t.Cleanup(func() { lookPath = orig })
+}
+
+func writeBrew(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("#!/bin/sh\necho Homebrew 4.4.0\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
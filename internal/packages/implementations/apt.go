@@ -7,9 +7,11 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
 
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
 )
 
 // APTManager implements the PackageManager interface for APT-based systems
@@ -116,7 +118,11 @@ func (a *APTManager) SetupSpecialPackage(_ string) error {
 
 // Install installs a package using apt
 func (a *APTManager) Install(pkg string) error {
-	cmd := exec.Command("sudo", "apt-get", "install", "-y", pkg)
+	// apt-get accepts an empty name, which would otherwise pass silently as root
+	if strings.TrimSpace(pkg) == "" {
+		return fmt.Errorf("package name is required")
+	}
+	cmd := system.PrivilegedCommand("apt-get", "install", "-y", pkg)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("failed to install package %s: %v\nOutput: %s", pkg, err, output)
@@ -126,7 +132,7 @@ func (a *APTManager) Install(pkg string) error {
 
 // Remove removes a package
 func (a *APTManager) Remove(packageName string) error {
-	cmd := exec.Command(a.aptGetPath, "remove", "-y", packageName)
+	cmd := system.PrivilegedCommand(a.aptGetPath, "remove", "-y", packageName)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
@@ -173,7 +179,7 @@ func (a *APTManager) GetName() string {
 
 // Upgrade upgrades all packages
 func (a *APTManager) Upgrade() error {
-	cmd := exec.Command("sudo", a.aptGetPath, "upgrade", "-y")
+	cmd := system.PrivilegedCommand(a.aptGetPath, "upgrade", "-y")
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
@@ -191,11 +197,80 @@ func (a *APTManager) IsPackageAvailable(packageName string) bool {
 
 // Uninstall removes a package using apt (Renamed from Remove)
 func (a *APTManager) Uninstall(packageName string) error {
-	cmd := exec.Command("sudo", "apt-get", "remove", "-y", packageName)
+	cmd := system.PrivilegedCommand("apt-get", "remove", "-y", packageName)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to remove package %s: %w", packageName, err)
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// InstallCommand returns the command that installs the space-separated pkgs
+func (a *APTManager) InstallCommand(pkgs string) string {
+	return fmt.Sprintf("%sapt-get install -y %s", system.SudoPrefix(), pkgs)
+}
+
+// UpgradeCommand returns the command that upgrades the installed pkgs
+func (a *APTManager) UpgradeCommand(pkgs string) string {
+	return fmt.Sprintf("%sapt-get install -y --only-upgrade %s", system.SudoPrefix(), pkgs)
+}
+
+// VersionedPackage returns the package spec asking for version of pkg
+func (a *APTManager) VersionedPackage(pkg, version string) string {
+	return fmt.Sprintf("%s=%s", pkg, version)
+}
+
+// Pinnable reports that apt installs exact versions
+func (a *APTManager) Pinnable() bool {
+	return true
+}
+
+// DockerRepoCommand returns the commands that add Docker's apt repository and
+// signing key for the distro described by osRelease (the fields of
+// /etc/os-release)
+func (a *APTManager) DockerRepoCommand(osRelease map[string]string) (string, error) {
+	distro, codename, err := aptDockerDistro(osRelease)
+	if err != nil {
+		return "", err
+	}
+	sudo := system.SudoPrefix()
+	url := "https://download.docker.com/linux/" + distro
+	return strings.Join([]string{
+		fmt.Sprintf("%sapt-get install -y ca-certificates curl", sudo),
+		fmt.Sprintf("%sinstall -m 0755 -d /etc/apt/keyrings", sudo),
+		fmt.Sprintf("curl -fsSL %s/gpg | %stee /etc/apt/keyrings/docker.asc > /dev/null", url, sudo),
+		fmt.Sprintf("%schmod a+r /etc/apt/keyrings/docker.asc", sudo),
+		fmt.Sprintf(`echo "deb [arch=$(dpkg --print-architecture) signed-by=/etc/apt/keyrings/docker.asc] %s %s stable" | %stee /etc/apt/sources.list.d/docker.list > /dev/null`, url, codename, sudo),
+		fmt.Sprintf("%sapt-get update", sudo),
+	}, " && "), nil
+}
+
+// aptDockerDistro returns the distro directory of download.docker.com for
+// osRelease and its release codename. Derivatives (Linux Mint, Pop!_OS) use
+// the repository of the distro they are based on.
+func aptDockerDistro(osRelease map[string]string) (distro, codename string, err error) {
+	id := osRelease["ID"]
+	switch {
+	case id == "debian":
+		distro, codename = "debian", osRelease["VERSION_CODENAME"]
+	case osReleaseIs(osRelease, "ubuntu"):
+		distro, codename = "ubuntu", osRelease["UBUNTU_CODENAME"]
+		if codename == "" {
+			codename = osRelease["VERSION_CODENAME"]
+		}
+	case osReleaseIs(osRelease, "debian"):
+		distro, codename = "debian", osRelease["VERSION_CODENAME"]
+	default:
+		return "", "", fmt.Errorf("docker's apt repository has no packages for %s", id)
+	}
+	if codename == "" {
+		return "", "", fmt.Errorf("could not tell the %s release codename from /etc/os-release", id)
+	}
+	return distro, codename, nil
+}
+
+// osReleaseIs reports whether osRelease describes name or a distro based on it
+func osReleaseIs(osRelease map[string]string, name string) bool {
+	return osRelease["ID"] == name || slices.Contains(strings.Fields(osRelease["ID_LIKE"]), name)
+}
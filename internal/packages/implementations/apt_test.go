@@ -2,6 +2,7 @@ package implementations
 
 import (
 	"os/exec"
+	"strings"
 	"testing"
 
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
@@ -222,4 +223,47 @@ func TestAptInstall(t *testing.T) {
 	// Test installing a package
 	err = pm.Install("test-package")
 	assert.NoError(t, err)
-} 
\ No newline at end of file
+} 
+func TestAptDockerDistro(t *testing.T) {
+	tests := []struct {
+		name             string
+		osRelease        map[string]string
+		distro, codename string
+		wantErr          bool
+	}{
+		{name: "ubuntu", osRelease: map[string]string{"ID": "ubuntu", "VERSION_CODENAME": "noble", "UBUNTU_CODENAME": "noble"}, distro: "ubuntu", codename: "noble"},
+		{name: "debian", osRelease: map[string]string{"ID": "debian", "VERSION_CODENAME": "bookworm"}, distro: "debian", codename: "bookworm"},
+		{name: "mint", osRelease: map[string]string{"ID": "linuxmint", "ID_LIKE": "ubuntu debian", "VERSION_CODENAME": "wilma", "UBUNTU_CODENAME": "noble"}, distro: "ubuntu", codename: "noble"},
+		{name: "raspbian", osRelease: map[string]string{"ID": "raspbian", "ID_LIKE": "debian", "VERSION_CODENAME": "bookworm"}, distro: "debian", codename: "bookworm"},
+		{name: "no codename", osRelease: map[string]string{"ID": "debian"}, wantErr: true},
+		{name: "unknown distro", osRelease: map[string]string{"ID": "deepin"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		distro, codename, err := aptDockerDistro(tt.osRelease)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %s %s", tt.name, distro, codename)
+			}
+			continue
+		}
+		if err != nil || distro != tt.distro || codename != tt.codename {
+			t.Errorf("%s: aptDockerDistro() = %s, %s, %v; want %s, %s", tt.name, distro, codename, err, tt.distro, tt.codename)
+		}
+	}
+}
+
+func TestAptDockerRepoCommand(t *testing.T) {
+	cmdStr, err := (&APTManager{}).DockerRepoCommand(map[string]string{"ID": "ubuntu", "VERSION_CODENAME": "jammy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"curl -fsSL https://download.docker.com/linux/ubuntu/gpg",
+		"signed-by=/etc/apt/keyrings/docker.asc] https://download.docker.com/linux/ubuntu jammy stable",
+		"apt-get update",
+	} {
+		if !strings.Contains(cmdStr, want) {
+			t.Errorf("Expected %q in the apt repository command:\n%s", want, cmdStr)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package implementations
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+// chocoRebootRequired are the exit codes of an install that worked but needs a
+// reboot to finish (ERROR_SUCCESS_REBOOT_INITIATED and _REQUIRED)
+var chocoRebootRequired = []uint32{1641, 3010}
+
+// ChocoPackageManager implements package management with Chocolatey, which
+// needs an elevated shell
+type ChocoPackageManager struct {
+	chocoPath string
+}
+
+// NewChocoPackageManager creates a new Chocolatey package manager instance
+func NewChocoPackageManager() (interfaces.PackageManager, error) {
+	chocoPath, err := exec.LookPath("choco")
+	if err != nil {
+		return nil, fmt.Errorf("choco is required but not found: %w", err)
+	}
+	return &ChocoPackageManager{chocoPath: chocoPath}, nil
+}
+
+// GetName returns the name of the package manager
+func (c *ChocoPackageManager) GetName() string {
+	return string(interfaces.Chocolatey)
+}
+
+// IsAvailable checks if choco is available on the system
+func (c *ChocoPackageManager) IsAvailable() bool {
+	_, err := exec.LookPath("choco")
+	return err == nil
+}
+
+// Install installs a package using choco
+func (c *ChocoPackageManager) Install(packageName string) error {
+	output, err := exec.Command(c.chocoPath, "install", packageName, "-y", "--no-progress").CombinedOutput()
+	if err != nil && !chocoSucceeded(err) {
+		return fmt.Errorf("failed to install package %s: %v\nOutput: %s", packageName, err, output)
+	}
+	return nil
+}
+
+// Uninstall removes a package using choco
+func (c *ChocoPackageManager) Uninstall(packageName string) error {
+	cmd := exec.Command(c.chocoPath, "uninstall", packageName, "-y")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil && !chocoSucceeded(err) {
+		return fmt.Errorf("failed to remove package %s: %w", packageName, err)
+	}
+	return nil
+}
+
+// Update does nothing: choco reads its sources on every command
+func (c *ChocoPackageManager) Update() error {
+	return nil
+}
+
+// Upgrade upgrades all packages
+func (c *ChocoPackageManager) Upgrade() error {
+	cmd := exec.Command(c.chocoPath, "upgrade", "all", "-y", "--no-progress")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil && !chocoSucceeded(err) {
+		return err
+	}
+	return nil
+}
+
+// IsInstalled checks if a package is installed
+func (c *ChocoPackageManager) IsInstalled(packageName string) (bool, error) {
+	packages, err := c.list(packageName)
+	if err != nil {
+		return false, err
+	}
+	_, ok := packages[strings.ToLower(packageName)]
+	return ok, nil
+}
+
+// IsPackageAvailable checks if a package is in the Chocolatey sources
+func (c *ChocoPackageManager) IsPackageAvailable(packageName string) bool {
+	output, err := exec.Command(c.chocoPath, "search", packageName, "--exact", "--limit-output").Output()
+	return err == nil && strings.TrimSpace(string(output)) != ""
+}
+
+// GetVersion returns the version of an installed package
+func (c *ChocoPackageManager) GetVersion(packageName string) (string, error) {
+	packages, err := c.list(packageName)
+	if err != nil {
+		return "", err
+	}
+	version, ok := packages[strings.ToLower(packageName)]
+	if !ok {
+		return "", fmt.Errorf("package %s is not installed", packageName)
+	}
+	return version, nil
+}
+
+// ListInstalled returns a list of installed packages
+func (c *ChocoPackageManager) ListInstalled() ([]string, error) {
+	packages, err := c.list("")
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(packages))
+	for name := range packages {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// SetupSpecialPackage handles packages that need extra setup; choco needs none
+func (c *ChocoPackageManager) SetupSpecialPackage(_ string) error {
+	return nil
+}
+
+// list returns the installed packages and their versions, lowercased, from
+// choco list's "name|version" lines; only packageName when it is given
+func (c *ChocoPackageManager) list(packageName string) (map[string]string, error) {
+	args := []string{"list", "--limit-output"}
+	if packageName != "" {
+		args = append(args, "--exact", packageName)
+	}
+	output, err := exec.Command(c.chocoPath, args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list choco packages: %w", err)
+	}
+	packages := make(map[string]string)
+	for _, line := range strings.Split(string(output), "\n") {
+		if name, version, ok := strings.Cut(strings.TrimSpace(line), "|"); ok {
+			packages[strings.ToLower(name)] = version
+		}
+	}
+	return packages, nil
+}
+
+// chocoSucceeded reports whether a choco command that failed with err worked,
+// but needs a reboot
+func chocoSucceeded(err error) bool {
+	code := exitCode(err)
+	for _, reboot := range chocoRebootRequired {
+		if code == reboot {
+			return true
+		}
+	}
+	return false
+}
+
+// InstallCommand returns the command that installs the space-separated pkgs
+func (c *ChocoPackageManager) InstallCommand(pkgs string) string {
+	return fmt.Sprintf("choco install -y --no-progress %s", pkgs)
+}
+
+// UpgradeCommand returns the command that upgrades the installed pkgs
+func (c *ChocoPackageManager) UpgradeCommand(pkgs string) string {
+	return fmt.Sprintf("choco upgrade -y --no-progress %s", pkgs)
+}
+
+// VersionedPackage returns the package spec asking for version of pkg
+func (c *ChocoPackageManager) VersionedPackage(pkg, version string) string {
+	return pkg
+}
+
+// Pinnable reports false: Chocolatey is given the package alone
+func (c *ChocoPackageManager) Pinnable() bool {
+	return false
+}
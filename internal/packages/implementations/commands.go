@@ -0,0 +1,29 @@
+package implementations
+
+import (
+	"fmt"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+// commandBuilders build the commands of each package manager; they run
+// nothing, so none needs its manager installed
+var commandBuilders = map[interfaces.PackageManagerType]interfaces.PackageCommands{
+	interfaces.APT:        &APTManager{},
+	interfaces.Pkg:        &PkgManager{},
+	interfaces.DNF:        &DnfPackageManager{},
+	interfaces.Pacman:     &PacmanPackageManager{},
+	interfaces.Zypper:     &ZypperPackageManager{},
+	interfaces.Homebrew:   &HomebrewPackageManager{},
+	interfaces.Winget:     &WingetPackageManager{},
+	interfaces.Chocolatey: &ChocoPackageManager{},
+}
+
+// Commands returns the command builder of the package manager called name,
+// e.g. apt or brew
+func Commands(name string) (interfaces.PackageCommands, error) {
+	if commands, ok := commandBuilders[interfaces.PackageManagerType(name)]; ok {
+		return commands, nil
+	}
+	return nil, fmt.Errorf("unsupported package manager: %s", name)
+}
@@ -7,28 +7,20 @@ import (
 	"strings"
 
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
 )
 
 // DnfPackageManager implements package management for Fedora-based systems
-type DnfPackageManager struct {
-	sudoPath string
-}
+type DnfPackageManager struct{}
 
 // NewDnfPackageManager creates a new DNF package manager instance
 func NewDnfPackageManager() (interfaces.PackageManager, error) {
-	sudoPath, err := exec.LookPath("sudo")
-	if err != nil {
-		return nil, fmt.Errorf("sudo is required but not found: %w", err)
-	}
-
 	// Verify dnf is available
 	if _, err := exec.LookPath("dnf"); err != nil {
 		return nil, fmt.Errorf("dnf is required but not found: %w", err)
 	}
 
-	return &DnfPackageManager{
-		sudoPath: sudoPath,
-	}, nil
+	return &DnfPackageManager{}, nil
 }
 
 // Name returns the name of the package manager
@@ -49,7 +41,7 @@ func (d *DnfPackageManager) IsAvailable() bool {
 
 // Install installs a package using dnf
 func (d *DnfPackageManager) Install(packageName string) error {
-	cmd := exec.Command("sudo", "dnf", "install", "-y", packageName)
+	cmd := system.PrivilegedCommand("dnf", "install", "-y", packageName)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
@@ -57,7 +49,7 @@ func (d *DnfPackageManager) Install(packageName string) error {
 
 // Update updates the package list
 func (d *DnfPackageManager) Update() error {
-	cmd := exec.Command(d.sudoPath, "dnf", "check-update")
+	cmd := system.PrivilegedCommand("dnf", "check-update")
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
@@ -65,7 +57,7 @@ func (d *DnfPackageManager) Update() error {
 
 // IsInstalled checks if a package is installed using dnf
 func (d *DnfPackageManager) IsInstalled(packageName string) (bool, error) {
-	cmd := exec.Command(d.sudoPath, "dnf", "list", "installed", packageName)
+	cmd := system.PrivilegedCommand("dnf", "list", "installed", packageName)
 	err := cmd.Run()
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
@@ -80,14 +72,14 @@ func (d *DnfPackageManager) IsInstalled(packageName string) (bool, error) {
 
 // IsPackageAvailable checks if a specific package is available in dnf repositories
 func (d *DnfPackageManager) IsPackageAvailable(packageName string) bool {
-	cmd := exec.Command(d.sudoPath, "dnf", "list", "available", packageName)
+	cmd := system.PrivilegedCommand("dnf", "list", "available", packageName)
 	err := cmd.Run()
 	return err == nil
 }
 
 // Upgrade upgrades all packages using dnf
 func (d *DnfPackageManager) Upgrade() error {
-	cmd := exec.Command("sudo", "dnf", "upgrade", "-y")
+	cmd := system.PrivilegedCommand("dnf", "upgrade", "-y")
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
@@ -95,7 +87,7 @@ func (d *DnfPackageManager) Upgrade() error {
 
 // Uninstall removes a package using dnf (Renamed from Remove)
 func (d *DnfPackageManager) Uninstall(packageName string) error {
-	cmd := exec.Command(d.sudoPath, "dnf", "remove", "-y", packageName)
+	cmd := system.PrivilegedCommand("dnf", "remove", "-y", packageName)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
@@ -148,7 +140,7 @@ func (d *DnfPackageManager) ListInstalled() ([]string, error) {
 func (d *DnfPackageManager) SetupSpecialPackage(packageName string) error {
 	switch packageName {
 	case "docker":
-		cmd := exec.Command(d.sudoPath, "dnf", "config-manager", "--add-repo", "https://download.docker.com/linux/fedora/docker-ce.repo")
+		cmd := system.PrivilegedCommand("dnf", "config-manager", "--add-repo", "https://download.docker.com/linux/fedora/docker-ce.repo")
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 		if err := cmd.Run(); err != nil {
@@ -158,4 +150,51 @@ func (d *DnfPackageManager) SetupSpecialPackage(packageName string) error {
 	default:
 		return nil
 	}
-} 
\ No newline at end of file
+} 
+
+// InstallCommand returns the command that installs the space-separated pkgs
+func (d *DnfPackageManager) InstallCommand(pkgs string) string {
+	return fmt.Sprintf("%sdnf install -y %s", system.SudoPrefix(), pkgs)
+}
+
+// UpgradeCommand returns the command that upgrades the installed pkgs
+func (d *DnfPackageManager) UpgradeCommand(pkgs string) string {
+	return fmt.Sprintf("%sdnf upgrade -y %s", system.SudoPrefix(), pkgs)
+}
+
+// VersionedPackage returns the package spec asking for version of pkg
+func (d *DnfPackageManager) VersionedPackage(pkg, version string) string {
+	return fmt.Sprintf("%s-%s", pkg, version)
+}
+
+// Pinnable reports that dnf installs exact versions
+func (d *DnfPackageManager) Pinnable() bool {
+	return true
+}
+
+// DockerRepoCommand returns the commands that add Docker's dnf repository for
+// the distro described by osRelease (the fields of /etc/os-release)
+func (d *DnfPackageManager) DockerRepoCommand(osRelease map[string]string) (string, error) {
+	distro, err := dnfDockerDistro(osRelease)
+	if err != nil {
+		return "", err
+	}
+	// dnf5 (Fedora 41) replaced --add-repo with addrepo --from-repofile
+	repo := "https://download.docker.com/linux/" + distro + "/docker-ce.repo"
+	return fmt.Sprintf("test -f /etc/yum.repos.d/docker-ce.repo || (%[1]sdnf install -y dnf-plugins-core && (%[1]sdnf config-manager addrepo --from-repofile=%[2]s || %[1]sdnf config-manager --add-repo %[2]s))", system.SudoPrefix(), repo), nil
+}
+
+// dnfDockerDistro returns the distro directory of download.docker.com for
+// osRelease; derivatives such as Rocky Linux use the CentOS repository
+func dnfDockerDistro(osRelease map[string]string) (string, error) {
+	switch id := osRelease["ID"]; {
+	case id == "fedora":
+		return "fedora", nil
+	case id == "rhel":
+		return "rhel", nil
+	case osReleaseIs(osRelease, "rhel"), osReleaseIs(osRelease, "centos"), osReleaseIs(osRelease, "fedora"):
+		return "centos", nil
+	default:
+		return "", fmt.Errorf("docker's dnf repository has no packages for %s", id)
+	}
+}
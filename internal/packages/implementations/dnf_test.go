@@ -2,6 +2,7 @@ package implementations
 
 import (
 	"os/exec"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -83,4 +84,26 @@ func TestDnfPackageManager_Remove(t *testing.T) {
 	if err == nil {
 		t.Error("Uninstall() expected error for non-existent package, got nil")
 	}
-} 
\ No newline at end of file
+} 
+func TestDnfDockerDistro(t *testing.T) {
+	tests := []struct {
+		name      string
+		osRelease map[string]string
+		distro    string
+	}{
+		{name: "fedora", osRelease: map[string]string{"ID": "fedora"}, distro: "fedora"},
+		{name: "rocky", osRelease: map[string]string{"ID": "rocky", "ID_LIKE": "rhel centos fedora"}, distro: "centos"},
+	}
+	for _, tt := range tests {
+		if distro, err := dnfDockerDistro(tt.osRelease); err != nil || distro != tt.distro {
+			t.Errorf("%s: dnfDockerDistro() = %s, %v; want %s", tt.name, distro, err, tt.distro)
+		}
+	}
+}
+
+func TestDnfDockerRepoCommand(t *testing.T) {
+	cmdStr, err := (&DnfPackageManager{}).DockerRepoCommand(map[string]string{"ID": "fedora"})
+	if err != nil || !strings.Contains(cmdStr, "https://download.docker.com/linux/fedora/docker-ce.repo") {
+		t.Errorf("dnf repository command = %s, %v", cmdStr, err)
+	}
+}
@@ -147,4 +147,25 @@ func (h *HomebrewPackageManager) IsPackageAvailable(pkg string) bool {
 	cmd := exec.Command(h.brewPath, "info", pkg)
 	err := cmd.Run()
 	return err == nil
-} 
\ No newline at end of file
+} 
+
+// InstallCommand returns the command that installs the space-separated pkgs
+func (h *HomebrewPackageManager) InstallCommand(pkgs string) string {
+	return fmt.Sprintf("brew install %s", pkgs)
+}
+
+// UpgradeCommand returns the command that upgrades the installed pkgs
+func (h *HomebrewPackageManager) UpgradeCommand(pkgs string) string {
+	return fmt.Sprintf("brew upgrade %s", pkgs)
+}
+
+// VersionedPackage returns the package spec asking for version of pkg
+func (h *HomebrewPackageManager) VersionedPackage(pkg, version string) string {
+	return fmt.Sprintf("%s@%s", pkg, version)
+}
+
+// Pinnable reports false: name@version only exists for the few versioned formulae
+// (e.g. python@3.12)
+func (h *HomebrewPackageManager) Pinnable() bool {
+	return false
+}
@@ -7,28 +7,20 @@ import (
 	"strings"
 
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
 )
 
 // PacmanPackageManager implements package management for Arch-based systems
-type PacmanPackageManager struct {
-	sudoPath string
-}
+type PacmanPackageManager struct{}
 
 // NewPacmanPackageManager creates a new Pacman package manager instance
 func NewPacmanPackageManager() (interfaces.PackageManager, error) {
-	sudoPath, err := exec.LookPath("sudo")
-	if err != nil {
-		return nil, fmt.Errorf("sudo is required but not found: %w", err)
-	}
-
 	// Verify pacman is available
 	if _, err := exec.LookPath("pacman"); err != nil {
 		return nil, fmt.Errorf("pacman is required but not found: %w", err)
 	}
 
-	return &PacmanPackageManager{
-		sudoPath: sudoPath,
-	}, nil
+	return &PacmanPackageManager{}, nil
 }
 
 // Name returns the name of the package manager
@@ -49,7 +41,7 @@ func (p *PacmanPackageManager) IsAvailable() bool {
 
 // Update updates the package list
 func (p *PacmanPackageManager) Update() error {
-	cmd := exec.Command(p.sudoPath, "pacman", "-Sy")
+	cmd := system.PrivilegedCommand("pacman", "-Sy")
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
@@ -60,7 +52,7 @@ func (p *PacmanPackageManager) Update() error {
 
 // Install installs a package using pacman
 func (p *PacmanPackageManager) Install(pkg string) error {
-	cmd := exec.Command("sudo", "pacman", "-S", "--noconfirm", pkg)
+	cmd := system.PrivilegedCommand("pacman", "-S", "--noconfirm", pkg)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
@@ -68,7 +60,7 @@ func (p *PacmanPackageManager) Install(pkg string) error {
 
 // IsInstalled checks if a package is installed using Pacman
 func (p *PacmanPackageManager) IsInstalled(pkg string) (bool, error) {
-	cmd := exec.Command(p.sudoPath, "pacman", "-Q", pkg)
+	cmd := system.PrivilegedCommand("pacman", "-Q", pkg)
 	err := cmd.Run()
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
@@ -83,14 +75,14 @@ func (p *PacmanPackageManager) IsInstalled(pkg string) (bool, error) {
 
 // IsPackageAvailable checks if a specific package is available in Pacman repositories
 func (p *PacmanPackageManager) IsPackageAvailable(pkg string) bool {
-	cmd := exec.Command(p.sudoPath, "pacman", "-Si", pkg)
+	cmd := system.PrivilegedCommand("pacman", "-Si", pkg)
 	err := cmd.Run()
 	return err == nil
 }
 
 // Uninstall removes a package using Pacman (Renamed from Remove)
 func (p *PacmanPackageManager) Uninstall(pkg string) error {
-	cmd := exec.Command(p.sudoPath, "pacman", "-Rns", "--noconfirm", pkg)
+	cmd := system.PrivilegedCommand("pacman", "-Rns", "--noconfirm", pkg)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
@@ -156,7 +148,7 @@ func (p *PacmanPackageManager) SetupSpecialPackage(pkg string) error {
 		}
 		if !installed {
 			// First ensure base-devel is installed
-			cmd := exec.Command(p.sudoPath, "pacman", "-S", "--noconfirm", "base-devel", "git")
+			cmd := system.PrivilegedCommand("pacman", "-S", "--noconfirm", "base-devel", "git")
 			cmd.Stdout = os.Stdout
 			cmd.Stderr = os.Stderr
 			if err := cmd.Run(); err != nil {
@@ -193,8 +185,29 @@ func (p *PacmanPackageManager) SetupSpecialPackage(pkg string) error {
 
 // Upgrade upgrades all packages
 func (p *PacmanPackageManager) Upgrade() error {
-	cmd := exec.Command("sudo", "pacman", "-Syu", "--noconfirm")
+	cmd := system.PrivilegedCommand("pacman", "-Syu", "--noconfirm")
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
-} 
\ No newline at end of file
+} 
+
+// InstallCommand returns the command that installs the space-separated pkgs
+func (p *PacmanPackageManager) InstallCommand(pkgs string) string {
+	return fmt.Sprintf("%spacman -S --noconfirm %s", system.SudoPrefix(), pkgs)
+}
+
+// UpgradeCommand returns the command that upgrades the installed pkgs
+func (p *PacmanPackageManager) UpgradeCommand(pkgs string) string {
+	return fmt.Sprintf("%spacman -S --noconfirm %s", system.SudoPrefix(), pkgs)
+}
+
+// VersionedPackage returns the package spec asking for version of pkg
+func (p *PacmanPackageManager) VersionedPackage(pkg, version string) string {
+	return fmt.Sprintf("%s=%s", pkg, version)
+}
+
+// Pinnable reports false: the repositories only carry the current version of each
+// package
+func (p *PacmanPackageManager) Pinnable() bool {
+	return false
+}
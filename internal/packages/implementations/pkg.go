@@ -0,0 +1,143 @@
+package implementations
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+// PkgManager implements package management for Termux on Android. pkg wraps
+// apt, installs into $PREFIX and runs unprivileged, so nothing here uses sudo.
+type PkgManager struct {
+	pkgPath string
+}
+
+// NewPkgPackageManager creates a new Termux pkg package manager instance
+func NewPkgPackageManager() (interfaces.PackageManager, error) {
+	pkgPath, err := exec.LookPath("pkg")
+	if err != nil {
+		return nil, fmt.Errorf("pkg is required but not found: %w", err)
+	}
+	return &PkgManager{pkgPath: pkgPath}, nil
+}
+
+// Name returns the name of the package manager
+func (p *PkgManager) Name() string {
+	return string(interfaces.Pkg)
+}
+
+// GetName returns the name of the package manager
+func (p *PkgManager) GetName() string {
+	return string(interfaces.Pkg)
+}
+
+// IsAvailable checks if pkg is available on the system
+func (p *PkgManager) IsAvailable() bool {
+	_, err := exec.LookPath("pkg")
+	return err == nil
+}
+
+// Install installs a package using pkg
+func (p *PkgManager) Install(packageName string) error {
+	cmd := exec.Command(p.pkgPath, "install", "-y", packageName)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to install package %s: %v\nOutput: %s", packageName, err, output)
+	}
+	return nil
+}
+
+// Uninstall removes a package using pkg
+func (p *PkgManager) Uninstall(packageName string) error {
+	cmd := exec.Command(p.pkgPath, "uninstall", "-y", packageName)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to remove package %s: %w", packageName, err)
+	}
+	return nil
+}
+
+// Update updates the package list
+func (p *PkgManager) Update() error {
+	cmd := exec.Command(p.pkgPath, "update", "-y")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Upgrade upgrades all packages
+func (p *PkgManager) Upgrade() error {
+	cmd := exec.Command(p.pkgPath, "upgrade", "-y")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// IsInstalled checks if a package is installed; Termux keeps the dpkg database
+func (p *PkgManager) IsInstalled(packageName string) (bool, error) {
+	cmd := exec.Command("dpkg", "-s", packageName)
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check package status for %s: %w", packageName, err)
+	}
+	return true, nil
+}
+
+// IsPackageAvailable checks if a package is available in the Termux repositories
+func (p *PkgManager) IsPackageAvailable(packageName string) bool {
+	output, err := exec.Command("apt-cache", "policy", packageName).CombinedOutput()
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(output), "Candidate:") && !strings.Contains(string(output), "Candidate: (none)")
+}
+
+// GetVersion returns the version of an installed package
+func (p *PkgManager) GetVersion(packageName string) (string, error) {
+	output, err := exec.Command("dpkg-query", "-W", "-f=${Version}", packageName).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// ListInstalled returns a list of installed packages
+func (p *PkgManager) ListInstalled() ([]string, error) {
+	output, err := exec.Command("dpkg-query", "-W", "-f=${Package}\n").Output()
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSpace(string(output)), "\n"), nil
+}
+
+// SetupSpecialPackage handles packages that need extra setup; Termux needs none
+func (p *PkgManager) SetupSpecialPackage(_ string) error {
+	return nil
+}
+
+// InstallCommand returns the command that installs the space-separated pkgs
+func (p *PkgManager) InstallCommand(pkgs string) string {
+	return fmt.Sprintf("pkg install -y %s", pkgs)
+}
+
+// UpgradeCommand returns the command that upgrades the installed pkgs
+func (p *PkgManager) UpgradeCommand(pkgs string) string {
+	return fmt.Sprintf("pkg upgrade -y %s", pkgs)
+}
+
+// VersionedPackage returns the package spec asking for version of pkg
+func (p *PkgManager) VersionedPackage(pkg, version string) string {
+	return fmt.Sprintf("%s=%s", pkg, version)
+}
+
+// Pinnable reports false: the Termux repositories only carry the current version of
+// each package
+func (p *PkgManager) Pinnable() bool {
+	return false
+}
@@ -0,0 +1,252 @@
+package implementations
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+// winget's exit codes are HRESULTs; these two mean the package is already
+// installed, which is no reason to fail
+const (
+	// wingetUpdateNotApplicable is APPINSTALLER_CLI_ERROR_UPDATE_NOT_APPLICABLE,
+	// returned for an installed package with no newer version
+	wingetUpdateNotApplicable = 0x8A15002B
+	// wingetPackageAlreadyInstalled is APPINSTALLER_CLI_ERROR_PACKAGE_ALREADY_INSTALLED
+	wingetPackageAlreadyInstalled = 0x8A150061
+	// wingetNoApplicationsFound is APPINSTALLER_CLI_ERROR_NO_APPLICATIONS_FOUND
+	wingetNoApplicationsFound = 0x8A150014
+)
+
+// wingetAgreements accepts the source and package agreements, which winget
+// otherwise asks about
+var wingetAgreements = []string{"--accept-source-agreements"}
+
+// WingetPackageManager implements package management with the Windows Package
+// Manager. Packages are winget package ids, matched exactly.
+type WingetPackageManager struct {
+	wingetPath string
+}
+
+// NewWingetPackageManager creates a new winget package manager instance
+func NewWingetPackageManager() (interfaces.PackageManager, error) {
+	wingetPath, err := exec.LookPath("winget")
+	if err != nil {
+		return nil, fmt.Errorf("winget is required but not found: %w", err)
+	}
+	return &WingetPackageManager{wingetPath: wingetPath}, nil
+}
+
+// GetName returns the name of the package manager
+func (w *WingetPackageManager) GetName() string {
+	return string(interfaces.Winget)
+}
+
+// IsAvailable checks if winget is available on the system
+func (w *WingetPackageManager) IsAvailable() bool {
+	_, err := exec.LookPath("winget")
+	return err == nil
+}
+
+// Install installs a package by its winget id. A package winget reports as
+// already installed returns interfaces.ErrAlreadyInstalled.
+func (w *WingetPackageManager) Install(packageName string) error {
+	args := append([]string{"install", "--id", packageName, "--exact", "--silent", "--accept-package-agreements"}, wingetAgreements...)
+	output, err := exec.Command(w.wingetPath, args...).CombinedOutput()
+	if WingetAlreadyInstalled(err) {
+		return fmt.Errorf("%s: %w", packageName, interfaces.ErrAlreadyInstalled)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to install package %s: %v\nOutput: %s", packageName, err, output)
+	}
+	return nil
+}
+
+// Uninstall removes a package by its winget id
+func (w *WingetPackageManager) Uninstall(packageName string) error {
+	args := append([]string{"uninstall", "--id", packageName, "--exact", "--silent"}, wingetAgreements...)
+	cmd := exec.Command(w.wingetPath, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to remove package %s: %w", packageName, err)
+	}
+	return nil
+}
+
+// Update refreshes winget's package sources
+func (w *WingetPackageManager) Update() error {
+	cmd := exec.Command(w.wingetPath, "source", "update")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Upgrade upgrades all packages
+func (w *WingetPackageManager) Upgrade() error {
+	args := append([]string{"upgrade", "--all", "--silent", "--accept-package-agreements"}, wingetAgreements...)
+	cmd := exec.Command(w.wingetPath, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	if WingetAlreadyInstalled(err) {
+		return nil
+	}
+	return err
+}
+
+// IsInstalled checks if a package is installed
+func (w *WingetPackageManager) IsInstalled(packageName string) (bool, error) {
+	rows, err := w.list(packageName)
+	if err != nil {
+		return false, err
+	}
+	return len(rows) > 0, nil
+}
+
+// IsPackageAvailable checks if a package id is in winget's sources
+func (w *WingetPackageManager) IsPackageAvailable(packageName string) bool {
+	args := append([]string{"show", "--id", packageName, "--exact"}, wingetAgreements...)
+	return exec.Command(w.wingetPath, args...).Run() == nil
+}
+
+// GetVersion returns the version of an installed package
+func (w *WingetPackageManager) GetVersion(packageName string) (string, error) {
+	rows, err := w.list(packageName)
+	if err != nil {
+		return "", err
+	}
+	if len(rows) == 0 {
+		return "", fmt.Errorf("package %s is not installed", packageName)
+	}
+	return rows[0].Version, nil
+}
+
+// ListInstalled returns the ids of the installed packages
+func (w *WingetPackageManager) ListInstalled() ([]string, error) {
+	rows, err := w.list("")
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	return ids, nil
+}
+
+// SetupSpecialPackage handles packages that need extra setup; winget needs none
+func (w *WingetPackageManager) SetupSpecialPackage(_ string) error {
+	return nil
+}
+
+// list returns winget list's rows, for the package id when one is given
+func (w *WingetPackageManager) list(id string) ([]wingetRow, error) {
+	args := append([]string{"list"}, wingetAgreements...)
+	if id != "" {
+		args = append(args, "--id", id, "--exact")
+	}
+	output, err := exec.Command(w.wingetPath, args...).Output()
+	if exitCode(err) == wingetNoApplicationsFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list winget packages: %w", err)
+	}
+	return parseWingetTable(string(output)), nil
+}
+
+// WingetAlreadyInstalled reports whether err is winget exiting because the
+// package is already installed
+func WingetAlreadyInstalled(err error) bool {
+	code := exitCode(err)
+	return code == wingetPackageAlreadyInstalled || code == wingetUpdateNotApplicable
+}
+
+// exitCode returns the exit code of a command that failed with err as winget
+// reports it, or 0 when err is not an exit
+func exitCode(err error) uint32 {
+	var exit *exec.ExitError
+	if !errors.As(err, &exit) {
+		return 0
+	}
+	return uint32(exit.ExitCode())
+}
+
+// wingetRow is a package in winget list's table
+type wingetRow struct {
+	Name, ID, Version string
+}
+
+// parseWingetTable reads the table winget list prints: a header naming the
+// columns, a line of dashes, then one package per line at the header's column
+// offsets. Progress output before the header is skipped.
+func parseWingetTable(output string) []wingetRow {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(output, "\r", "\n"), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	for i := 1; i < len(lines); i++ {
+		if !strings.HasPrefix(strings.TrimSpace(lines[i]), "---") {
+			continue
+		}
+		header := lines[i-1]
+		idCol, versionCol := strings.Index(header, "Id"), strings.Index(header, "Version")
+		if idCol <= 0 || versionCol <= idCol {
+			return nil
+		}
+		var rows []wingetRow
+		for _, line := range lines[i+1:] {
+			if len(line) <= versionCol {
+				continue
+			}
+			fields := strings.Fields(line[versionCol:])
+			if len(fields) == 0 {
+				continue
+			}
+			rows = append(rows, wingetRow{
+				Name:    strings.TrimSpace(line[:idCol]),
+				ID:      strings.TrimSpace(line[idCol:versionCol]),
+				Version: fields[0],
+			})
+		}
+		return rows
+	}
+	return nil
+}
+
+// InstallCommand returns the command that installs the space-separated pkgs
+func (w *WingetPackageManager) InstallCommand(pkgs string) string {
+	return wingetCommand("install", pkgs)
+}
+
+// UpgradeCommand returns the command that upgrades the installed pkgs
+func (w *WingetPackageManager) UpgradeCommand(pkgs string) string {
+	return wingetCommand("upgrade", pkgs)
+}
+
+// VersionedPackage returns the package spec asking for version of pkg
+func (w *WingetPackageManager) VersionedPackage(pkg, version string) string {
+	return pkg
+}
+
+// Pinnable reports false: winget is given the package id alone
+func (w *WingetPackageManager) Pinnable() bool {
+	return false
+}
+
+// wingetCommand runs winget's action for each space-separated package in pkgs,
+// one command at a time since --id takes a single package
+func wingetCommand(action, pkgs string) string {
+	var cmds []string
+	for _, pkg := range strings.Fields(pkgs) {
+		cmds = append(cmds, fmt.Sprintf("winget %s --silent --accept-package-agreements --accept-source-agreements --exact --id %s", action, pkg))
+	}
+	return strings.Join(cmds, " && ")
+}
@@ -0,0 +1,42 @@
+package implementations
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWingetTable(t *testing.T) {
+	// winget draws a spinner before the table, ending its frames with \r
+	output := "   - \r   \\ \r" +
+		"Name             Id                        Version     Available Source\r\n" +
+		"-----------------------------------------------------------------------\r\n" +
+		"ripgrep          BurntSushi.ripgrep.MSVC   14.1.0      14.1.1    winget\r\n" +
+		"Windows Terminal Microsoft.WindowsTerminal 1.21.2361.0           winget\r\n"
+
+	rows := parseWingetTable(output)
+	if assert.Len(t, rows, 2) {
+		assert.Equal(t, wingetRow{Name: "ripgrep", ID: "BurntSushi.ripgrep.MSVC", Version: "14.1.0"}, rows[0])
+		assert.Equal(t, "Windows Terminal", rows[1].Name)
+	}
+	assert.Empty(t, parseWingetTable("No installed package found matching input criteria.\r\n"))
+}
+
+func TestWingetAlreadyInstalled(t *testing.T) {
+	assert.False(t, WingetAlreadyInstalled(nil))
+	assert.False(t, WingetAlreadyInstalled(errors.New("winget not found")))
+	// Exit codes above 255 cannot be produced here; any ordinary failure is not a skip
+	err := exec.Command("sh", "-c", "exit 1").Run()
+	assert.False(t, WingetAlreadyInstalled(err))
+}
+
+func TestNewWingetPackageManager(t *testing.T) {
+	if _, err := exec.LookPath("winget"); err != nil {
+		t.Skip("winget not available, skipping test")
+	}
+	pm, err := NewWingetPackageManager()
+	assert.NoError(t, err)
+	assert.Equal(t, "winget", pm.GetName())
+}
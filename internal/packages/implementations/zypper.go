@@ -0,0 +1,161 @@
+package implementations
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+)
+
+// zypperNotFoundExitCode is zypper search's exit status when nothing matches
+const zypperNotFoundExitCode = 104
+
+// ZypperPackageManager implements package management for openSUSE and SLES
+type ZypperPackageManager struct{}
+
+// NewZypperPackageManager creates a new zypper package manager instance
+func NewZypperPackageManager() (interfaces.PackageManager, error) {
+	// Verify zypper is available
+	if _, err := exec.LookPath("zypper"); err != nil {
+		return nil, fmt.Errorf("zypper is required but not found: %w", err)
+	}
+
+	return &ZypperPackageManager{}, nil
+}
+
+// Name returns the name of the package manager
+func (z *ZypperPackageManager) Name() string {
+	return string(interfaces.Zypper)
+}
+
+// GetName returns the name of the package manager
+func (z *ZypperPackageManager) GetName() string {
+	return string(interfaces.Zypper)
+}
+
+// IsAvailable checks if zypper is available on the system
+func (z *ZypperPackageManager) IsAvailable() bool {
+	_, err := exec.LookPath("zypper")
+	return err == nil
+}
+
+// Install installs a package using zypper
+func (z *ZypperPackageManager) Install(packageName string) error {
+	cmd := system.PrivilegedCommand("zypper", "--non-interactive", "install", packageName)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Update refreshes the repository metadata
+func (z *ZypperPackageManager) Update() error {
+	cmd := system.PrivilegedCommand("zypper", "--non-interactive", "refresh")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to refresh repositories: %w", err)
+	}
+	return nil
+}
+
+// IsInstalled checks if a package is installed, asking rpm since zypper has no
+// quiet query for a single package
+func (z *ZypperPackageManager) IsInstalled(packageName string) (bool, error) {
+	err := exec.Command("rpm", "-q", packageName).Run()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check zypper installed status for %s: %w", packageName, err)
+	}
+	return true, nil
+}
+
+// IsPackageAvailable checks if a specific package is available in the zypper repositories
+func (z *ZypperPackageManager) IsPackageAvailable(packageName string) bool {
+	err := exec.Command("zypper", "--non-interactive", "search", "--match-exact", packageName).Run()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == zypperNotFoundExitCode {
+		return false
+	}
+	return err == nil
+}
+
+// Upgrade upgrades all packages using zypper
+func (z *ZypperPackageManager) Upgrade() error {
+	cmd := system.PrivilegedCommand("zypper", "--non-interactive", "update")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Uninstall removes a package using zypper
+func (z *ZypperPackageManager) Uninstall(packageName string) error {
+	cmd := system.PrivilegedCommand("zypper", "--non-interactive", "remove", packageName)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to remove package %s: %w", packageName, err)
+	}
+	return nil
+}
+
+// GetVersion returns the version of an installed package
+func (z *ZypperPackageManager) GetVersion(packageName string) (string, error) {
+	output, err := exec.Command("rpm", "-q", "--queryformat", "%{VERSION}-%{RELEASE}", packageName).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get version for package %s: %w", packageName, err)
+	}
+	version := strings.TrimSpace(string(output))
+	if version == "" {
+		return "", fmt.Errorf("no version information found for package %s", packageName)
+	}
+	return version, nil
+}
+
+// ListInstalled returns a list of installed packages
+func (z *ZypperPackageManager) ListInstalled() ([]string, error) {
+	output, err := exec.Command("rpm", "-qa", "--queryformat", "%{NAME}\n").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list installed packages: %w", err)
+	}
+	return parseRPMNames(string(output)), nil
+}
+
+// parseRPMNames returns the package names rpm printed, one per line
+func parseRPMNames(output string) []string {
+	var packages []string
+	for _, line := range strings.Split(output, "\n") {
+		if name := strings.TrimSpace(line); name != "" {
+			packages = append(packages, name)
+		}
+	}
+	return packages
+}
+
+// SetupSpecialPackage for zypper; docker and the rest ship in the main repositories
+func (z *ZypperPackageManager) SetupSpecialPackage(packageName string) error {
+	return nil
+}
+
+// InstallCommand returns the command that installs the space-separated pkgs
+func (z *ZypperPackageManager) InstallCommand(pkgs string) string {
+	return fmt.Sprintf("%szypper --non-interactive install %s", system.SudoPrefix(), pkgs)
+}
+
+// UpgradeCommand returns the command that upgrades the installed pkgs
+func (z *ZypperPackageManager) UpgradeCommand(pkgs string) string {
+	return fmt.Sprintf("%szypper --non-interactive update %s", system.SudoPrefix(), pkgs)
+}
+
+// VersionedPackage returns the package spec asking for version of pkg
+func (z *ZypperPackageManager) VersionedPackage(pkg, version string) string {
+	return pkg
+}
+
+// Pinnable reports false: zypper is given the package alone, at its current version
+func (z *ZypperPackageManager) Pinnable() bool {
+	return false
+}
@@ -0,0 +1,38 @@
+package implementations
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRPMNames(t *testing.T) {
+	assert.Equal(t, []string{"zypper", "ripgrep", "fish"}, parseRPMNames("zypper\nripgrep\n\nfish\n"))
+	assert.Empty(t, parseRPMNames(""))
+}
+
+func TestNewZypperPackageManager(t *testing.T) {
+	if _, err := exec.LookPath("zypper"); err != nil {
+		t.Skip("zypper not available, skipping test")
+	}
+	pm, err := NewZypperPackageManager()
+	assert.NoError(t, err)
+	assert.Equal(t, "zypper", pm.GetName())
+}
+
+func TestZypperPackageManager_IsInstalled(t *testing.T) {
+	if _, err := exec.LookPath("zypper"); err != nil {
+		t.Skip("zypper not available, skipping test")
+	}
+	pm, err := NewZypperPackageManager()
+	assert.NoError(t, err)
+
+	installed, err := pm.IsInstalled("zypper")
+	assert.NoError(t, err)
+	assert.True(t, installed, "Expected 'zypper' to be installed")
+
+	installed, err = pm.IsInstalled("nonexistent-package-qwezxc")
+	assert.NoError(t, err)
+	assert.False(t, installed, "Expected 'nonexistent-package-qwezxc' not to be installed")
+}
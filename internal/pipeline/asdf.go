@@ -0,0 +1,93 @@
+package pipeline
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cache"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+)
+
+// asdfRepo and asdfRef are where asdf is cloned from when it is not installed;
+// v0.14 is the last release with the asdf.sh the rc block sources
+const (
+	asdfRepo = "https://github.com/asdf-vm/asdf.git"
+	asdfRef  = "v0.14.1"
+)
+
+// asdfSteps sets up asdf unless it is installed, then installs lang through it
+// with its plugin and makes the version the user's default
+func asdfSteps(lang *interfaces.Language) []InstallationStep {
+	return []InstallationStep{
+		{
+			Name:        fmt.Sprintf("install-lang-%s-asdf", lang.Name),
+			Description: fmt.Sprintf("Setting up asdf for %s", lang.Name),
+			Action: func(ctx *InstallationContext) error {
+				return ensureAsdf(ctx, lang.Name)
+			},
+			Timeout: 5 * time.Minute,
+		},
+		runtimeManagerInstallStep(lang, interfaces.LanguageInstallerAsdf),
+	}
+}
+
+// ensureAsdf clones asdf into ~/.asdf unless it is installed, and loads it from
+// the shell's rc file. Several languages may use asdf; the clone happens once
+// and the rc block is written once.
+func ensureAsdf(ctx *InstallationContext, item string) error {
+	home, err := system.UserHome()
+	if err != nil {
+		return err
+	}
+	if vm, ok := system.FindVersionManager(interfaces.LanguageInstallerAsdf); ok {
+		ctx.Logger.Info("Using existing asdf (%s) for %s", vm.Path, item)
+	} else {
+		dir := filepath.Join(home, ".asdf")
+		existed := exists(dir)
+		if err := cache.CheckCommand(asdfRepo); err != nil {
+			return fmt.Errorf("failed to clone asdf: %w", err)
+		}
+		cmd := ctx.command(item, "git", "clone", "--depth", "1", "--branch", asdfRef, asdfRepo, dir)
+		if output, err := ctx.runCommand(item, cmd); err != nil {
+			return fmt.Errorf("failed to clone asdf: %w (Output: %s)", err, string(output))
+		}
+		ctx.recordFile(item, dir, existed)
+	}
+
+	rc := ctx.stageRC()
+	err = shell.EnsureAsdfInit(home, ctx.Platform.Shell, rc)
+	ctx.recordRCBlocks(item, rc)
+	if err != nil {
+		return fmt.Errorf("failed to load asdf from the shell rc file: %w", err)
+	}
+	return nil
+}
+
+// runtimeManagerInstallStep installs lang through manager (asdf or mise), set
+// up by an earlier step, and makes the version the user's default
+func runtimeManagerInstallStep(lang *interfaces.Language, manager string) InstallationStep {
+	return InstallationStep{
+		Name:        fmt.Sprintf("install-lang-%s", lang.Name),
+		Description: fmt.Sprintf("Installing language %s using %s", lang.Name, manager),
+		Action: func(ctx *InstallationContext) error {
+			vm, ok := system.FindVersionManager(manager)
+			if !ok {
+				return fmt.Errorf("%s is not installed", manager)
+			}
+			cmdStr, err := vm.InstallCommand(lang.Name, lang.Version)
+			if err != nil {
+				return err
+			}
+			if output, err := ctx.runCommand(lang.Name, ctx.command(lang.Name, "sh", "-c", cmdStr)); err != nil {
+				return fmt.Errorf("%s install of %s failed: %w (Output: %s)", manager, lang.Name, err, string(output))
+			}
+			tool, _ := system.VersionManagerTool(manager, lang.Name)
+			ctx.State.RecordInstaller(lang.Name, manager, tool)
+			return nil
+		},
+		Timeout: 10 * time.Minute,
+	}
+}
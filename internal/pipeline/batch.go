@@ -0,0 +1,132 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// batchManagers are the package managers that install several packages in one
+// command; winget and choco take one package at a time
+var batchManagers = map[string]bool{
+	"apt":    true,
+	"pkg":    true,
+	"dnf":    true,
+	"pacman": true,
+	"zypper": true,
+	"brew":   true,
+}
+
+// batchPackage returns the package t installs when nothing but that package of
+// the platform's manager installs it, so it can share one install command with
+// the other selected tools. Tools with pre-install commands, a minimum version
+// to upgrade to, a built-in installer or another manager install on their own.
+func (t *Tool) batchPackage(ctx *InstallationContext) (string, bool) {
+	manager := ctx.Platform.PackageManager
+	if !batchManagers[manager] || t.Builtin != "" || t.IsGroup() || t.MinVersion != "" {
+		return "", false
+	}
+	if _, ok := ctx.KeepExisting[t.Name]; ok {
+		return "", false
+	}
+	if ctx.managerFor(t) != manager {
+		return "", false
+	}
+	if method, err := t.determineInstallationMethod(ctx, manager); err != nil || method != PackageManagerInstall {
+		return "", false
+	}
+	strategy := t.GetInstallStrategy(ctx.Platform)
+	if len(strategy.PreInstall) > 0 {
+		return "", false
+	}
+	pkg := t.PackageFor(manager)
+	if name, err := strategy.GetPackageName(manager); err == nil && name != "" {
+		pkg = name
+	}
+	return pkg, true
+}
+
+// batchInstallStep installs the packages of the selected tools, given in
+// install order, in one command of the platform's manager. It returns false
+// when fewer than two tools can share it. A tool that depends on a selected
+// tool installed on its own stays out of the batch, which runs first.
+// Afterwards each tool whose package is installed is marked batched, so its own
+// package step is skipped and only its verification runs; when the command
+// fails the tools install one at a time as usual.
+func batchInstallStep(order []string, selected map[string]*Tool, ctx *InstallationContext) (InstallationStep, bool) {
+	manager := ctx.Platform.PackageManager
+	packages := make(map[string]string)
+	var names []string
+	for _, name := range order {
+		t, ok := selected[name]
+		if !ok {
+			continue
+		}
+		pkg, ok := t.batchPackage(ctx)
+		if !ok {
+			continue
+		}
+		deps := t.requiredFrom(selected)
+		for _, dep := range t.Dependencies {
+			deps = append(deps, dep.Name)
+		}
+		for _, dep := range deps {
+			if _, isSelected := selected[dep]; isSelected && packages[dep] == "" {
+				ok = false
+			}
+		}
+		if ok {
+			packages[name] = pkg
+			names = append(names, name)
+		}
+	}
+	if len(names) < 2 {
+		return InstallationStep{}, false
+	}
+
+	return InstallationStep{
+		Name:        fmt.Sprintf("batch-install-%s", manager),
+		Description: fmt.Sprintf("Installing %d packages via %s", len(names), manager),
+		Action: func(ctx *InstallationContext) error {
+			specs := make([]string, 0, len(names))
+			for _, name := range names {
+				spec, err := ctx.lockedToolPackage(name, packages[name])
+				if err != nil {
+					return err
+				}
+				specs = append(specs, spec)
+			}
+			cmdStr, err := ctx.installCommand(manager, strings.Join(specs, " "))
+			if err != nil {
+				return err
+			}
+
+			ctx.Logger.CommandStart(cmdStr, 1, 1)
+			start := time.Now()
+			// The step has no item, so its commands run in the step's own context
+			label := fmt.Sprintf("batch-install-%s", manager)
+			if _, err := ctx.runCommand(label, ctx.shellCommand("", cmdStr)); err != nil {
+				// One broken package fails the whole command, so each tool gets its own try
+				ctx.Logger.Warn("Installing the packages together via %s failed, installing them one at a time: %v", manager, err)
+			} else {
+				ctx.Logger.CommandSuccess(cmdStr, time.Since(start))
+			}
+
+			// Which packages are present says which tools the batch installed
+			batched := make(map[string]bool, len(names))
+			for _, name := range names {
+				pkg := packages[name]
+				if installed, err := ctx.PackageManager.IsInstalled(pkg); err != nil || !installed {
+					continue
+				}
+				batched[name] = true
+				if !ctx.preinstalled(manager, pkg) {
+					ctx.recordPackage(name, manager, pkg)
+				}
+			}
+			ctx.batched = batched
+			return nil
+		},
+		Timeout: 30 * time.Minute,
+	}, true
+}
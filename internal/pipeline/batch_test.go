@@ -0,0 +1,42 @@
+package pipeline
+
+import "testing"
+
+func TestBatchInstallStep(t *testing.T) {
+	ctx := NewInstallationContext(&Platform{OS: "linux", PackageManager: "apt", Shell: "bash"}, &fakePM{}, nil)
+	ripgrep := NewTool("ripgrep", CategoryDevelopment)
+	bat := NewTool("bat", CategoryDevelopment)
+	bat.Install.PackageNames = map[string]string{"apt": "bat-cat"}
+	node := NewTool("node", CategoryDevelopment)
+	node.MinVersion = "20"
+	fd := NewTool("fd", CategoryDevelopment)
+	fd.AddDependency(Dependency{Name: "node"})
+	selected := map[string]*Tool{"ripgrep": ripgrep, "bat": bat, "node": node, "fd": fd}
+
+	if pkg, ok := bat.batchPackage(ctx); !ok || pkg != "bat-cat" {
+		t.Errorf("batchPackage(bat) = %q, %v, want its apt package", pkg, ok)
+	}
+	if _, ok := node.batchPackage(ctx); ok {
+		t.Error("Expected a tool with a minimum version to install on its own")
+	}
+
+	step, ok := batchInstallStep([]string{"node", "ripgrep", "bat", "fd"}, selected, ctx)
+	if !ok {
+		t.Fatal("Expected a batch step for two plain apt packages")
+	}
+	if step.Item != "" || step.Description != "Installing 2 packages via apt" {
+		t.Errorf("Unexpected batch step: item %q, description %q", step.Item, step.Description)
+	}
+
+	if _, ok := batchInstallStep([]string{"node", "ripgrep"}, selected, ctx); ok {
+		t.Error("Expected no batch step for a single package")
+	}
+	zypper := NewInstallationContext(&Platform{OS: "linux", PackageManager: "zypper", Shell: "bash"}, &fakePM{}, nil)
+	if step, ok := batchInstallStep([]string{"ripgrep", "bat"}, selected, zypper); !ok || step.Description != "Installing 2 packages via zypper" {
+		t.Errorf("Expected a zypper batch step, got %q, %v", step.Description, ok)
+	}
+	winget := NewInstallationContext(&Platform{OS: "windows", PackageManager: "winget", Shell: "pwsh"}, &fakePM{}, nil)
+	if _, ok := batchInstallStep([]string{"ripgrep", "bat"}, selected, winget); ok {
+		t.Error("Expected no batch step for winget, which installs one package at a time")
+	}
+}
@@ -0,0 +1,9 @@
+package pipeline
+
+// builtinInstallers plan the install steps of the tools whose definition names
+// them in builtin, before the tool's verify step
+var builtinInstallers = map[string]func(t *Tool, ctx *InstallationContext) ([]InstallationStep, error){
+	"docker": dockerSteps,
+	"neovim": neovimSteps,
+	"tmux":   tmuxSteps,
+}
@@ -0,0 +1,132 @@
+package pipeline
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExecuteStopsWhenCancelledWithoutReader(t *testing.T) {
+	// An unbuffered channel nobody reads, as when the UI has quit
+	progChan := make(chan ProgressEvent)
+	ctx := NewInstallationContext(&Platform{OS: "linux", PackageManager: "apt", Shell: "bash"}, &fakePM{}, progChan)
+	p := NewInstallationPipeline(ctx)
+
+	var ran atomic.Int32
+	for _, name := range []string{"first", "second", "third"} {
+		p.AddStep(InstallationStep{
+			Name: name,
+			Action: func(*InstallationContext) error {
+				ran.Add(1)
+				return nil
+			},
+		})
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- p.Execute() }()
+
+	// The pipeline is stuck sending the first TaskStart
+	select {
+	case err := <-done:
+		t.Fatalf("Execute() returned before anything read progress: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	ctx.Cancel()
+	ctx.Cancel() // a second quit must not panic
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrCancelled) {
+			t.Errorf("Execute() error = %v, want ErrCancelled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Execute() goroutine did not exit after Cancel")
+	}
+	if got := ran.Load(); got > 1 {
+		t.Errorf("%d steps ran after Cancel, want at most the one in flight", got)
+	}
+	if _, ok := <-progChan; ok {
+		t.Error("expected the progress channel to be closed")
+	}
+}
+
+func TestCancelWithoutNewInstallationContext(t *testing.T) {
+	ctx := &InstallationContext{}
+	if ctx.Cancelled() {
+		t.Fatal("a new context should not be cancelled")
+	}
+	ctx.Cancel()
+	if !ctx.Cancelled() {
+		t.Fatal("expected the context to be cancelled")
+	}
+}
+
+// sleepStep runs sleep in the item's step context, as install commands do
+func sleepStep(name, item, seconds string, timeout time.Duration) InstallationStep {
+	return InstallationStep{
+		Name:  name,
+		Item:  item,
+		Group: "Modern",
+		Action: func(ctx *InstallationContext) error {
+			return ctx.command(item, "sleep", seconds).Run()
+		},
+		Timeout: timeout,
+	}
+}
+
+func TestStepTimeoutStopsCommand(t *testing.T) {
+	ctx := NewInstallationContext(&Platform{OS: "linux", PackageManager: "apt", Shell: "bash"}, &fakePM{}, nil)
+	p := NewInstallationPipeline(ctx)
+	p.AddStep(sleepStep("slow-install", "slow", "30", 100*time.Millisecond))
+
+	start := time.Now()
+	err := p.Execute()
+	if !IsTimeout(err) {
+		t.Fatalf("Execute() error = %v, want a TimeoutError", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Execute() took %s, the sleep was not stopped", elapsed)
+	}
+	g := p.Summary().Groups()[0]
+	if got := g.String(); got != "Modern: 0 ok, 1 failed (slow timed out)" {
+		t.Errorf("summary = %q", got)
+	}
+}
+
+func TestItemTimeoutSpansSteps(t *testing.T) {
+	ctx := NewInstallationContext(&Platform{OS: "linux", PackageManager: "apt", Shell: "bash"}, &fakePM{}, nil)
+	p := NewInstallationPipeline(ctx)
+	p.ItemTimeouts = map[string]time.Duration{"slow": 300 * time.Millisecond}
+	p.AddStep(sleepStep("slow-first", "slow", "0.2", 0))
+	p.AddStep(sleepStep("slow-second", "slow", "0.2", 0))
+
+	err := p.Execute()
+	var timeout *TimeoutError
+	if !errors.As(err, &timeout) {
+		t.Fatalf("Execute() error = %v, want a TimeoutError", err)
+	}
+	if timeout.Step != "slow-second" || timeout.Timeout >= 300*time.Millisecond {
+		t.Errorf("timeout = %+v, want slow-second with what was left of 300ms", timeout)
+	}
+}
+
+func TestCancelStopsRunningCommand(t *testing.T) {
+	ctx := NewInstallationContext(&Platform{OS: "linux", PackageManager: "apt", Shell: "bash"}, &fakePM{}, nil)
+	p := NewInstallationPipeline(ctx)
+	p.AddStep(sleepStep("slow-install", "slow", "30", 0))
+
+	done := make(chan error, 1)
+	go func() { done <- p.Execute() }()
+	time.Sleep(100 * time.Millisecond)
+	ctx.Cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrCancelled) || IsTimeout(err) {
+			t.Errorf("Execute() error = %v, want ErrCancelled", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Execute() did not return after Cancel stopped the command")
+	}
+}
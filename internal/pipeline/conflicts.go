@@ -0,0 +1,170 @@
+package pipeline
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+)
+
+// How to handle a tool that is already installed by a different manager
+const (
+	// ConflictKeep leaves the existing install in place
+	ConflictKeep = "keep"
+	// ConflictReinstall installs the tool again with bootstrap-cli's manager
+	ConflictReinstall = "reinstall"
+	// ConflictSkip drops the tool from this run
+	ConflictSkip = "skip"
+)
+
+// ConflictResolutions lists the valid answers, default first
+var ConflictResolutions = []string{ConflictKeep, ConflictReinstall, ConflictSkip}
+
+// ValidateConflictResolution checks a resolution given on the command line
+func ValidateConflictResolution(resolution string) error {
+	for _, valid := range ConflictResolutions {
+		if resolution == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown conflict resolution %q (use %s)", resolution, strings.Join(ConflictResolutions, ", "))
+}
+
+// ManagerConflict is a selected tool that is already on PATH, installed by a
+// manager other than the one bootstrap-cli would use for it
+type ManagerConflict struct {
+	Tool *Tool
+	// Path is where the existing binary was found
+	Path string
+	// Existing is the manager the binary appears to come from
+	Existing string
+	// Wanted is the manager bootstrap-cli would install the tool with
+	Wanted string
+}
+
+// String renders e.g. "bat is already installed via cargo (/home/me/.cargo/bin/bat); bootstrap-cli would use apt"
+func (c ManagerConflict) String() string {
+	return fmt.Sprintf("%s is already installed via %s (%s); bootstrap-cli would use %s", c.Tool.Name, c.Existing, c.Path, c.Wanted)
+}
+
+// Provenance guesses which manager installed the binary at path from where it
+// lives, following symlinks (brew and pipx link into their own trees). System
+// directories belong to systemManager on Linux; it is "" elsewhere, since on
+// macOS /usr/bin holds the OS's own tools. Unknown locations return "".
+func Provenance(path, home, systemManager string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		if manager := provenanceOf(resolved, home, systemManager); manager != "" {
+			return manager
+		}
+	}
+	return provenanceOf(path, home, systemManager)
+}
+
+// provenanceOf maps one path to a manager by its prefix
+func provenanceOf(path, home, systemManager string) string {
+	under := func(dir string) bool {
+		return dir != "" && strings.HasPrefix(path, filepath.Clean(dir)+string(filepath.Separator))
+	}
+	goPath := os.Getenv("GOPATH")
+	if goPath == "" && home != "" {
+		goPath = filepath.Join(home, "go")
+	}
+	switch {
+	case under(os.Getenv("PREFIX")) && strings.Contains(os.Getenv("PREFIX"), "com.termux"):
+		return "pkg"
+	case under("/opt/homebrew"), under("/usr/local/Cellar"), under("/home/linuxbrew/.linuxbrew"), under(filepath.Join(home, ".linuxbrew")):
+		return "brew"
+	case under(filepath.Join(home, ".cargo")):
+		return "cargo"
+	case under(filepath.Join(goPath, "bin")):
+		return "go"
+	case under(filepath.Join(home, ".local", "pipx")), under(filepath.Join(home, ".local", "share", "pipx")):
+		return "pipx"
+	case under(filepath.Join(home, ".nvm")), under("/usr/local/lib/node_modules"), under(filepath.Join(home, ".npm-global")):
+		return "npm"
+	case under("/snap"):
+		return "snap"
+	case under("/nix"):
+		return "nix"
+	case under("/usr/bin"), under("/bin"), under("/usr/sbin"), under("/sbin"):
+		return systemManager
+	default:
+		return ""
+	}
+}
+
+// FindManagerConflicts returns the tools already on PATH whose existing install
+// comes from a different manager than the one ctx would use. Tools that are
+// missing, or whose origin cannot be told, are not conflicts.
+func FindManagerConflicts(tools []*Tool, ctx *InstallationContext) []ManagerConflict {
+	home, _ := system.UserHome()
+	systemManager := ""
+	if ctx.Platform.OS == "linux" {
+		systemManager = ctx.Platform.PackageManager
+	}
+	var conflicts []ManagerConflict
+	for _, t := range tools {
+		if len(t.Group) > 0 {
+			continue
+		}
+		for _, name := range append([]string{t.Name}, t.Aliases...) {
+			path, err := lookPath(name)
+			if err != nil {
+				continue
+			}
+			existing := Provenance(path, home, systemManager)
+			if wanted := ctx.managerFor(t); existing != "" && existing != wanted {
+				conflicts = append(conflicts, ManagerConflict{Tool: t, Path: path, Existing: existing, Wanted: wanted})
+			}
+			break
+		}
+	}
+	return conflicts
+}
+
+// PromptConflictResolution asks how to handle c on out and reads the answer from in;
+// an empty or unrecognised answer keeps the existing install
+func PromptConflictResolution(c ManagerConflict, in io.Reader, out io.Writer) string {
+	fmt.Fprintf(out, "%s.\n[k]eep existing, [r]einstall via %s, or [s]kip %s? [K/r/s] ", c, c.Wanted, c.Tool.Name)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "r", "reinstall":
+		return ConflictReinstall
+	case "s", "skip":
+		return ConflictSkip
+	default:
+		return ConflictKeep
+	}
+}
+
+// ResolveManagerConflicts settles each conflict with resolve and returns the
+// tools left to install. Kept tools stay in the list but their package step is
+// skipped, so the existing install and its provenance are recorded.
+func (i *Installer) ResolveManagerConflicts(tools []*Tool, conflicts []ManagerConflict, resolve func(ManagerConflict) string) []*Tool {
+	skipped := make(map[string]bool)
+	for _, c := range conflicts {
+		switch resolve(c) {
+		case ConflictSkip:
+			i.Logger.Info("Skipping %s", c.Tool.Name)
+			skipped[c.Tool.Name] = true
+		case ConflictReinstall:
+			i.Logger.Info("Reinstalling %s via %s", c.Tool.Name, c.Wanted)
+		default:
+			if i.Context.KeepExisting == nil {
+				i.Context.KeepExisting = make(map[string]string)
+			}
+			i.Context.KeepExisting[c.Tool.Name] = c.Existing
+		}
+	}
+	kept := make([]*Tool, 0, len(tools))
+	for _, t := range tools {
+		if !skipped[t.Name] {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,132 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+func TestProvenance(t *testing.T) {
+	t.Setenv("GOPATH", "")
+	t.Setenv("PREFIX", "")
+	home := "/home/me"
+	tests := map[string]string{
+		"/home/me/.cargo/bin/bat":                 "cargo",
+		"/home/me/go/bin/gopls":                   "go",
+		"/opt/homebrew/bin/rg":                    "brew",
+		"/home/linuxbrew/.linuxbrew/bin/fd":       "brew",
+		"/home/me/.nvm/versions/node/v20/bin/tsc": "npm",
+		"/snap/bin/nvim":                          "snap",
+		"/usr/bin/git":                            "apt",
+		"/usr/local/bin/custom":                   "",
+	}
+	for path, want := range tests {
+		if got := Provenance(path, home, "apt"); got != want {
+			t.Errorf("Provenance(%q) = %q, want %q", path, got, want)
+		}
+	}
+	if got := Provenance("/usr/bin/git", home, ""); got != "" {
+		t.Errorf("Expected system paths to be unattributed without a system manager, got %q", got)
+	}
+}
+
+func TestProvenance_FollowsSymlinks(t *testing.T) {
+	home := t.TempDir()
+	venv := filepath.Join(home, ".local", "pipx", "venvs", "httpie", "bin")
+	bin := filepath.Join(home, ".local", "bin")
+	for _, dir := range []string{venv, bin} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(venv, "http"), nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(bin, "http")
+	if err := os.Symlink(filepath.Join(venv, "http"), link); err != nil {
+		t.Fatal(err)
+	}
+	if got := Provenance(link, home, "apt"); got != "pipx" {
+		t.Errorf("Expected the pipx venv behind the symlink, got %q", got)
+	}
+}
+
+func TestFindManagerConflicts(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GOPATH", "")
+	t.Setenv("PREFIX", "")
+	orig := lookPath
+	t.Cleanup(func() { lookPath = orig })
+	lookPath = func(file string) (string, error) {
+		switch file {
+		case "bat":
+			return filepath.Join(home, ".cargo", "bin", "bat"), nil
+		case "git":
+			return "/usr/bin/git", nil
+		}
+		return "", fmt.Errorf("%s not found", file)
+	}
+
+	ctx := NewInstallationContext(&Platform{OS: "linux", PackageManager: "apt"}, nil, nil)
+	tools := []*Tool{{Tool: interfaces.Tool{Name: "bat"}}, {Tool: interfaces.Tool{Name: "git"}}, {Tool: interfaces.Tool{Name: "fzf"}}}
+	conflicts := FindManagerConflicts(tools, ctx)
+	if len(conflicts) != 1 || conflicts[0].Tool.Name != "bat" || conflicts[0].Existing != "cargo" || conflicts[0].Wanted != "apt" {
+		t.Fatalf("Expected only bat to conflict (cargo vs apt), got %v", conflicts)
+	}
+	if !strings.Contains(conflicts[0].String(), "bat is already installed via cargo") {
+		t.Errorf("Unexpected conflict description: %q", conflicts[0].String())
+	}
+}
+
+func TestPromptConflictResolution(t *testing.T) {
+	c := ManagerConflict{Tool: &Tool{Tool: interfaces.Tool{Name: "bat"}}, Path: "/home/me/.cargo/bin/bat", Existing: "cargo", Wanted: "apt"}
+	tests := map[string]string{"r\n": ConflictReinstall, "s\n": ConflictSkip, "\n": ConflictKeep, "": ConflictKeep, "huh\n": ConflictKeep}
+	for answer, want := range tests {
+		var out bytes.Buffer
+		if got := PromptConflictResolution(c, strings.NewReader(answer), &out); got != want {
+			t.Errorf("PromptConflictResolution(%q) = %q, want %q", answer, got, want)
+		}
+		if !strings.Contains(out.String(), "[r]einstall via apt") {
+			t.Errorf("Expected the prompt to name bootstrap-cli's manager, got %q", out.String())
+		}
+	}
+}
+
+func TestResolveManagerConflicts(t *testing.T) {
+	installer, err := NewInstaller(&Platform{OS: "linux", PackageManager: "apt"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bat, rg, fd := &Tool{Tool: interfaces.Tool{Name: "bat"}}, &Tool{Tool: interfaces.Tool{Name: "ripgrep"}}, &Tool{Tool: interfaces.Tool{Name: "fd"}}
+	conflicts := []ManagerConflict{
+		{Tool: bat, Existing: "cargo", Wanted: "apt"},
+		{Tool: rg, Existing: "brew", Wanted: "apt"},
+		{Tool: fd, Existing: "snap", Wanted: "apt"},
+	}
+	answers := map[string]string{"bat": ConflictKeep, "ripgrep": ConflictSkip, "fd": ConflictReinstall}
+	tools := installer.ResolveManagerConflicts([]*Tool{bat, rg, fd}, conflicts, func(c ManagerConflict) string {
+		return answers[c.Tool.Name]
+	})
+
+	if len(tools) != 2 || tools[0] != bat || tools[1] != fd {
+		t.Errorf("Expected ripgrep to be skipped, got %v", tools)
+	}
+	if got := installer.Context.KeepExisting; len(got) != 1 || got["bat"] != "cargo" {
+		t.Errorf("Expected only bat to be kept, got %v", got)
+	}
+}
+
+func TestValidateConflictResolution(t *testing.T) {
+	if err := ValidateConflictResolution("reinstall"); err != nil {
+		t.Errorf("Expected reinstall to be valid, got %v", err)
+	}
+	if err := ValidateConflictResolution("replace"); err == nil {
+		t.Error("Expected an unknown resolution to be rejected")
+	}
+}
@@ -4,15 +4,26 @@
 package pipeline
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cache"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cmdexec"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/release"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
 )
 
 // InstallationContext holds the context for an installation process
@@ -24,6 +35,17 @@ type InstallationContext struct {
 	Timeout       time.Duration
 	RetryCount    int
 	RetryDelay    time.Duration
+	// Retry is how failing steps are retried; a step's RetryCount and RetryDelay
+	// override its attempts and first delay
+	Retry         cmdexec.RetryPolicy
+	// Concurrency is how many tools install at once (default DefaultConcurrency);
+	// tools installed with a SerialManager still install one at a time
+	Concurrency   int
+	// ToolTimeout is how long installing a tool may take before it fails with a
+	// TimeoutError (default DefaultToolTimeout); a tool's own Timeout overrides it
+	ToolTimeout   time.Duration
+	// Releases installs tools from GitHub releases (default release.NewInstaller())
+	Releases      *release.Installer
 	tools         map[string]*Tool
 	shellConfig   *shell.Config
 	// Add dependency graph
@@ -31,8 +53,82 @@ type InstallationContext struct {
 	// Track installed tools
 	installedTools map[string]bool
 	ProgressChan   chan<- ProgressEvent
+	// LanguageStrategy is the default install strategy for languages that don't set one
+	LanguageStrategy string
+	// VersionManager forces the existing version manager languages are installed
+	// with (mise, asdf, fnm, volta); system.VersionManagerNone never uses one.
+	// Empty detects one in VersionManagerOrder.
+	VersionManager string
+	// VersionManagerOrder is the preference among detected version managers
+	// (default system.DefaultVersionManagerOrder)
+	VersionManagerOrder []string
+	// Verbose streams command output live to stdout/stderr, prefixed with the item name.
+	// Only use it when no TUI owns the terminal.
+	Verbose bool
+	// Lock, when set, pins every tool and language to the version it records
+	Lock *manifest.Lock
+	// ToolManagers overrides the package manager per tool name, taking precedence
+	// over the tool's own PreferredManager
+	ToolManagers map[string]string
+	// Shells are the shells tool completions and the prompt are written for,
+	// the selected shell first; empty means just Platform.Shell for completions
+	// and the selected shell for the prompt
+	Shells []string
+	// LoginShellChange is the login shell switch the user approved; without it the
+	// selected shell is configured but the login shell is left alone
+	LoginShellChange *shell.LoginShellChange
+	// PromptStyle writes a default config for this prompt (see shell.PromptStyles)
+	// and loads it from the selected shell's rc file; empty leaves the prompt alone
+	PromptStyle string
+	// ForcePromptConfig replaces an existing prompt config with the default one
+	ForcePromptConfig bool
+	// NeovimConfig is the starter config cloned into an empty Neovim config
+	// directory: a git URL or GitHub user/repo, NeovimConfigNone for none, or
+	// empty for NeovimStarterConfig
+	NeovimConfig string
+	// NoSudo installs tools that have a GitHub release from it instead of a
+	// system package, and fails steps that would install one
+	NoSudo bool
+	// KeepExisting maps tools to leave as they are to the manager that installed
+	// them (see ResolveManagerConflicts)
+	KeepExisting map[string]string
+	// BatchPackages installs the tools that are plain packages of the platform's
+	// manager with one command before the rest (see batchInstallStep)
+	BatchPackages bool
+
+	// batched are the tools the batch install step installed; it is written
+	// before any tool step runs
+	batched map[string]bool
+
+	// packageBytes totals the installed sizes reported by package manager commands
+	diskMu       sync.Mutex
+	packageBytes int64
+
+	// ctx is cancelled by Cancel; every step's context derives from it
+	ctx      context.Context
+	cancel   context.CancelFunc
+	ctxOnce  sync.Once
+	stepMu   sync.Mutex
+	stepCtxs map[string]context.Context // item -> context of its running step
+
+	// releasesMu guards creating Releases on first use
+	releasesMu sync.Mutex
+
+	// rc buffers the run's rc file edits until the pipeline finishes (see
+	// stageRC); rcOwners maps each staged block to the item that staged it
+	rcMu     sync.Mutex
+	rc       *shell.RCWriter
+	rcOwners map[string]string
+
+	// journal records the run's actions at journalPath, for rolling it back
+	journal     *manifest.Journal
+	journalPath string
 }
 
+// DefaultToolTimeout is how long installing a tool may take unless the tool or
+// InstallationContext.ToolTimeout says otherwise
+const DefaultToolTimeout = 5 * time.Minute
+
 // NewInstallationContext creates a new installation context
 func NewInstallationContext(platform *Platform, pkgManager PackageManager, progressChan chan<- ProgressEvent) *InstallationContext {
 	logger := log.NewInstallLogger(false)
@@ -44,6 +140,8 @@ func NewInstallationContext(platform *Platform, pkgManager PackageManager, progr
 		Timeout:       5 * time.Minute,
 		RetryCount:    3,
 		RetryDelay:    time.Second,
+		Retry:         cmdexec.DefaultRetryPolicy,
+		ToolTimeout:   DefaultToolTimeout,
 		tools:         make(map[string]*Tool),
 		shellConfig:   shell.NewConfig(platform.Shell, logger),
 		dependencyGraph: NewDependencyGraph(),
@@ -52,19 +150,106 @@ func NewInstallationContext(platform *Platform, pkgManager PackageManager, progr
 	}
 }
 
-// GetTool returns a tool by name
+// completionShells are the shells tool completions are written for: Shells, or
+// the platform's shell unless it could not be detected
+func (c *InstallationContext) completionShells() []string {
+	if len(c.Shells) > 0 {
+		return c.Shells
+	}
+	if c.Platform == nil || c.Platform.Shell == "" || c.Platform.Shell == "unknown" {
+		return nil
+	}
+	return []string{c.Platform.Shell}
+}
+
+// lookPath is swapped out in tests
+var lookPath = exec.LookPath
+
+// managerFor returns the package manager that installs t: a ToolManagers override,
+// then the tool's PreferredManager, then the platform's primary manager. A preferred
+// manager that is not installed is ignored.
+func (c *InstallationContext) managerFor(t *Tool) string {
+	for _, manager := range []string{c.ToolManagers[t.Name], t.PreferredManager} {
+		if manager == "" {
+			continue
+		}
+		if manager == c.Platform.PackageManager {
+			return manager
+		}
+		if _, err := lookPath(manager); err == nil {
+			return manager
+		}
+		c.Logger.Warn("Preferred package manager %s for %s is not installed, using %s", manager, t.Name, c.Platform.PackageManager)
+	}
+	return c.Platform.PackageManager
+}
+
+// runShell runs cmdStr with the platform's shell for item like runCommand,
+// logging its start and outcome
+func (c *InstallationContext) runShell(item, cmdStr string) ([]byte, error) {
+	c.Logger.CommandStart(cmdStr, 1, 1)
+	start := time.Now()
+	output, err := c.runCommand(item, c.shellCommand(item, cmdStr))
+	if err != nil {
+		c.Logger.CommandError(cmdStr, err, 1, 1)
+		return output, err
+	}
+	c.Logger.CommandSuccess(cmdStr, time.Since(start))
+	return output, nil
+}
+
+// runCommand runs cmd and returns its combined output. In verbose mode the output
+// is also streamed as it is produced, each line prefixed with label.
+func (c *InstallationContext) runCommand(label string, cmd *exec.Cmd) ([]byte, error) {
+	// Install commands may fetch and pipe scripts; refuse hosts not on the allowlist
+	if err := cache.CheckCommand(strings.Join(cmd.Args, " ")); err != nil {
+		return nil, err
+	}
+	if !c.Verbose {
+		out, err := cmd.CombinedOutput()
+		c.recordPackageSize(out)
+		c.State.AppendOutput(label, out)
+		return out, err
+	}
+
+	var captured bytes.Buffer
+	prefix := fmt.Sprintf("[%s] ", label)
+	stdout := cmdexec.NewPrefixWriter(os.Stdout, prefix)
+	stderr := cmdexec.NewPrefixWriter(os.Stderr, prefix)
+	cmd.Stdout = io.MultiWriter(&captured, stdout)
+	cmd.Stderr = io.MultiWriter(&captured, stderr)
+	err := cmd.Run()
+	stdout.Flush()
+	stderr.Flush()
+	c.recordPackageSize(captured.Bytes())
+	c.State.AppendOutput(label, captured.Bytes())
+	return captured.Bytes(), err
+}
+
+// GetTool returns a tool by name or alias
 func (c *InstallationContext) GetTool(name string) *Tool {
-	return c.tools[name]
+	if tool, ok := c.tools[name]; ok {
+		return tool
+	}
+	for _, tool := range c.tools {
+		if tool.Matches(name) {
+			return tool
+		}
+	}
+	return nil
 }
 
-// AddTool adds a tool to the context and its dependencies to the graph
+// AddTool adds a tool to the context and its dependencies to the graph. The
+// tools it requires are dependencies too once they have been added.
 func (c *InstallationContext) AddTool(tool *Tool) {
 	c.tools[tool.Name] = tool
 	
-	// Add tool dependencies to the graph
-	if len(tool.Dependencies) > 0 {
-		c.dependencyGraph.AddDependency(tool.Name, tool.Dependencies)
+	// Every tool is a node, so the ones without dependencies are ordered too
+	deps := append([]Dependency(nil), tool.Dependencies...)
+	for _, name := range tool.requiredFrom(c.tools) {
+		deps = append(deps, Dependency{Name: name, Type: PackageDependency})
 	}
+	c.dependencyGraph.AddDependency(tool.Name, deps)
 }
 
 // VerifyInstallation verifies that a tool is properly installed
@@ -73,7 +258,7 @@ func (c *InstallationContext) VerifyInstallation(tool *Tool) error {
 		return nil
 	}
 
-	cmd := exec.Command("sh", "-c", tool.Verify.Command.Command)
+	cmd := c.command(tool.Name, "sh", "-c", tool.Verify.Command.Command)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("verification failed: %w (Output: %s)", err, string(output))
@@ -93,7 +278,7 @@ func (c *InstallationContext) VerifyInstallation(tool *Tool) error {
 
 	// Check required files
 	for _, file := range tool.Verify.RequiredFiles {
-		if _, err := exec.Command("test", "-f", file).Output(); err != nil {
+		if _, err := c.command(tool.Name, "test", "-f", file).Output(); err != nil {
 			return fmt.Errorf("required file not found: %s", file)
 		}
 	}
@@ -109,7 +294,7 @@ func (c *InstallationContext) SetupEnvironment(tool *Tool) error {
 	// Execute post-install commands
 	for _, cmd := range strategy.PostInstall {
 		c.Logger.Info("Executing post-install command: %s", cmd.Command)
-		execCmd := exec.Command("sh", "-c", cmd.Command)
+		execCmd := c.command(tool.Name, "sh", "-c", cmd.Command)
 		output, err := execCmd.CombinedOutput()
 		if err != nil {
 			c.Logger.Error("Post-install command failed: %v (Output: %s)", err, string(output))
@@ -151,7 +336,7 @@ func (c *InstallationContext) ExecutePostInstall(tool *Tool) error {
 
 	for _, cmd := range strategy.PostInstall {
 		c.Logger.Info("Executing post-install command: %s", cmd.Command)
-		execCmd := exec.Command("sh", "-c", cmd.Command)
+		execCmd := c.command(tool.Name, "sh", "-c", cmd.Command)
 		output, err := execCmd.CombinedOutput()
 		if err != nil {
 			c.Logger.Error("Post-install command failed: %v (Output: %s)", err, string(output))
@@ -166,17 +351,18 @@ func (c *InstallationContext) ExecutePostInstall(tool *Tool) error {
 // UpdatePath updates the PATH environment variable with installed binary paths
 func (c *InstallationContext) UpdatePath() error {
 	// Get the current PATH
+	binDir := system.BinDir()
 	path := os.Getenv("PATH")
 	if path == "" {
-		path = "/usr/local/bin:/usr/bin:/bin"
+		path = binDir + ":/usr/bin:/bin"
 	}
 
-	// Add common binary paths
+	// Add common binary paths; on Termux the prefix replaces /usr/local
 	paths := []string{
-		"/usr/local/bin",
+		binDir,
 		"/usr/bin",
 		"/bin",
-		"/usr/local/go/bin",
+		filepath.Join(system.InstallPrefix(os.Getenv), "go", "bin"),
 		os.ExpandEnv("$HOME/.local/bin"),
 		os.ExpandEnv("$HOME/go/bin"),
 		os.ExpandEnv("$HOME/.cargo/bin"),
@@ -196,7 +382,7 @@ func (c *InstallationContext) UpdatePath() error {
 	}
 
 	// Execute the source command
-	cmd := exec.Command("sh", "-c", sourceCmd)
+	cmd := c.command("", "sh", "-c", sourceCmd)
 	if output, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("failed to reload shell configuration: %w (output: %s)", err, string(output))
 	}
@@ -211,7 +397,7 @@ func (c *InstallationContext) reloadShellConfig() error {
 		return fmt.Errorf("failed to apply shell configuration: %w", err)
 	}
 
-	cmd := exec.Command("sh", "-c", sourceCmd)
+	cmd := c.command("", "sh", "-c", sourceCmd)
 	if output, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("failed to reload shell configuration: %w (output: %s)", err, string(output))
 	}
@@ -244,11 +430,18 @@ func (c *InstallationContext) ResolveDependencies(tool *Tool) error {
 			continue
 		}
 		
-		// Find the dependency tool
-		depTool, exists := c.tools[depName]
-		if !exists {
+		// Find the dependency tool, which may be referred to by an alias
+		depTool := c.GetTool(depName)
+		if depTool == nil {
 			return fmt.Errorf("dependency %s not found in available tools", depName)
 		}
+		if depTool.Name != depName {
+			depName = depTool.Name
+			if c.installedTools[depName] {
+				c.Logger.Info("Dependency %s already installed, skipping", depName)
+				continue
+			}
+		}
 		
 		// Install the dependency
 		c.Logger.Info("Installing dependency %s for %s", depName, tool.Name)
@@ -287,6 +480,86 @@ func (c *InstallationContext) installTool(tool *Tool) error {
 // sendProgress convenience method on context
 func (c *InstallationContext) sendProgress(event ProgressEvent) {
 	if c.ProgressChan != nil {
-		c.ProgressChan <- event
+		select {
+		case c.ProgressChan <- event:
+		case <-c.Done():
+		}
+	}
+}
+
+// Done returns a channel that is closed once the run is cancelled
+func (c *InstallationContext) Done() <-chan struct{} {
+	return c.Context().Done()
+}
+
+// Context returns the context of the run, which Cancel cancels
+func (c *InstallationContext) Context() context.Context {
+	c.ctxOnce.Do(func() { c.ctx, c.cancel = context.WithCancel(context.Background()) })
+	return c.ctx
+}
+
+// Cancel stops the run, typically because the UI reading ProgressChan has quit:
+// progress sends blocked on a reader that is gone return, the commands of the
+// steps in flight are stopped and no further steps start. It is safe to call
+// more than once.
+func (c *InstallationContext) Cancel() {
+	c.Context()
+	c.cancel()
+}
+
+// StepContext returns the context the running step of item runs its commands
+// with, which ends when the step times out or the run is cancelled
+func (c *InstallationContext) StepContext(item string) context.Context {
+	c.stepMu.Lock()
+	defer c.stepMu.Unlock()
+	if ctx, ok := c.stepCtxs[item]; ok {
+		return ctx
+	}
+	return c.Context()
+}
+
+// startStep gives the step of item about to run a context bounded by timeout
+// (none when it is 0); the returned function ends it
+func (c *InstallationContext) startStep(item string, timeout time.Duration) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(c.Context())
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(c.Context(), timeout)
+	}
+	c.stepMu.Lock()
+	if c.stepCtxs == nil {
+		c.stepCtxs = make(map[string]context.Context)
+	}
+	c.stepCtxs[item] = ctx
+	c.stepMu.Unlock()
+	return ctx, func() {
+		c.stepMu.Lock()
+		delete(c.stepCtxs, item)
+		c.stepMu.Unlock()
+		cancel()
+	}
+}
+
+// command builds a command for the running step of item; it is stopped, along
+// with the processes it started, when the step times out or the run is cancelled
+func (c *InstallationContext) command(item, name string, args ...string) *exec.Cmd {
+	return cmdexec.KillTreeOnCancel(exec.CommandContext(c.StepContext(item), name, args...))
+}
+
+// shellCommand is command running cmdStr with sh, or with cmd on Windows where
+// winget and choco are
+func (c *InstallationContext) shellCommand(item, cmdStr string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return c.command(item, "cmd", "/C", cmdStr)
+	}
+	return c.command(item, "sh", "-c", cmdStr)
+}
+
+// Cancelled reports whether Cancel has been called
+func (c *InstallationContext) Cancelled() bool {
+	select {
+	case <-c.Done():
+		return true
+	default:
+		return false
 	}
 } 
\ No newline at end of file
@@ -3,29 +3,24 @@ package pipeline
 import (
 	"fmt"
 	"sort"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
 )
 
 // DependencyType represents the type of dependency
-type DependencyType string
+type DependencyType = interfaces.DependencyType
 
 const (
 	// PackageDependency represents a package manager dependency
-	PackageDependency DependencyType = "package"
+	PackageDependency = interfaces.PackageDependency
 	// SystemDependency represents a system-level dependency
-	SystemDependency DependencyType = "system"
+	SystemDependency = interfaces.SystemDependency
 	// FileDependency represents a file dependency
-	FileDependency DependencyType = "file"
+	FileDependency = interfaces.FileDependency
 )
 
 // Dependency represents a dependency with its requirements
-type Dependency struct {
-	Name         string
-	Type         DependencyType
-	Version      string
-	Optional     bool
-	Platform     []string // Supported platforms (e.g., ["linux", "darwin"])
-	Alternatives []string // Alternative dependencies that can satisfy this requirement
-}
+type Dependency = interfaces.Dependency
 
 // DependencyGraph manages dependencies and their relationships
 type DependencyGraph struct {
@@ -0,0 +1,199 @@
+package pipeline
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cache"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+)
+
+// DiskUsage is the disk space an install consumed, as far as it can be measured:
+// what the package managers reported plus the growth of known install directories
+type DiskUsage struct {
+	// Packages is the total the package managers reported installing, in bytes
+	Packages int64 `json:"packages_bytes"`
+	// Dirs is how much each known install directory grew, in bytes
+	Dirs map[string]int64 `json:"dirs_bytes,omitempty"`
+}
+
+// Total returns the bytes consumed overall
+func (u *DiskUsage) Total() int64 {
+	total := u.Packages
+	for _, size := range u.Dirs {
+		total += size
+	}
+	return total
+}
+
+// String renders e.g. "Disk space used: 412.0 MB (packages 300.0 MB, ~/.nvm 112.0 MB)"
+func (u *DiskUsage) String() string {
+	var parts []string
+	if u.Packages > 0 {
+		parts = append(parts, "packages "+FormatBytes(u.Packages))
+	}
+	dirs := make([]string, 0, len(u.Dirs))
+	for dir := range u.Dirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		parts = append(parts, fmt.Sprintf("%s %s", dir, FormatBytes(u.Dirs[dir])))
+	}
+	s := "Disk space used: " + FormatBytes(u.Total())
+	if len(parts) > 0 {
+		s += " (" + strings.Join(parts, ", ") + ")"
+	}
+	return s
+}
+
+// FormatBytes renders n in decimal units, as package managers report them
+func FormatBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, exp := float64(n)/unit, 0
+	for value >= unit && exp < 3 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", value, "kMGT"[exp])
+}
+
+// diskUsageDirs are the install locations that grow outside the package
+// manager: version managers, toolchains, shell frameworks and the download cache.
+// Paths under home are labelled with ~.
+func diskUsageDirs(home string) map[string]string {
+	dirs := map[string]string{"/usr/local/go": "/usr/local/go"}
+	for _, rel := range []string{".nvm", ".pyenv", ".goenv", ".asdf", ".local/share/mise", ".cargo", ".rustup", ".oh-my-zsh", ".dotfiles"} {
+		dirs["~/"+rel] = filepath.Join(home, rel)
+	}
+	if dir, err := cache.DefaultDir(); err == nil {
+		dirs["download cache"] = dir
+	}
+	return dirs
+}
+
+// diskSnapshot maps a directory label to the bytes it holds
+type diskSnapshot map[string]int64
+
+// takeDiskSnapshot measures each directory; missing ones count as empty
+func takeDiskSnapshot(dirs map[string]string) diskSnapshot {
+	snapshot := make(diskSnapshot, len(dirs))
+	for label, dir := range dirs {
+		snapshot[label] = dirSize(dir)
+	}
+	return snapshot
+}
+
+// growth returns how much each directory grew since s, leaving out those that did not
+func (s diskSnapshot) growth(after diskSnapshot) map[string]int64 {
+	grown := make(map[string]int64)
+	for label, size := range after {
+		if delta := size - s[label]; delta > 0 {
+			grown[label] = delta
+		}
+	}
+	return grown
+}
+
+// dirSize sums the sizes of the regular files under dir, like du without
+// following symlinks; unreadable entries are skipped
+func dirSize(dir string) int64 {
+	var total int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
+
+// packageSizePatterns match the installed size package managers print:
+// apt ("After this operation, 5,120 kB of additional disk space will be used"),
+// dnf ("Installed size: 12 M"), pacman ("Total Installed Size:  3.50 MiB") and
+// brew ("/opt/homebrew/Cellar/rg/14.1.0: 13 files, 6.1MB")
+var packageSizePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`After this operation, ([\d.,]+) ?([kKMG]?B) of additional disk space will be used`),
+	regexp.MustCompile(`Installed size: ([\d.]+) ?([kKMG]i?B?)\b`),
+	regexp.MustCompile(`Total Installed Size:\s+([\d.]+) ?([KMG]iB)`),
+	regexp.MustCompile(`: \d+ files, ([\d.]+) ?([KMG]?B)\b`),
+}
+
+// ParsePackageSize returns the bytes a package manager reports installing in
+// output, summing every report it finds
+func ParsePackageSize(output string) int64 {
+	var total int64
+	for _, pattern := range packageSizePatterns {
+		for _, match := range pattern.FindAllStringSubmatch(output, -1) {
+			value, err := strconv.ParseFloat(strings.ReplaceAll(match[1], ",", ""), 64)
+			if err != nil {
+				continue
+			}
+			total += int64(value * sizeUnit(match[2]))
+		}
+	}
+	return total
+}
+
+// sizeUnit returns the bytes in a size unit such as kB, M or MiB
+func sizeUnit(unit string) float64 {
+	base := 1000.0
+	if strings.Contains(unit, "i") {
+		base = 1024
+	}
+	switch strings.ToUpper(unit[:1]) {
+	case "K":
+		return base
+	case "M":
+		return base * base
+	case "G":
+		return base * base * base
+	default:
+		return 1
+	}
+}
+
+// recordPackageSize adds the installed size a package manager reported to the total
+func (c *InstallationContext) recordPackageSize(output []byte) {
+	if size := ParsePackageSize(string(output)); size > 0 {
+		c.diskMu.Lock()
+		c.packageBytes += size
+		c.diskMu.Unlock()
+	}
+}
+
+// startDiskUsage snapshots the known install directories before installing
+func (i *Installer) startDiskUsage() diskSnapshot {
+	home, err := system.UserHome()
+	if err != nil {
+		return nil
+	}
+	return takeDiskSnapshot(diskUsageDirs(home))
+}
+
+// finishDiskUsage works out what the install consumed since before was taken
+func (i *Installer) finishDiskUsage(before diskSnapshot) *DiskUsage {
+	c := i.Context
+	c.diskMu.Lock()
+	usage := &DiskUsage{Packages: c.packageBytes}
+	c.diskMu.Unlock()
+	if before != nil {
+		if home, err := system.UserHome(); err == nil {
+			usage.Dirs = before.growth(takeDiskSnapshot(diskUsageDirs(home)))
+		}
+	}
+	return usage
+}
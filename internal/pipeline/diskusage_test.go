@@ -0,0 +1,80 @@
+package pipeline
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParsePackageSize(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   int64
+	}{
+		{"apt", "Need to get 1,234 kB of archives.\nAfter this operation, 5,120 kB of additional disk space will be used.", 5120000},
+		{"dnf", "Total download size: 3 M\nInstalled size: 12 M\n", 12000000},
+		{"pacman", "Total Download Size:   1.00 MiB\nTotal Installed Size:  2.00 MiB\n", 2 * 1024 * 1024},
+		{"brew", "==> Pouring ripgrep--14.1.0.arm64_sonoma.bottle.tar.gz\n/opt/homebrew/Cellar/ripgrep/14.1.0: 13 files, 6.1MB", 6100000},
+		{"no report", "Reading package lists... Done", 0},
+	}
+	for _, tt := range tests {
+		if got := ParsePackageSize(tt.output); got != tt.want {
+			t.Errorf("ParsePackageSize(%s) = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		512:           "512 B",
+		5120000:       "5.1 MB",
+		1500000000:    "1.5 GB",
+		2000000000000: "2.0 TB",
+	}
+	for n, want := range tests {
+		if got := FormatBytes(n); got != want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestDiskSnapshotGrowth(t *testing.T) {
+	home := t.TempDir()
+	nvm := filepath.Join(home, ".nvm")
+	if err := os.MkdirAll(nvm, 0755); err != nil {
+		t.Fatal(err)
+	}
+	dirs := map[string]string{"~/.nvm": nvm, "~/.pyenv": filepath.Join(home, ".pyenv")}
+
+	before := takeDiskSnapshot(dirs)
+	if err := os.WriteFile(filepath.Join(nvm, "node"), make([]byte, 4096), 0644); err != nil {
+		t.Fatal(err)
+	}
+	grown := before.growth(takeDiskSnapshot(dirs))
+
+	if len(grown) != 1 || grown["~/.nvm"] != 4096 {
+		t.Errorf("Expected only ~/.nvm to grow by 4096 bytes, got %v", grown)
+	}
+}
+
+func TestDiskUsageString(t *testing.T) {
+	usage := &DiskUsage{Packages: 300000000, Dirs: map[string]int64{"~/.nvm": 112000000}}
+	got := usage.String()
+	if !strings.HasPrefix(got, "Disk space used: 412.0 MB") || !strings.Contains(got, "~/.nvm 112.0 MB") {
+		t.Errorf("Unexpected disk usage line: %q", got)
+	}
+}
+
+func TestRunCommandRecordsPackageSize(t *testing.T) {
+	ctx := NewInstallationContext(&Platform{OS: "linux", PackageManager: "apt"}, nil, nil)
+	cmd := exec.Command("sh", "-c", "echo 'After this operation, 2 MB of additional disk space will be used.'")
+	if _, err := ctx.runCommand("test", cmd); err != nil {
+		t.Fatalf("runCommand() error = %v", err)
+	}
+	if ctx.packageBytes != 2000000 {
+		t.Errorf("Expected 2 MB recorded, got %d bytes", ctx.packageBytes)
+	}
+}
@@ -0,0 +1,269 @@
+package pipeline
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dockerPackages are Docker Engine, its CLI and the compose and buildx plugins
+// for each package manager. On macOS, Homebrew installs the CLI and colima,
+// which runs the engine in a VM, unless Docker Desktop is already installed.
+var dockerPackages = map[string][]string{
+	"apt":    {"docker-ce", "docker-ce-cli", "containerd.io", "docker-buildx-plugin", "docker-compose-plugin"},
+	"dnf":    {"docker-ce", "docker-ce-cli", "containerd.io", "docker-buildx-plugin", "docker-compose-plugin"},
+	"pacman": {"docker", "docker-compose", "docker-buildx"},
+	"brew":   {"colima", "docker", "docker-compose"},
+}
+
+// osReleasePath is read to pick Docker's repository for the distro
+var osReleasePath = "/etc/os-release"
+
+// DockerRepository is implemented by package managers whose distros install
+// Docker from Docker's own repository (apt and dnf)
+type DockerRepository interface {
+	// DockerRepoCommand returns the commands that add the repository for the
+	// distro described by osRelease (the fields of /etc/os-release)
+	DockerRepoCommand(osRelease map[string]string) (string, error)
+}
+
+// dockerSteps installs Docker from Docker's own apt or dnf repository, or the
+// distro's packages elsewhere, then starts the service and lets the user run
+// docker without sudo on Linux, and checks the daemon answers
+func dockerSteps(t *Tool, ctx *InstallationContext) ([]InstallationStep, error) {
+	manager := ctx.Platform.PackageManager
+	packages, ok := dockerPackages[manager]
+	if !ok {
+		return nil, fmt.Errorf("docker cannot be installed with %s", manager)
+	}
+	commands, err := ctx.commands(manager)
+	if err != nil {
+		return nil, err
+	}
+	var steps []InstallationStep
+	if repo, ok := commands.(DockerRepository); ok {
+		steps = append(steps, dockerRepoStep(t, repo))
+	}
+	steps = append(steps, dockerPackagesStep(t, manager, packages))
+	if ctx.Platform.OS == "linux" {
+		steps = append(steps, dockerServiceStep(t), dockerGroupStep(t))
+	}
+	return append(steps, dockerDaemonStep(t)), nil
+}
+
+// dockerInstalled reports whether a docker CLI is on PATH, from an earlier run,
+// the distro's docker.io or Docker Desktop, so its packages are left alone
+func dockerInstalled(ctx *InstallationContext, t *Tool) bool {
+	if existing, ok := ctx.KeepExisting[t.Name]; ok {
+		ctx.Logger.Info("Keeping %s installed via %s", t.Name, existing)
+		return true
+	}
+	if path, err := exec.LookPath("docker"); err == nil {
+		ctx.Logger.Info("docker is already installed (%s), skipping its packages", path)
+		return true
+	}
+	return false
+}
+
+// dockerRepoStep adds Docker's package repository and signing key
+func dockerRepoStep(t *Tool, repo DockerRepository) InstallationStep {
+	return InstallationStep{
+		Name:        fmt.Sprintf("%s-add-repository", t.Name),
+		Description: "Adding the Docker package repository",
+		Action: func(ctx *InstallationContext) error {
+			// The packages step reports an existing docker
+			if _, keep := ctx.KeepExisting[t.Name]; keep {
+				return nil
+			}
+			if _, err := exec.LookPath("docker"); err == nil {
+				return nil
+			}
+			osRelease, err := readOSRelease(osReleasePath)
+			if err != nil {
+				return err
+			}
+			cmdStr, err := repo.DockerRepoCommand(osRelease)
+			if err != nil {
+				return err
+			}
+			if output, err := ctx.runShell(t.Name, cmdStr); err != nil {
+				return fmt.Errorf("failed to add the Docker repository: %w (Output: %s)", err, string(output))
+			}
+			return nil
+		},
+		Timeout: 5 * time.Minute,
+	}
+}
+
+// readOSRelease parses the KEY=value lines of an os-release file
+func readOSRelease(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer f.Close()
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok || strings.HasPrefix(key, "#") {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		fields[key] = strings.Trim(value, "'")
+	}
+	return fields, scanner.Err()
+}
+
+// dockerPackagesStep installs the Docker packages with manager
+func dockerPackagesStep(t *Tool, manager string, packages []string) InstallationStep {
+	return InstallationStep{
+		Name:        fmt.Sprintf("%s-install-package", t.Name),
+		Description: fmt.Sprintf("Installing %s via %s", strings.Join(packages, ", "), manager),
+		Action: func(ctx *InstallationContext) error {
+			if dockerInstalled(ctx, t) {
+				return nil
+			}
+			cmdStr, err := ctx.installCommand(manager, strings.Join(packages, " "))
+			if err != nil {
+				return err
+			}
+			// Only the packages this run installs are journaled for rollback
+			var fresh []string
+			for _, pkg := range packages {
+				if !ctx.preinstalled(manager, pkg) {
+					fresh = append(fresh, pkg)
+				}
+			}
+			if output, err := ctx.runShell(t.Name, cmdStr); err != nil {
+				return fmt.Errorf("package installation failed: %w (Output: %s)", err, string(output))
+			}
+			for _, pkg := range fresh {
+				ctx.recordPackage(t.Name, manager, pkg)
+			}
+			if manager == "brew" {
+				ctx.Logger.Info("Installed colima to run Docker; start the engine with `colima start`")
+			}
+			return nil
+		},
+		Timeout: 15 * time.Minute,
+	}
+}
+
+// dockerServiceStep starts the Docker service now and at boot where systemd
+// runs; containers and WSL without systemd have to start dockerd themselves
+func dockerServiceStep(t *Tool) InstallationStep {
+	return InstallationStep{
+		Name:        fmt.Sprintf("%s-enable-service", t.Name),
+		Description: "Enabling the Docker service",
+		Action: func(ctx *InstallationContext) error {
+			if _, err := os.Stat("/run/systemd/system"); err != nil {
+				ctx.Logger.Warn("systemd is not running, so the Docker service was not enabled; start the daemon with `sudo service docker start` or `sudo dockerd`")
+				return nil
+			}
+			cmdStr := sudoPrefix() + "systemctl enable --now docker"
+			if output, err := ctx.runShell(t.Name, cmdStr); err != nil {
+				return fmt.Errorf("failed to enable the Docker service: %w (Output: %s)", err, string(output))
+			}
+			return nil
+		},
+		Timeout: 2 * time.Minute,
+	}
+}
+
+// dockerGroupStep adds the user to the docker group, so docker runs without
+// sudo once they log in again
+func dockerGroupStep(t *Tool) InstallationStep {
+	return InstallationStep{
+		Name:        fmt.Sprintf("%s-add-group", t.Name),
+		Description: "Adding you to the docker group",
+		Action: func(ctx *InstallationContext) error {
+			u, err := user.Current()
+			if err != nil {
+				return fmt.Errorf("failed to look up the current user: %w", err)
+			}
+			if u.Uid == "0" {
+				return nil
+			}
+			if member, _ := dockerGroupMember(u); member {
+				ctx.Logger.Info("%s is already in the docker group", u.Username)
+				return nil
+			}
+			sudo := sudoPrefix()
+			cmdStr := fmt.Sprintf("%[1]sgroupadd -f docker && %[1]susermod -aG docker %[2]s", sudo, u.Username)
+			if output, err := ctx.runShell(t.Name, cmdStr); err != nil {
+				return fmt.Errorf("failed to add %s to the docker group: %w (Output: %s)", u.Username, err, string(output))
+			}
+			ctx.Logger.Warn("Added %s to the docker group: log out and back in (or run `newgrp docker`) before running docker without sudo", u.Username)
+			return nil
+		},
+		Timeout: 1 * time.Minute,
+	}
+}
+
+// dockerGroupMember reports whether u is in the docker group and whether this
+// process already has the group, which it only gets at the next login
+func dockerGroupMember(u *user.User) (member, active bool) {
+	group, err := user.LookupGroup("docker")
+	if err != nil {
+		return false, false
+	}
+	ids, err := u.GroupIds()
+	if err != nil || !slices.Contains(ids, group.Gid) {
+		return false, false
+	}
+	gid, err := strconv.Atoi(group.Gid)
+	if err != nil {
+		return true, false
+	}
+	groups, _ := os.Getgroups()
+	return true, slices.Contains(groups, gid) || os.Getgid() == gid
+}
+
+// dockerDaemonStep runs docker version, which needs the daemon. Until the user
+// logs in again, a docker group added in this run only works through sg; an
+// unreachable daemon is a warning, as it may just not be started yet.
+func dockerDaemonStep(t *Tool) InstallationStep {
+	return InstallationStep{
+		Name:        fmt.Sprintf("%s-check-daemon", t.Name),
+		Description: "Checking the Docker daemon answers",
+		Action: func(ctx *InstallationContext) error {
+			output, err := ctx.command(t.Name, "docker", "version").CombinedOutput()
+			if err == nil {
+				ctx.Logger.Info("The Docker daemon is running")
+				return nil
+			}
+			if u, uerr := user.Current(); uerr == nil && u.Uid != "0" {
+				if member, active := dockerGroupMember(u); member && !active {
+					if _, lerr := exec.LookPath("sg"); lerr == nil {
+						if output, err = ctx.command(t.Name, "sg", "docker", "-c", "docker version").CombinedOutput(); err == nil {
+							ctx.Logger.Info("The Docker daemon is running; docker works without sudo after you log in again")
+							return nil
+						}
+					}
+				}
+			}
+			hint := "check `sudo systemctl status docker`"
+			if ctx.Platform.OS == "darwin" {
+				hint = "start it with `colima start` or open Docker Desktop"
+			}
+			ctx.Logger.Warn("docker version could not reach the Docker daemon (%s); %s", lastLine(string(output)), hint)
+			return nil
+		},
+		Timeout: 1 * time.Minute,
+	}
+}
+
+// lastLine returns the last non-empty line of output
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
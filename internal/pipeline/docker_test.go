@@ -0,0 +1,53 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+func TestReadOSRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "os-release")
+	content := "# comment\nNAME=\"Ubuntu\"\nID=ubuntu\nID_LIKE=debian\nVERSION_CODENAME='noble'\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fields, err := readOSRelease(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fields["NAME"] != "Ubuntu" || fields["ID"] != "ubuntu" || fields["ID_LIKE"] != "debian" || fields["VERSION_CODENAME"] != "noble" {
+		t.Errorf("readOSRelease() = %v", fields)
+	}
+}
+
+func TestDockerInstallationSteps(t *testing.T) {
+	tests := []struct {
+		os, manager string
+		want        []string
+	}{
+		{"linux", "apt", []string{"docker-add-repository", "docker-install-package", "docker-enable-service", "docker-add-group", "docker-check-daemon", "docker-verify"}},
+		{"linux", "pacman", []string{"docker-install-package", "docker-enable-service", "docker-add-group", "docker-check-daemon", "docker-verify"}},
+		{"darwin", "brew", []string{"docker-install-package", "docker-check-daemon", "docker-verify"}},
+	}
+	for _, tt := range tests {
+		platform := &Platform{OS: tt.os, PackageManager: tt.manager}
+		ctx := NewInstallationContext(platform, &fakePM{}, nil)
+		tool := &Tool{Tool: interfaces.Tool{Name: "docker", Category: string(CategoryDevelopment)}, Builtin: "docker"}
+		var names []string
+		for _, step := range tool.GenerateInstallationSteps(platform, ctx, true) {
+			names = append(names, step.Name)
+		}
+		if strings.Join(names, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("%s/%s: steps = %v, want %v", tt.os, tt.manager, names, tt.want)
+		}
+	}
+
+	tool := &Tool{Tool: interfaces.Tool{Name: "docker", Category: string(CategoryDevelopment), Description: "Containers"}, Builtin: "podman"}
+	if err := tool.Validate(); err == nil || !strings.Contains(err.Error(), "builtin") {
+		t.Errorf("Expected an unknown builtin installer to be invalid, got %v", err)
+	}
+}
@@ -2,54 +2,102 @@ package pipeline
 
 import (
 	"fmt"
-	"os/exec"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
-	// TODO: Add import for os user home dir if needed for target path
-	// TODO: Add import for logger if needed
-)
 
-// GenerateDotfileCloneSteps creates pipeline steps for cloning a dotfiles repository.
-func GenerateDotfileCloneSteps(repoURL, targetDir string) []InstallationStep {
-	steps := []InstallationStep{}
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/dotfiles"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+)
 
-	// Step 1: Check for git dependency (optional, could be handled by main dependency graph)
-	// steps = append(steps, InstallationStep{
-	// 	Name:        "check-git-for-dotfiles",
-	// 	Description: "Checking for git command",
-	// 	Action: func() error {
-	// 		if _, err := exec.LookPath("git"); err != nil {
-	// 			return fmt.Errorf("git command not found, required for cloning dotfiles: %w", err)
-	// 		}
-	// 		return nil
-	// 	},
-	// })
+// GenerateDotfileSteps creates pipeline steps for checking out a dotfiles
+// repository (a git URL, a GitHub user/repo, or a local path) in targetDir and
+// symlinking it into $HOME. The symlink files of the configs whose source_repo
+// is the repository decide what is linked where; without any, every entry at
+// the top of the repository is linked to ~/.<name>.
+func GenerateDotfileSteps(repoURL, targetDir string, configs []*interfaces.Dotfile) []InstallationStep {
+	return []InstallationStep{
+		dotfileCloneStep(repoURL, targetDir),
+		dotfileLinkStep(repoURL, targetDir, configs),
+	}
+}
 
-	// Step 2: Clone the repository
-	// Construct the full GitHub URL if only user/repo is provided
-	fullRepoURL := repoURL
-	if !strings.Contains(repoURL, "://") { // Basic check if it's not a full URL
-		fullRepoURL = fmt.Sprintf("https://github.com/%s.git", repoURL)
+// dotfileSource returns what to clone for repo and the directory its files are
+// linked from. A local directory that is not a git repository is linked in
+// place and has nothing to clone.
+func dotfileSource(repo, targetDir string) (url, dir string, err error) {
+	if !dotfiles.IsLocalRepo(repo) {
+		if !strings.Contains(repo, "://") && !strings.Contains(repo, "@") {
+			repo = fmt.Sprintf("https://github.com/%s.git", repo)
+		}
+		return repo, targetDir, nil
+	}
+	home, err := system.UserHome()
+	if err != nil {
+		return "", "", err
 	}
+	path, err := filepath.Abs(dotfiles.ExpandHome(repo, home))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve %s: %w", repo, err)
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return "", "", fmt.Errorf("dotfiles directory %s not found", path)
+	}
+	if path == filepath.Clean(targetDir) || !exists(filepath.Join(path, ".git")) {
+		return "", path, nil
+	}
+	return path, targetDir, nil
+}
 
-	cloneStep := InstallationStep{
+// dotfileCloneStep clones the repository into targetDir, or pulls it when
+// targetDir already has a checkout
+func dotfileCloneStep(repoURL, targetDir string) InstallationStep {
+	cloned := false
+	return InstallationStep{
 		Name:        fmt.Sprintf("clone-dotfiles-%s", filepath.Base(repoURL)),
-		Description: fmt.Sprintf("Cloning dotfiles from %s", fullRepoURL),
+		Description: fmt.Sprintf("Cloning dotfiles from %s", repoURL),
 		Action: func(ctx *InstallationContext) error {
-			ctx.sendProgress(TaskLog{TaskID: ctx.State.CurrentStep, Line: fmt.Sprintf("Attempting to clone %s into %s", fullRepoURL, targetDir)})
-			cmd := exec.Command("git", "clone", "--depth=1", fullRepoURL, targetDir)
+			url, dir, err := dotfileSource(repoURL, targetDir)
+			if err != nil {
+				return err
+			}
+			if url == "" {
+				ctx.sendProgress(TaskLog{TaskID: ctx.State.CurrentStep, Line: fmt.Sprintf("Using the dotfiles in %s in place", dir)})
+				return nil
+			}
+			if exists(targetDir) {
+				if !exists(filepath.Join(targetDir, ".git")) {
+					return fmt.Errorf("failed to clone dotfiles repo '%s': %s exists and is not a git repository", url, targetDir)
+				}
+				ctx.sendProgress(TaskLog{TaskID: ctx.State.CurrentStep, Line: fmt.Sprintf("Updating the dotfiles checkout in %s", targetDir)})
+				if output, err := ctx.command(repoURL, "git", "-C", targetDir, "pull", "--ff-only").CombinedOutput(); err != nil {
+					ctx.Logger.Warn("Could not update the dotfiles in %s, linking them as they are: %v (Output: %s)", targetDir, err, strings.TrimSpace(string(output)))
+				}
+				return nil
+			}
+
+			ctx.sendProgress(TaskLog{TaskID: ctx.State.CurrentStep, Line: fmt.Sprintf("Attempting to clone %s into %s", url, targetDir)})
+			// The user named this repository, so unlike downloads it is not held to
+			// the download host allowlist, whether given over https or SSH
+			cmd := ctx.command(repoURL, "git", "clone", "--depth=1", url, targetDir)
 			output, err := cmd.CombinedOutput()
 			if err != nil {
 				ctx.sendProgress(TaskLog{TaskID: ctx.State.CurrentStep, Line: fmt.Sprintf("Clone failed: %s", string(output))})
-				return fmt.Errorf("failed to clone dotfiles repo '%s': %w", fullRepoURL, err)
+				return fmt.Errorf("failed to clone dotfiles repo '%s': %w", url, err)
 			}
+			cloned = true
 			ctx.sendProgress(TaskLog{TaskID: ctx.State.CurrentStep, Line: "Successfully cloned dotfiles."})
 			return nil
 		},
 		Rollback: func(ctx *InstallationContext) error {
+			// A checkout that was there before the run is left alone
+			if !cloned {
+				return nil
+			}
 			ctx.sendProgress(TaskLog{TaskID: ctx.State.CurrentStep, Line: fmt.Sprintf("Attempting to roll back dotfiles clone by removing %s", targetDir)})
-			cmd := exec.Command("rm", "-rf", targetDir)
+			cmd := ctx.command(repoURL, "rm", "-rf", targetDir)
 			output, err := cmd.CombinedOutput()
 			if err != nil {
 				ctx.sendProgress(TaskLog{TaskID: ctx.State.CurrentStep, Line: fmt.Sprintf("Rollback failed: %s", string(output))})
@@ -60,13 +108,59 @@ func GenerateDotfileCloneSteps(repoURL, targetDir string) []InstallationStep {
 		Timeout:    5 * time.Minute,
 		RetryCount: 1,
 	}
-	steps = append(steps, cloneStep)
+}
+
+// dotfileLinkStep symlinks the checked out dotfiles into $HOME, backing up what
+// is in the way and cleaning up the links of earlier runs, and records the
+// links in ~/.bootstrap-cli/dotfiles.json for `dotfiles status`
+func dotfileLinkStep(repoURL, targetDir string, configs []*interfaces.Dotfile) InstallationStep {
+	return InstallationStep{
+		Name:        fmt.Sprintf("link-dotfiles-%s", filepath.Base(repoURL)),
+		Description: "Linking dotfiles into your home directory",
+		Action: func(ctx *InstallationContext) error {
+			_, dir, err := dotfileSource(repoURL, targetDir)
+			if err != nil {
+				return err
+			}
+			home, err := system.UserHome()
+			if err != nil {
+				return err
+			}
+			links, err := dotfiles.PlanLinks(dir, home, repoURL, configs)
+			if err != nil {
+				return err
+			}
+			statePath, err := dotfiles.DefaultStatePath()
+			if err != nil {
+				return err
+			}
+			previous, err := dotfiles.LoadState(statePath)
+			if err != nil {
+				return err
+			}
 
-	// TODO: Add steps for symlinking configurations from the cloned repo
-	// This would involve: 
-	// 1. Determining the source files/dirs within targetDir.
-	// 2. Determining the destination paths in $HOME.
-	// 3. Using os.Symlink or similar.
+			linker := &dotfiles.Linker{Home: home}
+			report, err := linker.Link(dir, links, previous)
+			if report != nil {
+				for _, target := range report.Removed {
+					ctx.Logger.Info("Removed stale dotfile link %s", target)
+				}
+				for _, target := range report.Linked {
+					backup, backedUp := report.BackedUp[target]
+					if backedUp {
+						ctx.Logger.Info("Backed up %s to %s", target, backup)
+					}
+					ctx.recordFile(repoURL, target, backedUp)
+				}
+				ctx.Logger.Info("Linked %d dotfiles (%d already linked)", len(report.Linked), len(report.Unchanged))
+			}
+			if err != nil {
+				return err
+			}
 
-	return steps
-} 
\ No newline at end of file
+			state := &dotfiles.State{Repo: repoURL, Dir: dir, Links: links, LinkedAt: time.Now(), Packages: previous.Packages}
+			return state.Save(statePath)
+		},
+		Timeout: 1 * time.Minute,
+	}
+}
@@ -0,0 +1,41 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDotfileSource(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	target := filepath.Join(home, ".dotfiles")
+	plain := filepath.Join(home, "dots")
+	checkout := filepath.Join(home, "src", "dotfiles")
+	for _, dir := range []string{plain, filepath.Join(checkout, ".git")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		repo, url, dir string
+	}{
+		{"me/dotfiles", "https://github.com/me/dotfiles.git", target},
+		{"git@github.com:me/dotfiles.git", "git@github.com:me/dotfiles.git", target},
+		{"~/dots", "", plain},
+		{"~/src/dotfiles", checkout, target},
+	}
+	for _, tt := range tests {
+		url, dir, err := dotfileSource(tt.repo, target)
+		if err != nil {
+			t.Fatalf("dotfileSource(%s) error = %v", tt.repo, err)
+		}
+		if url != tt.url || dir != tt.dir {
+			t.Errorf("dotfileSource(%s) = %q, %q; want %q, %q", tt.repo, url, dir, tt.url, tt.dir)
+		}
+	}
+	if _, _, err := dotfileSource("~/missing", target); err == nil {
+		t.Error("Expected a missing local directory to be an error")
+	}
+}
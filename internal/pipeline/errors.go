@@ -1,6 +1,35 @@
 package pipeline
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrCancelled is returned when a run is cancelled before all of its steps ran
+var ErrCancelled = errors.New("installation cancelled")
+
+// TimeoutError is returned for a step that did not finish within its timeout
+type TimeoutError struct {
+	Step    string
+	Timeout time.Duration
+	Cause   error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s", e.Timeout)
+}
+
+// Unwrap returns the underlying error
+func (e *TimeoutError) Unwrap() error {
+	return e.Cause
+}
+
+// IsTimeout reports whether err is, or wraps, a TimeoutError
+func IsTimeout(err error) bool {
+	var timeout *TimeoutError
+	return errors.As(err, &timeout)
+}
 
 // InstallationError represents an error during the installation process
 type InstallationError struct {
@@ -14,6 +14,8 @@ type ProgressEvent interface {
 type TaskStart struct {
 	TaskID      string // Unique identifier for the task/step (e.g., step.Name)
 	Description string // User-friendly description (e.g., "Installing git...")
+	Group       string // Summary group the task belongs to (e.g., a tool category)
+	Item        string // Tool, font or language the task installs
 }
 func (TaskStart) IsProgressEvent() {}
 
@@ -32,12 +34,24 @@ type TaskLog struct {
 }
 func (TaskLog) IsProgressEvent() {}
 
+// TaskRetry indicates a failed step is about to be run again.
+type TaskRetry struct {
+	TaskID   string // Unique identifier for the task/step
+	Attempt  int    // The attempt about to start, counting from 1
+	Attempts int    // Total attempts allowed
+	Error    error  // Why the previous attempt failed
+}
+func (TaskRetry) IsProgressEvent() {}
+
 // TaskEnd indicates a specific installation step has finished.
 type TaskEnd struct {
 	TaskID   string        // Unique identifier for the task/step
 	Success  bool          // Whether the step succeeded
 	Error    error         // Error message if Success is false
 	Duration time.Duration // How long the step took
+	Version  string        // Version the step's item reported once verified, if known
+	Output   string        // Command output the step captured, if it failed
+	LogPath  string        // Failure log the output was written to, if any
 }
 func (TaskEnd) IsProgressEvent() {}
 
@@ -69,6 +83,9 @@ func (e TaskProgress) String() string {
 func (e TaskLog) String() string {
 	return fmt.Sprintf("LOG   [%s]: %s", e.TaskID, e.Line)
 }
+func (e TaskRetry) String() string {
+	return fmt.Sprintf("RETRY [%s]: attempt %d/%d - %s", e.TaskID, e.Attempt, e.Attempts, errorString(e.Error))
+}
 func (e TaskEnd) String() string {
 	if e.Success {
 		return fmt.Sprintf("END   [%s]: OK (%.2fs)", e.TaskID, e.Duration.Seconds())
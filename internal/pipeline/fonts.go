@@ -1,16 +1,46 @@
 package pipeline
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
+	"path"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cache"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
 )
 
-// GenerateFontInstallSteps creates pipeline steps for installing a font based on commands.
+// windowsFontsKey is where fonts installed for the current user are registered
+const windowsFontsKey = `HKCU\Software\Microsoft\Windows NT\CurrentVersion\Fonts`
+
+// FontDir returns the directory fonts are installed in for the user on goos:
+// ~/Library/Fonts on macOS, the per-user fonts folder on Windows and
+// ~/.local/share/fonts elsewhere
+func FontDir(goos, home string) string {
+	switch goos {
+	case "darwin":
+		return filepath.Join(home, "Library", "Fonts")
+	case "windows":
+		local := os.Getenv("LOCALAPPDATA")
+		if local == "" {
+			local = filepath.Join(home, "AppData", "Local")
+		}
+		return filepath.Join(local, "Microsoft", "Windows", "Fonts")
+	default:
+		return filepath.Join(home, ".local", "share", "fonts")
+	}
+}
+
+// GenerateFontInstallSteps creates pipeline steps for installing a font. A
+// font with install commands runs them; otherwise its source archive is
+// downloaded and the .ttf and .otf files in it are installed into FontDir.
 func GenerateFontInstallSteps(font *interfaces.Font, platform *Platform) []InstallationStep {
 	steps := []InstallationStep{}
 	if font == nil {
@@ -18,13 +48,30 @@ func GenerateFontInstallSteps(font *interfaces.Font, platform *Platform) []Insta
 		return steps
 	}
 
-	// Determine target directory based on OS (might be needed by install commands via env var?)
-	homeDir, _ := os.UserHomeDir()
-	var targetDir string
-	if platform.OS == "darwin" {
-		targetDir = filepath.Join(homeDir, "Library", "Fonts")
-	} else {
-		targetDir = filepath.Join(homeDir, ".local", "share", "fonts")
+	homeDir, _ := system.UserHome()
+	targetDir := FontDir(platform.OS, homeDir)
+
+	// Fetch the source archive through the download cache, so it can be
+	// pre-downloaded for offline runs; install commands get it as ${source}
+	var sourcePath string
+	if font.Source != "" {
+		sourcePath = filepath.Join(os.TempDir(), "bootstrap-cli-fonts", filepath.Base(font.Source))
+	}
+	if len(font.Install) == 0 {
+		if sourcePath == "" {
+			fmt.Printf("Skipping font %s: it has no source or install commands.\n", font.Name)
+			return steps
+		}
+		// On Linux each font gets its own directory, so it can be removed as a whole
+		if platform.OS != "darwin" && platform.OS != "windows" {
+			targetDir = filepath.Join(targetDir, fontDirName(font.Name))
+		}
+		var installed []string
+		return append(steps,
+			fontDownloadStep(font, sourcePath),
+			fontInstallStep(font, sourcePath, targetDir, platform.OS, &installed),
+			fontVerifyStep(font, platform.OS, &installed),
+		)
 	}
 
 	// Step 1: Ensure target directory exists
@@ -36,19 +83,25 @@ func GenerateFontInstallSteps(font *interfaces.Font, platform *Platform) []Insta
 			return os.MkdirAll(targetDir, 0755)
 		},
 	})
+	if sourcePath != "" {
+		steps = append(steps, fontDownloadStep(font, sourcePath))
+	}
 
 	// Step 2: Run Install Commands
 	for i, cmdStr := range font.Install {
-		installCmdStr := cmdStr 
+		installCmdStr := cmdStr
+		if sourcePath != "" {
+			installCmdStr = strings.ReplaceAll(installCmdStr, "${source}", "file://"+sourcePath)
+		}
 		stepName := fmt.Sprintf("install-font-%s-step%d", font.Name, i)
 		steps = append(steps, InstallationStep{
 			Name:        stepName,
 			Description: fmt.Sprintf("Running font install command: %s", installCmdStr),
 			Action: func(ctx *InstallationContext) error {
 				ctx.sendProgress(TaskLog{TaskID: stepName, Line: fmt.Sprintf("Executing: %s", installCmdStr)})
-				cmd := exec.Command("sh", "-c", installCmdStr)
+				cmd := ctx.command(font.Name, "sh", "-c", installCmdStr)
 				// TODO: Capture live output -> TaskLog
-				output, err := cmd.CombinedOutput()
+				output, err := ctx.runCommand(font.Name, cmd)
 				if len(output) > 0 {
 					ctx.sendProgress(TaskLog{TaskID: stepName, Line: string(output)})
 				}
@@ -70,7 +123,7 @@ func GenerateFontInstallSteps(font *interfaces.Font, platform *Platform) []Insta
 			Description: fmt.Sprintf("Running font verify command: %s", verifyCmdStr),
 			Action: func(ctx *InstallationContext) error {
 				ctx.sendProgress(TaskLog{TaskID: stepName, Line: fmt.Sprintf("Verifying: %s", verifyCmdStr)})
-				cmd := exec.Command("sh", "-c", verifyCmdStr)
+				cmd := ctx.command(font.Name, "sh", "-c", verifyCmdStr)
 				// TODO: Capture live output -> TaskLog
 				err := cmd.Run() // CombinedOutput might be better
 				if err != nil {
@@ -85,5 +138,303 @@ func GenerateFontInstallSteps(font *interfaces.Font, platform *Platform) []Insta
 	return steps
 }
 
-// copyFile is likely no longer needed here if using command-based install
-// func copyFile(src, dst string) error { ... } 
\ No newline at end of file
+// fontDownloadStep fetches the font's source archive into sourcePath
+func fontDownloadStep(font *interfaces.Font, sourcePath string) InstallationStep {
+	return InstallationStep{
+		Name:        fmt.Sprintf("download-font-%s", font.Name),
+		Description: fmt.Sprintf("Downloading %s", font.Source),
+		Action: func(ctx *InstallationContext) error {
+			downloads, err := cache.NewDefault()
+			if err != nil {
+				return fmt.Errorf("failed to open download cache: %w", err)
+			}
+			if err := os.MkdirAll(filepath.Dir(sourcePath), 0755); err != nil {
+				return fmt.Errorf("failed to create font download directory: %w", err)
+			}
+			if err := downloads.Fetch(font.Source, "", font.SHA256, sourcePath); err != nil {
+				return fmt.Errorf("failed to download font: %w", err)
+			}
+			return nil
+		},
+		Timeout: 5 * time.Minute,
+	}
+}
+
+// fontInstallStep extracts the fonts in the archive at sourcePath into
+// targetDir, reporting each one as it is installed, and refreshes the font
+// cache. On Windows each font is registered for the current user as well.
+func fontInstallStep(font *interfaces.Font, sourcePath, targetDir, goos string, installed *[]string) InstallationStep {
+	return InstallationStep{
+		Name:        fmt.Sprintf("install-font-%s", font.Name),
+		Description: fmt.Sprintf("Installing %s into %s", font.Name, targetDir),
+		Action: func(ctx *InstallationContext) error {
+			taskID := ctx.State.CurrentStep
+			*installed = nil // A retry starts over
+			if err := os.MkdirAll(targetDir, 0755); err != nil {
+				return fmt.Errorf("failed to create font directory %s: %w", targetDir, err)
+			}
+			files, err := extractFonts(sourcePath, targetDir, func(file string, done, total int) {
+				ctx.sendProgress(TaskProgress{
+					TaskID:  taskID,
+					Percent: float64(done) * 100 / float64(total),
+					Message: fmt.Sprintf("Installed %d/%d: %s", done, total, filepath.Base(file)),
+				})
+			})
+			for _, file := range files {
+				ctx.recordFile(font.Name, file.Path, file.Existed)
+				*installed = append(*installed, file.Path)
+			}
+			if err != nil {
+				return err
+			}
+			ctx.Logger.Info("Installed %d font files for %s into %s", len(files), font.Name, targetDir)
+
+			if goos == "windows" {
+				registerWindowsFonts(ctx, font.Name, *installed)
+				return nil
+			}
+			if _, err := lookPath("fc-cache"); err != nil {
+				if goos != "darwin" {
+					ctx.Logger.Warn("fc-cache not found; %s is available once the font cache is rebuilt", font.Name)
+				}
+				return nil
+			}
+			ctx.sendProgress(TaskLog{TaskID: taskID, Line: "Refreshing the font cache (fc-cache -f)"})
+			if output, err := ctx.runCommand(font.Name, ctx.command(font.Name, "fc-cache", "-f")); err != nil {
+				return fmt.Errorf("failed to refresh the font cache: %w (Output: %s)", err, strings.TrimSpace(string(output)))
+			}
+			return nil
+		},
+		Timeout: 5 * time.Minute,
+	}
+}
+
+// registerWindowsFonts registers the installed font files for the current
+// user, warning when they cannot be: the files are in place, but applications
+// will not see them until they are installed from Explorer
+func registerWindowsFonts(ctx *InstallationContext, item string, files []string) {
+	for _, file := range files {
+		kind := "TrueType"
+		if strings.EqualFold(filepath.Ext(file), ".otf") {
+			kind = "OpenType"
+		}
+		value := fmt.Sprintf("%s (%s)", strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)), kind)
+		cmd := ctx.command(item, "reg", "add", windowsFontsKey, "/v", value, "/t", "REG_SZ", "/d", file, "/f")
+		if output, err := ctx.runCommand(item, cmd); err != nil {
+			ctx.Logger.Warn("Could not register %s (%v: %s); it is copied to %s, open it and choose Install to finish", filepath.Base(file), err, strings.TrimSpace(string(output)), filepath.Dir(file))
+		}
+	}
+}
+
+// fontVerifyStep checks that fc-list reports the font's family, or where
+// there is no fc-list that the installed files are in place
+func fontVerifyStep(font *interfaces.Font, goos string, installed *[]string) InstallationStep {
+	family := font.Family
+	if family == "" {
+		family = font.Name
+	}
+	return InstallationStep{
+		Name:        fmt.Sprintf("verify-font-%s", font.Name),
+		Description: fmt.Sprintf("Verifying %s is available", family),
+		Action: func(ctx *InstallationContext) error {
+			if _, err := lookPath("fc-list"); err != nil || goos == "windows" {
+				for _, file := range *installed {
+					if _, err := os.Stat(file); err != nil {
+						return fmt.Errorf("font file %s is missing after installation", file)
+					}
+				}
+				return nil
+			}
+			output, err := ctx.command(font.Name, "fc-list", ":", "family").Output()
+			if err != nil {
+				return fmt.Errorf("failed to list installed fonts: %w", err)
+			}
+			if !fontListed(string(output), family) {
+				return fmt.Errorf("%s was installed but fc-list does not report the %q family", font.Name, family)
+			}
+			return nil
+		},
+		Timeout: 1 * time.Minute,
+	}
+}
+
+// fontListed reports whether fc-list output has family, ignoring case and
+// spaces (fc-list reports "JetBrainsMono Nerd Font" for JetBrains Mono)
+func fontListed(output, family string) bool {
+	squash := func(s string) string { return strings.ToLower(strings.ReplaceAll(s, " ", "")) }
+	want := squash(family)
+	for _, line := range strings.Split(output, "\n") {
+		// A font with several names lists them separated by commas
+		for _, name := range strings.Split(line, ",") {
+			if squash(name) == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fontDirName returns the directory a font is installed in under the font
+// directory on Linux, e.g. JetBrainsMonoNerdFont
+func fontDirName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '/' || r == '\\' || r == '.' {
+			return -1
+		}
+		return r
+	}, name)
+}
+
+// fontFile is a font written by extractFonts
+type fontFile struct {
+	Path string
+	// Existed is set when the file was already installed and is replaced
+	Existed bool
+}
+
+// isFont reports whether an archive entry is a font to install, leaving out
+// the resource forks macOS adds to zip files
+func isFont(name string) bool {
+	base := path.Base(name)
+	ext := strings.ToLower(path.Ext(base))
+	return (ext == ".ttf" || ext == ".otf") && !strings.HasPrefix(base, ".") && !strings.Contains(name, "__MACOSX/")
+}
+
+// extractFonts writes the .ttf and .otf files of the archive (zip or tar.gz)
+// at archive into dir, flattening the archive's directories, and calls
+// progress after each one. A source that is a single font file is copied.
+func extractFonts(archive, dir string, progress func(file string, done, total int)) ([]fontFile, error) {
+	lower := strings.ToLower(archive)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return extractFontZip(archive, dir, progress)
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return extractFontTarGz(archive, dir, progress)
+	case isFont(lower):
+		f, err := os.Open(archive)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		file, err := writeFont(f, filepath.Join(dir, filepath.Base(archive)))
+		if err != nil {
+			return nil, err
+		}
+		progress(file.Path, 1, 1)
+		return []fontFile{file}, nil
+	case strings.HasSuffix(lower, ".tar.xz"):
+		return nil, fmt.Errorf("cannot extract %s: .tar.xz archives are not supported, use the font's .zip release", filepath.Base(archive))
+	default:
+		return nil, fmt.Errorf("cannot extract %s: expected a .zip or .tar.gz archive, or a .ttf or .otf file", filepath.Base(archive))
+	}
+}
+
+func extractFontZip(archive, dir string, progress func(file string, done, total int)) ([]fontFile, error) {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read font archive: %w", err)
+	}
+	defer r.Close()
+	var entries []*zip.File
+	for _, f := range r.File {
+		if !f.FileInfo().IsDir() && isFont(f.Name) {
+			entries = append(entries, f)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no .ttf or .otf files in %s", filepath.Base(archive))
+	}
+	var files []fontFile
+	for i, entry := range entries {
+		rc, err := entry.Open()
+		if err != nil {
+			return files, fmt.Errorf("failed to read font archive: %w", err)
+		}
+		file, err := writeFont(rc, filepath.Join(dir, path.Base(entry.Name)))
+		rc.Close()
+		if err != nil {
+			return files, err
+		}
+		files = append(files, file)
+		progress(file.Path, i+1, len(entries))
+	}
+	return files, nil
+}
+
+func extractFontTarGz(archive, dir string, progress func(file string, done, total int)) ([]fontFile, error) {
+	// The first pass counts the fonts, so progress can be reported as a share
+	total := 0
+	if err := walkTarGz(archive, func(hdr *tar.Header, _ io.Reader) error {
+		total++
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("no .ttf or .otf files in %s", filepath.Base(archive))
+	}
+	var files []fontFile
+	err := walkTarGz(archive, func(hdr *tar.Header, r io.Reader) error {
+		file, err := writeFont(r, filepath.Join(dir, path.Base(hdr.Name)))
+		if err != nil {
+			return err
+		}
+		files = append(files, file)
+		progress(file.Path, len(files), total)
+		return nil
+	})
+	return files, err
+}
+
+// walkTarGz calls fn for each font in the tar.gz archive
+func walkTarGz(archive string, fn func(hdr *tar.Header, r io.Reader) error) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to read font archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read font archive: %w", err)
+		}
+		if hdr.Typeflag == tar.TypeReg && isFont(hdr.Name) {
+			if err := fn(hdr, tr); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// writeFont writes r to dest through a temporary file
+func writeFont(r io.Reader, dest string) (fontFile, error) {
+	file := fontFile{Path: dest, Existed: exists(dest)}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), "."+filepath.Base(dest)+"-")
+	if err != nil {
+		return file, fmt.Errorf("failed to create %s: %w", dest, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return file, fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return file, fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return file, fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return file, fmt.Errorf("failed to install %s: %w", dest, err)
+	}
+	return file, nil
+}
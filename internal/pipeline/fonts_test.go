@@ -0,0 +1,169 @@
+package pipeline
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// fontArchiveEntries are written into the test archives; only the fonts are installed
+var fontArchiveEntries = []string{
+	"README.md",
+	"fonts/ttf/JetBrainsMonoNerdFont-Regular.ttf",
+	"fonts/ttf/JetBrainsMonoNerdFont-Bold.ttf",
+	"OFL.otf",
+	"__MACOSX/fonts/ttf/._JetBrainsMonoNerdFont-Regular.ttf",
+}
+
+func writeFontZip(t *testing.T, path string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, name := range fontArchiveEntries {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(name))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeFontTarGz(t *testing.T, path string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, name := range fontArchiveEntries {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(name)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(name))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractFonts(t *testing.T) {
+	want := []string{"JetBrainsMonoNerdFont-Bold.ttf", "JetBrainsMonoNerdFont-Regular.ttf", "OFL.otf"}
+	for name, write := range map[string]func(*testing.T, string){
+		"JetBrainsMono.zip":    writeFontZip,
+		"JetBrainsMono.tar.gz": writeFontTarGz,
+	} {
+		t.Run(name, func(t *testing.T) {
+			archive := filepath.Join(t.TempDir(), name)
+			write(t, archive)
+			dir := t.TempDir()
+			// An installed font is replaced and reported as existing
+			if err := os.WriteFile(filepath.Join(dir, "OFL.otf"), []byte("old"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			var reported []int
+			files, err := extractFonts(archive, dir, func(_ string, done, total int) {
+				if total != len(want) {
+					t.Errorf("progress total = %d, want %d", total, len(want))
+				}
+				reported = append(reported, done)
+			})
+			if err != nil {
+				t.Fatalf("extractFonts() error = %v", err)
+			}
+			if len(reported) != len(want) || reported[len(reported)-1] != len(want) {
+				t.Errorf("progress calls = %v, want 1..%d", reported, len(want))
+			}
+
+			var got []string
+			for _, file := range files {
+				got = append(got, filepath.Base(file.Path))
+				if existed := filepath.Base(file.Path) == "OFL.otf"; file.Existed != existed {
+					t.Errorf("%s Existed = %v, want %v", file.Path, file.Existed, existed)
+				}
+			}
+			sort.Strings(got)
+			if len(got) != len(want) {
+				t.Fatalf("extracted %v, want %v", got, want)
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("extracted %v, want %v", got, want)
+				}
+			}
+			entries, _ := os.ReadDir(dir)
+			if len(entries) != len(want) {
+				t.Errorf("font directory has %d entries, want only the %d fonts", len(entries), len(want))
+			}
+			if data, _ := os.ReadFile(filepath.Join(dir, "OFL.otf")); string(data) != "OFL.otf" {
+				t.Errorf("OFL.otf = %q, want it replaced", data)
+			}
+		})
+	}
+}
+
+func TestExtractFontsUnsupported(t *testing.T) {
+	dir := t.TempDir()
+	progress := func(string, int, int) {}
+	if _, err := extractFonts(filepath.Join(dir, "JetBrainsMono.tar.xz"), dir, progress); err == nil {
+		t.Error("Expected a .tar.xz archive to be an error")
+	}
+
+	empty := filepath.Join(dir, "empty.zip")
+	f, err := os.Create(empty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zip.NewWriter(f).Close()
+	f.Close()
+	if _, err := extractFonts(empty, dir, progress); err == nil {
+		t.Error("Expected an archive without fonts to be an error")
+	}
+}
+
+func TestFontListed(t *testing.T) {
+	output := "DejaVu Sans\nJetBrainsMono Nerd Font,JetBrainsMono Nerd Font Mono\nNoto Sans\n"
+	tests := map[string]bool{
+		"JetBrainsMono Nerd Font":      true,
+		"JetBrains Mono Nerd Font":     true,
+		"jetbrainsmono nerd font mono": true,
+		"FiraCode Nerd Font":           false,
+		"JetBrains":                    false,
+	}
+	for family, want := range tests {
+		if got := fontListed(output, family); got != want {
+			t.Errorf("fontListed(%q) = %v, want %v", family, got, want)
+		}
+	}
+}
+
+func TestFontDir(t *testing.T) {
+	t.Setenv("LOCALAPPDATA", "")
+	home := "/home/me"
+	tests := map[string]string{
+		"linux":   filepath.Join(home, ".local", "share", "fonts"),
+		"darwin":  filepath.Join(home, "Library", "Fonts"),
+		"windows": filepath.Join(home, "AppData", "Local", "Microsoft", "Windows", "Fonts"),
+	}
+	for goos, want := range tests {
+		if got := FontDir(goos, home); got != want {
+			t.Errorf("FontDir(%s) = %s, want %s", goos, got, want)
+		}
+	}
+}
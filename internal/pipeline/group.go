@@ -0,0 +1,78 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IsGroup reports whether the tool is a group ("meta-tool") that installs its members
+func (t *Tool) IsGroup() bool {
+	return len(t.Group) > 0
+}
+
+// validateGroup checks that a group lists members and nothing to install itself
+func (t *Tool) validateGroup() error {
+	for i, member := range t.Group {
+		if member == "" {
+			return fmt.Errorf("group member %d cannot be empty", i)
+		}
+		if strings.EqualFold(member, t.Name) {
+			return fmt.Errorf("group %s cannot contain itself", t.Name)
+		}
+	}
+	if len(t.Install.PackageNames) > 0 || len(t.Install.CustomInstall) > 0 || len(t.PlatformConfig) > 0 {
+		return fmt.Errorf("group %s cannot also define packages or install commands", t.Name)
+	}
+	return nil
+}
+
+// ExpandGroups replaces every group in tools with its members, looked up in
+// catalog (nested groups are expanded too). Each tool appears once, in
+// first-seen order. The returned map gives, for each member pulled in by a
+// group, the name of the outermost group that selected it.
+func ExpandGroups(tools, catalog []*Tool) ([]*Tool, map[string]string, error) {
+	expanded := make([]*Tool, 0, len(tools))
+	groupOf := make(map[string]string)
+	seen := make(map[string]bool)
+
+	var expand func(tool *Tool, group string, path []string) error
+	expand = func(tool *Tool, group string, path []string) error {
+		if !tool.IsGroup() {
+			if seen[tool.Name] {
+				return nil
+			}
+			seen[tool.Name] = true
+			expanded = append(expanded, tool)
+			if group != "" {
+				groupOf[tool.Name] = group
+			}
+			return nil
+		}
+		for _, name := range path {
+			if name == tool.Name {
+				return fmt.Errorf("group cycle: %s -> %s", strings.Join(path, " -> "), tool.Name)
+			}
+		}
+		if group == "" {
+			group = tool.Name
+		}
+		path = append(path, tool.Name)
+		for _, name := range tool.Group {
+			member := FindTool(catalog, name)
+			if member == nil {
+				return fmt.Errorf("group %s: member %s is not in the catalog", tool.Name, name)
+			}
+			if err := expand(member, group, path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, tool := range tools {
+		if err := expand(tool, "", nil); err != nil {
+			return nil, nil, err
+		}
+	}
+	return expanded, groupOf, nil
+}
@@ -0,0 +1,76 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+)
+
+func groupTestTool(name string, members ...string) *Tool {
+	tool := NewTool(name, CategoryDevelopment)
+	if len(members) > 0 {
+		tool.Group = members
+		return tool
+	}
+	tool.Install.PackageNames = map[string]string{"apt": name}
+	return tool
+}
+
+func TestExpandGroups(t *testing.T) {
+	bat, fd, rg := groupTestTool("bat"), groupTestTool("fd"), groupTestTool("ripgrep")
+	search := groupTestTool("search", "fd", "rg")
+	rg.Aliases = []string{"rg"}
+	modern := groupTestTool("modern-cli", "bat", "search", "fd")
+	catalog := []*Tool{bat, fd, rg, search, modern}
+
+	tools, groupOf, err := ExpandGroups([]*Tool{fd, modern, bat}, catalog)
+	if err != nil {
+		t.Fatalf("ExpandGroups() error = %v", err)
+	}
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	if got := strings.Join(names, ","); got != "fd,bat,ripgrep" {
+		t.Errorf("Expected deduplicated members in first-seen order, got %s", got)
+	}
+	if groupOf["bat"] != "modern-cli" || groupOf["ripgrep"] != "modern-cli" {
+		t.Errorf("Expected members attributed to the outermost group, got %v", groupOf)
+	}
+	if _, ok := groupOf["fd"]; ok {
+		t.Errorf("fd was selected directly and should not be attributed to a group")
+	}
+}
+
+func TestExpandGroups_Errors(t *testing.T) {
+	a := groupTestTool("a", "b")
+	b := groupTestTool("b", "a")
+	if _, _, err := ExpandGroups([]*Tool{a}, []*Tool{a, b}); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("Expected a cycle error, got %v", err)
+	}
+
+	missing := groupTestTool("extras", "nope")
+	if _, _, err := ExpandGroups([]*Tool{missing}, []*Tool{missing}); err == nil {
+		t.Error("Expected an error for a member missing from the catalog")
+	}
+}
+
+func TestTool_ValidateGroup(t *testing.T) {
+	group := groupTestTool("suite", "bat")
+	group.Description = "A bundle"
+	if err := group.Validate(); err != nil {
+		t.Errorf("Validate() on a group error = %v", err)
+	}
+
+	group.Install.PackageNames = map[string]string{"apt": "suite"}
+	if err := group.Validate(); err == nil {
+		t.Error("Expected a group with packages to be invalid")
+	}
+}
+
+func TestPreflight_PassesGroups(t *testing.T) {
+	platform := &Platform{OS: "linux", PackageManager: "apt"}
+	tools, issues := Preflight([]*Tool{groupTestTool("suite", "bat")}, platform)
+	if len(tools) != 1 || len(issues) != 0 {
+		t.Errorf("Expected groups to pass preflight, got %d tools and %v", len(tools), issues)
+	}
+}
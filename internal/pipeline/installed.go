@@ -0,0 +1,264 @@
+package pipeline
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+)
+
+// presence is what a run found on the system before it installed anything
+type presence struct {
+	tools     map[string]bool
+	languages map[string]bool
+	// shell is the shell the run configures, and shellFound whether it was
+	// already installed
+	shell      string
+	shellFound bool
+	// prompt is the prompt style the run configures, and promptConfig whether
+	// its config file already existed
+	prompt       string
+	promptConfig bool
+}
+
+// installedPath returns where the installed snapshot is kept
+func (i *Installer) installedPath() (string, error) {
+	if i.InstalledPath != "" {
+		return i.InstalledPath, nil
+	}
+	return manifest.DefaultInstalledPath()
+}
+
+// notePresence records which selections are already on the system before the
+// run, so the snapshot can mark them as pre-existing for uninstall to leave alone
+func (i *Installer) notePresence(tools []*Tool, languages []*interfaces.Language, sh *interfaces.Shell, prompt string) {
+	p := &presence{tools: make(map[string]bool), languages: make(map[string]bool), prompt: prompt}
+	if sh != nil {
+		p.shell = shellCommand(sh)
+		_, err := lookPath(p.shell)
+		p.shellFound = err == nil
+	}
+	for _, tool := range tools {
+		_, kept := i.Context.KeepExisting[tool.Name]
+		if _, err := lookPath(toolCommand(tool)); kept || err == nil {
+			p.tools[tool.Name] = true
+		}
+	}
+	for _, lang := range languages {
+		if _, err := lookPath(languageCommand(lang)); err == nil {
+			p.languages[lang.Name] = true
+		}
+	}
+	if prompt != "" {
+		if home, err := system.UserHome(); err == nil {
+			_, err := os.Stat(shell.PromptConfigPath(home, prompt))
+			p.promptConfig = err == nil
+		}
+	}
+	i.presence = p
+}
+
+// preExisting keeps what an earlier snapshot recorded; a new item is
+// pre-existing when the run found it before installing
+func preExisting(previous manifest.InstalledItem, recorded bool, found bool) bool {
+	if recorded {
+		return previous.PreExisting
+	}
+	return found
+}
+
+// skipInstalled drops the tools and languages the installed snapshot records
+// that are still on PATH, so running up again does not reinstall them. Nothing
+// is skipped with Reinstall, when installing from a lock, or for a tool with a
+// MinVersion, whose installed version still has to be checked.
+func (i *Installer) skipInstalled(tools []*Tool, languages []*interfaces.Language) ([]*Tool, []*interfaces.Language) {
+	if i.Reinstall || i.Context.Lock != nil {
+		return tools, languages
+	}
+	path, err := i.installedPath()
+	if err != nil {
+		return tools, languages
+	}
+	s, err := manifest.LoadInstalled(path)
+	if err != nil {
+		i.Logger.Warn("Failed to read installed snapshot: %v", err)
+		return tools, languages
+	}
+
+	keptTools := make([]*Tool, 0, len(tools))
+	for _, tool := range tools {
+		if tool.MinVersion == "" && manifest.Has(s.Tools, tool.Name, lookPath) {
+			i.Logger.Info("Skipping %s: already installed", tool.Name)
+			continue
+		}
+		keptTools = append(keptTools, tool)
+	}
+	keptLanguages := make([]*interfaces.Language, 0, len(languages))
+	for _, lang := range languages {
+		if manifest.Has(s.Languages, lang.Name, lookPath) {
+			i.Logger.Info("Skipping %s: already installed", lang.Name)
+			continue
+		}
+		keptLanguages = append(keptLanguages, lang)
+	}
+	return keptTools, keptLanguages
+}
+
+// recordInstalled adds the tools, languages and shell a run installed or
+// upgraded to the installed snapshot, with the versions the package manager
+// reports, or else the ones verification found
+func (i *Installer) recordInstalled(tools []*Tool, languages []*interfaces.Language) error {
+	path, err := i.installedPath()
+	if err != nil {
+		return err
+	}
+	resolver, _ := i.Context.PackageManager.(VersionResolver)
+	pm := i.Context.Platform.PackageManager
+	version := func(pkg string) string {
+		if resolver == nil || pkg == "" {
+			return ""
+		}
+		v, err := resolver.GetVersion(pkg)
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(v)
+	}
+
+	found := i.presence
+	if found == nil {
+		found = &presence{}
+	}
+
+	now := time.Now()
+	return manifest.UpdateInstalled(path, func(s *manifest.Installed) {
+		for _, tool := range tools {
+			if len(tool.Group) > 0 {
+				continue
+			}
+			var item manifest.InstalledItem
+			if binary := i.Context.State.Binary(tool.Name); binary != "" {
+				item = manifest.InstalledItem{Manager: manifest.ManagerGitHubRelease, Path: binary, Command: toolCommand(tool)}
+			} else {
+				manager := i.provenance(tool)
+				item = manifest.InstalledItem{
+					Version: version(tool.PackageFor(pm)),
+					Manager: manager,
+					Package: tool.PackageFor(manager),
+					Command: toolCommand(tool),
+				}
+			}
+			if item.Version == "" {
+				item.Version = i.Context.State.Version(tool.Name)
+			}
+			previous, recorded := s.Tools[tool.Name]
+			item.PreExisting = preExisting(previous, recorded, found.tools[tool.Name])
+			s.Tools[tool.Name] = stamp(item, previous, now)
+		}
+		for _, lang := range languages {
+			install, ok := i.Context.State.Installer(lang.Name)
+			if !ok {
+				install.Installer = pm
+				if packages := lang.SystemPackages(pm); len(packages) > 0 {
+					install.Package = packages[0]
+				}
+			}
+			item := manifest.InstalledItem{Manager: install.Installer, Package: install.Package, Command: languageCommand(lang)}
+			// Only the package manager knows the version of its own packages
+			if install.Installer == pm {
+				item.Version = version(install.Package)
+			}
+			if item.Version == "" {
+				item.Version = i.Context.State.Version(lang.Name)
+			}
+			previous, recorded := s.Languages[lang.Name]
+			item.PreExisting = preExisting(previous, recorded, found.languages[lang.Name])
+			s.Languages[lang.Name] = stamp(item, previous, now)
+		}
+		if name := found.shell; name != "" {
+			item := manifest.InstalledItem{Version: version(name), Manager: pm, Package: name, Command: name}
+			previous, recorded := s.Shells[name]
+			item.PreExisting = preExisting(previous, recorded, found.shellFound)
+			s.Shells[name] = stamp(item, previous, now)
+		}
+		if style := found.prompt; style != "" {
+			if home, err := system.UserHome(); err == nil {
+				item := manifest.InstalledItem{Path: shell.PromptConfigPath(home, style)}
+				previous, recorded := s.Prompts[style]
+				item.PreExisting = preExisting(previous, recorded, found.promptConfig)
+				s.Prompts[style] = stamp(item, previous, now)
+			}
+		}
+	})
+}
+
+// recordedTool returns what the installed snapshot records for tool
+func (i *Installer) recordedTool(tool *Tool) (manifest.InstalledItem, bool) {
+	path, err := i.installedPath()
+	if err != nil {
+		return manifest.InstalledItem{}, false
+	}
+	s, err := manifest.LoadInstalled(path)
+	if err != nil {
+		return manifest.InstalledItem{}, false
+	}
+	item, ok := s.Tools[tool.Name]
+	return item, ok
+}
+
+// forgetInstalled removes an uninstalled tool from the installed snapshot
+func (i *Installer) forgetInstalled(tool *Tool) error {
+	path, err := i.installedPath()
+	if err != nil {
+		return err
+	}
+	return manifest.UpdateInstalled(path, func(s *manifest.Installed) {
+		delete(s.Tools, tool.Name)
+	})
+}
+
+// stamp keeps the previous install time unless the item is new or its version changed
+func stamp(item, previous manifest.InstalledItem, now time.Time) manifest.InstalledItem {
+	item.InstalledAt = now
+	if !previous.InstalledAt.IsZero() && previous.Version == item.Version {
+		item.InstalledAt = previous.InstalledAt
+	}
+	return item
+}
+
+// provenance returns the manager a tool was installed with, or found installed by
+// when the existing install was kept
+func (i *Installer) provenance(tool *Tool) string {
+	if existing, ok := i.Context.KeepExisting[tool.Name]; ok {
+		return existing
+	}
+	return i.Context.managerFor(tool)
+}
+
+// toolCommand returns the executable that shows a tool is on PATH: its name or
+// the first alias found, since packages like fd-find install as fdfind
+func toolCommand(tool *Tool) string {
+	for _, name := range append([]string{tool.Name}, tool.Aliases...) {
+		if _, err := lookPath(name); err == nil {
+			return name
+		}
+	}
+	return tool.Name
+}
+
+// shellCommand returns the executable of a shell, e.g. zsh
+func shellCommand(sh *interfaces.Shell) string {
+	return strings.ToLower(sh.Name)
+}
+
+// languageCommand returns the executable of a language, taken from its verify command
+func languageCommand(lang *interfaces.Language) string {
+	if fields := strings.Fields(lang.VerifyCommand); len(fields) > 0 {
+		return fields[0]
+	}
+	return strings.ToLower(lang.Name)
+}
@@ -0,0 +1,183 @@
+package pipeline
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+)
+
+// versionPM reports a fixed version for every package
+type versionPM struct{ fakePM }
+
+func (v *versionPM) GetVersion(pkg string) (string, error) { return "1.2.3\n", nil }
+
+func TestInstaller_InstalledSnapshot(t *testing.T) {
+	orig := lookPath
+	t.Cleanup(func() { lookPath = orig })
+	lookPath = func(file string) (string, error) {
+		if file == "fdfind" {
+			return "/usr/bin/fdfind", nil
+		}
+		return "", fmt.Errorf("%s not found", file)
+	}
+
+	installer, err := NewInstaller(&Platform{OS: "linux", PackageManager: "apt"}, &versionPM{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	installer.InstalledPath = filepath.Join(t.TempDir(), manifest.InstalledFileName)
+
+	fd := &Tool{Tool: interfaces.Tool{Name: "fd", Aliases: []string{"fdfind"}}}
+	group := &Tool{Tool: interfaces.Tool{Name: "modern-cli"}, Group: []string{"fd"}}
+	golang := &interfaces.Language{Name: "Go", VerifyCommand: "go version"}
+	if err := installer.recordInstalled([]*Tool{fd, group}, []*interfaces.Language{golang}); err != nil {
+		t.Fatalf("recordInstalled() error = %v", err)
+	}
+
+	s, err := manifest.LoadInstalled(installer.InstalledPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := manifest.InstalledItem{Version: "1.2.3", Manager: "apt", Command: "fdfind"}
+	if got := s.Tools["fd"]; got.Version != want.Version || got.Manager != want.Manager || got.Command != want.Command {
+		t.Errorf("Tools[fd] = %+v, want %+v", got, want)
+	}
+	if _, ok := s.Tools["modern-cli"]; ok {
+		t.Error("Expected groups to be left out of the snapshot")
+	}
+	if got := s.Languages["Go"]; got.Command != "go" {
+		t.Errorf("Expected the Go command from its verify command, got %+v", got)
+	}
+
+	if err := installer.forgetInstalled(fd); err != nil {
+		t.Fatalf("forgetInstalled() error = %v", err)
+	}
+	if s, _ := manifest.LoadInstalled(installer.InstalledPath); len(s.Tools) != 0 {
+		t.Errorf("Expected fd to be removed, got %+v", s.Tools)
+	}
+}
+
+func TestInstaller_InstalledSnapshotRelease(t *testing.T) {
+	orig := lookPath
+	t.Cleanup(func() { lookPath = orig })
+	lookPath = func(file string) (string, error) { return "/home/me/.local/bin/" + file, nil }
+
+	installer, err := NewInstaller(&Platform{OS: "linux", PackageManager: "apt"}, &versionPM{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	installer.InstalledPath = filepath.Join(t.TempDir(), manifest.InstalledFileName)
+	installer.Context.State.RecordBinary("lazygit", "/home/me/.local/bin/lazygit")
+	installer.Context.State.RecordVersion("lazygit", "0.44.1")
+
+	if err := installer.recordInstalled([]*Tool{{Tool: interfaces.Tool{Name: "lazygit"}}}, nil); err != nil {
+		t.Fatalf("recordInstalled() error = %v", err)
+	}
+	s, _ := manifest.LoadInstalled(installer.InstalledPath)
+	want := manifest.InstalledItem{Version: "0.44.1", Manager: manifest.ManagerGitHubRelease, Command: "lazygit", Path: "/home/me/.local/bin/lazygit"}
+	if got := s.Tools["lazygit"]; got.Version != want.Version || got.Manager != want.Manager || got.Path != want.Path || got.Package != "" {
+		t.Errorf("Tools[lazygit] = %+v, want %+v", got, want)
+	}
+}
+
+func TestInstaller_InstalledSnapshotLanguageInstaller(t *testing.T) {
+	installer, err := NewInstaller(&Platform{OS: "linux", PackageManager: "apt"}, &versionPM{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	installer.InstalledPath = filepath.Join(t.TempDir(), manifest.InstalledFileName)
+	installer.Context.State.RecordInstaller("Node.js", "mise", "node")
+	installer.Context.State.RecordVersion("Node.js", "22.11.0")
+	installer.Context.State.RecordInstaller("Python", "apt", "python3")
+	node := &interfaces.Language{Name: "Node.js", VerifyCommand: "node --version"}
+	python := &interfaces.Language{Name: "Python", VerifyCommand: "python3 --version"}
+
+	if err := installer.recordInstalled(nil, []*interfaces.Language{node, python}); err != nil {
+		t.Fatalf("recordInstalled() error = %v", err)
+	}
+	s, _ := manifest.LoadInstalled(installer.InstalledPath)
+	if got := s.Languages["Node.js"]; got.Manager != "mise" || got.Package != "node" || got.Version != "22.11.0" {
+		t.Errorf("Expected Node.js to be recorded as installed with mise, got %+v", got)
+	}
+	if got := s.Languages["Python"]; got.Manager != "apt" || got.Package != "python3" || got.Version != "1.2.3" {
+		t.Errorf("Expected Python to be recorded as the apt package, got %+v", got)
+	}
+}
+
+func TestInstaller_InstalledSnapshotPreExisting(t *testing.T) {
+	orig := lookPath
+	t.Cleanup(func() { lookPath = orig })
+	lookPath = func(file string) (string, error) {
+		if file == "git" {
+			return "/usr/bin/git", nil
+		}
+		return "", fmt.Errorf("%s not found", file)
+	}
+
+	installer, err := NewInstaller(&Platform{OS: "linux", PackageManager: "apt"}, &versionPM{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	installer.InstalledPath = filepath.Join(t.TempDir(), manifest.InstalledFileName)
+	git, bat := &Tool{Tool: interfaces.Tool{Name: "git"}}, &Tool{Tool: interfaces.Tool{Name: "bat"}}
+
+	installer.notePresence([]*Tool{git, bat}, nil, nil, "")
+	if err := installer.recordInstalled([]*Tool{git, bat}, nil); err != nil {
+		t.Fatalf("recordInstalled() error = %v", err)
+	}
+	s, _ := manifest.LoadInstalled(installer.InstalledPath)
+	if !s.Tools["git"].PreExisting || s.Tools["bat"].PreExisting || s.Tools["bat"].Package != "bat" {
+		t.Errorf("Expected only git to be pre-existing, got %+v", s.Tools)
+	}
+
+	// On the next run bat is on PATH because bootstrap-cli installed it
+	lookPath = func(string) (string, error) { return "/usr/bin/x", nil }
+	installer.notePresence([]*Tool{git, bat}, nil, nil, "")
+	if err := installer.recordInstalled([]*Tool{git, bat}, nil); err != nil {
+		t.Fatalf("recordInstalled() error = %v", err)
+	}
+	s, _ = manifest.LoadInstalled(installer.InstalledPath)
+	if !s.Tools["git"].PreExisting || s.Tools["bat"].PreExisting {
+		t.Errorf("Expected the first run to decide what was pre-existing, got %+v", s.Tools)
+	}
+}
+
+func TestInstaller_SkipInstalled(t *testing.T) {
+	orig := lookPath
+	t.Cleanup(func() { lookPath = orig })
+	lookPath = func(file string) (string, error) {
+		if file == "bat" || file == "go" || file == "zsh" {
+			return "/usr/bin/" + file, nil
+		}
+		return "", fmt.Errorf("%s not found", file)
+	}
+
+	installer, err := NewInstaller(&Platform{OS: "linux", PackageManager: "apt"}, &versionPM{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	installer.InstalledPath = filepath.Join(t.TempDir(), manifest.InstalledFileName)
+	bat, fd, jq := &Tool{Tool: interfaces.Tool{Name: "bat"}}, &Tool{Tool: interfaces.Tool{Name: "fd"}}, &Tool{Tool: interfaces.Tool{Name: "jq"}, MinVersion: "1.7"}
+	golang := &interfaces.Language{Name: "Go", VerifyCommand: "go version"}
+	installer.notePresence(nil, nil, &interfaces.Shell{Name: "zsh"}, "")
+	if err := installer.recordInstalled([]*Tool{bat, fd, jq}, []*interfaces.Language{golang}); err != nil {
+		t.Fatalf("recordInstalled() error = %v", err)
+	}
+	if s, _ := manifest.LoadInstalled(installer.InstalledPath); s.Shells["zsh"].Manager != "apt" || !s.Shells["zsh"].PreExisting {
+		t.Errorf("Expected the pre-existing zsh to be recorded, got %+v", s.Shells)
+	}
+
+	// fd is recorded but gone from PATH, and jq still needs its version checked
+	tools, languages := installer.skipInstalled([]*Tool{bat, fd, jq}, []*interfaces.Language{golang})
+	if len(tools) != 2 || tools[0] != fd || tools[1] != jq || len(languages) != 0 {
+		t.Errorf("Expected only fd and jq to be installed again, got %v, %v", tools, languages)
+	}
+
+	installer.Reinstall = true
+	if tools, languages := installer.skipInstalled([]*Tool{bat, fd, jq}, []*interfaces.Language{golang}); len(tools) != 3 || len(languages) != 1 {
+		t.Errorf("Expected Reinstall to skip nothing, got %v, %v", tools, languages)
+	}
+}
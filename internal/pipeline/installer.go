@@ -4,10 +4,14 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/dotfiles"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
 )
 
 // Installer manages the installation of tools using a pipeline-based approach
@@ -18,6 +22,43 @@ type Installer struct {
 	// Add a field to hold the read-end of the channel for the UI
 	ProgressChan <-chan ProgressEvent
 	progressChanWriter chan<- ProgressEvent // Internal write-end for the pipeline
+	// LockPath is where resolved versions are written after a successful run
+	// (default ~/.bootstrap-cli/bootstrap.lock)
+	LockPath string
+	// Catalog resolves the members of selected groups and the tools selected
+	// tools require (default: the selection itself)
+	Catalog []*Tool
+	// RequiredBy names, for each tool the last run pulled in for a requirement,
+	// the tool that required it
+	RequiredBy map[string]string
+	// DiskUsage is the disk space consumed by the last InstallSelections run
+	DiskUsage *DiskUsage
+	// InstalledPath is the snapshot of managed tools kept in sync on install and
+	// removal (default ~/.bootstrap-cli/installed.json)
+	InstalledPath string
+	// QueuePath is where the install queue of a run in progress is kept, so it
+	// can be resumed after an interruption (default ~/.bootstrap-cli/queue.json)
+	QueuePath string
+	// JournalPath is where the actions of a run are recorded, so a failed run
+	// can be rolled back (default ~/.bootstrap-cli/journal.json)
+	JournalPath string
+	// LogDir is where the output of failed installs is written, one log per
+	// item (default ~/.bootstrap-cli/logs)
+	LogDir string
+	// DotfilesDir is where the dotfiles repository is cloned (default ~/.dotfiles)
+	DotfilesDir string
+	// Dotfiles are the dotfile configs; the symlink files of those whose
+	// source_repo is the selected repository map it into $HOME
+	Dotfiles []*interfaces.Dotfile
+
+	// Reinstall installs every selection again, even the tools and languages
+	// the installed snapshot records as still on PATH
+	Reinstall bool
+
+	// presence is what the current run found installed before it started
+	presence *presence
+	// running tracks InstallSelections calls in progress, for Wait
+	running sync.WaitGroup
 }
 
 // NewInstaller creates a new installer instance
@@ -40,6 +81,94 @@ func NewInstaller(platform *Platform, pkgManager PackageManager) (*Installer, er
 	}, nil
 }
 
+// NewRun creates an installer for another run with the same platform, package
+// manager and settings, e.g. to retry what failed. Each run closes its progress
+// channel, so an installer cannot be reused.
+func (i *Installer) NewRun() (*Installer, error) {
+	next, err := NewInstaller(i.Context.Platform, i.Context.PackageManager)
+	if err != nil {
+		return nil, err
+	}
+	next.LockPath, next.Catalog, next.InstalledPath, next.QueuePath = i.LockPath, i.Catalog, i.InstalledPath, i.QueuePath
+	next.JournalPath = i.JournalPath
+	next.LogDir = i.LogDir
+	next.DotfilesDir, next.Dotfiles = i.DotfilesDir, i.Dotfiles
+	next.Reinstall = i.Reinstall
+	ctx := next.Context
+	ctx.Lock = i.Context.Lock
+	ctx.Verbose = i.Context.Verbose
+	ctx.ToolManagers = i.Context.ToolManagers
+	ctx.LanguageStrategy = i.Context.LanguageStrategy
+	ctx.VersionManager = i.Context.VersionManager
+	ctx.VersionManagerOrder = i.Context.VersionManagerOrder
+	ctx.LoginShellChange = i.Context.LoginShellChange
+	ctx.Shells = i.Context.Shells
+	ctx.PromptStyle = i.Context.PromptStyle
+	ctx.ForcePromptConfig = i.Context.ForcePromptConfig
+	ctx.KeepExisting = i.Context.KeepExisting
+	ctx.NoSudo = i.Context.NoSudo
+	ctx.BatchPackages = i.Context.BatchPackages
+	ctx.Retry = i.Context.Retry
+	ctx.Concurrency = i.Context.Concurrency
+	ctx.ToolTimeout = i.Context.ToolTimeout
+	ctx.Releases = i.Context.Releases
+	return next, nil
+}
+
+// Succeeded returns the items (tools, fonts, languages...) the last run
+// installed, by name
+func (i *Installer) Succeeded() map[string]bool {
+	done := make(map[string]bool)
+	if i.Pipeline == nil {
+		return done
+	}
+	for _, group := range i.Pipeline.Summary().Groups() {
+		for _, item := range group.Succeeded {
+			done[item] = true
+		}
+	}
+	return done
+}
+
+// FailureLog describes each step of the last run that failed, with the error
+// and command output it reported and the failure log holding all of it
+func (i *Installer) FailureLog() string {
+	if i.Pipeline == nil {
+		return ""
+	}
+	state := i.Context.State
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	var b strings.Builder
+	for _, step := range i.Pipeline.Steps {
+		err, failed := state.StepErrors[step.Name]
+		if !failed {
+			continue
+		}
+		item := step.Item
+		if item == "" {
+			item = step.Name
+		}
+		fmt.Fprintf(&b, "%s (%s): %v\n", item, step.Name, err)
+		if path := state.LogPaths[step.Item]; path != "" {
+			fmt.Fprintf(&b, "  full output: %s\n", path)
+		}
+	}
+	return b.String()
+}
+
+// Cancel stops a run in progress, e.g. when the UI reading ProgressChan quits;
+// see InstallationContext.Cancel
+func (i *Installer) Cancel() {
+	i.Context.Cancel()
+}
+
+// Wait blocks until the InstallSelections calls in progress have returned,
+// so the commands a cancelled run stopped have exited
+func (i *Installer) Wait() {
+	i.running.Wait()
+}
+
 // Install installs a tool using the pipeline-based approach
 func (i *Installer) Install(tool *Tool) error {
 	i.Logger.Info("Starting installation of %s", tool.Name)
@@ -111,11 +240,52 @@ func (i *Installer) InstallSelections(
 	selectedLanguages []*interfaces.Language,
 	selectedShell *interfaces.Shell,
 ) error { 
+	i.running.Add(1)
+	defer i.running.Done()
 	if len(selectedTools) == 0 && !manageDotfiles && len(selectedFonts) == 0 && len(selectedLanguages) == 0 && selectedShell == nil {
 		i.Logger.Info("No items selected for installation.")
 		return nil
 	}
 	i.Logger.Info("Starting dependency-aware installation...")
+	if i.Context.Lock != nil {
+		if err := CheckPinnable(i.Context.Platform.PackageManager); err != nil {
+			return fmt.Errorf("cannot install from the lock file: %w", err)
+		}
+	}
+
+	// 0. Expand groups into their members, skipping tools not offered on this OS and
+	// members this platform cannot install
+	catalog := i.Catalog
+	if catalog == nil {
+		catalog = selectedTools
+	}
+	selectedTools, groupOf, err := ExpandGroups(selectedTools, catalog)
+	if err != nil {
+		return fmt.Errorf("failed to expand tool groups: %w", err)
+	}
+	// Required tools that were not selected are pulled in, and go first
+	if selectedTools, i.RequiredBy, err = ResolveRequires(selectedTools, catalog); err != nil {
+		return err
+	}
+	for _, tool := range selectedTools {
+		if _, ok := i.RequiredBy[tool.Name]; ok {
+			i.Logger.Info("Adding %s", RequiredLabel(tool.Name, i.RequiredBy))
+		}
+	}
+	installable := make([]*Tool, 0, len(selectedTools))
+	for _, tool := range selectedTools {
+		if ok, reason := tool.SupportsOS(i.Context.Platform.OS); !ok {
+			i.Logger.Warn("Skipping %s: %s", tool.Name, reason)
+			continue
+		}
+		if groupOf[tool.Name] != "" && !tool.HasInstallMethod(i.Context.Platform) {
+			i.Logger.Warn("Skipping %s from group %s: no %s package or install commands available", tool.Name, groupOf[tool.Name], i.Context.Platform.PackageManager)
+			continue
+		}
+		installable = append(installable, tool)
+	}
+	selectedTools = installable
+	selectedTools, selectedLanguages = i.skipInstalled(selectedTools, selectedLanguages)
 
 	// 1. Build Combined Dependency Graph for Tools
 	// TODO: Include dependencies from fonts, languages, dotfiles (e.g., git)
@@ -139,11 +309,28 @@ func (i *Installer) InstallSelections(
 	// 3. Create Single Pipeline & Generate Ordered Steps
 	// Create the pipeline using the installer's context (which has the channel)
 	pipeline := NewInstallationPipeline(i.Context)
+	pipeline.Concurrency = i.Context.Concurrency
+	if pipeline.Concurrency == 0 {
+		pipeline.Concurrency = DefaultConcurrency()
+	}
+	pipeline.Parallel = make(map[string][]string)
+	pipeline.Locks = make(map[string]string)
+	pipeline.ItemTimeouts = make(map[string]time.Duration)
+	pipeline.LogDir = i.logDir()
 	// No need to set Logger/State again as NewInstallationPipeline does it from context
 	i.Pipeline = pipeline // Store the pipeline instance for this run? Or just execute?
 
 	addedSteps := make(map[string]bool) 
 
+	// Plain packages install together first, each tool then verifying its own
+	if i.Context.BatchPackages {
+		i.Context.batched = nil
+		if step, ok := batchInstallStep(installOrder, toolMap, i.Context); ok {
+			i.Pipeline.AddStep(step)
+			i.Logger.Info("  Added step: %s", step.Name)
+		}
+	}
+
 	// Add Tool Steps in Order
 	for _, toolName := range installOrder {
         if _, alreadyAdded := addedSteps[toolName]; alreadyAdded {
@@ -157,10 +344,23 @@ func (i *Installer) InstallSelections(
         }
 		i.Logger.Info("Generating installation steps for: %s", toolName)
 		steps := toolToInstall.GenerateInstallationSteps(i.Context.Platform, i.Context, true) // skip dependency step
+		// Members of a selected group are summarized under the group
+		group := GroupLabel(ToolCategory(toolToInstall.Category))
+		if name := groupOf[toolName]; name != "" {
+			group = GroupLabel(ToolCategory(name))
+		}
 		for _, step := range steps {
+			step.Group, step.Item = group, toolToInstall.Name
+			if by, ok := i.RequiredBy[toolName]; ok {
+				step.Description = fmt.Sprintf("%s (required by %s)", step.Description, by)
+			}
 			i.Pipeline.AddStep(step)
 			i.Logger.Info("  Added step: %s", step.Name)
 		}
+		i.allowParallel(toolToInstall, toolMap)
+		if timeout := i.toolTimeout(toolToInstall); timeout > 0 {
+			i.Pipeline.ItemTimeouts[toolToInstall.Name] = timeout
+		}
 		addedSteps[toolName] = true
 	}
 
@@ -171,6 +371,7 @@ func (i *Installer) InstallSelections(
 			i.Logger.Info("Generating steps for font: %s", font.Name)
 			fontSteps := GenerateFontInstallSteps(font, i.Context.Platform) // Call font step generator
 			for _, step := range fontSteps {
+				step.Group, step.Item = GroupFonts, font.Name
 				i.Pipeline.AddStep(step)
 				i.Logger.Info("  Added font step: %s", step.Name)
 			}
@@ -185,6 +386,7 @@ func (i *Installer) InstallSelections(
 			// Pass context to generator as it might be needed for strategy decisions
 			langSteps := GenerateLanguageInstallSteps(lang, i.Context) 
 			for _, step := range langSteps {
+				step.Group, step.Item = GroupLanguages, lang.Name
 				i.Pipeline.AddStep(step)
 				i.Logger.Info("  Added language step: %s", step.Name)
 			}
@@ -193,16 +395,21 @@ func (i *Installer) InstallSelections(
 
 	// Add Dotfiles Steps (if selected)
 	if manageDotfiles && dotfilesRepoURL != "" {
-		i.Logger.Info("Adding dotfiles clone steps for repo: %s", dotfilesRepoURL)
-		// TODO: Determine appropriate targetDir (e.g., ~/.dotfiles)
-		homeDir, _ := os.UserHomeDir() // Handle potential error
-		targetDir := filepath.Join(homeDir, ".dotfiles") // Example target
-		dotfileSteps := GenerateDotfileCloneSteps(dotfilesRepoURL, targetDir)
+		i.Logger.Info("Adding dotfiles steps for repo: %s", dotfilesRepoURL)
+		targetDir := i.DotfilesDir
+		if targetDir == "" {
+			homeDir, err := system.UserHome()
+			if err != nil {
+				return err
+			}
+			targetDir = dotfiles.DefaultDir(homeDir)
+		}
+		dotfileSteps := GenerateDotfileSteps(dotfilesRepoURL, targetDir, i.Dotfiles)
 		for _, step := range dotfileSteps {
+			step.Group, step.Item = GroupDotfiles, dotfilesRepoURL
 			i.Pipeline.AddStep(step)
 			i.Logger.Info("  Added dotfiles step: %s", step.Name)
 		}
-		// TODO: Add symlinking steps after clone
 	}
 
 	// Add Shell Configuration Steps (if selected)
@@ -210,6 +417,7 @@ func (i *Installer) InstallSelections(
 		i.Logger.Info("Adding steps for shell configuration: %s", selectedShell.Name)
 		shellSteps := GenerateShellConfigSteps(selectedShell, i.Context)
 		for _, step := range shellSteps {
+			step.Group, step.Item = GroupShell, selectedShell.Name
 			i.Pipeline.AddStep(step)
 			i.Logger.Info("  Added shell config step: %s", step.Name)
 		}
@@ -217,15 +425,125 @@ func (i *Installer) InstallSelections(
 
 	// 4. Execute the single, ordered pipeline
 	i.Logger.Info("Executing combined installation pipeline with %d steps...", len(i.Pipeline.Steps))
-	if err := i.Pipeline.Execute(); err != nil {
+	i.startQueue(toolMap, selectedLanguages)
+	i.startJournal()
+	prompt := ""
+	if selectedShell != nil {
+		prompt = i.Context.PromptStyle
+	}
+	i.notePresence(selectedTools, selectedLanguages, selectedShell, prompt)
+	diskBefore := i.startDiskUsage()
+	err = i.Pipeline.Execute()
+	i.DiskUsage = i.finishDiskUsage(diskBefore)
+	i.finishQueue(err)
+	i.finishJournal(err)
+	if err != nil {
 		return fmt.Errorf("installation pipeline failed: %w", err)
 	}
 
 	// 5. Final Environment Setup ?
 	i.Logger.Info("Installation pipeline completed successfully.")
+
+	// 6. Record the run so audit/repair can see what was installed
+	if err := i.recordRun(selectedTools, dotfilesRepoURL, selectedFonts, selectedLanguages, selectedShell); err != nil {
+		i.Logger.Warn("Failed to record run in manifest: %v", err)
+	}
+
+	// 7. Update the snapshot of what is managed now, for other programs to read
+	if len(selectedTools) > 0 || len(selectedLanguages) > 0 || selectedShell != nil {
+		if err := i.recordInstalled(selectedTools, selectedLanguages); err != nil {
+			i.Logger.Warn("Failed to update installed snapshot: %v", err)
+		}
+	}
+
+	// 8. Record the exact versions installed, unless they were pinned from a lock already
+	if i.Context.Lock == nil && (len(selectedTools) > 0 || len(selectedLanguages) > 0) {
+		if err := i.writeLock(selectedTools, selectedLanguages); err != nil {
+			i.Logger.Warn("Failed to write lock file: %v", err)
+		}
+	}
 	return nil
 }
 
+// allowParallel lets tool install alongside the other selected tools once the
+// ones it depends on are installed. Tools installed with a SerialManager share
+// a lock so they still install one at a time.
+func (i *Installer) allowParallel(tool *Tool, selected map[string]*Tool) {
+	deps := []string{}
+	for _, dep := range tool.Dependencies {
+		if _, ok := selected[dep.Name]; ok {
+			deps = append(deps, dep.Name)
+		}
+	}
+	deps = append(deps, tool.requiredFrom(selected)...)
+	i.Pipeline.Parallel[tool.Name] = deps
+
+	manager := i.Context.managerFor(tool)
+	if method, err := tool.determineInstallationMethod(i.Context, manager); err == nil && method == PackageManagerInstall && SerialManager(manager) {
+		i.Pipeline.Locks[tool.Name] = manager
+	}
+}
+
+// logDir returns where failure logs are written, "" when there is no home
+// directory to default to
+func (i *Installer) logDir() string {
+	if i.LogDir != "" {
+		return i.LogDir
+	}
+	dir, err := manifest.DefaultLogDir()
+	if err != nil {
+		return ""
+	}
+	return dir
+}
+
+// toolTimeout returns how long installing tool may take, 0 for no limit
+func (i *Installer) toolTimeout(tool *Tool) time.Duration {
+	if tool.Timeout > 0 {
+		return tool.Timeout
+	}
+	return i.Context.ToolTimeout
+}
+
+// recordRun appends the completed selections to the run manifest
+func (i *Installer) recordRun(
+	selectedTools []*Tool,
+	dotfilesRepoURL string,
+	selectedFonts []*interfaces.Font,
+	selectedLanguages []*interfaces.Language,
+	selectedShell *interfaces.Shell,
+) error {
+	path, err := manifest.DefaultPath()
+	if err != nil {
+		return err
+	}
+
+	run := manifest.Run{DotfilesRepo: dotfilesRepoURL}
+	if i.Context.Platform != nil {
+		run.PackageManager = i.Context.Platform.PackageManager
+	}
+	for _, tool := range selectedTools {
+		run.Tools = append(run.Tools, tool.Name)
+		if len(tool.Group) > 0 {
+			continue
+		}
+		if run.Provenance == nil {
+			run.Provenance = make(map[string]string)
+		}
+		run.Provenance[tool.Name] = i.provenance(tool)
+	}
+	for _, font := range selectedFonts {
+		run.Fonts = append(run.Fonts, font.Name)
+	}
+	for _, lang := range selectedLanguages {
+		run.Languages = append(run.Languages, lang.Name)
+	}
+	if selectedShell != nil {
+		run.Shell = selectedShell.Name
+	}
+	return manifest.Record(path, run)
+}
+
 // Uninstall removes a tool and its dependencies
 func (i *Installer) Uninstall(tool *Tool) error {
 	i.Logger.Info("Starting uninstallation of %s", tool.Name)
@@ -235,11 +553,18 @@ func (i *Installer) Uninstall(tool *Tool) error {
 		{
 			Name: "Remove package",
 			Action: func(ctx *InstallationContext) error {
+				// A release install is a binary of our own, not a package
+				if item, ok := i.recordedTool(tool); ok && item.Manager == manifest.ManagerGitHubRelease && item.Path != "" {
+					if err := os.Remove(item.Path); err != nil && !os.IsNotExist(err) {
+						return fmt.Errorf("failed to remove %s: %w", item.Path, err)
+					}
+					return nil
+				}
 				strategy := tool.GetInstallStrategy(ctx.Platform)
 				if pkgName, ok := strategy.PackageNames[ctx.Platform.PackageManager]; ok {
 					return ctx.PackageManager.Uninstall(pkgName)
 				}
-				return ctx.PackageManager.Uninstall(tool.Name)
+				return ctx.PackageManager.Uninstall(tool.PackageFor(ctx.Platform.PackageManager))
 			},
 		},
 		{
@@ -260,6 +585,9 @@ func (i *Installer) Uninstall(tool *Tool) error {
 		return fmt.Errorf("uninstallation failed: %w", err)
 	}
 	
+	if err := i.forgetInstalled(tool); err != nil {
+		i.Logger.Warn("Failed to update installed snapshot: %v", err)
+	}
 	i.Logger.Info("Successfully uninstalled %s", tool.Name)
 	return nil
 }
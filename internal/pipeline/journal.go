@@ -0,0 +1,175 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+)
+
+// journalPath returns where the install journal is kept, or "" when there is no
+// state directory
+func (i *Installer) journalPath() string {
+	if i.JournalPath != "" {
+		return i.JournalPath
+	}
+	path, err := manifest.DefaultJournalPath()
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// startJournal starts recording the run's actions. The journal a failed run
+// left behind is continued, so resuming that run keeps what it did rollbackable.
+func (i *Installer) startJournal() {
+	path := i.journalPath()
+	if path == "" || len(i.Pipeline.Steps) == 0 {
+		return
+	}
+	j, err := manifest.LoadJournal(path)
+	if err != nil {
+		i.Logger.Warn("Starting a new install journal: %v", err)
+	}
+	if j == nil {
+		j = &manifest.Journal{PackageManager: i.Context.Platform.PackageManager}
+	}
+	if err := j.Save(path); err != nil {
+		i.Logger.Warn("Failed to save install journal: %v", err)
+		return
+	}
+	i.Context.journal, i.Context.journalPath = j, path
+}
+
+// finishJournal removes the install journal after a successful run; after a
+// failure it is kept for bootstrap-cli rollback
+func (i *Installer) finishJournal(err error) {
+	path := i.Context.journalPath
+	i.Context.journal, i.Context.journalPath = nil, ""
+	if path == "" || err != nil {
+		return
+	}
+	if err := manifest.RemoveJournal(path); err != nil {
+		i.Logger.Warn("%v", err)
+	}
+}
+
+// Journaled returns the actions the last run recorded and could roll back, or
+// nil when it finished without leaving a journal
+func (i *Installer) Journaled() []manifest.JournalEntry {
+	path := i.journalPath()
+	if path == "" {
+		return nil
+	}
+	j, err := manifest.LoadJournal(path)
+	if err != nil || j == nil {
+		return nil
+	}
+	return j.Entries
+}
+
+// record journals an action of the run; it does nothing outside a journaled run
+func (c *InstallationContext) record(entry manifest.JournalEntry) {
+	if c.journal == nil {
+		return
+	}
+	if err := c.journal.Record(c.journalPath, entry); err != nil {
+		c.Logger.Warn("Failed to update install journal: %v", err)
+	}
+}
+
+// preinstalled reports whether manager already has pkg installed. When it cannot
+// tell, the package is taken as pre-existing so a rollback leaves it alone.
+func (c *InstallationContext) preinstalled(manager, pkg string) bool {
+	if c.journal == nil || c.PackageManager == nil || manager != c.Platform.PackageManager {
+		return true
+	}
+	installed, err := c.PackageManager.IsInstalled(pkg)
+	return err != nil || installed
+}
+
+// recordPackage journals pkg as installed by manager for item
+func (c *InstallationContext) recordPackage(item, manager, pkg string) {
+	c.record(manifest.JournalEntry{Kind: manifest.JournalPackage, Item: item, Manager: manager, Package: pkg})
+}
+
+// recordFile journals path as created for item, unless it existed before
+func (c *InstallationContext) recordFile(item, path string, existed bool) {
+	if !existed {
+		c.record(manifest.JournalEntry{Kind: manifest.JournalFile, Item: item, Path: path})
+	}
+}
+
+// recordDir journals dir, and everything in it, as created for item
+func (c *InstallationContext) recordDir(item, dir string) {
+	c.record(manifest.JournalEntry{Kind: manifest.JournalDir, Item: item, Path: dir})
+}
+
+// recordRCBlocks journals the managed blocks rc added for item. The blocks a
+// stageRC view staged are journaled once flushRC writes them.
+func (c *InstallationContext) recordRCBlocks(item string, rc *shell.RCWriter) {
+	for _, change := range rc.Changes() {
+		if change.Applied && change.Created {
+			c.record(manifest.JournalEntry{Kind: manifest.JournalRCBlock, Item: item, Path: change.Path, Block: change.Block})
+			continue
+		}
+		if !change.Applied && rc.Buffered {
+			c.rcMu.Lock()
+			if c.rcOwners == nil {
+				c.rcOwners = make(map[string]string)
+			}
+			c.rcOwners[change.Path+"\x00"+change.Block] = item
+			c.rcMu.Unlock()
+		}
+	}
+}
+
+// stageRC returns a writer whose rc file edits are buffered with the rest of
+// the run's, so each rc file is written once when the pipeline finishes
+func (c *InstallationContext) stageRC() *shell.RCWriter {
+	c.rcMu.Lock()
+	defer c.rcMu.Unlock()
+	if c.rc == nil {
+		c.rc = shell.NewBufferedRCWriter()
+		if c.Logger != nil {
+			c.rc.Warn = c.Logger.Warn
+		}
+	}
+	return c.rc.Share()
+}
+
+// flushRC writes the rc file edits the run staged, each file in one pass, and
+// journals the blocks it added for the items that staged them
+func (c *InstallationContext) flushRC() error {
+	c.rcMu.Lock()
+	rc, owners := c.rc, c.rcOwners
+	c.rc, c.rcOwners = nil, nil
+	c.rcMu.Unlock()
+	if rc == nil {
+		return nil
+	}
+
+	changes, err := rc.Flush()
+	for _, change := range changes {
+		if !change.Applied {
+			continue
+		}
+		c.Logger.Info("Updated %s (%s)", change.Path, change.Block)
+		for _, block := range change.NewBlocks {
+			if item, ok := owners[change.Path+"\x00"+block]; ok {
+				c.record(manifest.JournalEntry{Kind: manifest.JournalRCBlock, Item: item, Path: change.Path, Block: block})
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update shell rc files: %w", err)
+	}
+	return nil
+}
+
+// exists reports whether path exists; when it cannot tell it says it does
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return !os.IsNotExist(err)
+}
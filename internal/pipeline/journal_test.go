@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+)
+
+func TestInstallerJournalsActionsUntilSuccess(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	installer, err := NewInstaller(&Platform{OS: "linux", PackageManager: "apt", Shell: "bash"}, &fakePM{})
+	if err != nil {
+		t.Fatalf("NewInstaller() error = %v", err)
+	}
+	dir := t.TempDir()
+	installer.JournalPath = filepath.Join(dir, manifest.JournalFileName)
+	existing := filepath.Join(dir, "existing")
+	if err := os.WriteFile(existing, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	bashrc := filepath.Join(dir, ".bashrc")
+	if err := os.WriteFile(bashrc, []byte(shell.UpsertBlock("", "fd", "alias find=fd")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewInstallationPipeline(installer.Context)
+	p.AddStep(InstallationStep{Name: "fd-install-package", Item: "fd", Action: func(ctx *InstallationContext) error {
+		if ctx.preinstalled("apt", "fd-find") {
+			t.Error("Expected fd-find to be missing before the run")
+		}
+		if !ctx.preinstalled("brew", "fd") {
+			t.Error("Expected a package of another manager to count as pre-existing")
+		}
+		ctx.recordPackage("fd", "apt", "fd-find")
+		return nil
+	}})
+	p.AddStep(InstallationStep{Name: "ensure-prompt-config-starship", Item: "starship", Action: func(ctx *InstallationContext) error {
+		ctx.recordFile("starship", filepath.Join(dir, "starship.toml"), false)
+		ctx.recordFile("starship", existing, exists(existing))
+		rc := ctx.stageRC()
+		for _, block := range []string{"fd", "prompt"} {
+			if _, err := rc.UpsertBlock(bashrc, block, "echo "+block); err != nil {
+				return err
+			}
+		}
+		ctx.recordRCBlocks("starship", rc)
+		if data, _ := os.ReadFile(bashrc); shell.HasBlock(string(data), "prompt") {
+			t.Error("Expected the rc edits to wait for the end of the run")
+		}
+		return nil
+	}})
+	p.AddStep(InstallationStep{Name: "bat-custom-install-0", Item: "bat", RetryDelay: time.Millisecond, Action: func(*InstallationContext) error {
+		return errors.New("download failed")
+	}})
+	installer.Pipeline = p
+
+	installer.startJournal()
+	runErr := p.Execute()
+	if runErr == nil {
+		t.Fatal("Expected the bat step to fail the run")
+	}
+	installer.finishJournal(runErr)
+
+	entries := installer.Journaled()
+	if len(entries) != 3 {
+		t.Fatalf("Expected the package, the new file and the new block journaled, got %+v", entries)
+	}
+	if e := entries[0]; e.Kind != manifest.JournalPackage || e.Package != "fd-find" || e.Manager != "apt" {
+		t.Errorf("Unexpected package entry %+v", e)
+	}
+	if e := entries[1]; e.Kind != manifest.JournalFile || filepath.Base(e.Path) != "starship.toml" {
+		t.Errorf("Unexpected file entry %+v", e)
+	}
+	if e := entries[2]; e.Kind != manifest.JournalRCBlock || e.Block != "prompt" || e.Path != bashrc {
+		t.Errorf("Unexpected rc block entry %+v", e)
+	}
+
+	// Outside a run nothing is journaled, and a successful run removes the journal
+	installer.Context.recordPackage("bat", "apt", "bat")
+	installer.startJournal()
+	installer.finishJournal(nil)
+	if j, _ := manifest.LoadJournal(installer.JournalPath); j != nil {
+		t.Errorf("Expected a successful run to remove the journal, got %+v", j)
+	}
+}
@@ -2,11 +2,12 @@ package pipeline
 
 import (
 	"fmt"
-	"os"
 	"os/exec"
+	"strings"
 	"time"
 
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
 )
 
 // GenerateLanguageInstallSteps creates pipeline steps for installing a language.
@@ -17,45 +18,245 @@ func GenerateLanguageInstallSteps(lang *interfaces.Language, context *Installati
 		return steps
 	}
 
-	// TODO: Determine installation strategy (e.g., use version manager like pyenv/nvm if specified and available, otherwise use system PM)
-	// This logic needs access to the InstallationContext to check for installed tools (version managers) and system PM.
-	
-	// --- Placeholder: Simple system package manager install --- 
-	// This assumes the language name directly maps to a package name.
-	// A real implementation would use lang.Installer, lang.Version, lang.PackageNames etc.
-	pkgName := lang.Name // Very naive assumption
+	// The system strategy installs the distro packages directly, with no version manager or rc edits
+	strategy := lang.ResolveStrategy(context.LanguageStrategy)
 	pkgManagerName := context.Platform.PackageManager
-	var installCmdStr string
-	switch pkgManagerName {
-	case "apt":
-		installCmdStr = fmt.Sprintf("sudo apt-get install -y %s", pkgName)
-	case "brew":
-		installCmdStr = fmt.Sprintf("brew install %s", pkgName)
-	case "dnf":
-		installCmdStr = fmt.Sprintf("sudo dnf install -y %s", pkgName)
-	case "pacman":
-		installCmdStr = fmt.Sprintf("sudo pacman -S --noconfirm %s", pkgName)
+	var pkgName string
+	switch strategy {
+	case interfaces.LanguageStrategySystem:
+		pkgName = strings.Join(lang.SystemPackages(pkgManagerName), " ")
+	case interfaces.LanguageStrategyVersionManager:
+		// asdf or mise picked for the language is set up when it is missing
+		switch lang.RuntimeManager() {
+		case interfaces.LanguageInstallerAsdf:
+			steps = append(asdfSteps(lang), languagePackageSteps(lang)...)
+			return appendLanguageVerify(steps, lang)
+		case interfaces.LanguageInstallerMise:
+			steps = append(miseSteps(lang), languagePackageSteps(lang)...)
+			steps = append(steps, miseDoctorStep(lang))
+			return appendLanguageVerify(steps, lang)
+		}
+		// A version manager the user already has wins over setting up another one
+		if vm := system.DetectVersionManager(lang.Name, context.VersionManager, context.VersionManagerOrder); vm != nil {
+			steps = append(existingVersionManagerSteps(lang, vm), languagePackageSteps(lang)...)
+			return appendLanguageVerify(steps, lang)
+		}
+		// TODO: Install through lang.Installer (nvm, pyenv...) once version managers are pipeline steps.
+		// --- Placeholder: Simple system package manager install ---
+		// This assumes the language name directly maps to a package name.
+		pkgName = lang.Name // Very naive assumption
 	default:
-		// Return an error step? Log a warning?
+		fmt.Printf("Unknown install strategy '%s' for language %s\n", strategy, lang.Name)
+		return steps
+	}
+
+	// Languages install with the same command as tools and shells
+	if _, err := context.installCommand(pkgManagerName, pkgName); err != nil {
 		fmt.Printf("Unsupported package manager '%s' for language %s install\n", pkgManagerName, lang.Name)
 		return steps
 	}
 
 	steps = append(steps, InstallationStep{
 		Name:        fmt.Sprintf("install-lang-%s", lang.Name),
-		Description: fmt.Sprintf("Installing language %s using %s", lang.Name, pkgManagerName),
+		Description: fmt.Sprintf("Installing language %s using %s (%s)", lang.Name, pkgManagerName, strategy),
 		Action: func(ctx *InstallationContext) error {
+			// In locked mode pin the language's primary package to the locked version
+			packages := strings.Fields(pkgName)
+			primary := packages[0]
+			var fresh []string
+			for _, pkg := range packages {
+				if !ctx.preinstalled(pkgManagerName, pkg) {
+					fresh = append(fresh, pkg)
+				}
+			}
+			pinned, err := ctx.lockedLanguagePackage(lang.Name, packages[0])
+			if err != nil {
+				return err
+			}
+			packages[0] = pinned
+			installCmdStr, err := ctx.installCommand(pkgManagerName, strings.Join(packages, " "))
+			if err != nil {
+				return err
+			}
+
 			// TODO: Add logging via ctx.Logger or ctx.sendProgress
-			cmd := exec.Command("sh", "-c", installCmdStr)
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
-			return cmd.Run()
+			cmd := ctx.shellCommand(lang.Name, installCmdStr)
+			if output, err := ctx.runCommand(lang.Name, cmd); err != nil {
+				return fmt.Errorf("language install command failed: %w (Output: %s)", err, string(output))
+			}
+			for _, pkg := range fresh {
+				ctx.recordPackage(lang.Name, pkgManagerName, pkg)
+			}
+			ctx.State.RecordInstaller(lang.Name, pkgManagerName, primary)
+			return nil
 		},
 		Timeout: 5 * time.Minute,
 	})
 	// --- End Placeholder ---
 
-	// TODO: Add verification steps based on lang.Verify
+	steps = append(steps, languagePackageSteps(lang)...)
+
+	return appendLanguageVerify(steps, lang)
+}
+
+// appendLanguageVerify adds a step recording the version the language's verify
+// command reports and checking it against a pinned version. Problems are only
+// warnings: a new version may not be on this process's PATH until a new shell
+// starts.
+func appendLanguageVerify(steps []InstallationStep, lang *interfaces.Language) []InstallationStep {
+	if lang.VerifyCommand == "" {
+		return steps
+	}
+	pinned := PinnedVersion(lang.Version)
+	return append(steps, InstallationStep{
+		Name:        fmt.Sprintf("verify-lang-%s", lang.Name),
+		Description: fmt.Sprintf("Checking the installed version of %s", lang.Name),
+		Action: func(ctx *InstallationContext) error {
+			output, err := ctx.command(lang.Name, "sh", "-c", lang.VerifyCommand).CombinedOutput()
+			if err != nil {
+				if pinned {
+					ctx.Logger.Warn("Could not check the %s version: %s failed: %v", lang.Name, lang.VerifyCommand, err)
+				}
+				return nil
+			}
+			installed, ok := ParseVersion(string(output))
+			if !ok {
+				ctx.Logger.Warn("Could not check the %s version: no version in the output of %s", lang.Name, lang.VerifyCommand)
+				return nil
+			}
+			ctx.State.RecordVersion(lang.Name, installed)
+			switch {
+			case !pinned:
+			case !VersionMatches(installed, lang.Version):
+				ctx.Logger.Warn("%s %s is on PATH, but version %s is pinned; open a new shell if it was just installed", lang.Name, installed, lang.Version)
+			default:
+				ctx.Logger.Info("%s %s matches the pinned version %s", lang.Name, installed, lang.Version)
+			}
+			return nil
+		},
+		Timeout: 1 * time.Minute,
+	})
+}
 
-	return steps
-} 
\ No newline at end of file
+// existingVersionManagerSteps installs lang through a version manager that was
+// already set up, leaving the shell rc files to it
+func existingVersionManagerSteps(lang *interfaces.Language, vm *system.ExistingVersionManager) []InstallationStep {
+	return []InstallationStep{{
+		Name:        fmt.Sprintf("install-lang-%s", lang.Name),
+		Description: fmt.Sprintf("Installing language %s using the existing %s", lang.Name, vm.Name),
+		Action: func(ctx *InstallationContext) error {
+			cmdStr, err := vm.InstallCommand(lang.Name, lang.Version)
+			if err != nil {
+				return err
+			}
+			ctx.Logger.Info("Using existing %s (%s) for %s", vm.Name, vm.Path, lang.Name)
+			if output, err := ctx.runCommand(lang.Name, ctx.command(lang.Name, "sh", "-c", cmdStr)); err != nil {
+				return fmt.Errorf("%s install of %s failed: %w (Output: %s)", vm.Name, lang.Name, err, string(output))
+			}
+			tool, _ := system.VersionManagerTool(vm.Name, lang.Name)
+			ctx.State.RecordInstaller(lang.Name, vm.Name, tool)
+			return nil
+		},
+		Timeout: 10 * time.Minute,
+	}}
+}
+
+// languagePackageSteps installs the language's global packages, if it has any
+func languagePackageSteps(lang *interfaces.Language) []InstallationStep {
+	if len(lang.GlobalPackages) == 0 {
+		return nil
+	}
+	if globalPackageTool(lang.Name) == "" {
+		fmt.Printf("No global package installer known for language %s; skipping %s\n", lang.Name, strings.Join(lang.GlobalPackages, ", "))
+		return nil
+	}
+	return []InstallationStep{globalPackagesStep(lang)}
+}
+
+// globalPackagesStep installs the language's global packages one by one, reporting
+// each; a retry only attempts the packages that have not been installed yet
+func globalPackagesStep(lang *interfaces.Language) InstallationStep {
+	installed := make(map[string]bool)
+	return InstallationStep{
+		Name:        fmt.Sprintf("install-lang-%s-global-packages", lang.Name),
+		Description: fmt.Sprintf("Installing %s global packages: %s", lang.Name, strings.Join(lang.GlobalPackages, ", ")),
+		Action: func(ctx *InstallationContext) error {
+			var failed []string
+			for _, pkg := range lang.GlobalPackages {
+				if installed[pkg] {
+					continue
+				}
+				cmdStr, err := globalPackageCommand(lang.Name, pkg, exec.LookPath)
+				if err != nil {
+					return err
+				}
+				if manager := lang.RuntimeManager(); manager != "" {
+					if vm, ok := system.FindVersionManager(manager); ok {
+						cmdStr = vm.GlobalPackageCommand(lang.Name, cmdStr)
+					}
+				}
+				ctx.Logger.CommandStart(cmdStr, 1, 1)
+				start := time.Now()
+				if output, err := ctx.runCommand(lang.Name, ctx.command(lang.Name, "sh", "-c", cmdStr)); err != nil {
+					ctx.Logger.CommandError(cmdStr, err, 1, 1)
+					ctx.Logger.Warn("%s global package %s failed: %v (Output: %s)", lang.Name, pkg, err, strings.TrimSpace(string(output)))
+					failed = append(failed, pkg)
+					continue
+				}
+				ctx.Logger.CommandSuccess(cmdStr, time.Since(start))
+				ctx.Logger.Info("%s global package %s installed", lang.Name, pkg)
+				installed[pkg] = true
+			}
+			if len(failed) > 0 {
+				return fmt.Errorf("failed to install %s global packages: %s", lang.Name, strings.Join(failed, ", "))
+			}
+			return nil
+		},
+		Timeout: 10 * time.Minute,
+	}
+}
+
+// globalPackageTool returns the package tool that installs global packages for a
+// language, or "" when the language has none
+func globalPackageTool(language string) string {
+	switch strings.TrimSuffix(strings.ToLower(language), ".js") {
+	case "node", "nodejs", "javascript", "typescript":
+		return "npm"
+	case "python", "python3":
+		return "pipx"
+	case "go", "golang":
+		return "go"
+	case "rust":
+		return "cargo"
+	case "ruby":
+		return "gem"
+	default:
+		return ""
+	}
+}
+
+// globalPackageCommand returns the command that installs pkg globally for a
+// language. Python packages use pipx when it is on PATH and pip --user otherwise;
+// Go packages without a version get @latest.
+func globalPackageCommand(language, pkg string, lookPath func(string) (string, error)) (string, error) {
+	switch globalPackageTool(language) {
+	case "npm":
+		return fmt.Sprintf("npm install -g %s", pkg), nil
+	case "pipx":
+		if _, err := lookPath("pipx"); err == nil {
+			return fmt.Sprintf("pipx install %s", pkg), nil
+		}
+		return fmt.Sprintf("python3 -m pip install --user %s", pkg), nil
+	case "go":
+		if !strings.Contains(pkg, "@") {
+			pkg += "@latest"
+		}
+		return fmt.Sprintf("go install %s", pkg), nil
+	case "cargo":
+		return fmt.Sprintf("cargo install %s", pkg), nil
+	case "gem":
+		return fmt.Sprintf("gem install %s", pkg), nil
+	default:
+		return "", fmt.Errorf("no global package installer known for language %s", language)
+	}
+}
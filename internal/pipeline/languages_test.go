@@ -0,0 +1,258 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+func TestGenerateLanguageInstallStepsStrategy(t *testing.T) {
+	lang := &interfaces.Language{Name: "Node.js", Installer: "nvm"}
+	lang.PackageNames.APT = "nodejs npm"
+
+	ctx := NewInstallationContext(&Platform{OS: "linux", PackageManager: "apt"}, nil, nil)
+	ctx.LanguageStrategy = interfaces.LanguageStrategySystem
+	// Keep a version manager on the host from taking over
+	ctx.VersionManager = "none"
+
+	steps := GenerateLanguageInstallSteps(lang, ctx)
+	if len(steps) != 1 {
+		t.Fatalf("Expected 1 step, got %d", len(steps))
+	}
+	if !strings.Contains(steps[0].Description, "(system)") {
+		t.Errorf("Expected the context default strategy to apply, got %q", steps[0].Description)
+	}
+
+	// A strategy set on the language wins over the context default
+	lang.Strategy = interfaces.LanguageStrategyVersionManager
+	steps = GenerateLanguageInstallSteps(lang, ctx)
+	if len(steps) != 1 || !strings.Contains(steps[0].Description, "(version-manager)") {
+		t.Errorf("Expected the language strategy to apply, got %+v", steps)
+	}
+
+	lang.Strategy = "homebrew-cask"
+	if steps := GenerateLanguageInstallSteps(lang, ctx); len(steps) != 0 {
+		t.Errorf("Expected no steps for an unknown strategy, got %d", len(steps))
+	}
+}
+
+func TestGenerateLanguageInstallStepsManagers(t *testing.T) {
+	lang := &interfaces.Language{Name: "Go", Strategy: interfaces.LanguageStrategySystem}
+	for manager, want := range map[string]int{"apt": 1, "pkg": 1, "winget": 1, "choco": 1, "zypper": 1, "nix": 0} {
+		ctx := NewInstallationContext(&Platform{OS: "linux", PackageManager: manager}, nil, nil)
+		if steps := GenerateLanguageInstallSteps(lang, ctx); len(steps) != want {
+			t.Errorf("%s: got %d steps, want %d", manager, len(steps), want)
+		}
+	}
+}
+
+func TestLanguageSystemPackages(t *testing.T) {
+	lang := &interfaces.Language{Name: "Python"}
+	lang.PackageNames.APT = "python3 python3-pip"
+
+	if got := lang.SystemPackages("apt"); len(got) != 2 || got[1] != "python3-pip" {
+		t.Errorf("Unexpected apt packages: %v", got)
+	}
+	if got := lang.SystemPackages("pacman"); len(got) != 1 || got[0] != "python" {
+		t.Errorf("Expected fallback to the language name, got %v", got)
+	}
+}
+
+func TestGenerateLanguageInstallStepsGlobalPackages(t *testing.T) {
+	lang := &interfaces.Language{Name: "Node.js", GlobalPackages: []string{"typescript", "prettier"}}
+	ctx := NewInstallationContext(&Platform{OS: "linux", PackageManager: "apt"}, nil, nil)
+
+	steps := GenerateLanguageInstallSteps(lang, ctx)
+	if len(steps) != 2 {
+		t.Fatalf("Expected an install step and a global packages step, got %d", len(steps))
+	}
+	if !strings.Contains(steps[1].Description, "typescript, prettier") {
+		t.Errorf("Expected the global packages step to list the packages, got %q", steps[1].Description)
+	}
+
+	// Languages without a known package tool keep only the install step
+	lang = &interfaces.Language{Name: "Haskell", GlobalPackages: []string{"hlint"}}
+	if steps := GenerateLanguageInstallSteps(lang, ctx); len(steps) != 1 {
+		t.Errorf("Expected global packages to be skipped for Haskell, got %d steps", len(steps))
+	}
+}
+
+func TestGlobalPackageCommand(t *testing.T) {
+	found := func(string) (string, error) { return "/usr/bin/pipx", nil }
+	missing := func(string) (string, error) { return "", fmt.Errorf("not found") }
+
+	tests := []struct {
+		language string
+		pkg      string
+		lookPath func(string) (string, error)
+		want     string
+	}{
+		{"Node.js", "typescript", missing, "npm install -g typescript"},
+		{"Python", "black", found, "pipx install black"},
+		{"Python", "black", missing, "python3 -m pip install --user black"},
+		{"Go", "golang.org/x/tools/gopls", missing, "go install golang.org/x/tools/gopls@latest"},
+		{"Go", "github.com/x/y@v1.2.0", missing, "go install github.com/x/y@v1.2.0"},
+		{"Rust", "ripgrep", missing, "cargo install ripgrep"},
+	}
+	for _, tt := range tests {
+		got, err := globalPackageCommand(tt.language, tt.pkg, tt.lookPath)
+		if err != nil || got != tt.want {
+			t.Errorf("globalPackageCommand(%s, %s) = %q, %v; want %q", tt.language, tt.pkg, got, err, tt.want)
+		}
+	}
+	if _, err := globalPackageCommand("Haskell", "hlint", missing); err == nil {
+		t.Error("Expected an error for a language without a package tool")
+	}
+}
+
+func TestGenerateLanguageInstallStepsExistingVersionManager(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "fnm"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+	t.Setenv("HOME", t.TempDir())
+
+	lang := &interfaces.Language{Name: "Node.js", Installer: "nvm", Version: "18"}
+	lang.GlobalPackages = []string{"typescript"}
+	ctx := NewInstallationContext(&Platform{OS: "linux", PackageManager: "apt"}, nil, nil)
+	ctx.LanguageStrategy = interfaces.LanguageStrategyVersionManager
+
+	steps := GenerateLanguageInstallSteps(lang, ctx)
+	if len(steps) != 2 || !strings.Contains(steps[0].Description, "existing fnm") {
+		t.Fatalf("Expected fnm to install Node.js before its global packages, got %+v", steps)
+	}
+
+	// none keeps the bundled version manager path
+	ctx.VersionManager = "none"
+	if steps := GenerateLanguageInstallSteps(lang, ctx); strings.Contains(steps[0].Description, "fnm") {
+		t.Errorf("Expected detection to be disabled, got %q", steps[0].Description)
+	}
+}
+
+func TestGenerateLanguageInstallStepsAsdf(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("PATH", t.TempDir())
+	asdf := filepath.Join(home, ".asdf", "bin", "asdf")
+	if err := os.MkdirAll(filepath.Dir(asdf), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(asdf, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	lang := &interfaces.Language{Name: "Node.js", Installer: interfaces.LanguageInstallerAsdf, Version: "20"}
+	lang.GlobalPackages = []string{"pnpm"}
+	ctx := NewInstallationContext(&Platform{OS: "linux", PackageManager: "apt", Shell: "zsh"}, nil, nil)
+	ctx.LanguageStrategy = interfaces.LanguageStrategyVersionManager
+	// asdf picked for the language wins over detection being disabled
+	ctx.VersionManager = "none"
+
+	steps := GenerateLanguageInstallSteps(lang, ctx)
+	var names []string
+	for _, step := range steps {
+		names = append(names, step.Name)
+	}
+	want := []string{"install-lang-Node.js-asdf", "install-lang-Node.js", "install-lang-Node.js-global-packages"}
+	if strings.Join(names, " ") != strings.Join(want, " ") {
+		t.Fatalf("Expected steps %v, got %v", want, names)
+	}
+
+	// asdf is already in ~/.asdf, so setting it up only loads it from .zshrc
+	if err := steps[0].Action(ctx); err != nil {
+		t.Fatalf("asdf setup error = %v", err)
+	}
+	if err := ctx.flushRC(); err != nil {
+		t.Fatalf("flushRC() error = %v", err)
+	}
+	zshrc, err := os.ReadFile(filepath.Join(home, ".zshrc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(zshrc), `. "$HOME/.asdf/asdf.sh"`) {
+		t.Errorf("Expected .zshrc to source asdf.sh, got:\n%s", zshrc)
+	}
+}
+
+func TestGenerateLanguageInstallStepsMise(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv("MISE_DATA_DIR", "")
+	t.Setenv("XDG_DATA_HOME", "")
+	mise := filepath.Join(home, ".local", "bin", "mise")
+	if err := os.MkdirAll(filepath.Dir(mise), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(mise, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	lang := &interfaces.Language{Name: "Python", Installer: interfaces.LanguageInstallerMise, Version: "3.12"}
+	ctx := NewInstallationContext(&Platform{OS: "linux", PackageManager: "apt", Shell: "bash"}, nil, nil)
+	ctx.LanguageStrategy = interfaces.LanguageStrategyVersionManager
+
+	steps := GenerateLanguageInstallSteps(lang, ctx)
+	var names []string
+	for _, step := range steps {
+		names = append(names, step.Name)
+	}
+	want := []string{"install-lang-Python-mise", "install-lang-Python", "verify-lang-Python-mise"}
+	if strings.Join(names, " ") != strings.Join(want, " ") {
+		t.Fatalf("Expected steps %v, got %v", want, names)
+	}
+
+	// mise is already in ~/.local/bin, so setting it up activates it in .bashrc
+	// and puts it and its shims on PATH for the rest of the run
+	if err := steps[0].Action(ctx); err != nil {
+		t.Fatalf("mise setup error = %v", err)
+	}
+	if err := ctx.flushRC(); err != nil {
+		t.Fatalf("flushRC() error = %v", err)
+	}
+	bashrc, err := os.ReadFile(filepath.Join(home, ".bashrc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if activate := fmt.Sprintf(`eval "$(%s activate bash)"`, mise); !strings.Contains(string(bashrc), activate) {
+		t.Errorf("Expected .bashrc to run %s, got:\n%s", activate, bashrc)
+	}
+	path := filepath.SplitList(os.Getenv("PATH"))
+	if len(path) < 2 || path[0] != filepath.Dir(mise) || path[1] != filepath.Join(home, ".local", "share", "mise", "shims") {
+		t.Errorf("Expected mise and its shims first on PATH, got %v", path)
+	}
+}
+
+func TestMiseDoctorFindings(t *testing.T) {
+	output := `version: 2024.12.0 linux-x64
+activated: no
+shims_on_path: yes
+
+2 warnings found:
+
+1. mise is not activated, run mise help activate or
+   read documentation at https://mise.jdx.dev for activation instructions.
+
+2. new mise version 2025.1.0 available, currently on 2024.12.0
+
+1 problem found:
+
+1. plugin python is not installed
+No problems with the config
+`
+	want := []string{
+		"new mise version 2025.1.0 available, currently on 2024.12.0",
+		"plugin python is not installed",
+	}
+	if got := miseDoctorFindings(output); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("miseDoctorFindings() = %q, want %q", got, want)
+	}
+	if got := miseDoctorFindings("version: 2024.12.0\nNo problems found\n"); len(got) != 0 {
+		t.Errorf("Expected no findings, got %q", got)
+	}
+}
@@ -0,0 +1,112 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/packages/implementations"
+)
+
+// VersionResolver is implemented by package managers that can report the
+// installed version of a package
+type VersionResolver interface {
+	GetVersion(pkg string) (string, error)
+}
+
+// CheckPinnable returns an error when pm cannot install an exact package
+// version, so a lock file can neither be written nor applied with it
+func CheckPinnable(pm string) error {
+	_, err := pinnableCommands(pm)
+	return err
+}
+
+// PinnedPackage returns the package spec that installs exactly version with the
+// given package manager
+func PinnedPackage(pm, pkg, version string) (string, error) {
+	commands, err := pinnableCommands(pm)
+	if err != nil {
+		return "", fmt.Errorf("cannot pin %s to %s: %w", pkg, version, err)
+	}
+	return commands.VersionedPackage(pkg, version), nil
+}
+
+// pinnableCommands returns the command builder of pm when it can install exact
+// package versions
+func pinnableCommands(pm string) (interfaces.PackageCommands, error) {
+	commands, err := implementations.Commands(pm)
+	if err != nil || !commands.Pinnable() {
+		return nil, fmt.Errorf("%s cannot install exact package versions, so its installs cannot be locked", pm)
+	}
+	return commands, nil
+}
+
+// lockedToolPackage pins a tool's package to the version recorded in the lock.
+// Without a lock pkg is returned unchanged.
+func (c *InstallationContext) lockedToolPackage(name, pkg string) (string, error) {
+	if c.Lock == nil {
+		return pkg, nil
+	}
+	version, ok := c.Lock.ToolVersion(name)
+	return c.pinLocked(name, pkg, version, ok)
+}
+
+// lockedLanguagePackage pins a language's package to the version recorded in the lock.
+// Without a lock pkg is returned unchanged.
+func (c *InstallationContext) lockedLanguagePackage(name, pkg string) (string, error) {
+	if c.Lock == nil {
+		return pkg, nil
+	}
+	version, ok := c.Lock.LanguageVersion(name)
+	return c.pinLocked(name, pkg, version, ok)
+}
+
+// pinLocked pins pkg to version; in locked mode an item missing from the lock is an
+// error rather than silently drifting to the latest version
+func (c *InstallationContext) pinLocked(name, pkg, version string, ok bool) (string, error) {
+	if !ok {
+		return "", fmt.Errorf("%s is not in the lock file", name)
+	}
+	return PinnedPackage(c.Platform.PackageManager, pkg, version)
+}
+
+// writeLock resolves the installed version of every tool and language and saves
+// them to the lock file, so a later --locked run can reproduce them exactly
+func (i *Installer) writeLock(selectedTools []*Tool, selectedLanguages []*interfaces.Language) error {
+	resolver, ok := i.Context.PackageManager.(VersionResolver)
+	if !ok {
+		return fmt.Errorf("package manager cannot report installed versions")
+	}
+	path := i.LockPath
+	if path == "" {
+		var err error
+		if path, err = manifest.DefaultLockPath(); err != nil {
+			return err
+		}
+	}
+
+	pm := i.Context.Platform.PackageManager
+	if err := CheckPinnable(pm); err != nil {
+		return err
+	}
+	lock := manifest.NewLock(pm)
+	for _, tool := range selectedTools {
+		version, err := resolver.GetVersion(tool.PackageFor(pm))
+		if err != nil || version == "" {
+			i.Logger.Warn("Could not resolve installed version of %s: %v", tool.Name, err)
+			continue
+		}
+		lock.Tools[tool.Name] = strings.TrimSpace(version)
+	}
+	for _, lang := range selectedLanguages {
+		packages := lang.SystemPackages(pm)
+		version, err := resolver.GetVersion(packages[0])
+		if err != nil || version == "" {
+			i.Logger.Warn("Could not resolve installed version of %s: %v", lang.Name, err)
+			continue
+		}
+		lock.Languages[lang.Name] = strings.TrimSpace(version)
+	}
+	return lock.Save(path)
+}
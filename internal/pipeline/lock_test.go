@@ -0,0 +1,45 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+)
+
+func TestPinnedPackage(t *testing.T) {
+	tests := []struct {
+		pm      string
+		want    string
+		wantErr bool
+	}{
+		{pm: "apt", want: "ripgrep=13.0.0-4"},
+		{pm: "dnf", want: "ripgrep-13.0.0-4"},
+		// brew has no formula for every version and pacman keeps only the latest
+		{pm: "brew", wantErr: true},
+		{pm: "pacman", wantErr: true},
+		{pm: "winget", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := PinnedPackage(tt.pm, "ripgrep", "13.0.0-4")
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("PinnedPackage(%s) = %q, %v; want %q, wantErr %v", tt.pm, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLockedToolPackage(t *testing.T) {
+	ctx := NewInstallationContext(&Platform{OS: "linux", PackageManager: "apt"}, nil, nil)
+
+	if pkg, err := ctx.lockedToolPackage("fd", "fd-find"); err != nil || pkg != "fd-find" {
+		t.Errorf("Expected package unchanged without a lock, got %q, %v", pkg, err)
+	}
+
+	ctx.Lock = manifest.NewLock("apt")
+	ctx.Lock.Tools["fd"] = "8.7.0-3"
+	if pkg, err := ctx.lockedToolPackage("fd", "fd-find"); err != nil || pkg != "fd-find=8.7.0-3" {
+		t.Errorf("Expected pinned package, got %q, %v", pkg, err)
+	}
+	if _, err := ctx.lockedToolPackage("bat", "bat"); err == nil {
+		t.Error("Expected an error for a tool missing from the lock")
+	}
+}
@@ -0,0 +1,161 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/release"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+)
+
+// miseRelease is where mise is downloaded from when it is not installed
+var miseRelease = release.Spec{
+	Repo:      "jdx/mise",
+	Asset:     "mise-{tag}-{os}-{arch}.tar.gz",
+	Binary:    "mise/bin/mise",
+	Checksums: "SHASUMS256.txt",
+	OSNames:   map[string]string{"darwin": "macos"},
+	ArchNames: map[string]string{"amd64": "x64"},
+}
+
+// miseSteps sets up mise unless it is installed, then installs lang through it
+// and makes the version the user's default
+func miseSteps(lang *interfaces.Language) []InstallationStep {
+	return []InstallationStep{
+		{
+			Name:        fmt.Sprintf("install-lang-%s-mise", lang.Name),
+			Description: fmt.Sprintf("Setting up mise for %s", lang.Name),
+			Action: func(ctx *InstallationContext) error {
+				return ensureMise(ctx, lang.Name)
+			},
+			Timeout: 5 * time.Minute,
+		},
+		runtimeManagerInstallStep(lang, interfaces.LanguageInstallerMise),
+	}
+}
+
+// ensureMise downloads mise into ~/.local/bin unless it is installed, and
+// activates it in the shell's rc file. mise and its shims are also put on this
+// process's PATH, so the steps after it find the versions mise installs
+// without a new shell.
+func ensureMise(ctx *InstallationContext, item string) error {
+	home, err := system.UserHome()
+	if err != nil {
+		return err
+	}
+	vm, ok := system.FindVersionManager(interfaces.LanguageInstallerMise)
+	if ok {
+		ctx.Logger.Info("Using existing mise (%s) for %s", vm.Path, item)
+	} else {
+		installer, err := ctx.releases()
+		if err != nil {
+			return err
+		}
+		arch := ctx.Platform.Arch
+		if arch == "" {
+			arch = runtime.GOARCH
+		}
+		target, err := installer.BinaryPath("mise")
+		if err != nil {
+			return err
+		}
+		existed := exists(target)
+		tag, binary, err := installer.Install("mise", &miseRelease, release.Latest, ctx.Platform.OS, arch)
+		if err != nil {
+			return fmt.Errorf("failed to download mise: %w", err)
+		}
+		ctx.recordFile(item, binary, existed)
+		ctx.Logger.Info("Installed mise %s to %s", tag, binary)
+		vm = &system.ExistingVersionManager{Name: interfaces.LanguageInstallerMise, Path: binary}
+	}
+	prependPath(miseShims(home))
+	prependPath(filepath.Dir(vm.Path))
+
+	rc := ctx.stageRC()
+	err = shell.EnsureMiseActivate(home, ctx.Platform.Shell, vm.Path, rc)
+	ctx.recordRCBlocks(item, rc)
+	if err != nil {
+		return fmt.Errorf("failed to activate mise in the shell rc file: %w", err)
+	}
+	return nil
+}
+
+// miseShims returns mise's shims directory, under MISE_DATA_DIR when it is set
+func miseShims(home string) string {
+	if dir := os.Getenv("MISE_DATA_DIR"); dir != "" {
+		return filepath.Join(dir, "shims")
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "mise", "shims")
+	}
+	return filepath.Join(home, ".local", "share", "mise", "shims")
+}
+
+// miseDoctorStep runs mise doctor after lang is installed and passes on the
+// warnings and problems it reports. They never fail the install.
+func miseDoctorStep(lang *interfaces.Language) InstallationStep {
+	return InstallationStep{
+		Name:        fmt.Sprintf("verify-lang-%s-mise", lang.Name),
+		Description: fmt.Sprintf("Running mise doctor for %s", lang.Name),
+		Action: func(ctx *InstallationContext) error {
+			vm, ok := system.FindVersionManager(interfaces.LanguageInstallerMise)
+			if !ok {
+				ctx.Logger.Warn("Could not run mise doctor for %s: mise is not installed", lang.Name)
+				return nil
+			}
+			output, err := ctx.command(lang.Name, vm.Path, "doctor").CombinedOutput()
+			findings := miseDoctorFindings(string(output))
+			for _, finding := range findings {
+				ctx.Logger.Warn("mise doctor: %s", finding)
+			}
+			if err != nil && len(findings) == 0 {
+				ctx.Logger.Warn("mise doctor failed: %v (Output: %s)", err, strings.TrimSpace(string(output)))
+			}
+			return nil
+		},
+		Timeout: 1 * time.Minute,
+	}
+}
+
+// miseDoctorHeading starts a list of findings in mise doctor's output, e.g.
+// "2 warnings found:"
+var miseDoctorHeading = regexp.MustCompile(`^\d+ (warning|problem)s? found:$`)
+
+// miseDoctorItem starts one finding of such a list, e.g. "1. ..."
+var miseDoctorItem = regexp.MustCompile(`^\d+\.\s+`)
+
+// miseDoctorFindings returns the warnings and problems listed in the output of
+// mise doctor, each joined onto one line. mise not being activated is left out:
+// this process started before the rc file activating it was written.
+func miseDoctorFindings(output string) []string {
+	var findings []string
+	listing := false
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case miseDoctorHeading.MatchString(trimmed):
+			listing = true
+		case !listing || trimmed == "":
+		case miseDoctorItem.MatchString(line):
+			findings = append(findings, miseDoctorItem.ReplaceAllString(line, ""))
+		case line != trimmed && len(findings) > 0:
+			findings[len(findings)-1] += " " + trimmed
+		default:
+			listing = false
+		}
+	}
+	kept := findings[:0]
+	for _, finding := range findings {
+		if !strings.Contains(finding, "not activated") {
+			kept = append(kept, finding)
+		}
+	}
+	return kept
+}
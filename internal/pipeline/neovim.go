@@ -0,0 +1,428 @@
+package pipeline
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cache"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/release"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+)
+
+// NeovimStarterConfig is cloned into an empty ~/.config/nvim unless
+// InstallationContext.NeovimConfig names another repository
+const NeovimStarterConfig = "https://github.com/nvim-lua/kickstart.nvim.git"
+
+// NeovimConfigNone as InstallationContext.NeovimConfig skips the starter config
+const NeovimConfigNone = "none"
+
+// neovimRelease is the tarball Neovim is installed from on Linux, since distro
+// packages are often several releases behind. It unpacks to a directory with
+// bin/nvim and the runtime files next to it.
+var neovimRelease = release.Spec{
+	Repo:      "neovim/neovim",
+	Asset:     "nvim-{os}-{arch}.tar.gz",
+	ArchNames: map[string]string{"amd64": "x86_64", "arm64": "arm64"},
+}
+
+// neovimSteps installs Neovim from its GitHub release on Linux, Homebrew on
+// macOS and winget or Chocolatey on Windows, then clones a starter config
+// into an empty config directory and installs the config's plugins
+func neovimSteps(t *Tool, ctx *InstallationContext) ([]InstallationStep, error) {
+	var install InstallationStep
+	switch ctx.Platform.OS {
+	case "linux":
+		arch := ctx.Platform.Arch
+		if arch == "" {
+			arch = runtime.GOARCH
+		}
+		// Other architectures have no release tarball
+		if _, ok := neovimRelease.ArchNames[arch]; ok {
+			install = neovimReleaseStep(t)
+		} else {
+			install = neovimPackageStep(t, ctx.Platform.PackageManager)
+		}
+	case "darwin":
+		install = neovimPackageStep(t, "brew")
+	case "windows":
+		install = neovimWindowsStep(t)
+	default:
+		install = neovimPackageStep(t, ctx.Platform.PackageManager)
+	}
+	return []InstallationStep{install, neovimConfigStep(t), neovimPluginsStep(t)}, nil
+}
+
+// neovimCurrent reports whether an installed nvim can be kept: one installed
+// with another manager the user chose to keep, or one at least MinVersion
+func neovimCurrent(ctx *InstallationContext, t *Tool) bool {
+	if existing, ok := ctx.KeepExisting[t.Name]; ok {
+		ctx.Logger.Info("Keeping %s installed via %s", t.Name, existing)
+		return true
+	}
+	current, ok := t.InstalledVersion()
+	if !ok {
+		return false
+	}
+	if t.MinVersion != "" && CompareVersions(current, t.MinVersion) < 0 {
+		ctx.Logger.Info("Neovim %s is older than %s, installing the release", current, t.MinVersion)
+		return false
+	}
+	ctx.Logger.Info("Neovim %s is already installed, skipping", current)
+	return true
+}
+
+// neovimReleaseStep unpacks the release tarball into ~/.local/opt/nvim and
+// links ~/.local/bin/nvim to it, replacing an earlier release
+func neovimReleaseStep(t *Tool) InstallationStep {
+	return InstallationStep{
+		Name:        fmt.Sprintf("%s-install-release", t.Name),
+		Description: fmt.Sprintf("Installing %s from the %s GitHub releases", t.Name, neovimRelease.Repo),
+		Action: func(ctx *InstallationContext) error {
+			if neovimCurrent(ctx, t) {
+				return nil
+			}
+			installer, err := ctx.releases()
+			if err != nil {
+				return err
+			}
+			arch := ctx.Platform.Arch
+			if arch == "" {
+				arch = runtime.GOARCH
+			}
+			tmp, err := os.MkdirTemp("", "bootstrap-nvim-")
+			if err != nil {
+				return fmt.Errorf("failed to create temp directory: %w", err)
+			}
+			defer os.RemoveAll(tmp)
+			tag, asset, err := installer.Download(t.Name, &neovimRelease, t.Version, ctx.Platform.OS, arch, tmp)
+			if err != nil {
+				return err
+			}
+
+			home, err := system.UserHome()
+			if err != nil {
+				return err
+			}
+			dir := filepath.Join(home, ".local", "opt", "nvim")
+			if err := unpackRelease(asset, dir); err != nil {
+				return fmt.Errorf("failed to install %s %s: %w", t.Name, tag, err)
+			}
+			link, err := installer.BinaryPath("nvim")
+			if err != nil {
+				return err
+			}
+			existed := exists(link)
+			if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", filepath.Dir(link), err)
+			}
+			if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to replace %s: %w", link, err)
+			}
+			if err := os.Symlink(filepath.Join(dir, "bin", "nvim"), link); err != nil {
+				return fmt.Errorf("failed to link %s: %w", link, err)
+			}
+			ctx.recordFile(t.Name, link, existed)
+			// Let the verify step and the plugin install find nvim in this run
+			prependPath(filepath.Dir(link))
+			ctx.Logger.Info("Installed %s %s to %s", t.Name, tag, dir)
+			return nil
+		},
+		Timeout: 10 * time.Minute,
+	}
+}
+
+// neovimPackageStep installs the neovim package with manager
+func neovimPackageStep(t *Tool, manager string) InstallationStep {
+	pkg := t.PackageFor(manager)
+	return InstallationStep{
+		Name:        fmt.Sprintf("%s-install-package", t.Name),
+		Description: fmt.Sprintf("Installing %s via %s", pkg, manager),
+		Action: func(ctx *InstallationContext) error {
+			if neovimCurrent(ctx, t) {
+				return nil
+			}
+			cmdStr, err := ctx.installCommand(manager, pkg)
+			if err != nil {
+				return err
+			}
+			fresh := !ctx.preinstalled(manager, pkg)
+			if output, err := ctx.runShell(t.Name, cmdStr); err != nil {
+				return fmt.Errorf("package installation failed: %w (Output: %s)", err, string(output))
+			}
+			if fresh {
+				ctx.recordPackage(t.Name, manager, pkg)
+			}
+			return nil
+		},
+		Timeout: 10 * time.Minute,
+	}
+}
+
+// neovimWindowsStep installs Neovim with winget, or Chocolatey without it
+func neovimWindowsStep(t *Tool) InstallationStep {
+	return InstallationStep{
+		Name:        fmt.Sprintf("%s-install-package", t.Name),
+		Description: fmt.Sprintf("Installing %s with winget or Chocolatey", t.Name),
+		Action: func(ctx *InstallationContext) error {
+			if neovimCurrent(ctx, t) {
+				return nil
+			}
+			var cmd *exec.Cmd
+			switch {
+			case lookPathOK("winget"):
+				cmd = ctx.command(t.Name, "winget", "install", "--id", "Neovim.Neovim", "--exact", "--silent", "--accept-package-agreements", "--accept-source-agreements")
+			case lookPathOK("choco"):
+				cmd = ctx.command(t.Name, "choco", "install", "neovim", "-y")
+			default:
+				return fmt.Errorf("cannot install %s: neither winget nor choco is installed", t.Name)
+			}
+			if output, err := ctx.runCommand(t.Name, cmd); err != nil {
+				return fmt.Errorf("failed to install %s with %s: %w (Output: %s)", t.Name, cmd.Args[0], err, strings.TrimSpace(string(output)))
+			}
+			// Both install to Program Files, which PATH only has in a new shell
+			if programFiles := os.Getenv("ProgramFiles"); programFiles != "" {
+				prependPath(filepath.Join(programFiles, "Neovim", "bin"))
+			}
+			return nil
+		},
+		Timeout: 10 * time.Minute,
+	}
+}
+
+// lookPathOK reports whether name is on PATH
+func lookPathOK(name string) bool {
+	_, err := lookPath(name)
+	return err == nil
+}
+
+// neovimConfigDir returns the directory Neovim reads its config from
+func neovimConfigDir(goos, home string) string {
+	if goos == "windows" {
+		local := os.Getenv("LOCALAPPDATA")
+		if local == "" {
+			local = filepath.Join(home, "AppData", "Local")
+		}
+		return filepath.Join(local, "nvim")
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "nvim")
+	}
+	return filepath.Join(home, ".config", "nvim")
+}
+
+// neovimConfigRepo returns the URL of the starter config to clone for
+// setting (a git URL, a GitHub user/repo, or empty for kickstart.nvim), or ""
+// when it is NeovimConfigNone
+func neovimConfigRepo(setting string) string {
+	switch {
+	case setting == "":
+		return NeovimStarterConfig
+	case strings.EqualFold(setting, NeovimConfigNone):
+		return ""
+	case !strings.Contains(setting, "://") && !strings.Contains(setting, "@") && strings.Count(setting, "/") == 1:
+		return fmt.Sprintf("https://github.com/%s.git", setting)
+	}
+	return setting
+}
+
+// neovimConfigStep clones the starter config into the config directory when
+// it is missing or empty; an existing config is never touched
+func neovimConfigStep(t *Tool) InstallationStep {
+	var cloned string
+	return InstallationStep{
+		Name:        fmt.Sprintf("%s-starter-config", t.Name),
+		Description: "Setting up a starter Neovim config",
+		Action: func(ctx *InstallationContext) error {
+			repo := neovimConfigRepo(ctx.NeovimConfig)
+			if repo == "" {
+				return nil
+			}
+			home, err := system.UserHome()
+			if err != nil {
+				return err
+			}
+			dir := neovimConfigDir(ctx.Platform.OS, home)
+			if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+				ctx.Logger.Info("Keeping the existing Neovim config in %s", dir)
+				return nil
+			}
+			if !lookPathOK("git") {
+				ctx.Logger.Warn("git is not installed, so no starter config was cloned into %s", dir)
+				return nil
+			}
+			if err := cache.CheckCommand(repo); err != nil {
+				return fmt.Errorf("failed to clone the Neovim config %s: %w", repo, err)
+			}
+			// git clone refuses an existing directory unless it is empty
+			os.Remove(dir)
+			ctx.sendProgress(TaskLog{TaskID: ctx.State.CurrentStep, Line: fmt.Sprintf("Cloning %s into %s", repo, dir)})
+			if output, err := ctx.runCommand(t.Name, ctx.command(t.Name, "git", "clone", "--depth=1", repo, dir)); err != nil {
+				return fmt.Errorf("failed to clone the Neovim config %s: %w (Output: %s)", repo, err, strings.TrimSpace(string(output)))
+			}
+			cloned = dir
+			ctx.Logger.Info("Cloned the Neovim config %s into %s", repo, dir)
+			return nil
+		},
+		Rollback: func(ctx *InstallationContext) error {
+			// A config that was there before the run is left alone
+			if cloned == "" {
+				return nil
+			}
+			if err := os.RemoveAll(cloned); err != nil {
+				return fmt.Errorf("failed to remove the Neovim config %s during rollback: %w", cloned, err)
+			}
+			return nil
+		},
+		Timeout: 5 * time.Minute,
+	}
+}
+
+// neovimPluginsStep installs the plugins of a config that uses lazy.nvim,
+// headless, so the first start of nvim does not have to. A failed sync is a
+// warning: nvim works and retries it when it starts.
+func neovimPluginsStep(t *Tool) InstallationStep {
+	return InstallationStep{
+		Name:        fmt.Sprintf("%s-install-plugins", t.Name),
+		Description: "Installing Neovim plugins",
+		Action: func(ctx *InstallationContext) error {
+			home, err := system.UserHome()
+			if err != nil {
+				return err
+			}
+			dir := neovimConfigDir(ctx.Platform.OS, home)
+			if !usesLazy(dir) {
+				return nil
+			}
+			if !lookPathOK("nvim") {
+				ctx.Logger.Warn("nvim is not on PATH, so the plugins of %s were not installed", dir)
+				return nil
+			}
+			// The output is captured, so the plugin install is logged rather than drawn
+			output, err := ctx.runCommand(t.Name, ctx.command(t.Name, "nvim", "--headless", "+Lazy! sync", "+qa"))
+			if len(output) > 0 {
+				ctx.sendProgress(TaskLog{TaskID: ctx.State.CurrentStep, Line: string(output)})
+			}
+			if err != nil {
+				ctx.Logger.Warn("Neovim plugin install failed (%s); they are installed when nvim starts", lastLine(string(output)+err.Error()))
+				return nil
+			}
+			ctx.Logger.Info("Installed the Neovim plugins of %s", dir)
+			return nil
+		},
+		Timeout: 10 * time.Minute,
+	}
+}
+
+// usesLazy reports whether the Lua files of the config in dir set up lazy.nvim
+func usesLazy(dir string) bool {
+	found := false
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return filepath.SkipDir
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && strings.HasSuffix(path, ".lua") {
+			if data, err := os.ReadFile(path); err == nil && strings.Contains(string(data), "lazy.nvim") {
+				found = true
+				return fs.SkipAll
+			}
+		}
+		return nil
+	})
+	return found
+}
+
+// unpackRelease extracts the tar.gz at archive into dir without the
+// archive's top directory, replacing what dir had. The files are unpacked
+// next to dir first, so a failed extraction leaves the old release working.
+func unpackRelease(archive, dir string) error {
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(dir), err)
+	}
+	staging, err := os.MkdirTemp(filepath.Dir(dir), "."+filepath.Base(dir)+"-")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(dir), err)
+	}
+	defer os.RemoveAll(staging)
+
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		// Drop the top directory (nvim-linux-x86_64/)
+		_, rel, ok := strings.Cut(strings.TrimPrefix(filepath.ToSlash(filepath.Clean(hdr.Name)), "./"), "/")
+		if !ok || rel == "" {
+			continue
+		}
+		target := filepath.Join(staging, filepath.FromSlash(rel))
+		if !strings.HasPrefix(target, staging+string(filepath.Separator)) {
+			return fmt.Errorf("archive entry %s is outside the archive", hdr.Name)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", target, err)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", filepath.Dir(target), err)
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(hdr.Mode)&0777)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %w", target, err)
+			}
+			_, err = io.Copy(out, tr)
+			out.Close()
+			if err != nil {
+				return fmt.Errorf("failed to write %s: %w", target, err)
+			}
+		case tar.TypeSymlink:
+			if resolved := filepath.Join(filepath.Dir(target), hdr.Linkname); filepath.IsAbs(hdr.Linkname) || !strings.HasPrefix(resolved, staging+string(filepath.Separator)) {
+				return fmt.Errorf("archive entry %s links outside the archive", hdr.Name)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", filepath.Dir(target), err)
+			}
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return fmt.Errorf("failed to create %s: %w", target, err)
+			}
+		}
+	}
+	if _, err := os.Stat(filepath.Join(staging, "bin", "nvim")); err != nil {
+		return fmt.Errorf("the archive has no bin/nvim")
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to remove the previous release in %s: %w", dir, err)
+	}
+	if err := os.Rename(staging, dir); err != nil {
+		return fmt.Errorf("failed to install into %s: %w", dir, err)
+	}
+	return nil
+}
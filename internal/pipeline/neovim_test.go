@@ -0,0 +1,142 @@
+package pipeline
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+func TestNeovimInstallationSteps(t *testing.T) {
+	tests := []struct {
+		os, arch, manager string
+		want              []string
+	}{
+		{"linux", "amd64", "apt", []string{"neovim-install-release", "neovim-starter-config", "neovim-install-plugins", "neovim-verify"}},
+		{"linux", "riscv64", "apt", []string{"neovim-install-package", "neovim-starter-config", "neovim-install-plugins", "neovim-verify"}},
+		{"darwin", "arm64", "brew", []string{"neovim-install-package", "neovim-starter-config", "neovim-install-plugins", "neovim-verify"}},
+	}
+	for _, tt := range tests {
+		platform := &Platform{OS: tt.os, Arch: tt.arch, PackageManager: tt.manager}
+		ctx := NewInstallationContext(platform, &fakePM{}, nil)
+		tool := &Tool{Tool: interfaces.Tool{Name: "neovim", Category: string(CategoryDevelopment)}, Builtin: "neovim"}
+		var names []string
+		for _, step := range tool.GenerateInstallationSteps(platform, ctx, true) {
+			names = append(names, step.Name)
+		}
+		if strings.Join(names, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("%s/%s: steps = %v, want %v", tt.os, tt.arch, names, tt.want)
+		}
+	}
+}
+
+func TestNeovimConfigRepo(t *testing.T) {
+	tests := map[string]string{
+		"":                                  NeovimStarterConfig,
+		"none":                              "",
+		"me/nvim-config":                    "https://github.com/me/nvim-config.git",
+		"git@github.com:me/nvim-config.git": "git@github.com:me/nvim-config.git",
+		"https://gitlab.com/me/nvim.git":    "https://gitlab.com/me/nvim.git",
+	}
+	for setting, want := range tests {
+		if got := neovimConfigRepo(setting); got != want {
+			t.Errorf("neovimConfigRepo(%q) = %q, want %q", setting, got, want)
+		}
+	}
+}
+
+func TestUsesLazy(t *testing.T) {
+	dir := t.TempDir()
+	if usesLazy(dir) {
+		t.Error("Expected an empty config not to use lazy.nvim")
+	}
+	plugins := filepath.Join(dir, "lua", "config")
+	if err := os.MkdirAll(plugins, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(plugins, "lazy.lua"), []byte(`local lazypath = vim.fn.stdpath("data") .. "/lazy/lazy.nvim"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !usesLazy(dir) {
+		t.Error("Expected a config that bootstraps lazy.nvim to use it")
+	}
+}
+
+// writeNeovimRelease writes a release tarball with entries, a regular file for
+// each name and a symlink for each "name -> target"
+func writeNeovimRelease(t *testing.T, entries ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "nvim-linux-x86_64.tar.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, entry := range entries {
+		name, link, isLink := strings.Cut(entry, " -> ")
+		hdr := &tar.Header{Name: name, Mode: 0755, Size: int64(len(name)), Typeflag: tar.TypeReg}
+		if isLink {
+			hdr = &tar.Header{Name: name, Linkname: link, Typeflag: tar.TypeSymlink}
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if !isLink {
+			tw.Write([]byte(name))
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestUnpackRelease(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "opt", "nvim")
+	if err := os.MkdirAll(filepath.Join(dir, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "bin", "old"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := writeNeovimRelease(t,
+		"nvim-linux-x86_64/bin/nvim",
+		"nvim-linux-x86_64/share/nvim/runtime/filetype.lua",
+		"nvim-linux-x86_64/lib/nvim/parser/lua.so",
+	)
+	if err := unpackRelease(archive, dir); err != nil {
+		t.Fatalf("unpackRelease() error = %v", err)
+	}
+	for _, rel := range []string{"bin/nvim", "share/nvim/runtime/filetype.lua", "lib/nvim/parser/lua.so"} {
+		if _, err := os.Stat(filepath.Join(dir, rel)); err != nil {
+			t.Errorf("Expected %s to be unpacked: %v", rel, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bin", "old")); !os.IsNotExist(err) {
+		t.Error("Expected the previous release to be replaced")
+	}
+
+	for name, entries := range map[string][]string{
+		"no binary":      {"nvim-linux-x86_64/share/nvim/runtime/filetype.lua"},
+		"escaping link":  {"nvim-linux-x86_64/bin/nvim", "nvim-linux-x86_64/share/evil -> ../../../../etc/passwd"},
+		"absolute link":  {"nvim-linux-x86_64/bin/nvim", "nvim-linux-x86_64/share/evil -> /etc/passwd"},
+		"escaping entry": {"nvim-linux-x86_64/../../../evil", "nvim-linux-x86_64/bin/nvim"},
+	} {
+		if err := unpackRelease(writeNeovimRelease(t, entries...), dir); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "bin", "nvim")); err != nil {
+			t.Errorf("%s: expected the installed release to be kept: %v", name, err)
+		}
+	}
+}
@@ -42,4 +42,9 @@ func (a *PackageManagerAdapter) SetupSpecialPackage(pkg string) error {
 // IsPackageAvailable checks if a package is available
 func (a *PackageManagerAdapter) IsPackageAvailable(pkg string) bool {
 	return a.pm.IsPackageAvailable(pkg)
-} 
\ No newline at end of file
+}
+
+// GetVersion returns the installed version of a package
+func (a *PackageManagerAdapter) GetVersion(pkg string) (string, error) {
+	return a.pm.GetVersion(pkg)
+}
@@ -0,0 +1,179 @@
+package pipeline
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+)
+
+// serialManagers hold a system-wide lock while they install, so only one of
+// their installs can run at a time
+var serialManagers = map[string]bool{
+	"apt":    true,
+	"dnf":    true,
+	"yum":    true,
+	"pacman": true,
+	"zypper": true,
+	"pkg":    true,
+}
+
+// SerialManager reports whether installs with manager have to run one at a
+// time, e.g. apt and dnf; brew can install several packages at once
+func SerialManager(manager string) bool {
+	return serialManagers[manager]
+}
+
+// DefaultConcurrency is how many tools install at once unless set: one per CPU
+func DefaultConcurrency() int {
+	return runtime.NumCPU()
+}
+
+// stepUnit is the consecutive steps of one item, run in order by one worker
+type stepUnit struct {
+	item  string
+	steps []int // Indexes into Steps
+}
+
+// units splits the steps into runs of consecutive steps of the same item
+func (p *InstallationPipeline) units() []stepUnit {
+	var units []stepUnit
+	for i, step := range p.Steps {
+		if n := len(units); n > 0 && step.Item != "" {
+			last := p.Steps[units[n-1].steps[0]]
+			if last.Item == step.Item && last.Group == step.Group {
+				units[n-1].steps = append(units[n-1].steps, i)
+				continue
+			}
+		}
+		units = append(units, stepUnit{item: step.Item, steps: []int{i}})
+	}
+	return units
+}
+
+// executeConcurrently runs consecutive Parallel items up to Concurrency at a
+// time and everything else in order. On a failure no more items are started;
+// the ones running finish their current step, then every completed step is
+// rolled back in the reverse order it completed.
+func (p *InstallationPipeline) executeConcurrently() error {
+	units := p.units()
+	var completed []int
+	for start := 0; start < len(units); {
+		end := start + 1
+		if _, ok := p.Parallel[units[start].item]; ok {
+			for end < len(units) {
+				if _, ok := p.Parallel[units[end].item]; !ok {
+					break
+				}
+				end++
+			}
+		}
+		done, failed, err := p.runBatch(units[start:end])
+		completed = append(completed, done...)
+		if errors.Is(err, ErrCancelled) {
+			return ErrCancelled
+		}
+		if err != nil {
+			return p.fail(p.Steps[failed], err, append(completed, failed))
+		}
+		start = end
+	}
+
+	p.Context.State.UpdateState("pipeline", "completed", nil)
+	p.sendProgress(PipelineComplete{OverallSuccess: true, FinalError: nil})
+	return nil
+}
+
+// runBatch runs units concurrently, each after the units it waits for (see
+// Parallel), and returns the steps that completed in the order they did. On a
+// failure it also returns the failed step and its error.
+func (p *InstallationPipeline) runBatch(units []stepUnit) (completed []int, failed int, err error) {
+	var mu sync.Mutex
+	// finished[item] is closed once all of the item's steps have completed
+	finished := make(map[string]chan struct{}, len(units))
+	for _, u := range units {
+		finished[u.item] = make(chan struct{})
+	}
+	stop := make(chan struct{})
+	abort := func(step int, stepErr error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err == nil {
+			failed, err = step, stepErr
+			close(stop)
+		}
+	}
+
+	slots := make(chan struct{}, p.Concurrency)
+	var wg sync.WaitGroup
+	for _, u := range units {
+		wg.Add(1)
+		go func(u stepUnit) {
+			defer wg.Done()
+			for _, dep := range p.Parallel[u.item] {
+				// Items outside this batch finished before it started
+				if ch, ok := finished[dep]; ok && dep != u.item {
+					select {
+					case <-ch:
+					case <-stop:
+						return
+					}
+				}
+			}
+			// Queue for the lock first so waiting on it does not hold a slot
+			unlock := p.lock(p.Locks[u.item])
+			defer unlock()
+			select {
+			case slots <- struct{}{}:
+			case <-stop:
+				return
+			}
+			defer func() { <-slots }()
+
+			for _, idx := range u.steps {
+				step := p.Steps[idx]
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				// Stop between steps once the run is cancelled; completed steps are kept
+				if p.Context.Cancelled() {
+					p.Context.State.UpdateState(step.Name, "cancelled", ErrCancelled)
+					abort(idx, ErrCancelled)
+					return
+				}
+				if stepErr := p.runStep(step); stepErr != nil {
+					abort(idx, stepErr)
+					return
+				}
+				mu.Lock()
+				completed = append(completed, idx)
+				mu.Unlock()
+			}
+			last := p.Steps[u.steps[len(u.steps)-1]]
+			p.itemChanged(last.Group, u.item, true)
+			close(finished[u.item])
+		}(u)
+	}
+	wg.Wait()
+	return completed, failed, err
+}
+
+// lock takes the named lock and returns its release; an empty name locks nothing
+func (p *InstallationPipeline) lock(name string) func() {
+	if name == "" {
+		return func() {}
+	}
+	p.mu.Lock()
+	if p.locks == nil {
+		p.locks = make(map[string]*sync.Mutex)
+	}
+	l, ok := p.locks[name]
+	if !ok {
+		l = &sync.Mutex{}
+		p.locks[name] = l
+	}
+	p.mu.Unlock()
+	l.Lock()
+	return l.Unlock
+}
@@ -0,0 +1,153 @@
+package pipeline
+
+import (
+	"errors"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tracker records which items run at the same time
+type tracker struct {
+	mu       sync.Mutex
+	running  map[string]bool
+	peak     int
+	overlaps map[[2]string]bool
+	finished []string
+}
+
+func newTracker() *tracker {
+	return &tracker{running: make(map[string]bool), overlaps: make(map[[2]string]bool)}
+}
+
+func (tr *tracker) step(item string, err error) func(*InstallationContext) error {
+	return func(*InstallationContext) error {
+		tr.mu.Lock()
+		for other := range tr.running {
+			tr.overlaps[[2]string{item, other}], tr.overlaps[[2]string{other, item}] = true, true
+		}
+		tr.running[item] = true
+		if len(tr.running) > tr.peak {
+			tr.peak = len(tr.running)
+		}
+		tr.mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		tr.mu.Lock()
+		delete(tr.running, item)
+		tr.finished = append(tr.finished, item)
+		tr.mu.Unlock()
+		return err
+	}
+}
+
+func TestExecuteConcurrently(t *testing.T) {
+	ctx := NewInstallationContext(&Platform{OS: "linux", PackageManager: "apt", Shell: "bash"}, &fakePM{}, nil)
+	p := NewInstallationPipeline(ctx)
+	p.Concurrency = 3
+	tr := newTracker()
+	for _, item := range []string{"bat", "fd", "fzf", "ripgrep", "git", "jq"} {
+		p.AddStep(InstallationStep{Name: item + "-install", Group: "Modern", Item: item, Action: tr.step(item, nil)})
+	}
+	p.AddStep(InstallationStep{Name: "zsh-configure", Group: "Shell", Item: "zsh", Action: tr.step("zsh", nil)})
+	p.Parallel = map[string][]string{"bat": nil, "fd": nil, "fzf": {"bat"}, "ripgrep": nil, "git": nil, "jq": nil}
+	p.Locks = map[string]string{"git": "apt", "jq": "apt"}
+
+	var items []string
+	p.OnItem = func(_, item string, done bool) {
+		if done {
+			items = append(items, item)
+		}
+	}
+	if err := p.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if tr.peak < 2 || tr.peak > 3 {
+		t.Errorf("Expected between 2 and 3 tools at once, got %d", tr.peak)
+	}
+	if tr.overlaps[[2]string{"fzf", "bat"}] {
+		t.Error("Expected fzf to wait for bat")
+	}
+	if tr.overlaps[[2]string{"git", "jq"}] {
+		t.Error("Expected git and jq to share the apt lock")
+	}
+	for _, item := range []string{"bat", "fd", "fzf", "ripgrep", "git", "jq"} {
+		if tr.overlaps[[2]string{"zsh", item}] {
+			t.Errorf("Expected zsh to run alone, but it overlapped %s", item)
+		}
+	}
+	if len(tr.finished) != 7 || tr.finished[6] != "zsh" {
+		t.Errorf("Expected zsh to run last, got %v", tr.finished)
+	}
+	if len(items) != 7 {
+		t.Errorf("Expected every item to be reported done, got %v", items)
+	}
+}
+
+func TestExecuteConcurrentlyRollback(t *testing.T) {
+	ctx := NewInstallationContext(&Platform{OS: "linux", PackageManager: "apt", Shell: "bash"}, &fakePM{}, nil)
+	ctx.Retry.Attempts = 1
+	p := NewInstallationPipeline(ctx)
+	p.Concurrency = 2
+	tr := newTracker()
+
+	var mu sync.Mutex
+	var rolledBack []string
+	rollback := func(item string) func(*InstallationContext) error {
+		return func(*InstallationContext) error {
+			mu.Lock()
+			defer mu.Unlock()
+			rolledBack = append(rolledBack, item)
+			return nil
+		}
+	}
+	p.AddStep(InstallationStep{Name: "bat-install", Item: "bat", Action: tr.step("bat", nil), Rollback: rollback("bat")})
+	p.AddStep(InstallationStep{Name: "fd-install", Item: "fd", Action: tr.step("fd", errors.New("E: Unable to locate package fd")), Rollback: rollback("fd")})
+	p.AddStep(InstallationStep{Name: "fzf-install", Item: "fzf", Action: tr.step("fzf", nil), Rollback: rollback("fzf")})
+	p.AddStep(InstallationStep{Name: "zsh-configure", Item: "zsh", Action: tr.step("zsh", nil)})
+	p.Parallel = map[string][]string{"bat": nil, "fd": nil, "fzf": {"fd"}}
+
+	if err := p.Execute(); err == nil {
+		t.Fatal("Expected the fd failure to fail the run")
+	}
+	for _, item := range tr.finished {
+		if item == "fzf" || item == "zsh" {
+			t.Errorf("Expected %s not to start after fd failed", item)
+		}
+	}
+	if len(rolledBack) != 2 {
+		t.Errorf("Expected bat and fd to be rolled back, got %v", rolledBack)
+	}
+	if failed := ctx.State.GetFailedSteps(); len(failed) != 1 || failed[0] != "fd-install" {
+		t.Errorf("Expected only fd-install to fail, got %v", failed)
+	}
+}
+
+func TestAllowParallel(t *testing.T) {
+	installer, err := NewInstaller(&Platform{OS: "darwin", PackageManager: "apt", Shell: "zsh"}, &fakePM{})
+	if err != nil {
+		t.Fatalf("NewInstaller() error = %v", err)
+	}
+	installer.Context.ToolManagers = map[string]string{"fd": "apt", "bat": "brew"}
+	lookPath = func(string) (string, error) { return "/usr/local/bin/brew", nil }
+	defer func() { lookPath = exec.LookPath }()
+	installer.Pipeline = &InstallationPipeline{Parallel: make(map[string][]string), Locks: make(map[string]string)}
+
+	fzf := NewTool("fzf", CategoryDevelopment)
+	fzf.AddDependency(Dependency{Name: "fd"})
+	fzf.AddDependency(Dependency{Name: "curl"})
+	selected := map[string]*Tool{"fzf": fzf, "fd": NewTool("fd", CategoryDevelopment), "bat": NewTool("bat", CategoryDevelopment)}
+	for _, tool := range selected {
+		installer.allowParallel(tool, selected)
+	}
+
+	if deps := installer.Pipeline.Parallel["fzf"]; len(deps) != 1 || deps[0] != "fd" {
+		t.Errorf("Expected fzf to wait only for the selected fd, got %v", deps)
+	}
+	if locks := installer.Pipeline.Locks; locks["fd"] != "apt" || locks["fzf"] != "apt" || locks["bat"] != "" {
+		t.Errorf("Expected apt installs to share a lock and brew installs not to, got %v", locks)
+	}
+}
@@ -1,10 +1,15 @@
 package pipeline
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cmdexec"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
 )
 
 // InstallationStep represents a single step in the installation pipeline
@@ -16,6 +21,9 @@ type InstallationStep struct {
 	Timeout     time.Duration
 	RetryCount  int
 	RetryDelay  time.Duration
+	// Group and Item place the step in the install summary (e.g. "Modern" / "bat")
+	Group string
+	Item  string
 }
 
 // InstallationPipeline represents a sequence of installation steps
@@ -25,6 +33,31 @@ type InstallationPipeline struct {
 	Logger       interfaces.Logger
 	progressChan chan<- ProgressEvent
 	Context      *InstallationContext
+	// OnItem is called when the last step of an item (Step.Item) completes, with
+	// done set, and when a rollback undoes one of its steps, with done unset
+	OnItem func(group, item string, done bool)
+	// Concurrency is how many items may install at once; 1 or less runs every
+	// step in order
+	Concurrency int
+	// Parallel lists the items (Step.Item) that may install alongside each
+	// other, each with the items it has to wait for. Steps of other items run
+	// alone, once everything before them has finished.
+	Parallel map[string][]string
+	// Locks names a lock per item; items holding the same lock never install at
+	// the same time, e.g. two apt installs
+	Locks map[string]string
+	// LogDir is where the output of a failed step is written, one log per item
+	// (see manifest.WriteFailureLog); empty writes none
+	LogDir string
+	// ItemTimeouts bounds how long all the steps of an item (Step.Item) may
+	// take together, e.g. a tool's timeout; each step is also bounded by its own
+	// Timeout
+	ItemTimeouts map[string]time.Duration
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+	// itemStarts is when the first step of each item with a timeout started
+	itemStarts map[string]time.Time
 }
 
 // NewInstallationPipeline creates a new installation pipeline
@@ -43,53 +76,53 @@ func (p *InstallationPipeline) AddStep(step InstallationStep) {
 	if step.Timeout == 0 {
 		step.Timeout = 5 * time.Minute
 	}
-	if step.RetryCount == 0 {
-		step.RetryCount = 3
-	}
-	if step.RetryDelay == 0 {
-		step.RetryDelay = 5 * time.Second
-	}
 	p.Steps = append(p.Steps, step)
 }
 
 // Execute runs all steps in the pipeline
-func (p *InstallationPipeline) Execute() error {
-	var finalError error
+func (p *InstallationPipeline) Execute() (err error) {
 	// startTime := time.Now() // Track start time for duration - Removed as not used for overall pipeline duration event yet
 
 	// Ensure channel is closed when execution finishes (success or failure)
 	if p.progressChan != nil {
 		defer close(p.progressChan)
 	}
+	// Write the rc file edits the steps staged, also those of the items that
+	// finished before a failure
+	defer func() {
+		if rcErr := p.Context.flushRC(); rcErr != nil {
+			if err == nil {
+				err = rcErr
+			} else {
+				p.Logger.Warn("%v", rcErr)
+			}
+		}
+	}()
 
-	for i, step := range p.Steps {
-		stepStartTime := time.Now()
-		p.Context.State.UpdateState(step.Name, "running", nil)
-		p.sendProgress(TaskStart{TaskID: step.Name, Description: step.Description})
-		
-		// Execute step with retry
-		err := p.executeStepWithRetry(step)
-		duration := time.Since(stepStartTime)
+	if p.Concurrency > 1 && len(p.Parallel) > 0 {
+		return p.executeConcurrently()
+	}
 
-		if err != nil {
-			p.Context.State.UpdateState(step.Name, "failed", err)
-			p.sendProgress(TaskEnd{TaskID: step.Name, Success: false, Error: err, Duration: duration})
-			
+	for i, step := range p.Steps {
+		// Stop between steps once the run is cancelled; completed steps are kept
+		if p.Context.Cancelled() {
+			p.Context.State.UpdateState(step.Name, "cancelled", ErrCancelled)
+			return ErrCancelled
+		}
+		if err := p.runStep(step); err != nil {
+			if errors.Is(err, ErrCancelled) {
+				return ErrCancelled
+			}
 			// Attempt rollback of completed steps
-			rollbackErr := p.rollback(i)
-			if rollbackErr != nil {
-				finalError = fmt.Errorf("step '%s' failed: %w; rollback also failed: %w", 
-					step.Name, err, rollbackErr)
-			} else {
-				finalError = fmt.Errorf("step '%s' failed: %w; rollback successful", step.Name, err)
+			completed := make([]int, i+1)
+			for n := range completed {
+				completed[n] = n
 			}
-			// Send complete message immediately on critical failure + rollback attempt
-			p.sendProgress(PipelineComplete{OverallSuccess: false, FinalError: finalError})
-			return finalError // Stop pipeline execution
+			return p.fail(step, err, completed)
+		}
+		if step.Item != "" && (i == len(p.Steps)-1 || p.Steps[i+1].Item != step.Item || p.Steps[i+1].Group != step.Group) {
+			p.itemChanged(step.Group, step.Item, true)
 		}
-		
-		p.Context.State.UpdateState(step.Name, "completed", nil)
-		p.sendProgress(TaskEnd{TaskID: step.Name, Success: true, Duration: duration})
 	}
 	
 	p.Context.State.UpdateState("pipeline", "completed", nil)
@@ -98,43 +131,171 @@ func (p *InstallationPipeline) Execute() error {
 	return nil
 }
 
-// executeStepWithRetry executes a step with retry logic
-func (p *InstallationPipeline) executeStepWithRetry(step InstallationStep) error {
-	var lastErr error
-	
-	for attempt := 0; attempt <= step.RetryCount; attempt++ {
-		if attempt > 0 {
-			p.Context.State.UpdateState(step.Name, "retrying", lastErr)
-			// TODO: Send a TaskLog or specific Retry message?
-			p.sendProgress(TaskLog{TaskID: step.Name, Line: fmt.Sprintf("Retrying (attempt %d/%d)... Error: %v", attempt, step.RetryCount, lastErr)})
-			time.Sleep(step.RetryDelay)
+// runStep runs one step and reports its start and end
+func (p *InstallationPipeline) runStep(step InstallationStep) error {
+	start := time.Now()
+	p.Context.State.UpdateState(step.Name, "running", nil)
+	p.sendProgress(TaskStart{TaskID: step.Name, Description: step.Description, Group: step.Group, Item: step.Item})
+	p.Context.State.ResetOutput(step.Item)
+
+	// Execute step with retry; its commands are stopped once it runs out of time
+	timeout := p.stepTimeout(step)
+	ctx, end := p.Context.startStep(step.Item, timeout)
+	err := p.executeStepWithRetry(step, ctx)
+	switch {
+	case err == nil:
+	case p.Context.Cancelled():
+		err = fmt.Errorf("%w: %w", ErrCancelled, err)
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		err = &TimeoutError{Step: step.Name, Timeout: timeout, Cause: err}
+	}
+	end()
+	duration := time.Since(start)
+	if err != nil {
+		p.Context.State.UpdateState(step.Name, "failed", err)
+		output := p.Context.State.Output(step.Item)
+		p.sendProgress(TaskEnd{TaskID: step.Name, Success: false, Error: err, Duration: duration,
+			Output: string(output), LogPath: p.writeFailureLog(step, output)})
+		return err
+	}
+	p.Context.State.UpdateState(step.Name, "completed", nil)
+	p.sendProgress(TaskEnd{TaskID: step.Name, Success: true, Duration: duration, Version: p.Context.State.Version(step.Item)})
+	return nil
+}
+
+// writeFailureLog writes the output of the failed step to LogDir and returns
+// the log's path, or "" when there is nothing to write
+func (p *InstallationPipeline) writeFailureLog(step InstallationStep, output []byte) string {
+	if p.LogDir == "" || step.Item == "" || len(output) == 0 {
+		return ""
+	}
+	path, err := manifest.WriteFailureLog(p.LogDir, step.Item, output, time.Now())
+	if err != nil {
+		if p.Logger != nil {
+			p.Logger.Warn("Failed to write the output of %s: %v", step.Name, err)
 		}
-		
-		// Execute step
+		return ""
+	}
+	p.Context.State.RecordLogPath(step.Item, path)
+	return path
+}
+
+// stepTimeout returns how long step may run: its own Timeout, cut short by
+// what is left of its item's timeout. It is 0 when neither is set.
+func (p *InstallationPipeline) stepTimeout(step InstallationStep) time.Duration {
+	timeout := step.Timeout
+	limit, ok := p.ItemTimeouts[step.Item]
+	if !ok || limit <= 0 {
+		return timeout
+	}
+	p.mu.Lock()
+	if p.itemStarts == nil {
+		p.itemStarts = make(map[string]time.Time)
+	}
+	started, ok := p.itemStarts[step.Item]
+	if !ok {
+		started = time.Now()
+		p.itemStarts[step.Item] = started
+	}
+	p.mu.Unlock()
+
+	// A step started with no time left times out straight away
+	left := max(limit-time.Since(started), time.Nanosecond)
+	if timeout <= 0 || left < timeout {
+		return left
+	}
+	return timeout
+}
+
+// fail rolls back the given steps, latest first, after step failed with err
+// and reports the end of the pipeline
+func (p *InstallationPipeline) fail(step InstallationStep, err error, completed []int) error {
+	var finalError error
+	if rollbackErr := p.rollback(completed); rollbackErr != nil {
+		finalError = fmt.Errorf("step '%s' failed: %w; rollback also failed: %w",
+			step.Name, err, rollbackErr)
+	} else {
+		finalError = fmt.Errorf("step '%s' failed: %w; rollback successful", step.Name, err)
+	}
+	// Send complete message immediately on critical failure + rollback attempt
+	p.sendProgress(PipelineComplete{OverallSuccess: false, FinalError: finalError})
+	return finalError // Stop pipeline execution
+}
+
+// itemChanged calls OnItem; items finish on several goroutines when running
+// concurrently
+func (p *InstallationPipeline) itemChanged(group, item string, done bool) {
+	if p.OnItem == nil || item == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.OnItem(group, item, done)
+}
+
+// executeStepWithRetry executes a step, retrying failures that may be transient
+// (a held package manager lock, a network timeout) with exponential backoff as
+// set by the context's Retry policy. A step's own RetryCount and RetryDelay
+// override the policy's attempts and first delay.
+func (p *InstallationPipeline) executeStepWithRetry(step InstallationStep, ctx context.Context) error {
+	policy := p.Context.Retry
+	if step.RetryCount > 0 {
+		policy.Attempts = step.RetryCount + 1
+	}
+	if step.RetryDelay > 0 {
+		policy.InitialDelay = step.RetryDelay
+	}
+
+	attempts := 0
+	err := policy.Do(func() error {
+		attempts++
 		// TODO: Capture stdout/stderr from step.Action() and send as TaskLog events if possible.
-		err := step.Action(p.Context)
-		if err == nil {
-			return nil
+		err := p.runAction(step, ctx)
+		// Retrying is pointless once the step has timed out or the run is cancelled
+		if errors.Is(err, ErrCancelled) || (err != nil && ctx.Err() != nil) {
+			return cmdexec.Permanent(err)
 		}
-		
-		lastErr = err
-		
-		// Check if error is retryable
-		if !isRetryableError(err) {
+		return err
+	}, func(attempt, total int, lastErr error) {
+		p.Context.State.UpdateState(step.Name, "retrying", lastErr)
+		p.sendProgress(TaskRetry{TaskID: step.Name, Attempt: attempt, Attempts: total, Error: lastErr})
+		p.sendProgress(TaskLog{TaskID: step.Name, Line: fmt.Sprintf("Retrying (attempt %d/%d)... Error: %v", attempt, total, lastErr)})
+	})
+	if err != nil && attempts > 1 {
+		return fmt.Errorf("failed after %d attempts: %w", attempts, err)
+	}
+	return err
+}
+
+// runAction runs the action of step, giving up on it once ctx is done. Its
+// commands are stopped then too, so the action is given until they have been
+// killed to return, with the output they captured.
+func (p *InstallationPipeline) runAction(step InstallationStep, ctx context.Context) error {
+	result := make(chan error, 1)
+	go func() { result <- step.Action(p.Context) }()
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+	}
+	select {
+	case err := <-result:
+		if err != nil {
 			return err
 		}
+	case <-time.After(cmdexec.KillWaitDelay + time.Second):
 	}
-	
-	return fmt.Errorf("failed after %d attempts: %w", step.RetryCount, lastErr)
+	return ctx.Err()
 }
 
-// rollback attempts to roll back completed steps in reverse order
-func (p *InstallationPipeline) rollback(lastCompletedIndex int) error {
+// rollback attempts to roll back the given steps, indexes into Steps in the
+// order they completed, in reverse order
+func (p *InstallationPipeline) rollback(completed []int) error {
 	var firstRollbackErr error
 	p.sendProgress(TaskLog{TaskID: "pipeline", Line: "Attempting rollback..."})
 
-	for i := lastCompletedIndex; i >= 0; i-- {
-		step := p.Steps[i]
+	for n := len(completed) - 1; n >= 0; n-- {
+		step := p.Steps[completed[n]]
 		stepStartTime := time.Now()
 		p.Context.State.UpdateState(step.Name, "rolling_back", nil)
 		p.sendProgress(TaskStart{TaskID: step.Name + "-rollback", Description: "Rolling back: " + step.Name})
@@ -156,6 +317,9 @@ func (p *InstallationPipeline) rollback(lastCompletedIndex int) error {
 		} else {
 		p.Context.State.UpdateState(step.Name, "rolled_back", nil)
 			p.sendProgress(TaskEnd{TaskID: step.Name + "-rollback", Success: true, Duration: duration})
+			if step.Rollback != nil {
+				p.itemChanged(step.Group, step.Item, false)
+			}
 		}
 	}
 	
@@ -165,22 +329,18 @@ func (p *InstallationPipeline) rollback(lastCompletedIndex int) error {
 // sendProgress sends an event to the progress channel if it's not nil.
 func (p *InstallationPipeline) sendProgress(event ProgressEvent) {
 	if p.progressChan != nil {
-		// Use non-blocking send or buffered channel to prevent pipeline locking if UI isn't reading
-		// For simplicity now, using blocking send. Consider buffered channel in New.
-		p.progressChan <- event
+		// Block until the UI reads the event, unless the run is cancelled because
+		// the UI has quit and nothing will read it again
+		select {
+		case p.progressChan <- event:
+		case <-p.Context.Done():
+		}
 	}
 	if p.Logger != nil { // Also log the event string representation
 		p.Logger.Debug("Progress Event: %s", event)
 	}
 }
 
-// isRetryableError determines if an error should trigger a retry
-func isRetryableError(err error) bool {
-	// TODO: Implement more sophisticated error classification
-	// For now, consider network-related errors as retryable
-	return err != nil
-}
-
 // GetProgress returns the current progress of the pipeline
 func (p *InstallationPipeline) GetProgress() string {
 	return fmt.Sprintf("Steps: %d, State: %s", len(p.Steps), p.State.String())
@@ -2,38 +2,47 @@ package pipeline
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cmdexec"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
 	// Import for interfaces.PackageManager
 )
 
 // --- Fake PackageManager for testing ---
-type fakePM struct {}
+type fakePM struct{}
+
 func (f *fakePM) Install(pkg string) error             { return nil }
 func (f *fakePM) Uninstall(pkg string) error           { return nil }
 func (f *fakePM) IsInstalled(pkg string) (bool, error) { return false, nil }
-func (f *fakePM) Update() error                      { return nil }
+func (f *fakePM) Update() error                        { return nil }
 func (f *fakePM) SetupSpecialPackage(pkg string) error { return nil }
-func (f *fakePM) IsPackageAvailable(pkg string) bool { return true }
-func (f *fakePM) GetName() string                    { return "fake" }
+func (f *fakePM) IsPackageAvailable(pkg string) bool   { return true }
+func (f *fakePM) GetName() string                      { return "fake" }
+
 // Ensure it satisfies the pipeline.PackageManager interface (defined in interfaces.go of this package)
 var _ PackageManager = (*fakePM)(nil)
+
 // It ALSO needs to satisfy interfaces.PackageManager if used elsewhere expecting that.
 // var _ interfaces.PackageManager = (*fakePM)(nil) // Add if needed and update methods
 
-// --- Helper to create context --- 
+// --- Helper to create context ---
 func newTestContext(t *testing.T) (*InstallationContext, chan ProgressEvent) {
-	progChan := make(chan ProgressEvent, 10) 
+	progChan := make(chan ProgressEvent, 10)
 	platform := &Platform{OS: "linux", Arch: "amd64", PackageManager: "apt", Shell: "bash"}
-	
+
 	// Use the package-level fakePM
 	var pm PackageManager = &fakePM{}
-	
+
 	context := NewInstallationContext(platform, pm, progChan)
 	return context, progChan
 }
 
-// --- Tests --- 
+// --- Tests ---
 
 func TestInstallationState(t *testing.T) {
 	state := NewInstallationState()
@@ -60,7 +69,7 @@ func TestInstallationState(t *testing.T) {
 func TestInstallationPipeline(t *testing.T) {
 	ctx, progChan := newTestContext(t)
 	defer close(progChan)
-	
+
 	pipeline := NewInstallationPipeline(ctx) // Pass context
 
 	// Test successful step
@@ -114,6 +123,15 @@ func TestPlatformDetection(t *testing.T) {
 	}
 }
 
+func TestPlatformFor(t *testing.T) {
+	sysInfo := &system.Info{OS: "linux", Arch: "arm64", Shell: "/bin/zsh", PackageType: "apt"}
+	got := PlatformFor(sysInfo, "brew")
+	want := Platform{OS: "linux", Arch: "arm64", PackageManager: "brew", Shell: "/bin/zsh"}
+	if *got != want {
+		t.Errorf("PlatformFor() = %+v, want %+v", *got, want)
+	}
+}
+
 func TestPipelineTimeout(t *testing.T) {
 	ctx, progChan := newTestContext(t)
 	defer close(progChan)
@@ -163,4 +181,40 @@ func TestPipelineRetry(t *testing.T) {
 	if attempts != 3 {
 		t.Errorf("Expected 3 attempts, got %d", attempts)
 	}
-} 
\ No newline at end of file
+}
+func TestPipelineWritesFailureLog(t *testing.T) {
+	progChan := make(chan ProgressEvent, 100)
+	ctx := NewInstallationContext(&Platform{OS: "linux", PackageManager: "apt", Shell: "bash"}, &fakePM{}, progChan)
+	ctx.Retry = cmdexec.RetryPolicy{Attempts: 1}
+	p := NewInstallationPipeline(ctx)
+	p.LogDir = filepath.Join(t.TempDir(), "logs")
+	p.AddStep(InstallationStep{
+		Name:  "fd-install",
+		Group: "Modern",
+		Item:  "fd",
+		Action: func(ctx *InstallationContext) error {
+			_, err := ctx.runCommand("fd", ctx.command("fd", "sh", "-c", "echo 'E: Unable to locate package fd-find'; exit 100"))
+			return err
+		},
+	})
+
+	if err := p.Execute(); err == nil {
+		t.Fatal("Execute() should fail")
+	}
+	var end TaskEnd
+	for event := range progChan {
+		if e, ok := event.(TaskEnd); ok && e.TaskID == "fd-install" {
+			end = e
+		}
+	}
+	if !strings.Contains(end.Output, "Unable to locate package") || end.LogPath == "" {
+		t.Fatalf("TaskEnd = %+v, want the output and a log path", end)
+	}
+	data, err := os.ReadFile(end.LogPath)
+	if err != nil || !strings.Contains(string(data), "Unable to locate package") {
+		t.Errorf("log = %q, %v", data, err)
+	}
+	if g := p.Summary().Groups()[0]; g.Logs["fd"] != end.LogPath {
+		t.Errorf("summary logs = %v, want fd: %s", g.Logs, end.LogPath)
+	}
+}
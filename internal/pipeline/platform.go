@@ -4,8 +4,13 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/packages/factory"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
 )
 
 // Platform represents the current system platform
@@ -16,6 +21,40 @@ type Platform struct {
 	Arch           string
 }
 
+// DetectHost detects this machine's platform and the package manager commands
+// install with, in the configured manager priority
+func DetectHost() (*Platform, interfaces.PackageManager, error) {
+	sysInfo, err := system.Detect()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to detect system info: %w", err)
+	}
+	pm, err := DetectPackageManager()
+	if err != nil {
+		return nil, nil, err
+	}
+	return PlatformFor(sysInfo, pm.GetName()), pm, nil
+}
+
+// DetectPackageManager returns the package manager commands install with, in
+// the configured manager priority
+func DetectPackageManager() (interfaces.PackageManager, error) {
+	pm, err := factory.NewPackageManagerFactory().GetPackageManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect package manager: %w", err)
+	}
+	return pm, nil
+}
+
+// PlatformFor returns the platform of a detected system whose packages install with manager
+func PlatformFor(sysInfo *system.Info, manager string) *Platform {
+	return &Platform{
+		OS:             sysInfo.OS,
+		Arch:           sysInfo.Arch,
+		PackageManager: manager,
+		Shell:          sysInfo.Shell,
+	}
+}
+
 // DetectPlatform detects the current platform and its characteristics
 func DetectPlatform() (*Platform, error) {
 	platform := &Platform{
@@ -38,6 +77,12 @@ func DetectPlatform() (*Platform, error) {
 
 // detectPackageManager detects the available package manager
 func (p *Platform) detectPackageManager() error {
+	// Termux ships apt too, but its pkg wrapper is the supported front end
+	if system.IsTermux(os.Getenv) {
+		p.PackageManager = "pkg"
+		return nil
+	}
+
 	// Check for apt (Debian/Ubuntu)
 	if _, err := exec.LookPath("apt"); err == nil {
 		p.PackageManager = "apt"
@@ -62,6 +107,12 @@ func (p *Platform) detectPackageManager() error {
 		return nil
 	}
 
+	// Check for zypper (openSUSE)
+	if _, err := exec.LookPath("zypper"); err == nil {
+		p.PackageManager = "zypper"
+		return nil
+	}
+
 	// Check for yum (RHEL/CentOS)
 	if _, err := exec.LookPath("yum"); err == nil {
 		p.PackageManager = "yum"
@@ -74,6 +125,11 @@ func (p *Platform) detectPackageManager() error {
 // detectShell detects the current shell
 func (p *Platform) detectShell() error {
 	shell := os.Getenv("SHELL")
+	if shell == "" && runtime.GOOS == "windows" {
+		// Windows has no $SHELL; PowerShell is its shell
+		p.Shell = "pwsh"
+		return nil
+	}
 	if shell == "" {
 		return fmt.Errorf("SHELL environment variable not set")
 	}
@@ -86,6 +142,10 @@ func (p *Platform) detectShell() error {
 		p.Shell = "bash"
 	case strings.Contains(shellName, "fish"):
 		p.Shell = "fish"
+	case filepath.Base(shellName) == "nu":
+		p.Shell = "nu"
+	case strings.Contains(filepath.Base(shellName), "pwsh"), strings.Contains(filepath.Base(shellName), "powershell"):
+		p.Shell = "pwsh"
 	default:
 		p.Shell = "unknown"
 	}
@@ -93,6 +153,12 @@ func (p *Platform) detectShell() error {
 	return nil
 }
 
+// sudoPrefix returns "sudo " for system package commands, or "" when running as
+// root, without sudo installed, or on Termux
+func sudoPrefix() string {
+	return system.SudoPrefix()
+}
+
 // String returns a string representation of the platform
 func (p *Platform) String() string {
 	return fmt.Sprintf("OS: %s, Arch: %s, Package Manager: %s, Shell: %s",
@@ -103,7 +169,7 @@ func (p *Platform) String() string {
 func (p *Platform) IsSupported() bool {
 	// Check OS
 	switch p.OS {
-	case "linux", "darwin":
+	case "linux", "darwin", "android":
 		// These OSes are supported
 	default:
 		return false
@@ -111,7 +177,7 @@ func (p *Platform) IsSupported() bool {
 
 	// Check package manager
 	switch p.PackageManager {
-	case "apt", "brew", "pacman", "dnf", "yum":
+	case "apt", "brew", "pacman", "dnf", "zypper", "yum", "pkg":
 		// These package managers are supported
 	default:
 		return false
@@ -119,7 +185,7 @@ func (p *Platform) IsSupported() bool {
 
 	// Check shell
 	switch p.Shell {
-	case "bash", "zsh", "fish":
+	case "bash", "zsh", "fish", "nu", "pwsh":
 		// These shells are supported
 	default:
 		return false
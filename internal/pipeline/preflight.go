@@ -0,0 +1,85 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PreflightIssue describes a selected tool that cannot be installed on the current platform
+type PreflightIssue struct {
+	Tool   string
+	Reason string
+}
+
+// Error implements the error interface
+func (p PreflightIssue) Error() string {
+	return fmt.Sprintf("%s: %s", p.Tool, p.Reason)
+}
+
+// HasInstallMethod reports whether the tool can be installed on the platform,
+// either through a package for the active package manager or the platform's
+// OS, custom install commands or a GitHub release.
+func (t *Tool) HasInstallMethod(platform *Platform) bool {
+	if t.Release != nil {
+		return true
+	}
+	strategy := t.GetInstallStrategy(platform)
+	if name, _ := strategy.GetPackageName(platform.PackageManager); name != "" || strategy.PackageNames[platform.OS] != "" {
+		return true
+	}
+	if strategy.HasCustomInstall() {
+		return true
+	}
+	// Fall back to the generic strategy when a platform override has no method of its own
+	if fallback := t.Install; len(fallback.CustomInstall) > 0 {
+		return true
+	}
+	if name, _ := t.Install.GetPackageName(platform.PackageManager); name != "" || t.Install.PackageNames[platform.OS] != "" {
+		return true
+	}
+	return false
+}
+
+// SupportsOS reports whether the tool is offered on goos, and if not, why.
+// Android matches linux entries, as it does for Go build constraints.
+func (t *Tool) SupportsOS(goos string) (bool, string) {
+	matches := func(list []string) bool {
+		for _, name := range list {
+			if strings.EqualFold(name, goos) || (goos == "android" && strings.EqualFold(name, "linux")) {
+				return true
+			}
+		}
+		return false
+	}
+	if matches(t.UnsupportedOS) {
+		return false, fmt.Sprintf("not supported on %s", goos)
+	}
+	if len(t.SupportedOS) > 0 && !matches(t.SupportedOS) {
+		return false, fmt.Sprintf("only supported on %s", strings.Join(t.SupportedOS, ", "))
+	}
+	return true, ""
+}
+
+// Preflight splits the selected tools into those that can be installed on the platform
+// and issues for those that cannot, so impossible selections are reported before
+// any installation starts. Groups pass through; their members are checked when
+// the installer expands them.
+func Preflight(tools []*Tool, platform *Platform) ([]*Tool, []PreflightIssue) {
+	installable := make([]*Tool, 0, len(tools))
+	var issues []PreflightIssue
+	for _, tool := range tools {
+		if ok, reason := tool.SupportsOS(platform.OS); !ok {
+			issues = append(issues, PreflightIssue{Tool: tool.Name, Reason: reason})
+			continue
+		}
+		if tool.IsGroup() || tool.HasInstallMethod(platform) {
+			installable = append(installable, tool)
+			continue
+		}
+		issues = append(issues, PreflightIssue{
+			Tool:   tool.Name,
+			Reason: fmt.Sprintf("no %s package or install commands available", platform.PackageManager),
+		})
+	}
+	return installable, issues
+}
@@ -0,0 +1,118 @@
+package pipeline
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+// privilegedManagers install system packages, so they run as root (through
+// sudo unless bootstrap-cli already is root)
+var privilegedManagers = map[string]bool{"apt": true, "dnf": true, "pacman": true, "zypper": true}
+
+// NeedsPrivilege reports whether installing t on platform runs a command as
+// root: a system package, Docker's builtin installer, or an install command
+// that calls sudo. Tools with a release still need it when a system package
+// exists, since the package is preferred; groups are checked by their members.
+func (t *Tool) NeedsPrivilege(platform *Platform) bool {
+	if t.IsGroup() {
+		return false
+	}
+	manager := platform.PackageManager
+	if t.PreferredManager != "" {
+		manager = t.PreferredManager
+	}
+	switch t.Builtin {
+	case "docker":
+		return true
+	case "neovim":
+		if neovimFromRelease(platform) {
+			return false
+		}
+	}
+	strategy := t.GetInstallStrategy(platform)
+	if privilegedManagers[manager] {
+		if name, _ := strategy.GetPackageName(manager); name != "" || t.Builtin != "" {
+			return true
+		}
+		if name, _ := t.Install.GetPackageName(manager); name != "" {
+			return true
+		}
+		if len(strategy.SystemDependencies) > 0 {
+			return true
+		}
+	}
+	for _, commands := range [][]Command{strategy.PreInstall, strategy.CustomInstall, strategy.PostInstall} {
+		for _, cmd := range commands {
+			if cmd.RequiresSudo || callsSudo(cmd.Command) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WithoutSudo splits tools into those that can be installed without root and
+// issues for the rest, for a run with --no-sudo. Tools with a GitHub release
+// are kept and installed from it into ~/.local/bin instead of their system
+// package (see InstallationContext.NoSudo).
+func WithoutSudo(tools []*Tool, platform *Platform) ([]*Tool, []PreflightIssue) {
+	kept := make([]*Tool, 0, len(tools))
+	var issues []PreflightIssue
+	for _, tool := range tools {
+		if !tool.NeedsPrivilege(platform) || (tool.Release != nil && tool.Builtin == "") {
+			kept = append(kept, tool)
+			continue
+		}
+		issues = append(issues, PreflightIssue{Tool: tool.Name, Reason: "installing it needs sudo, which --no-sudo rules out"})
+	}
+	return kept, issues
+}
+
+// ShellNeedsPrivilege reports whether selecting sh installs it with a system
+// package, because it is not on PATH yet
+func ShellNeedsPrivilege(sh *interfaces.Shell, platform *Platform) bool {
+	if _, err := lookPath(sh.Name); err == nil {
+		return false
+	}
+	return privilegedManagers[platform.PackageManager]
+}
+
+// LanguageNeedsPrivilege reports whether the language is installed with a
+// system package under strategy, rather than a version manager in $HOME
+func LanguageNeedsPrivilege(lang *interfaces.Language, strategy string, platform *Platform) bool {
+	return lang.ResolveStrategy(strategy) == interfaces.LanguageStrategySystem && privilegedManagers[platform.PackageManager]
+}
+
+// neovimFromRelease reports whether the neovim builtin installs the release
+// tarball into $HOME on platform rather than a package
+func neovimFromRelease(platform *Platform) bool {
+	if platform.OS != "linux" {
+		return false
+	}
+	arch := platform.Arch
+	if arch == "" {
+		arch = runtime.GOARCH
+	}
+	_, ok := neovimRelease.ArchNames[arch]
+	return ok
+}
+
+// callsSudo reports whether a shell command runs sudo
+func callsSudo(command string) bool {
+	for _, field := range strings.FieldsFunc(command, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\n' || r == ';' || r == '&' || r == '|' || r == '(' || r == '`'
+	}) {
+		if field == "sudo" {
+			return true
+		}
+	}
+	return false
+}
+
+// sudoRefusal is the error of a privileged step run with NoSudo
+func sudoRefusal(item string) error {
+	return fmt.Errorf("%s needs sudo, which --no-sudo rules out", item)
+}
@@ -0,0 +1,110 @@
+package pipeline
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/release"
+)
+
+func TestTool_NeedsPrivilege(t *testing.T) {
+	apt := &Platform{OS: "linux", Arch: "amd64", PackageManager: "apt"}
+	brew := &Platform{OS: "darwin", Arch: "arm64", PackageManager: "brew"}
+
+	packaged := NewTool("bat", CategoryDevelopment)
+	packaged.SetInstallation(InstallStrategy{PackageNames: map[string]string{"apt": "bat", "brew": "bat"}})
+
+	scripted := NewTool("starship", CategoryShell)
+	scripted.SetInstallation(InstallStrategy{CustomInstall: []Command{{Command: "curl -sS https://starship.rs/install.sh | sh -s -- -y"}}})
+
+	sudoScript := NewTool("rustscan", CategoryDevelopment)
+	sudoScript.SetInstallation(InstallStrategy{CustomInstall: []Command{{Command: "curl -LO https://example.com/rustscan.deb && sudo dpkg -i rustscan.deb"}}})
+
+	docker := NewTool("docker", CategoryDevelopment)
+	docker.Builtin = "docker"
+	neovim := NewTool("neovim", CategoryDevelopment)
+	neovim.Builtin = "neovim"
+
+	tests := []struct {
+		tool     *Tool
+		platform *Platform
+		want     bool
+	}{
+		{packaged, apt, true},
+		{packaged, brew, false},
+		{scripted, apt, false},
+		{sudoScript, brew, true},
+		{docker, brew, true},
+		{neovim, apt, false},
+		{neovim, &Platform{OS: "linux", Arch: "riscv64", PackageManager: "apt"}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.tool.NeedsPrivilege(tt.platform); got != tt.want {
+			t.Errorf("%s.NeedsPrivilege(%s) = %v, want %v", tt.tool.Name, tt.platform.PackageManager, got, tt.want)
+		}
+	}
+}
+
+func TestWithoutSudo(t *testing.T) {
+	platform := &Platform{OS: "linux", Arch: "amd64", PackageManager: "apt"}
+
+	packaged := NewTool("htop", CategorySystem)
+	packaged.SetInstallation(InstallStrategy{PackageNames: map[string]string{"apt": "htop"}})
+	released := NewTool("delta", CategoryDevelopment)
+	released.SetInstallation(InstallStrategy{PackageNames: map[string]string{"apt": "git-delta"}})
+	released.Release = &release.Spec{Repo: "dandavison/delta", Asset: "delta-{version}-{arch}-{os}.tar.gz"}
+	scripted := NewTool("starship", CategoryShell)
+	scripted.SetInstallation(InstallStrategy{CustomInstall: []Command{{Command: "curl -sS https://starship.rs/install.sh | sh"}}})
+
+	kept, issues := WithoutSudo([]*Tool{packaged, released, scripted}, platform)
+	if len(kept) != 2 || kept[0].Name != "delta" || kept[1].Name != "starship" {
+		t.Errorf("Expected delta and starship to be kept, got %v", kept)
+	}
+	if len(issues) != 1 || issues[0].Tool != "htop" {
+		t.Fatalf("Expected a single issue for htop, got %v", issues)
+	}
+
+	// The release stands in for the package when installing
+	ctx := &InstallationContext{Platform: platform, NoSudo: true}
+	if method, err := released.determineInstallationMethod(ctx, "apt"); err != nil || method != BinaryInstall {
+		t.Errorf("determineInstallationMethod() with NoSudo = %v, %v; want %v", method, err, BinaryInstall)
+	}
+}
+
+func TestShellNeedsPrivilege(t *testing.T) {
+	orig := lookPath
+	t.Cleanup(func() { lookPath = orig })
+	lookPath = func(file string) (string, error) {
+		if file == "bash" {
+			return "/bin/bash", nil
+		}
+		return "", errors.New("not found")
+	}
+
+	apt := &Platform{OS: "linux", PackageManager: "apt"}
+	if ShellNeedsPrivilege(&interfaces.Shell{Name: "bash"}, apt) {
+		t.Error("Expected an installed shell not to need sudo")
+	}
+	if !ShellNeedsPrivilege(&interfaces.Shell{Name: "zsh"}, apt) {
+		t.Error("Expected installing zsh with apt to need sudo")
+	}
+	if ShellNeedsPrivilege(&interfaces.Shell{Name: "zsh"}, &Platform{OS: "darwin", PackageManager: "brew"}) {
+		t.Error("Expected installing zsh with brew not to need sudo")
+	}
+}
+
+func TestCallsSudo(t *testing.T) {
+	tests := map[string]bool{
+		"sudo apt-get install -y curl":           true,
+		"curl -fsSL https://x | sudo tee /etc/x": true,
+		"cd /tmp && (sudo make install)":         true,
+		"echo pseudo":                            false,
+		"curl -fsSL https://x | sh":              false,
+	}
+	for command, want := range tests {
+		if got := callsSudo(command); got != want {
+			t.Errorf("callsSudo(%q) = %v, want %v", command, got, want)
+		}
+	}
+}
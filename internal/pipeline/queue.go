@@ -0,0 +1,166 @@
+package pipeline
+
+import (
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+)
+
+// QueueKind returns the kind of queued item a step in group installs
+func QueueKind(group string) string {
+	switch group {
+	case GroupFonts:
+		return manifest.QueueFont
+	case GroupLanguages:
+		return manifest.QueueLanguage
+	case GroupDotfiles:
+		return manifest.QueueDotfiles
+	case GroupShell:
+		return manifest.QueueShell
+	default:
+		return manifest.QueueTool
+	}
+}
+
+// newQueue lists the items of the run's pipeline in the order they install, with
+// the package, version and method resolved for each
+func (i *Installer) newQueue(tools map[string]*Tool, languages []*interfaces.Language) *manifest.Queue {
+	ctx := i.Context
+	pm := ctx.Platform.PackageManager
+	q := &manifest.Queue{
+		PackageManager:   pm,
+		Locked:           ctx.Lock != nil,
+		LanguageStrategy: ctx.LanguageStrategy,
+		VersionManager:   ctx.VersionManager,
+		PromptStyle:      ctx.PromptStyle,
+	}
+	langs := make(map[string]*interfaces.Language, len(languages))
+	for _, lang := range languages {
+		langs[lang.Name] = lang
+	}
+
+	index := make(map[string]int)
+	for _, step := range i.Pipeline.Steps {
+		if step.Item == "" {
+			continue
+		}
+		kind := QueueKind(step.Group)
+		key := kind + "/" + step.Item
+		if n, ok := index[key]; ok {
+			// Only tools installed from their package or a release have an install step
+			if method := stepMethod(step.Name); method != "" {
+				q.Items[n].Method = string(method)
+			}
+			continue
+		}
+		item := manifest.QueueItem{Kind: kind, Name: step.Item}
+		switch kind {
+		case manifest.QueueTool:
+			if t, ok := tools[step.Item]; ok {
+				item.Manager = ctx.managerFor(t)
+				item.Package = t.PackageFor(item.Manager)
+				strategy := t.GetInstallStrategy(ctx.Platform)
+				if name, err := strategy.GetPackageName(item.Manager); err == nil && name != "" {
+					item.Package = name
+				}
+				item.Method = string(CustomInstall)
+				if method := stepMethod(step.Name); method != "" {
+					item.Method = string(method)
+				}
+				if ctx.Lock != nil {
+					item.Version, _ = ctx.Lock.ToolVersion(t.Name)
+				}
+			}
+		case manifest.QueueLanguage:
+			if lang, ok := langs[step.Item]; ok {
+				item.Method = lang.ResolveStrategy(ctx.LanguageStrategy)
+				item.Manager = pm
+				if item.Method == interfaces.LanguageStrategyVersionManager {
+					item.Manager = ctx.VersionManager
+					if manager := lang.RuntimeManager(); manager != "" {
+						item.Manager = manager
+					}
+				}
+				item.Package = lang.SystemPackages(pm)[0]
+				if ctx.Lock != nil {
+					item.Version, _ = ctx.Lock.LanguageVersion(lang.Name)
+				}
+			}
+		case manifest.QueueShell:
+			item.Manager = pm
+		}
+		index[key] = len(q.Items)
+		q.Items = append(q.Items, item)
+	}
+	return q
+}
+
+// stepMethod returns the install method of a tool's main install step, or ""
+// for its other steps
+func stepMethod(name string) InstallationMethod {
+	switch {
+	case strings.HasSuffix(name, "-install-package"):
+		return PackageManagerInstall
+	case strings.HasSuffix(name, "-install-release"):
+		return BinaryInstall
+	}
+	return ""
+}
+
+// queuePath returns where the install queue is kept, or "" when there is no
+// state directory
+func (i *Installer) queuePath() string {
+	if i.QueuePath != "" {
+		return i.QueuePath
+	}
+	path, err := manifest.DefaultQueuePath()
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// startQueue saves the run's install queue and keeps it up to date as items
+// complete, so an interrupted run can be resumed
+func (i *Installer) startQueue(tools map[string]*Tool, languages []*interfaces.Language) {
+	path := i.queuePath()
+	if path == "" || len(i.Pipeline.Steps) == 0 {
+		return
+	}
+	q := i.newQueue(tools, languages)
+	if err := q.Save(path); err != nil {
+		i.Logger.Warn("Failed to save install queue: %v", err)
+		return
+	}
+	i.Pipeline.OnItem = func(group, item string, done bool) {
+		if !q.SetDone(QueueKind(group), item, done) {
+			return
+		}
+		if err := q.Save(path); err != nil {
+			i.Logger.Warn("Failed to update install queue: %v", err)
+		}
+	}
+}
+
+// finishQueue removes the install queue after a successful run; after a failure
+// it is kept with the items that are left
+func (i *Installer) finishQueue(err error) {
+	path := i.queuePath()
+	if path == "" {
+		return
+	}
+	if err == nil {
+		if err := manifest.RemoveQueue(path); err != nil {
+			i.Logger.Warn("%v", err)
+		}
+		return
+	}
+	q, loadErr := manifest.LoadQueue(path)
+	if loadErr != nil || q == nil {
+		return
+	}
+	if rest := len(q.Remaining()); rest > 0 {
+		i.Logger.Info("%d item(s) left in the install queue; run bootstrap-cli up --resume to finish them", rest)
+	}
+}
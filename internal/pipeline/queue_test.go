@@ -0,0 +1,81 @@
+package pipeline
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+)
+
+func TestInstallerQueueTracksRemainingItems(t *testing.T) {
+	installer, err := NewInstaller(&Platform{OS: "linux", PackageManager: "apt", Shell: "bash"}, &fakePM{})
+	if err != nil {
+		t.Fatalf("NewInstaller() error = %v", err)
+	}
+	installer.QueuePath = filepath.Join(t.TempDir(), manifest.QueueFileName)
+	installer.Context.Lock = manifest.NewLock("apt")
+	installer.Context.Lock.Tools["git"] = "1:2.39.2-1"
+
+	ok := func(*InstallationContext) error { return nil }
+	p := NewInstallationPipeline(installer.Context)
+	p.AddStep(InstallationStep{Name: "git-install-package", Group: "Essential", Item: "git", Action: ok})
+	p.AddStep(InstallationStep{Name: "git-verify", Group: "Essential", Item: "git", Action: ok})
+	p.AddStep(InstallationStep{Name: "bat-custom-install-0", Group: "Modern", Item: "bat", RetryDelay: time.Millisecond, Action: func(*InstallationContext) error {
+		return errors.New("download failed")
+	}})
+	p.AddStep(InstallationStep{Name: "install-font", Group: GroupFonts, Item: "FiraCode", Action: ok})
+	installer.Pipeline = p
+
+	tools := map[string]*Tool{"git": {Tool: interfaces.Tool{Name: "git"}}, "bat": {Tool: interfaces.Tool{Name: "bat"}, PreferredManager: "brew"}}
+	installer.startQueue(tools, nil)
+	q, err := manifest.LoadQueue(installer.QueuePath)
+	if err != nil || q == nil || len(q.Items) != 3 {
+		t.Fatalf("Expected a queue with three items before the run, got %+v, %v", q, err)
+	}
+	git := q.Items[0]
+	if git.Kind != manifest.QueueTool || git.Package != "git" || git.Version != "1:2.39.2-1" || git.Manager != "apt" || git.Method != string(PackageManagerInstall) {
+		t.Errorf("Unexpected git entry %+v", git)
+	}
+	if bat := q.Items[1]; bat.Method != string(CustomInstall) {
+		t.Errorf("Expected bat to be queued as a custom install, got %+v", bat)
+	}
+	if font := q.Items[2]; font.Kind != manifest.QueueFont || font.Name != "FiraCode" {
+		t.Errorf("Unexpected font entry %+v", font)
+	}
+
+	runErr := p.Execute()
+	if runErr == nil {
+		t.Fatal("Expected the bat step to fail the run")
+	}
+	installer.finishQueue(runErr)
+	q, err = manifest.LoadQueue(installer.QueuePath)
+	if err != nil || q == nil {
+		t.Fatalf("Expected the queue to be kept after a failed run, got %v", err)
+	}
+	rest := q.Remaining()
+	if len(rest) != 2 || rest[0].Name != "bat" || rest[1].Name != "FiraCode" {
+		t.Errorf("Remaining() = %+v, want bat and FiraCode", rest)
+	}
+
+	installer.finishQueue(nil)
+	if q, _ := manifest.LoadQueue(installer.QueuePath); q != nil {
+		t.Error("Expected a successful run to remove the queue")
+	}
+}
+
+func TestQueueKind(t *testing.T) {
+	for group, want := range map[string]string{
+		GroupFonts:     manifest.QueueFont,
+		GroupLanguages: manifest.QueueLanguage,
+		GroupDotfiles:  manifest.QueueDotfiles,
+		GroupShell:     manifest.QueueShell,
+		"Modern":       manifest.QueueTool,
+	} {
+		if got := QueueKind(group); got != want {
+			t.Errorf("QueueKind(%q) = %q, want %q", group, got, want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/release"
+)
+
+// releases returns the installer for GitHub release tools, creating the default
+// one on first use
+func (c *InstallationContext) releases() (*release.Installer, error) {
+	c.releasesMu.Lock()
+	defer c.releasesMu.Unlock()
+	if c.Releases == nil {
+		installer, err := release.NewInstaller()
+		if err != nil {
+			return nil, err
+		}
+		c.Releases = installer
+	}
+	return c.Releases, nil
+}
+
+// releaseStep installs the tool's binary from its GitHub release into
+// ~/.local/bin, resolving "latest" through the GitHub API
+func (t *Tool) releaseStep() InstallationStep {
+	return InstallationStep{
+		Name:        fmt.Sprintf("%s-install-release", t.Name),
+		Description: fmt.Sprintf("Installing %s from the %s GitHub releases", t.Name, t.Release.Repo),
+		Action: func(ctx *InstallationContext) error {
+			installer, err := ctx.releases()
+			if err != nil {
+				return err
+			}
+			arch := ctx.Platform.Arch
+			if arch == "" {
+				arch = runtime.GOARCH
+			}
+			target, err := installer.BinaryPath(t.Name)
+			if err != nil {
+				return err
+			}
+			existed := exists(target)
+			tag, binary, err := installer.Install(t.Name, t.Release, t.Version, ctx.Platform.OS, arch)
+			if err != nil {
+				return err
+			}
+			ctx.recordFile(t.Name, binary, existed)
+			ctx.State.RecordBinary(t.Name, binary)
+			// Let the verify step and later tools find the binary in this run
+			prependPath(filepath.Dir(binary))
+			ctx.Logger.Info("Installed %s %s to %s", t.Name, tag, binary)
+			return nil
+		},
+		Timeout: 10 * time.Minute,
+	}
+}
+
+// prependPath puts dir first on this process's PATH unless it is already there
+func prependPath(dir string) {
+	path := os.Getenv("PATH")
+	sep := string(os.PathListSeparator)
+	if !strings.Contains(sep+path+sep, sep+dir+sep) {
+		os.Setenv("PATH", dir+sep+path)
+	}
+}
@@ -0,0 +1,41 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/release"
+)
+
+// missingPM has no packages available
+type missingPM struct{ fakePM }
+
+func (m *missingPM) IsPackageAvailable(string) bool { return false }
+
+func TestReleaseInstallMethod(t *testing.T) {
+	platform := &Platform{OS: "linux", Arch: "amd64", PackageManager: "apt", Shell: "bash"}
+	delta := NewTool("delta", CategoryDevelopment)
+	delta.Release = &release.Spec{Repo: "dandavison/delta", Asset: "delta-{version}-{arch}-{os}.tar.gz"}
+
+	hasStep := func(ctx *InstallationContext, suffix string) bool {
+		for _, step := range delta.GenerateInstallationSteps(platform, ctx, true) {
+			if strings.HasSuffix(step.Name, suffix) {
+				return true
+			}
+		}
+		return false
+	}
+	// The package is preferred when the package manager has it
+	if ctx := NewInstallationContext(platform, &fakePM{}, nil); !hasStep(ctx, "-install-package") || hasStep(ctx, "-install-release") {
+		t.Error("Expected delta to be installed from its package when one is available")
+	}
+	if ctx := NewInstallationContext(platform, &missingPM{}, nil); !hasStep(ctx, "-install-release") {
+		t.Error("Expected delta to be installed from its GitHub release without a package")
+	}
+	if !delta.HasInstallMethod(platform) {
+		t.Error("Expected a GitHub release to count as an install method")
+	}
+	if stepMethod("delta-install-release") != BinaryInstall {
+		t.Error("Expected the release step to be queued as a binary install")
+	}
+}
@@ -0,0 +1,135 @@
+package pipeline
+
+import (
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/install"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+)
+
+// RunReport collects the progress events of a pipeline run into the same
+// install.Report that "tools install --output json" writes
+type RunReport struct {
+	items   map[string]string              // task -> item it belongs to
+	entries map[string]*install.ToolReport // item -> its outcome
+	order   []string                       // items in the order they started
+}
+
+// NewRunReport creates an empty run report
+func NewRunReport() *RunReport {
+	return &RunReport{
+		items:   make(map[string]string),
+		entries: make(map[string]*install.ToolReport),
+	}
+}
+
+// Add records the step event starts or ends. An item fails if any of its steps
+// fail, and takes as long as its steps together.
+func (r *RunReport) Add(event ProgressEvent) {
+	switch event := event.(type) {
+	case TaskStart:
+		if event.Item == "" {
+			return
+		}
+		r.items[event.TaskID] = event.Item
+		if _, ok := r.entries[event.Item]; !ok {
+			r.entries[event.Item] = &install.ToolReport{Name: event.Item, Status: install.StatusSkipped}
+			r.order = append(r.order, event.Item)
+		}
+	case TaskEnd:
+		entry, ok := r.entries[r.items[event.TaskID]]
+		if !ok {
+			return
+		}
+		entry.DurationMS += event.Duration.Milliseconds()
+		switch {
+		case !event.Success:
+			if entry.Status != install.StatusError {
+				entry.Status, entry.Error = install.StatusError, errorString(event.Error)
+			}
+		case entry.Status == install.StatusSkipped:
+			entry.Status = install.StatusSuccess
+		}
+	}
+}
+
+// Run calls run, typically an InstallSelections, adding the events it sends on
+// events to the report, and returns its error once it has returned
+func (r *RunReport) Run(events <-chan ProgressEvent, run func() error) error {
+	done := make(chan error, 1)
+	go func() { done <- run() }()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return <-done
+			}
+			r.Add(event)
+		case err := <-done:
+			// Nothing is sent once run has returned; take what is left buffered
+			for {
+				select {
+				case event, ok := <-events:
+					if !ok {
+						return err
+					}
+					r.Add(event)
+				default:
+					return err
+				}
+			}
+		}
+	}
+}
+
+// Report returns the report of installer's last run, which failed with err
+// unless it is nil: one entry per item in install order, with the items whose
+// steps never started reported as skipped
+func (r *RunReport) Report(installer *Installer, err error) *install.Report {
+	order := r.order
+	if installer.Pipeline != nil {
+		seen := make(map[string]bool)
+		order = nil
+		for _, step := range installer.Pipeline.Steps {
+			if step.Item != "" && !seen[step.Item] {
+				seen[step.Item] = true
+				order = append(order, step.Item)
+			}
+		}
+		for _, item := range r.order {
+			if !seen[item] {
+				order = append(order, item)
+			}
+		}
+	}
+
+	report := &install.Report{Tools: make([]*install.ToolReport, 0, len(order))}
+	for _, item := range order {
+		entry, ok := r.entries[item]
+		if !ok {
+			entry = &install.ToolReport{Name: item, Status: install.StatusSkipped}
+		}
+		entry.Package, entry.PackageManager = installer.reportedPackage(item)
+		entry.RequiredBy = installer.RequiredBy[item]
+		report.Tools = append(report.Tools, entry)
+	}
+	if err != nil {
+		report.Error = err.Error()
+	}
+	return report
+}
+
+// reportedPackage returns the package item was installed from and the manager
+// that installed it, like the installed snapshot records them
+func (i *Installer) reportedPackage(item string) (pkg, manager string) {
+	ctx := i.Context
+	if tool, ok := ctx.tools[item]; ok {
+		if ctx.State.Binary(item) != "" {
+			return "", manifest.ManagerGitHubRelease
+		}
+		manager := i.provenance(tool)
+		return tool.PackageFor(manager), manager
+	}
+	if lang, ok := ctx.State.Installer(item); ok {
+		return lang.Package, lang.Installer
+	}
+	return "", ""
+}
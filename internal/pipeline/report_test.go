@@ -0,0 +1,58 @@
+package pipeline
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/install"
+)
+
+func TestRunReport(t *testing.T) {
+	installer, err := NewInstaller(&Platform{OS: "linux", PackageManager: "apt", Shell: "bash"}, &fakePM{})
+	if err != nil {
+		t.Fatalf("NewInstaller() error = %v", err)
+	}
+	bat := NewTool("bat", CategoryDevelopment)
+	bat.Install.PackageNames = map[string]string{"apt": "bat-cat"}
+	installer.Context.tools["bat"] = bat
+	installer.Context.tools["fd"] = NewTool("fd", CategoryDevelopment)
+	installer.RequiredBy = map[string]string{"fd": "fzf"}
+
+	p := NewInstallationPipeline(installer.Context)
+	p.AddStep(InstallationStep{Name: "bat-install-package", Item: "bat", Action: func(*InstallationContext) error { return nil }})
+	p.AddStep(InstallationStep{Name: "bat-verify", Item: "bat", Action: func(*InstallationContext) error { return nil }})
+	p.AddStep(InstallationStep{Name: "fd-install-package", Item: "fd", RetryDelay: time.Millisecond, Action: func(*InstallationContext) error {
+		return errors.New("E: Unable to locate package fd")
+	}})
+	p.AddStep(InstallationStep{Name: "fzf-install-package", Item: "fzf", Action: func(*InstallationContext) error { return nil }})
+	installer.Pipeline = p
+
+	events := NewRunReport()
+	err = events.Run(installer.ProgressChan, p.Execute)
+	if err == nil {
+		t.Fatal("Expected the fd step to fail the run")
+	}
+	report := events.Report(installer, err)
+
+	want := []struct{ name, pkg, status string }{
+		{"bat", "bat-cat", install.StatusSuccess},
+		{"fd", "fd", install.StatusError},
+		{"fzf", "", install.StatusSkipped},
+	}
+	if len(report.Tools) != len(want) {
+		t.Fatalf("Expected %d entries, got %+v", len(want), report.Tools)
+	}
+	for n, w := range want {
+		got := report.Tools[n]
+		if got.Name != w.name || got.Package != w.pkg || got.Status != w.status {
+			t.Errorf("entry %d = %+v, want %s %q %s", n, got, w.name, w.pkg, w.status)
+		}
+	}
+	if fd := report.Tools[1]; fd.PackageManager != "apt" || fd.RequiredBy != "fzf" || fd.Error == "" {
+		t.Errorf("Expected fd's manager, requirement and error, got %+v", fd)
+	}
+	if failed := report.Failed(); len(failed) != 1 || failed[0] != "fd" || report.Error == "" {
+		t.Errorf("Expected the report to fail on fd, got %v, %q", failed, report.Error)
+	}
+}
@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cmdexec"
+)
+
+func TestInstallerFailureLogAndNewRun(t *testing.T) {
+	installer, err := NewInstaller(&Platform{OS: "linux", PackageManager: "apt", Shell: "bash"}, &fakePM{})
+	if err != nil {
+		t.Fatalf("NewInstaller() error = %v", err)
+	}
+	installer.Context.LanguageStrategy = "system"
+	installer.Context.ToolManagers = map[string]string{"bat": "brew"}
+	installer.Context.NoSudo = true
+	installer.Context.Shells = []string{"zsh", "bash"}
+	installer.LockPath = "/tmp/bootstrap.lock"
+
+	p := NewInstallationPipeline(installer.Context)
+	p.AddStep(InstallationStep{Name: "install-git", Group: "Essential", Item: "git", Action: func(*InstallationContext) error { return nil }})
+	p.AddStep(InstallationStep{Name: "install-bat", Group: "Modern", Item: "bat", RetryDelay: time.Millisecond, Action: func(*InstallationContext) error {
+		return errors.New("apt-get failed (Output: E: Unable to locate package bat)")
+	}})
+	p.AddStep(InstallationStep{Name: "install-fzf", Group: "Modern", Item: "fzf", Action: func(*InstallationContext) error { return nil }})
+	installer.Pipeline = p
+	if err := p.Execute(); err == nil {
+		t.Fatal("Expected the bat step to fail the run")
+	}
+
+	if done := installer.Succeeded(); !done["git"] || done["bat"] || done["fzf"] {
+		t.Errorf("Expected only git to have succeeded, got %v", done)
+	}
+	if log := installer.FailureLog(); !strings.HasPrefix(log, "bat (install-bat): ") || !strings.Contains(log, "Unable to locate package bat") || strings.Contains(log, "git") {
+		t.Errorf("Unexpected failure log %q", log)
+	}
+
+	next, err := installer.NewRun()
+	if err != nil {
+		t.Fatalf("NewRun() error = %v", err)
+	}
+	if next.Context == installer.Context || next.Context.LanguageStrategy != "system" || next.Context.ToolManagers["bat"] != "brew" || len(next.Context.Shells) != 2 || next.LockPath != installer.LockPath {
+		t.Errorf("Expected a fresh context with the same settings, got %+v", next.Context)
+	}
+	// A retry of a --no-sudo run must not start using sudo
+	if !next.Context.NoSudo {
+		t.Error("Expected the new run to keep NoSudo")
+	}
+	if len(next.Context.State.FailedSteps) != 0 || next.FailureLog() != "" {
+		t.Error("Expected the new run to start without failures")
+	}
+}
+
+func TestStepRetryPolicy(t *testing.T) {
+	ctx := NewInstallationContext(&Platform{OS: "linux", PackageManager: "apt", Shell: "bash"}, &fakePM{}, nil)
+	ctx.Retry = cmdexec.RetryPolicy{Attempts: 3, InitialDelay: time.Millisecond, Multiplier: 2}
+
+	p := NewInstallationPipeline(ctx)
+	locked := 0
+	p.AddStep(InstallationStep{Name: "install-bat", Action: func(*InstallationContext) error {
+		locked++
+		if locked < 3 {
+			return errors.New("package installation failed: exit status 100 (Output: E: Could not get lock /var/lib/dpkg/lock-frontend)")
+		}
+		return nil
+	}})
+	missing := 0
+	p.AddStep(InstallationStep{Name: "install-nope", Action: func(*InstallationContext) error {
+		missing++
+		return errors.New("package installation failed: exit status 100 (Output: E: Unable to locate package nope)")
+	}})
+
+	err := p.Execute()
+	if locked != 3 {
+		t.Errorf("Expected the held lock to be retried until it cleared, got %d attempts", locked)
+	}
+	if err == nil || missing != 1 {
+		t.Errorf("Expected a missing package to fail without retrying, got %d attempts (err %v)", missing, err)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"time"
 
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
-	// TODO: Import internal/shell if needed for config writer logic
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/packages/implementations"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
 )
 
 // GenerateShellConfigSteps creates pipeline steps for configuring the selected shell.
@@ -16,25 +18,43 @@ func GenerateShellConfigSteps(shell *interfaces.Shell, context *InstallationCont
 		return steps
 	}
 
-	// Example: Add step to set shell as default (if defined in config)
-	if shell.SetDefaultCommand != "" {
-		// Need to run this command
-		// TODO: Integrate with command execution logic
-		setDefaultCmdStr := shell.SetDefaultCommand
+	// A shell that is not on PATH yet is installed first
+	steps = append(steps, InstallationStep{
+		Name:        fmt.Sprintf("install-shell-%s", shell.Name),
+		Description: fmt.Sprintf("Installing %s if missing", shell.Name),
+		Action: func(ctx *InstallationContext) error {
+			return installShell(ctx, shell)
+		},
+		Timeout: 10 * time.Minute,
+	})
+
+	// Changing the login shell needs the user's consent, collected before the run
+	if change := context.LoginShellChange; change != nil {
 		steps = append(steps, InstallationStep{
 			Name:        fmt.Sprintf("set-default-shell-%s", shell.Name),
 			Description: fmt.Sprintf("Setting %s as default login shell", shell.Name),
 			Action: func(ctx *InstallationContext) error {
-				// TODO: Use ctx.Logger / ctx.sendProgress
-				fmt.Printf("TODO: Execute command: %s\n", setDefaultCmdStr)
-				// cmd := exec.Command("sh", "-c", setDefaultCmdStr)
-				// return cmd.Run()
-				return nil // Placeholder
+				return changeLoginShell(ctx, change)
 			},
 			Timeout: 1 * time.Minute,
 		})
 	}
 
+	if style := context.PromptStyle; style != "" {
+		shellNames, force := context.Shells, context.ForcePromptConfig
+		if len(shellNames) == 0 {
+			shellNames = []string{shell.Name}
+		}
+		steps = append(steps, InstallationStep{
+			Name:        fmt.Sprintf("ensure-prompt-config-%s", style),
+			Description: fmt.Sprintf("Writing default %s prompt config", style),
+			Action: func(ctx *InstallationContext) error {
+				return ensurePromptConfig(ctx, style, shellNames, force)
+			},
+			Timeout: 30 * time.Second,
+		})
+	}
+
 	// TODO: Add steps to configure the shell environment based on other selections.
 	// This would involve:
 	// 1. Gathering all required aliases, env vars, PATH additions, source commands
@@ -58,4 +78,108 @@ func GenerateShellConfigSteps(shell *interfaces.Shell, context *InstallationCont
 	// })
 
 	return steps
-} 
\ No newline at end of file
+}
+
+// shellInstallCommand returns the command that installs sh with manager: the
+// shell's install_commands entry, or the manager's install of its package
+func (c *InstallationContext) shellInstallCommand(sh *interfaces.Shell, manager string) (string, error) {
+	if command := sh.InstallCommandFor(manager); command != "" {
+		return command, nil
+	}
+	return c.installCommand(manager, shellPackage(sh, manager))
+}
+
+// shellPackage returns the package sh is installed from with manager, which is
+// named after the shell unless its definition says otherwise
+func shellPackage(sh *interfaces.Shell, manager string) string {
+	if manager == "winget" && sh.WingetPackage != "" {
+		return sh.WingetPackage
+	}
+	if sh.Package != "" {
+		return sh.Package
+	}
+	return sh.Name
+}
+
+// installShell installs sh with the platform's package manager unless it is
+// already on PATH
+func installShell(ctx *InstallationContext, sh *interfaces.Shell) error {
+	if path, err := lookPath(sh.Name); err == nil {
+		ctx.Logger.Info("%s is already installed at %s", sh.Name, path)
+		return nil
+	}
+	if ctx.NoSudo && privilegedManagers[ctx.Platform.PackageManager] {
+		return sudoRefusal(sh.Name)
+	}
+	cmdStr, err := ctx.shellInstallCommand(sh, ctx.Platform.PackageManager)
+	if err != nil {
+		return fmt.Errorf("cannot install %s: %w", sh.Name, err)
+	}
+	pkg := shellPackage(sh, ctx.Platform.PackageManager)
+	fresh := !ctx.preinstalled(ctx.Platform.PackageManager, pkg)
+	ctx.Logger.CommandStart(cmdStr, 1, 1)
+	start := time.Now()
+	output, err := ctx.runCommand(sh.Name, ctx.shellCommand(sh.Name, cmdStr))
+	if implementations.WingetAlreadyInstalled(err) {
+		ctx.Logger.Info("%s is already installed, skipping", sh.Name)
+		return nil
+	}
+	if err != nil {
+		ctx.Logger.CommandError(cmdStr, err, 1, 1)
+		return fmt.Errorf("failed to install %s: %w (Output: %s)", sh.Name, err, string(output))
+	}
+	ctx.Logger.CommandSuccess(cmdStr, time.Since(start))
+	if fresh {
+		ctx.recordPackage(sh.Name, ctx.Platform.PackageManager, pkg)
+	}
+	return nil
+}
+
+// changeLoginShell switches the login shell, recording the previous one first.
+// It runs after the install-shell step, so a shell this run installed is found.
+func changeLoginShell(ctx *InstallationContext, change *shell.LoginShellChange) error {
+	if err := change.Resolve(); err != nil {
+		return err
+	}
+	ctx.Logger.Info("Changing login shell: %s", change.Command)
+	return shell.ApplyLoginShellChange(change)
+}
+
+// ensurePromptConfig writes the default config for the prompt style unless the
+// user already has one, and loads it in each of shellNames that supports it
+func ensurePromptConfig(ctx *InstallationContext, style string, shellNames []string, force bool) error {
+	home, err := system.UserHome()
+	if err != nil {
+		return err
+	}
+	path := shell.PromptConfigPath(home, style)
+	existed := exists(path)
+	rc := ctx.stageRC()
+	written := false
+	for _, shellName := range shellNames {
+		if shell.ValidatePromptStyle(style, shellName) != nil {
+			ctx.Logger.Info("Not loading the %s prompt in %s, which it does not support", style, shellName)
+			continue
+		}
+		// The config is shared, so it is only replaced once
+		var wrote bool
+		wrote, err = shell.EnsurePromptConfig(home, style, shellName, force && !written, rc)
+		written = written || wrote
+		if err != nil {
+			break
+		}
+	}
+	if written && !rc.DryRun {
+		ctx.recordFile(style, path, existed)
+	}
+	ctx.recordRCBlocks(style, rc)
+	if err != nil {
+		return fmt.Errorf("failed to configure %s prompt: %w", style, err)
+	}
+	if written {
+		ctx.Logger.Info("Wrote default %s config to %s", style, path)
+	} else {
+		ctx.Logger.Info("Keeping existing %s config at %s (use --force to replace it)", style, path)
+	}
+	return nil
+}
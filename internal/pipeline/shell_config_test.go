@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+func TestShellInstallCommand(t *testing.T) {
+	sh := &interfaces.Shell{Name: "fish"}
+	sh.InstallCommands.Brew = "brew install fish --HEAD"
+
+	tests := []struct {
+		manager string
+		want    string
+		wantErr bool
+	}{
+		{manager: "brew", want: "brew install fish --HEAD"},
+		{manager: "pkg", want: "pkg install -y fish"},
+		{manager: "nix", wantErr: true},
+	}
+	ctx := NewInstallationContext(&Platform{OS: "linux", PackageManager: "apt"}, nil, nil)
+	for _, tt := range tests {
+		got, err := ctx.shellInstallCommand(sh, tt.manager)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("shellInstallCommand(%s) error = %v, wantErr %v", tt.manager, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("shellInstallCommand(%s) = %q, want %q", tt.manager, got, tt.want)
+		}
+	}
+}
+
+func TestShellInstallCommandPackage(t *testing.T) {
+	sh := &interfaces.Shell{Name: "nu", Package: "nushell"}
+	ctx := NewInstallationContext(&Platform{OS: "darwin", PackageManager: "brew"}, nil, nil)
+	if got, err := ctx.shellInstallCommand(sh, "brew"); err != nil || got != "brew install nushell" {
+		t.Errorf("shellInstallCommand(brew) = %q, %v; want the nushell package", got, err)
+	}
+}
+
+func TestEnsurePromptConfigShells(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	ctx := NewInstallationContext(&Platform{OS: "linux", PackageManager: "apt", Shell: "zsh"}, &fakePM{}, nil)
+	if err := ensurePromptConfig(ctx, "p10k", []string{"zsh", "bash"}, false); err != nil {
+		t.Fatalf("ensurePromptConfig() error = %v", err)
+	}
+	if err := ctx.flushRC(); err != nil {
+		t.Fatalf("flushRC() error = %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(home, ".zshrc")); err != nil || !strings.Contains(string(data), ".p10k.zsh") {
+		t.Errorf("Expected .zshrc to load the p10k config, got %q (%v)", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".bashrc")); !os.IsNotExist(err) {
+		t.Errorf("Expected no .bashrc for a zsh-only prompt, stat error = %v", err)
+	}
+}
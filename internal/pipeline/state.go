@@ -15,6 +15,20 @@ type InstallationState struct {
 	RollbackSteps []string
 	Status        string
 	Error         error
+	// StepErrors holds why each failed step failed, including command output
+	StepErrors map[string]error
+	// Versions holds the version each item reported once verified
+	Versions map[string]string
+	// Outputs holds the command output each item's running step captured
+	Outputs map[string][]byte
+	// LogPaths holds the failure log written for each item that failed
+	LogPaths map[string]string
+	// Binaries holds the file each item installed from a GitHub release was
+	// written to
+	Binaries map[string]string
+	// Installers holds the installer each language was installed with, e.g.
+	// apt or asdf, and the package or plugin it installed
+	Installers map[string]LanguageInstall
 	StartTime     time.Time
 	LastUpdated   time.Time
 }
@@ -43,11 +57,112 @@ func (s *InstallationState) UpdateState(step string, status string, err error) {
 		s.CompletedSteps = append(s.CompletedSteps, step)
 	case "failed":
 		s.FailedSteps = append(s.FailedSteps, step)
+		if s.StepErrors == nil {
+			s.StepErrors = make(map[string]error)
+		}
+		s.StepErrors[step] = err
 	case "rollback":
 		s.RollbackSteps = append(s.RollbackSteps, step)
 	}
 }
 
+// RecordVersion records the version item reported after it was verified
+func (s *InstallationState) RecordVersion(item, version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Versions == nil {
+		s.Versions = make(map[string]string)
+	}
+	s.Versions[item] = version
+}
+
+// Version returns the version recorded for item, if any
+func (s *InstallationState) Version(item string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Versions[item]
+}
+
+// RecordBinary records that item was installed from a release to path
+func (s *InstallationState) RecordBinary(item, path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Binaries == nil {
+		s.Binaries = make(map[string]string)
+	}
+	s.Binaries[item] = path
+}
+
+// Binary returns the release binary recorded for item, if any
+func (s *InstallationState) Binary(item string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Binaries[item]
+}
+
+// LanguageInstall is how a language was installed
+type LanguageInstall struct {
+	// Installer is the package manager or version manager, e.g. apt or mise
+	Installer string
+	// Package is the package, or the plugin or tool name in a version manager
+	Package string
+}
+
+// RecordInstaller records that item was installed with installer from pkg
+func (s *InstallationState) RecordInstaller(item, installer, pkg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Installers == nil {
+		s.Installers = make(map[string]LanguageInstall)
+	}
+	s.Installers[item] = LanguageInstall{Installer: installer, Package: pkg}
+}
+
+// Installer returns how item was installed, if it was recorded
+func (s *InstallationState) Installer(item string) (LanguageInstall, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	install, ok := s.Installers[item]
+	return install, ok
+}
+
+// AppendOutput adds command output captured while a step of item ran
+func (s *InstallationState) AppendOutput(item string, output []byte) {
+	if len(output) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Outputs == nil {
+		s.Outputs = make(map[string][]byte)
+	}
+	s.Outputs[item] = append(s.Outputs[item], output...)
+}
+
+// ResetOutput drops the output captured for item, as its next step starts
+func (s *InstallationState) ResetOutput(item string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.Outputs, item)
+}
+
+// Output returns the output captured for item since its step started
+func (s *InstallationState) Output(item string) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Outputs[item]
+}
+
+// RecordLogPath records the failure log written for item
+func (s *InstallationState) RecordLogPath(item, path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.LogPaths == nil {
+		s.LogPaths = make(map[string]string)
+	}
+	s.LogPaths[item] = path
+}
+
 // GetProgress returns the current progress as a percentage
 func (s *InstallationState) GetProgress() float64 {
 	s.mu.Lock()
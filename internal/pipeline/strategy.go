@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
 )
 
 // VersionConstraint defines version requirements for a package
@@ -40,13 +42,11 @@ func (vc *VersionConstraint) Validate(version string) error {
 		return nil
 	}
 
-	// TODO: Implement proper version comparison
-	// For now, just do string comparison
-	if vc.MinVersion != "" && version < vc.MinVersion {
+	if vc.MinVersion != "" && CompareVersions(version, vc.MinVersion) < 0 {
 		return fmt.Errorf("version %s is below minimum required version %s", 
 			version, vc.MinVersion)
 	}
-	if vc.MaxVersion != "" && version > vc.MaxVersion {
+	if vc.MaxVersion != "" && CompareVersions(version, vc.MaxVersion) > 0 {
 		return fmt.Errorf("version %s is above maximum allowed version %s", 
 			version, vc.MaxVersion)
 	}
@@ -183,10 +183,7 @@ func (s *InstallStrategy) Validate() error {
 
 // GetPackageName returns the package name for the given package manager
 func (s *InstallStrategy) GetPackageName(pkgManager string) (string, error) {
-	if name, ok := s.PackageNames[pkgManager]; ok {
-		return name, nil
-	}
-	if name, ok := s.PackageNames["default"]; ok {
+	if name, ok := interfaces.ResolvePackageName(s.PackageNames, pkgManager); ok {
 		return name, nil
 	}
 	return "", fmt.Errorf("no package name found for package manager %s", pkgManager)
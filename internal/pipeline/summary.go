@@ -0,0 +1,217 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Groups used for non-tool steps in the install summary
+const (
+	GroupFonts     = "Fonts"
+	GroupLanguages = "Languages"
+	GroupDotfiles  = "Dotfiles"
+	GroupShell     = "Shell"
+	groupOther     = "Other"
+)
+
+// GroupLabel turns a tool category (usually the catalog subdirectory, e.g. "modern"
+// or "cli-utilities") into a summary header
+func GroupLabel(category ToolCategory) string {
+	if category == "" {
+		return groupOther
+	}
+	parts := strings.FieldsFunc(string(category), func(r rune) bool {
+		return r == '-' || r == '_' || r == '/'
+	})
+	for i, part := range parts {
+		if strings.EqualFold(part, "cli") {
+			parts[i] = "CLI"
+			continue
+		}
+		parts[i] = strings.ToUpper(part[:1]) + part[1:]
+	}
+	return strings.Join(parts, " ")
+}
+
+// GroupSummary holds the outcome of every item installed under one group
+type GroupSummary struct {
+	Group     string   `json:"group"`
+	Succeeded []string `json:"succeeded,omitempty"`
+	Failed    []string `json:"failed,omitempty"`
+	// Versions holds the version each succeeded item reported, if known
+	Versions map[string]string `json:"versions,omitempty"`
+	// TimedOut lists the failed items that failed by running out of time
+	TimedOut []string `json:"timed_out,omitempty"`
+	// Logs holds the failure log with the output of each failed item, if written
+	Logs map[string]string `json:"logs,omitempty"`
+}
+
+// Label renders an item with its version, e.g. "bat 0.24.0"
+func (g *GroupSummary) Label(item string) string {
+	if version := g.Versions[item]; version != "" {
+		return item + " " + version
+	}
+	return item
+}
+
+// IsTimedOut reports whether item failed by running out of time
+func (g *GroupSummary) IsTimedOut(item string) bool {
+	for _, name := range g.TimedOut {
+		if name == item {
+			return true
+		}
+	}
+	return false
+}
+
+// String renders the group as e.g. "Modern: 8 ok, 2 failed (fd, bat timed out)"
+func (g *GroupSummary) String() string {
+	s := fmt.Sprintf("%s: %d ok", g.Group, len(g.Succeeded))
+	if len(g.Failed) > 0 {
+		failed := make([]string, len(g.Failed))
+		for i, item := range g.Failed {
+			failed[i] = item
+			if g.IsTimedOut(item) {
+				failed[i] += " timed out"
+			}
+		}
+		s += fmt.Sprintf(", %d failed (%s)", len(g.Failed), strings.Join(failed, ", "))
+	}
+	return s
+}
+
+// Summary aggregates step outcomes into per-item results grouped by category.
+// An item (a tool, font, language...) fails if any of its steps fail.
+type Summary struct {
+	groups   []string
+	order    map[string][]string        // group -> items in first-seen order
+	items    map[string]map[string]bool // group -> item -> success
+	versions map[string]string          // item -> version
+	timedOut map[string]bool            // item -> a step timed out
+	logs     map[string]string          // item -> failure log
+}
+
+// NewSummary creates an empty summary
+func NewSummary() *Summary {
+	return &Summary{
+		order:    make(map[string][]string),
+		items:    make(map[string]map[string]bool),
+		versions: make(map[string]string),
+		timedOut: make(map[string]bool),
+		logs:     make(map[string]string),
+	}
+}
+
+// SetVersion records the version item reported once installed
+func (s *Summary) SetVersion(item, version string) {
+	if item != "" && version != "" {
+		s.versions[item] = version
+	}
+}
+
+// SetLogPath records the failure log written for item
+func (s *Summary) SetLogPath(item, path string) {
+	if item != "" && path != "" {
+		s.logs[item] = path
+	}
+}
+
+// Record adds the outcome of one step belonging to item in group
+func (s *Summary) Record(group, item string, success bool) {
+	if item == "" {
+		return
+	}
+	if group == "" {
+		group = groupOther
+	}
+	items, ok := s.items[group]
+	if !ok {
+		items = make(map[string]bool)
+		s.items[group] = items
+		s.groups = append(s.groups, group)
+	}
+	if prev, seen := items[item]; seen {
+		items[item] = prev && success
+		return
+	}
+	items[item] = success
+	s.order[group] = append(s.order[group], item)
+}
+
+// RecordError adds the outcome of one step belonging to item in group, which
+// failed with err unless it is nil; a TimeoutError marks the item timed out
+func (s *Summary) RecordError(group, item string, err error) {
+	s.Record(group, item, err == nil)
+	if item != "" && IsTimeout(err) {
+		s.timedOut[item] = true
+	}
+}
+
+// Groups returns the group summaries in the order groups were first seen
+func (s *Summary) Groups() []*GroupSummary {
+	groups := make([]*GroupSummary, 0, len(s.groups))
+	for _, group := range s.groups {
+		g := &GroupSummary{Group: group}
+		for _, item := range s.order[group] {
+			if s.items[group][item] {
+				g.Succeeded = append(g.Succeeded, item)
+				if version := s.versions[item]; version != "" {
+					if g.Versions == nil {
+						g.Versions = make(map[string]string)
+					}
+					g.Versions[item] = version
+				}
+			} else {
+				g.Failed = append(g.Failed, item)
+				if s.timedOut[item] {
+					g.TimedOut = append(g.TimedOut, item)
+				}
+				if path := s.logs[item]; path != "" {
+					if g.Logs == nil {
+						g.Logs = make(map[string]string)
+					}
+					g.Logs[item] = path
+				}
+			}
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+// Summary groups the outcome of every executed step of the pipeline.
+// Steps that never ran (after an earlier failure) are not counted.
+func (p *InstallationPipeline) Summary() *Summary {
+	summary := NewSummary()
+	state := p.Context.State
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	done := make(map[string]bool)
+	for _, name := range state.CompletedSteps {
+		done[name] = true
+	}
+	failed := make(map[string]bool)
+	for _, name := range state.FailedSteps {
+		failed[name] = true
+	}
+	for _, step := range p.Steps {
+		switch {
+		case failed[step.Name]:
+			err := state.StepErrors[step.Name]
+			if err == nil {
+				err = fmt.Errorf("step %s failed", step.Name)
+			}
+			summary.RecordError(step.Group, step.Item, err)
+		case done[step.Name]:
+			summary.Record(step.Group, step.Item, true)
+		}
+	}
+	for item, version := range state.Versions {
+		summary.SetVersion(item, version)
+	}
+	for item, path := range state.LogPaths {
+		summary.SetLogPath(item, path)
+	}
+	return summary
+}
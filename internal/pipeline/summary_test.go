@@ -0,0 +1,72 @@
+package pipeline
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGroupLabel(t *testing.T) {
+	tests := map[ToolCategory]string{
+		"modern":        "Modern",
+		"cli-utilities": "CLI Utilities",
+		"":              "Other",
+	}
+	for category, want := range tests {
+		if got := GroupLabel(category); got != want {
+			t.Errorf("GroupLabel(%q) = %q, want %q", category, got, want)
+		}
+	}
+}
+
+func TestSummaryGroupsItems(t *testing.T) {
+	s := NewSummary()
+	s.Record("Modern", "bat", true)
+	s.Record("Modern", "bat", true)
+	s.Record("Essential", "git", true)
+	s.Record("Modern", "fd", true)
+	s.Record("Modern", "fd", false) // a later failed step fails the whole tool
+	s.Record("Modern", "", false)   // steps without an item are ignored
+
+	groups := s.Groups()
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 groups, got %d", len(groups))
+	}
+	if got := groups[0].String(); got != "Modern: 1 ok, 1 failed (fd)" {
+		t.Errorf("Unexpected Modern summary: %s", got)
+	}
+	if got := groups[1].String(); got != "Essential: 1 ok" {
+		t.Errorf("Unexpected Essential summary: %s", got)
+	}
+}
+
+func TestSummaryVersions(t *testing.T) {
+	s := NewSummary()
+	s.Record("Modern", "bat", true)
+	s.Record("Modern", "fd", false)
+	s.SetVersion("bat", "0.24.0")
+	s.SetVersion("fd", "8.7.0")
+
+	g := s.Groups()[0]
+	if got := g.Label("bat"); got != "bat 0.24.0" {
+		t.Errorf("Label(bat) = %q, want bat 0.24.0", got)
+	}
+	if _, ok := g.Versions["fd"]; ok {
+		t.Errorf("Expected no version for the failed fd, got %v", g.Versions)
+	}
+}
+
+func TestSummaryTimedOut(t *testing.T) {
+	s := NewSummary()
+	s.RecordError("Modern", "bat", &TimeoutError{Step: "bat-install", Timeout: time.Minute})
+	s.RecordError("Modern", "fd", errors.New("exit status 100"))
+	s.RecordError("Modern", "rg", nil)
+
+	g := s.Groups()[0]
+	if got := g.String(); got != "Modern: 1 ok, 2 failed (bat timed out, fd)" {
+		t.Errorf("String() = %q", got)
+	}
+	if !g.IsTimedOut("bat") || g.IsTimedOut("fd") {
+		t.Errorf("TimedOut = %v, want [bat]", g.TimedOut)
+	}
+}
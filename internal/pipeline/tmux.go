@@ -0,0 +1,224 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cache"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+)
+
+// TmuxPluginManager is the repository TPM is cloned from
+const TmuxPluginManager = "https://github.com/tmux-plugins/tpm"
+
+// TmuxBlock is the managed block written into the tmux config
+const TmuxBlock = "tmux"
+
+// tmuxConfig is the managed block's body: a few defaults most people set, and
+// the plugins TPM installs. TPM's run line has to come after the plugins.
+const tmuxConfig = `set -g mouse on
+set -g history-limit 50000
+set -g base-index 1
+setw -g pane-base-index 1
+set -g renumber-windows on
+set -sg escape-time 10
+set -g default-terminal "tmux-256color"
+
+# Plugins, installed by TPM (prefix + I installs ones added later)
+set -g @plugin 'tmux-plugins/tpm'
+set -g @plugin 'tmux-plugins/tmux-sensible'
+run '~/.tmux/plugins/tpm/tpm'`
+
+// tmuxSteps installs the tmux package, then clones TPM, writes the managed
+// block into the tmux config and installs its plugins
+func tmuxSteps(t *Tool, ctx *InstallationContext) ([]InstallationStep, error) {
+	return []InstallationStep{
+		tmuxPackageStep(t, ctx.Platform.PackageManager),
+		tmuxPluginManagerStep(t),
+		tmuxConfigStep(t),
+		tmuxPluginsStep(t),
+	}, nil
+}
+
+// tmuxPackageStep installs the tmux package unless tmux is already installed
+func tmuxPackageStep(t *Tool, manager string) InstallationStep {
+	pkg := t.PackageFor(manager)
+	return InstallationStep{
+		Name:        fmt.Sprintf("%s-install-package", t.Name),
+		Description: fmt.Sprintf("Installing %s via %s", pkg, manager),
+		Action: func(ctx *InstallationContext) error {
+			if existing, ok := ctx.KeepExisting[t.Name]; ok {
+				ctx.Logger.Info("Keeping %s installed via %s", t.Name, existing)
+				return nil
+			}
+			if current, ok := t.InstalledVersion(); ok {
+				ctx.Logger.Info("tmux %s is already installed, skipping", current)
+				return nil
+			}
+			cmdStr, err := ctx.installCommand(manager, pkg)
+			if err != nil {
+				return err
+			}
+			fresh := !ctx.preinstalled(manager, pkg)
+			if output, err := ctx.runShell(t.Name, cmdStr); err != nil {
+				return fmt.Errorf("package installation failed: %w (Output: %s)", err, string(output))
+			}
+			if fresh {
+				ctx.recordPackage(t.Name, manager, pkg)
+			}
+			return nil
+		},
+		Timeout: 10 * time.Minute,
+	}
+}
+
+// tmuxPluginDir returns where TPM and the plugins it installs live
+func tmuxPluginDir(home string) string {
+	return filepath.Join(home, ".tmux", "plugins")
+}
+
+// TmuxConfPath returns the tmux config the managed block goes into:
+// ~/.tmux.conf, or ~/.config/tmux/tmux.conf when only that one exists
+func TmuxConfPath(home string) string {
+	conf := filepath.Join(home, ".tmux.conf")
+	xdg := filepath.Join(home, ".config", "tmux", "tmux.conf")
+	if !exists(conf) && exists(xdg) {
+		return xdg
+	}
+	return conf
+}
+
+// tmuxPluginManagerStep clones TPM unless it is already there
+func tmuxPluginManagerStep(t *Tool) InstallationStep {
+	var cloned string
+	return InstallationStep{
+		Name:        fmt.Sprintf("%s-install-tpm", t.Name),
+		Description: "Installing the tmux plugin manager (TPM)",
+		Action: func(ctx *InstallationContext) error {
+			home, err := system.UserHome()
+			if err != nil {
+				return err
+			}
+			dir := filepath.Join(tmuxPluginDir(home), "tpm")
+			if exists(dir) {
+				ctx.Logger.Info("TPM is already installed in %s", dir)
+				return nil
+			}
+			if !lookPathOK("git") {
+				ctx.Logger.Warn("git is not installed, so TPM was not cloned into %s", dir)
+				return nil
+			}
+			if err := cache.CheckCommand(TmuxPluginManager); err != nil {
+				return fmt.Errorf("failed to clone TPM: %w", err)
+			}
+			if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", filepath.Dir(dir), err)
+			}
+			ctx.sendProgress(TaskLog{TaskID: ctx.State.CurrentStep, Line: fmt.Sprintf("Cloning %s into %s", TmuxPluginManager, dir)})
+			if output, err := ctx.runCommand(t.Name, ctx.command(t.Name, "git", "clone", "--depth=1", TmuxPluginManager, dir)); err != nil {
+				return fmt.Errorf("failed to clone TPM: %w (Output: %s)", err, strings.TrimSpace(string(output)))
+			}
+			cloned = dir
+			ctx.recordDir(t.Name, dir)
+			ctx.Logger.Info("Installed TPM into %s", dir)
+			return nil
+		},
+		Rollback: func(ctx *InstallationContext) error {
+			if cloned == "" {
+				return nil
+			}
+			if err := os.RemoveAll(cloned); err != nil {
+				return fmt.Errorf("failed to remove TPM %s during rollback: %w", cloned, err)
+			}
+			return nil
+		},
+		Timeout: 5 * time.Minute,
+	}
+}
+
+// tmuxConfigStep writes the managed block into the tmux config, after
+// whatever the file already has. Running it again leaves the block as it is,
+// and a block edited by hand is left alone.
+func tmuxConfigStep(t *Tool) InstallationStep {
+	var written string
+	return InstallationStep{
+		Name:        fmt.Sprintf("%s-config", t.Name),
+		Description: "Writing the tmux config",
+		Action: func(ctx *InstallationContext) error {
+			home, err := system.UserHome()
+			if err != nil {
+				return err
+			}
+			path := TmuxConfPath(home)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+			}
+			// Not staged with the run's rc edits: the plugins step reads the
+			// config right after
+			rc := shell.NewRCWriter()
+			if ctx.Logger != nil {
+				rc.Warn = ctx.Logger.Warn
+			}
+			change, err := rc.UpsertBlock(path, TmuxBlock, tmuxConfig)
+			ctx.recordRCBlocks(t.Name, rc)
+			if err != nil {
+				return fmt.Errorf("failed to write the tmux config %s: %w", path, err)
+			}
+			if change != nil && change.Applied && change.Created {
+				written = path
+			}
+			return nil
+		},
+		Rollback: func(ctx *InstallationContext) error {
+			// A block from an earlier run is left alone
+			if written == "" {
+				return nil
+			}
+			if _, err := shell.NewRCWriter().RemoveBlock(written, TmuxBlock); err != nil {
+				return fmt.Errorf("failed to remove the tmux block from %s during rollback: %w", written, err)
+			}
+			return nil
+		},
+	}
+}
+
+// tmuxPluginsStep runs TPM's install script, which installs the plugins of
+// the config without a tmux session. A failure is a warning: prefix + I
+// installs them from tmux.
+func tmuxPluginsStep(t *Tool) InstallationStep {
+	return InstallationStep{
+		Name:        fmt.Sprintf("%s-install-plugins", t.Name),
+		Description: "Installing tmux plugins",
+		Action: func(ctx *InstallationContext) error {
+			home, err := system.UserHome()
+			if err != nil {
+				return err
+			}
+			script := filepath.Join(tmuxPluginDir(home), "tpm", "bin", "install_plugins")
+			if !exists(script) {
+				return nil
+			}
+			if !lookPathOK("tmux") {
+				ctx.Logger.Warn("tmux is not on PATH, so the tmux plugins were not installed")
+				return nil
+			}
+			cmd := ctx.command(t.Name, script)
+			cmd.Env = append(os.Environ(), "TMUX_PLUGIN_MANAGER_PATH="+tmuxPluginDir(home)+string(filepath.Separator))
+			output, err := ctx.runCommand(t.Name, cmd)
+			if len(output) > 0 {
+				ctx.sendProgress(TaskLog{TaskID: ctx.State.CurrentStep, Line: string(output)})
+			}
+			if err != nil {
+				ctx.Logger.Warn("tmux plugin install failed (%s); press prefix + I in tmux to install them", lastLine(string(output)+err.Error()))
+				return nil
+			}
+			ctx.Logger.Info("Installed the tmux plugins")
+			return nil
+		},
+		Timeout: 5 * time.Minute,
+	}
+}
@@ -0,0 +1,81 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+)
+
+func TestTmuxInstallationSteps(t *testing.T) {
+	platform := &Platform{OS: "linux", PackageManager: "apt"}
+	ctx := NewInstallationContext(platform, &fakePM{}, nil)
+	tool := &Tool{Tool: interfaces.Tool{Name: "tmux", Category: string(CategoryDevelopment)}, Builtin: "tmux"}
+	var names []string
+	for _, step := range tool.GenerateInstallationSteps(platform, ctx, true) {
+		names = append(names, step.Name)
+	}
+	want := []string{"tmux-install-package", "tmux-install-tpm", "tmux-config", "tmux-install-plugins", "tmux-verify"}
+	if strings.Join(names, " ") != strings.Join(want, " ") {
+		t.Errorf("steps = %v, want %v", names, want)
+	}
+}
+
+func TestTmuxConfigStep(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(shell.DryRunEnvVar, "")
+	conf := filepath.Join(home, ".tmux.conf")
+	mine := "set -g prefix C-a\n"
+	if err := os.WriteFile(conf, []byte(mine), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := NewInstallationContext(&Platform{OS: "linux", PackageManager: "apt"}, nil, nil)
+	step := tmuxConfigStep(&Tool{Tool: interfaces.Tool{Name: "tmux"}})
+	for i := 0; i < 2; i++ {
+		if err := step.Action(ctx); err != nil {
+			t.Fatalf("run %d: tmux config error = %v", i+1, err)
+		}
+	}
+	data, err := os.ReadFile(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), mine) {
+		t.Errorf("Expected the existing config to be kept first, got:\n%s", data)
+	}
+	if n := strings.Count(string(data), shell.BlockEnd(TmuxBlock)); n != 1 {
+		t.Errorf("Expected one tmux block after running twice, got %d:\n%s", n, data)
+	}
+	if !strings.Contains(string(data), "run '~/.tmux/plugins/tpm/tpm'") {
+		t.Errorf("Expected the block to run TPM, got:\n%s", data)
+	}
+
+	if err := step.Rollback(ctx); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if data, _ := os.ReadFile(conf); string(data) != mine {
+		t.Errorf("Expected rollback to leave only the existing config, got %q", data)
+	}
+}
+
+func TestTmuxConfPath(t *testing.T) {
+	home := t.TempDir()
+	if got, want := TmuxConfPath(home), filepath.Join(home, ".tmux.conf"); got != want {
+		t.Errorf("TmuxConfPath() = %s, want %s", got, want)
+	}
+	xdg := filepath.Join(home, ".config", "tmux", "tmux.conf")
+	if err := os.MkdirAll(filepath.Dir(xdg), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(xdg, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := TmuxConfPath(home); got != xdg {
+		t.Errorf("TmuxConfPath() = %s, want the XDG config %s", got, xdg)
+	}
+}
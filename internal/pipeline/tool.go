@@ -3,11 +3,16 @@ package pipeline
 import (
 	"fmt"
 	"os/exec"
+	"strings"
 	"time"
 
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cache"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cmdexec"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/install"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/packages/implementations"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/release"
 )
 
 // ToolCategory represents the category of a tool
@@ -74,22 +79,49 @@ type PackageInfo struct {
 
 // Tool represents a tool that can be installed
 type Tool struct {
-	Name        string
-	Category    ToolCategory
-	Description string
-	Version     string
+	// Tool is the catalog definition: names, packages, dependencies, shell
+	// config and completions, the same value the package installer reads
+	interfaces.Tool `yaml:",inline"`
+
+	// MinVersion is the oldest acceptable version; an installed tool below it is
+	// upgraded instead of being left alone
+	MinVersion  string
+	// Timeout is how long installing the tool may take (e.g. "15m" for one built
+	// from source); 0 uses InstallationContext.ToolTimeout
+	Timeout     time.Duration
 	Homepage    string
-	Tags        []string
 
-	// Dependencies required by this tool
-	Dependencies []Dependency
+	// Group lists the member tools of a group ("meta-tool", e.g. git-suite).
+	// A group installs its members and has no packages of its own.
+	Group []string
 
-	// System dependencies required by this tool
-	SystemDependencies []string
+	// SupportedOS limits the tool to these operating systems (GOOS values such
+	// as linux or darwin); empty means every OS
+	SupportedOS []string
+
+	// UnsupportedOS lists operating systems the tool is not offered on
+	UnsupportedOS []string
+
+	// Conflicts names tools that must not be installed alongside this one (e.g.
+	// two ls replacements that both alias ls); declaring it on either side is enough
+	Conflicts []string
 
 	// Installation strategy
 	Install InstallStrategy
 
+	// Release installs the tool from a GitHub release when the package manager
+	// has no package for it
+	Release *release.Spec
+
+	// PreferredManager installs the tool with this package manager instead of the
+	// system's primary one when it is available (e.g. "brew" for newer releases)
+	PreferredManager string
+
+	// Builtin names the Go installer of a tool that needs more than packages
+	// and commands (e.g. "docker", which adds a repository and a group); it
+	// replaces the package, release and custom install steps
+	Builtin string
+
 	// Verification strategy
 	Verify VerifyStrategy
 
@@ -107,8 +139,7 @@ type Tool struct {
 func NewTool(name string, category ToolCategory) *Tool {
 	logger := log.New(log.InfoLevel)
 	return &Tool{
-		Name:            name,
-		Category:        category,
+		Tool:            interfaces.Tool{Name: name, Category: string(category)},
 		PlatformConfig:  make(map[string]InstallStrategy),
 		cmdExecutor:     cmdexec.NewCommandExecutor(logger),
 		logger:          logger,
@@ -144,6 +175,40 @@ func (t *Tool) GetInstallStrategy(platform *Platform) InstallStrategy {
 	return t.Install
 }
 
+// PackageFor returns the package name to install the tool with on the given package
+// manager, falling back to the "default" package name and then the tool name
+func (t *Tool) PackageFor(pm string) string {
+	if name, err := t.Install.GetPackageName(pm); err == nil && name != "" {
+		return name
+	}
+	return t.Name
+}
+
+// FindTool resolves a tool name or alias to the canonical tool, or nil if none matches.
+// Exact names take precedence over aliases.
+func FindTool(tools []*Tool, name string) *Tool {
+	for _, tool := range tools {
+		if strings.EqualFold(tool.Name, name) {
+			return tool
+		}
+	}
+	for _, tool := range tools {
+		if tool.Matches(name) {
+			return tool
+		}
+	}
+	return nil
+}
+
+// ToolNames returns the names of tools
+func ToolNames(tools []*Tool) []string {
+	names := make([]string, len(tools))
+	for i, tool := range tools {
+		names[i] = tool.Name
+	}
+	return names
+}
+
 // checkBinaryPath checks if a binary exists in the PATH
 func (t *Tool) checkBinaryPath(path string) (bool, error) {
 	_, err := exec.LookPath(path)
@@ -164,7 +229,7 @@ func (t *Tool) checkRequiredFile(file string) (bool, error) {
 }
 
 // VerifyInstallation checks if the tool is installed correctly
-func (t *Tool) VerifyInstallation(_ *InstallationContext) error {
+func (t *Tool) VerifyInstallation(ctx *InstallationContext) error {
 	// Check binary paths with retries
 	for _, path := range t.Verify.BinaryPaths {
 		var exists bool
@@ -212,24 +277,37 @@ func (t *Tool) VerifyInstallation(_ *InstallationContext) error {
 	// Execute verification command with timeout
 	if t.Verify.Command.Command != "" {
 		cmd := exec.Command("sh", "-c", t.Verify.Command.Command)
+		if ctx != nil {
+			cmd = ctx.command(t.Name, "sh", "-c", t.Verify.Command.Command)
+		}
 		if err := t.cmdExecutor.ExecuteWithRetry(cmd, t.cmdExecutor.DefaultRetries, t.cmdExecutor.DefaultDelay); err != nil {
 			return fmt.Errorf("verification command failed: %w", err)
 		}
 	}
 
-	return nil
+	return t.checkPinnedVersion()
 }
 
 // determineInstallationMethod determines the best installation method for the tool
-func (t *Tool) determineInstallationMethod(context *InstallationContext) (InstallationMethod, error) {
-	// Get the package name for the current platform
-	packageName := t.Install.PackageNames[context.Platform.OS]
-	if packageName == "" {
-		packageName = t.Install.PackageNames["default"]
+func (t *Tool) determineInstallationMethod(context *InstallationContext, manager string) (InstallationMethod, error) {
+	// Offline, the release pre-downloaded by `cache warm` is the one install
+	// that needs no network
+	if t.Release != nil && cache.Offline() {
+		return BinaryInstall, nil
+	}
+	// Without sudo, a release into ~/.local/bin stands in for the system package
+	if t.Release != nil && context.NoSudo && privilegedManagers[manager] {
+		return BinaryInstall, nil
 	}
 
-	// Check if package is available in repositories
-	if !context.PackageManager.IsPackageAvailable(packageName) {
+	// Get the package name for the chosen manager
+	packageName := t.PackageFor(manager)
+
+	// Check if package is available in repositories; only the primary manager can be queried
+	if manager == context.Platform.PackageManager && !context.PackageManager.IsPackageAvailable(packageName) {
+		if t.Release != nil {
+			return BinaryInstall, nil
+		}
 		return "", fmt.Errorf("package %s is not available", packageName)
 	}
 
@@ -257,8 +335,18 @@ func (t *Tool) GenerateInstallationSteps(platform *Platform, context *Installati
 		})
 	}
 	
+	if t.Builtin != "" {
+		builtin, err := builtinInstallers[t.Builtin](t, context)
+		if err != nil {
+			t.logger.Error("Failed to plan the %s install: %v", t.Name, err)
+			return steps
+		}
+		return append(append(append(steps, builtin...), t.verifyStep()), t.completionSteps()...)
+	}
+
 	// Determine installation method
-	method, err := t.determineInstallationMethod(context)
+	manager := context.managerFor(t)
+	method, err := t.determineInstallationMethod(context, manager)
 	if err != nil {
 		t.logger.Error("Failed to determine installation method: %v", err)
 		return steps
@@ -278,8 +366,8 @@ func (t *Tool) GenerateInstallationSteps(platform *Platform, context *Installati
 				ctx.Logger.CommandStart(preCmd.Command, 1, 1)
 				start := time.Now()
 				
-				execCmd := exec.Command("sh", "-c", preCmd.Command)
-				output, err := execCmd.CombinedOutput()
+				execCmd := ctx.command(t.Name, "sh", "-c", preCmd.Command)
+				output, err := ctx.runCommand(t.Name, execCmd)
 				
 				duration := time.Since(start)
 				if err != nil {
@@ -296,48 +384,90 @@ func (t *Tool) GenerateInstallationSteps(platform *Platform, context *Installati
 	// Add main installation step based on method
 	switch method {
 	case PackageManagerInstall:
-		// Get package name for the platform
-		pkgName, ok := strategy.PackageNames[platform.PackageManager]
-		if !ok {
-			t.logger.Error("No package name defined for %s on %s", t.Name, platform.PackageManager)
-			return steps
+		// Get package name for the manager, preferring a platform-specific override
+		pkgName := t.PackageFor(manager)
+		if name, err := strategy.GetPackageName(manager); err == nil && name != "" {
+			pkgName = name
 		}
 		
 		stepName := fmt.Sprintf("%s-install-package", t.Name)
 		steps = append(steps, InstallationStep{
 			Name: stepName,
-			Description: fmt.Sprintf("Installing %s via %s", pkgName, platform.PackageManager),
+			Description: fmt.Sprintf("Installing %s via %s", pkgName, manager),
 			Action: func(ctx *InstallationContext) error {
-				var cmdStr string
-				switch platform.PackageManager {
-				case "apt":
-					cmdStr = fmt.Sprintf("sudo apt-get install -y %s", pkgName)
-				case "brew":
-					cmdStr = fmt.Sprintf("brew install %s", pkgName)
-				case "pacman":
-					cmdStr = fmt.Sprintf("sudo pacman -S --noconfirm %s", pkgName)
-				default:
-					return fmt.Errorf("unsupported package manager: %s", platform.PackageManager)
+				if existing, ok := ctx.KeepExisting[t.Name]; ok {
+					ctx.Logger.Info("Keeping %s installed via %s", t.Name, existing)
+					return nil
+				}
+				if ctx.batched[t.Name] {
+					ctx.Logger.Info("%s was installed with the other %s packages", t.Name, manager)
+					return nil
 				}
+				pkg, err := ctx.lockedToolPackage(t.Name, pkgName)
+				if err != nil {
+					return err
+				}
+
+				// An installed tool is left alone unless it is older than MinVersion
+				var from string
+				if t.MinVersion != "" {
+					if current, ok := t.InstalledVersion(); ok {
+						if CompareVersions(current, t.MinVersion) >= 0 {
+							ctx.Logger.Info("%s %s meets minimum version %s, skipping", t.Name, current, t.MinVersion)
+							return nil
+						}
+						from = current
+					}
+				}
+
+				cmdStr, err := ctx.installCommand(manager, pkg)
+				if from != "" {
+					cmdStr, err = ctx.upgradeCommand(manager, pkg)
+				}
+				if err != nil {
+					return err
+				}
+				// Only a package this run installs is journaled for rollback
+				fresh := from == "" && !ctx.preinstalled(manager, pkgName)
 				
 				ctx.Logger.CommandStart(cmdStr, 1, 1)
 				start := time.Now()
 				
-				execCmd := exec.Command("sh", "-c", cmdStr)
-				output, err := execCmd.CombinedOutput()
+				execCmd := ctx.shellCommand(t.Name, cmdStr)
+				output, err := ctx.runCommand(t.Name, execCmd)
 				
 				duration := time.Since(start)
+				// winget fails for a package it already has; that is nothing to undo
+				if implementations.WingetAlreadyInstalled(err) {
+					ctx.Logger.Info("%s is already installed via %s, skipping", t.Name, manager)
+					return nil
+				}
 				if err != nil {
 					ctx.Logger.CommandError(cmdStr, err, 1, 1)
 					return fmt.Errorf("package installation failed: %w (Output: %s)", err, string(output))
 				}
 				ctx.Logger.CommandSuccess(cmdStr, duration)
+				if fresh {
+					ctx.recordPackage(t.Name, manager, pkgName)
+				}
+
+				if from != "" {
+					to, _ := t.InstalledVersion()
+					if to == "" || CompareVersions(to, t.MinVersion) < 0 {
+						return fmt.Errorf("%s is at %s after upgrading, below minimum version %s", t.Name, to, t.MinVersion)
+					}
+					ctx.Logger.Info("Upgraded %s from %s to %s", t.Name, from, to)
+				}
 				return nil
 			},
 			Timeout: 10 * time.Minute,
 		})
 		
 	case BinaryInstall:
+		if t.Release != nil {
+			steps = append(steps, t.releaseStep())
+			break
+		}
 		// Binary installation is not directly supported in the current InstallStrategy
 		// We'll use custom installation instead
 		t.logger.Warn("Binary installation not directly supported, using custom installation")
@@ -355,8 +485,8 @@ func (t *Tool) GenerateInstallationSteps(platform *Platform, context *Installati
 					ctx.Logger.CommandStart(customCmd.Command, 1, 1)
 					start := time.Now()
 					
-					execCmd := exec.Command("sh", "-c", customCmd.Command)
-					output, err := execCmd.CombinedOutput()
+					execCmd := ctx.command(t.Name, "sh", "-c", customCmd.Command)
+					output, err := ctx.runCommand(t.Name, execCmd)
 					
 					duration := time.Since(start)
 					if err != nil {
@@ -382,8 +512,8 @@ func (t *Tool) GenerateInstallationSteps(platform *Platform, context *Installati
 				ctx.Logger.CommandStart(postCmd.Command, 1, 1)
 				start := time.Now()
 				
-				execCmd := exec.Command("sh", "-c", postCmd.Command)
-				output, err := execCmd.CombinedOutput()
+				execCmd := ctx.command(t.Name, "sh", "-c", postCmd.Command)
+				output, err := ctx.runCommand(t.Name, execCmd)
 				
 				duration := time.Since(start)
 				if err != nil {
@@ -398,16 +528,59 @@ func (t *Tool) GenerateInstallationSteps(platform *Platform, context *Installati
 	}
 	
 	// Add verification step
-	steps = append(steps, InstallationStep{
-		Name: fmt.Sprintf("%s-verify", t.Name),
+	steps = append(steps, t.verifyStep())
+	steps = append(steps, t.completionSteps()...)
+	
+	return steps
+}
+
+// completionSteps writes the tool's shell completions, if it has any, for the
+// shell the run configures
+func (t *Tool) completionSteps() []InstallationStep {
+	if t.Completions.Command == "" && len(t.Completions.Scripts) == 0 {
+		return nil
+	}
+	return []InstallationStep{{
+		Name:        fmt.Sprintf("%s-completions", t.Name),
+		Description: fmt.Sprintf("Installing shell completions for %s", t.Name),
+		Action: func(ctx *InstallationContext) error {
+			shells := ctx.completionShells()
+			if len(shells) == 0 {
+				return nil
+			}
+			logger, ok := ctx.Logger.(*log.Logger)
+			if !ok {
+				logger = log.New(log.InfoLevel)
+			}
+			rc := ctx.stageRC()
+			err := install.InstallCompletions(&t.Tool, ctx.managerFor(t), shells, rc, logger)
+			ctx.recordRCBlocks(t.Name, rc)
+			if err != nil {
+				return fmt.Errorf("failed to install %s completions: %w", t.Name, err)
+			}
+			return nil
+		},
+		Timeout: 1 * time.Minute,
+	}}
+}
+
+// verifyStep checks the tool works and records its version
+func (t *Tool) verifyStep() InstallationStep {
+	return InstallationStep{
+		Name:        fmt.Sprintf("%s-verify", t.Name),
 		Description: fmt.Sprintf("Verifying installation of %s", t.Name),
 		Action: func(ctx *InstallationContext) error {
-			return t.VerifyInstallation(ctx)
+			if err := t.VerifyInstallation(ctx); err != nil {
+				return err
+			}
+			// Record the working version for the summary and installed.json
+			if version, ok := t.InstalledVersion(); ok {
+				ctx.State.RecordVersion(t.Name, version)
+			}
+			return nil
 		},
 		Timeout: 1 * time.Minute,
-	})
-	
-	return steps
+	}
 }
 
 // Validate checks if the tool configuration is valid
@@ -423,8 +596,18 @@ func (t *Tool) Validate() error {
 		return fmt.Errorf("tool description cannot be empty")
 	}
 
+	if t.IsGroup() {
+		return t.validateGroup()
+	}
+	if t.Timeout < 0 {
+		return fmt.Errorf("tool timeout cannot be negative")
+	}
+	if _, ok := builtinInstallers[t.Builtin]; t.Builtin != "" && !ok {
+		return fmt.Errorf("unknown builtin installer: %s", t.Builtin)
+	}
+
 	// Validate category
-	switch t.Category {
+	switch ToolCategory(t.Category) {
 	case CategoryEssential, CategoryDevelopment, CategoryShell, CategorySystem:
 		// Valid categories
 	default:
@@ -1,7 +1,14 @@
 package pipeline
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
 )
 
 func TestTool_NewTool(t *testing.T) {
@@ -9,7 +16,7 @@ func TestTool_NewTool(t *testing.T) {
 	if tool.Name != "test-tool" {
 		t.Errorf("Expected tool name 'test-tool', got '%s'", tool.Name)
 	}
-	if tool.Category != CategoryDevelopment {
+	if tool.Category != string(CategoryDevelopment) {
 		t.Errorf("Expected category Development, got '%s'", tool.Category)
 	}
 }
@@ -191,4 +198,175 @@ func TestTool_CustomInstallation(t *testing.T) {
 	if len(steps) != expectedSteps {
 		t.Errorf("Expected %d steps, got %d", expectedSteps, len(steps))
 	}
-} 
\ No newline at end of file
+} 
+func TestPreflight(t *testing.T) {
+	platform := &Platform{OS: "linux", PackageManager: "apt"}
+
+	packaged := NewTool("bat", CategoryShell)
+	packaged.SetInstallation(InstallStrategy{PackageNames: map[string]string{"apt": "bat"}})
+
+	scripted := NewTool("starship", CategoryShell)
+	scripted.SetInstallation(InstallStrategy{CustomInstall: []Command{{Command: "curl -sS https://starship.rs/install.sh | sh"}}})
+
+	brewOnly := NewTool("mas", CategorySystem)
+	brewOnly.SetInstallation(InstallStrategy{PackageNames: map[string]string{"brew": "mas"}})
+
+	linuxPackage := NewTool("xclip", CategorySystem)
+	linuxPackage.SetInstallation(InstallStrategy{PackageNames: map[string]string{"linux": "xclip"}})
+
+	installable, issues := Preflight([]*Tool{packaged, scripted, brewOnly, linuxPackage}, platform)
+
+	if len(installable) != 3 || installable[0].Name != "bat" || installable[1].Name != "starship" || installable[2].Name != "xclip" {
+		t.Errorf("Expected bat, starship and xclip to be installable, got %v", installable)
+	}
+	if len(issues) != 1 || issues[0].Tool != "mas" {
+		t.Fatalf("Expected a single issue for mas, got %v", issues)
+	}
+}
+
+func TestTool_SupportsOS(t *testing.T) {
+	linuxOnly := NewTool("build-essential", CategorySystem)
+	linuxOnly.SupportedOS = []string{"linux"}
+	notMac := NewTool("htop", CategorySystem)
+	notMac.UnsupportedOS = []string{"darwin"}
+	anywhere := NewTool("git", CategorySystem)
+
+	tests := []struct {
+		tool *Tool
+		goos string
+		want bool
+	}{
+		{linuxOnly, "linux", true},
+		{linuxOnly, "android", true},
+		{linuxOnly, "darwin", false},
+		{notMac, "darwin", false},
+		{notMac, "linux", true},
+		{anywhere, "darwin", true},
+	}
+	for _, tt := range tests {
+		ok, reason := tt.tool.SupportsOS(tt.goos)
+		if ok != tt.want {
+			t.Errorf("%s.SupportsOS(%q) = %v, want %v", tt.tool.Name, tt.goos, ok, tt.want)
+		}
+		if !ok && reason == "" {
+			t.Errorf("%s.SupportsOS(%q) gave no reason", tt.tool.Name, tt.goos)
+		}
+	}
+}
+
+func TestPreflight_UnsupportedOS(t *testing.T) {
+	tool := NewTool("build-essential", CategorySystem)
+	tool.SupportedOS = []string{"linux"}
+	tool.SetInstallation(InstallStrategy{PackageNames: map[string]string{"brew": "gcc"}})
+
+	installable, issues := Preflight([]*Tool{tool}, &Platform{OS: "darwin", PackageManager: "brew"})
+	if len(installable) != 0 {
+		t.Errorf("Expected no installable tools, got %v", installable)
+	}
+	if len(issues) != 1 || issues[0].Reason != "only supported on linux" {
+		t.Fatalf("Expected an OS issue for build-essential, got %v", issues)
+	}
+}
+
+func TestTool_PackageFor(t *testing.T) {
+	tool := NewTool("fd", CategoryDevelopment)
+	tool.Install.PackageNames = map[string]string{"apt": "fd-find", "default": "fd-bin"}
+
+	if got := tool.PackageFor("apt"); got != "fd-find" {
+		t.Errorf("Expected 'fd-find' for apt, got '%s'", got)
+	}
+	if got := tool.PackageFor("pacman"); got != "fd-bin" {
+		t.Errorf("Expected default package 'fd-bin' for pacman, got '%s'", got)
+	}
+	if got := tool.PackageFor("pkg"); got != "fd-find" {
+		t.Errorf("Expected Termux pkg to reuse the apt name 'fd-find', got '%s'", got)
+	}
+	tool.Install.PackageNames = nil
+	if got := tool.PackageFor("brew"); got != "fd" {
+		t.Errorf("Expected tool name 'fd' as fallback, got '%s'", got)
+	}
+}
+
+func TestFindTool(t *testing.T) {
+	ripgrep := NewTool("ripgrep", CategoryDevelopment)
+	ripgrep.Aliases = []string{"rg"}
+	fd := NewTool("fd", CategoryDevelopment)
+	fd.Aliases = []string{"fd-find", "fdfind"}
+	tools := []*Tool{ripgrep, fd}
+
+	tests := map[string]*Tool{
+		"ripgrep": ripgrep,
+		"RG":      ripgrep,
+		"fd-find": fd,
+		"fd":      fd,
+		"exa":     nil,
+	}
+	for name, want := range tests {
+		if got := FindTool(tools, name); got != want {
+			t.Errorf("FindTool(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestInstallationContext_ManagerFor(t *testing.T) {
+	orig := lookPath
+	t.Cleanup(func() { lookPath = orig })
+	lookPath = func(file string) (string, error) {
+		if file == "brew" {
+			return "/home/linuxbrew/.linuxbrew/bin/brew", nil
+		}
+		return "", fmt.Errorf("%s not found", file)
+	}
+
+	ctx := NewInstallationContext(&Platform{OS: "linux", PackageManager: "apt"}, nil, nil)
+	tool := &Tool{Tool: interfaces.Tool{Name: "neovim"}}
+	if got := ctx.managerFor(tool); got != "apt" {
+		t.Errorf("Expected the primary manager without a preference, got %q", got)
+	}
+
+	tool.PreferredManager = "pacman"
+	if got := ctx.managerFor(tool); got != "apt" {
+		t.Errorf("Expected an unavailable preference to fall back to apt, got %q", got)
+	}
+
+	tool.PreferredManager = "brew"
+	if got := ctx.managerFor(tool); got != "brew" {
+		t.Errorf("Expected the tool's preferred manager, got %q", got)
+	}
+
+	ctx.ToolManagers = map[string]string{"neovim": "apt"}
+	if got := ctx.managerFor(tool); got != "apt" {
+		t.Errorf("Expected the settings override to win over the tool preference, got %q", got)
+	}
+}
+
+func TestTool_CompletionSteps(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	ctx := NewInstallationContext(&Platform{OS: "linux", PackageManager: "apt", Shell: "zsh"}, &fakePM{}, nil)
+	tool := NewTool("demo", CategoryDevelopment)
+	if steps := tool.completionSteps(); len(steps) != 0 {
+		t.Errorf("Expected no completion step for a tool without completions, got %d", len(steps))
+	}
+
+	tool.Completions.Command = "echo complete-{shell}"
+	steps := tool.GenerateInstallationSteps(ctx.Platform, ctx, true)
+	if len(steps) == 0 || steps[len(steps)-1].Name != "demo-completions" {
+		t.Fatalf("Expected the install to end with a completions step, got %d steps", len(steps))
+	}
+	if err := steps[len(steps)-1].Action(ctx); err != nil {
+		t.Fatalf("completions step error = %v", err)
+	}
+	if err := ctx.flushRC(); err != nil {
+		t.Fatalf("flushRC() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".zsh", "completions", "_demo"))
+	if err != nil || strings.TrimSpace(string(data)) != "complete-zsh" {
+		t.Errorf("Expected the zsh completion script, got %q (%v)", data, err)
+	}
+	zshrc, _ := os.ReadFile(filepath.Join(home, ".zshrc"))
+	if !shell.HasBlock(string(zshrc), "demo-completion") {
+		t.Errorf("Expected .zshrc to load the completions, got:\n%s", zshrc)
+	}
+}
@@ -0,0 +1,111 @@
+package pipeline
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ToolConflict is a pair of selected tools that declare they must not be
+// installed together
+type ToolConflict struct {
+	A, B *Tool
+}
+
+// String renders e.g. "lsd conflicts with eza"
+func (c ToolConflict) String() string {
+	return fmt.Sprintf("%s conflicts with %s", c.A.Name, c.B.Name)
+}
+
+// ToolConflictError reports conflicting tools that were selected together where
+// there is nobody to ask which one to keep
+type ToolConflictError struct {
+	Conflicts []ToolConflict
+}
+
+func (e *ToolConflictError) Error() string {
+	lines := make([]string, len(e.Conflicts))
+	for i, c := range e.Conflicts {
+		lines[i] = c.String()
+	}
+	return fmt.Sprintf("conflicting tools selected; choose one of each pair:\n  - %s", strings.Join(lines, "\n  - "))
+}
+
+// conflictsWith reports whether t declares other (by name or alias) as a conflict
+func (t *Tool) conflictsWith(other *Tool) bool {
+	for _, name := range t.Conflicts {
+		if other.Matches(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindToolConflicts returns the pairs of tools that conflict, in selection
+// order. A conflict declared on either tool counts.
+func FindToolConflicts(tools []*Tool) []ToolConflict {
+	var conflicts []ToolConflict
+	for i, a := range tools {
+		for _, b := range tools[i+1:] {
+			if a.conflictsWith(b) || b.conflictsWith(a) {
+				conflicts = append(conflicts, ToolConflict{A: a, B: b})
+			}
+		}
+	}
+	return conflicts
+}
+
+// ResolveToolConflicts asks choose which tool of each conflicting pair to keep
+// and returns the selection without the others. Pairs whose tool was already
+// dropped by an earlier answer are not asked about.
+func ResolveToolConflicts(tools []*Tool, conflicts []ToolConflict, choose func(ToolConflict) *Tool) []*Tool {
+	dropped := make(map[*Tool]bool)
+	for _, c := range conflicts {
+		if dropped[c.A] || dropped[c.B] {
+			continue
+		}
+		if choose(c) == c.A {
+			dropped[c.B] = true
+		} else {
+			dropped[c.A] = true
+		}
+	}
+	kept := make([]*Tool, 0, len(tools))
+	for _, t := range tools {
+		if !dropped[t] {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// PromptToolConflict asks on out which tool of c to keep and reads the answer
+// from in; anything but 2 or the second tool's name keeps the first
+func PromptToolConflict(c ToolConflict, in io.Reader, out io.Writer) *Tool {
+	fmt.Fprintf(out, "%s. Install [1] %s or [2] %s? [1/2] ", c, c.A.Name, c.B.Name)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch answer = strings.TrimSpace(answer); {
+	case answer == "2" || strings.EqualFold(answer, c.B.Name):
+		return c.B
+	default:
+		return c.A
+	}
+}
+
+// CatalogConflictIssues checks the conflicts declared in a catalog: each must
+// name a known tool other than the one declaring it
+func CatalogConflictIssues(catalog []*Tool) []string {
+	var issues []string
+	for _, t := range catalog {
+		for _, name := range t.Conflicts {
+			switch other := FindTool(catalog, name); {
+			case other == nil:
+				issues = append(issues, fmt.Sprintf("%s: conflicts with unknown tool %q", t.Name, name))
+			case other == t:
+				issues = append(issues, fmt.Sprintf("%s: conflicts with itself", t.Name))
+			}
+		}
+	}
+	return issues
+}
@@ -0,0 +1,89 @@
+package pipeline
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func conflictingTools() (lsd, eza, bat *Tool) {
+	lsd = NewTool("lsd", CategoryDevelopment)
+	eza = NewTool("eza", CategoryDevelopment)
+	eza.Aliases = []string{"exa"}
+	lsd.Conflicts = []string{"exa"}
+	bat = NewTool("bat", CategoryDevelopment)
+	return lsd, eza, bat
+}
+
+func TestFindToolConflicts(t *testing.T) {
+	lsd, eza, bat := conflictingTools()
+
+	conflicts := FindToolConflicts([]*Tool{bat, eza, lsd})
+	if len(conflicts) != 1 {
+		t.Fatalf("Expected one conflict, got %v", conflicts)
+	}
+	// Declared on lsd only, but found in either order
+	if conflicts[0].A != eza || conflicts[0].B != lsd {
+		t.Errorf("Expected eza/lsd in selection order, got %s", conflicts[0])
+	}
+	if got := FindToolConflicts([]*Tool{bat, lsd}); len(got) != 0 {
+		t.Errorf("Expected no conflicts without eza, got %v", got)
+	}
+}
+
+func TestResolveToolConflicts(t *testing.T) {
+	lsd, eza, bat := conflictingTools()
+	tree := NewTool("tree", CategoryDevelopment)
+	tree.Conflicts = []string{"lsd", "eza"}
+	tools := []*Tool{lsd, eza, bat, tree}
+
+	var asked []string
+	kept := ResolveToolConflicts(tools, FindToolConflicts(tools), func(c ToolConflict) *Tool {
+		asked = append(asked, c.String())
+		return c.B
+	})
+
+	// lsd/eza keeps eza, so lsd/tree is not asked; eza/tree keeps tree
+	if want := []string{"lsd conflicts with eza", "eza conflicts with tree"}; strings.Join(asked, ";") != strings.Join(want, ";") {
+		t.Errorf("Expected questions %v, got %v", want, asked)
+	}
+	if len(kept) != 2 || kept[0] != bat || kept[1] != tree {
+		t.Errorf("Expected bat and tree kept, got %v", kept)
+	}
+}
+
+func TestPromptToolConflict(t *testing.T) {
+	lsd, eza, _ := conflictingTools()
+	c := ToolConflict{A: lsd, B: eza}
+	tests := map[string]*Tool{"\n": lsd, "1\n": lsd, "2\n": eza, "EZA\n": eza, "": lsd}
+	for answer, want := range tests {
+		var out bytes.Buffer
+		if got := PromptToolConflict(c, strings.NewReader(answer), &out); got != want {
+			t.Errorf("answer %q kept %s, want %s", answer, got.Name, want.Name)
+		}
+		if !strings.Contains(out.String(), "[1] lsd or [2] eza") {
+			t.Errorf("Unexpected prompt %q", out.String())
+		}
+	}
+}
+
+func TestToolConflictError(t *testing.T) {
+	lsd, eza, _ := conflictingTools()
+	var err error = &ToolConflictError{Conflicts: []ToolConflict{{A: lsd, B: eza}}}
+	var conflictErr *ToolConflictError
+	if !errors.As(err, &conflictErr) || !strings.Contains(err.Error(), "lsd conflicts with eza") {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestCatalogConflictIssues(t *testing.T) {
+	lsd, eza, bat := conflictingTools()
+	bat.Conflicts = []string{"batcat", "bat"}
+
+	issues := CatalogConflictIssues([]*Tool{lsd, eza, bat})
+	want := []string{`bat: conflicts with unknown tool "batcat"`, "bat: conflicts with itself"}
+	if strings.Join(issues, ";") != strings.Join(want, ";") {
+		t.Errorf("Expected %v, got %v", want, issues)
+	}
+}
@@ -0,0 +1,105 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/requires"
+)
+
+// requiresLookup looks tools up by name or alias, in tools before catalog, and
+// returns their requirements with every alternative spelled as the tool's name
+func requiresLookup(tools, catalog []*Tool) (requires.Lookup, func(string) *Tool) {
+	find := func(name string) *Tool {
+		if t := FindTool(tools, name); t != nil {
+			return t
+		}
+		return FindTool(catalog, name)
+	}
+	lookup := func(name string) ([]string, bool) {
+		t := find(name)
+		if t == nil {
+			return nil, false
+		}
+		reqs := make([]string, len(t.Requires))
+		for i, req := range t.Requires {
+			alternatives := requires.Alternatives(req)
+			for j, alt := range alternatives {
+				if other := find(alt); other != nil {
+					alternatives[j] = other.Name
+				}
+			}
+			reqs[i] = strings.Join(alternatives, requires.AlternativeSep)
+		}
+		return reqs, true
+	}
+	return lookup, find
+}
+
+// ResolveRequires orders tools so the tools they require come first, adding the
+// required tools that were not selected from catalog. The returned map names,
+// for each tool added, the tool that required it. A cycle is a *requires.CycleError.
+func ResolveRequires(tools, catalog []*Tool) ([]*Tool, map[string]string, error) {
+	lookup, find := requiresLookup(tools, catalog)
+	names := make([]string, len(tools))
+	for i, t := range tools {
+		names[i] = t.Name
+	}
+	res, err := requires.Resolve(names, lookup)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve tool requirements: %w", err)
+	}
+	ordered := make([]*Tool, len(res.Order))
+	for i, name := range res.Order {
+		ordered[i] = find(name)
+	}
+	return ordered, res.RequiredBy, nil
+}
+
+// RequiredLabel renders a tool with the tool that pulled it in, if any, e.g.
+// "fd (required by fzf)"
+func RequiredLabel(name string, requiredBy map[string]string) string {
+	return (&requires.Resolution{RequiredBy: requiredBy}).Label(name)
+}
+
+// requiredFrom returns the names of the tools in selected that meet t's
+// requirements; requirements met by none of them are left out
+func (t *Tool) requiredFrom(selected map[string]*Tool) []string {
+	var names []string
+	for _, req := range t.Requires {
+		for _, alt := range requires.Alternatives(req) {
+			if other := findSelected(selected, alt); other != nil {
+				names = append(names, other.Name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+func findSelected(selected map[string]*Tool, name string) *Tool {
+	if t, ok := selected[name]; ok {
+		return t
+	}
+	for _, t := range selected {
+		if t.Matches(name) {
+			return t
+		}
+	}
+	return nil
+}
+
+// CatalogRequiresIssues checks the requirements declared in a catalog: each
+// must name a known tool other than the one declaring it, without cycles
+func CatalogRequiresIssues(catalog []*Tool) []string {
+	lookup, _ := requiresLookup(nil, catalog)
+	names := make([]string, len(catalog))
+	for i, t := range catalog {
+		names[i] = t.Name
+	}
+	var issues []string
+	for _, err := range requires.Check(names, lookup) {
+		issues = append(issues, err.Error())
+	}
+	return issues
+}
@@ -0,0 +1,73 @@
+package pipeline
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/requires"
+)
+
+func TestResolveRequires(t *testing.T) {
+	fzf := NewTool("fzf", CategoryDevelopment)
+	fzf.Requires = []string{"fd-find"}
+	fd := NewTool("fd", CategoryDevelopment)
+	fd.Aliases = []string{"fd-find"}
+	bat := NewTool("bat", CategoryDevelopment)
+	catalog := []*Tool{bat, fd, fzf}
+
+	tools, requiredBy, err := ResolveRequires([]*Tool{fzf, bat}, catalog)
+	if err != nil {
+		t.Fatalf("ResolveRequires failed: %v", err)
+	}
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	if got := strings.Join(names, ","); got != "fd,fzf,bat" {
+		t.Errorf("Expected fd pulled in before fzf, got %s", got)
+	}
+	if got := RequiredLabel("fd", requiredBy); got != "fd (required by fzf)" {
+		t.Errorf("Unexpected label %q", got)
+	}
+
+	// A selected requirement is only moved ahead of the tool requiring it
+	if _, requiredBy, _ = ResolveRequires([]*Tool{fzf, fd}, catalog); len(requiredBy) != 0 {
+		t.Errorf("Expected nothing pulled in, got %v", requiredBy)
+	}
+
+	// The graph orders required tools first, and tools without dependencies too
+	ctx := NewInstallationContext(&Platform{OS: "linux"}, nil, nil)
+	ctx.dependencyGraph = NewDependencyGraph()
+	for _, tool := range tools {
+		ctx.AddTool(tool)
+	}
+	order, err := ctx.dependencyGraph.GetInstallOrder()
+	if err != nil {
+		t.Fatalf("GetInstallOrder failed: %v", err)
+	}
+	if got := strings.Join(order, ","); got != "bat,fd,fzf" {
+		t.Errorf("Expected every tool in the install order, got %s", got)
+	}
+}
+
+func TestResolveRequiresCycle(t *testing.T) {
+	pnpm := NewTool("pnpm", CategoryDevelopment)
+	node := NewTool("node", CategoryDevelopment)
+	pnpm.Requires = []string{"node"}
+	node.Requires = []string{"pnpm"}
+	bat := NewTool("bat", CategoryDevelopment)
+	bat.Requires = []string{"less"}
+	catalog := []*Tool{pnpm, node, bat}
+
+	_, _, err := ResolveRequires([]*Tool{pnpm}, catalog)
+	var cycle *requires.CycleError
+	if !errors.As(err, &cycle) {
+		t.Fatalf("Expected a cycle error, got %v", err)
+	}
+
+	issues := CatalogRequiresIssues(catalog)
+	if len(issues) != 2 || issues[0] != "requires cycle: pnpm -> node -> pnpm" || issues[1] != "bat requires unknown less" {
+		t.Errorf("Unexpected issues: %v", issues)
+	}
+}
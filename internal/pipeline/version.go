@@ -0,0 +1,176 @@
+package pipeline
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/packages/implementations"
+)
+
+// dottedVersion matches a version such as 2.34.1 anywhere in a line, including
+// glued forms like go1.21.0 or jq-1.6
+var dottedVersion = regexp.MustCompile(`\d+(?:\.\d+)+`)
+
+// bareVersion is the fallback for tools that print a single number (e.g. "less 590")
+var bareVersion = regexp.MustCompile(`\b\d+\b`)
+
+// ParseVersion extracts the version from the output of a tool's --version
+// command. Lines that only print a binary path (from "which x && x --version")
+// are ignored, and dotted versions are preferred over bare numbers.
+func ParseVersion(output string) (string, bool) {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "/") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	for _, line := range lines {
+		if v := dottedVersion.FindString(line); v != "" {
+			return v, true
+		}
+	}
+	for _, line := range lines {
+		if v := bareVersion.FindString(line); v != "" {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// CompareVersions compares two dotted versions numerically, returning -1, 0 or
+// 1. A leading "v" and any pre-release or build suffix are ignored, missing
+// components count as zero, and trailing letters ("3.2a") are dropped.
+func CompareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for len(pa) < len(pb) {
+		pa = append(pa, 0)
+	}
+	for len(pb) < len(pa) {
+		pb = append(pb, 0)
+	}
+	for i := range pa {
+		switch {
+		case pa[i] < pb[i]:
+			return -1
+		case pa[i] > pb[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionParts splits a version into its numeric components
+func versionParts(v string) []int {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+ "); i >= 0 {
+		v = v[:i]
+	}
+	var parts []int
+	for _, field := range strings.Split(v, ".") {
+		digits := field
+		for j, r := range field {
+			if r < '0' || r > '9' {
+				digits = field[:j]
+				break
+			}
+		}
+		n, _ := strconv.Atoi(digits)
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+// PinnedVersion reports whether version names a release, such as 20 or 1.21.3,
+// rather than a moving target like "latest" or "stable"
+func PinnedVersion(version string) bool {
+	v := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	return v != "" && v[0] >= '0' && v[0] <= '9'
+}
+
+// VersionMatches reports whether installed is the pinned version want. Only
+// the components want gives are compared, so 20 matches 20.11.1 and 1.21
+// matches 1.21.6, but 1.2 does not match 1.21.0.
+func VersionMatches(installed, want string) bool {
+	have, pinned := versionParts(installed), versionParts(want)
+	if len(have) < len(pinned) {
+		return false
+	}
+	for i := range pinned {
+		if have[i] != pinned[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// checkPinnedVersion fails when the tool's version is pinned and the installed
+// one, as printed by its verify command, is a different release
+func (t *Tool) checkPinnedVersion() error {
+	if !PinnedVersion(t.Version) {
+		return nil
+	}
+	installed, ok := t.InstalledVersion()
+	if !ok {
+		return nil
+	}
+	if !VersionMatches(installed, t.Version) {
+		return fmt.Errorf("%s %s is installed, but version %s is pinned", t.Name, installed, t.Version)
+	}
+	return nil
+}
+
+// VersionCommand returns the command that prints the tool's version: its verify
+// command, or "<binary> --version" when it has none
+func (t *Tool) VersionCommand() string {
+	if t.Verify.Command.Command != "" {
+		return t.Verify.Command.Command
+	}
+	return toolCommand(t) + " --version"
+}
+
+// InstalledVersion runs the tool's version command and parses the version it
+// prints. It reports false when the tool is not installed or prints no version.
+func (t *Tool) InstalledVersion() (string, bool) {
+	out, err := exec.Command("sh", "-c", t.VersionCommand()).CombinedOutput()
+	if err != nil {
+		return "", false
+	}
+	return ParseVersion(string(out))
+}
+
+// commands returns the command builder for manager: the context's own package
+// manager when it builds its commands, as a fake does in tests, or else the
+// implementation of the manager with that name
+func (c *InstallationContext) commands(manager string) (interfaces.PackageCommands, error) {
+	if manager == c.Platform.PackageManager {
+		if commands, ok := c.PackageManager.(interfaces.PackageCommands); ok {
+			return commands, nil
+		}
+	}
+	return implementations.Commands(manager)
+}
+
+// installCommand returns the command that installs the space-separated pkgs
+// with manager
+func (c *InstallationContext) installCommand(manager, pkgs string) (string, error) {
+	commands, err := c.commands(manager)
+	if err != nil {
+		return "", err
+	}
+	return commands.InstallCommand(pkgs), nil
+}
+
+// upgradeCommand returns the command that upgrades the installed pkgs with manager
+func (c *InstallationContext) upgradeCommand(manager, pkgs string) (string, error) {
+	commands, err := c.commands(manager)
+	if err != nil {
+		return "", err
+	}
+	return commands.UpgradeCommand(pkgs), nil
+}
@@ -0,0 +1,196 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"git version 2.34.1", "2.34.1"},
+		{"ripgrep 13.0.0 (rev af6b6c543b)\n-SIMD -AVX (compiled)", "13.0.0"},
+		{"go version go1.21.0 linux/amd64", "1.21.0"},
+		{"jq-1.6", "1.6"},
+		{"tmux 3.2a", "3.2"},
+		{"v18.17.1", "18.17.1"},
+		{"/usr/bin/python3.11\nPython 3.11.4", "3.11.4"},
+		{"less 590 (GNU regular expressions)", "590"},
+	}
+	for _, tt := range tests {
+		got, ok := ParseVersion(tt.output)
+		if !ok || got != tt.want {
+			t.Errorf("ParseVersion(%q) = %q, %v; want %q", tt.output, got, ok, tt.want)
+		}
+	}
+
+	if v, ok := ParseVersion("command not found"); ok {
+		t.Errorf("ParseVersion() found %q in output without a version", v)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2", "1.2.0", 0},
+		{"v1.10.0", "1.9.9", 1},
+		{"0.9", "0.10", -1},
+		{"3.2a", "3.2", 0},
+		{"2.0.0-rc1", "2.0.0", 0},
+		{"13.0.0", "14", -1},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestPinnedVersion(t *testing.T) {
+	for _, v := range []string{"20", "1.21.3", "v0.9.2"} {
+		if !PinnedVersion(v) {
+			t.Errorf("PinnedVersion(%q) = false, want true", v)
+		}
+	}
+	for _, v := range []string{"", "latest", "stable", "lts/*"} {
+		if PinnedVersion(v) {
+			t.Errorf("PinnedVersion(%q) = true, want false", v)
+		}
+	}
+}
+
+func TestVersionMatches(t *testing.T) {
+	tests := []struct {
+		installed, want string
+		match           bool
+	}{
+		{"20.11.1", "20", true},
+		{"1.21.6", "1.21", true},
+		{"1.21.0", "v1.21.0", true},
+		{"1.21.0", "1.2", false},
+		{"18.17.1", "20", false},
+		{"3", "3.11", false},
+	}
+	for _, tt := range tests {
+		if got := VersionMatches(tt.installed, tt.want); got != tt.match {
+			t.Errorf("VersionMatches(%q, %q) = %v, want %v", tt.installed, tt.want, got, tt.match)
+		}
+	}
+}
+
+func TestTool_VerifyInstallationPinnedVersion(t *testing.T) {
+	tool := &Tool{Tool: interfaces.Tool{Name: "fake", Version: "2.1"}}
+	tool.Verify.Command.Command = "echo fake 2.0.3"
+	if err := tool.checkPinnedVersion(); err == nil || !strings.Contains(err.Error(), "version 2.1 is pinned") {
+		t.Errorf("Expected a pinned version mismatch, got %v", err)
+	}
+	tool.Version = "2.0"
+	if err := tool.checkPinnedVersion(); err != nil {
+		t.Errorf("Expected 2.0.3 to match the pinned 2.0, got %v", err)
+	}
+	tool.Version = "latest"
+	tool.Verify.Command.Command = "echo fake 9.9"
+	if err := tool.checkPinnedVersion(); err != nil {
+		t.Errorf("Expected latest not to be checked, got %v", err)
+	}
+}
+
+func TestTool_InstalledVersion(t *testing.T) {
+	tool := NewTool("fake", CategoryDevelopment)
+	tool.Verify.Command.Command = "echo 'fake 1.4.2 (build 7)'"
+	if v, ok := tool.InstalledVersion(); !ok || v != "1.4.2" {
+		t.Errorf("InstalledVersion() = %q, %v; want 1.4.2", v, ok)
+	}
+
+	tool.Verify.Command.Command = "exit 1"
+	if _, ok := tool.InstalledVersion(); ok {
+		t.Error("InstalledVersion() should report false when the verify command fails")
+	}
+}
+
+func TestUpgradeCommand(t *testing.T) {
+	ctx := NewInstallationContext(&Platform{OS: "linux", PackageManager: "apt"}, nil, nil)
+	for _, manager := range []string{"apt", "pkg", "brew", "pacman", "zypper", "winget", "choco"} {
+		cmd, err := ctx.upgradeCommand(manager, "ripgrep")
+		if err != nil {
+			t.Errorf("upgradeCommand(%s) error = %v", manager, err)
+			continue
+		}
+		if !strings.HasSuffix(cmd, " ripgrep") {
+			t.Errorf("upgradeCommand(%s) = %q, want it to name the package", manager, cmd)
+		}
+	}
+	if _, err := ctx.upgradeCommand("nix", "ripgrep"); err == nil {
+		t.Error("upgradeCommand() should reject unknown package managers")
+	}
+}
+
+func TestInstallCommandWinget(t *testing.T) {
+	ctx := NewInstallationContext(&Platform{OS: "windows", PackageManager: "winget"}, nil, nil)
+	cmd, err := ctx.installCommand("winget", "Python.Python.3.12 Python.Launcher")
+	if err != nil {
+		t.Fatalf("installCommand(winget) error = %v", err)
+	}
+	parts := strings.Split(cmd, " && ")
+	if len(parts) != 2 || !strings.HasSuffix(parts[0], "--id Python.Python.3.12") || !strings.HasSuffix(parts[1], "--id Python.Launcher") {
+		t.Errorf("installCommand(winget) = %q, want one winget install per package", cmd)
+	}
+}
+
+// commandPM is a package manager that builds its own commands, recording the
+// packages it was asked to install
+type commandPM struct {
+	fakePM
+	installs []string
+}
+
+func (c *commandPM) InstallCommand(pkgs string) string {
+	c.installs = append(c.installs, pkgs)
+	return "true"
+}
+func (c *commandPM) UpgradeCommand(pkgs string) string           { return "true" }
+func (c *commandPM) VersionedPackage(pkg, version string) string { return pkg + "==" + version }
+func (c *commandPM) Pinnable() bool                              { return true }
+
+func TestInstallCommandFromPackageManager(t *testing.T) {
+	pm := &commandPM{}
+	platform := &Platform{OS: "linux", PackageManager: "apt", Shell: "bash"}
+	ctx := NewInstallationContext(platform, pm, nil)
+
+	ripgrep := NewTool("ripgrep", CategoryDevelopment)
+	var ran bool
+	for _, step := range ripgrep.GenerateInstallationSteps(platform, ctx, true) {
+		if step.Name != "ripgrep-install-package" {
+			continue
+		}
+		if err := step.Action(ctx); err != nil {
+			t.Fatalf("install step failed: %v", err)
+		}
+		ran = true
+	}
+	if !ran || len(pm.installs) != 1 || pm.installs[0] != "ripgrep" {
+		t.Errorf("Expected the package step to build its command with the package manager, got %v", pm.installs)
+	}
+
+	// Other managers still build their commands from their implementation
+	if cmd, err := ctx.installCommand("brew", "ripgrep"); err != nil || cmd != "brew install ripgrep" {
+		t.Errorf("installCommand(brew) = %q, %v; want brew's own command", cmd, err)
+	}
+}
+
+func TestVersionConstraint_ComparesNumerically(t *testing.T) {
+	vc := &VersionConstraint{MinVersion: "1.9.0"}
+	if err := vc.Validate("1.10.0"); err != nil {
+		t.Errorf("Validate(1.10.0) with minimum 1.9.0 error = %v", err)
+	}
+	if err := vc.Validate("1.8.5"); err == nil {
+		t.Error("Validate(1.8.5) with minimum 1.9.0 should fail")
+	}
+}
@@ -0,0 +1,281 @@
+// Package profile exports what bootstrap-cli manages on a machine as a
+// shareable, versioned profile, and compares a profile with another machine
+// so it can be applied there.
+package profile
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/apply"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+)
+
+// SchemaVersion is the profile format this build writes. Older profiles are
+// upgraded by migrations when they are read; newer ones are refused.
+const SchemaVersion = 1
+
+// Profile is the tools, languages and shell setup of a machine
+type Profile struct {
+	SchemaVersion int    `yaml:"schema_version"`
+	Tools         []Item `yaml:"tools,omitempty"`
+	Languages     []Item `yaml:"languages,omitempty"`
+	Shell         string `yaml:"shell,omitempty"`
+	// Prompt is the prompt style (see shell.PromptStyles)
+	Prompt string `yaml:"prompt,omitempty"`
+	// PluginManager is the shell framework, e.g. oh-my-zsh
+	PluginManager string `yaml:"plugin_manager,omitempty"`
+	// Dotfiles is the git repository the dotfiles were cloned from
+	Dotfiles string `yaml:"dotfiles,omitempty"`
+}
+
+// Item is a tool or language and the version it was exported at
+type Item struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version,omitempty"`
+}
+
+// migration upgrades a profile document from one schema version to the next
+type migration struct {
+	// from is the version the migration applies to; it produces from+1
+	from int
+	// apply rewrites doc, the profile's top-level mapping
+	apply func(doc *yaml.Node) error
+}
+
+// migrations are applied in order to bring profiles up to SchemaVersion
+var migrations []migration
+
+// Export builds a profile from the installed snapshot, the run history and the
+// shell frameworks bootstrap-cli installed
+func Export(installed *manifest.Installed, m *manifest.Manifest, frameworks []string) *Profile {
+	p := &Profile{
+		SchemaVersion: SchemaVersion,
+		Tools:         items(installed.Tools),
+		Languages:     items(installed.Languages),
+	}
+	// The most recent run that set them wins
+	for i := len(m.Runs) - 1; i >= 0; i-- {
+		if p.Shell == "" {
+			p.Shell = m.Runs[i].Shell
+		}
+		if p.Dotfiles == "" {
+			p.Dotfiles = m.Runs[i].DotfilesRepo
+		}
+	}
+	if p.Shell == "" && len(installed.Shells) == 1 {
+		for name := range installed.Shells {
+			p.Shell = name
+		}
+	}
+	if prompts := names(installed.Prompts); len(prompts) > 0 {
+		p.Prompt = prompts[0]
+	}
+	if len(frameworks) > 0 {
+		p.PluginManager = frameworks[0]
+	}
+	return p
+}
+
+// items returns the managed items sorted by name
+func items(managed map[string]manifest.InstalledItem) []Item {
+	var list []Item
+	for _, name := range names(managed) {
+		list = append(list, Item{Name: name, Version: managed[name].Version})
+	}
+	return list
+}
+
+func names(managed map[string]manifest.InstalledItem) []string {
+	list := make([]string, 0, len(managed))
+	for name := range managed {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Write encodes the profile as YAML to w
+func (p *Profile) Write(w io.Writer) error {
+	data, err := yaml.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to encode profile: %w", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// Load reads the profile at path, migrating it to SchemaVersion
+func Load(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid profile %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Parse decodes a profile, migrating it to SchemaVersion
+func Parse(data []byte) (*Profile, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse profile: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("profile is not a mapping")
+	}
+	root := doc.Content[0]
+
+	var header struct {
+		SchemaVersion int `yaml:"schema_version"`
+	}
+	if err := root.Decode(&header); err != nil {
+		return nil, fmt.Errorf("failed to parse schema_version: %w", err)
+	}
+	from := header.SchemaVersion
+	switch {
+	case from == 0:
+		return nil, fmt.Errorf("missing schema_version")
+	case from > SchemaVersion:
+		return nil, fmt.Errorf("schema_version %d is newer than this bootstrap-cli supports (%d); upgrade bootstrap-cli", from, SchemaVersion)
+	}
+	for _, m := range migrations {
+		if m.from < from {
+			continue
+		}
+		if err := m.apply(root); err != nil {
+			return nil, fmt.Errorf("failed to migrate profile from schema_version %d: %w", m.from, err)
+		}
+	}
+
+	var p Profile
+	if err := root.Decode(&p); err != nil {
+		return nil, fmt.Errorf("failed to parse profile: %w", err)
+	}
+	p.SchemaVersion = SchemaVersion
+	return &p, nil
+}
+
+// Spec returns the desired state to plan the profile with. Languages are
+// pinned to their exported version; tools install at the catalog's version,
+// so a different one is only reported.
+func (p *Profile) Spec() *apply.Spec {
+	spec := &apply.Spec{Shell: p.Shell, Prompt: p.Prompt, PluginManager: p.PluginManager, Dotfiles: p.Dotfiles}
+	for _, tool := range p.Tools {
+		spec.Tools = append(spec.Tools, tool.Name)
+	}
+	for _, lang := range p.Languages {
+		if lang.Version != "" {
+			spec.Languages = append(spec.Languages, lang.Name+"@"+lang.Version)
+			continue
+		}
+		spec.Languages = append(spec.Languages, lang.Name)
+	}
+	return spec
+}
+
+// Status is how an item of a profile compares with the machine
+type Status string
+
+const (
+	// StatusInstalled is already present
+	StatusInstalled Status = "already installed"
+	// StatusInstall is missing and will be installed
+	StatusInstall Status = "will install"
+	// StatusMismatch is present at a different version than the profile's
+	StatusMismatch Status = "version mismatch"
+)
+
+// Change is one item of a profile compared with the machine
+type Change struct {
+	Kind   string
+	Name   string
+	Status Status
+	// Want is the profile's version, Have the one installed
+	Want string
+	Have string
+}
+
+// Diff compares the profile with plan, the plan of its Spec on this machine,
+// using installed for the versions already there
+func (p *Profile) Diff(plan *apply.Plan, installed *manifest.Installed) []Change {
+	var changes []Change
+	planned := make(map[string]bool)
+	for _, tool := range plan.Install {
+		planned["tool "+strings.ToLower(tool.Name)] = true
+	}
+	for _, lang := range plan.Languages {
+		planned["language "+strings.ToLower(lang.Name)] = true
+	}
+	compare := func(kind string, item Item, managed map[string]manifest.InstalledItem) {
+		change := Change{Kind: kind, Name: item.Name, Status: StatusInstalled, Want: item.Version}
+		if planned[kind+" "+strings.ToLower(item.Name)] {
+			change.Status = StatusInstall
+		} else if have, ok := managed[item.Name]; ok {
+			change.Have = have.Version
+			if !sameVersion(change.Want, change.Have) {
+				change.Status = StatusMismatch
+			}
+		}
+		changes = append(changes, change)
+	}
+	for _, tool := range p.Tools {
+		compare("tool", tool, installed.Tools)
+	}
+	for _, lang := range p.Languages {
+		compare("language", lang, installed.Languages)
+	}
+
+	setting := func(kind, name string, missing bool) {
+		if name == "" {
+			return
+		}
+		status := StatusInstalled
+		if missing {
+			status = StatusInstall
+		}
+		changes = append(changes, Change{Kind: kind, Name: name, Status: status})
+	}
+	setting("shell", p.Shell, plan.Shell != nil)
+	setting("prompt", p.Prompt, plan.PromptStyle != "")
+	setting("plugin manager", p.PluginManager, plan.PluginManager != nil)
+	setting("dotfiles", p.Dotfiles, plan.Dotfiles != "")
+	return changes
+}
+
+// sameVersion reports whether have satisfies want; a profile version of 1.22
+// matches 1.22.3, and an unknown version on either side matches anything
+func sameVersion(want, have string) bool {
+	want, have = strings.TrimPrefix(want, "v"), strings.TrimPrefix(have, "v")
+	return want == "" || have == "" || want == have || strings.HasPrefix(have, want+".")
+}
+
+// PrintDiff writes changes to w, one line per item
+func PrintDiff(w io.Writer, changes []Change) {
+	for _, c := range changes {
+		mark := "="
+		switch c.Status {
+		case StatusInstall:
+			mark = "+"
+		case StatusMismatch:
+			mark = "~"
+		}
+		name := c.Name
+		if c.Want != "" {
+			name += " " + c.Want
+		}
+		line := fmt.Sprintf("  %s %s %s: %s", mark, c.Kind, name, c.Status)
+		if c.Status == StatusMismatch {
+			line += fmt.Sprintf(" (installed: %s)", c.Have)
+		}
+		fmt.Fprintln(w, line)
+	}
+}
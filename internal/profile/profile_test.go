@@ -0,0 +1,102 @@
+package profile
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/apply"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+)
+
+func testInstalled() *manifest.Installed {
+	installed := manifest.NewInstalled()
+	installed.Tools["ripgrep"] = manifest.InstalledItem{Version: "14.1.0"}
+	installed.Tools["bat"] = manifest.InstalledItem{Version: "0.24.0"}
+	installed.Languages["Go"] = manifest.InstalledItem{Version: "1.22.3"}
+	installed.Prompts["starship"] = manifest.InstalledItem{Path: "/home/me/.config/starship.toml"}
+	return installed
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	m := &manifest.Manifest{Runs: []manifest.Run{
+		{Shell: "bash", DotfilesRepo: "https://github.com/me/dotfiles.git"},
+		{Shell: "zsh"},
+	}}
+	p := Export(testInstalled(), m, []string{"oh-my-zsh"})
+	if p.SchemaVersion != SchemaVersion || p.Shell != "zsh" || p.Prompt != "starship" || p.PluginManager != "oh-my-zsh" {
+		t.Errorf("Unexpected profile: %+v", p)
+	}
+	if p.Dotfiles != "https://github.com/me/dotfiles.git" {
+		t.Errorf("Dotfiles = %q, want the repository of the last run that had one", p.Dotfiles)
+	}
+	if len(p.Tools) != 2 || p.Tools[0] != (Item{Name: "bat", Version: "0.24.0"}) {
+		t.Errorf("Tools = %+v, want them sorted with their versions", p.Tools)
+	}
+
+	var buf bytes.Buffer
+	if err := p.Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "schema_version: 1\n") {
+		t.Errorf("Expected the profile to start with its schema_version, got:\n%s", buf.String())
+	}
+	parsed, err := Parse(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(parsed.Tools) != 2 || parsed.Languages[0].Version != "1.22.3" || parsed.Shell != "zsh" {
+		t.Errorf("Unexpected parsed profile: %+v", parsed)
+	}
+	if spec := parsed.Spec(); spec.Languages[0] != "Go@1.22.3" || spec.Tools[1] != "ripgrep" {
+		t.Errorf("Unexpected spec: %+v", spec)
+	}
+}
+
+func TestParseSchemaVersion(t *testing.T) {
+	if _, err := Parse([]byte("tools: [{name: bat}]\n")); err == nil || !strings.Contains(err.Error(), "missing schema_version") {
+		t.Errorf("Expected a profile without schema_version to be refused, got %v", err)
+	}
+	if _, err := Parse([]byte("schema_version: 99\n")); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("Expected a newer profile to be refused, got %v", err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	p := &Profile{
+		SchemaVersion: SchemaVersion,
+		Tools:         []Item{{Name: "ripgrep", Version: "14.1.0"}, {Name: "bat", Version: "0.23.0"}, {Name: "fzf", Version: "0.54.0"}},
+		Languages:     []Item{{Name: "Go", Version: "1.22"}},
+		Shell:         "zsh",
+		Prompt:        "starship",
+	}
+	plan := &apply.Plan{
+		Install: []*pipeline.Tool{pipeline.NewTool("fzf", pipeline.CategoryDevelopment)},
+		Shell:   &interfaces.Shell{Name: "zsh"},
+	}
+	want := map[string]Status{
+		"ripgrep":  StatusInstalled,
+		"bat":      StatusMismatch,
+		"fzf":      StatusInstall,
+		"Go":       StatusInstalled,
+		"zsh":      StatusInstall,
+		"starship": StatusInstalled,
+	}
+	changes := p.Diff(plan, testInstalled())
+	if len(changes) != len(want) {
+		t.Fatalf("Diff() = %+v, want %d changes", changes, len(want))
+	}
+	for _, c := range changes {
+		if c.Status != want[c.Name] {
+			t.Errorf("%s %s: status = %q, want %q", c.Kind, c.Name, c.Status, want[c.Name])
+		}
+	}
+
+	var buf bytes.Buffer
+	PrintDiff(&buf, changes)
+	if !strings.Contains(buf.String(), "~ tool bat 0.23.0: version mismatch (installed: 0.24.0)") {
+		t.Errorf("Expected the mismatch with the installed version, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,397 @@
+// Package release installs tools from the archives attached to GitHub
+// releases, for tools that are missing or outdated in the distro repositories
+package release
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cache"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+)
+
+// DefaultAPIURL is the GitHub API that "latest" is resolved against
+const DefaultAPIURL = "https://api.github.com"
+
+// Latest is the version that installs the newest release
+const Latest = "latest"
+
+// Spec is the github_release install method of a tool. Asset and Binary are
+// templates: {version} is the release tag without a leading "v", {tag} the tag
+// itself, and {os} and {arch} the platform's GOOS and GOARCH, renamed by
+// OSNames and ArchNames when the project uses other names (e.g. x86_64).
+type Spec struct {
+	// Repo is the GitHub repository, as owner/name
+	Repo string `yaml:"repo"`
+	// Asset is the name of the release asset to download: a .tar.gz, .tgz or
+	// .zip archive, or the binary itself
+	Asset string `yaml:"asset"`
+	// Binary is the path of the executable inside the archive (default: the
+	// tool name, found anywhere in the archive)
+	Binary string `yaml:"binary,omitempty"`
+	// Checksum is the SHA-256 of the asset; it only applies to a pinned version
+	Checksum string `yaml:"checksum,omitempty"`
+	// Checksums is the release asset listing the SHA-256 of the other assets,
+	// e.g. checksums.txt or {asset}.sha256; it verifies a download when no
+	// Checksum is pinned
+	Checksums string `yaml:"checksums,omitempty"`
+	// OSNames and ArchNames map GOOS and GOARCH values to the names in asset names
+	OSNames   map[string]string `yaml:"os_names,omitempty"`
+	ArchNames map[string]string `yaml:"arch_names,omitempty"`
+}
+
+// Validate checks that the spec names a repository and an asset
+func (s *Spec) Validate() error {
+	if owner, name, ok := strings.Cut(s.Repo, "/"); !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return fmt.Errorf("github_release repo %q must be owner/name", s.Repo)
+	}
+	if s.Asset == "" {
+		return fmt.Errorf("github_release for %s needs an asset name", s.Repo)
+	}
+	if s.Checksum != "" && !isHexSHA256(s.Checksum) {
+		return fmt.Errorf("github_release checksum for %s must be 64 hex characters", s.Repo)
+	}
+	return nil
+}
+
+// Expand fills in the placeholders of a template for a release tag and platform
+func (s *Spec) Expand(template, tag, goos, goarch string) string {
+	if name, ok := s.OSNames[goos]; ok {
+		goos = name
+	}
+	if name, ok := s.ArchNames[goarch]; ok {
+		goarch = name
+	}
+	return strings.NewReplacer(
+		"{version}", strings.TrimPrefix(tag, "v"),
+		"{tag}", tag,
+		"{os}", goos,
+		"{arch}", goarch,
+	).Replace(template)
+}
+
+// URL returns the download URL of the asset for a release tag and platform
+func (s *Spec) URL(tag, goos, goarch string) string {
+	return s.assetURL(s.Asset, tag, goos, goarch)
+}
+
+// ChecksumsURL returns the download URL of the checksums asset, or "" when the
+// spec has none. Besides the usual placeholders, {asset} is the asset name.
+func (s *Spec) ChecksumsURL(tag, goos, goarch string) string {
+	if s.Checksums == "" {
+		return ""
+	}
+	asset := s.Expand(s.Asset, tag, goos, goarch)
+	return s.assetURL(strings.ReplaceAll(s.Checksums, "{asset}", asset), tag, goos, goarch)
+}
+
+func (s *Spec) assetURL(template, tag, goos, goarch string) string {
+	return fmt.Sprintf("https://github.com/%s/releases/download/%s/%s", s.Repo, tag, s.Expand(template, tag, goos, goarch))
+}
+
+// Installer downloads release assets and installs their binaries
+type Installer struct {
+	// Downloads fetches assets through the download cache and mirrors
+	Downloads *cache.Cache
+	// APIURL is the GitHub API to resolve "latest" with (default DefaultAPIURL)
+	APIURL string
+	// BinDir is where binaries are installed (default ~/.local/bin), so no
+	// sudo is needed
+	BinDir string
+	// Client makes the API requests (default cache.NewClient())
+	Client *http.Client
+}
+
+// NewInstaller creates an installer using the default download cache
+func NewInstaller() (*Installer, error) {
+	downloads, err := cache.NewDefault()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open download cache: %w", err)
+	}
+	return &Installer{Downloads: downloads}, nil
+}
+
+// LatestTag returns the tag of the newest release of repo
+func (i *Installer) LatestTag(repo string) (string, error) {
+	api := i.APIURL
+	if api == "" {
+		api = DefaultAPIURL
+	}
+	url := fmt.Sprintf("%s/repos/%s/releases/latest", strings.TrimSuffix(api, "/"), repo)
+	if err := cache.CheckURL(url); err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the latest %s release: %w", repo, err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	client := i.Client
+	if client == nil {
+		client = cache.NewClient()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the latest %s release: %w", repo, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to resolve the latest %s release: unexpected status %s", repo, resp.Status)
+	}
+	var latest struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&latest); err != nil || latest.TagName == "" {
+		return "", fmt.Errorf("failed to resolve the latest %s release: no tag in the response", repo)
+	}
+	return latest.TagName, nil
+}
+
+// ResolveTag returns the release tag version ("latest" or a tag) refers to.
+// Offline, "latest" is the tag recorded when the release was downloaded.
+func (i *Installer) ResolveTag(spec *Spec, version string) (string, error) {
+	if version != "" && version != Latest {
+		return version, nil
+	}
+	if cache.Offline() {
+		if tag, ok := i.Downloads.Tag(spec.Repo); ok {
+			return tag, nil
+		}
+		return "", fmt.Errorf("no %s release in the download cache to resolve latest offline; run `bootstrap-cli cache warm` while online", spec.Repo)
+	}
+	tag, err := i.LatestTag(spec.Repo)
+	if err != nil {
+		return "", err
+	}
+	if i.Downloads.Enabled() {
+		if err := i.Downloads.RecordTag(spec.Repo, tag); err != nil {
+			return "", err
+		}
+	}
+	return tag, nil
+}
+
+// Download fetches the asset of spec for version ("latest" or a release tag)
+// and platform into dir, verified against the pinned or published checksum.
+// It returns the tag and the asset's path.
+func (i *Installer) Download(name string, spec *Spec, version, goos, goarch, dir string) (tag, asset string, err error) {
+	if err := spec.Validate(); err != nil {
+		return "", "", err
+	}
+	if tag, err = i.ResolveTag(spec, version); err != nil {
+		return "", "", err
+	}
+	checksum := spec.Checksum
+	if version == "" || version == Latest {
+		// A checksum belongs to one release, not whatever is latest
+		checksum = ""
+	}
+
+	url := spec.URL(tag, goos, goarch)
+	asset = filepath.Join(dir, path.Base(url))
+	if checksum == "" && spec.Checksums != "" {
+		if checksum, err = i.publishedChecksum(spec, tag, goos, goarch, dir); err != nil {
+			return "", "", fmt.Errorf("failed to verify %s %s: %w", name, tag, err)
+		}
+	}
+	if err := i.Downloads.Fetch(url, tag, checksum, asset); err != nil {
+		return "", "", fmt.Errorf("failed to download %s %s: %w", name, tag, err)
+	}
+	return tag, asset, nil
+}
+
+// Install downloads the asset of spec for version ("latest" or a release tag)
+// and platform, and installs the binary as BinDir/name. It returns the tag
+// installed and the binary's path.
+func (i *Installer) Install(name string, spec *Spec, version, goos, goarch string) (tag, binary string, err error) {
+	dir, err := os.MkdirTemp("", "bootstrap-"+name+"-")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tag, asset, err := i.Download(name, spec, version, goos, goarch, dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	want := name
+	if spec.Binary != "" {
+		want = spec.Expand(spec.Binary, tag, goos, goarch)
+	}
+	binary, err = i.BinaryPath(name)
+	if err != nil {
+		return "", "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(binary), 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create %s: %w", filepath.Dir(binary), err)
+	}
+	if err := extract(asset, want, binary); err != nil {
+		return "", "", fmt.Errorf("failed to install %s from %s: %w", name, filepath.Base(asset), err)
+	}
+	return tag, binary, nil
+}
+
+// publishedChecksum downloads the checksums asset of a release and returns the
+// SHA-256 it lists for the asset
+func (i *Installer) publishedChecksum(spec *Spec, tag, goos, goarch, dir string) (string, error) {
+	url := spec.ChecksumsURL(tag, goos, goarch)
+	file := filepath.Join(dir, "checksums-"+path.Base(url))
+	if err := i.Downloads.Fetch(url, tag, "", file); err != nil {
+		return "", fmt.Errorf("failed to download checksums: %w", err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read checksums: %w", err)
+	}
+	asset := path.Base(spec.URL(tag, goos, goarch))
+	sum, ok := cache.LookupChecksum(data, asset)
+	if !ok {
+		return "", fmt.Errorf("%s lists no checksum for %s", path.Base(url), asset)
+	}
+	return sum, nil
+}
+
+// BinaryPath returns where Install puts the binary of the tool called name
+func (i *Installer) BinaryPath(name string) (string, error) {
+	binDir, err := i.binDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(binDir, name), nil
+}
+
+// binDir returns where binaries are installed
+func (i *Installer) binDir() (string, error) {
+	if i.BinDir != "" {
+		return i.BinDir, nil
+	}
+	home, err := system.UserHome()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "bin"), nil
+}
+
+// extract writes the file want from the archive at asset to dest as an
+// executable. want containing a slash is matched as a path in the archive,
+// otherwise by file name; an asset that is not an archive is the binary itself.
+func extract(asset, want, dest string) error {
+	lower := strings.ToLower(asset)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return extractTarGz(asset, want, dest)
+	case strings.HasSuffix(lower, ".zip"):
+		return extractZip(asset, want, dest)
+	default:
+		f, err := os.Open(asset)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return writeBinary(f, dest)
+	}
+}
+
+// matches reports whether an archive entry is the wanted binary
+func matches(entry, want string) bool {
+	entry = strings.TrimPrefix(path.Clean("/"+entry), "/")
+	if strings.Contains(want, "/") {
+		return entry == strings.TrimPrefix(path.Clean("/"+want), "/")
+	}
+	return path.Base(entry) == want
+}
+
+func extractTarGz(asset, want, dest string) error {
+	f, err := os.Open(asset)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return fmt.Errorf("%s not found in the archive", want)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		if hdr.Typeflag == tar.TypeReg && matches(hdr.Name, want) {
+			return writeBinary(tr, dest)
+		}
+	}
+}
+
+func extractZip(asset, want, dest string) error {
+	r, err := zip.OpenReader(asset)
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || !matches(f.Name, want) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		defer rc.Close()
+		return writeBinary(rc, dest)
+	}
+	return fmt.Errorf("%s not found in the archive", want)
+}
+
+// writeBinary writes r to dest through a temporary file, so a running copy of
+// the binary is replaced rather than overwritten
+func writeBinary(r io.Reader, dest string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(dest), "."+filepath.Base(dest)+"-")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dest, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return fmt.Errorf("failed to make %s executable: %w", dest, err)
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return fmt.Errorf("failed to install %s: %w", dest, err)
+	}
+	return nil
+}
+
+func isHexSHA256(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, c := range strings.ToLower(s) {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,187 @@
+package release
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cache"
+)
+
+func tarGz(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, body := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(body)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(body))
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+// newServer serves the GitHub API and release downloads, standing in for
+// github.com through the GitHub mirror setting
+func newServer(t *testing.T, assets map[string][]byte) *Installer {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/dandavison/delta/releases/latest", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"tag_name": "0.17.0"}`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, ok := assets[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(body)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	t.Setenv(cache.AllowHostsEnvVar, "127.0.0.1")
+	t.Setenv(cache.GitHubMirrorEnvVar, srv.URL)
+	t.Setenv(cache.DisableEnvVar, "1")
+	return &Installer{Downloads: cache.New(t.TempDir()), APIURL: srv.URL, BinDir: t.TempDir()}
+}
+
+func TestInstallLatestTarGz(t *testing.T) {
+	installer := newServer(t, map[string][]byte{
+		"/dandavison/delta/releases/download/0.17.0/delta-0.17.0-x86_64-unknown-linux-gnu.tar.gz": tarGz(t, map[string]string{
+			"delta-0.17.0-x86_64-unknown-linux-gnu/README.md": "readme",
+			"delta-0.17.0-x86_64-unknown-linux-gnu/delta":     "#!/bin/sh\necho delta 0.17.0\n",
+		}),
+	})
+	spec := &Spec{
+		Repo:      "dandavison/delta",
+		Asset:     "delta-{version}-{arch}-{os}.tar.gz",
+		OSNames:   map[string]string{"linux": "unknown-linux-gnu"},
+		ArchNames: map[string]string{"amd64": "x86_64"},
+	}
+
+	tag, binary, err := installer.Install("delta", spec, Latest, "linux", "amd64")
+	if err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if tag != "0.17.0" || binary != filepath.Join(installer.BinDir, "delta") {
+		t.Errorf("Install() = %s, %s", tag, binary)
+	}
+	info, err := os.Stat(binary)
+	if err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Errorf("Expected an executable binary, got %v, %v", info, err)
+	}
+	if data, _ := os.ReadFile(binary); !strings.Contains(string(data), "delta 0.17.0") {
+		t.Errorf("Expected the delta binary, got %q", data)
+	}
+}
+
+func TestInstallPinnedZip(t *testing.T) {
+	archive := zipArchive(t, map[string]string{"bin/eza": "eza", "eza.1": "man page"})
+	sum := sha256.Sum256(archive)
+	installer := newServer(t, map[string][]byte{
+		"/eza-community/eza/releases/download/v0.18.0/eza_x86_64-apple-darwin.zip": archive,
+	})
+	spec := &Spec{
+		Repo:      "eza-community/eza",
+		Asset:     "eza_{arch}-{os}.zip",
+		Binary:    "bin/eza",
+		Checksum:  hex.EncodeToString(sum[:]),
+		OSNames:   map[string]string{"darwin": "apple-darwin"},
+		ArchNames: map[string]string{"amd64": "x86_64"},
+	}
+
+	if _, binary, err := installer.Install("eza", spec, "v0.18.0", "darwin", "amd64"); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	} else if data, _ := os.ReadFile(binary); string(data) != "eza" {
+		t.Errorf("Expected bin/eza to be installed, got %q", data)
+	}
+
+	// The mirror's copy fails the checksum, so nothing is installed
+	spec.Checksum = strings.Repeat("0", 64)
+	_, _, err := installer.Install("eza", spec, "v0.18.0", "darwin", "amd64")
+	var mismatch *cache.ChecksumMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Expected != spec.Checksum || mismatch.Actual != hex.EncodeToString(sum[:]) {
+		t.Errorf("Expected a checksum mismatch naming both hashes, got %v", err)
+	}
+}
+
+func TestInstallVerifiesPublishedChecksums(t *testing.T) {
+	archive := tarGz(t, map[string]string{"delta": "delta"})
+	sum := sha256.Sum256(archive)
+	checksums := hex.EncodeToString(sum[:]) + "  delta-0.17.0.tar.gz\n" + strings.Repeat("1", 64) + "  delta-0.17.0.zip\n"
+	assets := map[string][]byte{
+		"/dandavison/delta/releases/download/0.17.0/delta-0.17.0.tar.gz":        archive,
+		"/dandavison/delta/releases/download/0.17.0/checksums.txt":              []byte(checksums),
+		"/dandavison/delta/releases/download/0.17.0/delta-0.17.0.tar.gz.sha256": []byte(strings.Repeat("2", 64) + "\n"),
+	}
+	installer := newServer(t, assets)
+	spec := &Spec{Repo: "dandavison/delta", Asset: "delta-{version}.tar.gz", Checksums: "checksums.txt"}
+
+	if _, _, err := installer.Install("delta", spec, Latest, "linux", "amd64"); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+
+	// The .sha256 asset lists a different hash, so the archive is rejected
+	spec.Checksums = "{asset}.sha256"
+	_, _, err := installer.Install("delta", spec, Latest, "linux", "amd64")
+	var mismatch *cache.ChecksumMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Expected != strings.Repeat("2", 64) {
+		t.Errorf("Expected the published checksum to be enforced, got %v", err)
+	}
+
+	t.Setenv(cache.SkipChecksumsEnvVar, "1")
+	if _, _, err := installer.Install("delta", spec, Latest, "linux", "amd64"); err != nil {
+		t.Errorf("Expected --skip-checksums to accept the archive, got %v", err)
+	}
+}
+
+func TestInstallMissingBinary(t *testing.T) {
+	installer := newServer(t, map[string][]byte{
+		"/dandavison/delta/releases/download/0.17.0/delta.tar.gz": tarGz(t, map[string]string{"LICENSE": "MIT"}),
+	})
+	spec := &Spec{Repo: "dandavison/delta", Asset: "delta.tar.gz"}
+	if _, _, err := installer.Install("delta", spec, Latest, "linux", "amd64"); err == nil || !strings.Contains(err.Error(), "delta not found") {
+		t.Errorf("Expected the missing binary to be reported, got %v", err)
+	}
+}
+
+func TestSpecValidate(t *testing.T) {
+	for _, spec := range []Spec{
+		{Repo: "delta", Asset: "delta.tar.gz"},
+		{Repo: "dandavison/delta/extra", Asset: "delta.tar.gz"},
+		{Repo: "dandavison/delta"},
+		{Repo: "dandavison/delta", Asset: "delta.tar.gz", Checksum: "abc"},
+	} {
+		if err := spec.Validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", spec)
+		}
+	}
+	if err := (&Spec{Repo: "ajeetdsouza/zoxide", Asset: "zoxide-{version}-{arch}-unknown-linux-musl.tar.gz"}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
@@ -0,0 +1,240 @@
+// Package remote provisions another machine over SSH: it uploads the
+// bootstrap-cli binary and a bootstrap.yaml to the target user's account, runs
+// apply there, and reads back the structured result.
+package remote
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/apply"
+)
+
+// Target is the account a remote run installs into
+type Target struct {
+	User string
+	Host string
+	// Port is the SSH port; 0 uses ssh's default or ~/.ssh/config
+	Port int
+}
+
+// ParseTarget parses user@host or user@host:port, with IPv6 hosts in brackets
+// (user@[::1]:2222). The user is required so the install always lands in a
+// deliberate account. A user or host that ssh could read as an option, one
+// starting with "-", is rejected.
+func ParseTarget(s string) (*Target, error) {
+	user, hostPort, ok := strings.Cut(s, "@")
+	if !ok || user == "" || hostPort == "" {
+		return nil, fmt.Errorf("invalid target %q: expected user@host[:port]", s)
+	}
+	t := &Target{User: user, Host: hostPort}
+	port, hasPort := "", false
+	if strings.HasPrefix(hostPort, "[") {
+		host, rest, ok := strings.Cut(hostPort[1:], "]")
+		if !ok || host == "" || (rest != "" && !strings.HasPrefix(rest, ":")) {
+			return nil, fmt.Errorf("invalid target %q: expected user@[ipv6-host][:port]", s)
+		}
+		t.Host = host
+		port, hasPort = strings.CutPrefix(rest, ":")
+	} else {
+		t.Host, port, hasPort = strings.Cut(hostPort, ":")
+	}
+	if hasPort {
+		n, err := strconv.Atoi(port)
+		if err != nil || n <= 0 || n > 65535 || t.Host == "" {
+			return nil, fmt.Errorf("invalid target %q: bad port %q", s, port)
+		}
+		t.Port = n
+	}
+	for _, part := range []string{t.User, t.Host} {
+		if strings.HasPrefix(part, "-") || strings.ContainsFunc(part, unicode.IsSpace) {
+			return nil, fmt.Errorf("invalid target %q: %q is not a valid user or host", s, part)
+		}
+	}
+	return t, nil
+}
+
+// String renders the target as user@host[:port]
+func (t *Target) String() string {
+	if t.Port != 0 {
+		return fmt.Sprintf("%s@%s:%d", t.User, t.bracketedHost(), t.Port)
+	}
+	return t.User + "@" + t.bracketedHost()
+}
+
+// bracketedHost returns the host with an IPv6 address in brackets, as scp and
+// user@host:port need it
+func (t *Target) bracketedHost() string {
+	if strings.Contains(t.Host, ":") {
+		return "[" + t.Host + "]"
+	}
+	return t.Host
+}
+
+// Provisioner runs apply on a Target
+type Provisioner struct {
+	Target *Target
+	// Binary is the bootstrap-cli executable uploaded to the target; empty uses
+	// the running executable, which must match the target's OS and architecture
+	Binary string
+	// Args are extra flags passed to the remote apply, e.g. --dry-run
+	Args []string
+	// Output receives the remote run's progress output
+	Output io.Writer
+	// Run runs a local command (ssh or scp) with its output going to stdout.
+	// Defaults to exec.
+	Run func(stdout io.Writer, name string, args ...string) error
+}
+
+// Apply uploads the binary and spec to a temporary directory on the target,
+// runs apply there and returns the result it reports. The directory is removed
+// afterwards. A remote run that fails still returns its result when it wrote one.
+func (p *Provisioner) Apply(specPath string) (*apply.Result, error) {
+	binary := p.Binary
+	if binary == "" {
+		if err := p.checkPlatform(); err != nil {
+			return nil, err
+		}
+		exe, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate the bootstrap-cli binary: %w", err)
+		}
+		binary = exe
+	}
+
+	dir, err := p.output("mktemp", "-d")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a working directory on %s: %w", p.Target, err)
+	}
+	defer p.ssh(io.Discard, "rm", "-rf", dir)
+
+	remoteBinary := path.Join(dir, "bootstrap-cli")
+	remoteSpec := path.Join(dir, apply.DefaultSpecFile)
+	remoteResult := path.Join(dir, "result.json")
+	if err := p.scp(binary, remoteBinary); err != nil {
+		return nil, err
+	}
+	if err := p.scp(specPath, remoteSpec); err != nil {
+		return nil, err
+	}
+
+	if err := p.ssh(io.Discard, "chmod", "+x", remoteBinary); err != nil {
+		return nil, fmt.Errorf("failed to make the binary executable on %s: %w", p.Target, err)
+	}
+
+	args := append([]string{remoteBinary, "apply", "--file", remoteSpec, "--result-file", remoteResult, "--yes"}, p.Args...)
+	runErr := p.ssh(p.out(), args...)
+
+	data, err := p.output("cat", remoteResult)
+	if err != nil {
+		if runErr != nil {
+			return nil, fmt.Errorf("remote apply on %s failed: %w", p.Target, runErr)
+		}
+		return nil, fmt.Errorf("failed to read the result from %s: %w", p.Target, err)
+	}
+	result, err := apply.ParseResult([]byte(data))
+	if err != nil {
+		return nil, err
+	}
+	result.Host = p.Target.String()
+	return result, nil
+}
+
+// checkPlatform fails when the target cannot run this executable
+func (p *Provisioner) checkPlatform() error {
+	uname, err := p.output("uname", "-sm")
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", p.Target, err)
+	}
+	goos, goarch := unamePlatform(uname)
+	if goos != runtime.GOOS || goarch != runtime.GOARCH {
+		return fmt.Errorf("%s is %s/%s but this binary is built for %s/%s; pass --remote-binary with a build for the target",
+			p.Target, goos, goarch, runtime.GOOS, runtime.GOARCH)
+	}
+	return nil
+}
+
+// unamePlatform maps `uname -sm` output to GOOS and GOARCH
+func unamePlatform(uname string) (string, string) {
+	fields := strings.Fields(uname)
+	if len(fields) < 2 {
+		return "", ""
+	}
+	goos := strings.ToLower(fields[0])
+	arches := map[string]string{"x86_64": "amd64", "amd64": "amd64", "aarch64": "arm64", "arm64": "arm64", "i686": "386", "i386": "386", "armv7l": "arm", "armv6l": "arm"}
+	goarch, ok := arches[fields[1]]
+	if !ok {
+		goarch = fields[1]
+	}
+	return goos, goarch
+}
+
+// ssh runs a command on the target. Arguments are quoted so the remote shell
+// sees the words as given.
+func (p *Provisioner) ssh(stdout io.Writer, command ...string) error {
+	args := []string{"-o", "BatchMode=yes"}
+	if p.Target.Port != 0 {
+		args = append(args, "-p", strconv.Itoa(p.Target.Port))
+	}
+	words := make([]string, len(command))
+	for i, word := range command {
+		words[i] = shellQuote(word)
+	}
+	// "--" keeps the destination from being read as an option
+	args = append(args, "--", p.Target.User+"@"+p.Target.Host, strings.Join(words, " "))
+	return p.run(stdout, "ssh", args...)
+}
+
+// output runs a command on the target and returns its trimmed stdout
+func (p *Provisioner) output(command ...string) (string, error) {
+	var out strings.Builder
+	if err := p.ssh(&out, command...); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// scp copies a local file to dest on the target
+func (p *Provisioner) scp(local, dest string) error {
+	args := []string{"-q", "-o", "BatchMode=yes"}
+	if p.Target.Port != 0 {
+		args = append(args, "-P", strconv.Itoa(p.Target.Port))
+	}
+	args = append(args, "--", local, p.Target.User+"@"+p.Target.bracketedHost()+":"+dest)
+	if err := p.run(io.Discard, "scp", args...); err != nil {
+		return fmt.Errorf("failed to upload %s to %s: %w", local, p.Target, err)
+	}
+	return nil
+}
+
+func (p *Provisioner) run(stdout io.Writer, name string, args ...string) error {
+	if p.Run != nil {
+		return p.Run(stdout, name, args...)
+	}
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = p.out()
+	return cmd.Run()
+}
+
+func (p *Provisioner) out() io.Writer {
+	if p.Output == nil {
+		return os.Stderr
+	}
+	return p.Output
+}
+
+// shellQuote quotes s for a POSIX shell, leaving plain words bare
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-./:=+,@%") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
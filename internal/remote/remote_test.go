@@ -0,0 +1,191 @@
+package remote
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := map[string]*Target{
+		"dev@box":           {User: "dev", Host: "box"},
+		"dev@10.0.0.5:2222": {User: "dev", Host: "10.0.0.5", Port: 2222},
+		"dev@[::1]":         {User: "dev", Host: "::1"},
+		"dev@[fe80::1]:22":  {User: "dev", Host: "fe80::1", Port: 22},
+	}
+	for in, want := range tests {
+		got, err := ParseTarget(in)
+		if err != nil {
+			t.Fatalf("ParseTarget(%q) error = %v", in, err)
+		}
+		if *got != *want || got.String() != in {
+			t.Errorf("ParseTarget(%q) = %+v, want %+v", in, got, want)
+		}
+	}
+	for _, in := range []string{"box", "@box", "dev@", "dev@box:ssh", "dev@box:0", "-oProxyCommand=x@box", "dev@-oProxyCommand=x", "dev@[::1", "dev@[::1]x", "dev@[]:22", "dev box@host"} {
+		if _, err := ParseTarget(in); err == nil {
+			t.Errorf("ParseTarget(%q) expected an error", in)
+		}
+	}
+}
+
+func TestProvisionerIPv6Destination(t *testing.T) {
+	f := &fakeSSH{}
+	p := &Provisioner{Target: &Target{User: "dev", Host: "::1"}, Output: io.Discard, Run: f.run}
+	if err := p.scp("/tmp/bootstrap.yaml", "/tmp/x/bootstrap.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ssh(io.Discard, "true"); err != nil {
+		t.Fatal(err)
+	}
+	// scp needs the address in brackets to tell it from the path; ssh takes it bare
+	for _, want := range []string{
+		"scp -q -o BatchMode=yes -- /tmp/bootstrap.yaml dev@[::1]:/tmp/x/bootstrap.yaml",
+		"ssh -o BatchMode=yes -- dev@::1 true",
+	} {
+		if !f.ran(want) {
+			t.Errorf("Expected %q, ran %v", want, f.commands)
+		}
+	}
+}
+
+func TestUnamePlatform(t *testing.T) {
+	tests := map[string][2]string{
+		"Linux x86_64":  {"linux", "amd64"},
+		"Darwin arm64":  {"darwin", "arm64"},
+		"Linux aarch64": {"linux", "arm64"},
+		"":              {"", ""},
+	}
+	for uname, want := range tests {
+		if goos, goarch := unamePlatform(uname); goos != want[0] || goarch != want[1] {
+			t.Errorf("unamePlatform(%q) = %s/%s, want %s/%s", uname, goos, goarch, want[0], want[1])
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{"/tmp/x.1": "/tmp/x.1", "a b": "'a b'", "it's": `'it'\''s'`, "": "''"}
+	for in, want := range tests {
+		if got := shellQuote(in); got != want {
+			t.Errorf("shellQuote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+// fakeSSH records commands and answers the ones Apply runs on the target
+type fakeSSH struct {
+	uname    string
+	result   string
+	applyErr error
+	commands []string
+}
+
+func (f *fakeSSH) run(stdout io.Writer, name string, args ...string) error {
+	line := name + " " + strings.Join(args, " ")
+	f.commands = append(f.commands, line)
+	if name != "ssh" {
+		return nil
+	}
+	remote := args[len(args)-1]
+	switch {
+	case remote == "uname -sm":
+		io.WriteString(stdout, f.uname+"\n")
+	case remote == "mktemp -d":
+		io.WriteString(stdout, "/tmp/tmp.abc\n")
+	case strings.HasPrefix(remote, "cat "):
+		if f.result == "" {
+			return errors.New("no such file")
+		}
+		io.WriteString(stdout, f.result)
+	case strings.Contains(remote, " apply "):
+		return f.applyErr
+	}
+	return nil
+}
+
+func (f *fakeSSH) ran(prefix string) bool {
+	for _, c := range f.commands {
+		if strings.HasPrefix(c, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func newTestProvisioner(t *testing.T, f *fakeSSH) (*Provisioner, string) {
+	spec := filepath.Join(t.TempDir(), "bootstrap.yaml")
+	if err := os.WriteFile(spec, []byte("tools: [git]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	target := &Target{User: "dev", Host: "box", Port: 2222}
+	return &Provisioner{Target: target, Args: []string{"--dry-run"}, Output: io.Discard, Run: f.run}, spec
+}
+
+func TestProvisionerApply(t *testing.T) {
+	f := &fakeSSH{
+		uname:  "Linux x86_64",
+		result: `{"cli_version":"1.2.0","groups":[{"group":"Modern","succeeded":["git"]}]}`,
+	}
+	if runtime.GOOS != "linux" || runtime.GOARCH != "amd64" {
+		f.uname = runtime.GOOS + " " + runtime.GOARCH
+	}
+	p, spec := newTestProvisioner(t, f)
+
+	result, err := p.Apply(spec)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if result.Host != "dev@box:2222" || len(result.Groups) != 1 || result.Groups[0].Succeeded[0] != "git" {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	for _, want := range []string{
+		"scp -q -o BatchMode=yes -P 2222 -- " + spec + " dev@box:/tmp/tmp.abc/bootstrap.yaml",
+		"ssh -o BatchMode=yes -p 2222 -- dev@box /tmp/tmp.abc/bootstrap-cli apply --file /tmp/tmp.abc/bootstrap.yaml --result-file /tmp/tmp.abc/result.json --yes --dry-run",
+		"ssh -o BatchMode=yes -p 2222 -- dev@box rm -rf /tmp/tmp.abc",
+	} {
+		if !f.ran(want) {
+			t.Errorf("Expected %q, ran %v", want, f.commands)
+		}
+	}
+}
+
+func TestProvisionerApply_PlatformMismatch(t *testing.T) {
+	f := &fakeSSH{uname: "Plan9 mips"}
+	p, spec := newTestProvisioner(t, f)
+
+	if _, err := p.Apply(spec); err == nil || !strings.Contains(err.Error(), "--remote-binary") {
+		t.Fatalf("Expected a platform mismatch error, got %v", err)
+	}
+	if f.ran("scp") {
+		t.Error("Expected nothing to be uploaded")
+	}
+
+	// An explicit binary skips the check
+	p.Binary = "/builds/bootstrap-cli-plan9"
+	f.result = `{"error":"boom"}`
+	f.applyErr = errors.New("exit status 1")
+	result, err := p.Apply(spec)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if !result.Failed() || result.Error != "boom" {
+		t.Errorf("Expected the remote failure in the result, got %+v", result)
+	}
+}
+
+func TestProvisionerApply_NoResult(t *testing.T) {
+	f := &fakeSSH{applyErr: errors.New("exit status 255")}
+	p, spec := newTestProvisioner(t, f)
+	p.Binary = "/builds/bootstrap-cli"
+
+	if _, err := p.Apply(spec); err == nil || !strings.Contains(err.Error(), "exit status 255") {
+		t.Fatalf("Expected the remote apply error, got %v", err)
+	}
+	if !f.ran("ssh -o BatchMode=yes -p 2222 -- dev@box rm -rf /tmp/tmp.abc") {
+		t.Error("Expected the working directory to be removed")
+	}
+}
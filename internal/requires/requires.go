@@ -0,0 +1,187 @@
+// Package requires orders selected items so the ones they require come first,
+// pulling in required items that were not selected and reporting cycles. It is
+// shared by tools, prompts and shell frameworks, which name their requirements
+// with a `requires` list.
+package requires
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AlternativeSep separates alternatives in a requirement: "zinit|oh-my-zsh" is
+// satisfied by either, and pulls in the first when neither is selected
+const AlternativeSep = "|"
+
+// Lookup returns the requirements of the named item and whether it is known
+type Lookup func(name string) ([]string, bool)
+
+// Resolution is the install order of a selection and its requirements
+type Resolution struct {
+	// Order lists every item, each after the items it requires
+	Order []string
+	// RequiredBy names, for each item pulled in, the item that first required it
+	RequiredBy map[string]string
+}
+
+// Added returns the items pulled in for a requirement, in Order
+func (r *Resolution) Added() []string {
+	var added []string
+	for _, name := range r.Order {
+		if _, ok := r.RequiredBy[name]; ok {
+			added = append(added, name)
+		}
+	}
+	return added
+}
+
+// Label renders an item with what pulled it in, e.g. "fd (required by fzf)"
+func (r *Resolution) Label(name string) string {
+	if by, ok := r.RequiredBy[name]; ok {
+		return fmt.Sprintf("%s (required by %s)", name, by)
+	}
+	return name
+}
+
+// CycleError reports items that require each other
+type CycleError struct {
+	// Cycle is the path around the cycle, starting and ending with the same item
+	Cycle []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("requires cycle: %s", strings.Join(e.Cycle, " -> "))
+}
+
+// UnknownError reports a requirement that names no known item
+type UnknownError struct {
+	Item        string
+	Requirement string
+}
+
+func (e *UnknownError) Error() string {
+	return fmt.Sprintf("%s requires unknown %s", e.Item, strings.ReplaceAll(e.Requirement, AlternativeSep, " or "))
+}
+
+// Alternatives splits a requirement into the names that satisfy it
+func Alternatives(requirement string) []string {
+	var names []string
+	for _, name := range strings.Split(requirement, AlternativeSep) {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Resolve orders selected and everything they require, requirements first and
+// otherwise in selection order. A requirement already met by a selected item,
+// or by one pulled in earlier, is not pulled in again. Selected items lookup
+// does not know are kept, without requirements.
+func Resolve(selected []string, lookup Lookup) (*Resolution, error) {
+	res := &Resolution{RequiredBy: make(map[string]string)}
+	chosen := make(map[string]bool, len(selected))
+	for _, name := range selected {
+		chosen[name] = true
+	}
+	done := make(map[string]bool)
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		if done[name] {
+			return nil
+		}
+		for i, p := range path {
+			if p == name {
+				return &CycleError{Cycle: append(append([]string(nil), path[i:]...), name)}
+			}
+		}
+		path = append(path, name)
+		reqs, _ := lookup(name)
+		for _, req := range reqs {
+			dep, err := satisfy(name, req, chosen, lookup)
+			if err != nil {
+				return err
+			}
+			if !chosen[dep] {
+				chosen[dep] = true
+				res.RequiredBy[dep] = name
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		done[name] = true
+		res.Order = append(res.Order, name)
+		return nil
+	}
+
+	for _, name := range selected {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// satisfy picks the item that meets req of item: an alternative already chosen,
+// or else the first known one
+func satisfy(item, req string, chosen map[string]bool, lookup Lookup) (string, error) {
+	alternatives := Alternatives(req)
+	for _, name := range alternatives {
+		if chosen[name] {
+			return name, nil
+		}
+	}
+	for _, name := range alternatives {
+		if _, ok := lookup(name); ok {
+			return name, nil
+		}
+	}
+	return "", &UnknownError{Item: item, Requirement: req}
+}
+
+// Check reports the problems in the requirements of every item in names:
+// requirements naming no known item, items requiring themselves and cycles,
+// each cycle once
+func Check(names []string, lookup Lookup) []error {
+	var errs []error
+	cycles := make(map[string]bool)
+	for _, name := range names {
+		reqs, _ := lookup(name)
+		for _, req := range reqs {
+			known := false
+			for _, alt := range Alternatives(req) {
+				if alt == name {
+					errs = append(errs, fmt.Errorf("%s requires itself", name))
+				}
+				_, ok := lookup(alt)
+				known = known || ok
+			}
+			if !known {
+				errs = append(errs, &UnknownError{Item: name, Requirement: req})
+			}
+		}
+		// Unknown requirements further down are reported for their own item
+		_, err := Resolve([]string{name}, lookup)
+		if cycle, ok := err.(*CycleError); ok && len(cycle.Cycle) > 2 && !cycles[cycleKey(cycle.Cycle)] {
+			cycles[cycleKey(cycle.Cycle)] = true
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// cycleKey names a cycle independently of the item it starts from
+func cycleKey(cycle []string) string {
+	ring := cycle[:len(cycle)-1]
+	start := 0
+	for i, name := range ring {
+		if name < ring[start] {
+			start = i
+		}
+	}
+	return strings.Join(append(append([]string(nil), ring[start:]...), ring[:start]...), " ")
+}
@@ -0,0 +1,94 @@
+package requires
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func lookupIn(graph map[string][]string) Lookup {
+	return func(name string) ([]string, bool) {
+		reqs, ok := graph[name]
+		return reqs, ok
+	}
+}
+
+func TestResolve(t *testing.T) {
+	graph := map[string][]string{
+		"fzf":           {"fd"},
+		"fd":            nil,
+		"bat":           nil,
+		"powerlevel10k": {"zinit|oh-my-zsh"},
+		"zinit":         nil,
+		"oh-my-zsh":     {"zsh"},
+		"zsh":           nil,
+	}
+
+	res, err := Resolve([]string{"bat", "fzf"}, lookupIn(graph))
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if want := []string{"bat", "fd", "fzf"}; !reflect.DeepEqual(res.Order, want) {
+		t.Errorf("Expected order %v, got %v", want, res.Order)
+	}
+	if got := res.Label("fd"); got != "fd (required by fzf)" {
+		t.Errorf("Expected fd to be labelled as required by fzf, got %q", got)
+	}
+	if got := res.Label("bat"); got != "bat" {
+		t.Errorf("Expected a selected item to keep its name, got %q", got)
+	}
+
+	// A selected alternative meets the requirement, even selected later
+	res, err = Resolve([]string{"powerlevel10k", "oh-my-zsh"}, lookupIn(graph))
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if want := []string{"zsh", "oh-my-zsh", "powerlevel10k"}; !reflect.DeepEqual(res.Order, want) {
+		t.Errorf("Expected order %v, got %v", want, res.Order)
+	}
+	if want := []string{"zsh"}; !reflect.DeepEqual(res.Added(), want) {
+		t.Errorf("Expected only zsh to be pulled in, got %v", res.Added())
+	}
+
+	// Otherwise the first alternative is pulled in
+	res, err = Resolve([]string{"powerlevel10k"}, lookupIn(graph))
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if want := []string{"zinit", "powerlevel10k"}; !reflect.DeepEqual(res.Order, want) {
+		t.Errorf("Expected order %v, got %v", want, res.Order)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	graph := map[string][]string{
+		"a":    {"b"},
+		"b":    {"c"},
+		"c":    {"a"},
+		"yarn": {"node"},
+	}
+
+	_, err := Resolve([]string{"a"}, lookupIn(graph))
+	var cycle *CycleError
+	if !errors.As(err, &cycle) {
+		t.Fatalf("Expected a cycle error, got %v", err)
+	}
+	if got := err.Error(); got != "requires cycle: a -> b -> c -> a" {
+		t.Errorf("Unexpected cycle error: %s", got)
+	}
+
+	_, err = Resolve([]string{"yarn"}, lookupIn(graph))
+	var unknown *UnknownError
+	if !errors.As(err, &unknown) || unknown.Requirement != "node" {
+		t.Fatalf("Expected an unknown requirement error, got %v", err)
+	}
+
+	errs := Check([]string{"a", "b", "c", "yarn"}, lookupIn(graph))
+	if len(errs) != 2 {
+		t.Fatalf("Expected the cycle once and the unknown requirement, got %v", errs)
+	}
+	if !strings.Contains(errs[0].Error(), "cycle") || errs[1].Error() != "yarn requires unknown node" {
+		t.Errorf("Unexpected problems: %v", errs)
+	}
+}
@@ -0,0 +1,33 @@
+package shell
+
+import "fmt"
+
+// AsdfBlock names the managed block that loads asdf in shell rc files
+const AsdfBlock = "asdf"
+
+// asdfInit returns the code that loads asdf cloned into ~/.asdf for shellName:
+// asdf's own init script for bash, zsh and fish, and its bin and shims
+// directories on PATH for nu and pwsh, which it has no init script for
+func asdfInit(shellName string) string {
+	switch shellName {
+	case "fish":
+		return "source ~/.asdf/asdf.fish"
+	case "nu":
+		return "$env.PATH = ($env.PATH | split row (char esep) | prepend ($env.HOME | path join '.asdf' 'bin') | prepend ($env.HOME | path join '.asdf' 'shims'))"
+	case "pwsh":
+		return `$env:PATH = "$HOME/.asdf/shims" + [IO.Path]::PathSeparator + "$HOME/.asdf/bin" + [IO.Path]::PathSeparator + $env:PATH`
+	default:
+		return `. "$HOME/.asdf/asdf.sh"`
+	}
+}
+
+// EnsureAsdfInit loads asdf from a managed block in the rc file of shellName
+// under home
+func EnsureAsdfInit(home, shellName string, rc *RCWriter) error {
+	rcFile := rcFileForShell(home, shellName)
+	if rcFile == "" {
+		return fmt.Errorf("unsupported shell for asdf: %s", shellName)
+	}
+	_, err := rc.UpsertBlock(rcFile, AsdfBlock, asdfInit(shellName))
+	return err
+}
@@ -0,0 +1,191 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+)
+
+// BackupDirName holds the copies of rc files taken before bootstrap-cli modifies
+// them, under ~/.bootstrap-cli
+const BackupDirName = "backups"
+
+// DefaultBackupKeep is how many backups of each rc file are kept
+const DefaultBackupKeep = 10
+
+// backupTimeFormat stamps backup file names, e.g. .zshrc.20240102-150405
+const backupTimeFormat = "20060102-150405"
+
+// Backups copies rc files aside before their first modification in a run, as
+// <filename>.<timestamp>, keeping the newest Keep copies of each file
+type Backups struct {
+	// Dir holds the backups (default ~/.bootstrap-cli/backups)
+	Dir string
+	// Keep is how many backups of each file are kept (default DefaultBackupKeep)
+	Keep int
+
+	mu sync.Mutex
+	// taken records the files backed up in this run
+	taken map[string]bool
+}
+
+// Backup is one saved copy of an rc file
+type Backup struct {
+	// Name is the base name of the rc file, e.g. .zshrc
+	Name string
+	// Path is where the copy is kept
+	Path string
+	Time time.Time
+}
+
+// defaultBackups is shared by every rc writer of the process, so each rc file is
+// backed up once per run however many writers edit it
+var defaultBackups = &Backups{}
+
+// DefaultBackups returns the backups of this run under ~/.bootstrap-cli/backups
+func DefaultBackups() *Backups {
+	return defaultBackups
+}
+
+// dir returns where backups are kept
+func (b *Backups) dir() (string, error) {
+	if b.Dir != "" {
+		return b.Dir, nil
+	}
+	state, err := manifest.StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(state, BackupDirName), nil
+}
+
+// Backup copies the rc file at path aside unless it was already backed up in
+// this run. A missing file has nothing to back up. The copy is written to a
+// temporary file and renamed, so a backup is never half-written.
+func (b *Backups) Backup(path string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.taken[path] {
+		return nil
+	}
+	if _, err := b.save(path); err != nil {
+		return err
+	}
+	if b.taken == nil {
+		b.taken = make(map[string]bool)
+	}
+	b.taken[path] = true
+	return nil
+}
+
+// save copies the file at path into the backup directory and prunes its old
+// backups; it returns "" when there is no file to copy
+func (b *Backups) save(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s for backup: %w", path, err)
+	}
+	dir, err := b.dir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	name := filepath.Base(path)
+	backup := filepath.Join(dir, name+"."+time.Now().Format(backupTimeFormat))
+	tmp := backup + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, backup); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	return backup, b.prune(name)
+}
+
+// prune removes all but the newest Keep backups of the file called name
+func (b *Backups) prune(name string) error {
+	backups, err := b.List(name)
+	if err != nil {
+		return err
+	}
+	keep := b.Keep
+	if keep <= 0 {
+		keep = DefaultBackupKeep
+	}
+	for _, old := range backups[min(keep, len(backups)):] {
+		if err := os.Remove(old.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove old backup %s: %w", old.Path, err)
+		}
+	}
+	return nil
+}
+
+// List returns the backups of the rc file called name, or of every file when
+// name is empty, newest first
+func (b *Backups) List(name string) ([]Backup, error) {
+	dir, err := b.dir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	var backups []Backup
+	for _, entry := range entries {
+		i := strings.LastIndex(entry.Name(), ".")
+		if entry.IsDir() || i <= 0 {
+			continue
+		}
+		at, err := time.ParseInLocation(backupTimeFormat, entry.Name()[i+1:], time.Local)
+		if err != nil {
+			continue
+		}
+		file := entry.Name()[:i]
+		if name != "" && file != name {
+			continue
+		}
+		backups = append(backups, Backup{Name: file, Path: filepath.Join(dir, entry.Name()), Time: at})
+	}
+	sort.SliceStable(backups, func(i, j int) bool {
+		if !backups[i].Time.Equal(backups[j].Time) {
+			return backups[i].Time.After(backups[j].Time)
+		}
+		return backups[i].Name < backups[j].Name
+	})
+	return backups, nil
+}
+
+// Restore replaces the rc file at target with backup. The current file is backed
+// up first, so the restore can itself be undone.
+func (b *Backups) Restore(backup Backup, target string) error {
+	data, err := os.ReadFile(backup.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read backup %s: %w", backup.Path, err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, err := b.save(target); err != nil {
+		return err
+	}
+	return writeAtomic(target, string(data))
+}
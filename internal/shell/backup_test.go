@@ -0,0 +1,86 @@
+package shell
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRCWriterBacksUpOncePerRun(t *testing.T) {
+	home := t.TempDir()
+	zshrc := filepath.Join(home, ".zshrc")
+	if err := os.WriteFile(zshrc, []byte("export EDITOR=vim\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	backups := &Backups{Dir: filepath.Join(home, ".bootstrap-cli", BackupDirName)}
+
+	// A dry run writes nothing, backups included
+	w := &RCWriter{DryRun: true, Out: io.Discard, Backups: backups}
+	if _, err := w.UpsertBlock(zshrc, "fd", "alias find=fd"); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := backups.List(""); len(list) != 0 {
+		t.Fatalf("Expected no backup on a dry run, got %v", list)
+	}
+
+	w = &RCWriter{Backups: backups}
+	for _, block := range []string{"fd", "bat"} {
+		if _, err := w.UpsertBlock(zshrc, block, "echo "+block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A file created by the run has nothing to back up
+	if _, err := w.UpsertBlock(filepath.Join(home, ".bashrc"), "fd", "alias find=fd"); err != nil {
+		t.Fatal(err)
+	}
+	list, err := backups.List("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Name != ".zshrc" {
+		t.Fatalf("Expected one backup of .zshrc, got %v", list)
+	}
+	if data, _ := os.ReadFile(list[0].Path); string(data) != "export EDITOR=vim\n" {
+		t.Errorf("Expected the backup to hold the file before the run, got %q", data)
+	}
+
+	// Restoring puts the backup back and keeps the current file as a new backup
+	if err := backups.Restore(list[0], zshrc); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if data, _ := os.ReadFile(zshrc); string(data) != "export EDITOR=vim\n" {
+		t.Errorf("Expected the backup restored, got %q", data)
+	}
+}
+
+func TestBackupsKeepNewest(t *testing.T) {
+	dir := t.TempDir()
+	backups := &Backups{Dir: dir, Keep: 2}
+	start := time.Date(2024, 1, 2, 15, 4, 5, 0, time.Local)
+	for i := 0; i < 3; i++ {
+		name := ".bashrc." + start.Add(time.Duration(i)*time.Minute).Format(backupTimeFormat)
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	bashrc := filepath.Join(t.TempDir(), ".bashrc")
+	if err := os.WriteFile(bashrc, []byte("set -o vi\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := backups.Backup(bashrc); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+	list, err := backups.List(".bashrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || !list[0].Time.After(list[1].Time) || !list[1].Time.Equal(start.Add(2*time.Minute)) {
+		t.Errorf("Expected the new backup and the newest old one, got %v", list)
+	}
+}
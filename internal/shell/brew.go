@@ -0,0 +1,38 @@
+package shell
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// BrewBlock names the managed block that puts Homebrew on PATH in shell rc files
+const BrewBlock = "brew"
+
+// brewShellenv returns the code that sets up the Homebrew executable at path
+// for shellName: PATH, MANPATH and the HOMEBREW_* variables
+func brewShellenv(shellName, path string) string {
+	prefix := filepath.Dir(filepath.Dir(path))
+	switch shellName {
+	case "fish":
+		return fmt.Sprintf("%s shellenv fish | source", fishWord(path))
+	case "nu":
+		// brew shellenv has no nushell output, so PATH is set directly
+		return fmt.Sprintf(`$env.PATH = ($env.PATH | split row (char esep) | prepend [%s %s])`,
+			NuQuote(filepath.Join(prefix, "bin")), NuQuote(filepath.Join(prefix, "sbin")))
+	case "pwsh":
+		return fmt.Sprintf("(& %s shellenv pwsh) | Out-String | Invoke-Expression", PSQuote(path))
+	default:
+		return fmt.Sprintf(`eval "$(%s shellenv)"`, shWord(path))
+	}
+}
+
+// EnsureBrewShellenv loads the environment of the Homebrew executable at path
+// from a managed block in the rc file of shellName under home
+func EnsureBrewShellenv(home, shellName, path string, rc *RCWriter) error {
+	rcFile := rcFileForShell(home, shellName)
+	if rcFile == "" {
+		return fmt.Errorf("unsupported shell for Homebrew: %s", shellName)
+	}
+	_, err := rc.UpsertBlock(rcFile, BrewBlock, brewShellenv(shellName, path))
+	return err
+}
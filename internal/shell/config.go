@@ -5,15 +5,17 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
 	"github.com/spf13/viper"
 )
 
 // Config represents shell configuration
 type Config struct {
-	// Shell type (bash, zsh, fish)
+	// Shell type (bash, zsh, fish, nu, pwsh)
 	Shell string
 	// Environment variables to set
 	EnvVars map[string]string
@@ -61,7 +63,10 @@ func (c *Config) AddPath(path string) {
 
 // GetConfigFile returns the path to the shell's config file
 func (c *Config) GetConfigFile() string {
-	home := os.Getenv("HOME")
+	home, err := system.UserHome()
+	if err != nil {
+		return ""
+	}
 	switch c.Shell {
 	case "bash":
 		return filepath.Join(home, ".bashrc")
@@ -69,6 +74,10 @@ func (c *Config) GetConfigFile() string {
 		return filepath.Join(home, ".zshrc")
 	case "fish":
 		return filepath.Join(home, ".config", "fish", "config.fish")
+	case "nu":
+		return filepath.Join(home, ".config", "nushell", "config.nu")
+	case "pwsh":
+		return PowerShellProfile(home, runtime.GOOS, exec.LookPath)
 	default:
 		return ""
 	}
@@ -87,7 +96,23 @@ func (c *Config) GenerateConfig() (string, error) {
 	for key, value := range c.EnvVars {
 		switch c.Shell {
 		case "fish":
-			fmt.Fprintf(&config, "set -gx %s %s\n", key, value)
+			word, err := FishValue(value)
+			if err != nil {
+				return "", fmt.Errorf("env %s: %w", key, err)
+			}
+			fmt.Fprintf(&config, "set -gx %s %s\n", key, word)
+		case "nu":
+			word, err := NuValue(value)
+			if err != nil {
+				return "", fmt.Errorf("env %s: %w", key, err)
+			}
+			fmt.Fprintf(&config, "$env.%s = %s\n", key, word)
+		case "pwsh":
+			word, err := PSValue(value)
+			if err != nil {
+				return "", fmt.Errorf("env %s: %w", key, err)
+			}
+			fmt.Fprintf(&config, "$env:%s = %s\n", key, word)
 		default:
 			fmt.Fprintf(&config, "export %s=%s\n", key, value)
 		}
@@ -98,7 +123,27 @@ func (c *Config) GenerateConfig() (string, error) {
 		switch c.Shell {
 		case "fish":
 			for _, path := range c.Paths {
-				fmt.Fprintf(&config, "fish_add_path %s\n", path)
+				word, err := FishValue(path)
+				if err != nil {
+					return "", fmt.Errorf("path %s: %w", path, err)
+				}
+				fmt.Fprintf(&config, "fish_add_path %s\n", word)
+			}
+		case "nu":
+			for _, path := range c.Paths {
+				word, err := NuValue(path)
+				if err != nil {
+					return "", fmt.Errorf("path %s: %w", path, err)
+				}
+				fmt.Fprintf(&config, "$env.PATH = ($env.PATH | split row (char esep) | prepend %s)\n", word)
+			}
+		case "pwsh":
+			for _, path := range c.Paths {
+				word, err := PSValue(path)
+				if err != nil {
+					return "", fmt.Errorf("path %s: %w", path, err)
+				}
+				fmt.Fprintf(&config, "$env:PATH = %s + [IO.Path]::PathSeparator + $env:PATH\n", word)
 			}
 		default:
 			paths := strings.Join(c.Paths, ":")
@@ -110,7 +155,19 @@ func (c *Config) GenerateConfig() (string, error) {
 	for name, command := range c.Aliases {
 		switch c.Shell {
 		case "fish":
-			fmt.Fprintf(&config, "alias %s='%s'\n", name, command)
+			fmt.Fprintf(&config, "alias %s=%s\n", name, FishQuote(command))
+		case "nu":
+			body, err := NuAlias(command)
+			if err != nil {
+				return "", fmt.Errorf("alias %s: %w", name, err)
+			}
+			fmt.Fprintf(&config, "alias %s = %s\n", name, body)
+		case "pwsh":
+			def, err := PSAlias(name, command)
+			if err != nil {
+				return "", fmt.Errorf("alias %s: %w", name, err)
+			}
+			fmt.Fprintf(&config, "%s\n", def)
 		default:
 			fmt.Fprintf(&config, "alias %s='%s'\n", name, command)
 		}
@@ -120,7 +177,23 @@ func (c *Config) GenerateConfig() (string, error) {
 	for name, body := range c.Functions {
 		switch c.Shell {
 		case "fish":
-			fmt.Fprintf(&config, "function %s\n%s\nend\n", name, body)
+			fishBody, err := FishFunctionBody(body)
+			if err != nil {
+				return "", fmt.Errorf("function %s: %w", name, err)
+			}
+			fmt.Fprintf(&config, "function %s\n%s\nend\n", name, strings.TrimRight(fishBody, "\n"))
+		case "nu":
+			nuBody, err := NuFunctionBody(body)
+			if err != nil {
+				return "", fmt.Errorf("function %s: %w", name, err)
+			}
+			fmt.Fprintf(&config, "def --env --wrapped %s [...args] {\n%s\n}\n", name, strings.TrimRight(nuBody, "\n"))
+		case "pwsh":
+			psBody, err := PSFunctionBody(body)
+			if err != nil {
+				return "", fmt.Errorf("function %s: %w", name, err)
+			}
+			fmt.Fprintf(&config, "function %s {\n%s\n}\n", name, strings.TrimRight(psBody, "\n"))
 		default:
 			fmt.Fprintf(&config, "%s() {\n%s\n}\n", name, body)
 		}
@@ -145,8 +218,10 @@ func (c *Config) Apply() (string, error) {
 
 	// Return the appropriate source command
 	switch c.Shell {
-	case "fish":
+	case "fish", "nu":
 		return fmt.Sprintf("source %s", tempFile), nil
+	case "pwsh":
+		return fmt.Sprintf(". %s", PSQuote(tempFile)), nil
 	default:
 		return fmt.Sprintf(". %s", tempFile), nil
 	}
@@ -154,6 +229,12 @@ func (c *Config) Apply() (string, error) {
 
 // findShellConfigDir searches for the shell config directory in multiple locations
 func findShellConfigDir() (string, error) {
+	// The user config directory is skipped when there is no home directory
+	var userDir string
+	if home, err := system.UserHome(); err == nil {
+		userDir = filepath.Join(home, ".config", "bootstrap-cli", "shell")
+	}
+
 	// Priority order for config locations
 	locations := []string{
 		// 1. Environment variable
@@ -163,7 +244,7 @@ func findShellConfigDir() (string, error) {
 		"config/dotfiles/shell",
 		
 		// 3. User's config directory
-		filepath.Join(os.Getenv("HOME"), ".config", "bootstrap-cli", "shell"),
+		userDir,
 		
 		// 4. System-wide config directory
 		filepath.Join("/etc", "bootstrap-cli", "shell"),
@@ -283,7 +364,7 @@ func findShellPath(shellType string) string {
 // expandPath expands ~ to the user's home directory in a path
 func expandPath(path string) string {
 	if strings.HasPrefix(path, "~/") {
-		home, err := os.UserHomeDir()
+		home, err := system.UserHome()
 		if err != nil {
 			return path
 		}
@@ -3,12 +3,15 @@ package shell
 import (
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/packages/factory"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
 )
 
 // DefaultConfigWriter implements interfaces.ShellConfigWriter
@@ -48,7 +51,7 @@ func NewConfigWriter() (interfaces.ShellConfigWriter, error) {
 
 // getDefaultRCFile returns the default RC file for a shell
 func getDefaultRCFile(shellType string) string {
-	homeDir, err := os.UserHomeDir()
+	homeDir, err := system.UserHome()
 	if err != nil {
 		return ""
 	}
@@ -60,6 +63,10 @@ func getDefaultRCFile(shellType string) string {
 		return filepath.Join(homeDir, ".zshrc")
 	case string(interfaces.FishShell):
 		return filepath.Join(homeDir, ".config", "fish", "config.fish")
+	case string(interfaces.NuShell):
+		return filepath.Join(homeDir, ".config", "nushell", "config.nu")
+	case string(interfaces.PowerShell):
+		return PowerShellProfile(homeDir, runtime.GOOS, exec.LookPath)
 	default:
 		return ""
 	}
@@ -118,6 +125,11 @@ func (w *DefaultConfigWriter) WriteConfig(configs []string, strategy interfaces.
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
+	// Keep a copy of the rc file as it was before this run first changed it
+	if err := DefaultBackups().Backup(configFile); err != nil {
+		return err
+	}
+
 	// Write the file
 	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
@@ -129,18 +141,60 @@ func (w *DefaultConfigWriter) WriteConfig(configs []string, strategy interfaces.
 // AddToPath adds a directory to the PATH environment variable
 func (w *DefaultConfigWriter) AddToPath(path string) error {
 	config := fmt.Sprintf("export PATH=%s:$PATH", path)
+	switch w.shell {
+	case interfaces.NuShell:
+		word, err := NuValue(path)
+		if err != nil {
+			return fmt.Errorf("path %s: %w", path, err)
+		}
+		config = fmt.Sprintf("$env.PATH = ($env.PATH | split row (char esep) | prepend %s)", word)
+	case interfaces.PowerShell:
+		word, err := PSValue(path)
+		if err != nil {
+			return fmt.Errorf("path %s: %w", path, err)
+		}
+		config = fmt.Sprintf("$env:PATH = %s + [IO.Path]::PathSeparator + $env:PATH", word)
+	}
 	return w.WriteConfig([]string{config}, interfaces.MergeWithExisting)
 }
 
 // SetEnvVar sets an environment variable
 func (w *DefaultConfigWriter) SetEnvVar(name, value string) error {
 	config := fmt.Sprintf("export %s=%s", name, value)
+	switch w.shell {
+	case interfaces.NuShell:
+		word, err := NuValue(value)
+		if err != nil {
+			return fmt.Errorf("env %s: %w", name, err)
+		}
+		config = fmt.Sprintf("$env.%s = %s", name, word)
+	case interfaces.PowerShell:
+		word, err := PSValue(value)
+		if err != nil {
+			return fmt.Errorf("env %s: %w", name, err)
+		}
+		config = fmt.Sprintf("$env:%s = %s", name, word)
+	}
 	return w.WriteConfig([]string{config}, interfaces.MergeWithExisting)
 }
 
 // AddAlias adds a shell alias
 func (w *DefaultConfigWriter) AddAlias(name, command string) error {
 	config := fmt.Sprintf("alias %s='%s'", name, command)
+	switch w.shell {
+	case interfaces.NuShell:
+		body, err := NuAlias(command)
+		if err != nil {
+			return fmt.Errorf("alias %s: %w", name, err)
+		}
+		config = fmt.Sprintf("alias %s = %s", name, body)
+	case interfaces.PowerShell:
+		def, err := PSAlias(name, command)
+		if err != nil {
+			return fmt.Errorf("alias %s: %w", name, err)
+		}
+		config = def
+	}
 	return w.WriteConfig([]string{config}, interfaces.MergeWithExisting)
 }
 
@@ -161,7 +215,7 @@ func (w *DefaultConfigWriter) HasConfig(config string) bool {
 
 // getConfigFile returns the appropriate config file path for the shell
 func (w *DefaultConfigWriter) getConfigFile() string {
-	home, err := os.UserHomeDir()
+	home, err := system.UserHome()
 	if err != nil {
 		w.logger.Error("Failed to get user home directory: %v", err)
 		return ""
@@ -174,6 +228,10 @@ func (w *DefaultConfigWriter) getConfigFile() string {
 		return filepath.Join(home, ".zshrc")
 	case interfaces.FishShell:
 		return filepath.Join(home, ".config", "fish", "config.fish")
+	case interfaces.NuShell:
+		return filepath.Join(home, ".config", "nushell", "config.nu")
+	case interfaces.PowerShell:
+		return PowerShellProfile(home, runtime.GOOS, exec.LookPath)
 	default:
 		return ""
 	}
@@ -187,6 +245,10 @@ func (w *DefaultConfigWriter) getShellType() interfaces.ShellType {
 		return interfaces.ZshShell
 	case interfaces.FishShell:
 		return interfaces.FishShell
+	case interfaces.NuShell:
+		return interfaces.NuShell
+	case interfaces.PowerShell:
+		return interfaces.PowerShell
 	default:
 		return interfaces.BashShell
 	}
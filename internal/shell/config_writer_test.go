@@ -282,6 +282,11 @@ func TestGetConfigFile(t *testing.T) {
 			shell:    interfaces.FishShell,
 			wantPath: filepath.Join(".config", "fish", "config.fish"),
 		},
+		{
+			name:     "nushell config",
+			shell:    interfaces.NuShell,
+			wantPath: filepath.Join(".config", "nushell", "config.nu"),
+		},
 		{
 			name:     "unknown shell",
 			shell:    "unknown",
@@ -0,0 +1,175 @@
+package shell
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// fishSafe are the characters that need no quoting in a fish word
+const fishSafe = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-./:=+,@%"
+
+// fishPositional matches bash positional parameters: $1, ${1}, $@, "$@" and $*
+var fishPositional = regexp.MustCompile(`"\$@"|\$@|\$\*|\$\{([1-9])\}|\$([1-9])`)
+
+// FishQuote quotes s as a single fish word with no expansion; words made only of
+// safe characters are left bare. Unlike POSIX shells, fish allows \' and \\
+// inside single quotes.
+func FishQuote(s string) string {
+	if s != "" && strings.Trim(s, fishSafe) == "" {
+		return s
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// FishValue converts a value written for a POSIX shell (an env var or PATH entry
+// from a tool's shell_config) to a fish word with the same meaning: $VAR and
+// ${VAR} stay expansions, $(cmd) becomes a fish command substitution, a leading
+// ~ expands, and everything else is quoted literally. Parameter expansions with
+// operators (${VAR:-x}, ${SHELL##*/}) have no fish equivalent and are an error.
+func FishValue(s string) (string, error) {
+	var b strings.Builder
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			b.WriteString(FishQuote(literal.String()))
+			literal.Reset()
+		}
+	}
+
+	if strings.HasPrefix(s, "~/") || s == "~" {
+		b.WriteString("~")
+		s = s[1:]
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			literal.WriteByte(s[i])
+			continue
+		}
+		switch next := s[i+1]; {
+		case next == '(':
+			end := matchingParen(s, i+1)
+			if end < 0 {
+				return "", fmt.Errorf("unterminated command substitution in %q", s)
+			}
+			flush()
+			// string collect keeps multi-line output as one value, like "$(cmd)"
+			fmt.Fprintf(&b, "(%s | string collect)", strings.TrimSpace(s[i+2:end]))
+			i = end
+		case next == '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated parameter expansion in %q", s)
+			}
+			name := s[i+2 : i+end]
+			if !isShellName(name) {
+				return "", fmt.Errorf("parameter expansion ${%s} has no fish equivalent", name)
+			}
+			flush()
+			fmt.Fprintf(&b, "{$%s}", name)
+			i += end
+		case isShellNameByte(next, true):
+			j := i + 1
+			for j < len(s) && isShellNameByte(s[j], false) {
+				j++
+			}
+			flush()
+			b.WriteString(s[i:j])
+			i = j - 1
+		default:
+			literal.WriteByte(s[i])
+		}
+	}
+	flush()
+	if b.Len() == 0 {
+		return "''", nil
+	}
+	return b.String(), nil
+}
+
+// FishFunctionBody converts the simple bash-isms of a function body to fish:
+// positional parameters become $argv and $(cmd) becomes (cmd). Bodies using
+// other bash syntax (${VAR:-x}, [[ ]], local, export) are rejected rather than
+// written as a block fish would fail to load.
+func FishFunctionBody(body string) (string, error) {
+	body = fishPositional.ReplaceAllStringFunc(body, func(m string) string {
+		sub := fishPositional.FindStringSubmatch(m)
+		switch {
+		case sub[1] != "":
+			return "$argv[" + sub[1] + "]"
+		case sub[2] != "":
+			return "$argv[" + sub[2] + "]"
+		default:
+			return "$argv"
+		}
+	})
+	for _, construct := range []string{"${", "[[", "local ", "export "} {
+		if strings.Contains(body, construct) {
+			return "", fmt.Errorf("function body uses %q, which fish does not support", strings.TrimSpace(construct))
+		}
+	}
+	return fishSubstitutions(body)
+}
+
+// fishSubstitutions rewrites $(cmd) as (cmd), and "$(cmd)" as one value, since
+// fish before 3.4 does not expand substitutions inside double quotes
+func fishSubstitutions(body string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(body); i++ {
+		if body[i] != '$' || i+1 == len(body) || body[i+1] != '(' {
+			b.WriteByte(body[i])
+			continue
+		}
+		end := matchingParen(body, i+1)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated command substitution in %q", body)
+		}
+		inner := strings.TrimSpace(body[i+2 : end])
+		quoted := strings.HasSuffix(b.String(), `"`) && end+1 < len(body) && body[end+1] == '"'
+		if quoted {
+			rest := strings.TrimSuffix(b.String(), `"`)
+			b.Reset()
+			b.WriteString(rest)
+			fmt.Fprintf(&b, "(%s | string collect)", inner)
+			end++
+		} else {
+			fmt.Fprintf(&b, "(%s)", inner)
+		}
+		i = end
+	}
+	return b.String(), nil
+}
+
+// matchingParen returns the index of the parenthesis closing the one at open,
+// or -1
+func matchingParen(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isShellName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isShellNameByte(name[i], i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func isShellNameByte(c byte, first bool) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!first && c >= '0' && c <= '9')
+}
@@ -0,0 +1,119 @@
+package shell
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+)
+
+func TestFishQuote(t *testing.T) {
+	tests := map[string]string{
+		"ls":                  "ls",
+		"/usr/local/bin":      "/usr/local/bin",
+		"ls -la":              "'ls -la'",
+		"fzf --preview 'bat'": `'fzf --preview \'bat\''`,
+		`C:\dir`:              `'C:\\dir'`,
+		"":                    "''",
+	}
+	for in, want := range tests {
+		if got := FishQuote(in); got != want {
+			t.Errorf("FishQuote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestFishValue(t *testing.T) {
+	tests := []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{in: "--height 40% --layout=reverse", want: "'--height 40% --layout=reverse'"},
+		{in: "$FZF_DEFAULT_COMMAND", want: "$FZF_DEFAULT_COMMAND"},
+		{in: "${HOME}/.cargo/bin", want: "{$HOME}/.cargo/bin"},
+		{in: "$HOME/.local/bin", want: "$HOME/.local/bin"},
+		{in: "~/.fzf/bin", want: "~/.fzf/bin"},
+		{in: "$(vivid generate molokai)", want: "(vivid generate molokai | string collect)"},
+		{in: "cost: $5", want: "'cost: $5'"},
+		{in: "${SHELL##*/}", wantErr: true},
+		{in: "${EDITOR:-vim}", wantErr: true},
+		{in: "$(unterminated", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := FishValue(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("FishValue(%q) = %s, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("FishValue(%q) = %s, %v; want %s", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestFishFunctionBody(t *testing.T) {
+	got, err := FishFunctionBody(`cd "$(fd --type d | fzf)"`)
+	if err != nil || got != `cd (fd --type d | fzf | string collect)` {
+		t.Errorf("FishFunctionBody() = %s, %v", got, err)
+	}
+	got, err = FishFunctionBody(`git commit -m "$1" "$@"`)
+	if err != nil || got != `git commit -m "$argv[1]" $argv` {
+		t.Errorf("FishFunctionBody() = %s, %v", got, err)
+	}
+	if _, err := FishFunctionBody("kill -${1:-9}"); err == nil {
+		t.Error("Expected ${1:-9} to be rejected")
+	}
+	if _, err := FishFunctionBody("[[ -n $1 ]] && echo yes"); err == nil {
+		t.Error("Expected [[ ]] to be rejected")
+	}
+}
+
+// TestFishBlocksParse checks every kind of generated fish snippet with fish's own
+// parser, so constructs fish would reject or misread are caught
+func TestFishBlocksParse(t *testing.T) {
+	fish, err := exec.LookPath("fish")
+	if err != nil {
+		t.Skip("fish is not installed")
+	}
+
+	c := NewConfig("fish", log.NewMockLogger())
+	c.AddEnvVar("FZF_DEFAULT_OPTS", "--height 40% --layout=reverse --border")
+	c.AddEnvVar("FZF_CTRL_T_COMMAND", "$FZF_DEFAULT_COMMAND")
+	c.AddEnvVar("LS_COLORS", "$(vivid generate molokai)")
+	c.AddAlias("preview", "fzf --preview 'bat --style=numbers --color=always {}'")
+	c.AddFunction("fcd", `cd "$(fd --type d --hidden --follow --exclude .git | fzf)"`)
+	c.AddPath("$HOME/.cargo/bin")
+	generated, err := c.GenerateConfig()
+	if err != nil {
+		t.Fatalf("GenerateConfig() error = %v", err)
+	}
+
+	blocks := map[string]string{
+		"generated": generated,
+		"starship":  promptInit(PromptStarship, "fish", ""),
+		"ohmyposh":  promptInit(PromptOhMyPosh, "fish", "/tmp/theme.omp.json"),
+		"managed":   UpsertBlock("", "fzf", "fish_add_path ~/.fzf/bin"),
+	}
+	for name, block := range blocks {
+		path := filepath.Join(t.TempDir(), name+".fish")
+		if err := os.WriteFile(path, []byte(block), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if out, err := exec.Command(fish, "--no-execute", path).CombinedOutput(); err != nil {
+			t.Errorf("fish rejected the %s block: %v\n%s\n%s", name, err, out, block)
+		}
+	}
+}
+
+func TestGenerateConfigRejectsBashOnlyFishFunction(t *testing.T) {
+	c := NewConfig("fish", log.NewMockLogger())
+	c.AddFunction("fkill", "ps -ef | fzf -m | awk '{print $2}' | xargs kill -${1:-9}")
+	if _, err := c.GenerateConfig(); err == nil || !strings.Contains(err.Error(), "fkill") {
+		t.Errorf("GenerateConfig() error = %v, want an error naming fkill", err)
+	}
+}
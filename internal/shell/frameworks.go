@@ -0,0 +1,295 @@
+package shell
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/cache"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+)
+
+const (
+	// FrameworkOhMyZsh is the oh-my-zsh framework for zsh
+	FrameworkOhMyZsh = "oh-my-zsh"
+	// FrameworkBashIt is the bash-it framework for bash
+	FrameworkBashIt = "bash-it"
+
+	ohMyZshInstallScript = "https://raw.githubusercontent.com/ohmyzsh/ohmyzsh/master/tools/install.sh"
+)
+
+// frameworkDefaults holds the upstream repository and install directory of each framework
+var frameworkDefaults = map[string]struct {
+	repo string
+	dir  string
+}{
+	FrameworkOhMyZsh: {repo: "https://github.com/ohmyzsh/ohmyzsh.git", dir: ".oh-my-zsh"},
+	FrameworkBashIt:  {repo: "https://github.com/Bash-it/bash-it.git", dir: ".bash_it"},
+}
+
+// commitPattern matches abbreviated or full git commit hashes
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// InstalledFramework records how a framework was installed
+type InstalledFramework struct {
+	Name        string    `json:"name"`
+	Repo        string    `json:"repo"`
+	Ref         string    `json:"ref,omitempty"`
+	Dir         string    `json:"dir"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// FrameworkInstaller installs, updates and removes shell frameworks at pinned refs
+type FrameworkInstaller struct {
+	// HomeDir is the directory frameworks are installed under
+	HomeDir string
+	// StatePath is where installed framework refs are recorded
+	StatePath string
+	// run executes a command with extra environment variables
+	run func(env []string, name string, args ...string) error
+	// fetch downloads and verifies an install script (default: the download cache)
+	fetch func(url, version, checksum string) (string, func(), error)
+}
+
+// NewFrameworkInstaller creates a framework installer for the current user
+func NewFrameworkInstaller() (*FrameworkInstaller, error) {
+	home, err := system.UserHome()
+	if err != nil {
+		return nil, err
+	}
+	stateDir, err := manifest.StateDir()
+	if err != nil {
+		return nil, err
+	}
+	return &FrameworkInstaller{
+		HomeDir:   home,
+		StatePath: filepath.Join(stateDir, "frameworks.json"),
+		run:       runCommand,
+	}, nil
+}
+
+// runCommand runs a command with env appended to the current environment
+func runCommand(env []string, name string, args ...string) error {
+	if err := cache.CheckCommand(strings.Join(args, " ")); err != nil {
+		return err
+	}
+	cmd := exec.Command(name, args...)
+	cmd.Env = append(os.Environ(), env...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w\nOutput: %s", name, err, string(output))
+	}
+	return nil
+}
+
+// FrameworkScript returns the URL of the install script a framework is set up
+// with; frameworks cloned straight from git have none
+func FrameworkScript(name string) (string, bool) {
+	if name == FrameworkOhMyZsh {
+		return ohMyZshInstallScript, true
+	}
+	return "", false
+}
+
+// fetchScript downloads an install script to a temporary file, verified against
+// checksum when one is given, and returns its path and a cleanup func
+func (f *FrameworkInstaller) fetchScript(url, checksum string) (string, func(), error) {
+	if f.fetch != nil {
+		return f.fetch(url, "", checksum)
+	}
+	downloads, err := cache.NewDefault()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to open download cache: %w", err)
+	}
+	// The script tracks master, so it is not cached under a version
+	downloads.SetEnabled(false)
+	return downloads.FetchScript(url, "", checksum)
+}
+
+// Install installs fw at its pinned ref and records the ref for later
+// update/uninstall. A framework the user installed themselves is recorded as
+// it is, without a ref, so it is updated rather than reinstalled.
+func (f *FrameworkInstaller) Install(fw *interfaces.ShellFramework) error {
+	defaults, ok := frameworkDefaults[fw.Name]
+	if !ok {
+		return fmt.Errorf("unsupported shell framework: %s", fw.Name)
+	}
+	repo := fw.Repo
+	if repo == "" {
+		repo = defaults.repo
+	}
+	dir := filepath.Join(f.HomeDir, defaults.dir)
+	if _, err := os.Stat(dir); err == nil {
+		return f.record(InstalledFramework{
+			Name:        fw.Name,
+			Repo:        repo,
+			Dir:         dir,
+			InstalledAt: time.Now(),
+		})
+	}
+
+	isCommit := commitPattern.MatchString(fw.Ref)
+	switch fw.Name {
+	case FrameworkOhMyZsh:
+		env := []string{"ZSH=" + dir, "REMOTE=" + repo, "RUNZSH=no", "CHSH=no", "KEEP_ZSHRC=yes"}
+		if fw.Ref != "" && !isCommit {
+			env = append(env, "BRANCH="+fw.Ref)
+		}
+		script, cleanup, err := f.fetchScript(ohMyZshInstallScript, fw.SHA256)
+		if err != nil {
+			return fmt.Errorf("failed to download the oh-my-zsh install script: %w", err)
+		}
+		err = f.run(env, "sh", script, "--unattended")
+		cleanup()
+		if err != nil {
+			return fmt.Errorf("failed to install oh-my-zsh: %w", err)
+		}
+	case FrameworkBashIt:
+		args := []string{"clone", "--depth=1"}
+		if fw.Ref != "" && !isCommit {
+			args = append(args, "--branch", fw.Ref)
+		}
+		if isCommit {
+			args = []string{"clone"}
+		}
+		args = append(args, repo, dir)
+		if err := f.run(nil, "git", args...); err != nil {
+			return fmt.Errorf("failed to clone bash-it: %w", err)
+		}
+	}
+
+	if isCommit {
+		if err := f.checkout(dir, fw.Ref); err != nil {
+			return err
+		}
+	}
+
+	return f.record(InstalledFramework{
+		Name:        fw.Name,
+		Repo:        repo,
+		Ref:         fw.Ref,
+		Dir:         dir,
+		InstalledAt: time.Now(),
+	})
+}
+
+// Update brings an installed framework up to date with its recorded ref.
+// Frameworks pinned to a commit are left untouched.
+func (f *FrameworkInstaller) Update(name string) error {
+	installed, err := f.Installed(name)
+	if err != nil {
+		return err
+	}
+	if commitPattern.MatchString(installed.Ref) {
+		return nil
+	}
+	if installed.Ref == "" {
+		return f.run(nil, "git", "-C", installed.Dir, "pull", "--ff-only")
+	}
+	if err := f.run(nil, "git", "-C", installed.Dir, "fetch", "--depth=1", "origin", installed.Ref); err != nil {
+		return fmt.Errorf("failed to fetch %s %s: %w", name, installed.Ref, err)
+	}
+	return f.run(nil, "git", "-C", installed.Dir, "checkout", "--force", "FETCH_HEAD")
+}
+
+// Uninstall removes an installed framework and forgets its recorded ref
+func (f *FrameworkInstaller) Uninstall(name string) error {
+	installed, err := f.Installed(name)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(installed.Dir); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", installed.Dir, err)
+	}
+
+	state, err := f.loadState()
+	if err != nil {
+		return err
+	}
+	delete(state, name)
+	return f.saveState(state)
+}
+
+// Installed returns the recorded installation of a framework
+func (f *FrameworkInstaller) Installed(name string) (*InstalledFramework, error) {
+	state, err := f.loadState()
+	if err != nil {
+		return nil, err
+	}
+	installed, ok := state[name]
+	if !ok {
+		return nil, fmt.Errorf("%s was not installed by bootstrap-cli", name)
+	}
+	return &installed, nil
+}
+
+// List returns the recorded installations of every framework, sorted by name
+func (f *FrameworkInstaller) List() ([]InstalledFramework, error) {
+	state, err := f.loadState()
+	if err != nil {
+		return nil, err
+	}
+	frameworks := make([]InstalledFramework, 0, len(state))
+	for _, installed := range state {
+		frameworks = append(frameworks, installed)
+	}
+	sort.Slice(frameworks, func(i, j int) bool { return frameworks[i].Name < frameworks[j].Name })
+	return frameworks, nil
+}
+
+// checkout fetches and checks out a specific commit
+func (f *FrameworkInstaller) checkout(dir, ref string) error {
+	if err := f.run(nil, "git", "-C", dir, "fetch", "origin", ref); err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", ref, err)
+	}
+	if err := f.run(nil, "git", "-C", dir, "checkout", "--force", ref); err != nil {
+		return fmt.Errorf("failed to check out %s: %w", ref, err)
+	}
+	return nil
+}
+
+// record stores an installed framework in the state file
+func (f *FrameworkInstaller) record(installed InstalledFramework) error {
+	state, err := f.loadState()
+	if err != nil {
+		return err
+	}
+	state[installed.Name] = installed
+	return f.saveState(state)
+}
+
+func (f *FrameworkInstaller) loadState() (map[string]InstalledFramework, error) {
+	state := make(map[string]InstalledFramework)
+	data, err := os.ReadFile(f.StatePath)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read framework state: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse framework state: %w", err)
+	}
+	return state, nil
+}
+
+func (f *FrameworkInstaller) saveState(state map[string]InstalledFramework) error {
+	if err := os.MkdirAll(filepath.Dir(f.StatePath), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode framework state: %w", err)
+	}
+	if err := os.WriteFile(f.StatePath, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write framework state: %w", err)
+	}
+	return nil
+}
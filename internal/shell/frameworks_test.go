@@ -0,0 +1,148 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+type recordedCommand struct {
+	env  []string
+	name string
+	args []string
+}
+
+func testFrameworkInstaller(t *testing.T) (*FrameworkInstaller, *[]recordedCommand) {
+	home := t.TempDir()
+	var commands []recordedCommand
+	f := &FrameworkInstaller{
+		HomeDir:   home,
+		StatePath: filepath.Join(home, ".bootstrap-cli", "frameworks.json"),
+		run: func(env []string, name string, args ...string) error {
+			commands = append(commands, recordedCommand{env: env, name: name, args: args})
+			return nil
+		},
+		fetch: func(string, string, string) (string, func(), error) {
+			return "install.sh", func() {}, nil
+		},
+	}
+	return f, &commands
+}
+
+func TestInstallBashItBranch(t *testing.T) {
+	f, commands := testFrameworkInstaller(t)
+
+	err := f.Install(&interfaces.ShellFramework{Name: FrameworkBashIt, Repo: "https://example.com/fork/bash-it.git", Ref: "v3.0.0"})
+	if err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+
+	if len(*commands) != 1 {
+		t.Fatalf("Expected 1 command, got %d", len(*commands))
+	}
+	args := strings.Join((*commands)[0].args, " ")
+	want := "clone --depth=1 --branch v3.0.0 https://example.com/fork/bash-it.git " + filepath.Join(f.HomeDir, ".bash_it")
+	if args != want {
+		t.Errorf("Unexpected clone args:\n got: %s\nwant: %s", args, want)
+	}
+
+	installed, err := f.Installed(FrameworkBashIt)
+	if err != nil {
+		t.Fatalf("Installed() error = %v", err)
+	}
+	if installed.Ref != "v3.0.0" || installed.Repo != "https://example.com/fork/bash-it.git" {
+		t.Errorf("Unexpected recorded framework: %+v", installed)
+	}
+}
+
+func TestInstallOhMyZshBranchEnv(t *testing.T) {
+	f, commands := testFrameworkInstaller(t)
+
+	if err := f.Install(&interfaces.ShellFramework{Name: FrameworkOhMyZsh, Ref: "stable"}); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+
+	if args := strings.Join((*commands)[0].args, " "); args != "install.sh --unattended" {
+		t.Errorf("Expected the downloaded script to be run, got sh %s", args)
+	}
+	env := strings.Join((*commands)[0].env, " ")
+	if !strings.Contains(env, "BRANCH=stable") || !strings.Contains(env, "REMOTE=https://github.com/ohmyzsh/ohmyzsh.git") {
+		t.Errorf("Expected installer env to pin branch and remote, got %s", env)
+	}
+}
+
+func TestInstallCommitChecksOut(t *testing.T) {
+	f, commands := testFrameworkInstaller(t)
+
+	if err := f.Install(&interfaces.ShellFramework{Name: FrameworkBashIt, Ref: "0123abcd"}); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+
+	last := (*commands)[len(*commands)-1]
+	if got := strings.Join(last.args, " "); !strings.HasSuffix(got, "checkout --force 0123abcd") {
+		t.Errorf("Expected checkout of pinned commit, got %s", got)
+	}
+
+	// Pinned commits are not moved by update
+	count := len(*commands)
+	if err := f.Update(FrameworkBashIt); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if len(*commands) != count {
+		t.Errorf("Expected update of pinned commit to be a no-op")
+	}
+}
+
+func TestUninstallFramework(t *testing.T) {
+	f, _ := testFrameworkInstaller(t)
+
+	if err := f.Install(&interfaces.ShellFramework{Name: FrameworkBashIt}); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	dir := filepath.Join(f.HomeDir, ".bash_it")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create framework dir: %v", err)
+	}
+
+	if err := f.Uninstall(FrameworkBashIt); err != nil {
+		t.Fatalf("Uninstall() error = %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed", dir)
+	}
+	if _, err := f.Installed(FrameworkBashIt); err == nil {
+		t.Errorf("Expected framework to be forgotten after uninstall")
+	}
+}
+
+func TestInstallUnsupportedFramework(t *testing.T) {
+	f, _ := testFrameworkInstaller(t)
+	if err := f.Install(&interfaces.ShellFramework{Name: "prezto"}); err == nil {
+		t.Error("Expected error for unsupported framework")
+	}
+}
+
+func TestInstallRecordsExistingFramework(t *testing.T) {
+	f, commands := testFrameworkInstaller(t)
+	dir := filepath.Join(f.HomeDir, ".oh-my-zsh")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create framework dir: %v", err)
+	}
+
+	if err := f.Install(&interfaces.ShellFramework{Name: FrameworkOhMyZsh, Ref: "master"}); err != nil {
+		t.Fatalf("Install() error = %v, want an existing oh-my-zsh to be adopted", err)
+	}
+	if len(*commands) != 0 {
+		t.Errorf("Expected no install commands for an existing framework, got %d", len(*commands))
+	}
+	installed, err := f.Installed(FrameworkOhMyZsh)
+	if err != nil {
+		t.Fatalf("Installed() error = %v", err)
+	}
+	if installed.Dir != dir || installed.Ref != "" {
+		t.Errorf("Unexpected recorded framework: %+v", installed)
+	}
+}
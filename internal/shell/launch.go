@@ -0,0 +1,97 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+// SpawnedEnvVar is set in shells spawned by bootstrap-cli so nested runs don't spawn again
+const SpawnedEnvVar = "BOOTSTRAP_CLI_SPAWNED_SHELL"
+
+// defaultTerm is used when the parent environment has no usable TERM
+const defaultTerm = "xterm-256color"
+
+// ResolveShellPath returns the executable for the named shell, falling back to
+// $SHELL, or on Windows, which has no $SHELL, to pwsh and then Windows PowerShell
+func ResolveShellPath(name string) (string, error) {
+	if name != "" {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+		if name == string(interfaces.PowerShell) {
+			if path, err := exec.LookPath("powershell"); err == nil {
+				return path, nil
+			}
+		}
+	}
+	if current := os.Getenv("SHELL"); current != "" {
+		return current, nil
+	}
+	if runtime.GOOS == "windows" {
+		for _, candidate := range []string{"pwsh", "powershell"} {
+			if path, err := exec.LookPath(candidate); err == nil {
+				return path, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("could not determine a shell to launch")
+}
+
+// InteractiveCommand builds the command that launches shellPath as a fresh
+// interactive (non-login) shell in the current working directory, so it reads
+// the user's rc file and picks up new aliases and PATH entries.
+func InteractiveCommand(shellPath string) (*exec.Cmd, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	// PowerShell is interactive and reads $PROFILE whenever it gets no command
+	args := []string{"-i"}
+	if shellName(shellPath) == string(interfaces.PowerShell) {
+		args = []string{"-NoLogo"}
+	}
+	cmd := exec.Command(shellPath, args...)
+	cmd.Dir = wd
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	env := os.Environ()
+	if term := os.Getenv("TERM"); term == "" || term == "dumb" {
+		env = append(env, "TERM="+defaultTerm)
+	}
+	// The parent's SHELL may differ from the one we launch (e.g. bash running a zsh setup)
+	env = append(env, "SHELL="+shellPath, SpawnedEnvVar+"=1")
+	cmd.Env = env
+
+	return cmd, nil
+}
+
+// LaunchInteractive replaces the end of a run with a fresh interactive shell and
+// waits for it to exit. It is a no-op inside a shell bootstrap-cli itself spawned.
+func LaunchInteractive(shellPath string) error {
+	if os.Getenv(SpawnedEnvVar) != "" {
+		return nil
+	}
+
+	cmd, err := InteractiveCommand(shellPath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Launching a new %s session with your updated configuration (type 'exit' to return)...\n", filepath.Base(shellPath))
+	if err := cmd.Run(); err != nil {
+		// A non-zero exit from the user's last command is not a launch failure
+		if _, ok := err.(*exec.ExitError); ok {
+			return nil
+		}
+		return fmt.Errorf("failed to launch %s: %w", shellPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package shell
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestInteractiveCommand(t *testing.T) {
+	t.Setenv("TERM", "dumb")
+
+	cmd, err := InteractiveCommand("/bin/zsh")
+	if err != nil {
+		t.Fatalf("InteractiveCommand() error = %v", err)
+	}
+
+	if len(cmd.Args) != 2 || cmd.Args[1] != "-i" {
+		t.Errorf("Expected an interactive non-login shell, got args %v", cmd.Args)
+	}
+	wd, _ := os.Getwd()
+	if cmd.Dir != wd {
+		t.Errorf("Expected working directory %s, got %s", wd, cmd.Dir)
+	}
+
+	env := strings.Join(cmd.Env, "\n")
+	for _, want := range []string{"TERM=" + defaultTerm, "SHELL=/bin/zsh", SpawnedEnvVar + "=1"} {
+		if !strings.Contains(env, want) {
+			t.Errorf("Expected environment to contain %s", want)
+		}
+	}
+}
+
+func TestLaunchInteractiveNested(t *testing.T) {
+	t.Setenv(SpawnedEnvVar, "1")
+	// Would block on an interactive shell if the nested guard didn't short-circuit
+	if err := LaunchInteractive("/nonexistent/shell"); err != nil {
+		t.Errorf("Expected nested launch to be a no-op, got %v", err)
+	}
+}
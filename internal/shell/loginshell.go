@@ -0,0 +1,223 @@
+package shell
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+)
+
+// LoginShellStateFile records the login shell bootstrap-cli replaced, under ~/.bootstrap-cli
+const LoginShellStateFile = "previous-shell.json"
+
+// LoginShellChange describes switching the user's login shell with chsh
+type LoginShellChange struct {
+	// Current is the login shell being replaced
+	Current string
+	// Target is the executable of the new login shell; empty until Resolve
+	// finds it when the shell is not installed yet
+	Target string
+	// Command changes the login shell (default: chsh -s Target)
+	Command string
+	// Shell is the shell being switched to
+	Shell *interfaces.Shell
+}
+
+// PlanLoginShellChange returns the change that makes sh the login shell, or nil
+// when it already is. A shell that is not installed yet, e.g. one this run
+// installs, is looked up again by Resolve when the change is applied.
+func PlanLoginShellChange(sh *interfaces.Shell) *LoginShellChange {
+	current := os.Getenv("SHELL")
+	change := &LoginShellChange{Current: current, Command: sh.SetDefaultCommand, Shell: sh}
+	name := sh.Name
+	if change.Resolve() == nil {
+		name = change.Target
+	} else if sh.Path != "" {
+		name = sh.Path
+	}
+	if current == name || (current != "" && filepath.Base(current) == filepath.Base(name)) {
+		return nil
+	}
+	return change
+}
+
+// Resolve finds the executable of the new login shell, which must be installed
+// so the new login shell works
+func (c *LoginShellChange) Resolve() error {
+	if c.Target != "" {
+		return nil
+	}
+	target := c.Shell.Path
+	if target == "" {
+		path, err := exec.LookPath(c.Shell.Name)
+		if err != nil {
+			return fmt.Errorf("%s is not installed, so it cannot be the login shell", c.Shell.Name)
+		}
+		target = path
+	}
+	if _, err := os.Stat(target); err != nil {
+		return fmt.Errorf("%s is not installed at %s, so it cannot be the login shell", c.Shell.Name, target)
+	}
+	c.Target = target
+	if c.Command == "" {
+		c.Command = "chsh -s " + target
+	}
+	return nil
+}
+
+// Summary explains the change and how to undo it
+func (c *LoginShellChange) Summary() string {
+	current := c.Current
+	if current == "" {
+		current = "(unknown)"
+	}
+	var b strings.Builder
+	fmt.Fprintln(&b, "bootstrap-cli is about to change your login shell:")
+	fmt.Fprintf(&b, "  current: %s\n", current)
+	if c.Target == "" {
+		fmt.Fprintf(&b, "  new:     %s (once it is installed)\n", c.Shell.Name)
+	} else {
+		fmt.Fprintf(&b, "  new:     %s\n", c.Target)
+	}
+	if c.Command != "" {
+		fmt.Fprintf(&b, "  command: %s\n", c.Command)
+	}
+	fmt.Fprintln(&b, "A login shell that fails to start can lock you out of graphical and SSH logins.")
+	if c.Current != "" {
+		fmt.Fprintf(&b, "To revert, run `bootstrap-cli shell revert` or `chsh -s %s`.\n", c.Current)
+	}
+	return b.String()
+}
+
+// ConfirmLoginShellChange prints the change summary to out and asks for consent
+// on in. Only an explicit "y" or "yes" approves it; anything else, including
+// end of input, declines.
+func ConfirmLoginShellChange(in io.Reader, out io.Writer, c *LoginShellChange) bool {
+	fmt.Fprint(out, c.Summary())
+	fmt.Fprint(out, "Change your login shell? [y/N]: ")
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+// ErrLoginShellConsent is returned when the login shell change cannot be confirmed
+// interactively and --yes was not given
+var ErrLoginShellConsent = errors.New("changing the login shell needs confirmation: run in a terminal or pass --yes")
+
+// ApproveLoginShellChange reports whether the change may go ahead: always with
+// assumeYes, otherwise only after the user confirms on in. Without a terminal to
+// ask on, it declines with ErrLoginShellConsent.
+func ApproveLoginShellChange(c *LoginShellChange, assumeYes bool, in *os.File, out io.Writer) (bool, error) {
+	if assumeYes {
+		fmt.Fprint(out, c.Summary())
+		return true, nil
+	}
+	if info, err := in.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false, ErrLoginShellConsent
+	}
+	return ConfirmLoginShellChange(in, out, c), nil
+}
+
+// RequestLoginShellChange asks up front whether sh may become the login shell
+// and returns the approved change, or nil to leave the login shell alone. The
+// change is applied later, once sh is installed.
+func RequestLoginShellChange(sh *interfaces.Shell, assumeYes bool, in *os.File, out io.Writer, logger *log.Logger) *LoginShellChange {
+	change := PlanLoginShellChange(sh)
+	if change == nil {
+		return nil
+	}
+	approved, err := ApproveLoginShellChange(change, assumeYes, in, out)
+	if err != nil {
+		logger.Warn("Not changing the login shell: %v", err)
+		return nil
+	}
+	if !approved {
+		logger.Info("Keeping %s as the login shell", change.Current)
+		return nil
+	}
+	return change
+}
+
+// ApplyLoginShellChange finds the new login shell (see Resolve), records the
+// current one for `bootstrap-cli shell revert` and runs the change. chsh may ask
+// for a password, so it gets the terminal.
+func ApplyLoginShellChange(c *LoginShellChange) error {
+	if err := c.Resolve(); err != nil {
+		return err
+	}
+	statePath, err := LoginShellStatePath()
+	if err != nil {
+		return err
+	}
+	if err := SaveLoginShellState(statePath, c); err != nil {
+		return err
+	}
+
+	cmd := exec.Command("sh", "-c", c.Command)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to change login shell: %w", err)
+	}
+	return nil
+}
+
+// LoginShellState is the record of a login shell change
+type LoginShellState struct {
+	Previous  string    `json:"previous"`
+	New       string    `json:"new"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// LoginShellStatePath returns where the previous login shell is recorded
+func LoginShellStatePath() (string, error) {
+	dir, err := manifest.StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, LoginShellStateFile), nil
+}
+
+// SaveLoginShellState records the change at path so it can be reverted
+func SaveLoginShellState(path string, c *LoginShellChange) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	data, err := json.MarshalIndent(LoginShellState{Previous: c.Current, New: c.Target, ChangedAt: time.Now()}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode login shell state: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadLoginShellState reads the recorded login shell change at path
+func LoadLoginShellState(path string) (*LoginShellState, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no login shell change has been recorded")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var state LoginShellState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &state, nil
+}
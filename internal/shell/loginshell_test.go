@@ -0,0 +1,111 @@
+package shell
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+func TestConfirmLoginShellChange(t *testing.T) {
+	change := &LoginShellChange{Current: "/bin/bash", Target: "/usr/bin/zsh", Command: "chsh -s /usr/bin/zsh"}
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"y\n", true},
+		{"YES\n", true},
+		{"\n", false},
+		{"n\n", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if got := ConfirmLoginShellChange(strings.NewReader(tt.input), &out, change); got != tt.want {
+			t.Errorf("ConfirmLoginShellChange(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+		if !strings.Contains(out.String(), "[y/N]") {
+			t.Errorf("expected a prompt, got %q", out.String())
+		}
+	}
+}
+
+func TestLoginShellChangeSummary(t *testing.T) {
+	summary := (&LoginShellChange{Current: "/bin/bash", Target: "/usr/bin/zsh", Command: "chsh -s /usr/bin/zsh"}).Summary()
+	for _, want := range []string{"/bin/bash", "/usr/bin/zsh", "bootstrap-cli shell revert", "chsh -s /bin/bash"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary missing %q:\n%s", want, summary)
+		}
+	}
+}
+
+func TestPlanLoginShellChange(t *testing.T) {
+	t.Setenv("SHELL", "/bin/sh")
+	change := PlanLoginShellChange(&interfaces.Shell{Name: "sh", Path: "/bin/sh"})
+	if change != nil {
+		t.Errorf("expected no change when the shell is already the login shell, got %+v", change)
+	}
+
+	t.Setenv("SHELL", "/bin/bash")
+	change = PlanLoginShellChange(&interfaces.Shell{Name: "sh", Path: "/bin/sh"})
+	if change == nil || change.Current != "/bin/bash" || change.Command != "chsh -s /bin/sh" {
+		t.Errorf("unexpected change %+v", change)
+	}
+
+	// A shell this run installs is found once it is there
+	path := filepath.Join(t.TempDir(), "nosuchshell")
+	change = PlanLoginShellChange(&interfaces.Shell{Name: "nosuchshell", Path: path})
+	if change == nil || change.Target != "" {
+		t.Fatalf("expected a pending change for a shell that is not installed yet, got %+v", change)
+	}
+	if !strings.Contains(change.Summary(), "nosuchshell (once it is installed)") {
+		t.Errorf("summary should name the shell to be installed:\n%s", change.Summary())
+	}
+	if err := change.Resolve(); err == nil {
+		t.Error("expected an error resolving a shell that is not installed")
+	}
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := change.Resolve(); err != nil || change.Target != path || change.Command != "chsh -s "+path {
+		t.Errorf("Resolve() = %v, change %+v; want the installed shell", err, change)
+	}
+}
+
+func TestApproveLoginShellChangeWithoutTerminal(t *testing.T) {
+	change := &LoginShellChange{Current: "/bin/bash", Target: "/usr/bin/zsh", Command: "chsh -s /usr/bin/zsh"}
+	in, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	w.Close()
+
+	var out bytes.Buffer
+	if ok, err := ApproveLoginShellChange(change, false, in, &out); ok || err != ErrLoginShellConsent {
+		t.Errorf("expected ErrLoginShellConsent without a terminal, got %v, %v", ok, err)
+	}
+	if ok, err := ApproveLoginShellChange(change, true, in, &out); !ok || err != nil {
+		t.Errorf("expected --yes to approve, got %v, %v", ok, err)
+	}
+}
+
+func TestLoginShellStateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", LoginShellStateFile)
+	if _, err := LoadLoginShellState(path); err == nil {
+		t.Error("expected an error before any change is recorded")
+	}
+	if err := SaveLoginShellState(path, &LoginShellChange{Current: "/bin/bash", Target: "/usr/bin/zsh"}); err != nil {
+		t.Fatalf("SaveLoginShellState failed: %v", err)
+	}
+	state, err := LoadLoginShellState(path)
+	if err != nil {
+		t.Fatalf("LoadLoginShellState failed: %v", err)
+	}
+	if state.Previous != "/bin/bash" || state.New != "/usr/bin/zsh" || state.ChangedAt.IsZero() {
+		t.Errorf("unexpected state %+v", state)
+	}
+}
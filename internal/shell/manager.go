@@ -5,9 +5,11 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
 )
 
 // manager implements the interfaces.ShellManager interface.
@@ -27,6 +29,10 @@ func (m *manager) DetectCurrent() (*interfaces.ShellInfo, error) {
 		// Fallback or further probing if SHELL is not set
 		// For now, try to find bash or zsh as a desperate measure
 		probeShells := []string{"zsh", "bash"}
+		if runtime.GOOS == "windows" {
+			// Windows has no $SHELL; PowerShell is the shell there
+			probeShells = []string{"pwsh", "powershell"}
+		}
 		for _, s := range probeShells {
 			p, err := exec.LookPath(s)
 			if err == nil {
@@ -39,7 +45,7 @@ func (m *manager) DetectCurrent() (*interfaces.ShellInfo, error) {
 		}
 	}
 
-	shellName := filepath.Base(shellPath)
+	shellName := shellName(shellPath)
 	
 	// Attempt to get version (simplified)
 	version := "unknown"
@@ -79,7 +85,7 @@ func (m *manager) DetectCurrent() (*interfaces.ShellInfo, error) {
 func (m *manager) ListAvailable() ([]*interfaces.ShellInfo, error) {
 	available := make([]*interfaces.ShellInfo, 0)
 	// Shells to check for. Could be expanded or made configurable.
-	potentialShells := []interfaces.ShellType{interfaces.BashShell, interfaces.ZshShell, interfaces.FishShell}
+	potentialShells := []interfaces.ShellType{interfaces.BashShell, interfaces.ZshShell, interfaces.FishShell, interfaces.NuShell, interfaces.PowerShell}
 
 	currentShellEnv := os.Getenv("SHELL")
 
@@ -110,7 +116,7 @@ func (m *manager) ListAvailable() ([]*interfaces.ShellInfo, error) {
 			}
 
 			configFiles := []string{}
-			homeDir, _ := os.UserHomeDir()
+			homeDir, _ := system.UserHome()
 			switch shellType {
 			case interfaces.BashShell:
 				configFiles = append(configFiles, filepath.Join(homeDir, ".bashrc"))
@@ -121,6 +127,10 @@ func (m *manager) ListAvailable() ([]*interfaces.ShellInfo, error) {
 				configFiles = append(configFiles, filepath.Join(homeDir, ".zshrc"))
 			case interfaces.FishShell:
 				configFiles = append(configFiles, filepath.Join(homeDir, ".config", "fish", "config.fish"))
+			case interfaces.NuShell:
+				configFiles = append(configFiles, filepath.Join(homeDir, ".config", "nushell", "env.nu"), filepath.Join(homeDir, ".config", "nushell", "config.nu"))
+			case interfaces.PowerShell:
+				configFiles = append(configFiles, PowerShellProfile(homeDir, runtime.GOOS, exec.LookPath))
 			}
 
 			info := &interfaces.ShellInfo{
@@ -0,0 +1,383 @@
+package shell
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	// LegacyMarker is the comment bootstrap-cli has historically written above lines it adds
+	LegacyMarker = "# Added by bootstrap-cli"
+	// BlockStartPrefix opens a bootstrap-managed block
+	BlockStartPrefix = "# >>> bootstrap-cli"
+	// BlockEndPrefix closes a bootstrap-managed block
+	BlockEndPrefix = "# <<< bootstrap-cli"
+)
+
+// MarkerLine is a line in an rc file that bootstrap-cli recognises as one of its markers
+type MarkerLine struct {
+	// Line is the 1-based line number
+	Line int
+	// Text is the trimmed marker line
+	Text string
+}
+
+// IsMarker reports whether line is a bootstrap-cli marker comment
+func IsMarker(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, LegacyMarker) ||
+		strings.HasPrefix(line, BlockStartPrefix) ||
+		strings.HasPrefix(line, BlockEndPrefix)
+}
+
+// ScanMarkers returns every bootstrap-cli marker line in the file at path.
+// A missing file yields no markers.
+func ScanMarkers(path string) ([]MarkerLine, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var markers []MarkerLine
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		if text := scanner.Text(); IsMarker(text) {
+			markers = append(markers, MarkerLine{Line: n, Text: strings.TrimSpace(text)})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return markers, nil
+}
+
+// ManagedBlock is a section of an rc file written by bootstrap-cli
+type ManagedBlock struct {
+	// Key names the block: the name in its start marker, or for legacy blocks any
+	// text after the marker, falling back to the first body line
+	Key string
+	// StartLine and EndLine are the 1-based line range, markers included
+	StartLine int
+	EndLine   int
+	// Body holds the lines between the markers
+	Body []string
+	// Legacy is set for blocks introduced by "# Added by bootstrap-cli", which end
+	// at the first blank line
+	Legacy bool
+	// Unterminated is set when a start marker has no matching end marker; the
+	// block then runs to the end of the file
+	Unterminated bool
+	// Version is the hash of the body bootstrap-cli wrote, from the start marker;
+	// empty for legacy blocks and blocks written before markers were versioned
+	Version string
+}
+
+// Edited reports whether the block was changed by hand since bootstrap-cli wrote
+// it, i.e. its body no longer matches the hash in its start marker
+func (b ManagedBlock) Edited() bool {
+	return b.Version != "" && BlockHash(strings.Join(b.Body, "\n")) != b.Version
+}
+
+// ListManagedBlocks returns the bootstrap-cli blocks in the rc file at path, in
+// file order. A missing file yields no blocks.
+func ListManagedBlocks(path string) ([]ManagedBlock, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return parseManagedBlocks(splitLines(string(data))), nil
+}
+
+// parseManagedBlocks finds the managed blocks in lines
+func parseManagedBlocks(lines []string) []ManagedBlock {
+	var blocks []ManagedBlock
+	var current *ManagedBlock
+	finish := func(end int) {
+		current.EndLine = end
+		blocks = append(blocks, *current)
+		current = nil
+	}
+
+	for i, line := range lines {
+		n := i + 1
+		text := strings.TrimSpace(line)
+
+		if current != nil && current.Legacy && (text == "" || IsMarker(text)) {
+			finish(n - 1)
+		}
+		if current != nil && !current.Legacy {
+			if text == BlockEnd(current.Key) {
+				finish(n)
+			} else {
+				current.Body = append(current.Body, line)
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(text, BlockStartPrefix):
+			key, version, _ := parseBlockStart(text)
+			current = &ManagedBlock{Key: key, StartLine: n, Version: version}
+		case strings.HasPrefix(text, LegacyMarker):
+			key := strings.TrimLeft(strings.TrimPrefix(text, LegacyMarker), " :-")
+			current = &ManagedBlock{Key: key, StartLine: n, Legacy: true}
+		case current != nil:
+			// Legacy block body line
+			current.Body = append(current.Body, line)
+			if current.Key == "" {
+				current.Key = text
+			}
+		}
+	}
+	if current != nil {
+		current.Unterminated = !current.Legacy
+		finish(len(lines))
+	}
+	return blocks
+}
+
+// BlockHash returns the version of a block body written in its start marker: a
+// short hash of the body, so an edited block can be told from an outdated one
+func BlockHash(body string) string {
+	sum := sha256.Sum256([]byte(strings.TrimRight(body, "\n")))
+	return hex.EncodeToString(sum[:4])
+}
+
+// BlockStart returns the line opening the managed block called name with body,
+// e.g. "# >>> bootstrap-cli fzf v1a2b3c4d >>>"
+func BlockStart(name, body string) string {
+	return fmt.Sprintf("%s %s v%s >>>", BlockStartPrefix, name, BlockHash(body))
+}
+
+// BlockEnd returns the line closing the managed block called name
+func BlockEnd(name string) string {
+	return fmt.Sprintf("%s %s <<<", BlockEndPrefix, name)
+}
+
+// parseBlockStart returns the name and version of a block start marker line;
+// blocks written before markers were versioned have no version
+func parseBlockStart(line string) (name, version string, ok bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, BlockStartPrefix) {
+		return "", "", false
+	}
+	fields := strings.Fields(strings.TrimSuffix(strings.TrimPrefix(line, BlockStartPrefix), ">>>"))
+	if n := len(fields); n > 1 && isVersion(fields[n-1]) {
+		version, fields = fields[n-1][1:], fields[:n-1]
+	}
+	return strings.Join(fields, " "), version, true
+}
+
+// isVersion reports whether field is a "v<hash>" block version
+func isVersion(field string) bool {
+	if len(field) != 9 || field[0] != 'v' {
+		return false
+	}
+	_, err := hex.DecodeString(field[1:])
+	return err == nil
+}
+
+// findBlock returns the line range of the managed block called name in lines,
+// markers included, and the version in its start marker; start is -1 when there
+// is no complete block
+func findBlock(lines []string, name string) (start, end int, version string) {
+	start = -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if start < 0 {
+			if key, v, ok := parseBlockStart(trimmed); ok && key == name {
+				start, version = i, v
+			}
+		} else if trimmed == BlockEnd(name) {
+			return start, i, version
+		}
+	}
+	return -1, -1, ""
+}
+
+// HasBlock reports whether content has a managed block called name
+func HasBlock(content, name string) bool {
+	start, _, _ := findBlock(strings.SplitAfter(content, "\n"), name)
+	return start >= 0
+}
+
+// BlockState is how a managed block in an rc file compares to the body
+// bootstrap-cli would write
+type BlockState int
+
+const (
+	// BlockMissing means there is no block of that name yet
+	BlockMissing BlockState = iota
+	// BlockCurrent means the block already has the body
+	BlockCurrent
+	// BlockOutdated means the block holds what bootstrap-cli wrote before, or was
+	// written before markers were versioned, and is replaced
+	BlockOutdated
+	// BlockEdited means the block was changed by hand and is left alone
+	BlockEdited
+)
+
+// CheckBlock returns the state of the managed block called name in content
+// against body
+func CheckBlock(content, name, body string) BlockState {
+	lines := strings.SplitAfter(content, "\n")
+	start, end, version := findBlock(lines, name)
+	if start < 0 {
+		return BlockMissing
+	}
+	current := strings.TrimRight(strings.Join(lines[start+1:end], ""), "\n")
+	switch {
+	case version == "":
+		return BlockOutdated
+	case BlockHash(current) != version:
+		return BlockEdited
+	case version == BlockHash(body) && current == strings.TrimRight(body, "\n"):
+		return BlockCurrent
+	default:
+		return BlockOutdated
+	}
+}
+
+// UpsertBlock returns content with the managed block called name set to body,
+// replacing the block if bootstrap-cli wrote it and appending it if it is missing.
+// A block edited by hand since is left alone (see CheckBlock).
+func UpsertBlock(content, name, body string) string {
+	block := BlockStart(name, body) + "\n" + strings.TrimRight(body, "\n") + "\n" + BlockEnd(name) + "\n"
+
+	lines := strings.SplitAfter(content, "\n")
+	switch CheckBlock(content, name, body) {
+	case BlockCurrent, BlockEdited:
+		return content
+	case BlockOutdated:
+		start, end, _ := findBlock(lines, name)
+		return strings.Join(lines[:start], "") + block + strings.Join(lines[end+1:], "")
+	}
+
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if content != "" {
+		content += "\n"
+	}
+	return content + block
+}
+
+// RemoveBlock returns content without the managed block called name, along with
+// the blank line UpsertBlock put before it. Content without the block is returned unchanged.
+func RemoveBlock(content, name string) string {
+	lines := strings.SplitAfter(content, "\n")
+	start, end, _ := findBlock(lines, name)
+	if start < 0 {
+		return content
+	}
+	if start > 0 && strings.TrimSpace(lines[start-1]) == "" {
+		start--
+	}
+	return strings.Join(lines[:start], "") + strings.Join(lines[end+1:], "")
+}
+
+// ConvertLegacyBlocks returns content with every legacy "# Added by
+// bootstrap-cli" block turned into a versioned managed block, and the names of
+// the blocks converted. A block is named after the text following its marker,
+// e.g. "# Added by bootstrap-cli: nvm", or legacy-<n> when there is none.
+func ConvertLegacyBlocks(content string) (string, []string) {
+	lines := splitLines(content)
+	var out, names []string
+	last, legacy := 0, 0
+	for _, b := range parseManagedBlocks(lines) {
+		if !b.Legacy {
+			continue
+		}
+		name := legacyBlockName(lines[b.StartLine-1])
+		for name == "" || HasBlock(content, name) || containsBlock(names, name) {
+			legacy++
+			name = fmt.Sprintf("legacy-%d", legacy)
+		}
+		out = append(out, lines[last:b.StartLine-1]...)
+		out = append(out, BlockStart(name, strings.Join(b.Body, "\n")))
+		out = append(out, b.Body...)
+		out = append(out, BlockEnd(name))
+		last = b.EndLine
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return content, nil
+	}
+	out = append(out, lines[last:]...)
+	converted := strings.Join(out, "\n")
+	if strings.HasSuffix(content, "\n") {
+		converted += "\n"
+	}
+	return converted, names
+}
+
+// legacyBlockName returns the name given after a legacy marker, e.g. "nvm" for
+// "# Added by bootstrap-cli: nvm", with spaces turned into dashes
+func legacyBlockName(marker string) string {
+	name := strings.TrimLeft(strings.TrimPrefix(strings.TrimSpace(marker), LegacyMarker), " :-")
+	return strings.Join(strings.Fields(name), "-")
+}
+
+// StripManagedBlocks returns content without any bootstrap-cli block, legacy
+// "# Added by bootstrap-cli" blocks included, and the keys of the blocks removed
+func StripManagedBlocks(content string) (string, []string) {
+	lines := splitLines(content)
+	blocks := parseManagedBlocks(lines)
+	if len(blocks) == 0 {
+		return content, nil
+	}
+	drop := make([]bool, len(lines))
+	keys := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		start := b.StartLine - 1
+		// Also drop the blank line written before the block
+		if start > 0 && strings.TrimSpace(lines[start-1]) == "" {
+			start--
+		}
+		for n := start; n < b.EndLine; n++ {
+			drop[n] = true
+		}
+		keys = append(keys, b.Key)
+	}
+	var kept []string
+	for n, line := range lines {
+		if !drop[n] {
+			kept = append(kept, line)
+		}
+	}
+	stripped := strings.Join(kept, "\n")
+	if stripped != "" && strings.HasSuffix(content, "\n") {
+		stripped += "\n"
+	}
+	return stripped, keys
+}
+
+// RCFiles returns the shell startup files bootstrap-cli may modify under home,
+// and the tmux configs it writes a block into
+func RCFiles(home string) []string {
+	return append([]string{
+		filepath.Join(home, ".tmux.conf"),
+		filepath.Join(home, ".config", "tmux", "tmux.conf"),
+		filepath.Join(home, ".bashrc"),
+		filepath.Join(home, ".bash_profile"),
+		filepath.Join(home, ".profile"),
+		filepath.Join(home, ".zshrc"),
+		filepath.Join(home, ".zprofile"),
+		filepath.Join(home, ".config", "fish", "config.fish"),
+		filepath.Join(home, ".config", "nushell", "env.nu"),
+		filepath.Join(home, ".config", "nushell", "config.nu"),
+	}, powerShellProfiles(home, runtime.GOOS)...)
+}
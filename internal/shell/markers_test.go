@@ -0,0 +1,238 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScanMarkers(t *testing.T) {
+	rc := filepath.Join(t.TempDir(), ".zshrc")
+	content := `export EDITOR=vim
+# Added by bootstrap-cli
+source ~/.zsh/bat.zsh
+# >>> bootstrap-cli fzf >>>
+source ~/.fzf.zsh
+# <<< bootstrap-cli fzf <<<
+`
+	if err := os.WriteFile(rc, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write rc file: %v", err)
+	}
+
+	markers, err := ScanMarkers(rc)
+	if err != nil {
+		t.Fatalf("ScanMarkers() error = %v", err)
+	}
+	if len(markers) != 3 {
+		t.Fatalf("Expected 3 markers, got %d: %v", len(markers), markers)
+	}
+	if markers[0].Line != 2 || markers[0].Text != LegacyMarker {
+		t.Errorf("Unexpected first marker: %+v", markers[0])
+	}
+	if markers[2].Line != 6 {
+		t.Errorf("Expected closing marker on line 6, got %d", markers[2].Line)
+	}
+}
+
+func TestScanMarkersMissingFile(t *testing.T) {
+	markers, err := ScanMarkers(filepath.Join(t.TempDir(), "missing"))
+	if err != nil {
+		t.Fatalf("ScanMarkers() error = %v", err)
+	}
+	if len(markers) != 0 {
+		t.Errorf("Expected no markers, got %v", markers)
+	}
+}
+
+func TestUpsertBlock(t *testing.T) {
+	content := "export EDITOR=vim"
+	added := UpsertBlock(content, "fzf", "source ~/.fzf.zsh\n")
+	want := "export EDITOR=vim\n\n" + BlockStart("fzf", "source ~/.fzf.zsh") + "\nsource ~/.fzf.zsh\n# <<< bootstrap-cli fzf <<<\n"
+	if added != want {
+		t.Fatalf("UpsertBlock() appended\n%q\nwant\n%q", added, want)
+	}
+
+	updated := UpsertBlock(added+"alias ll='ls -l'\n", "fzf", "source ~/.fzf.bash")
+	want = "export EDITOR=vim\n\n" + BlockStart("fzf", "source ~/.fzf.bash") + "\nsource ~/.fzf.bash\n# <<< bootstrap-cli fzf <<<\nalias ll='ls -l'\n"
+	if updated != want {
+		t.Errorf("UpsertBlock() replaced\n%q\nwant\n%q", updated, want)
+	}
+	if again := UpsertBlock(updated, "fzf", "source ~/.fzf.bash"); again != updated {
+		t.Errorf("UpsertBlock() should be idempotent, got\n%q", again)
+	}
+}
+
+func TestUpsertBlockStates(t *testing.T) {
+	fzf := func(body string) string {
+		return BlockStart("fzf", body) + "\n" + body + "\n" + BlockEnd("fzf") + "\n"
+	}
+	edited := strings.Replace(fzf("source ~/.fzf.zsh"), "source ~/.fzf.zsh\n", "source ~/.fzf.zsh\nexport FZF_DEFAULT_OPTS=--reverse\n", 1)
+	tests := []struct {
+		name    string
+		content string
+		body    string
+		state   BlockState
+		want    string
+	}{
+		{
+			name:  "fresh file",
+			body:  "source ~/.fzf.zsh",
+			state: BlockMissing,
+			want:  fzf("source ~/.fzf.zsh"),
+		},
+		{
+			name:    "existing identical block",
+			content: "export EDITOR=vim\n\n" + fzf("source ~/.fzf.zsh"),
+			body:    "source ~/.fzf.zsh",
+			state:   BlockCurrent,
+			want:    "export EDITOR=vim\n\n" + fzf("source ~/.fzf.zsh"),
+		},
+		{
+			name:    "existing outdated block",
+			content: "export EDITOR=vim\n\n" + fzf("source ~/.fzf.bash") + "alias ll='ls -l'\n",
+			body:    "source ~/.fzf.zsh",
+			state:   BlockOutdated,
+			want:    "export EDITOR=vim\n\n" + fzf("source ~/.fzf.zsh") + "alias ll='ls -l'\n",
+		},
+		{
+			name:    "block written before markers were versioned",
+			content: "# >>> bootstrap-cli fzf >>>\nsource ~/.fzf.zsh\n# <<< bootstrap-cli fzf <<<\n",
+			body:    "source ~/.fzf.zsh",
+			state:   BlockOutdated,
+			want:    fzf("source ~/.fzf.zsh"),
+		},
+		{
+			name:    "user-edited block",
+			content: edited,
+			body:    "source ~/.fzf.bash",
+			state:   BlockEdited,
+			want:    edited,
+		},
+		{
+			name:    "multiple sections in one file",
+			content: fzf("source ~/.fzf.bash") + "\n" + BlockStart("fzf-completion", "complete fzf") + "\ncomplete fzf\n" + BlockEnd("fzf-completion") + "\n",
+			body:    "source ~/.fzf.zsh",
+			state:   BlockOutdated,
+			want:    fzf("source ~/.fzf.zsh") + "\n" + BlockStart("fzf-completion", "complete fzf") + "\ncomplete fzf\n" + BlockEnd("fzf-completion") + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if state := CheckBlock(tt.content, "fzf", tt.body); state != tt.state {
+				t.Errorf("CheckBlock() = %v, want %v", state, tt.state)
+			}
+			got := UpsertBlock(tt.content, "fzf", tt.body)
+			if got != tt.want {
+				t.Errorf("UpsertBlock() =\n%q\nwant\n%q", got, tt.want)
+			}
+			if again := UpsertBlock(got, "fzf", tt.body); again != got {
+				t.Errorf("UpsertBlock() should be idempotent, got\n%q", again)
+			}
+		})
+	}
+}
+
+func TestConvertLegacyBlocks(t *testing.T) {
+	content := `export EDITOR=vim
+# Added by bootstrap-cli: nvm
+export NVM_DIR="$HOME/.nvm"
+
+# Added by bootstrap-cli
+source ~/.zsh/bat.zsh
+`
+	converted, names := ConvertLegacyBlocks(content)
+	if strings.Join(names, ",") != "nvm,legacy-1" {
+		t.Errorf("Unexpected converted block names %v", names)
+	}
+	want := "export EDITOR=vim\n" +
+		BlockStart("nvm", `export NVM_DIR="$HOME/.nvm"`) + "\nexport NVM_DIR=\"$HOME/.nvm\"\n" + BlockEnd("nvm") + "\n\n" +
+		BlockStart("legacy-1", "source ~/.zsh/bat.zsh") + "\nsource ~/.zsh/bat.zsh\n" + BlockEnd("legacy-1") + "\n"
+	if converted != want {
+		t.Errorf("ConvertLegacyBlocks() =\n%q\nwant\n%q", converted, want)
+	}
+	if again, names := ConvertLegacyBlocks(converted); again != converted || names != nil {
+		t.Errorf("Expected converted blocks to be left alone, got %v", names)
+	}
+
+	// The converted nvm block is then updated in place like any other
+	if updated := UpsertBlock(converted, "nvm", `export NVM_DIR="$HOME/.config/nvm"`); strings.Count(updated, "NVM_DIR") != 1 {
+		t.Errorf("Expected the legacy nvm block to be replaced, got\n%s", updated)
+	}
+}
+
+func TestRemoveBlock(t *testing.T) {
+	content := UpsertBlock(UpsertBlock("export EDITOR=vim\n", "fzf", "source ~/.fzf.zsh"), "bat", "alias cat=bat")
+	removed := RemoveBlock(content, "fzf")
+	want := "export EDITOR=vim\n\n" + BlockStart("bat", "alias cat=bat") + "\nalias cat=bat\n# <<< bootstrap-cli bat <<<\n"
+	if removed != want {
+		t.Errorf("RemoveBlock() left\n%q\nwant\n%q", removed, want)
+	}
+	if again := RemoveBlock(removed, "fzf"); again != removed {
+		t.Errorf("RemoveBlock() of a missing block changed the content:\n%q", again)
+	}
+}
+
+func TestListManagedBlocks(t *testing.T) {
+	rc := filepath.Join(t.TempDir(), ".zshrc")
+	content := `export EDITOR=vim
+# Added by bootstrap-cli
+source ~/.zsh/bat.zsh
+
+# >>> bootstrap-cli fzf >>>
+source ~/.fzf.zsh
+# <<< bootstrap-cli fzf <<<
+# Added by bootstrap-cli: nvm
+export NVM_DIR="$HOME/.nvm"
+# >>> bootstrap-cli rust >>>
+. "$HOME/.cargo/env"
+`
+	if err := os.WriteFile(rc, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write rc file: %v", err)
+	}
+
+	blocks, err := ListManagedBlocks(rc)
+	if err != nil {
+		t.Fatalf("ListManagedBlocks() error = %v", err)
+	}
+	want := []ManagedBlock{
+		{Key: "source ~/.zsh/bat.zsh", StartLine: 2, EndLine: 3, Body: []string{"source ~/.zsh/bat.zsh"}, Legacy: true},
+		{Key: "fzf", StartLine: 5, EndLine: 7, Body: []string{"source ~/.fzf.zsh"}},
+		{Key: "nvm", StartLine: 8, EndLine: 9, Body: []string{`export NVM_DIR="$HOME/.nvm"`}, Legacy: true},
+		{Key: "rust", StartLine: 10, EndLine: 11, Body: []string{`. "$HOME/.cargo/env"`}, Unterminated: true},
+	}
+	if !reflect.DeepEqual(blocks, want) {
+		t.Errorf("ListManagedBlocks() =\n%+v\nwant\n%+v", blocks, want)
+	}
+
+	missing, err := ListManagedBlocks(filepath.Join(t.TempDir(), "missing"))
+	if err != nil || missing != nil {
+		t.Errorf("Expected no blocks for a missing file, got %v, %v", missing, err)
+	}
+}
+
+func TestStripManagedBlocks(t *testing.T) {
+	content := `export EDITOR=vim
+
+# Added by bootstrap-cli
+source ~/.zsh/bat.zsh
+
+# >>> bootstrap-cli fzf >>>
+source ~/.fzf.zsh
+# <<< bootstrap-cli fzf <<<
+alias ll='ls -l'
+`
+	stripped, keys := StripManagedBlocks(content)
+	want := "export EDITOR=vim\nalias ll='ls -l'\n"
+	if stripped != want {
+		t.Errorf("StripManagedBlocks() = %q, want %q", stripped, want)
+	}
+	if strings.Join(keys, ",") != "source ~/.zsh/bat.zsh,fzf" {
+		t.Errorf("Unexpected block keys %v", keys)
+	}
+
+	if got, keys := StripManagedBlocks(want); got != want || keys != nil {
+		t.Errorf("Expected content without blocks to be unchanged, got %q %v", got, keys)
+	}
+}
@@ -0,0 +1,60 @@
+package shell
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MiseBlock names the managed block that activates mise in shell rc files
+const MiseBlock = "mise"
+
+// miseActivate returns the code that activates the mise executable at path for
+// shellName, which puts the versions mise installed on PATH in every prompt
+func miseActivate(shellName, path string) string {
+	switch shellName {
+	case "fish":
+		return fmt.Sprintf("%s activate fish | source", fishWord(path))
+	case "nu":
+		// Like the prompts, the activation script goes to the vendor autoload
+		// directory, as nushell cannot eval generated code
+		return fmt.Sprintf(`mkdir ($nu.data-dir | path join "vendor/autoload")
+^%s activate nu | save -f ($nu.data-dir | path join "vendor/autoload/mise.nu")`, NuQuote(path))
+	case "pwsh":
+		return fmt.Sprintf("(& %s activate pwsh) | Out-String | Invoke-Expression", PSQuote(path))
+	default:
+		return fmt.Sprintf(`eval "$(%s activate %s)"`, shWord(path), shellName)
+	}
+}
+
+// EnsureMiseActivate activates the mise executable at path from a managed block
+// in the rc file of shellName under home
+func EnsureMiseActivate(home, shellName, path string, rc *RCWriter) error {
+	rcFile := rcFileForShell(home, shellName)
+	if rcFile == "" {
+		return fmt.Errorf("unsupported shell for mise: %s", shellName)
+	}
+	_, err := rc.UpsertBlock(rcFile, MiseBlock, miseActivate(shellName, path))
+	return err
+}
+
+// plainWord reports whether s needs no quoting in a shell command
+func plainWord(s string) bool {
+	return s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-./") == ""
+}
+
+// shWord single-quotes s for sh, bash and zsh unless it is a plain word
+func shWord(s string) string {
+	if plainWord(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishWord single-quotes s for fish, which escapes quotes with a backslash,
+// unless it is a plain word
+func fishWord(s string) string {
+	if plainWord(s) {
+		return s
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
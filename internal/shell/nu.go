@@ -0,0 +1,235 @@
+package shell
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// bashPositional matches bash positional parameters: $1, ${1}, $@ and $*
+var bashPositional = regexp.MustCompile(`\$@|\$\*|\$\{([1-9])\}|\$([1-9])`)
+
+// bashVariable matches a bash variable reference, $VAR
+var bashVariable = regexp.MustCompile(`\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// NuQuote quotes s as a nushell string with no interpolation: a raw single-quoted
+// string when s has no single quote, a double-quoted one with backslash escapes
+// otherwise
+func NuQuote(s string) string {
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// NuValue converts a value written for a POSIX shell (an env var or PATH entry
+// from a tool's shell_config) to a nushell expression with the same meaning:
+// $VAR and ${VAR} read $env.VAR, $(cmd) becomes a subexpression, a leading ~
+// expands to $env.HOME, and everything else stays literal. A value mixing
+// literals and expansions becomes an interpolated string. Parameter expansions
+// with operators (${VAR:-x}, ${SHELL##*/}) have no nushell equivalent and are
+// an error.
+func NuValue(s string) (string, error) {
+	type part struct {
+		text string
+		expr bool
+		// sub marks a command substitution, which needs parentheses on its own
+		sub bool
+	}
+	var parts []part
+	var exprs int
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			parts = append(parts, part{text: literal.String()})
+			literal.Reset()
+		}
+	}
+	expr := func(e string, sub bool) {
+		flush()
+		parts = append(parts, part{text: e, expr: true, sub: sub})
+		exprs++
+	}
+
+	if strings.HasPrefix(s, "~/") || s == "~" {
+		expr("$env.HOME", false)
+		s = s[1:]
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			literal.WriteByte(s[i])
+			continue
+		}
+		switch next := s[i+1]; {
+		case next == '(':
+			end := matchingParen(s, i+1)
+			if end < 0 {
+				return "", fmt.Errorf("unterminated command substitution in %q", s)
+			}
+			// str trim drops the trailing newline, like "$(cmd)"
+			expr(strings.TrimSpace(s[i+2:end])+" | str trim", true)
+			i = end
+		case next == '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated parameter expansion in %q", s)
+			}
+			name := s[i+2 : i+end]
+			if !isShellName(name) {
+				return "", fmt.Errorf("parameter expansion ${%s} has no nushell equivalent", name)
+			}
+			expr("$env."+name, false)
+			i += end
+		case isShellNameByte(next, true):
+			j := i + 1
+			for j < len(s) && isShellNameByte(s[j], false) {
+				j++
+			}
+			expr("$env."+s[i+1:j], false)
+			i = j - 1
+		default:
+			literal.WriteByte(s[i])
+		}
+	}
+	flush()
+
+	switch {
+	case exprs == 0:
+		if len(parts) == 0 {
+			return "''", nil
+		}
+		return NuQuote(parts[0].text), nil
+	case len(parts) == 1:
+		// A lone expansion needs no string around it
+		if parts[0].sub {
+			return "(" + parts[0].text + ")", nil
+		}
+		return parts[0].text, nil
+	}
+	var b strings.Builder
+	b.WriteString(`$"`)
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "(", `\(`, ")", `\)`)
+	for _, p := range parts {
+		if p.expr {
+			b.WriteString("(" + p.text + ")")
+		} else {
+			b.WriteString(escape.Replace(p.text))
+		}
+	}
+	b.WriteString(`"`)
+	return b.String(), nil
+}
+
+// NuAlias converts the command of a POSIX alias to the body of a nushell alias.
+// The command runs as an external (^cmd) so aliases such as ls=lsd keep calling
+// the program rather than a nushell builtin of the same name. Commands using
+// shell syntax nushell reads differently ($VAR, &&, ;) are rejected.
+func NuAlias(command string) (string, error) {
+	for _, construct := range []string{"$", "`", "&&", "||", ";"} {
+		if strings.Contains(command, construct) {
+			return "", fmt.Errorf("alias command uses %q, which nushell does not support", construct)
+		}
+	}
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return "", fmt.Errorf("empty alias command")
+	}
+	if !strings.HasPrefix(command, "^") {
+		command = "^" + command
+	}
+	return command, nil
+}
+
+// NuFunctionBody converts the simple bash-isms of a function body to nushell,
+// for a command defined as `def --env --wrapped name [...args]`: positional
+// parameters become $args, $VAR becomes $env.VAR and $(cmd) a subexpression.
+// Single-quoted text is left alone. Bodies using other bash syntax (${VAR:-x},
+// [[ ]], &&, local, export) are rejected rather than written as a command
+// nushell would fail to parse.
+func NuFunctionBody(body string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(body); {
+		switch body[i] {
+		case '\'':
+			end := strings.IndexByte(body[i+1:], '\'')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated single quote in %q", body)
+			}
+			b.WriteString(body[i : i+end+2])
+			i += end + 2
+		case '"':
+			end := strings.IndexByte(body[i+1:], '"')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated double quote in %q", body)
+			}
+			word, err := nuQuotedWord(body[i+1 : i+1+end])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(word)
+			i += end + 2
+		default:
+			j := i
+			for j < len(body) && body[j] != '\'' && body[j] != '"' {
+				if body[j] == '$' && j+1 < len(body) && body[j+1] == '(' {
+					// A quote inside $(...) belongs to the substitution
+					if end := matchingParen(body, j+1); end > 0 {
+						j = end
+					}
+				}
+				j++
+			}
+			code, err := nuCode(body[i:j])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(code)
+			i = j
+		}
+	}
+	return b.String(), nil
+}
+
+// nuQuotedWord converts the contents of a double-quoted bash word: a word that
+// is a single expansion ("$1", "$@", "$VAR", "$(cmd)") becomes that expansion,
+// and a word without $ stays quoted. Nushell does not expand $ inside plain
+// double quotes, so anything else is rejected.
+func nuQuotedWord(word string) (string, error) {
+	if !strings.Contains(word, "$") {
+		return `"` + word + `"`, nil
+	}
+	if strings.HasPrefix(word, "$(") && matchingParen(word, 1) == len(word)-1 {
+		return "(" + strings.TrimSpace(word[2:len(word)-1]) + " | str trim)", nil
+	}
+	if m := bashPositional.FindString(word); m == word {
+		return nuPositionalArg(m), nil
+	}
+	if m := bashVariable.FindString(word); m == word {
+		return "$env." + word[1:], nil
+	}
+	return "", fmt.Errorf("function body expands %q inside double quotes, which nushell does not support", word)
+}
+
+// nuCode converts unquoted bash code
+func nuCode(code string) (string, error) {
+	code = bashVariable.ReplaceAllString(code, "$$env.$1")
+	code = bashPositional.ReplaceAllStringFunc(code, nuPositionalArg)
+	for _, construct := range []string{"${", "[[", "&&", "||", "local ", "export "} {
+		if strings.Contains(code, construct) {
+			return "", fmt.Errorf("function body uses %q, which nushell does not support", strings.TrimSpace(construct))
+		}
+	}
+	// $(cmd) becomes the subexpression (cmd)
+	return strings.ReplaceAll(code, "$(", "("), nil
+}
+
+// nuPositionalArg converts one positional parameter to the rest argument of a
+// wrapped nushell command
+func nuPositionalArg(m string) string {
+	sub := bashPositional.FindStringSubmatch(m)
+	n := sub[1] + sub[2]
+	if n == "" {
+		return "...$args"
+	}
+	return fmt.Sprintf("$args.%d", n[0]-'1')
+}
@@ -0,0 +1,160 @@
+package shell
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+)
+
+func TestNuQuote(t *testing.T) {
+	tests := map[string]string{
+		"ls -la":              "'ls -la'",
+		`C:\dir`:              `'C:\dir'`,
+		"fzf --preview 'bat'": `"fzf --preview 'bat'"`,
+		`say "it's"`:          `"say \"it's\""`,
+		"":                    "''",
+	}
+	for in, want := range tests {
+		if got := NuQuote(in); got != want {
+			t.Errorf("NuQuote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestNuValue(t *testing.T) {
+	tests := []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{in: "--height 40% --layout=reverse", want: "'--height 40% --layout=reverse'"},
+		{in: "$FZF_DEFAULT_COMMAND", want: "$env.FZF_DEFAULT_COMMAND"},
+		{in: "${HOME}/.cargo/bin", want: `$"($env.HOME)/.cargo/bin"`},
+		{in: "~/.fzf/bin", want: `$"($env.HOME)/.fzf/bin"`},
+		{in: "$(vivid generate molokai)", want: "(vivid generate molokai | str trim)"},
+		{in: "$HOME/bin (local)", want: `$"($env.HOME)/bin \(local\)"`},
+		{in: "cost: $5", want: "'cost: $5'"},
+		{in: "${SHELL##*/}", wantErr: true},
+		{in: "$(unterminated", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := NuValue(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("NuValue(%q) = %s, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("NuValue(%q) = %s, %v; want %s", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestNuAlias(t *testing.T) {
+	if got, err := NuAlias("lsd --group-dirs first"); err != nil || got != "^lsd --group-dirs first" {
+		t.Errorf("NuAlias() = %s, %v", got, err)
+	}
+	for _, command := range []string{"cd $HOME", "make && make install", "a; b"} {
+		if got, err := NuAlias(command); err == nil {
+			t.Errorf("NuAlias(%q) = %s, want an error", command, got)
+		}
+	}
+}
+
+func TestNuFunctionBody(t *testing.T) {
+	tests := map[string]string{
+		`cd "$(fd --type d | fzf)"`:    `cd (fd --type d | fzf | str trim)`,
+		`git commit -m "$1" "$@"`:      `git commit -m $args.0 ...$args`,
+		`echo $EDITOR ${2}`:            `echo $env.EDITOR $args.1`,
+		`awk '{print $2}' "notes.txt"`: `awk '{print $2}' "notes.txt"`,
+	}
+	for in, want := range tests {
+		if got, err := NuFunctionBody(in); err != nil || got != want {
+			t.Errorf("NuFunctionBody(%q) = %s, %v; want %s", in, got, err, want)
+		}
+	}
+	for _, body := range []string{"kill -${1:-9}", "[[ -n $1 ]] && echo yes", `echo "hello $USER"`} {
+		if _, err := NuFunctionBody(body); err == nil {
+			t.Errorf("Expected %q to be rejected", body)
+		}
+	}
+}
+
+func TestNuConfigWriter(t *testing.T) {
+	writer, tmpDir, cleanup := testConfigWriter(t, interfaces.NuShell)
+	defer cleanup()
+
+	if err := writer.SetEnvVar("EDITOR", "nvim"); err != nil {
+		t.Fatalf("SetEnvVar() error = %v", err)
+	}
+	if err := writer.AddToPath("$HOME/.cargo/bin"); err != nil {
+		t.Fatalf("AddToPath() error = %v", err)
+	}
+	if err := writer.AddAlias("ll", "lsd -l"); err != nil {
+		t.Fatalf("AddAlias() error = %v", err)
+	}
+	if err := writer.AddAlias("up", "cd .. && ls"); err == nil {
+		t.Error("Expected an alias nushell cannot run to be rejected")
+	}
+
+	configFile := writer.getConfigFile()
+	if configFile != filepath.Join(tmpDir, ".config", "nushell", "config.nu") {
+		t.Fatalf("getConfigFile() = %s, want config.nu under ~/.config/nushell", configFile)
+	}
+	content, err := os.ReadFile(configFile)
+	if err != nil {
+		t.Fatalf("Failed to read config file: %v", err)
+	}
+	want := "$env.EDITOR = 'nvim'\n" +
+		"$env.PATH = ($env.PATH | split row (char esep) | prepend $\"($env.HOME)/.cargo/bin\")\n" +
+		"alias ll = ^lsd -l\n"
+	if string(content) != want {
+		t.Errorf("config.nu = %q, want %q", content, want)
+	}
+	if !writer.HasConfig("alias ll = ^lsd -l") {
+		t.Error("Expected HasConfig() to find the alias")
+	}
+}
+
+// TestNuBlocksParse checks every kind of generated nushell snippet with nushell's
+// own parser, so constructs nushell would reject are caught
+func TestNuBlocksParse(t *testing.T) {
+	nu, err := exec.LookPath("nu")
+	if err != nil {
+		t.Skip("nu is not installed")
+	}
+
+	c := NewConfig("nu", log.NewMockLogger())
+	c.AddEnvVar("FZF_DEFAULT_OPTS", "--height 40% --layout=reverse --border")
+	c.AddEnvVar("FZF_CTRL_T_COMMAND", "$FZF_DEFAULT_COMMAND")
+	c.AddEnvVar("LS_COLORS", "$(vivid generate molokai)")
+	c.AddAlias("preview", "fzf --preview 'bat --style=numbers --color=always {}'")
+	c.AddFunction("fcd", `cd "$(fd --type d --hidden --follow --exclude .git | fzf)"`)
+	c.AddPath("$HOME/.cargo/bin")
+	generated, err := c.GenerateConfig()
+	if err != nil {
+		t.Fatalf("GenerateConfig() error = %v", err)
+	}
+
+	blocks := map[string]string{
+		"generated": generated,
+		"starship":  promptInit(PromptStarship, "nu", ""),
+		"ohmyposh":  promptInit(PromptOhMyPosh, "nu", "/tmp/theme.omp.json"),
+		"managed":   UpsertBlock("", "fzf", "$env.FZF_DEFAULT_COMMAND = 'fd --type f'"),
+	}
+	for name, block := range blocks {
+		path := filepath.Join(t.TempDir(), name+".nu")
+		if err := os.WriteFile(path, []byte(block), 0644); err != nil {
+			t.Fatal(err)
+		}
+		out, err := exec.Command(nu, "--no-config-file", "-c", "nu-check "+path).CombinedOutput()
+		if err != nil || strings.TrimSpace(string(out)) != "true" {
+			t.Errorf("nushell rejected the %s block: %v\n%s\n%s", name, err, out, block)
+		}
+	}
+}
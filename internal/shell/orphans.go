@@ -0,0 +1,86 @@
+package shell
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// completionSuffix marks the block that loads a tool's completions
+const completionSuffix = "-completion"
+
+// ReservedBlocks are the managed blocks written for version managers, Homebrew
+// and the prompt rather than for a catalog tool, so they are never orphaned
+var ReservedBlocks = []string{"nvm", "pyenv", "goenv", "rust", AsdfBlock, MiseBlock, BrewBlock, PromptBlock}
+
+// OrphanedBlock is a managed rc block for a tool that is no longer in the
+// catalog, typically after an upgrade dropped it
+type OrphanedBlock struct {
+	Path  string
+	Block ManagedBlock
+	// Tool is the catalog tool the block was written for
+	Tool string
+}
+
+// String renders e.g. "~/.zshrc: exa (lines 10-12)"
+func (o OrphanedBlock) String() string {
+	return fmt.Sprintf("%s: %s (lines %d-%d)", o.Path, o.Block.Key, o.Block.StartLine, o.Block.EndLine)
+}
+
+// FindOrphanedBlocks returns the managed blocks in the rc files under home
+// named after a tool that is not in tools. Legacy blocks are skipped since
+// their keys are free text.
+func FindOrphanedBlocks(home string, tools []string) ([]OrphanedBlock, error) {
+	known := make(map[string]bool, len(tools)+len(ReservedBlocks))
+	for _, name := range tools {
+		known[name] = true
+	}
+	for _, name := range ReservedBlocks {
+		known[name] = true
+	}
+
+	var orphans []OrphanedBlock
+	for _, rc := range RCFiles(home) {
+		blocks, err := ListManagedBlocks(rc)
+		if err != nil {
+			return nil, err
+		}
+		for _, block := range blocks {
+			tool := strings.TrimSuffix(block.Key, completionSuffix)
+			if block.Legacy || tool == "" || known[tool] {
+				continue
+			}
+			orphans = append(orphans, OrphanedBlock{Path: rc, Block: block, Tool: tool})
+		}
+	}
+	return orphans, nil
+}
+
+// RemoveOrphanedBlocks deletes orphans through w, which previews them in
+// dry-run mode
+func RemoveOrphanedBlocks(w *RCWriter, orphans []OrphanedBlock) error {
+	for _, o := range orphans {
+		if _, err := w.RemoveBlock(o.Path, o.Block.Key); err != nil {
+			return fmt.Errorf("failed to remove %s block from %s: %w", o.Block.Key, o.Path, err)
+		}
+	}
+	return nil
+}
+
+// PromptRemoveOrphanedBlocks lists orphans on out and asks whether to remove
+// them, reading the answer from in; the default is no
+func PromptRemoveOrphanedBlocks(orphans []OrphanedBlock, in io.Reader, out io.Writer) bool {
+	fmt.Fprintln(out, "These shell rc blocks belong to tools that are no longer in the catalog:")
+	for _, o := range orphans {
+		fmt.Fprintf(out, "  %s\n", o)
+	}
+	fmt.Fprint(out, "Remove them? [y/N] ")
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,54 @@
+package shell
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindOrphanedBlocks(t *testing.T) {
+	home := t.TempDir()
+	zshrc := filepath.Join(home, ".zshrc")
+	content := UpsertBlock("", "bat", "source ~/.zsh/bat.zsh")
+	content = UpsertBlock(content, "exa", "source ~/.zsh/exa.zsh")
+	content = UpsertBlock(content, "exa-completion", "fpath+=~/.zsh/completions")
+	content = UpsertBlock(content, "nvm", "export NVM_DIR=\"$HOME/.nvm\"")
+	content = UpsertBlock(content, PromptBlock, "eval \"$(starship init zsh)\"")
+	content += LegacyMarker + "\nalias old=true\n"
+	if err := os.WriteFile(zshrc, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	orphans, err := FindOrphanedBlocks(home, []string{"bat"})
+	if err != nil {
+		t.Fatalf("FindOrphanedBlocks() error = %v", err)
+	}
+	if len(orphans) != 2 || orphans[0].Block.Key != "exa" || orphans[1].Block.Key != "exa-completion" || orphans[1].Tool != "exa" {
+		t.Fatalf("Expected the exa and exa-completion blocks, got %v", orphans)
+	}
+
+	w := NewRCWriter()
+	w.DryRun = false
+	if err := RemoveOrphanedBlocks(w, orphans); err != nil {
+		t.Fatalf("RemoveOrphanedBlocks() error = %v", err)
+	}
+	data, _ := os.ReadFile(zshrc)
+	if strings.Contains(string(data), "exa") || !strings.Contains(string(data), "bat.zsh") || !strings.Contains(string(data), "NVM_DIR") {
+		t.Errorf("Expected only the exa blocks removed, got:\n%s", data)
+	}
+}
+
+func TestPromptRemoveOrphanedBlocks(t *testing.T) {
+	orphans := []OrphanedBlock{{Path: "/home/me/.zshrc", Block: ManagedBlock{Key: "exa", StartLine: 4, EndLine: 6}, Tool: "exa"}}
+	for answer, want := range map[string]bool{"y\n": true, "YES\n": true, "\n": false, "n\n": false} {
+		var out bytes.Buffer
+		if got := PromptRemoveOrphanedBlocks(orphans, strings.NewReader(answer), &out); got != want {
+			t.Errorf("answer %q = %v, want %v", answer, got, want)
+		}
+		if !strings.Contains(out.String(), "/home/me/.zshrc: exa (lines 4-6)") {
+			t.Errorf("Expected the orphan to be listed, got %q", out.String())
+		}
+	}
+}
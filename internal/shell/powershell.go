@@ -0,0 +1,190 @@
+package shell
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// powerShellProfileName is the file of $PROFILE.CurrentUserCurrentHost for the
+// console host
+const powerShellProfileName = "Microsoft.PowerShell_profile.ps1"
+
+// PowerShellProfile returns $PROFILE.CurrentUserCurrentHost under home on goos:
+// Documents\PowerShell on Windows, or Documents\WindowsPowerShell when only
+// Windows PowerShell 5 is installed according to lookPath, and
+// ~/.config/powershell elsewhere
+func PowerShellProfile(home, goos string, lookPath func(string) (string, error)) string {
+	if goos != "windows" {
+		return filepath.Join(home, ".config", "powershell", powerShellProfileName)
+	}
+	if _, err := lookPath("pwsh"); err != nil {
+		if _, err := lookPath("powershell"); err == nil {
+			return filepath.Join(home, "Documents", "WindowsPowerShell", powerShellProfileName)
+		}
+	}
+	return filepath.Join(home, "Documents", "PowerShell", powerShellProfileName)
+}
+
+// powerShellProfiles returns every profile bootstrap-cli may have written under
+// home on goos
+func powerShellProfiles(home, goos string) []string {
+	if goos != "windows" {
+		return []string{filepath.Join(home, ".config", "powershell", powerShellProfileName)}
+	}
+	return []string{
+		filepath.Join(home, "Documents", "PowerShell", powerShellProfileName),
+		filepath.Join(home, "Documents", "WindowsPowerShell", powerShellProfileName),
+	}
+}
+
+// PSQuote quotes s as a literal PowerShell string; single quotes are doubled
+func PSQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// PSValue converts a value written for a POSIX shell (an env var or PATH entry
+// from a tool's shell_config) to a PowerShell string with the same meaning:
+// $VAR and ${VAR} read $env:VAR, $(cmd) stays a subexpression, a leading ~
+// expands to $HOME, and everything else is literal. Parameter expansions with
+// operators (${VAR:-x}, ${SHELL##*/}) have no PowerShell equivalent and are an
+// error.
+func PSValue(s string) (string, error) {
+	var b strings.Builder
+	expanded := false
+	escape := strings.NewReplacer("`", "``", `"`, "`\"", "$", "`$")
+
+	if strings.HasPrefix(s, "~/") || s == "~" {
+		b.WriteString("$HOME")
+		expanded = true
+		s = s[1:]
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteString(escape.Replace(s[i : i+1]))
+			continue
+		}
+		switch next := s[i+1]; {
+		case next == '(':
+			end := matchingParen(s, i+1)
+			if end < 0 {
+				return "", fmt.Errorf("unterminated command substitution in %q", s)
+			}
+			fmt.Fprintf(&b, "$(%s)", strings.TrimSpace(s[i+2:end]))
+			expanded = true
+			i = end
+		case next == '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated parameter expansion in %q", s)
+			}
+			name := s[i+2 : i+end]
+			if !isShellName(name) {
+				return "", fmt.Errorf("parameter expansion ${%s} has no PowerShell equivalent", name)
+			}
+			fmt.Fprintf(&b, "${env:%s}", name)
+			expanded = true
+			i += end
+		case isShellNameByte(next, true):
+			j := i + 1
+			for j < len(s) && isShellNameByte(s[j], false) {
+				j++
+			}
+			fmt.Fprintf(&b, "${env:%s}", s[i+1:j])
+			expanded = true
+			i = j - 1
+		default:
+			b.WriteString("`$")
+		}
+	}
+	if !expanded {
+		return PSQuote(s), nil
+	}
+	return `"` + b.String() + `"`, nil
+}
+
+// PSAlias returns the PowerShell definition of a POSIX alias. A bare command
+// becomes a Set-Alias; one with arguments becomes a function passing on its own
+// arguments, after removing any built-in alias of the same name (ls, cat),
+// which would otherwise win over the function. Commands using shell syntax
+// PowerShell reads differently ($VAR, &&, backticks) are rejected.
+func PSAlias(name, command string) (string, error) {
+	for _, construct := range []string{"$", "`", "&&", "||"} {
+		if strings.Contains(command, construct) {
+			return "", fmt.Errorf("alias command uses %q, which PowerShell does not support", construct)
+		}
+	}
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return "", fmt.Errorf("empty alias command")
+	}
+	if !strings.ContainsAny(command, " \t|") {
+		return fmt.Sprintf("Set-Alias -Name %s -Value %s -Option AllScope -Force", name, command), nil
+	}
+	return fmt.Sprintf("Remove-Item Alias:%s -Force -ErrorAction SilentlyContinue\nfunction %s { %s @args }", name, name, command), nil
+}
+
+// PSFunctionBody converts the simple bash-isms of a function body to
+// PowerShell: positional parameters become $args, $VAR becomes $env:VAR, and
+// $(cmd) is already a PowerShell subexpression. Single-quoted text is left
+// alone. Bodies using other bash syntax (${VAR:-x}, [[ ]], &&, local, export)
+// are rejected rather than written as a function PowerShell would fail to run.
+func PSFunctionBody(body string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(body); {
+		switch body[i] {
+		case '\'':
+			end := strings.IndexByte(body[i+1:], '\'')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated single quote in %q", body)
+			}
+			b.WriteString(body[i : i+end+2])
+			i += end + 2
+		case '"':
+			end := strings.IndexByte(body[i+1:], '"')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated double quote in %q", body)
+			}
+			word := body[i+1 : i+1+end]
+			if word == "$@" || word == "$*" {
+				// "$@" passes each argument as its own word
+				b.WriteString("@args")
+			} else {
+				b.WriteString(`"` + psCode(word, true) + `"`)
+			}
+			i += end + 2
+		default:
+			j := i
+			for j < len(body) && body[j] != '\'' && body[j] != '"' {
+				j++
+			}
+			b.WriteString(psCode(body[i:j], false))
+			i = j
+		}
+	}
+	converted := b.String()
+	for _, construct := range []string{"${", "[[", "&&", "||", "local ", "export "} {
+		if strings.Contains(converted, construct) {
+			return "", fmt.Errorf("function body uses %q, which PowerShell does not support", strings.TrimSpace(construct))
+		}
+	}
+	return converted, nil
+}
+
+// psCode converts the positional parameters and variables of bash code, either
+// inside double quotes, where an index needs a subexpression, or unquoted
+func psCode(code string, quoted bool) string {
+	code = bashVariable.ReplaceAllString(code, "$$env:$1")
+	return bashPositional.ReplaceAllStringFunc(code, func(m string) string {
+		sub := bashPositional.FindStringSubmatch(m)
+		n := sub[1] + sub[2]
+		switch {
+		case n == "":
+			return "@args"
+		case quoted:
+			return fmt.Sprintf("$($args[%d])", n[0]-'1')
+		default:
+			return fmt.Sprintf("$args[%d]", n[0]-'1')
+		}
+	})
+}
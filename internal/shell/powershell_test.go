@@ -0,0 +1,217 @@
+package shell
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+)
+
+func TestPowerShellProfile(t *testing.T) {
+	home := filepath.Join("home", "user")
+	installed := func(names ...string) func(string) (string, error) {
+		return func(name string) (string, error) {
+			for _, n := range names {
+				if n == name {
+					return name, nil
+				}
+			}
+			return "", errors.New("not found")
+		}
+	}
+	tests := []struct {
+		name, goos string
+		lookPath   func(string) (string, error)
+		want       string
+	}{
+		{name: "linux", goos: "linux", lookPath: installed(), want: filepath.Join(home, ".config", "powershell", powerShellProfileName)},
+		{name: "windows pwsh", goos: "windows", lookPath: installed("pwsh", "powershell"), want: filepath.Join(home, "Documents", "PowerShell", powerShellProfileName)},
+		{name: "windows powershell 5", goos: "windows", lookPath: installed("powershell"), want: filepath.Join(home, "Documents", "WindowsPowerShell", powerShellProfileName)},
+		{name: "windows neither", goos: "windows", lookPath: installed(), want: filepath.Join(home, "Documents", "PowerShell", powerShellProfileName)},
+	}
+	for _, tt := range tests {
+		if got := PowerShellProfile(home, tt.goos, tt.lookPath); got != tt.want {
+			t.Errorf("%s: PowerShellProfile() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPSQuote(t *testing.T) {
+	tests := map[string]string{
+		"ls -la": "'ls -la'",
+		"it's":   "'it''s'",
+		`C:\dir`: `'C:\dir'`,
+		"":       "''",
+	}
+	for in, want := range tests {
+		if got := PSQuote(in); got != want {
+			t.Errorf("PSQuote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestPSValue(t *testing.T) {
+	tests := []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{in: "--height 40% --layout=reverse", want: "'--height 40% --layout=reverse'"},
+		{in: "$FZF_DEFAULT_COMMAND", want: `"${env:FZF_DEFAULT_COMMAND}"`},
+		{in: "${HOME}/.cargo/bin", want: `"${env:HOME}/.cargo/bin"`},
+		{in: "~/.fzf/bin", want: `"$HOME/.fzf/bin"`},
+		{in: "$(vivid generate molokai)", want: `"$(vivid generate molokai)"`},
+		{in: "say \"hi\" to $USER", want: "\"say `\"hi`\" to ${env:USER}\""},
+		{in: "cost: $5", want: "'cost: $5'"},
+		{in: "${SHELL##*/}", wantErr: true},
+		{in: "$(unterminated", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := PSValue(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("PSValue(%q) = %s, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("PSValue(%q) = %s, %v; want %s", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestPSAlias(t *testing.T) {
+	if got, err := PSAlias("ls", "lsd"); err != nil || got != "Set-Alias -Name ls -Value lsd -Option AllScope -Force" {
+		t.Errorf("PSAlias(ls) = %s, %v", got, err)
+	}
+	want := "Remove-Item Alias:cat -Force -ErrorAction SilentlyContinue\nfunction cat { bat --paging=never @args }"
+	if got, err := PSAlias("cat", "bat --paging=never"); err != nil || got != want {
+		t.Errorf("PSAlias(cat) = %s, %v; want %s", got, err, want)
+	}
+	for _, command := range []string{"cd $HOME", "make && make install"} {
+		if got, err := PSAlias("x", command); err == nil {
+			t.Errorf("PSAlias(%q) = %s, want an error", command, got)
+		}
+	}
+}
+
+func TestPSFunctionBody(t *testing.T) {
+	tests := map[string]string{
+		`cd "$(fd --type d | fzf)"`:    `cd "$(fd --type d | fzf)"`,
+		`git commit -m "$1" "$@"`:      `git commit -m "$($args[0])" @args`,
+		`echo $EDITOR ${2}`:            `echo $env:EDITOR $args[1]`,
+		`awk '{print $2}' "notes.txt"`: `awk '{print $2}' "notes.txt"`,
+	}
+	for in, want := range tests {
+		if got, err := PSFunctionBody(in); err != nil || got != want {
+			t.Errorf("PSFunctionBody(%q) = %s, %v; want %s", in, got, err, want)
+		}
+	}
+	for _, body := range []string{"kill -${1:-9}", "[[ -n $1 ]] && echo yes", "export EDITOR=vim"} {
+		if _, err := PSFunctionBody(body); err == nil {
+			t.Errorf("Expected %q to be rejected", body)
+		}
+	}
+}
+
+func TestPowerShellConfigWriter(t *testing.T) {
+	writer, tmpDir, cleanup := testConfigWriter(t, interfaces.PowerShell)
+	defer cleanup()
+
+	if err := writer.SetEnvVar("EDITOR", "nvim"); err != nil {
+		t.Fatalf("SetEnvVar() error = %v", err)
+	}
+	if err := writer.AddToPath("$HOME/.cargo/bin"); err != nil {
+		t.Fatalf("AddToPath() error = %v", err)
+	}
+	if err := writer.AddAlias("ll", "lsd -l"); err != nil {
+		t.Fatalf("AddAlias() error = %v", err)
+	}
+	if err := writer.AddAlias("up", "cd .. && ls"); err == nil {
+		t.Error("Expected an alias PowerShell cannot run to be rejected")
+	}
+
+	configFile := writer.getConfigFile()
+	if configFile != PowerShellProfile(tmpDir, "linux", exec.LookPath) {
+		t.Fatalf("getConfigFile() = %s, want the profile under ~/.config/powershell", configFile)
+	}
+	content, err := os.ReadFile(configFile)
+	if err != nil {
+		t.Fatalf("Failed to read profile: %v", err)
+	}
+	want := "$env:EDITOR = 'nvim'\n" +
+		"$env:PATH = \"${env:HOME}/.cargo/bin\" + [IO.Path]::PathSeparator + $env:PATH\n" +
+		"Remove-Item Alias:ll -Force -ErrorAction SilentlyContinue\nfunction ll { lsd -l @args }\n"
+	if string(content) != want {
+		t.Errorf("profile = %q, want %q", content, want)
+	}
+}
+
+func TestPowerShellGenerateConfig(t *testing.T) {
+	c := NewConfig("pwsh", log.NewMockLogger())
+	c.AddEnvVar("FZF_CTRL_T_COMMAND", "$FZF_DEFAULT_COMMAND")
+	c.AddAlias("ls", "lsd")
+	c.AddFunction("fcd", `cd "$(fd --type d | fzf)"`)
+	c.AddPath("~/.fzf/bin")
+	generated, err := c.GenerateConfig()
+	if err != nil {
+		t.Fatalf("GenerateConfig() error = %v", err)
+	}
+	for _, want := range []string{
+		`$env:FZF_CTRL_T_COMMAND = "${env:FZF_DEFAULT_COMMAND}"`,
+		"Set-Alias -Name ls -Value lsd -Option AllScope -Force",
+		"function fcd {\ncd \"$(fd --type d | fzf)\"\n}",
+		`$env:PATH = "$HOME/.fzf/bin" + [IO.Path]::PathSeparator + $env:PATH`,
+	} {
+		if !strings.Contains(generated, want) {
+			t.Errorf("Expected %q in the generated config:\n%s", want, generated)
+		}
+	}
+
+	c.AddEnvVar("BROKEN", "${SHELL##*/}")
+	if _, err := c.GenerateConfig(); err == nil {
+		t.Error("Expected an expansion PowerShell cannot read to be an error")
+	}
+}
+
+// TestPowerShellBlocksParse checks every kind of generated PowerShell snippet
+// with PowerShell's own parser, so syntax errors are caught
+func TestPowerShellBlocksParse(t *testing.T) {
+	pwsh, err := exec.LookPath("pwsh")
+	if err != nil {
+		t.Skip("pwsh is not installed")
+	}
+
+	c := NewConfig("pwsh", log.NewMockLogger())
+	c.AddEnvVar("FZF_DEFAULT_OPTS", "--height 40% --layout=reverse --border")
+	c.AddEnvVar("LS_COLORS", "$(vivid generate molokai)")
+	c.AddAlias("preview", "fzf --preview 'bat --style=numbers --color=always {}'")
+	c.AddFunction("fcd", `cd "$(fd --type d --hidden --follow --exclude .git | fzf)"`)
+	c.AddPath("$HOME/.cargo/bin")
+	generated, err := c.GenerateConfig()
+	if err != nil {
+		t.Fatalf("GenerateConfig() error = %v", err)
+	}
+
+	blocks := map[string]string{
+		"generated": generated,
+		"starship":  promptInit(PromptStarship, "pwsh", ""),
+		"ohmyposh":  promptInit(PromptOhMyPosh, "pwsh", "/tmp/theme.omp.json"),
+		"managed":   UpsertBlock("", "fzf", "$env:FZF_DEFAULT_COMMAND = 'fd --type f'"),
+	}
+	for name, block := range blocks {
+		path := filepath.Join(t.TempDir(), name+".ps1")
+		if err := os.WriteFile(path, []byte(block), 0644); err != nil {
+			t.Fatal(err)
+		}
+		check := "$e = $null; [void][System.Management.Automation.Language.Parser]::ParseFile(" + PSQuote(path) + ", [ref]$null, [ref]$e); if ($e) { $e; exit 1 }"
+		out, err := exec.Command(pwsh, "-NoLogo", "-NoProfile", "-Command", check).CombinedOutput()
+		if err != nil {
+			t.Errorf("PowerShell rejected the %s block: %v\n%s\n%s", name, err, out, block)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package shell
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Prompt styles selectable with --prompt-style
+const (
+	PromptStarship = "starship"
+	PromptP10k     = "p10k"
+	PromptOhMyPosh = "oh-my-posh"
+)
+
+// PromptBlock is the managed rc block that loads the prompt; one prompt is active at a time
+const PromptBlock = "prompt"
+
+//go:embed prompts/*
+var promptFS embed.FS
+
+// promptDefaults holds each prompt's curated config and where it is written, relative to home
+var promptDefaults = map[string]struct {
+	source string
+	dest   string
+}{
+	PromptStarship: {source: "prompts/starship.toml", dest: ".config/starship.toml"},
+	PromptP10k:     {source: "prompts/p10k.zsh", dest: ".p10k.zsh"},
+	PromptOhMyPosh: {source: "prompts/bootstrap.omp.json", dest: ".config/oh-my-posh/bootstrap.omp.json"},
+}
+
+// PromptStyles returns the supported prompt styles in sorted order
+func PromptStyles() []string {
+	styles := make([]string, 0, len(promptDefaults))
+	for style := range promptDefaults {
+		styles = append(styles, style)
+	}
+	sort.Strings(styles)
+	return styles
+}
+
+// ValidatePromptStyle checks that style is a supported prompt for shellName.
+// An empty style means no prompt config is written.
+func ValidatePromptStyle(style, shellName string) error {
+	if style == "" {
+		return nil
+	}
+	if _, ok := promptDefaults[style]; !ok {
+		return fmt.Errorf("unknown prompt style %q: must be one of %s", style, strings.Join(PromptStyles(), ", "))
+	}
+	if shellName != "" {
+		return CheckRequires([]string{style}, []string{shellName})
+	}
+	return nil
+}
+
+// PromptConfigPath returns where the config of a prompt style lives under home
+func PromptConfigPath(home, style string) string {
+	return filepath.Join(home, promptDefaults[style].dest)
+}
+
+// promptInit returns the rc block body that loads style in shellName
+func promptInit(style, shellName, configPath string) string {
+	switch style {
+	case PromptStarship:
+		switch shellName {
+		case "fish":
+			return "starship init fish | source"
+		case "nu":
+			// Nushell cannot eval generated code, so the init script is saved to
+			// the vendor autoload directory, which nushell loads on the next start
+			return `mkdir ($nu.data-dir | path join "vendor/autoload")
+starship init nu | save -f ($nu.data-dir | path join "vendor/autoload/starship.nu")`
+		case "pwsh":
+			return "Invoke-Expression (&starship init powershell)"
+		}
+		return fmt.Sprintf(`eval "$(starship init %s)"`, shellName)
+	case PromptP10k:
+		return fmt.Sprintf("[[ ! -f %s ]] || source %s", configPath, configPath)
+	case PromptOhMyPosh:
+		switch shellName {
+		case "fish":
+			return fmt.Sprintf("oh-my-posh init fish --config %s | source", configPath)
+		case "nu":
+			return fmt.Sprintf(`mkdir ($nu.data-dir | path join "vendor/autoload")
+oh-my-posh init nu --config %s --print | save -f ($nu.data-dir | path join "vendor/autoload/oh-my-posh.nu")`, configPath)
+		case "pwsh":
+			return fmt.Sprintf("oh-my-posh init pwsh --config %s | Invoke-Expression", PSQuote(configPath))
+		}
+		return fmt.Sprintf(`eval "$(oh-my-posh init %s --config %s)"`, shellName, configPath)
+	}
+	return ""
+}
+
+// EnsurePromptConfig writes the curated config for style under home when none
+// exists, or replaces it when force is set, and points the rc file of shellName
+// at the prompt. It reports whether the config file was written; in rc's
+// dry-run mode nothing is written.
+func EnsurePromptConfig(home, style, shellName string, force bool, rc *RCWriter) (bool, error) {
+	if err := ValidatePromptStyle(style, shellName); err != nil {
+		return false, err
+	}
+	rcFile := rcFileForShell(home, shellName)
+	if rcFile == "" {
+		return false, fmt.Errorf("unsupported shell for prompt config: %s", shellName)
+	}
+
+	path := PromptConfigPath(home, style)
+	written := false
+	if _, err := os.Stat(path); os.IsNotExist(err) || force {
+		data, err := promptFS.ReadFile(promptDefaults[style].source)
+		if err != nil {
+			return false, fmt.Errorf("failed to read default %s config: %w", style, err)
+		}
+		if !rc.DryRun {
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return false, fmt.Errorf("failed to create directory for %s: %w", path, err)
+			}
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				return false, fmt.Errorf("failed to write %s: %w", path, err)
+			}
+		}
+		written = true
+	} else if err != nil {
+		return false, fmt.Errorf("failed to check %s: %w", path, err)
+	}
+
+	if _, err := rc.UpsertBlock(rcFile, PromptBlock, promptInit(style, shellName, path)); err != nil {
+		return written, err
+	}
+	return written, nil
+}
+
+// rcFileForShell returns the interactive rc file of shellName under home
+func rcFileForShell(home, shellName string) string {
+	switch shellName {
+	case "bash":
+		return filepath.Join(home, ".bashrc")
+	case "zsh":
+		return filepath.Join(home, ".zshrc")
+	case "fish":
+		return filepath.Join(home, ".config", "fish", "config.fish")
+	case "nu":
+		return filepath.Join(home, ".config", "nushell", "config.nu")
+	case "pwsh":
+		return PowerShellProfile(home, runtime.GOOS, exec.LookPath)
+	default:
+		return ""
+	}
+}
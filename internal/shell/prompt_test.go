@@ -0,0 +1,89 @@
+package shell
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidatePromptStyle(t *testing.T) {
+	tests := []struct {
+		style, shell string
+		wantErr      bool
+	}{
+		{"", "bash", false},
+		{PromptStarship, "fish", false},
+		{PromptP10k, "zsh", false},
+		{PromptP10k, "bash", true},
+		{PromptOhMyPosh, "", false},
+		{"spaceship", "zsh", true},
+	}
+	for _, tt := range tests {
+		if err := ValidatePromptStyle(tt.style, tt.shell); (err != nil) != tt.wantErr {
+			t.Errorf("ValidatePromptStyle(%q, %q) error = %v, wantErr %v", tt.style, tt.shell, err, tt.wantErr)
+		}
+	}
+}
+
+func TestEnsurePromptConfigWritesMissingConfig(t *testing.T) {
+	home := t.TempDir()
+	written, err := EnsurePromptConfig(home, PromptStarship, "zsh", false, &RCWriter{})
+	if err != nil {
+		t.Fatalf("EnsurePromptConfig failed: %v", err)
+	}
+	if !written {
+		t.Error("expected the starship config to be written")
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".config", "starship.toml"))
+	if err != nil || !strings.Contains(string(data), "[character]") {
+		t.Errorf("expected the curated starship.toml, got %q (%v)", data, err)
+	}
+	rc, _ := os.ReadFile(filepath.Join(home, ".zshrc"))
+	if !strings.Contains(string(rc), `eval "$(starship init zsh)"`) {
+		t.Errorf("expected .zshrc to init starship, got:\n%s", rc)
+	}
+}
+
+func TestEnsurePromptConfigKeepsExistingConfig(t *testing.T) {
+	home := t.TempDir()
+	path := PromptConfigPath(home, PromptOhMyPosh)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := EnsurePromptConfig(home, PromptOhMyPosh, "fish", false, &RCWriter{})
+	if err != nil || written {
+		t.Fatalf("expected the existing config to be kept, got written=%v err=%v", written, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "{}" {
+		t.Errorf("existing config was overwritten: %q", data)
+	}
+	rc, _ := os.ReadFile(filepath.Join(home, ".config", "fish", "config.fish"))
+	if !strings.Contains(string(rc), "oh-my-posh init fish --config "+path+" | source") {
+		t.Errorf("expected config.fish to init oh-my-posh, got:\n%s", rc)
+	}
+
+	if written, err = EnsurePromptConfig(home, PromptOhMyPosh, "fish", true, &RCWriter{}); err != nil || !written {
+		t.Fatalf("expected --force to replace the config, got written=%v err=%v", written, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) == "{}" {
+		t.Error("expected --force to write the default config")
+	}
+}
+
+func TestEnsurePromptConfigDryRun(t *testing.T) {
+	home := t.TempDir()
+	if _, err := EnsurePromptConfig(home, PromptP10k, "zsh", false, &RCWriter{DryRun: true, Out: io.Discard}); err != nil {
+		t.Fatalf("EnsurePromptConfig failed: %v", err)
+	}
+	for _, path := range []string{PromptConfigPath(home, PromptP10k), filepath.Join(home, ".zshrc")} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("dry run wrote %s", path)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DryRunEnvVar enables dry-run mode, in which rc files are previewed instead of written
+const DryRunEnvVar = "DRY_RUN"
+
+// RCChange is a planned or applied edit of a managed block in a shell rc file
+type RCChange struct {
+	Path    string `json:"path"`
+	Block   string `json:"block"`
+	Diff    string `json:"diff"`
+	Applied bool   `json:"applied"`
+	// Created is set when the block did not exist before the change
+	Created bool `json:"created,omitempty"`
+	// NewBlocks are the blocks the change added, for a flushed change of several
+	NewBlocks []string `json:"new_blocks,omitempty"`
+}
+
+// RCWriter writes bootstrap-managed blocks into shell rc files. In dry-run mode it
+// prints a unified diff of each change instead of writing it.
+type RCWriter struct {
+	// DryRun previews changes without writing them
+	DryRun bool
+	// Buffered collects edits in memory until Flush, so each rc file is written
+	// once per run with a single diff
+	Buffered bool
+	// Out receives dry-run diffs (defaults to stdout)
+	Out io.Writer
+	// Backups copies each rc file aside before its first write in the run; nil
+	// writes without backups
+	Backups *Backups
+	// Warn receives warnings, e.g. about blocks edited by hand that are left
+	// alone (defaults to stderr)
+	Warn func(format string, args ...interface{})
+
+	mu      sync.Mutex
+	changes []RCChange
+	// pending holds the buffered edits by rc file path, in first-edit order
+	pending map[string]*pendingRC
+	order   []string
+	// parent is the writer a Share view stages its edits in
+	parent *RCWriter
+}
+
+// pendingRC is a buffered rc file: its content on disk and after the staged
+// edits, and the edits themselves, replayed by Flush when the file changed
+// on disk in the meantime
+type pendingRC struct {
+	before, after string
+	blocks        []string
+	edits         []func(string) string
+}
+
+// NewBufferedRCWriter creates an rc writer that buffers edits until Flush, in
+// dry-run mode when DRY_RUN is set
+func NewBufferedRCWriter() *RCWriter {
+	w := NewRCWriter()
+	w.Buffered = true
+	return w
+}
+
+// NewRCWriter creates an rc writer that backs rc files up before changing them,
+// in dry-run mode when DRY_RUN is set
+func NewRCWriter() *RCWriter {
+	return &RCWriter{DryRun: os.Getenv(DryRunEnvVar) != "", Backups: DefaultBackups()}
+}
+
+// Share returns a view of the buffered writer w that stages its edits in w and
+// lists just its own in Changes, so each part of a run can tell which blocks it
+// edited; w's Flush writes them
+func (w *RCWriter) Share() *RCWriter {
+	return &RCWriter{DryRun: w.DryRun, Buffered: true, Out: w.Out, Backups: w.Backups, Warn: w.Warn, parent: w}
+}
+
+// shared records the change the parent staged for a Share view
+func (w *RCWriter) shared(change *RCChange, err error) (*RCChange, error) {
+	if change != nil {
+		w.mu.Lock()
+		w.changes = append(w.changes, *change)
+		w.mu.Unlock()
+	}
+	return change, err
+}
+
+// UpsertBlock sets the managed block called name in the rc file at path to body.
+// A missing rc file is treated as empty. Legacy "# Added by bootstrap-cli" blocks
+// in the file are converted to managed blocks first. A block edited by hand is
+// left alone with a warning. It returns nil when the file is left unchanged.
+func (w *RCWriter) UpsertBlock(path, name, body string) (*RCChange, error) {
+	if w.parent != nil {
+		return w.shared(w.parent.UpsertBlock(path, name, body))
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	before, err := w.current(path)
+	if err != nil {
+		return nil, err
+	}
+	converted, _ := ConvertLegacyBlocks(before)
+	if CheckBlock(converted, name, body) == BlockEdited {
+		w.warn("%s: the %s block was edited by hand, leaving it alone (remove it to let bootstrap-cli write it again)", path, name)
+	}
+	return w.edit(path, name, before, func(content string) string {
+		converted, _ := ConvertLegacyBlocks(content)
+		return UpsertBlock(converted, name, body)
+	})
+}
+
+// warn reports a problem that does not stop the edit
+func (w *RCWriter) warn(format string, args ...interface{}) {
+	if w.Warn != nil {
+		w.Warn(format, args...)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: "+format+"\n", args...)
+}
+
+// RemoveBlock deletes the managed block called name from the rc file at path.
+// It returns nil when the file has no such block.
+func (w *RCWriter) RemoveBlock(path, name string) (*RCChange, error) {
+	if w.parent != nil {
+		return w.shared(w.parent.RemoveBlock(path, name))
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	before, err := w.current(path)
+	if err != nil {
+		return nil, err
+	}
+	return w.edit(path, name, before, func(content string) string {
+		return RemoveBlock(content, name)
+	})
+}
+
+// StripBlocks deletes every bootstrap-cli block from the rc file at path (see
+// StripManagedBlocks). It returns nil when the file has none.
+func (w *RCWriter) StripBlocks(path string) (*RCChange, error) {
+	if w.parent != nil {
+		return w.shared(w.parent.StripBlocks(path))
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	before, err := w.current(path)
+	if err != nil {
+		return nil, err
+	}
+	_, keys := StripManagedBlocks(before)
+	return w.edit(path, strings.Join(keys, ", "), before, func(content string) string {
+		after, _ := StripManagedBlocks(content)
+		return after
+	})
+}
+
+// current returns the content of the rc file at path including buffered edits;
+// a missing file is empty
+func (w *RCWriter) current(path string) (string, error) {
+	if p, ok := w.pending[path]; ok {
+		return p.after, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return string(data), nil
+}
+
+// edit applies apply, the edit of block name, to before, or stages it when
+// buffered. A staged edit returns an unapplied change of just that block.
+func (w *RCWriter) edit(path, name, before string, apply func(string) string) (*RCChange, error) {
+	after := apply(before)
+	if !w.Buffered {
+		return w.write(path, name, before, after)
+	}
+	if after == before {
+		return nil, nil
+	}
+	if w.pending == nil {
+		w.pending = make(map[string]*pendingRC)
+	}
+	p, ok := w.pending[path]
+	if !ok {
+		p = &pendingRC{before: before}
+		w.pending[path] = p
+		w.order = append(w.order, path)
+	}
+	p.after = after
+	p.edits = append(p.edits, apply)
+	if !containsBlock(p.blocks, name) {
+		p.blocks = append(p.blocks, name)
+	}
+	return &RCChange{Path: path, Block: name, Diff: UnifiedDiff(path, before, after)}, nil
+}
+
+// Flush writes every buffered rc file in one pass, each as a single change
+// naming all of its blocks, and returns those changes. A file that changed on
+// disk since its first edit (e.g. replaced by a dotfiles step) gets the edits
+// replayed on its new content. Files whose edits cancel out are left alone.
+// Every file is attempted; the first error is returned.
+func (w *RCWriter) Flush() ([]RCChange, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var flushed []RCChange
+	var firstErr error
+	for _, path := range w.order {
+		p := w.pending[path]
+		before, after := p.before, p.after
+		if data, err := os.ReadFile(path); err == nil || os.IsNotExist(err) {
+			if current := string(data); current != before {
+				before, after = current, current
+				for _, apply := range p.edits {
+					after = apply(after)
+				}
+			}
+		}
+		change, err := w.write(path, strings.Join(p.blocks, ","), before, after)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if change != nil {
+			flushed = append(flushed, *change)
+		}
+	}
+	w.pending, w.order = nil, nil
+	return flushed, firstErr
+}
+
+// write records the edit of block name from before to after, applying it unless in dry-run mode
+func (w *RCWriter) write(path, name, before, after string) (*RCChange, error) {
+	if after == before {
+		return nil, nil
+	}
+
+	change := RCChange{Path: path, Block: name, Diff: UnifiedDiff(path, before, after)}
+	for _, block := range strings.Split(name, ",") {
+		if block = strings.TrimSpace(block); !HasBlock(before, block) && HasBlock(after, block) {
+			change.NewBlocks = append(change.NewBlocks, block)
+		}
+	}
+	change.Created = len(change.NewBlocks) > 0
+	if w.DryRun {
+		out := w.Out
+		if out == nil {
+			out = os.Stdout
+		}
+		fmt.Fprint(out, change.Diff)
+	} else {
+		if w.Backups != nil {
+			if err := w.Backups.Backup(path); err != nil {
+				return nil, err
+			}
+		}
+		if err := writeAtomic(path, after); err != nil {
+			return nil, err
+		}
+		change.Applied = true
+	}
+	w.changes = append(w.changes, change)
+	return &change, nil
+}
+
+// Changes returns every change planned or applied so far, in order
+func (w *RCWriter) Changes() []RCChange {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]RCChange(nil), w.changes...)
+}
+
+// writeAtomic replaces the rc file at path with content through a temporary file,
+// so a shell starting mid-write never reads half of it. A symlinked rc file (e.g.
+// from a dotfiles repo) is written through to its target.
+func writeAtomic(path, content string) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp := path + ".bootstrap-tmp"
+	if err := os.WriteFile(tmp, []byte(content), mode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+func containsBlock(blocks []string, name string) bool {
+	for _, b := range blocks {
+		if b == name {
+			return true
+		}
+	}
+	return false
+}
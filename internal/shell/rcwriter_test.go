@@ -0,0 +1,218 @@
+package shell
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRCWriterDryRun(t *testing.T) {
+	rc := filepath.Join(t.TempDir(), ".bashrc")
+	original := "export EDITOR=vim\n"
+	if err := os.WriteFile(rc, []byte(original), 0644); err != nil {
+		t.Fatalf("Failed to write rc file: %v", err)
+	}
+
+	var out bytes.Buffer
+	w := &RCWriter{DryRun: true, Out: &out}
+	change, err := w.UpsertBlock(rc, "nvm", `export NVM_DIR="$HOME/.nvm"`)
+	if err != nil {
+		t.Fatalf("UpsertBlock() error = %v", err)
+	}
+	if change == nil || change.Applied {
+		t.Fatalf("Expected an unapplied change, got %+v", change)
+	}
+
+	data, _ := os.ReadFile(rc)
+	if string(data) != original {
+		t.Errorf("Dry run modified the rc file:\n%s", data)
+	}
+	for _, want := range []string{"--- " + rc, "+++ " + rc, "@@ -1,1 +1,5 @@", " export EDITOR=vim", "+" + BlockStart("nvm", `export NVM_DIR="$HOME/.nvm"`), `+export NVM_DIR="$HOME/.nvm"`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Diff missing %q:\n%s", want, out.String())
+		}
+	}
+	if changes := w.Changes(); len(changes) != 1 || changes[0].Diff != out.String() {
+		t.Errorf("Expected the printed diff to be recorded, got %+v", changes)
+	}
+}
+
+func TestRCWriterWrites(t *testing.T) {
+	rc := filepath.Join(t.TempDir(), "fish", "config.fish")
+	w := &RCWriter{}
+	if _, err := w.UpsertBlock(rc, "starship", "starship init fish | source"); err != nil {
+		t.Fatalf("UpsertBlock() error = %v", err)
+	}
+	data, err := os.ReadFile(rc)
+	if err != nil {
+		t.Fatalf("Expected rc file to be created: %v", err)
+	}
+	if !HasBlock(string(data), "starship") {
+		t.Errorf("Managed block not written:\n%s", data)
+	}
+
+	change, err := w.UpsertBlock(rc, "starship", "starship init fish | source")
+	if err != nil || change != nil {
+		t.Errorf("Expected no change for an up-to-date block, got %+v, %v", change, err)
+	}
+}
+
+func TestRCWriterRemoveBlock(t *testing.T) {
+	rc := filepath.Join(t.TempDir(), ".zshrc")
+	w := &RCWriter{}
+	if change, err := w.RemoveBlock(rc, "fzf"); err != nil || change != nil {
+		t.Errorf("Expected no change for a missing rc file, got %+v, %v", change, err)
+	}
+	if _, err := w.UpsertBlock(rc, "fzf", "source ~/.fzf.zsh"); err != nil {
+		t.Fatalf("UpsertBlock() error = %v", err)
+	}
+	change, err := w.RemoveBlock(rc, "fzf")
+	if err != nil || change == nil || !change.Applied {
+		t.Fatalf("Expected the block to be removed, got %+v, %v", change, err)
+	}
+	if data, _ := os.ReadFile(rc); HasBlock(string(data), "fzf") {
+		t.Errorf("Managed block still present:\n%s", data)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	if diff := UnifiedDiff("rc", "a\n", "a\n"); diff != "" {
+		t.Errorf("Expected no diff for identical content, got %q", diff)
+	}
+	diff := UnifiedDiff("rc", "a\nb\nc\n", "a\nB\nc\n")
+	want := "--- rc\n+++ rc\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"
+	if diff != want {
+		t.Errorf("UnifiedDiff() =\n%s\nwant\n%s", diff, want)
+	}
+}
+
+func TestRCWriterBuffered(t *testing.T) {
+	dir := t.TempDir()
+	bashrc := filepath.Join(dir, ".bashrc")
+	zshrc := filepath.Join(dir, ".zshrc")
+	original := "export EDITOR=vim\n"
+	if err := os.WriteFile(bashrc, []byte(original), 0600); err != nil {
+		t.Fatalf("Failed to write rc file: %v", err)
+	}
+
+	w := &RCWriter{Buffered: true}
+	w.UpsertBlock(bashrc, "nvm", "old")
+	w.UpsertBlock(zshrc, "nvm", "nvm")
+	w.UpsertBlock(bashrc, "pyenv", "pyenv")
+	w.UpsertBlock(bashrc, "nvm", "nvm")
+	if data, _ := os.ReadFile(bashrc); string(data) != original {
+		t.Fatalf("Buffered writer modified the rc file before Flush:\n%s", data)
+	}
+
+	changes, err := w.Flush()
+	if err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if len(changes) != 2 || changes[0].Path != bashrc || changes[0].Block != "nvm,pyenv" || !changes[0].Applied {
+		t.Fatalf("Expected one applied change per file, got %+v", changes)
+	}
+	if strings.Contains(changes[0].Diff, "+old") {
+		t.Errorf("Expected the diff to show only the final content:\n%s", changes[0].Diff)
+	}
+	data, _ := os.ReadFile(bashrc)
+	if !strings.HasPrefix(string(data), original) || strings.Count(string(data), BlockStart("nvm", "nvm")) != 1 || !HasBlock(string(data), "pyenv") {
+		t.Errorf("Unexpected rc file after Flush:\n%s", data)
+	}
+	if info, _ := os.Stat(bashrc); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the rc file mode to be kept, got %v", info.Mode().Perm())
+	}
+
+	if changes, err := w.Flush(); err != nil || len(changes) != 0 {
+		t.Errorf("Expected a second Flush to write nothing, got %+v, %v", changes, err)
+	}
+}
+
+func TestRCWriterShare(t *testing.T) {
+	dir := t.TempDir()
+	zshrc := filepath.Join(dir, ".zshrc")
+
+	w := &RCWriter{Buffered: true}
+	prompt, mise := w.Share(), w.Share()
+	prompt.UpsertBlock(zshrc, "starship", "starship")
+	mise.UpsertBlock(zshrc, "mise", "mise")
+	if len(prompt.Changes()) != 1 || prompt.Changes()[0].Block != "starship" || prompt.Changes()[0].Applied {
+		t.Errorf("Expected the view to list just its staged edit, got %+v", prompt.Changes())
+	}
+	if _, err := os.Stat(zshrc); !os.IsNotExist(err) {
+		t.Fatalf("Expected nothing written before Flush")
+	}
+
+	// A step replaced the rc file in the meantime; its content is kept
+	replaced := "# from dotfiles\n"
+	if err := os.WriteFile(zshrc, []byte(replaced), 0644); err != nil {
+		t.Fatalf("Failed to write rc file: %v", err)
+	}
+	changes, err := w.Flush()
+	if err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if len(changes) != 1 || strings.Join(changes[0].NewBlocks, ",") != "starship,mise" {
+		t.Fatalf("Expected one change adding both blocks, got %+v", changes)
+	}
+	data, _ := os.ReadFile(zshrc)
+	if !strings.HasPrefix(string(data), replaced) || !HasBlock(string(data), "starship") || !HasBlock(string(data), "mise") {
+		t.Errorf("Expected the edits replayed on the new content, got:\n%s", data)
+	}
+}
+
+func TestRCWriterWritesThroughSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "bashrc")
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("# mine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, ".bashrc")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := (&RCWriter{}).UpsertBlock(link, "fzf", "source ~/.fzf.bash"); err != nil {
+		t.Fatalf("UpsertBlock() error = %v", err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("Expected .bashrc to stay a symlink, got %v, %v", info, err)
+	}
+	if data, _ := os.ReadFile(target); !HasBlock(string(data), "fzf") {
+		t.Errorf("Expected the block in the symlink target:\n%s", data)
+	}
+}
+
+func TestRCWriterLeavesEditedBlocks(t *testing.T) {
+	rc := filepath.Join(t.TempDir(), ".zshrc")
+	edited := UpsertBlock("", "fzf", "source ~/.fzf.zsh") + "# Added by bootstrap-cli: nvm\nexport NVM_DIR=\"$HOME/.nvm\"\n"
+	edited = strings.Replace(edited, "source ~/.fzf.zsh\n", "source ~/.fzf.zsh\nbindkey '^T' fzf-file-widget\n", 1)
+	if err := os.WriteFile(rc, []byte(edited), 0644); err != nil {
+		t.Fatalf("Failed to write rc file: %v", err)
+	}
+
+	var warnings []string
+	w := &RCWriter{Warn: func(format string, args ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}}
+	if _, err := w.UpsertBlock(rc, "fzf", "source ~/.fzf.bash"); err != nil {
+		t.Fatalf("UpsertBlock() error = %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "edited by hand") {
+		t.Errorf("Expected a warning about the edited block, got %v", warnings)
+	}
+
+	// The edited block is kept while the legacy block is converted
+	data, _ := os.ReadFile(rc)
+	if !strings.Contains(string(data), "bindkey") || strings.Contains(string(data), ".fzf.bash") {
+		t.Errorf("Expected the edited block to be left alone, got:\n%s", data)
+	}
+	if strings.Contains(string(data), LegacyMarker) || !HasBlock(string(data), "nvm") {
+		t.Errorf("Expected the legacy block to be converted, got:\n%s", data)
+	}
+}
@@ -0,0 +1,41 @@
+package shell
+
+import (
+	"fmt"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/requires"
+)
+
+// shellRequires lists what each prompt style and shell framework requires, in
+// the form of a tool's requires list
+var shellRequires = map[string][]string{
+	PromptP10k:       {string(interfaces.ZshShell)},
+	FrameworkOhMyZsh: {string(interfaces.ZshShell)},
+	FrameworkBashIt:  {string(interfaces.BashShell)},
+}
+
+// requiresLookup knows the shells, prompt styles and frameworks
+func requiresLookup(name string) ([]string, bool) {
+	switch interfaces.ShellType(name) {
+	case interfaces.BashShell, interfaces.ZshShell, interfaces.FishShell, interfaces.NuShell, interfaces.PowerShell:
+		return nil, true
+	}
+	_, prompt := promptDefaults[name]
+	_, framework := frameworkDefaults[name]
+	return shellRequires[name], prompt || framework
+}
+
+// CheckRequires checks that the shells the prompt styles and frameworks in
+// items require are among shells. Shells are not pulled in: the first one
+// missing is an error, before anything is installed.
+func CheckRequires(items, shells []string) error {
+	res, err := requires.Resolve(append(append([]string(nil), shells...), items...), requiresLookup)
+	if err != nil {
+		return err
+	}
+	if added := res.Added(); len(added) > 0 {
+		return fmt.Errorf("%s requires %s, which is not among the configured shells", res.RequiredBy[added[0]], added[0])
+	}
+	return nil
+}
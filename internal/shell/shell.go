@@ -2,22 +2,31 @@ package shell
 
 import (
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
 )
 
 // detectCurrentShell returns the current shell type based on the SHELL environment variable
 func detectCurrentShell() interfaces.ShellType {
 	shell := os.Getenv("SHELL")
 	switch {
+	case shell == "" && runtime.GOOS == "windows":
+		return interfaces.PowerShell
 	case strings.Contains(shell, "bash"):
 		return interfaces.BashShell
 	case strings.Contains(shell, "zsh"):
 		return interfaces.ZshShell
 	case strings.Contains(shell, "fish"):
 		return interfaces.FishShell
+	case filepath.Base(shell) == "nu":
+		return interfaces.NuShell
+	case shellName(shell) == string(interfaces.PowerShell):
+		return interfaces.PowerShell
 	default:
 		return interfaces.BashShell // Default to bash if unknown
 	}
@@ -33,6 +42,10 @@ func isShellInstalled(shell interfaces.ShellType) bool {
 		return strings.Contains(shellPath, "zsh")
 	case interfaces.FishShell:
 		return strings.Contains(shellPath, "fish")
+	case interfaces.NuShell:
+		return filepath.Base(shellPath) == "nu"
+	case interfaces.PowerShell:
+		return shellName(shellPath) == string(interfaces.PowerShell)
 	default:
 		return false
 	}
@@ -40,9 +53,9 @@ func isShellInstalled(shell interfaces.ShellType) bool {
 
 // getShellConfigFiles returns the list of configuration files for a given shell
 func getShellConfigFiles(shell interfaces.ShellType) ([]string, error) {
-	home := os.Getenv("HOME")
-	if home == "" {
-		return nil, interfaces.ErrHomeDirNotFound
+	home, err := system.UserHome()
+	if err != nil {
+		return nil, err
 	}
 
 	switch shell {
@@ -59,6 +72,13 @@ func getShellConfigFiles(shell interfaces.ShellType) ([]string, error) {
 		return []string{
 			filepath.Join(home, ".config/fish/config.fish"),
 		}, nil
+	case interfaces.NuShell:
+		return []string{
+			filepath.Join(home, ".config/nushell/env.nu"),
+			filepath.Join(home, ".config/nushell/config.nu"),
+		}, nil
+	case interfaces.PowerShell:
+		return []string{PowerShellProfile(home, runtime.GOOS, exec.LookPath)}, nil
 	default:
 		return nil, interfaces.ErrUnsupportedShell
 	}
@@ -73,7 +93,21 @@ func getShellTypeFromPath(path string) interfaces.ShellType {
 		return interfaces.ZshShell
 	case strings.Contains(path, "fish"):
 		return interfaces.FishShell
+	case filepath.Base(path) == "nu":
+		return interfaces.NuShell
+	case shellName(path) == string(interfaces.PowerShell):
+		return interfaces.PowerShell
 	default:
 		return ""
 	}
+}
+
+// shellName returns the name of the shell at path: its base name without .exe,
+// with Windows PowerShell's powershell named pwsh like PowerShell 7
+func shellName(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), ".exe")
+	if name == "powershell" {
+		return string(interfaces.PowerShell)
+	}
+	return name
 } 
\ No newline at end of file
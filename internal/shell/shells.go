@@ -0,0 +1,97 @@
+package shell
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+// ParseShells splits a comma-separated shell list into trimmed, lowercased names.
+// The first shell is the primary one, used as the login shell. PowerShell may
+// be given as powershell or pwsh.
+func ParseShells(list string) []string {
+	var shells []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			shells = append(shells, shellName(name))
+		}
+	}
+	return shells
+}
+
+// WithPrimary puts primary first among shells, adding it when it is missing;
+// an empty primary leaves shells as they are
+func WithPrimary(primary string, shells []string) []string {
+	if primary == "" {
+		return shells
+	}
+	primary = shellName(strings.ToLower(primary))
+	list := []string{primary}
+	for _, name := range shells {
+		if name != primary {
+			list = append(list, name)
+		}
+	}
+	return list
+}
+
+// ValidateShells checks that every shell is supported and listed once, and that the
+// shell each prompt style or framework in items needs is among them (e.g.
+// oh-my-zsh and p10k need zsh to be configured)
+func ValidateShells(shells []string, items ...string) error {
+	seen := make(map[string]bool, len(shells))
+	for _, name := range shells {
+		switch interfaces.ShellType(name) {
+		case interfaces.BashShell, interfaces.ZshShell, interfaces.FishShell, interfaces.NuShell, interfaces.PowerShell:
+		default:
+			return fmt.Errorf("%w: %s", interfaces.ErrUnsupportedShell, name)
+		}
+		if seen[name] {
+			return fmt.Errorf("shell %s listed more than once", name)
+		}
+		seen[name] = true
+	}
+	for _, item := range items {
+		_, prompt := promptDefaults[item]
+		if _, framework := frameworkDefaults[item]; !prompt && !framework {
+			return fmt.Errorf("unknown prompt style or shell framework: %s", item)
+		}
+	}
+	return CheckRequires(items, shells)
+}
+
+// KnownShell is a shell bootstrap-cli supports, with its state on this machine
+type KnownShell struct {
+	Name string
+	// Path is where the shell is installed, or empty when it is not
+	Path string
+	// Default reports whether it is the login shell
+	Default bool
+	// Installable reports whether the catalog can install it (see `shell use`)
+	Installable bool
+}
+
+// ListShells reports the supported shells (bash, zsh, fish, nu and pwsh) in order: where
+// each is installed according to lookPath, whether it is loginShell, and
+// whether catalog has an entry to install it from
+func ListShells(catalog []*interfaces.Shell, loginShell string, lookPath func(string) (string, error)) []KnownShell {
+	names := []interfaces.ShellType{interfaces.BashShell, interfaces.ZshShell, interfaces.FishShell, interfaces.NuShell, interfaces.PowerShell}
+	shells := make([]KnownShell, 0, len(names))
+	for _, name := range names {
+		known := KnownShell{Name: string(name)}
+		if path, err := lookPath(known.Name); err == nil {
+			known.Path = path
+		}
+		known.Default = loginShell != "" && filepath.Base(loginShell) == known.Name
+		for _, sh := range catalog {
+			if strings.EqualFold(sh.Name, known.Name) {
+				known.Installable = true
+				break
+			}
+		}
+		shells = append(shells, known)
+	}
+	return shells
+}
@@ -0,0 +1,81 @@
+package shell
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+func TestParseShells(t *testing.T) {
+	got := ParseShells(" zsh, Bash,,fish ")
+	want := []string{"zsh", "bash", "fish"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseShells() = %v, want %v", got, want)
+	}
+}
+
+func TestWithPrimary(t *testing.T) {
+	if got := WithPrimary("zsh", []string{"bash", "zsh"}); !reflect.DeepEqual(got, []string{"zsh", "bash"}) {
+		t.Errorf("WithPrimary(zsh) = %v, want [zsh bash]", got)
+	}
+	if got := WithPrimary("", []string{"bash"}); !reflect.DeepEqual(got, []string{"bash"}) {
+		t.Errorf("WithPrimary(\"\") = %v, want [bash]", got)
+	}
+	if got := WithPrimary("Fish", nil); !reflect.DeepEqual(got, []string{"fish"}) {
+		t.Errorf("WithPrimary(Fish) = %v, want [fish]", got)
+	}
+}
+
+func TestValidateShells(t *testing.T) {
+	tests := []struct {
+		name       string
+		shells     []string
+		frameworks []string
+		wantErr    bool
+	}{
+		{name: "zsh and bash", shells: []string{"zsh", "bash"}},
+		{name: "nushell", shells: []string{"nu", "bash"}},
+		{name: "framework for configured shell", shells: []string{"zsh", "bash"}, frameworks: []string{FrameworkOhMyZsh, FrameworkBashIt}},
+		{name: "framework for missing shell", shells: []string{"bash"}, frameworks: []string{FrameworkOhMyZsh}, wantErr: true},
+		{name: "prompt for configured shell", shells: []string{"bash", "zsh"}, frameworks: []string{PromptP10k}},
+		{name: "prompt for missing shell", shells: []string{"bash", "fish"}, frameworks: []string{PromptP10k}, wantErr: true},
+		{name: "unknown framework", shells: []string{"zsh"}, frameworks: []string{"prezto"}, wantErr: true},
+		{name: "duplicate shell", shells: []string{"zsh", "zsh"}, wantErr: true},
+		{name: "unsupported shell", shells: []string{"tcsh"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateShells(tt.shells, tt.frameworks...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateShells() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := ValidateShells([]string{"tcsh"}); !errors.Is(err, interfaces.ErrUnsupportedShell) {
+		t.Errorf("Expected ErrUnsupportedShell, got %v", err)
+	}
+}
+
+func TestListShells(t *testing.T) {
+	catalog := []*interfaces.Shell{{Name: "zsh"}, {Name: "Fish"}, {Name: "nu", Package: "nushell"}, {Name: "pwsh", Package: "powershell"}}
+	lookPath := func(name string) (string, error) {
+		if name == "bash" || name == "zsh" {
+			return "/usr/bin/" + name, nil
+		}
+		return "", errors.New("not found")
+	}
+	got := ListShells(catalog, "/bin/zsh", lookPath)
+	want := []KnownShell{
+		{Name: "bash", Path: "/usr/bin/bash"},
+		{Name: "zsh", Path: "/usr/bin/zsh", Default: true, Installable: true},
+		{Name: "fish", Installable: true},
+		{Name: "nu", Installable: true},
+		{Name: "pwsh", Installable: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListShells() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,148 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+)
+
+// smokeTestTimeout bounds one smoke test, rc file startup included
+const smokeTestTimeout = 30 * time.Second
+
+// basePath is the PATH a fresh login starts from, before rc files add to it
+const basePath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+// SmokeTest is a quick command proving a language works in a new shell
+type SmokeTest struct {
+	Name    string
+	Command string
+}
+
+// SmokeResult is the outcome of one smoke test
+type SmokeResult struct {
+	SmokeTest
+	Passed bool
+	// Output is what the command printed, rc file noise included
+	Output string
+}
+
+// smokeCommands are the built-in smoke tests by lowercased language name
+var smokeCommands = map[string]string{
+	"node.js": `node -e "console.log('ok')"`,
+	"node":    `node -e "console.log('ok')"`,
+	"python":  `python3 -c "print('ok')"`,
+	"go":      "go version",
+	"rust":    "cargo --version",
+}
+
+// SmokeTestFor returns the smoke test for a language: a built-in one for
+// Node.js, Python, Go and Rust, otherwise its verify command
+func SmokeTestFor(language, verifyCommand string) (SmokeTest, bool) {
+	if command, ok := smokeCommands[strings.ToLower(language)]; ok {
+		return SmokeTest{Name: language, Command: command}, true
+	}
+	if verifyCommand != "" {
+		return SmokeTest{Name: language, Command: verifyCommand}, true
+	}
+	return SmokeTest{}, false
+}
+
+// SmokeTestsFor returns the smoke tests for languages, leaving out those with none
+func SmokeTestsFor(languages []*interfaces.Language) []SmokeTest {
+	var tests []SmokeTest
+	for _, lang := range languages {
+		if test, ok := SmokeTestFor(lang.Name, lang.VerifyCommand); ok {
+			tests = append(tests, test)
+		}
+	}
+	return tests
+}
+
+// RunSmokeTests runs each test in a fresh interactive shellPath that starts from
+// a bare environment and reads the user's rc file, so a test only passes when
+// the rc edits alone put the language on PATH
+func RunSmokeTests(shellPath string, tests []SmokeTest) []SmokeResult {
+	results := make([]SmokeResult, 0, len(tests))
+	for _, test := range tests {
+		results = append(results, runSmokeTest(shellPath, test))
+	}
+	return results
+}
+
+func runSmokeTest(shellPath string, test SmokeTest) SmokeResult {
+	ctx, cancel := context.WithTimeout(context.Background(), smokeTestTimeout)
+	defer cancel()
+
+	// fish reads config.fish for every shell and PowerShell reads $PROFILE; bash
+	// and zsh only read rc files when interactive, and nu -c only reads the config
+	// files it is given
+	args := []string{"-i", "-c", test.Command}
+	switch shellName(shellPath) {
+	case "fish":
+		args = []string{"-c", test.Command}
+	case "pwsh":
+		args = []string{"-NoLogo", "-Command", test.Command}
+	case "nu":
+		if home, err := system.UserHome(); err == nil {
+			dir := filepath.Join(home, ".config", "nushell")
+			args = []string{"--env-config", filepath.Join(dir, "env.nu"), "--config", filepath.Join(dir, "config.nu"), "-c", test.Command}
+		}
+	}
+	cmd := exec.CommandContext(ctx, shellPath, args...)
+	cmd.Env = freshShellEnv(shellPath)
+	output, err := cmd.CombinedOutput()
+
+	result := SmokeResult{SmokeTest: test, Passed: err == nil, Output: strings.TrimSpace(string(output))}
+	if ctx.Err() != nil {
+		result.Output = fmt.Sprintf("timed out after %s", smokeTestTimeout)
+	}
+	return result
+}
+
+// freshShellEnv is the environment of a new login: the user's identity and
+// terminal, the base PATH and nothing this process added since. Windows has no
+// base PATH to start from, so there the system PATH is kept along with the
+// variables Windows programs need to run.
+func freshShellEnv(shellPath string) []string {
+	path := basePath
+	keys := []string{"HOME", "USER", "LOGNAME", "LANG", "PREFIX"}
+	switch {
+	case runtime.GOOS == "windows":
+		path = os.Getenv("PATH")
+		keys = append(keys, "USERPROFILE", "USERNAME", "APPDATA", "LOCALAPPDATA", "SystemRoot", "ComSpec", "PATHEXT", "TEMP", "TMP")
+	case system.IsTermux(os.Getenv):
+		path = filepath.Join(os.Getenv("PREFIX"), "bin") + ":" + path
+	}
+	env := []string{"PATH=" + path, "SHELL=" + shellPath, SpawnedEnvVar + "=1"}
+	for _, key := range keys {
+		if value, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+value)
+		}
+	}
+	if term := os.Getenv("TERM"); term != "" && term != "dumb" {
+		env = append(env, "TERM="+term)
+	} else {
+		env = append(env, "TERM="+defaultTerm)
+	}
+	return env
+}
+
+// String renders e.g. "PASS Go (go version)" or "FAIL Rust (cargo --version): cargo: command not found"
+func (r SmokeResult) String() string {
+	if r.Passed {
+		return fmt.Sprintf("PASS %s (%s)", r.Name, r.Command)
+	}
+	last := r.Output
+	if i := strings.LastIndex(last, "\n"); i >= 0 {
+		last = last[i+1:]
+	}
+	return fmt.Sprintf("FAIL %s (%s): %s", r.Name, r.Command, last)
+}
@@ -0,0 +1,61 @@
+package shell
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSmokeTestFor(t *testing.T) {
+	if test, ok := SmokeTestFor("Go", "go version"); !ok || test.Command != "go version" {
+		t.Errorf("Expected the built-in Go smoke test, got %+v", test)
+	}
+	if test, ok := SmokeTestFor("Node.js", "node --version"); !ok || !strings.HasPrefix(test.Command, "node -e") {
+		t.Errorf("Expected the built-in Node.js smoke test, got %+v", test)
+	}
+	if test, ok := SmokeTestFor("Zig", "zig version"); !ok || test.Command != "zig version" {
+		t.Errorf("Expected the verify command for an unknown language, got %+v", test)
+	}
+	if _, ok := SmokeTestFor("Zig", ""); ok {
+		t.Error("Expected no smoke test without a verify command")
+	}
+}
+
+func TestRunSmokeTests_FreshShell(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("PREFIX", "")
+
+	// The binary is only reachable through the PATH entry the rc file adds
+	bin := filepath.Join(home, ".toolchain", "bin")
+	if err := os.MkdirAll(bin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bin, "mylang"), []byte("#!/bin/sh\necho ok\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+":"+os.Getenv("PATH"))
+
+	tests := []SmokeTest{{Name: "MyLang", Command: "mylang"}}
+	if results := RunSmokeTests(bash, tests); results[0].Passed {
+		t.Errorf("Expected the test to fail before the rc file adds the PATH entry: %s", results[0])
+	}
+
+	rc := "export PATH=\"$HOME/.toolchain/bin:$PATH\"\n"
+	if err := os.WriteFile(filepath.Join(home, ".bashrc"), []byte(rc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	results := RunSmokeTests(bash, tests)
+	if !results[0].Passed {
+		t.Errorf("Expected the test to pass once the rc file adds the PATH entry: %s", results[0])
+	}
+	if !strings.HasPrefix(results[0].String(), "PASS MyLang") {
+		t.Errorf("Unexpected result line: %q", results[0].String())
+	}
+}
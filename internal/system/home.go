@@ -0,0 +1,51 @@
+package system
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+// currentUser looks up the current user (replaced in tests)
+var currentUser = user.Current
+
+// UserHome returns the current user's home directory. It uses $HOME when set,
+// then the user database, and finally the conventional home of the current user
+// name, so minimal containers and CI runners without $HOME still work.
+func UserHome() (string, error) {
+	if home := os.Getenv("HOME"); home != "" {
+		return home, nil
+	}
+
+	name := os.Getenv("USER")
+	if u, err := currentUser(); err == nil {
+		if u.HomeDir != "" {
+			return u.HomeDir, nil
+		}
+		if u.Username != "" {
+			name = u.Username
+		}
+	}
+	if home := defaultHome(name); home != "" {
+		return home, nil
+	}
+	return "", fmt.Errorf("%w: $HOME is not set and the current user is unknown; set HOME and retry", interfaces.ErrHomeDirNotFound)
+}
+
+// defaultHome returns the conventional home directory for a user name on this OS
+func defaultHome(name string) string {
+	switch {
+	case name == "":
+		return ""
+	case name == "root" && runtime.GOOS != "darwin":
+		return "/root"
+	case runtime.GOOS == "darwin":
+		return filepath.Join("/Users", name)
+	default:
+		return filepath.Join("/home", name)
+	}
+}
@@ -0,0 +1,40 @@
+package system
+
+import (
+	"errors"
+	"os/user"
+	"runtime"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+func TestUserHome(t *testing.T) {
+	defer func(orig func() (*user.User, error)) { currentUser = orig }(currentUser)
+
+	t.Setenv("HOME", "/home/from-env")
+	if home, err := UserHome(); err != nil || home != "/home/from-env" {
+		t.Errorf("Expected $HOME to win, got %q, %v", home, err)
+	}
+
+	t.Setenv("HOME", "")
+	t.Setenv("USER", "")
+	currentUser = func() (*user.User, error) { return &user.User{Username: "dev", HomeDir: "/srv/dev"}, nil }
+	if home, err := UserHome(); err != nil || home != "/srv/dev" {
+		t.Errorf("Expected user database home, got %q, %v", home, err)
+	}
+
+	currentUser = func() (*user.User, error) { return &user.User{Username: "dev"}, nil }
+	want := "/home/dev"
+	if runtime.GOOS == "darwin" {
+		want = "/Users/dev"
+	}
+	if home, err := UserHome(); err != nil || home != want {
+		t.Errorf("Expected conventional home %q, got %q, %v", want, home, err)
+	}
+
+	currentUser = func() (*user.User, error) { return nil, errors.New("no passwd entry") }
+	if _, err := UserHome(); !errors.Is(err, interfaces.ErrHomeDirNotFound) {
+		t.Errorf("Expected ErrHomeDirNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package system
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// geteuid and lookPath are replaced in tests
+var (
+	geteuid  = os.Geteuid
+	lookPath = exec.LookPath
+)
+
+// NeedsSudo reports whether system package operations must run through sudo.
+// Root (as in most containers, which often ship without sudo) runs them
+// directly, and Termux runs unprivileged and has no sudo.
+func NeedsSudo() bool {
+	return geteuid() != 0 && !IsTermux(os.Getenv)
+}
+
+// SudoAvailable reports whether sudo is on PATH
+func SudoAvailable() bool {
+	_, err := lookPath("sudo")
+	return err == nil
+}
+
+// usesSudo reports whether privileged commands are prefixed with sudo. Without
+// sudo they run directly and report their own permission errors.
+func usesSudo() bool {
+	return NeedsSudo() && SudoAvailable()
+}
+
+// PrivilegedCommand builds a command that needs root, running it through sudo
+// only when the current user is not root and sudo is installed
+func PrivilegedCommand(name string, args ...string) *exec.Cmd {
+	if usesSudo() {
+		return exec.Command("sudo", append(append(sudoFlags(), name), args...)...)
+	}
+	return exec.Command(name, args...)
+}
+
+// SudoPrefix returns "sudo " (with the flags PrivilegedCommand adds) for
+// privileged commands run through a shell, or "" when PrivilegedCommand would
+// run them directly
+func SudoPrefix() string {
+	if usesSudo() {
+		return strings.Join(append([]string{"sudo"}, sudoFlags()...), " ") + " "
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package system
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+// stubPrivilege sets the effective uid and whether sudo is installed for one test
+func stubPrivilege(t *testing.T, euid int, sudo bool) {
+	t.Helper()
+	origEuid, origLookPath := geteuid, lookPath
+	t.Cleanup(func() { geteuid, lookPath = origEuid, origLookPath })
+	geteuid = func() int { return euid }
+	lookPath = func(file string) (string, error) {
+		if file == "sudo" && sudo {
+			return "/usr/bin/sudo", nil
+		}
+		return "", exec.ErrNotFound
+	}
+}
+
+func TestPrivilegedCommand(t *testing.T) {
+	t.Setenv("PREFIX", "")
+
+	tests := []struct {
+		name     string
+		euid     int
+		sudo     bool
+		wantArgs []string
+		prefix   string
+	}{
+		{"user with sudo", 1000, true, []string{"sudo", "apt-get", "install", "-y", "git"}, "sudo "},
+		{"root container without sudo", 0, false, []string{"apt-get", "install", "-y", "git"}, ""},
+		{"root with sudo", 0, true, []string{"apt-get", "install", "-y", "git"}, ""},
+		{"user without sudo", 1000, false, []string{"apt-get", "install", "-y", "git"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubPrivilege(t, tt.euid, tt.sudo)
+			cmd := PrivilegedCommand("apt-get", "install", "-y", "git")
+			if !reflect.DeepEqual(cmd.Args, tt.wantArgs) {
+				t.Errorf("PrivilegedCommand() args = %v, want %v", cmd.Args, tt.wantArgs)
+			}
+			if got := SudoPrefix(); got != tt.prefix {
+				t.Errorf("SudoPrefix() = %q, want %q", got, tt.prefix)
+			}
+		})
+	}
+}
+
+func TestNeedsSudo(t *testing.T) {
+	t.Setenv("PREFIX", "")
+	stubPrivilege(t, 0, true)
+	if NeedsSudo() {
+		t.Error("Expected root not to need sudo")
+	}
+
+	stubPrivilege(t, 1000, true)
+	if !NeedsSudo() {
+		t.Error("Expected a regular user to need sudo")
+	}
+
+	t.Setenv("PREFIX", "/data/data/com.termux/files/usr")
+	if NeedsSudo() {
+		t.Error("Expected no sudo on Termux")
+	}
+}
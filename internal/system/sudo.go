@@ -0,0 +1,177 @@
+package system
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// SudoAskpassEnvVar is sudo's own variable naming a helper program that prints
+// the password; when it is set sudo is always run with -A
+const SudoAskpassEnvVar = "SUDO_ASKPASS"
+
+// sudoKeepAliveInterval refreshes the sudo timestamp well within sudo's
+// default 5 minute timeout
+var sudoKeepAliveInterval = time.Minute
+
+// runSudo runs sudo with args, feeding it stdin; replaced in tests
+var runSudo = func(stdin io.Reader, args ...string) error {
+	cmd := exec.Command("sudo", args...)
+	cmd.Stdin = stdin
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+var (
+	sudoMu sync.Mutex
+	// sudoPrimed is set while a SudoSession holds valid credentials; sudo then
+	// runs with -n so an expired timestamp fails instead of prompting inside the UI
+	sudoPrimed bool
+)
+
+// sudoFlags returns the flags PrivilegedCommand and SudoPrefix pass to sudo
+func sudoFlags() []string {
+	if askpassConfigured() {
+		return []string{"-A"}
+	}
+	sudoMu.Lock()
+	defer sudoMu.Unlock()
+	if sudoPrimed {
+		return []string{"-n"}
+	}
+	return nil
+}
+
+func askpassConfigured() bool {
+	return os.Getenv(SudoAskpassEnvVar) != ""
+}
+
+// SudoSession keeps the sudo timestamp fresh for the length of a run
+type SudoSession struct {
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// PrimeSudo asks for sudo credentials once, before a UI owns the terminal, and
+// keeps them cached until Stop. In order it uses cached credentials or
+// NOPASSWD, the SUDO_ASKPASS helper, a password line read from in (with
+// passwordStdin) and a prompt on the terminal. The password is only held in
+// memory long enough to hand it to sudo. Nothing happens when sudo is not used.
+func PrimeSudo(passwordStdin bool, in *os.File, out io.Writer) (*SudoSession, error) {
+	if !usesSudo() {
+		return &SudoSession{}, nil
+	}
+
+	if err := runSudo(nil, "-n", "-v"); err != nil {
+		switch {
+		case askpassConfigured():
+			if err := runSudo(nil, "-A", "-v"); err != nil {
+				return nil, fmt.Errorf("failed to authenticate with %s: %w", SudoAskpassEnvVar, err)
+			}
+		case passwordStdin:
+			password, err := readLine(in)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the sudo password from stdin: %w", err)
+			}
+			if err := validateSudoPassword(password); err != nil {
+				return nil, err
+			}
+		case term.IsTerminal(in.Fd()):
+			fmt.Fprint(out, "[sudo] password for installing system packages: ")
+			password, err := term.ReadPassword(in.Fd())
+			fmt.Fprintln(out)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the sudo password: %w", err)
+			}
+			if err := validateSudoPassword(password); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("sudo needs a password and there is no terminal to ask on; pass --sudo-password-stdin or set %s", SudoAskpassEnvVar)
+		}
+	}
+
+	sudoMu.Lock()
+	sudoPrimed = true
+	sudoMu.Unlock()
+
+	s := &SudoSession{stop: make(chan struct{}), done: make(chan struct{})}
+	go s.keepAlive()
+	return s, nil
+}
+
+// validateSudoPassword hands password to sudo -S to cache the timestamp, then
+// clears it
+func validateSudoPassword(password []byte) error {
+	input := append(password, '\n')
+	defer clear(input)
+	if err := runSudo(bytes.NewReader(input), "-S", "-p", "", "-v"); err != nil {
+		return fmt.Errorf("sudo authentication failed: %w", err)
+	}
+	return nil
+}
+
+// readLine reads one line from r a byte at a time, so input after the password
+// is left for later prompts
+func readLine(r io.Reader) ([]byte, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := r.Read(b)
+		if n == 1 {
+			if b[0] == '\n' {
+				break
+			}
+			line = append(line, b[0])
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			clear(line)
+			return nil, err
+		}
+	}
+	return bytes.TrimRight(line, "\r"), nil
+}
+
+// keepAlive refreshes the timestamp until Stop
+func (s *SudoSession) keepAlive() {
+	defer close(s.done)
+	ticker := time.NewTicker(sudoKeepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			// A refresh that fails surfaces on the next privileged command
+			_ = runSudo(nil, "-n", "-v")
+		}
+	}
+}
+
+// Stop ends the keep-alive; privileged commands prompt normally again
+func (s *SudoSession) Stop() {
+	if s == nil || s.stop == nil {
+		return
+	}
+	s.once.Do(func() {
+		close(s.stop)
+		<-s.done
+		sudoMu.Lock()
+		sudoPrimed = false
+		sudoMu.Unlock()
+	})
+}
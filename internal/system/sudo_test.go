@@ -0,0 +1,175 @@
+package system
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSudo records sudo invocations; -n -v succeeds only when cached is set
+type fakeSudo struct {
+	mu     sync.Mutex
+	cached bool
+	calls  []string
+	stdin  []string
+}
+
+func (f *fakeSudo) run(stdin io.Reader, args ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, strings.Join(args, " "))
+	if stdin != nil {
+		data, _ := io.ReadAll(stdin)
+		f.stdin = append(f.stdin, string(data))
+	}
+	if args[0] == "-n" && !f.cached {
+		return errors.New("a password is required")
+	}
+	if args[0] == "-S" {
+		f.cached = true
+	}
+	return nil
+}
+
+func (f *fakeSudo) count(call string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, c := range f.calls {
+		if c == call {
+			n++
+		}
+	}
+	return n
+}
+
+func stubSudo(t *testing.T, cached bool) *fakeSudo {
+	t.Helper()
+	t.Setenv("PREFIX", "")
+	t.Setenv(SudoAskpassEnvVar, "")
+	stubPrivilege(t, 1000, true)
+	f := &fakeSudo{cached: cached}
+	orig := runSudo
+	t.Cleanup(func() { runSudo = orig })
+	runSudo = f.run
+	return f
+}
+
+// pipeInput returns a file to read input from, like a redirected stdin
+func pipeInput(t *testing.T, input string) *os.File {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Close() })
+	go func() {
+		io.WriteString(w, input)
+		w.Close()
+	}()
+	return r
+}
+
+func TestPrimeSudo_Cached(t *testing.T) {
+	f := stubSudo(t, true)
+
+	s, err := PrimeSudo(false, pipeInput(t, ""), io.Discard)
+	if err != nil {
+		t.Fatalf("PrimeSudo() error = %v", err)
+	}
+	if got := SudoPrefix(); got != "sudo -n " {
+		t.Errorf("Expected sudo -n while primed, got %q", got)
+	}
+	s.Stop()
+	if got := SudoPrefix(); got != "sudo " {
+		t.Errorf("Expected plain sudo after Stop, got %q", got)
+	}
+	if len(f.stdin) != 0 {
+		t.Errorf("Expected no password to be sent, got %v", f.stdin)
+	}
+}
+
+func TestPrimeSudo_PasswordStdin(t *testing.T) {
+	f := stubSudo(t, false)
+	in := pipeInput(t, "hunter2\nnext answer\n")
+
+	s, err := PrimeSudo(true, in, io.Discard)
+	if err != nil {
+		t.Fatalf("PrimeSudo() error = %v", err)
+	}
+	defer s.Stop()
+	if f.count("-S -p  -v") != 1 || len(f.stdin) != 1 || f.stdin[0] != "hunter2\n" {
+		t.Errorf("Expected the password on sudo -S's stdin, got calls %v stdin %q", f.calls, f.stdin)
+	}
+	rest, _ := io.ReadAll(in)
+	if string(rest) != "next answer\n" {
+		t.Errorf("Expected input after the password to be left, got %q", rest)
+	}
+}
+
+func TestPrimeSudo_Askpass(t *testing.T) {
+	f := stubSudo(t, false)
+	t.Setenv(SudoAskpassEnvVar, "/usr/bin/ssh-askpass")
+
+	s, err := PrimeSudo(false, pipeInput(t, ""), io.Discard)
+	if err != nil {
+		t.Fatalf("PrimeSudo() error = %v", err)
+	}
+	defer s.Stop()
+	if f.count("-A -v") != 1 {
+		t.Errorf("Expected sudo -A -v, got %v", f.calls)
+	}
+	if cmd := PrivilegedCommand("apt-get", "update"); strings.Join(cmd.Args, " ") != "sudo -A apt-get update" {
+		t.Errorf("Expected privileged commands to use the askpass helper, got %v", cmd.Args)
+	}
+}
+
+func TestPrimeSudo_NoTerminal(t *testing.T) {
+	stubSudo(t, false)
+	if _, err := PrimeSudo(false, pipeInput(t, ""), io.Discard); err == nil || !strings.Contains(err.Error(), "--sudo-password-stdin") {
+		t.Errorf("Expected an error pointing at --sudo-password-stdin, got %v", err)
+	}
+}
+
+func TestPrimeSudo_NotNeeded(t *testing.T) {
+	f := stubSudo(t, false)
+	stubPrivilege(t, 0, true)
+
+	s, err := PrimeSudo(false, pipeInput(t, ""), io.Discard)
+	if err != nil {
+		t.Fatalf("PrimeSudo() error = %v", err)
+	}
+	s.Stop()
+	if len(f.calls) != 0 {
+		t.Errorf("Expected sudo not to run as root, got %v", f.calls)
+	}
+}
+
+func TestSudoSessionKeepAlive(t *testing.T) {
+	f := stubSudo(t, true)
+	orig := sudoKeepAliveInterval
+	t.Cleanup(func() { sudoKeepAliveInterval = orig })
+	sudoKeepAliveInterval = 5 * time.Millisecond
+
+	s, err := PrimeSudo(false, pipeInput(t, ""), io.Discard)
+	if err != nil {
+		t.Fatalf("PrimeSudo() error = %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for f.count("-n -v") < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	s.Stop()
+	refreshes := f.count("-n -v")
+	if refreshes < 3 {
+		t.Fatalf("Expected the timestamp to be refreshed, got %d calls", refreshes)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if f.count("-n -v") != refreshes {
+		t.Error("Expected refreshes to stop after Stop")
+	}
+}
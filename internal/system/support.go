@@ -0,0 +1,79 @@
+package system
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UnsupportedPlatformExitCode is the exit status when bootstrap-cli cannot set up
+// this platform
+const UnsupportedPlatformExitCode = 3
+
+// supportedOS are the operating systems bootstrap-cli can set up; Termux may
+// report android
+var supportedOS = []string{"linux", "darwin", "android"}
+
+// supportedManagers are the package managers with an implementation
+var supportedManagers = []string{"apt", "dnf", "pacman", "zypper", "brew", "pkg", "winget", "choco"}
+
+// versionManagerArches lists the architectures each language's version manager
+// has prebuilt binaries for. Languages that are not listed (Python through
+// pyenv) build from source and work everywhere.
+var versionManagerArches = map[string][]string{
+	"Node.js": {"amd64", "arm64", "arm", "ppc64le", "s390x"},
+	"Go":      {"amd64", "arm64", "386", "arm", "ppc64le", "s390x", "riscv64", "loong64"},
+	"Rust":    {"amd64", "arm64", "386", "arm", "ppc64le", "s390x", "riscv64", "loong64", "mips64le"},
+}
+
+// UnsupportedPlatformError lists what bootstrap-cli cannot handle on this platform
+type UnsupportedPlatformError struct {
+	Platform string
+	Issues   []string
+}
+
+func (e *UnsupportedPlatformError) Error() string {
+	return fmt.Sprintf("unsupported platform %s:\n  - %s", e.Platform, strings.Join(e.Issues, "\n  - "))
+}
+
+// ExitCode returns the process exit status for the error
+func (e *UnsupportedPlatformError) ExitCode() int {
+	return UnsupportedPlatformExitCode
+}
+
+// PlatformIssues returns why goos with package manager manager cannot be set up,
+// or nothing when it can; an empty manager means none was found
+func PlatformIssues(goos, manager string) []string {
+	var issues []string
+	if !contains(supportedOS, goos) {
+		issues = append(issues, fmt.Sprintf("OS %s is not supported; bootstrap-cli runs on Linux, macOS and Termux", goos))
+	}
+	switch {
+	case manager == "":
+		issues = append(issues, fmt.Sprintf("no supported package manager found; install one of %s", strings.Join(supportedManagers, ", ")))
+	case !contains(supportedManagers, manager):
+		issues = append(issues, fmt.Sprintf("package manager %s is not supported; use one of %s", manager, strings.Join(supportedManagers, ", ")))
+	}
+	return issues
+}
+
+// LanguageIssues returns the languages that cannot be installed through their
+// version manager on arch, with what to do instead
+func LanguageIssues(arch string, languages []string) []string {
+	var issues []string
+	for _, lang := range languages {
+		arches, ok := versionManagerArches[lang]
+		if ok && !contains(arches, arch) {
+			issues = append(issues, fmt.Sprintf("arch %s has no binary fallback for %s; use --language-strategy system or deselect it", arch, lang))
+		}
+	}
+	return issues
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
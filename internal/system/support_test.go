@@ -0,0 +1,49 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPlatformIssues(t *testing.T) {
+	if issues := PlatformIssues("linux", "apt"); len(issues) != 0 {
+		t.Errorf("Expected linux/apt to be supported, got %v", issues)
+	}
+	if issues := PlatformIssues("android", "pkg"); len(issues) != 0 {
+		t.Errorf("Expected Termux to be supported, got %v", issues)
+	}
+
+	issues := PlatformIssues("freebsd", "")
+	if len(issues) != 2 || !strings.Contains(issues[0], "OS freebsd") || !strings.Contains(issues[1], "no supported package manager") {
+		t.Errorf("Expected the OS and the missing manager to be reported, got %v", issues)
+	}
+	if issues := PlatformIssues("linux", "zypper"); len(issues) != 0 {
+		t.Errorf("Expected linux/zypper to be supported, got %v", issues)
+	}
+	if issues := PlatformIssues("linux", "xbps"); len(issues) != 1 || !strings.Contains(issues[0], "xbps") {
+		t.Errorf("Expected an unknown manager to be reported, got %v", issues)
+	}
+}
+
+func TestLanguageIssues(t *testing.T) {
+	issues := LanguageIssues("riscv64", []string{"Node.js", "Python", "Go"})
+	if len(issues) != 1 || !strings.Contains(issues[0], "arch riscv64 has no binary fallback for Node.js") {
+		t.Errorf("Expected only Node.js to be unavailable on riscv64, got %v", issues)
+	}
+	if issues := LanguageIssues("amd64", []string{"Node.js", "Go", "Rust"}); len(issues) != 0 {
+		t.Errorf("Expected every language on amd64, got %v", issues)
+	}
+}
+
+func TestUnsupportedPlatformError(t *testing.T) {
+	err := fmt.Errorf("up: %w", &UnsupportedPlatformError{Platform: "freebsd/amd64", Issues: []string{"OS freebsd is not supported"}})
+	var unsupported *UnsupportedPlatformError
+	if !errors.As(err, &unsupported) || unsupported.ExitCode() != 3 {
+		t.Fatalf("Expected a wrapped UnsupportedPlatformError with exit code 3, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "freebsd/amd64:\n  - OS freebsd") {
+		t.Errorf("Unexpected message: %q", err.Error())
+	}
+}
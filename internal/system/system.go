@@ -10,6 +10,8 @@ import (
 	"os/exec"
 	"runtime"
 	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
 )
 
 // Info contains information about the current system
@@ -24,6 +26,7 @@ type Info struct {
 	PackageType     string  // Package manager type (apt, dnf, pacman, brew)
 	IsRoot          bool
 	IsWSL           bool
+	IsTermux        bool    // Termux on Android: $PREFIX layout, pkg, no sudo
 	IsDocker        bool
 	IsVM            bool
 	IsContainer     bool
@@ -47,13 +50,29 @@ type Info struct {
 	IsDryRun        bool
 }
 
+// homeDirOrEmpty returns the user's home directory, or "" when it cannot be determined
+func homeDirOrEmpty() string {
+	home, _ := UserHome()
+	return home
+}
+
+// DefaultLanguageStrategy returns how languages should be installed when neither the
+// user nor the language config chooses: plain system packages in containers and WSL,
+// where version managers and rc edits are rarely wanted, and version managers elsewhere
+func (i *Info) DefaultLanguageStrategy() string {
+	if i.IsContainer {
+		return interfaces.LanguageStrategySystem
+	}
+	return interfaces.LanguageStrategyVersionManager
+}
+
 // Detect gathers information about the current system
 func Detect() (*Info, error) {
 	info := &Info{
 		OS:     runtime.GOOS,
 		Arch:   runtime.GOARCH,
 		Shell:  os.Getenv("SHELL"),
-		HomeDir: os.Getenv("HOME"),
+		HomeDir: homeDirOrEmpty(),
 		IsRoot: os.Geteuid() == 0,
 	}
 
@@ -64,8 +83,11 @@ func Detect() (*Info, error) {
 	}
 
 	// Detect OS-specific information
-	switch info.OS {
-	case "linux":
+	switch {
+	case IsTermux(os.Getenv):
+		// Termux has no /etc/os-release and reports GOOS linux or android
+		getTermuxInfo(info)
+	case info.OS == "linux":
 		if err := getLinuxDistroInfo(info); err != nil {
 			return nil, fmt.Errorf("failed to get Linux distribution info: %w", err)
 		}
@@ -76,8 +98,10 @@ func Detect() (*Info, error) {
 			info.PackageType = "dnf"
 		} else if _, err := exec.LookPath("pacman"); err == nil {
 			info.PackageType = "pacman"
+		} else if _, err := exec.LookPath("zypper"); err == nil {
+			info.PackageType = "zypper"
 		}
-	case "darwin":
+	case info.OS == "darwin":
 		if err := getDarwinInfo(info); err != nil {
 			return nil, fmt.Errorf("failed to get macOS info: %w", err)
 		}
@@ -226,4 +250,17 @@ func getDarwinInfo(info *Info) error {
 	}
 	info.Version = strings.TrimSpace(string(out))
 	return nil
-} 
\ No newline at end of file
+} 
+// HomebrewPrefix returns where Homebrew installs on goos/goarch by default:
+// /opt/homebrew on Apple silicon, /usr/local on Intel Macs and
+// /home/linuxbrew/.linuxbrew on Linux
+func HomebrewPrefix(goos, goarch string) string {
+	switch {
+	case goos == "darwin" && goarch == "arm64":
+		return "/opt/homebrew"
+	case goos == "darwin":
+		return "/usr/local"
+	default:
+		return "/home/linuxbrew/.linuxbrew"
+	}
+}
@@ -52,4 +52,17 @@ func TestDetect(t *testing.T) {
 			t.Error("Detect() PackageType is empty")
 		}
 	}
-} 
\ No newline at end of file
+} 
+func TestHomebrewPrefix(t *testing.T) {
+	tests := map[[2]string]string{
+		{"darwin", "arm64"}: "/opt/homebrew",
+		{"darwin", "amd64"}: "/usr/local",
+		{"linux", "amd64"}:  "/home/linuxbrew/.linuxbrew",
+		{"linux", "arm64"}:  "/home/linuxbrew/.linuxbrew",
+	}
+	for platform, want := range tests {
+		if got := HomebrewPrefix(platform[0], platform[1]); got != want {
+			t.Errorf("HomebrewPrefix(%s, %s) = %q, want %q", platform[0], platform[1], got, want)
+		}
+	}
+}
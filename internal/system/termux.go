@@ -0,0 +1,43 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+// termuxPackage appears in $PREFIX on Termux (e.g. /data/data/com.termux/files/usr)
+const termuxPackage = "com.termux"
+
+// defaultPrefix is where binaries are installed outside Termux
+const defaultPrefix = "/usr/local"
+
+// IsTermux reports whether bootstrap-cli is running under Termux on Android,
+// where $PREFIX replaces /usr and /usr/local and there is no sudo
+func IsTermux(getenv func(string) string) bool {
+	return strings.Contains(getenv("PREFIX"), termuxPackage)
+}
+
+// InstallPrefix returns the prefix binary installs go under: $PREFIX on Termux,
+// /usr/local elsewhere
+func InstallPrefix(getenv func(string) string) string {
+	if IsTermux(getenv) {
+		return getenv("PREFIX")
+	}
+	return defaultPrefix
+}
+
+// BinDir returns the directory installed binaries are placed in
+func BinDir() string {
+	return filepath.Join(InstallPrefix(os.Getenv), "bin")
+}
+
+// getTermuxInfo fills in the distribution details for Termux
+func getTermuxInfo(info *Info) {
+	info.IsTermux = true
+	info.Distro = "termux"
+	info.Version = os.Getenv("TERMUX_VERSION")
+	info.PackageType = string(interfaces.Pkg)
+}
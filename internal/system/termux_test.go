@@ -0,0 +1,42 @@
+package system
+
+import "testing"
+
+func TestIsTermux(t *testing.T) {
+	termux := func(key string) string {
+		if key == "PREFIX" {
+			return "/data/data/com.termux/files/usr"
+		}
+		return ""
+	}
+	linux := func(string) string { return "" }
+
+	if !IsTermux(termux) {
+		t.Error("Expected a com.termux $PREFIX to be detected as Termux")
+	}
+	if IsTermux(linux) {
+		t.Error("Expected no Termux without $PREFIX")
+	}
+	if got := InstallPrefix(termux); got != "/data/data/com.termux/files/usr" {
+		t.Errorf("InstallPrefix() on Termux = %q", got)
+	}
+	if got := InstallPrefix(linux); got != "/usr/local" {
+		t.Errorf("InstallPrefix() = %q, want /usr/local", got)
+	}
+}
+
+func TestDetectTermux(t *testing.T) {
+	t.Setenv("PREFIX", "/data/data/com.termux/files/usr")
+	t.Setenv("TERMUX_VERSION", "0.118.0")
+
+	info, err := Detect()
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+	if !info.IsTermux || info.Distro != "termux" || info.Version != "0.118.0" || info.PackageType != "pkg" {
+		t.Errorf("Unexpected Termux info: %+v", info)
+	}
+	if NeedsSudo() {
+		t.Error("Expected no sudo on Termux")
+	}
+}
@@ -0,0 +1,195 @@
+package system
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// VersionManagerNone disables detection, so languages always get the version
+// manager bootstrap-cli sets up itself (nvm, pyenv, goenv, rustup)
+const VersionManagerNone = "none"
+
+// DefaultVersionManagerOrder is the preference among existing version managers
+// when more than one can install a language
+var DefaultVersionManagerOrder = []string{"mise", "asdf", "fnm", "volta"}
+
+// existingVersionManagers maps each detectable manager to the languages it can
+// install, by language name and its plugin or tool name there
+var existingVersionManagers = map[string]map[string]string{
+	"mise":  {"Node.js": "node", "Python": "python", "Go": "go", "Rust": "rust"},
+	"asdf":  {"Node.js": "nodejs", "Python": "python", "Go": "golang", "Rust": "rust"},
+	"fnm":   {"Node.js": "node"},
+	"volta": {"Node.js": "node"},
+}
+
+// versionManagerHomePaths are where managers install themselves when they are
+// not on PATH yet, e.g. before the shell rc file that adds them was sourced
+var versionManagerHomePaths = map[string]string{
+	"mise":  ".local/bin/mise",
+	"asdf":  ".asdf/bin/asdf",
+	"fnm":   ".local/share/fnm/fnm",
+	"volta": ".volta/bin/volta",
+}
+
+// ExistingVersionManager is a version manager the user had already set up
+type ExistingVersionManager struct {
+	Name string
+	// Path is the manager's executable
+	Path string
+}
+
+// ValidVersionManager checks a --version-manager value: a detectable manager or none
+func ValidVersionManager(name string) error {
+	if name == "" || name == VersionManagerNone {
+		return nil
+	}
+	if _, ok := existingVersionManagers[name]; !ok {
+		return fmt.Errorf("unknown version manager %q: must be one of %s or %s", name, strings.Join(DefaultVersionManagerOrder, ", "), VersionManagerNone)
+	}
+	return nil
+}
+
+// DetectVersionManager returns the first manager in order that is installed and
+// can install language, or nil. A forced manager is the only one considered;
+// none disables detection. An empty order uses DefaultVersionManagerOrder.
+func DetectVersionManager(language, forced string, order []string) *ExistingVersionManager {
+	switch forced {
+	case VersionManagerNone:
+		return nil
+	case "":
+		if len(order) == 0 {
+			order = DefaultVersionManagerOrder
+		}
+	default:
+		order = []string{forced}
+	}
+	for _, name := range order {
+		if _, ok := existingVersionManagers[name][language]; !ok {
+			continue
+		}
+		if path := findVersionManager(name); path != "" {
+			return &ExistingVersionManager{Name: name, Path: path}
+		}
+	}
+	return nil
+}
+
+// findVersionManager returns the path of a manager's executable, or ""
+func findVersionManager(name string) string {
+	if path, err := lookPath(name); err == nil {
+		return path
+	}
+	home, err := UserHome()
+	if err != nil {
+		return ""
+	}
+	path := filepath.Join(home, versionManagerHomePaths[name])
+	if info, err := os.Stat(path); err == nil && !info.IsDir() && info.Mode()&0111 != 0 {
+		return path
+	}
+	return ""
+}
+
+// VersionManagerTool returns the plugin or tool name manager installs language
+// as, e.g. nodejs for Node.js with asdf
+func VersionManagerTool(manager, language string) (string, bool) {
+	tool, ok := existingVersionManagers[manager][language]
+	return tool, ok
+}
+
+// IsVersionManager reports whether name is a version manager bootstrap-cli can
+// install languages through, e.g. mise, rather than a package manager
+func IsVersionManager(name string) bool {
+	_, ok := existingVersionManagers[name]
+	return ok
+}
+
+// FindVersionManager returns the executable of manager when it is installed,
+// also where it installs itself before it is on PATH
+func FindVersionManager(manager string) (*ExistingVersionManager, bool) {
+	if _, ok := existingVersionManagers[manager]; !ok {
+		return nil, false
+	}
+	path := findVersionManager(manager)
+	if path == "" {
+		return nil, false
+	}
+	return &ExistingVersionManager{Name: manager, Path: path}, true
+}
+
+// InstallCommand returns the shell command that installs version of language
+// with the manager and makes it the user's default. An empty version is the
+// latest release (for Node.js through mise or fnm, the latest LTS).
+func (m *ExistingVersionManager) InstallCommand(language, version string) (string, error) {
+	tool, ok := existingVersionManagers[m.Name][language]
+	if !ok {
+		return "", fmt.Errorf("%s cannot install %s", m.Name, language)
+	}
+	bin := shellWord(m.Path)
+	switch m.Name {
+	case "mise":
+		switch {
+		case version != "":
+		case tool == "node":
+			version = "lts"
+		default:
+			version = "latest"
+		}
+		return fmt.Sprintf("%s use --global %s@%s", bin, tool, version), nil
+	case "asdf":
+		// latest:18 is the newest 18.x; names such as stable are used as is
+		spec := "latest"
+		if version != "" {
+			spec = version
+			if unicode.IsDigit(rune(version[0])) {
+				spec = "latest:" + version
+			}
+		}
+		// asdf 0.16 replaced global with set --home
+		return fmt.Sprintf("(%[1]s plugin add %[2]s || true) && %[1]s install %[2]s %[3]s && (%[1]s set --home %[2]s %[3]s || %[1]s global %[2]s %[3]s)",
+			bin, tool, spec), nil
+	case "fnm":
+		if version == "" {
+			return fmt.Sprintf("%[1]s install --lts && %[1]s default lts-latest", bin), nil
+		}
+		return fmt.Sprintf("%[1]s install %[2]s && %[1]s default %[2]s", bin, version), nil
+	case "volta":
+		if version == "" {
+			return fmt.Sprintf("%s install %s", bin, tool), nil
+		}
+		return fmt.Sprintf("%s install %s@%s", bin, tool, version), nil
+	default:
+		return "", fmt.Errorf("unknown version manager %s", m.Name)
+	}
+}
+
+// GlobalPackageCommand returns cmd, which installs a global package for
+// language, run against the version the manager installed. With asdf or mise
+// their shims come first on PATH so npm or pip is theirs, and they reshim
+// afterwards so the commands the package adds (yarn, pnpm, poetry) get shims.
+// Other managers run cmd as it is.
+func (m *ExistingVersionManager) GlobalPackageCommand(language, cmd string) string {
+	tool, ok := existingVersionManagers[m.Name][language]
+	if !ok {
+		return cmd
+	}
+	switch m.Name {
+	case "asdf":
+		return fmt.Sprintf(`PATH="${ASDF_DATA_DIR:-$HOME/.asdf}/shims:$PATH" %s && %s reshim %s`, cmd, shellWord(m.Path), tool)
+	case "mise":
+		return fmt.Sprintf(`PATH="${MISE_DATA_DIR:-${XDG_DATA_HOME:-$HOME/.local/share}/mise}/shims:$PATH" %s && %s reshim`, cmd, shellWord(m.Path))
+	default:
+		return cmd
+	}
+}
+
+// shellWord single-quotes s for sh when it contains anything but plain path characters
+func shellWord(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-./") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,130 @@
+package system
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// stubVersionManagers puts the named managers on PATH for one test
+func stubVersionManagers(t *testing.T, names ...string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	orig := lookPath
+	t.Cleanup(func() { lookPath = orig })
+	lookPath = func(file string) (string, error) {
+		for _, name := range names {
+			if name == file {
+				return "/usr/local/bin/" + file, nil
+			}
+		}
+		return "", exec.ErrNotFound
+	}
+}
+
+func TestDetectVersionManager(t *testing.T) {
+	stubVersionManagers(t, "fnm", "asdf")
+
+	tests := []struct {
+		language, forced string
+		order            []string
+		want             string
+	}{
+		{"Node.js", "", nil, "asdf"},
+		{"Node.js", "", []string{"volta", "fnm", "asdf"}, "fnm"},
+		{"Python", "", []string{"fnm"}, ""},
+		{"Python", "", nil, "asdf"},
+		{"Node.js", "fnm", nil, "fnm"},
+		{"Node.js", "mise", nil, ""},
+		{"Node.js", VersionManagerNone, nil, ""},
+		{"Ruby", "", nil, ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if vm := DetectVersionManager(tt.language, tt.forced, tt.order); vm != nil {
+			got = vm.Name
+		}
+		if got != tt.want {
+			t.Errorf("DetectVersionManager(%s, %q, %v) = %q, want %q", tt.language, tt.forced, tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestDetectVersionManager_HomeInstall(t *testing.T) {
+	stubVersionManagers(t)
+	home := os.Getenv("HOME")
+	volta := filepath.Join(home, ".volta", "bin", "volta")
+	if err := os.MkdirAll(filepath.Dir(volta), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(volta, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	vm := DetectVersionManager("Node.js", "", nil)
+	if vm == nil || vm.Name != "volta" || vm.Path != volta {
+		t.Errorf("Expected volta from %s, got %+v", volta, vm)
+	}
+}
+
+func TestExistingVersionManagerInstallCommand(t *testing.T) {
+	tests := []struct {
+		manager, language, version, want string
+	}{
+		{"mise", "Node.js", "18", "mise use --global node@18"},
+		{"mise", "Go", "", "mise use --global go@latest"},
+		{"mise", "Node.js", "", "mise use --global node@lts"},
+		{"asdf", "Python", "3.11", "(asdf plugin add python || true) && asdf install python latest:3.11 && (asdf set --home python latest:3.11 || asdf global python latest:3.11)"},
+		{"asdf", "Rust", "stable", "(asdf plugin add rust || true) && asdf install rust stable && (asdf set --home rust stable || asdf global rust stable)"},
+		{"fnm", "Node.js", "", "fnm install --lts && fnm default lts-latest"},
+		{"fnm", "Node.js", "20", "fnm install 20 && fnm default 20"},
+		{"volta", "Node.js", "18", "volta install node@18"},
+	}
+	for _, tt := range tests {
+		vm := &ExistingVersionManager{Name: tt.manager, Path: tt.manager}
+		got, err := vm.InstallCommand(tt.language, tt.version)
+		if err != nil {
+			t.Fatalf("InstallCommand(%s, %s) error = %v", tt.manager, tt.language, err)
+		}
+		if got != tt.want {
+			t.Errorf("InstallCommand(%s, %s, %q) =\n  %s\nwant\n  %s", tt.manager, tt.language, tt.version, got, tt.want)
+		}
+	}
+
+	vm := &ExistingVersionManager{Name: "volta", Path: "/home/me/my tools/volta"}
+	if _, err := vm.InstallCommand("Python", ""); err == nil {
+		t.Error("Expected volta to refuse Python")
+	}
+	if got, _ := vm.InstallCommand("Node.js", ""); got != "'/home/me/my tools/volta' install node" {
+		t.Errorf("Expected a quoted path, got %s", got)
+	}
+}
+
+func TestValidVersionManager(t *testing.T) {
+	for _, name := range []string{"", "none", "mise", "asdf", "fnm", "volta"} {
+		if err := ValidVersionManager(name); err != nil {
+			t.Errorf("ValidVersionManager(%q) error = %v", name, err)
+		}
+	}
+	if err := ValidVersionManager("nvm"); err == nil {
+		t.Error("Expected nvm to be rejected")
+	}
+}
+
+func TestExistingVersionManagerGlobalPackageCommand(t *testing.T) {
+	asdf := &ExistingVersionManager{Name: "asdf", Path: "/home/me/.asdf/bin/asdf"}
+	want := `PATH="${ASDF_DATA_DIR:-$HOME/.asdf}/shims:$PATH" npm install -g pnpm && /home/me/.asdf/bin/asdf reshim nodejs`
+	if got := asdf.GlobalPackageCommand("Node.js", "npm install -g pnpm"); got != want {
+		t.Errorf("GlobalPackageCommand() =\n  %s\nwant\n  %s", got, want)
+	}
+	mise := &ExistingVersionManager{Name: "mise", Path: "mise"}
+	want = `PATH="${MISE_DATA_DIR:-${XDG_DATA_HOME:-$HOME/.local/share}/mise}/shims:$PATH" pip install --user poetry && mise reshim`
+	if got := mise.GlobalPackageCommand("Python", "pip install --user poetry"); got != want {
+		t.Errorf("GlobalPackageCommand() =\n  %s\nwant\n  %s", got, want)
+	}
+	fnm := &ExistingVersionManager{Name: "fnm", Path: "fnm"}
+	if got := fnm.GlobalPackageCommand("Node.js", "npm install -g pnpm"); got != "npm install -g pnpm" {
+		t.Errorf("Expected other managers to run the command as is, got %s", got)
+	}
+}
@@ -5,13 +5,13 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
+	"runtime"
 	"strings"
 	"time"
 
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
 	base_iface "github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
-	"github.com/YitzhakMizrahi/bootstrap-cli/internal/packages/factory"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
@@ -58,6 +58,13 @@ type Model struct {
 	shellManager      interfaces.ShellManager // Added ShellManager
 	ManageDotfiles    bool // Exported field for dotfiles choice
 	DotfilesRepoURL   string // Exported field for dotfiles repo URL
+	// shells are the other shells to configure beside the selected one (see SetShells)
+	shells            []string
+	// installOutsideUI makes the TUI exit after selection so the caller can install
+	// with live command output instead of the installation screen
+	installOutsideUI  bool
+	// installer runs the installation screen's background install
+	installer *pipeline.Installer
 }
 
 // New creates a new application model
@@ -148,6 +155,11 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c":
+			// Stop a background install so it doesn't block sending progress nobody
+			// reads; its commands are killed, and the caller waits for them with StopInstall
+			if m.installer != nil {
+				m.installer.Cancel()
+			}
 			return m, tea.Quit // Global quit
 		}
 	case tea.WindowSizeMsg:
@@ -298,55 +310,22 @@ func (m *Model) transitionTo(targetScreen Screen) tea.Cmd {
 	switch targetScreen {
 	case WelcomeScreen: newScreen = screens.NewWelcomeScreen()
 	case ShellSelectionScreen: 
-		// 1. Load all defined shells from configuration
-		definedShells, err := m.config.LoadShells()
-		if err != nil { 
-			m.err = fmt.Errorf("failed to load shell definitions: %w", err)
-			newScreen = screens.NewWelcomeScreen() 
-			break 
-		}
-
-		// 2. Get shells available on the system
-		var systemShellsInfo []*interfaces.ShellInfo
-		if m.shellManager != nil {
-			systemShellsInfo, err = m.shellManager.ListAvailable()
-			if err != nil {
-				m.err = fmt.Errorf("failed to list available system shells: %w", err)
-				// Proceeding with empty systemShellsInfo, or could go to WelcomeScreen
-				systemShellsInfo = []*interfaces.ShellInfo{} 
-			}
-		} else {
-			m.err = fmt.Errorf("shell manager not initialized")
+		// 1. Load the defined shells that are available on the system
+		availableDisplayShells, err := m.availableShells()
+		if err != nil {
+			m.err = err
 			newScreen = screens.NewWelcomeScreen()
 			break
 		}
 
-		// 3. Filter defined shells to only those available on the system
-		availableDisplayShells := make([]*interfaces.Shell, 0)
-		systemShellMap := make(map[string]bool)
-		for _, sysShell := range systemShellsInfo {
-			systemShellMap[sysShell.Type] = true // Assuming Type is like "bash", "zsh"
-			systemShellMap[sysShell.Current] = true // sysShell.Current might be the name or path
-		}
-
-		for _, defShell := range definedShells {
-			if systemShellMap[defShell.Name] { // Assuming defShell.Name matches sysShell.Type or sysShell.Current
-				availableDisplayShells = append(availableDisplayShells, defShell)
-			}
-		}
-
-		// 4. Get the current shell's path or name for pre-selection
+		// 2. Get the current shell's path or name for pre-selection
 		currentShellIdentifier := ""
-		if m.shellManager != nil {
-			currentShellInfo, err := m.shellManager.DetectCurrent()
-			if err != nil {
-				m.err = fmt.Errorf("failed to detect current shell: %w", err)
-				// Proceeding without a pre-selected current shell
-			} else if currentShellInfo != nil {
-				currentShellIdentifier = currentShellInfo.Current // Or currentShellInfo.Path, depending on what NewShellSelectionScreen expects
-			}
-		} else {
-			// shellManager not initialized, error already set above
+		currentShellInfo, err := m.shellManager.DetectCurrent()
+		if err != nil {
+			m.err = fmt.Errorf("failed to detect current shell: %w", err)
+			// Proceeding without a pre-selected current shell
+		} else if currentShellInfo != nil {
+			currentShellIdentifier = currentShellInfo.Current // Or currentShellInfo.Path, depending on what NewShellSelectionScreen expects
 		}
 		
 		preselectedName := ""
@@ -363,13 +342,13 @@ func (m *Model) transitionTo(targetScreen Screen) tea.Cmd {
 	case EssentialToolScreen: 
 		tools, err := m.config.LoadTools()
 		if err != nil { m.err = err; newScreen = screens.NewWelcomeScreen(); break }
-		essentialTools := filterToolsByCategory(tools, "essential")
+		essentialTools := filterToolsByCategory(filterToolsForOS(tools, runtime.GOOS), "essential")
 		preselectedEssential := filterToolsByCategory(m.selectedTools, "essential")
 		newScreen = screens.NewEssentialToolScreen("", essentialTools, preselectedEssential)
 	case ModernToolScreen:
 		tools, err := m.config.LoadTools()
 		if err != nil { m.err = err; newScreen = screens.NewWelcomeScreen(); break }
-		modernTools := filterToolsByCategory(tools, "modern")
+		modernTools := filterToolsByCategory(filterToolsForOS(tools, runtime.GOOS), "modern")
 		preselectedModern := filterToolsByCategory(m.selectedTools, "modern")
 		newScreen = screens.NewModernToolScreen("", modernTools, preselectedModern)
 	case FontScreen:
@@ -382,33 +361,23 @@ func (m *Model) transitionTo(targetScreen Screen) tea.Cmd {
 		newScreen = screens.NewLanguageScreen("", langs, m.selectedLanguages)
 	case DotfilesScreen: newScreen = screens.NewDotfilesScreen()
 	case InstallationScreen:
+		if m.installOutsideUI {
+			// Selections are complete; hand the terminal back for a verbose install
+			return tea.Quit
+		}
 		fmt.Println("Transitioning to Installation Screen...") // Use fmt for now
 
 		// --- Prepare for Installation --- 
-		// 1. Gather all selections (ensure this is done before this transition)
-		selectedPipelineTools := m.SelectedTools()
-		// TODO: Gather fonts, languages, shell, dotfiles
-		selectedToolNames := make([]string, len(selectedPipelineTools))
-		for i, tool := range selectedPipelineTools {
-			selectedToolNames[i] = tool.Name
-		}
-
-		// 2. Load full pipeline tool definitions (PLACEHOLDER - Needs real implementation)
-		selectedPipelineTools = make([]*pipeline.Tool, 0)
-		fmt.Println("TODO: Placeholder: Using empty tool list for pipeline installation start.")
-		// TODO: Implement loading based on selectedToolNames
-
-		// 3. Prepare Platform and PackageManager for Installer
+		// 1. Prepare Platform and PackageManager for Installer
 		sysInfo, err := system.Detect()
 		if err != nil {
 			m.err = fmt.Errorf("failed to detect system info for install: %w", err)
 			newScreen = screens.NewWelcomeScreen()
 			break
 		}
-		pkgManagerFactory := factory.NewPackageManagerFactory()
-		pkgManagerImpl, err := pkgManagerFactory.GetPackageManager() // base_iface.PackageManager
+		pkgManagerImpl, err := pipeline.DetectPackageManager() // base_iface.PackageManager
 		if err != nil {
-			m.err = fmt.Errorf("failed to detect package manager for install: %w", err)
+			m.err = err
 			newScreen = screens.NewWelcomeScreen()
 			break
 		}
@@ -417,25 +386,29 @@ func (m *Model) transitionTo(targetScreen Screen) tea.Cmd {
 		var pipelinePackageManager pipeline.PackageManager = &packageManagerAdapter{impl: pkgManagerImpl}
 		fmt.Println("TODO: Verify and complete PackageManager adapter implementation for pipeline.")
 
-		pipelinePlatform := &pipeline.Platform{
-			OS:             sysInfo.OS,
-			Arch:           sysInfo.Arch,
-			PackageManager: sysInfo.PackageType, // TODO: Use GetName from adapter?
-			Shell:          sysInfo.Shell,
-		}
+		pipelinePlatform := pipeline.PlatformFor(sysInfo, pkgManagerImpl.GetName())
+
+		// 2. Pre-flight: gather the selected tools, dropping those with no install
+		// method on this platform, which the installation screen lists as skipped
+		selectedPipelineTools, preflightIssues := pipeline.Preflight(m.SelectedTools(), pipelinePlatform)
 
-		// 4. Create Installer
+		// 3. Create Installer
 		installer, err := pipeline.NewInstaller(pipelinePlatform, pipelinePackageManager)
 		if err != nil {
 			m.err = fmt.Errorf("failed to create installer: %w", err)
 			newScreen = screens.NewWelcomeScreen() 
 			break
 		}
+		installer.Context.LanguageStrategy = sysInfo.DefaultLanguageStrategy()
+		installer.Context.Shells = m.SelectedShells()
+		m.installer = installer
 
-		// 5. Create the Installation Screen, passing the READ end of the progress channel
-		newScreen = screens.NewInstallationScreen(installer.ProgressChan)
+		// 4. Create the Installation Screen, passing the READ end of the progress channel
+		installScreen := screens.NewInstallationScreen(installer.ProgressChan)
+		installScreen.SetSkipped(preflightIssues)
+		newScreen = installScreen
 
-		// 6. Create command to run the installation in the background
+		// 5. Create command to run the installation in the background
 		installCmd := func() tea.Msg {
 			fmt.Println("Starting background installation process...")
 			// Pass all the collected selections to the installer
@@ -505,6 +478,17 @@ func filterToolsByCategory(tools []*pipeline.Tool, category string) []*pipeline.
 	return filtered
 }
 
+// filterToolsForOS drops tools that are not offered on goos
+func filterToolsForOS(tools []*pipeline.Tool, goos string) []*pipeline.Tool {
+	filtered := make([]*pipeline.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if ok, _ := tool.SupportsOS(goos); ok {
+			filtered = append(filtered, tool)
+		}
+	}
+	return filtered
+}
+
 // View method - Removing debug prints
 func (m *Model) View() string {
 	if !m.screenReady {
@@ -569,6 +553,34 @@ func (m *Model) View() string {
 	return styles.AppStyle.Render(finalView.String())
 }
 
+// availableShells returns the configured shells that are present on the system
+func (m *Model) availableShells() ([]*interfaces.Shell, error) {
+	definedShells, err := m.config.LoadShells()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load shell definitions: %w", err)
+	}
+	if m.shellManager == nil {
+		return nil, fmt.Errorf("shell manager not initialized")
+	}
+	systemShellsInfo, err := m.shellManager.ListAvailable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list available system shells: %w", err)
+	}
+
+	systemShellMap := make(map[string]bool)
+	for _, sysShell := range systemShellsInfo {
+		systemShellMap[sysShell.Type] = true    // Assuming Type is like "bash", "zsh"
+		systemShellMap[sysShell.Current] = true // sysShell.Current might be the name or path
+	}
+	available := make([]*interfaces.Shell, 0)
+	for _, defShell := range definedShells {
+		if systemShellMap[defShell.Name] {
+			available = append(available, defShell)
+		}
+	}
+	return available, nil
+}
+
 // SelectedTools returns the selected tools
 func (m *Model) SelectedTools() []*pipeline.Tool {
 	return m.selectedTools
@@ -589,6 +601,37 @@ func (m *Model) GetSelectedShell() *interfaces.Shell {
 	return m.selectedShell
 }
 
+// SetShells sets the shells tool completions and the prompt are written for
+// besides the selected shell, e.g. settings.yaml's shells
+func (m *Model) SetShells(shells []string) {
+	m.shells = shells
+}
+
+// SelectedShells returns the shells to configure: the selected shell first,
+// then the ones SetShells added
+func (m *Model) SelectedShells() []string {
+	primary := ""
+	if m.selectedShell != nil {
+		primary = m.selectedShell.Name
+	}
+	return shell.WithPrimary(primary, m.shells)
+}
+
+// StopInstall cancels the installation screen's background install, if any,
+// and waits for the commands it was running to exit
+func (m *Model) StopInstall() {
+	if m.installer != nil {
+		m.installer.Cancel()
+		m.installer.Wait()
+	}
+}
+
+// SetInstallOutsideUI makes the TUI exit once selections are made instead of
+// showing the installation screen, so installation output can go to the terminal
+func (m *Model) SetInstallOutsideUI(outside bool) {
+	m.installOutsideUI = outside
+}
+
 // GetManageDotfiles returns whether dotfiles should be managed.
 func (m *Model) GetManageDotfiles() bool {
 	return m.ManageDotfiles
@@ -615,4 +658,5 @@ func (a *packageManagerAdapter) SetupSpecialPackage(pkg string) error {
 }
 func (a *packageManagerAdapter) IsPackageAvailable(pkg string) bool { 
 	return a.impl.IsPackageAvailable(pkg) 
-} 
\ No newline at end of file
+}
+func (a *packageManagerAdapter) GetVersion(pkg string) (string, error) { return a.impl.GetVersion(pkg) } 
@@ -0,0 +1,252 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+)
+
+// dumbTerms cannot position the cursor, so the full-screen UI renders garbage in them
+var dumbTerms = map[string]bool{"": true, "dumb": true, "unknown": true, "emacs": true}
+
+// FullScreenSupported reports whether out is a terminal that can run the
+// full-screen UI. When it cannot (not a TTY, TERM dumb or unset, or a TERM
+// with no terminfo entry) the reason is returned for the plain-prompt fallback.
+func FullScreenSupported(getenv func(string) string, out *os.File) (bool, string) {
+	if info, err := out.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false, "output is not a terminal"
+	}
+	term := getenv("TERM")
+	if dumbTerms[strings.ToLower(term)] {
+		if term == "" {
+			return false, "TERM is not set"
+		}
+		return false, fmt.Sprintf("TERM=%s does not support full-screen output", term)
+	}
+	if !terminfoKnown(term, getenv) {
+		return false, fmt.Sprintf("no terminfo entry for TERM=%s", term)
+	}
+	return true, ""
+}
+
+// terminfoKnown reports whether term has a terminfo entry. Systems without any
+// terminfo database (minimal containers) are given the benefit of the doubt.
+func terminfoKnown(term string, getenv func(string) string) bool {
+	var dirs []string
+	if dir := getenv("TERMINFO"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	if home := getenv("HOME"); home != "" {
+		dirs = append(dirs, filepath.Join(home, ".terminfo"))
+	}
+	for _, dir := range filepath.SplitList(getenv("TERMINFO_DIRS")) {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	dirs = append(dirs, "/etc/terminfo", "/lib/terminfo", "/usr/share/terminfo", "/usr/lib/terminfo")
+
+	foundDatabase := false
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+		foundDatabase = true
+		// Entries live under their first letter, or its hex code on macOS
+		for _, sub := range []string{term[:1], fmt.Sprintf("%x", term[0])} {
+			if _, err := os.Stat(filepath.Join(dir, sub, term)); err == nil {
+				return true
+			}
+		}
+	}
+	return !foundDatabase
+}
+
+// RunPlain collects the same selections as the full-screen UI with numbered,
+// line-based prompts, for terminals that cannot run it. End of input skips the
+// remaining prompts.
+func (m *Model) RunPlain(in io.Reader, out io.Writer) error {
+	r := bufio.NewReader(in)
+
+	shells, err := m.availableShells()
+	if err != nil {
+		return err
+	}
+	shellNames := make([]string, len(shells))
+	for i, sh := range shells {
+		shellNames[i] = describe(sh.Name, sh.Description)
+	}
+	picked, err := promptChoices(r, out, "Primary shell (one number, empty keeps the current shell)", shellNames, false)
+	if err != nil {
+		return err
+	}
+	if len(picked) > 0 {
+		m.selectedShell = shells[picked[0]]
+	}
+
+	tools, err := m.config.LoadTools()
+	if err != nil {
+		return err
+	}
+	tools = filterToolsForOS(tools, runtime.GOOS)
+	m.selectedTools = nil
+	for _, category := range []struct{ key, title string }{{"essential", "Essential tools"}, {"modern", "Modern tools"}} {
+		candidates := filterToolsByCategory(tools, category.key)
+		names := make([]string, len(candidates))
+		for i, tool := range candidates {
+			names[i] = describe(tool.Label(), tool.Description)
+		}
+		picked, err := promptChoices(r, out, category.title, names, true)
+		if err != nil {
+			return err
+		}
+		for _, i := range picked {
+			m.selectedTools = append(m.selectedTools, candidates[i])
+		}
+	}
+
+	fonts, err := m.config.LoadFonts()
+	if err != nil {
+		return err
+	}
+	fontNames := make([]string, len(fonts))
+	for i, font := range fonts {
+		fontNames[i] = describe(font.Name, font.Description)
+	}
+	if picked, err = promptChoices(r, out, "Fonts", fontNames, true); err != nil {
+		return err
+	}
+	m.selectedFonts = nil
+	for _, i := range picked {
+		m.selectedFonts = append(m.selectedFonts, fonts[i])
+	}
+
+	langs, err := m.config.LoadLanguages()
+	if err != nil {
+		return err
+	}
+	langNames := make([]string, len(langs))
+	for i, lang := range langs {
+		langNames[i] = describe(lang.Name, lang.Description)
+	}
+	if picked, err = promptChoices(r, out, "Languages", langNames, true); err != nil {
+		return err
+	}
+	m.selectedLanguages = nil
+	for _, i := range picked {
+		m.selectedLanguages = append(m.selectedLanguages, langs[i])
+	}
+	// Each manager is offered the languages no earlier one was picked for
+	for _, manager := range []string{interfaces.LanguageInstallerAsdf, interfaces.LanguageInstallerMise} {
+		var offered []*interfaces.Language
+		var names []string
+		for _, lang := range m.selectedLanguages {
+			if lang.RuntimeManager() != "" {
+				continue
+			}
+			name := lang.Name
+			if lang.Installer != "" {
+				name = fmt.Sprintf("%s (instead of %s)", lang.Name, lang.Installer)
+			}
+			offered, names = append(offered, lang), append(names, name)
+		}
+		if len(offered) == 0 {
+			break
+		}
+		if picked, err = promptChoices(r, out, fmt.Sprintf("Install with %s (empty for none)", manager), names, true); err != nil {
+			return err
+		}
+		for _, i := range picked {
+			offered[i].Installer = manager
+		}
+	}
+
+	fmt.Fprint(out, "\nDotfiles repository (git URL, user/repo or local path; empty to skip): ")
+	url, err := readLine(r)
+	if err != nil {
+		return err
+	}
+	m.ManageDotfiles, m.DotfilesRepoURL = url != "", url
+	return nil
+}
+
+// promptChoices lists options and reads the numbers picked, returned as
+// zero-based indexes. With multi, "all" and several numbers are accepted.
+// Invalid input is reported and asked again; empty input picks nothing.
+func promptChoices(r *bufio.Reader, out io.Writer, title string, options []string, multi bool) ([]int, error) {
+	if len(options) == 0 {
+		return nil, nil
+	}
+	fmt.Fprintf(out, "\n%s:\n", title)
+	for i, option := range options {
+		fmt.Fprintf(out, "  %d) %s\n", i+1, option)
+	}
+	hint := "number"
+	if multi {
+		hint = "numbers separated by spaces or commas, or \"all\""
+	}
+	for {
+		fmt.Fprintf(out, "Enter %s: ", hint)
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		picked, err := parseChoices(line, len(options), multi)
+		if err == nil {
+			return picked, nil
+		}
+		fmt.Fprintf(out, "%v\n", err)
+	}
+}
+
+// parseChoices turns input like "1, 3 4" into zero-based indexes below n
+func parseChoices(line string, n int, multi bool) ([]int, error) {
+	if multi && strings.EqualFold(line, "all") {
+		all := make([]int, n)
+		for i := range all {
+			all[i] = i
+		}
+		return all, nil
+	}
+	fields := strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' })
+	if !multi && len(fields) > 1 {
+		return nil, fmt.Errorf("pick a single number")
+	}
+	seen := make(map[int]bool)
+	var picked []int
+	for _, field := range fields {
+		num, err := strconv.Atoi(field)
+		if err != nil || num < 1 || num > n {
+			return nil, fmt.Errorf("%q is not a number between 1 and %d", field, n)
+		}
+		if !seen[num-1] {
+			seen[num-1] = true
+			picked = append(picked, num-1)
+		}
+	}
+	return picked, nil
+}
+
+// readLine reads one trimmed line; end of input reads as an empty line
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// describe renders "name - description" for a prompt option
+func describe(name, description string) string {
+	if description == "" {
+		return name
+	}
+	return fmt.Sprintf("%s - %s", name, description)
+}
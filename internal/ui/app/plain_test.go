@@ -0,0 +1,103 @@
+package app
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func envFrom(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+func TestFullScreenSupported(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	if ok, reason := FullScreenSupported(envFrom(map[string]string{"TERM": "xterm"}), w); ok || !strings.Contains(reason, "not a terminal") {
+		t.Errorf("FullScreenSupported(pipe) = %v, %q; want not a terminal", ok, reason)
+	}
+
+	// /dev/null is a character device, so only TERM decides
+	tty, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skip("no null device")
+	}
+	defer tty.Close()
+
+	terminfo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(terminfo, "x"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(terminfo, "x", "xterm-256color"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		term string
+		want bool
+	}{
+		{"", false},
+		{"dumb", false},
+		{"emacs", false},
+		{"xterm-256color", true},
+		{"made-up-term", false},
+	}
+	for _, tt := range tests {
+		env := envFrom(map[string]string{"TERM": tt.term, "TERMINFO_DIRS": terminfo})
+		ok, reason := FullScreenSupported(env, tty)
+		if ok != tt.want {
+			t.Errorf("FullScreenSupported(TERM=%q) = %v (%s), want %v", tt.term, ok, reason, tt.want)
+		}
+		if !ok && reason == "" {
+			t.Errorf("FullScreenSupported(TERM=%q) gave no reason", tt.term)
+		}
+	}
+}
+
+func TestParseChoices(t *testing.T) {
+	tests := []struct {
+		line    string
+		multi   bool
+		want    []int
+		wantErr bool
+	}{
+		{"", true, nil, false},
+		{"1, 3 3", true, []int{0, 2}, false},
+		{"all", true, []int{0, 1, 2}, false},
+		{"2", false, []int{1}, false},
+		{"1 2", false, nil, true},
+		{"4", true, nil, true},
+		{"zsh", true, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseChoices(tt.line, 3, tt.multi)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseChoices(%q) error = %v, wantErr %v", tt.line, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseChoices(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestPromptChoices_RetriesInvalidInput(t *testing.T) {
+	var out strings.Builder
+	got, err := promptChoices(bufio.NewReader(strings.NewReader("9\n2\n")), &out, "Fonts", []string{"a", "b"}, true)
+	if err != nil {
+		t.Fatalf("promptChoices() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("promptChoices() = %v, want [1]", got)
+	}
+	if !strings.Contains(out.String(), "not a number between 1 and 2") {
+		t.Errorf("Expected the invalid choice to be reported, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,208 @@
+package components
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/ui/styles"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+)
+
+// NotificationKind selects a notification's icon and color
+type NotificationKind int
+
+const (
+	NotifyInfo NotificationKind = iota
+	NotifySuccess
+	NotifyWarning
+	NotifyError
+)
+
+const (
+	// defaultNotificationWidth is used when the terminal width is unknown
+	defaultNotificationWidth = 80
+	// maxNotificationWidth keeps boxes readable on very wide terminals
+	maxNotificationWidth = 120
+	// MinNotificationWidth is the narrowest box; below it notifications are
+	// printed as a single compact line
+	MinNotificationWidth = 40
+)
+
+// NotificationManager prints boxed notifications sized to the terminal
+type NotificationManager struct {
+	out   io.Writer
+	width int // SetWidth override; 0 follows the terminal
+	// termWidth reports the terminal width, or 0 when out is not a terminal
+	termWidth func() int
+}
+
+// NewNotificationManager creates a notification manager writing to out
+func NewNotificationManager(out io.Writer) *NotificationManager {
+	return &NotificationManager{out: out, termWidth: func() int { return terminalWidth(out) }}
+}
+
+// SetWidth overrides the box width; it is still clamped to the terminal. Zero
+// goes back to following the terminal width.
+func (n *NotificationManager) SetWidth(width int) {
+	n.width = width
+}
+
+// Width returns the box width for the next notification. The terminal is
+// measured each time, so resizes between notifications are picked up.
+func (n *NotificationManager) Width() int {
+	termWidth := n.termWidth()
+	width := n.width
+	if width <= 0 {
+		width = defaultNotificationWidth
+		if termWidth > 0 {
+			width = min(termWidth, maxNotificationWidth)
+		}
+	}
+	if termWidth > 0 && width > termWidth {
+		width = termWidth
+	}
+	return width
+}
+
+// Show prints a notification
+func (n *NotificationManager) Show(kind NotificationKind, title, message string) {
+	fmt.Fprintln(n.out, n.Render(kind, title, message))
+}
+
+// Render returns a notification as a box, or as a single line when the width is
+// below MinNotificationWidth
+func (n *NotificationManager) Render(kind NotificationKind, title, message string) string {
+	width := n.Width()
+	if width < MinNotificationWidth {
+		return compactNotification(kind, title, message)
+	}
+	return createNotificationBox(kind, title, message, width)
+}
+
+// NotificationAction is a choice offered with a notification
+type NotificationAction struct {
+	// Key is what the user types to choose the action, e.g. "r"
+	Key   string
+	Label string
+	// Run carries out the action; nil only dismisses the notification
+	Run func() error
+	// KeepOpen offers the actions again after Run, e.g. after showing a log
+	KeepOpen bool
+}
+
+// Prompt shows a notification with actions, reads the choice from in and runs
+// it. An empty answer or end of input picks the last action, which should be
+// the one that dismisses. The chosen action's error is returned.
+func (n *NotificationManager) Prompt(kind NotificationKind, title, message string, actions []NotificationAction, in io.Reader) error {
+	if len(actions) == 0 {
+		n.Show(kind, title, message)
+		return nil
+	}
+	choices := make([]string, len(actions))
+	for i, a := range actions {
+		choices[i] = fmt.Sprintf("[%s] %s", a.Key, a.Label)
+	}
+	reader := bufio.NewReader(in)
+	for {
+		body := strings.Join(choices, "  ")
+		if message != "" {
+			body = message + "\n\n" + body
+		}
+		n.Show(kind, title, body)
+		fmt.Fprint(n.out, "> ")
+
+		answer, err := reader.ReadString('\n')
+		action := matchAction(actions, strings.TrimSpace(answer))
+		if action == nil {
+			if err != nil {
+				action = &actions[len(actions)-1]
+			} else {
+				fmt.Fprintf(n.out, "Unknown choice %q\n", strings.TrimSpace(answer))
+				continue
+			}
+		}
+		if action.Run == nil {
+			return nil
+		}
+		if runErr := action.Run(); runErr != nil || !action.KeepOpen || err != nil {
+			return runErr
+		}
+	}
+}
+
+// matchAction returns the action whose key or label is answer; an empty answer
+// is the last action
+func matchAction(actions []NotificationAction, answer string) *NotificationAction {
+	if len(actions) == 0 {
+		return nil
+	}
+	if answer == "" {
+		return &actions[len(actions)-1]
+	}
+	for i, a := range actions {
+		if strings.EqualFold(answer, a.Key) || strings.EqualFold(answer, a.Label) {
+			return &actions[i]
+		}
+	}
+	return nil
+}
+
+// createNotificationBox renders a rounded box exactly width columns wide, wrapping
+// the message to fit
+func createNotificationBox(kind NotificationKind, title, message string, width int) string {
+	icon, color := notificationStyle(kind)
+	heading := lipgloss.NewStyle().Bold(true).Foreground(color).Render(icon + " " + title)
+	// lipgloss widths include padding but not the border
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(color).
+		Padding(0, 1).
+		Width(width - 2)
+	if message == "" {
+		return box.Render(heading)
+	}
+	return box.Render(heading + "\n" + message)
+}
+
+// compactNotification renders a notification on one line for narrow terminals
+func compactNotification(kind NotificationKind, title, message string) string {
+	icon, color := notificationStyle(kind)
+	line := icon + " " + title
+	if message != "" {
+		line += ": " + strings.Join(strings.Fields(message), " ")
+	}
+	return lipgloss.NewStyle().Foreground(color).Render(line)
+}
+
+// notificationStyle returns the icon and color for a kind
+func notificationStyle(kind NotificationKind) (string, lipgloss.TerminalColor) {
+	switch kind {
+	case NotifySuccess:
+		return "✓", styles.ColorSuccess
+	case NotifyWarning:
+		return "!", styles.ColorWarning
+	case NotifyError:
+		return "✗", styles.ColorError
+	default:
+		return "i", styles.ColorAccentAlt
+	}
+}
+
+// terminalWidth returns the width of the terminal out writes to, falling back
+// to $COLUMNS, or 0 when neither is known
+func terminalWidth(out io.Writer) int {
+	if f, ok := out.(*os.File); ok && term.IsTerminal(f.Fd()) {
+		if width, _, err := term.GetSize(f.Fd()); err == nil && width > 0 {
+			return width
+		}
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return 0
+}
@@ -0,0 +1,90 @@
+package components
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// fixedWidth returns a manager that writes nowhere and sees a terminal of width columns
+func fixedWidth(width int) *NotificationManager {
+	n := NewNotificationManager(nil)
+	n.termWidth = func() int { return width }
+	return n
+}
+
+func TestNotificationManager_Width(t *testing.T) {
+	tests := []struct {
+		name     string
+		terminal int
+		override int
+		want     int
+	}{
+		{"unknown terminal", 0, 0, defaultNotificationWidth},
+		{"narrow terminal", 60, 0, 60},
+		{"wide terminal", 200, 0, maxNotificationWidth},
+		{"override", 200, 90, 90},
+		{"override clamped to terminal", 50, 90, 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := fixedWidth(tt.terminal)
+			n.SetWidth(tt.override)
+			if got := n.Width(); got != tt.want {
+				t.Errorf("Width() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotificationManager_Render(t *testing.T) {
+	message := "Run `exec $SHELL` or open a new terminal so the new PATH and settings take effect."
+
+	box := fixedWidth(50).Render(NotifyInfo, "Open a new shell", message)
+	for _, line := range strings.Split(box, "\n") {
+		if w := lipgloss.Width(line); w != 50 {
+			t.Errorf("Expected every box line to be 50 columns, got %d: %q", w, line)
+		}
+	}
+
+	compact := fixedWidth(30).Render(NotifyWarning, "Open a new shell", message)
+	if strings.Contains(compact, "\n") || !strings.Contains(compact, "Open a new shell: Run") {
+		t.Errorf("Expected a single compact line below the minimum width, got %q", compact)
+	}
+}
+
+func TestNotificationManager_Prompt(t *testing.T) {
+	var out strings.Builder
+	n := NewNotificationManager(&out)
+	n.termWidth = func() int { return 80 }
+
+	var ran []string
+	actions := []NotificationAction{
+		{Key: "r", Label: "Retry failed", Run: func() error { ran = append(ran, "retry"); return nil }},
+		{Key: "l", Label: "Show log", KeepOpen: true, Run: func() error { ran = append(ran, "log"); return nil }},
+		{Key: "i", Label: "Ignore"},
+	}
+
+	// The log keeps the notification open; an unknown answer asks again
+	if err := n.Prompt(NotifyError, "Installation failed", "Failed: bat", actions, strings.NewReader("l\nx\nR\n")); err != nil {
+		t.Fatalf("Prompt() error = %v", err)
+	}
+	if strings.Join(ran, ",") != "log,retry" {
+		t.Errorf("Expected the log then a retry, ran %v", ran)
+	}
+	if !strings.Contains(out.String(), "[r] Retry failed  [l] Show log  [i] Ignore") || !strings.Contains(out.String(), `Unknown choice "x"`) {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+
+	// Enter or end of input picks the last action
+	ran = nil
+	for _, input := range []string{"\n", "", "l"} {
+		if err := n.Prompt(NotifyError, "Installation failed", "", actions, strings.NewReader(input)); err != nil {
+			t.Fatalf("Prompt(%q) error = %v", input, err)
+		}
+	}
+	if strings.Join(ran, ",") != "log" {
+		t.Errorf("Expected only the log from an unterminated answer, ran %v", ran)
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	// "github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/ui/styles" // Import our styles
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -288,6 +289,35 @@ func (s *BaseSelector) SetItems(items []interface{}, titleFn func(interface{}) s
 	s.list.SetItems(listItems)
 }
 
+// Focused returns the data item under the cursor, or nil
+func (s *BaseSelector) Focused() interface{} {
+	if item, ok := s.list.SelectedItem().(*SelectorItem); ok {
+		return item.item
+	}
+	return nil
+}
+
+// Filtering reports whether the filter input has the keyboard
+func (s *BaseSelector) Filtering() bool {
+	return s.list.FilterState() == list.Filtering
+}
+
+// SetDescription replaces the description shown for dataItem
+func (s *BaseSelector) SetDescription(dataItem interface{}, description string) {
+	for i, listItem := range s.list.Items() {
+		if si, ok := listItem.(*SelectorItem); ok && si.item == dataItem {
+			si.description = description
+			_ = s.list.SetItem(i, si)
+		}
+	}
+}
+
+// AddHelpKey lists an extra key the parent screen handles in the help line
+func (s *BaseSelector) AddHelpKey(keys, help string) {
+	binding := key.NewBinding(key.WithKeys(keys), key.WithHelp(keys, help))
+	s.list.AdditionalShortHelpKeys = func() []key.Binding { return []key.Binding{binding} }
+}
+
 // SetSize sets the width and height of the selector - usually called on tea.WindowSizeMsg
 func (s *BaseSelector) SetSize(width, height int) {
 	s.list.SetSize(width, height)
@@ -101,7 +101,7 @@ func (m Model) View() string {
         MarginRight(1) // Space between blocks
 
 	completedStyle := baseStepStyle.Copy().
-		Foreground(styles.NordAuroraGreen). // Green text for completed
+		Foreground(styles.ColorSuccess). // Green text for completed
         Faint(true) // Make completed steps less prominent
 
 	currentStyle := baseStepStyle.Copy().
@@ -110,7 +110,7 @@ func (m Model) View() string {
 		Bold(true)
 
 	pendingStyle := baseStepStyle.Copy().
-        Foreground(styles.NordPolarNight4) // Dark gray for pending
+        Foreground(styles.ColorMutedText) // Dark gray for pending
 
 	errorStyle := baseStepStyle.Copy().
         Foreground(styles.ColorBrightText).
@@ -2,12 +2,42 @@
 package ui
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"os"
+	"strings"
 
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/ui/components"
 )
 
+// StdinIsTerminal reports whether stdin is a terminal that can answer prompts
+func StdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Confirm asks a yes/no question on out and reads the answer from in,
+// returning def for an empty or unrecognised answer. Pass the same
+// *bufio.Reader for a series of questions so no input is lost between them.
+func Confirm(in io.Reader, out io.Writer, question string, def bool) bool {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	fmt.Fprintf(out, "%s [%s] ", question, hint)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	case "n", "no":
+		return false
+	default:
+		return def
+	}
+}
+
 // PromptDotfiles prompts for GitHub dotfiles URL
 func PromptDotfiles() (string, error) {
 	prompt := components.NewBasicPrompt("Clone dotfiles from GitHub?", []string{"Yes", "No"})
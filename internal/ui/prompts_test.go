@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		answer string
+		def    bool
+		want   bool
+	}{
+		{"y\n", false, true},
+		{"YES\n", false, true},
+		{"n\n", true, false},
+		{"\n", true, true},
+		{"", false, false},
+		{"maybe\n", false, false},
+	}
+	for _, tt := range tests {
+		if got := Confirm(strings.NewReader(tt.answer), io.Discard, "Continue?", tt.def); got != tt.want {
+			t.Errorf("Confirm(%q, default %v) = %v, want %v", tt.answer, tt.def, got, tt.want)
+		}
+	}
+
+	// A shared reader answers a series of questions in order
+	r := bufio.NewReader(strings.NewReader("y\nn\n"))
+	var out strings.Builder
+	if !Confirm(r, &out, "First?", false) || Confirm(r, &out, "Second?", true) {
+		t.Error("Expected the answers to be read one line per question")
+	}
+	if out.String() != "First? [y/N] Second? [Y/n] " {
+		t.Errorf("Unexpected prompts %q", out.String())
+	}
+}
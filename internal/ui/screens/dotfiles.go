@@ -87,7 +87,7 @@ func (s *DotfilesScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				} else {
 					// Basic validation (contains /)
 					if !strings.Contains(url, "/") {
-						s.err = fmt.Errorf("invalid format, use user/repo")
+						s.err = fmt.Errorf("invalid format, use a git URL, user/repo or a path")
 					} else {
 						s.repoURL = url
 						s.finished = true
@@ -117,10 +117,10 @@ func (s *DotfilesScreen) View() string {
 
 	switch s.state {
 	case dsAskManage:
-		body := styles.NormalTextStyle.Render("Manage dotfiles by cloning a GitHub repository? (y/n)")
+		body := styles.NormalTextStyle.Render("Manage dotfiles by cloning a repository and linking it into your home directory? (y/n)")
 		content.WriteString(body)
 	case dsAskURL:
-		body := styles.NormalTextStyle.Render("Enter GitHub repository URL (e.g., username/repo):")
+		body := styles.NormalTextStyle.Render("Enter a git URL, GitHub username/repo or local path:")
 		content.WriteString(body)
 		content.WriteString("\n\n")
 		content.WriteString(s.textInput.View())
@@ -35,8 +35,18 @@ type TaskState struct {
 	Error       error
 	StartTime   time.Time
 	EndTime     time.Time
+	Group       string // Summary group (tool category, Fonts, Languages...)
+	Item        string // Tool or other selection the task belongs to
+	Attempt     int    // Attempt in progress while retrying
+	Attempts    int    // Attempts allowed while retrying
+	Version     string // Version the item reported once verified
+	Output      string // Command output captured when the task failed
+	LogPath     string // Failure log holding Output, if written
 }
 
+// outputLines is how many of a failed item's last output lines are shown
+const outputLines = 20
+
 // --- Messages for internal screen updates ---
 
 // progressMsg wraps a ProgressEvent coming from the pipeline channel
@@ -66,8 +76,10 @@ type InstallationScreen struct {
 	tasks      []*TaskState       
 	taskMap    map[string]*TaskState 
 	progresses map[string]*progress.Model // Store pointers to progress models
-	activeTaskCount int
+	inFlight   map[string]bool // IDs of tasks that have started but not ended, in any order
 	logMessages []string // Simple log for now
+	skipped     []pipeline.PreflightIssue // Selections dropped by the pre-flight check
+	expanded    map[string]bool // Failed items whose output is shown in the summary
 	// TODO: Add more structured state later (e.g., map[taskID]taskState for progress bars)
 }
 
@@ -82,10 +94,17 @@ func NewInstallationScreen(progChan <-chan pipeline.ProgressEvent) *Installation
 		taskMap:      make(map[string]*TaskState),
 		tasks:        make([]*TaskState, 0),
 		progresses: make(map[string]*progress.Model), // Initialize map for pointers
+		inFlight:   make(map[string]bool),
+		expanded:   make(map[string]bool),
 		spinner:    sp,
 	}
 }
 
+// SetSkipped records selections that the pre-flight check found impossible to install
+func (s *InstallationScreen) SetSkipped(issues []pipeline.PreflightIssue) {
+	s.skipped = issues
+}
+
 // --- Bubble Tea Interface --- 
 
 func (s *InstallationScreen) Init() tea.Cmd {
@@ -118,12 +137,34 @@ func (s *InstallationScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// TODO: Implement cancellation signal to pipeline?
 			// For now, don't quit if not finished.
 			return s, nil 
+		case "e":
+			// Show or hide the output of every failed item
+			if s.finished {
+				failed := s.failedItems()
+				show := false
+				for _, item := range failed {
+					show = show || !s.expanded[item]
+				}
+				for _, item := range failed {
+					s.expanded[item] = show
+				}
+			}
+			return s, nil
+		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+			// Show or hide the output of the failed item with that number
+			if !s.finished {
+				return s, nil
+			}
+			if failed, n := s.failedItems(), int(msg.String()[0]-'1'); n < len(failed) {
+				s.expanded[failed[n]] = !s.expanded[failed[n]]
+			}
+			return s, nil
 		}
 
 	// Handle spinner tick if installation is ongoing
 	case spinner.TickMsg:
 		var cmd tea.Cmd
-		if !s.finished && s.activeTaskCount > 0 {
+		if !s.finished && len(s.inFlight) > 0 {
 			s.spinner, cmd = s.spinner.Update(msg)
 			cmds = append(cmds, cmd)
 		}
@@ -154,6 +195,14 @@ func (s *InstallationScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		
 		switch event := msg.event.(type) {
 		case pipeline.TaskStart:
+			// A restarted task (e.g. a retry) reuses its existing entry
+			if task, ok := s.taskMap[event.TaskID]; ok {
+				task.Status = StatusRunning
+				task.Error = nil
+				task.StartTime = time.Now()
+				s.inFlight[event.TaskID] = true
+				break
+			}
 			// Add new task to state
 			newTask := &TaskState{
 				ID:          event.TaskID,
@@ -161,10 +210,12 @@ func (s *InstallationScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				Status:      StatusRunning,
 				StartTime:   time.Now(),
 				Progress:    -1, // Indeterminate initially
+				Group:       event.Group,
+				Item:        event.Item,
 			}
 			s.tasks = append(s.tasks, newTask)
 			s.taskMap[event.TaskID] = newTask
-			s.activeTaskCount++
+			s.inFlight[event.TaskID] = true
 			// Potentially create a progress bar if needed later
 
 		case pipeline.TaskProgress:
@@ -178,7 +229,7 @@ func (s *InstallationScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if !pOk {
 					progWidth := s.width - 10 
 					if progWidth < 10 { progWidth = 10 }
-					newProgress := progress.New(progress.WithDefaultGradient())
+					newProgress := progress.New(styles.ProgressOption())
 					newProgress.Width = progWidth
 					s.progresses[event.TaskID] = &newProgress // Store pointer
 					p = &newProgress // Use the new pointer
@@ -188,6 +239,13 @@ func (s *InstallationScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				cmdsToBatch = append(cmdsToBatch, p.SetPercent(task.Progress))
 			}
 
+		case pipeline.TaskRetry:
+			if task, ok := s.taskMap[event.TaskID]; ok {
+				task.Status = StatusRetrying
+				task.Attempt, task.Attempts = event.Attempt, event.Attempts
+				task.Error = event.Error
+			}
+
 		case pipeline.TaskLog:
 			// Simple log for now - append to a shared log or task-specific?
 			// Append to general log for now
@@ -197,8 +255,10 @@ func (s *InstallationScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if task, ok := s.taskMap[event.TaskID]; ok {
 				task.EndTime = time.Now()
 				task.Error = event.Error
+				task.Output, task.LogPath = event.Output, event.LogPath
 				if event.Success {
 					task.Status = StatusDone
+					task.Version = event.Version
 					task.Progress = 1.0 // Ensure progress bar is full on success
 					if p, pOk := s.progresses[event.TaskID]; pOk {
 						cmdsToBatch = append(cmdsToBatch, p.SetPercent(1.0))
@@ -211,15 +271,14 @@ func (s *InstallationScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						task.Status = StatusFailed
 					}
 				}
-				s.activeTaskCount--
-				if s.activeTaskCount < 0 { s.activeTaskCount = 0 }
+				delete(s.inFlight, event.TaskID)
 			}
 
 		case pipeline.PipelineComplete:
 			s.finished = true
 			s.success = event.OverallSuccess
 			s.finalError = event.FinalError
-			s.activeTaskCount = 0 // Ensure counter is zero
+			s.inFlight = make(map[string]bool) // Nothing can still be running
 			// Stop listening implicitly as channel will close
 			return s, nil // Wait for user to press Enter/q to Quit
 		}
@@ -255,17 +314,28 @@ func (s *InstallationScreen) View() string {
 	content.WriteString(styles.TitleStyle.Render(s.title))
 	content.WriteString("\n\n")
 
+	// Pre-flight warnings
+	if len(s.skipped) > 0 {
+		content.WriteString(styles.WarningStyle.Render(fmt.Sprintf("Skipped %d tool(s) with no install method:", len(s.skipped))))
+		content.WriteString("\n")
+		for _, issue := range s.skipped {
+			content.WriteString(styles.WarningStyle.Render("  ! " + issue.Error()))
+			content.WriteString("\n")
+		}
+		content.WriteString("\n")
+	}
+
 	// Display Tasks
 	for _, task := range s.tasks {
 		var line strings.Builder
 
 		// Status Indicator
-		switch task.Status {
-		case StatusRunning, StatusRetrying, StatusRollingBack:
+		switch {
+		case s.inFlight[task.ID]:
 			line.WriteString(s.spinner.View() + " ")
-		case StatusDone:
+		case task.Status == StatusDone:
 			line.WriteString(styles.SuccessStyle.Render("✓") + " ")
-		case StatusFailed, StatusRollbackFailed:
+		case task.Status == StatusFailed || task.Status == StatusRollbackFailed:
 			line.WriteString(styles.ErrorStyle.Render("✗") + " ")
 		default: // Pending
 			line.WriteString(styles.UnselectedTextStyle.Render("·") + " ") // Use UnselectedTextStyle
@@ -274,7 +344,7 @@ func (s *InstallationScreen) View() string {
 		// Description
 		desc := task.Description
 		if task.Status == StatusRetrying {
-			desc += " (Retrying...)"
+			desc += fmt.Sprintf(" (retrying %d/%d)", task.Attempt, task.Attempts)
 		} else if task.Status == StatusRollingBack {
 			desc += " (Rolling back...)"
 		}
@@ -299,6 +369,11 @@ func (s *InstallationScreen) View() string {
 		content.WriteString("\n\n") // Add extra space between tasks
 	}
 
+	// Grouped summary once everything has run
+	if s.finished {
+		content.WriteString(s.summaryView())
+	}
+
 	// Footer / Final Status
 	footer := "\n"
 	if s.finished {
@@ -308,8 +383,8 @@ func (s *InstallationScreen) View() string {
 			footer += styles.ErrorStyle.Render(fmt.Sprintf("Installation Failed: %v", s.finalError))
 		}
         footer += "\nPress Enter or q to exit."
-	} else if s.activeTaskCount > 0 {
-		footer += styles.HelpStyle.Render(fmt.Sprintf("Installation in progress (%d active)... (Press Ctrl+C to attempt cancel)", s.activeTaskCount))
+	} else if len(s.inFlight) > 0 {
+		footer += styles.HelpStyle.Render(fmt.Sprintf("Installation in progress (%d active)... (Press Ctrl+C to attempt cancel)", len(s.inFlight)))
 	} else {
         footer += styles.HelpStyle.Render("Waiting for pipeline...") // Should not stay here long
     }
@@ -326,6 +401,92 @@ func (s *InstallationScreen) View() string {
 	return lipgloss.Place(s.width, s.height, lipgloss.Left, lipgloss.Top, finalContent+footer)
 }
 
+// Finished reports whether the pipeline has reported its completion
+func (s *InstallationScreen) Finished() bool {
+	return s.finished
+}
+
+// InFlight returns the IDs of tasks currently running, in the order they were started
+func (s *InstallationScreen) InFlight() []string {
+	var ids []string
+	for _, task := range s.tasks {
+		if s.inFlight[task.ID] {
+			ids = append(ids, task.ID)
+		}
+	}
+	return ids
+}
+
+// summaryView renders the per-category outcome of the finished tasks
+func (s *InstallationScreen) summaryView() string {
+	summary := pipeline.NewSummary()
+	for _, task := range s.tasks {
+		switch task.Status {
+		case StatusDone:
+			summary.Record(task.Group, task.Item, true)
+			summary.SetVersion(task.Item, task.Version)
+		case StatusFailed:
+			err := task.Error
+			if err == nil {
+				err = fmt.Errorf("%s failed", task.ID)
+			}
+			summary.RecordError(task.Group, task.Item, err)
+		}
+	}
+	groups := summary.Groups()
+	if len(groups) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	var failed []string // Failed items, numbered from 1 in the summary
+	b.WriteString(styles.TitleStyle.Render("Summary"))
+	b.WriteString("\n")
+	for _, g := range groups {
+		style := styles.SuccessStyle
+		if len(g.Failed) > 0 {
+			style = styles.ErrorStyle
+		}
+		b.WriteString("  " + style.Render(g.String()))
+		b.WriteString("\n")
+		// Each item with the version it reported, e.g. "bat 0.24.0 ✓"
+		var items []string
+		for _, item := range g.Succeeded {
+			items = append(items, g.Label(item)+" "+styles.SuccessStyle.Render("✓"))
+		}
+		for _, item := range g.Failed {
+			mark := "✗"
+			if g.IsTimedOut(item) {
+				mark += " timed out"
+			}
+			items = append(items, fmt.Sprintf("[%d] %s %s", len(failed)+1, item, styles.ErrorStyle.Render(mark)))
+			failed = append(failed, item)
+		}
+		b.WriteString("    " + strings.Join(items, "  "))
+		b.WriteString("\n")
+	}
+
+	// Where each failure's output went, and the output asked for
+	for n, item := range failed {
+		task := s.failedTask(item)
+		if task == nil {
+			continue
+		}
+		if task.LogPath != "" {
+			b.WriteString(styles.HelpStyle.Render(fmt.Sprintf("  [%d] %s output: %s", n+1, item, task.LogPath)))
+			b.WriteString("\n")
+		}
+		if s.expanded[item] {
+			b.WriteString(outputView(item, task.Output))
+		}
+	}
+	if len(failed) > 0 {
+		b.WriteString(styles.HelpStyle.Render("  Press a failed item's number to show its output, or e for all."))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
 // listenForProgress returns a command that listens for the next message on the progress channel.
 func (s *InstallationScreen) listenForProgress() tea.Cmd {
 	return func() tea.Msg {
@@ -342,4 +503,52 @@ func (s *InstallationScreen) listenForProgress() tea.Cmd {
 		}
 		return progressMsg{event}
 	}
-} 
\ No newline at end of file
+} 
+
+// failedItems returns the failed items in the order the summary numbers them
+func (s *InstallationScreen) failedItems() []string {
+	summary := pipeline.NewSummary()
+	for _, task := range s.tasks {
+		switch task.Status {
+		case StatusDone:
+			summary.Record(task.Group, task.Item, true)
+		case StatusFailed:
+			summary.Record(task.Group, task.Item, false)
+		}
+	}
+	var failed []string
+	for _, g := range summary.Groups() {
+		failed = append(failed, g.Failed...)
+	}
+	return failed
+}
+
+// failedTask returns the task that failed item
+func (s *InstallationScreen) failedTask(item string) *TaskState {
+	for _, task := range s.tasks {
+		if task.Item == item && task.Status == StatusFailed {
+			return task
+		}
+	}
+	return nil
+}
+
+// outputView renders the last outputLines lines of a failed item's output
+func outputView(item, output string) string {
+	if strings.TrimSpace(output) == "" {
+		return styles.HelpStyle.Render(fmt.Sprintf("    %s printed no output", item)) + "\n"
+	}
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	header := fmt.Sprintf("    %s output:", item)
+	if len(lines) > outputLines {
+		header = fmt.Sprintf("    %s output (last %d of %d lines):", item, outputLines, len(lines))
+		lines = lines[len(lines)-outputLines:]
+	}
+	var b strings.Builder
+	b.WriteString(styles.WarningStyle.Render(header))
+	b.WriteString("\n")
+	for _, line := range lines {
+		b.WriteString("      " + line + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,145 @@
+package screens
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func feed(s *InstallationScreen, events ...pipeline.ProgressEvent) {
+	for _, event := range events {
+		s.Update(progressMsg{event: event})
+	}
+}
+
+func TestInstallationScreenOutOfOrderResults(t *testing.T) {
+	s := NewInstallationScreen(make(chan pipeline.ProgressEvent))
+	s.Update(tea.WindowSizeMsg{Width: 100, Height: 60})
+
+	feed(s,
+		pipeline.TaskStart{TaskID: "bat", Description: "Installing bat"},
+		pipeline.TaskStart{TaskID: "fd", Description: "Installing fd"},
+		pipeline.TaskStart{TaskID: "fzf", Description: "Installing fzf"},
+		// Later tasks finish first, and one fails
+		pipeline.TaskEnd{TaskID: "fzf", Success: true},
+		pipeline.TaskEnd{TaskID: "bat", Success: false, Error: fmt.Errorf("boom")},
+		// Duplicate and unknown results must not disturb the in-flight set
+		pipeline.TaskEnd{TaskID: "fzf", Success: true},
+		pipeline.TaskEnd{TaskID: "ripgrep", Success: true},
+	)
+
+	if got := s.InFlight(); len(got) != 1 || got[0] != "fd" {
+		t.Fatalf("Expected only fd in flight, got %v", got)
+	}
+	view := s.View()
+	if !strings.Contains(view, "(1 active)") {
+		t.Errorf("Expected footer to report 1 active task, got:\n%s", view)
+	}
+	if !strings.Contains(view, "✓") || !strings.Contains(view, "✗") {
+		t.Errorf("Expected finished tasks to render their result, got:\n%s", view)
+	}
+
+	// A retried task goes back in flight without being listed twice
+	feed(s, pipeline.TaskStart{TaskID: "bat", Description: "Installing bat"})
+	if got := s.InFlight(); len(got) != 2 || got[0] != "bat" {
+		t.Errorf("Expected bat and fd in flight, got %v", got)
+	}
+	if len(s.tasks) != 3 {
+		t.Errorf("Expected 3 tasks, got %d", len(s.tasks))
+	}
+
+	feed(s, pipeline.TaskEnd{TaskID: "fd", Success: true}, pipeline.TaskEnd{TaskID: "bat", Success: true})
+	if got := s.InFlight(); len(got) != 0 {
+		t.Errorf("Expected nothing in flight, got %v", got)
+	}
+}
+
+func TestInstallationScreenRetry(t *testing.T) {
+	s := NewInstallationScreen(make(chan pipeline.ProgressEvent))
+	s.Update(tea.WindowSizeMsg{Width: 100, Height: 60})
+
+	feed(s,
+		pipeline.TaskStart{TaskID: "bat", Description: "Installing bat"},
+		pipeline.TaskRetry{TaskID: "bat", Attempt: 2, Attempts: 3, Error: fmt.Errorf("E: Could not get lock /var/lib/dpkg/lock-frontend")},
+	)
+	view := s.View()
+	if !strings.Contains(view, "Installing bat (retrying 2/3)") {
+		t.Errorf("Expected the retry to be shown next to the task, got:\n%s", view)
+	}
+	if strings.Contains(view, "✗") {
+		t.Errorf("Expected a retrying task not to be shown as failed, got:\n%s", view)
+	}
+	if got := s.InFlight(); len(got) != 1 || got[0] != "bat" {
+		t.Errorf("Expected bat to stay in flight, got %v", got)
+	}
+}
+
+func TestInstallationScreenSpinnerPerTask(t *testing.T) {
+	s := NewInstallationScreen(make(chan pipeline.ProgressEvent))
+	s.Update(tea.WindowSizeMsg{Width: 100, Height: 60})
+
+	feed(s,
+		pipeline.TaskStart{TaskID: "bat", Description: "Installing bat"},
+		pipeline.TaskStart{TaskID: "fd", Description: "Installing fd"},
+		pipeline.TaskStart{TaskID: "fzf", Description: "Installing fzf"},
+		pipeline.TaskEnd{TaskID: "fd", Success: true},
+	)
+	spinner := s.spinner.View()
+	for _, line := range strings.Split(s.View(), "\n") {
+		installing := strings.Contains(line, "Installing bat") || strings.Contains(line, "Installing fzf")
+		if installing != strings.Contains(line, spinner) {
+			t.Errorf("Expected a spinner next to each installing tool only, got %q", line)
+		}
+	}
+}
+
+func TestInstallationScreenSummaryVersions(t *testing.T) {
+	s := NewInstallationScreen(make(chan pipeline.ProgressEvent))
+	s.Update(tea.WindowSizeMsg{Width: 100, Height: 60})
+
+	feed(s,
+		pipeline.TaskStart{TaskID: "bat-install", Description: "Installing bat", Group: "Modern", Item: "bat"},
+		pipeline.TaskEnd{TaskID: "bat-install", Success: true},
+		pipeline.TaskStart{TaskID: "bat-verify", Description: "Verifying bat", Group: "Modern", Item: "bat"},
+		pipeline.TaskEnd{TaskID: "bat-verify", Success: true, Version: "0.24.0"},
+		pipeline.TaskStart{TaskID: "fd-install", Description: "Installing fd", Group: "Modern", Item: "fd"},
+		pipeline.TaskEnd{TaskID: "fd-install", Success: false, Error: fmt.Errorf("boom")},
+		pipeline.PipelineComplete{OverallSuccess: false, FinalError: fmt.Errorf("boom")},
+	)
+	view := s.View()
+	if !strings.Contains(view, "bat 0.24.0 ✓") || !strings.Contains(view, "fd ✗") {
+		t.Errorf("Expected the summary to list each tool with its version, got:\n%s", view)
+	}
+}
+
+func TestInstallationScreenFailedOutput(t *testing.T) {
+	s := NewInstallationScreen(make(chan pipeline.ProgressEvent))
+	s.Update(tea.WindowSizeMsg{Width: 100, Height: 80})
+
+	feed(s,
+		pipeline.TaskStart{TaskID: "fd-install", Description: "Installing fd", Group: "Modern", Item: "fd"},
+		pipeline.TaskEnd{TaskID: "fd-install", Success: false, Error: fmt.Errorf("exit status 100"),
+			Output: "Reading package lists...\nE: Unable to locate package fd-find\n", LogPath: "/home/me/.bootstrap-cli/logs/fd-20260504-130201.log"},
+		pipeline.PipelineComplete{OverallSuccess: false, FinalError: fmt.Errorf("exit status 100")},
+	)
+	view := s.View()
+	if !strings.Contains(view, "[1] fd ✗") || !strings.Contains(view, "fd-20260504-130201.log") {
+		t.Errorf("Expected the numbered failure and its log path, got:\n%s", view)
+	}
+	if strings.Contains(view, "Unable to locate package") {
+		t.Errorf("Expected the output to stay hidden until asked for, got:\n%s", view)
+	}
+
+	s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("1")})
+	if view := s.View(); !strings.Contains(view, "E: Unable to locate package fd-find") {
+		t.Errorf("Expected 1 to show fd's output, got:\n%s", view)
+	}
+	s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	if view := s.View(); !strings.Contains(view, "E: Unable to locate package fd-find") {
+		t.Errorf("Expected e twice to leave the output shown, got:\n%s", view)
+	}
+}
@@ -8,12 +8,17 @@ import (
 )
 
 // LanguageScreen uses the BaseSelector component for language selection.
+// Pressing a switches the focused language from its own version manager
+// (nvm, pyenv...) to asdf, then mise, then back.
 type LanguageScreen struct {
 	selector *components.BaseSelector
 	finished bool
 	title    string
 	width    int
 	height   int
+	// native holds the installer each language had before it was switched to
+	// asdf or mise
+	native map[*interfaces.Language]string
 }
 
 // NewLanguageScreen creates a new LanguageScreen.
@@ -27,20 +32,52 @@ func NewLanguageScreen(title string, languages []*interfaces.Language, preselect
 
 	selector.SetItems(items, 
 		func(item interface{}) string { if l, ok := item.(*interfaces.Language); ok { return l.Name }; return "" }, 
-		func(item interface{}) string { if l, ok := item.(*interfaces.Language); ok { return l.Description }; return "" },
+		func(item interface{}) string { if l, ok := item.(*interfaces.Language); ok { return languageDescription(l) }; return "" },
 	)
 	if len(selectedItems) > 0 {
 		selector.SetSelectedDataItems(selectedItems)
 	}
+	selector.AddHelpKey("a", "asdf/mise")
 
 	s := &LanguageScreen{
 		selector: selector,
 		finished: false,
 		title:    title,
+		native:   make(map[*interfaces.Language]string),
 	}
 	return s
 }
 
+// languageDescription is the language's description and what installs it,
+// e.g. "JavaScript runtime (nvm)"
+func languageDescription(l *interfaces.Language) string {
+	if l.Installer == "" {
+		return l.Description
+	}
+	return l.Description + " (" + l.Installer + ")"
+}
+
+// cycleInstaller switches the focused language to the next of asdf, mise and
+// its own installer
+func (s *LanguageScreen) cycleInstaller() {
+	lang, ok := s.selector.Focused().(*interfaces.Language)
+	if !ok {
+		return
+	}
+	switch lang.RuntimeManager() {
+	case interfaces.LanguageInstallerAsdf:
+		lang.Installer = interfaces.LanguageInstallerMise
+	case interfaces.LanguageInstallerMise:
+		lang.Installer = s.native[lang]
+	default:
+		if _, seen := s.native[lang]; !seen {
+			s.native[lang] = lang.Installer
+		}
+		lang.Installer = interfaces.LanguageInstallerAsdf
+	}
+	s.selector.SetDescription(lang, languageDescription(lang))
+}
+
 func (s *LanguageScreen) Init() tea.Cmd { 
     if s.selector != nil { return s.selector.Init() } 
     return nil
@@ -48,6 +85,10 @@ func (s *LanguageScreen) Init() tea.Cmd {
 
 func (s *LanguageScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
+	if key, ok := msg.(tea.KeyMsg); ok && key.String() == "a" && s.selector != nil && !s.selector.Filtering() {
+		s.cycleInstaller()
+		return s, nil
+	}
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg: 
 		s.width = msg.Width
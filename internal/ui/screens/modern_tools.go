@@ -28,7 +28,7 @@ func NewModernToolScreen(title string, tools []*pipeline.Tool, preselected []*pi
 
 	selector.SetItems(items, 
 		func(item interface{}) string { 
-			if t, ok := item.(*pipeline.Tool); ok { return t.Name }
+			if t, ok := item.(*pipeline.Tool); ok { return t.Label() }
 			return ""
 		}, 
 		func(item interface{}) string { 
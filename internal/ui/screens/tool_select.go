@@ -0,0 +1,278 @@
+package screens
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/pipeline"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/ui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// RecommendedTags mark the tools the "select recommended" shortcut picks
+var RecommendedTags = []string{"recommended", "essential"}
+
+// toolGroup is a category header and the tools listed under it
+type toolGroup struct {
+	category string
+	tools    []*interfaces.Tool
+}
+
+// ToolSelectScreen is a multi-select list of the catalog's tools grouped by
+// category, with a search filter and a shortcut for the recommended tools
+type ToolSelectScreen struct {
+	BaseScreenModel
+	groups    []toolGroup
+	selected  map[string]bool
+	cursor    int // Index into visible()
+	filter    string
+	filtering bool // Typing goes to the filter
+	confirmed bool
+	width     int
+	height    int
+}
+
+// NewToolSelectScreen lists tools under categories (e.g. from
+// Loader.GetCategories("tools")), in that order, with the tools named in
+// preselected checked. Tools of other categories are listed last.
+func NewToolSelectScreen(categories []string, tools []*interfaces.Tool, preselected []string) *ToolSelectScreen {
+	s := &ToolSelectScreen{selected: make(map[string]bool)}
+	index := make(map[string]int)
+	for _, category := range categories {
+		if _, ok := index[category]; !ok {
+			index[category] = len(s.groups)
+			s.groups = append(s.groups, toolGroup{category: category})
+		}
+	}
+	for _, tool := range tools {
+		i, ok := index[tool.Category]
+		if !ok {
+			i = len(s.groups)
+			index[tool.Category] = i
+			s.groups = append(s.groups, toolGroup{category: tool.Category})
+		}
+		s.groups[i].tools = append(s.groups[i].tools, tool)
+	}
+	for _, g := range s.groups {
+		sort.Slice(g.tools, func(i, j int) bool { return strings.ToLower(g.tools[i].Name) < strings.ToLower(g.tools[j].Name) })
+	}
+	for _, name := range preselected {
+		if tool := interfaces.FindTool(tools, name); tool != nil {
+			s.selected[tool.Name] = true
+		}
+	}
+	return s
+}
+
+// Init implements tea.Model
+func (s *ToolSelectScreen) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model
+func (s *ToolSelectScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.width, s.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		if s.filtering {
+			s.updateFilter(msg)
+			return s, nil
+		}
+		switch msg.String() {
+		case "up", "k":
+			if s.cursor > 0 {
+				s.cursor--
+			}
+		case "down", "j":
+			if s.cursor < len(s.visible())-1 {
+				s.cursor++
+			}
+		case " ":
+			if visible := s.visible(); s.cursor < len(visible) {
+				name := visible[s.cursor].Name
+				s.selected[name] = !s.selected[name]
+			}
+		case "/":
+			s.filtering = true
+		case "r":
+			s.SelectRecommended()
+		case "enter":
+			s.confirmed = true
+			s.SetDone(true)
+			return s, tea.Quit
+		case "esc", "q", "ctrl+c":
+			s.SetDone(true)
+			return s, tea.Quit
+		}
+	}
+	return s, nil
+}
+
+// updateFilter edits the filter while it has the focus; enter keeps it and
+// esc clears it
+func (s *ToolSelectScreen) updateFilter(msg tea.KeyMsg) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		s.filtering = false
+	case tea.KeyEsc:
+		s.filtering, s.filter = false, ""
+	case tea.KeyBackspace:
+		if s.filter != "" {
+			s.filter = s.filter[:len(s.filter)-1]
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		s.filter += string(msg.Runes)
+	}
+	s.cursor = 0
+}
+
+// SelectRecommended checks every tool tagged with one of RecommendedTags
+func (s *ToolSelectScreen) SelectRecommended() {
+	for _, g := range s.groups {
+		for _, tool := range g.tools {
+			if recommended(tool) {
+				s.selected[tool.Name] = true
+			}
+		}
+	}
+}
+
+func recommended(tool *interfaces.Tool) bool {
+	for _, tag := range tool.Tags {
+		for _, want := range RecommendedTags {
+			if strings.EqualFold(tag, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matches reports whether tool matches the filter by name, description or tag
+func (s *ToolSelectScreen) matches(tool *interfaces.Tool) bool {
+	if s.filter == "" {
+		return true
+	}
+	filter := strings.ToLower(s.filter)
+	if strings.Contains(strings.ToLower(tool.Name), filter) || strings.Contains(strings.ToLower(tool.Description), filter) {
+		return true
+	}
+	for _, tag := range tool.Tags {
+		if strings.Contains(strings.ToLower(tag), filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// visible returns the tools the filter lets through, in the order listed
+func (s *ToolSelectScreen) visible() []*interfaces.Tool {
+	var tools []*interfaces.Tool
+	for _, g := range s.groups {
+		for _, tool := range g.tools {
+			if s.matches(tool) {
+				tools = append(tools, tool)
+			}
+		}
+	}
+	return tools
+}
+
+// Confirmed reports whether the selection was confirmed with enter rather
+// than abandoned
+func (s *ToolSelectScreen) Confirmed() bool {
+	return s.confirmed
+}
+
+// Selected returns the names of the checked tools in the order listed
+func (s *ToolSelectScreen) Selected() []string {
+	var names []string
+	for _, g := range s.groups {
+		for _, tool := range g.tools {
+			if s.selected[tool.Name] {
+				names = append(names, tool.Name)
+			}
+		}
+	}
+	return names
+}
+
+// SelectedTools returns the checked tools in the order listed
+func (s *ToolSelectScreen) SelectedTools() []*interfaces.Tool {
+	var tools []*interfaces.Tool
+	for _, g := range s.groups {
+		for _, tool := range g.tools {
+			if s.selected[tool.Name] {
+				tools = append(tools, tool)
+			}
+		}
+	}
+	return tools
+}
+
+// View implements tea.Model
+func (s *ToolSelectScreen) View() string {
+	var lines []string
+	cursorLine := 0
+	row := 0
+	for _, g := range s.groups {
+		var rows []string
+		checked := 0
+		for _, tool := range g.tools {
+			if s.selected[tool.Name] {
+				checked++
+			}
+			if !s.matches(tool) {
+				continue
+			}
+			pointer, style := "  ", styles.NormalTextStyle
+			if row == s.cursor {
+				pointer, style = "> ", styles.SelectedTextStyle
+				cursorLine = len(lines) + len(rows) + 1
+			}
+			box := "[ ]"
+			if s.selected[tool.Name] {
+				box = "[x]"
+			}
+			rows = append(rows, pointer+style.Render(box+" "+tool.Label())+"  "+styles.HelpStyle.Render(tool.Description))
+			row++
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		header := fmt.Sprintf("%s (%d/%d selected)", pipeline.GroupLabel(pipeline.ToolCategory(g.category)), checked, len(g.tools))
+		lines = append(lines, styles.SubtitleStyle.Render(header))
+		lines = append(lines, rows...)
+	}
+	if row == 0 {
+		lines = append(lines, styles.HelpStyle.Render(fmt.Sprintf("No tools match %q", s.filter)))
+	}
+
+	// Keep the cursor in view when the list is taller than the screen
+	if maxLines := s.height - 6; maxLines > 0 && len(lines) > maxLines {
+		start := min(max(cursorLine-maxLines/2, 0), len(lines)-maxLines)
+		lines = lines[start : start+maxLines]
+	}
+
+	var b strings.Builder
+	b.WriteString(styles.TitleStyle.Render("Select tools"))
+	b.WriteString("\n")
+	switch {
+	case s.filtering:
+		b.WriteString(styles.InfoStyle.Render("/" + s.filter + "█"))
+	case s.filter != "":
+		b.WriteString(styles.InfoStyle.Render("filter: " + s.filter))
+	}
+	b.WriteString("\n")
+	b.WriteString(strings.Join(lines, "\n"))
+	b.WriteString("\n\n")
+	help := "↑/↓ move • space toggle • / filter • r select recommended • enter confirm • q quit"
+	if s.filtering {
+		help = "type to filter • enter keep filter • esc clear filter"
+	}
+	b.WriteString(styles.HelpStyle.Render(help))
+	return b.String()
+}
@@ -0,0 +1,90 @@
+package screens
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func keys(s *ToolSelectScreen, keys ...string) {
+	for _, key := range keys {
+		var msg tea.KeyMsg
+		switch key {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case " ":
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		}
+		s.Update(msg)
+	}
+}
+
+func TestToolSelectScreen(t *testing.T) {
+	tools := []*interfaces.Tool{
+		{Name: "ripgrep", Category: "modern", Description: "Fast grep", Tags: []string{"search"}},
+		{Name: "git", Category: "essential", Description: "Version control", Tags: []string{"essential"}},
+		{Name: "bat", Category: "modern", Description: "cat with wings", Tags: []string{"recommended"}},
+		{Name: "curl", Category: "essential", Description: "Transfer URLs"},
+	}
+	s := NewToolSelectScreen([]string{"essential", "modern"}, tools, []string{"CURL"})
+	s.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+
+	// Tools are grouped in category order and sorted within a group
+	var order []string
+	for _, tool := range s.visible() {
+		order = append(order, tool.Name)
+	}
+	if want := []string{"curl", "git", "bat", "ripgrep"}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("Expected order %v, got %v", want, order)
+	}
+	view := s.View()
+	if !strings.Contains(view, "[x] curl") || !strings.Contains(view, "(1/2 selected)") {
+		t.Errorf("Expected the saved selection to be checked, got:\n%s", view)
+	}
+
+	// Space toggles the tool under the cursor
+	keys(s, "down", " ")
+	if got := s.Selected(); !reflect.DeepEqual(got, []string{"curl", "git"}) {
+		t.Errorf("Expected curl and git selected, got %v", got)
+	}
+	keys(s, " ")
+	if got := s.Selected(); !reflect.DeepEqual(got, []string{"curl"}) {
+		t.Errorf("Expected git unchecked again, got %v", got)
+	}
+
+	// The filter matches descriptions and tags, and enter keeps it
+	keys(s, "/", "g", "r", "e", "p", "enter", " ")
+	if got := s.visible(); len(got) != 1 || got[0].Name != "ripgrep" {
+		t.Fatalf("Expected only ripgrep to match, got %v", got)
+	}
+	if !strings.Contains(s.View(), "filter: grep") {
+		t.Errorf("Expected the filter to be shown, got:\n%s", s.View())
+	}
+	keys(s, "/", "esc")
+	if got := s.visible(); len(got) != 4 {
+		t.Errorf("Expected esc to clear the filter, got %d tools", len(got))
+	}
+
+	// r adds the recommended tools to the selection
+	keys(s, "r")
+	if got, want := s.Selected(), []string{"curl", "git", "bat", "ripgrep"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v selected, got %v", want, got)
+	}
+
+	keys(s, "enter")
+	if !s.Confirmed() || !s.Done() {
+		t.Error("Expected enter to confirm the selection")
+	}
+	if got := s.SelectedTools(); len(got) != 4 || got[0] != tools[3] {
+		t.Errorf("Expected the selected tool definitions, got %v", got)
+	}
+}
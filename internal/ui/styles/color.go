@@ -0,0 +1,118 @@
+package styles
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Profile is the color depth a terminal supports
+type Profile int
+
+const (
+	// ProfileNone disables colors (NO_COLOR or TERM=dumb)
+	ProfileNone Profile = iota
+	// ProfileANSI is the basic 16-color palette
+	ProfileANSI
+	// ProfileANSI256 is the xterm 256-color palette
+	ProfileANSI256
+	// ProfileTrueColor is 24-bit color
+	ProfileTrueColor
+)
+
+// String returns the profile name
+func (p Profile) String() string {
+	switch p {
+	case ProfileNone:
+		return "none"
+	case ProfileANSI:
+		return "16-color"
+	case ProfileANSI256:
+		return "256-color"
+	default:
+		return "truecolor"
+	}
+}
+
+var (
+	profileMu sync.RWMutex
+	current   = ProfileTrueColor
+)
+
+// DetectProfile works out the terminal's color depth from COLORTERM and TERM,
+// honoring NO_COLOR
+func DetectProfile(getenv func(string) string) Profile {
+	if getenv("NO_COLOR") != "" {
+		return ProfileNone
+	}
+	term := strings.ToLower(getenv("TERM"))
+	if term == "dumb" {
+		return ProfileNone
+	}
+	switch strings.ToLower(getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return ProfileTrueColor
+	}
+	switch {
+	case strings.HasSuffix(term, "-direct"), strings.Contains(term, "truecolor"):
+		return ProfileTrueColor
+	case strings.Contains(term, "256color"):
+		return ProfileANSI256
+	default:
+		return ProfileANSI
+	}
+}
+
+// UseProfile makes every style in this package render with profile p. The
+// palette carries 256- and 16-color fallbacks, so basic terminals and SSH
+// sessions get the nearest readable colors instead of degraded hex values.
+func UseProfile(p Profile) {
+	profileMu.Lock()
+	current = p
+	profileMu.Unlock()
+
+	switch p {
+	case ProfileNone:
+		lipgloss.SetColorProfile(termenv.Ascii)
+	case ProfileANSI:
+		lipgloss.SetColorProfile(termenv.ANSI)
+	case ProfileANSI256:
+		lipgloss.SetColorProfile(termenv.ANSI256)
+	default:
+		lipgloss.SetColorProfile(termenv.TrueColor)
+	}
+}
+
+// CurrentProfile returns the profile set by UseProfile, for renderers outside lipgloss
+func CurrentProfile() Profile {
+	profileMu.RLock()
+	defer profileMu.RUnlock()
+	return current
+}
+
+// ColorFor returns the value of c for the current profile, or "" without colors
+func ColorFor(c lipgloss.CompleteColor) string {
+	switch CurrentProfile() {
+	case ProfileNone:
+		return ""
+	case ProfileANSI:
+		return c.ANSI
+	case ProfileANSI256:
+		return c.ANSI256
+	default:
+		return c.TrueColor
+	}
+}
+
+// ProgressOption fills progress bars with the theme's gradient on truecolor
+// terminals and with the solid accent color elsewhere, where blended hex values
+// band badly
+func ProgressOption() progress.Option {
+	if g := CurrentTheme().Gradient; CurrentProfile() == ProfileTrueColor && g[0] != "" {
+		return progress.WithGradient(g[0], g[1])
+	}
+	return progress.WithSolidFill(ColorFor(ColorProgressFull))
+}
@@ -0,0 +1,44 @@
+package styles
+
+import "testing"
+
+func TestDetectProfile(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want Profile
+	}{
+		{name: "COLORTERM truecolor", env: map[string]string{"COLORTERM": "truecolor", "TERM": "xterm-256color"}, want: ProfileTrueColor},
+		{name: "COLORTERM 24bit", env: map[string]string{"COLORTERM": "24bit"}, want: ProfileTrueColor},
+		{name: "direct-color TERM", env: map[string]string{"TERM": "xterm-direct"}, want: ProfileTrueColor},
+		{name: "256-color TERM", env: map[string]string{"TERM": "screen-256color"}, want: ProfileANSI256},
+		{name: "basic TERM", env: map[string]string{"TERM": "xterm"}, want: ProfileANSI},
+		{name: "unset TERM", env: map[string]string{}, want: ProfileANSI},
+		{name: "dumb TERM", env: map[string]string{"TERM": "dumb", "COLORTERM": "truecolor"}, want: ProfileNone},
+		{name: "NO_COLOR", env: map[string]string{"NO_COLOR": "1", "COLORTERM": "truecolor"}, want: ProfileNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+			if got := DetectProfile(getenv); got != tt.want {
+				t.Errorf("DetectProfile() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColorFor(t *testing.T) {
+	t.Cleanup(func() { UseProfile(ProfileTrueColor) })
+
+	for profile, want := range map[Profile]string{
+		ProfileTrueColor: "#8FBCBB",
+		ProfileANSI256:   "109",
+		ProfileANSI:      "6",
+		ProfileNone:      "",
+	} {
+		UseProfile(profile)
+		if got := ColorFor(ColorAccent); got != want {
+			t.Errorf("ColorFor(ColorAccent) with %s = %q, want %q", profile, got, want)
+		}
+	}
+}
@@ -3,118 +3,158 @@ package styles
 
 import "github.com/charmbracelet/lipgloss"
 
-// Nord-inspired color theme
-const (
-	NordPolarNight1 = lipgloss.Color("#2E3440") // Darkest Background
-	NordPolarNight2 = lipgloss.Color("#3B4252")
-	NordPolarNight3 = lipgloss.Color("#434C5E") // Darker Background / Borders
-	NordPolarNight4 = lipgloss.Color("#4C566A")
-
-	NordSnowStorm1 = lipgloss.Color("#D8DEE9") // Slightly Dim Text
-	NordSnowStorm2 = lipgloss.Color("#E5E9F0") // Normal Text
-	NordSnowStorm3 = lipgloss.Color("#ECEFF4") // Brighter Text / Highlights
-
-	NordFrostGreen  = lipgloss.Color("#8FBCBB") // Accent Color 1
-	NordFrostBlue   = lipgloss.Color("#88C0D0") // Accent Color 2
-	NordFrostPurple = lipgloss.Color("#B48EAD") // Accent Color 3
-	NordFrostLightBlue = lipgloss.Color("#81A1C1") // Accent Color 4
-
-	NordAuroraRed    = lipgloss.Color("#BF616A") // Error
-	NordAuroraOrange = lipgloss.Color("#D08770")
-	NordAuroraYellow = lipgloss.Color("#EBCB8B") // Warning
-	NordAuroraGreen  = lipgloss.Color("#A3BE8C") // Success
-	NordAuroraPurple = lipgloss.Color("#B48EAD")
-
-	// Map to our style variables
-	ColorBackground    = NordPolarNight1
-	ColorSubtleBorder  = NordPolarNight3
-	ColorNormalText    = NordSnowStorm2
-	ColorDimText       = NordSnowStorm1
-	ColorBrightText    = NordSnowStorm3
-	ColorAccent        = NordFrostGreen // Using Frost Green as primary accent
-	ColorAccentAlt     = NordFrostBlue    // Alt accent
-	ColorSuccess       = NordAuroraGreen
-	ColorWarning       = NordAuroraYellow
-	ColorError         = NordAuroraRed
-	ColorSpinner       = ColorAccent
-	ColorProgressEmpty = NordPolarNight3
-	ColorProgressFull  = ColorAccent
+// Nord-inspired color theme, with 256- and 16-color fallbacks for terminals
+// without truecolor (see UseProfile)
+var (
+	NordPolarNight1 = lipgloss.CompleteColor{TrueColor: "#2E3440", ANSI256: "236", ANSI: "0"} // Darkest Background
+	NordPolarNight2 = lipgloss.CompleteColor{TrueColor: "#3B4252", ANSI256: "237", ANSI: "0"}
+	NordPolarNight3 = lipgloss.CompleteColor{TrueColor: "#434C5E", ANSI256: "238", ANSI: "8"} // Darker Background / Borders
+	NordPolarNight4 = lipgloss.CompleteColor{TrueColor: "#4C566A", ANSI256: "240", ANSI: "8"}
+
+	NordSnowStorm1 = lipgloss.CompleteColor{TrueColor: "#D8DEE9", ANSI256: "253", ANSI: "7"} // Slightly Dim Text
+	NordSnowStorm2 = lipgloss.CompleteColor{TrueColor: "#E5E9F0", ANSI256: "254", ANSI: "7"} // Normal Text
+	NordSnowStorm3 = lipgloss.CompleteColor{TrueColor: "#ECEFF4", ANSI256: "255", ANSI: "15"} // Brighter Text / Highlights
+
+	NordFrostGreen  = lipgloss.CompleteColor{TrueColor: "#8FBCBB", ANSI256: "109", ANSI: "6"} // Accent Color 1
+	NordFrostBlue   = lipgloss.CompleteColor{TrueColor: "#88C0D0", ANSI256: "116", ANSI: "14"} // Accent Color 2
+	NordFrostPurple = lipgloss.CompleteColor{TrueColor: "#B48EAD", ANSI256: "139", ANSI: "5"} // Accent Color 3
+	NordFrostLightBlue = lipgloss.CompleteColor{TrueColor: "#81A1C1", ANSI256: "110", ANSI: "4"} // Accent Color 4
+
+	NordAuroraRed    = lipgloss.CompleteColor{TrueColor: "#BF616A", ANSI256: "131", ANSI: "1"} // Error
+	NordAuroraOrange = lipgloss.CompleteColor{TrueColor: "#D08770", ANSI256: "173", ANSI: "3"}
+	NordAuroraYellow = lipgloss.CompleteColor{TrueColor: "#EBCB8B", ANSI256: "222", ANSI: "11"} // Warning
+	NordAuroraGreen  = lipgloss.CompleteColor{TrueColor: "#A3BE8C", ANSI256: "144", ANSI: "2"} // Success
+	NordAuroraPurple = lipgloss.CompleteColor{TrueColor: "#B48EAD", ANSI256: "139", ANSI: "5"}
+
+	// Map to our style variables; ApplyTheme replaces these
+	ColorBackground    lipgloss.CompleteColor
+	ColorSubtleBorder  lipgloss.CompleteColor
+	ColorNormalText    lipgloss.CompleteColor
+	ColorDimText       lipgloss.CompleteColor
+	ColorBrightText    lipgloss.CompleteColor
+	ColorMutedText     lipgloss.CompleteColor
+	ColorAccent        lipgloss.CompleteColor
+	ColorAccentAlt     lipgloss.CompleteColor
+	ColorSuccess       lipgloss.CompleteColor
+	ColorWarning       lipgloss.CompleteColor
+	ColorError         lipgloss.CompleteColor
+	ColorSpinner       lipgloss.CompleteColor
+	ColorProgressEmpty lipgloss.CompleteColor
+	ColorProgressFull  lipgloss.CompleteColor
 )
 
 var (
 	// General
+	BaseStyle lipgloss.Style
+	AppStyle  lipgloss.Style
+
+	// Text Styles
+	TitleStyle          lipgloss.Style
+	SubtitleStyle       lipgloss.Style
+	NormalTextStyle     lipgloss.Style
+	SelectedTextStyle   lipgloss.Style
+	UnselectedTextStyle lipgloss.Style
+	HelpStyle           lipgloss.Style
+
+	// Status Messages
+	SuccessStyle lipgloss.Style
+	WarningStyle lipgloss.Style
+	ErrorStyle   lipgloss.Style
+	InfoStyle    lipgloss.Style
+
+	// Borders & Layout
+	BorderStyle        lipgloss.Style
+	FocusedBorderStyle lipgloss.Style
+
+	// List Styles
+	ListTitleStyle lipgloss.Style
+	ListItemStyle  lipgloss.Style
+
+	// Help / Key Map Style for Bubble Tea list
+	KeyMapStyle lipgloss.Style
+
+	// Specific Components
+	StepIndicatorHeaderStyle lipgloss.Style
+	StepStyle                lipgloss.Style
+	StepCompletedStyle       lipgloss.Style
+	StepCurrentStyle         lipgloss.Style
+	StepPendingStyle         lipgloss.Style
+	StepErrorStyle           lipgloss.Style
+
+	// Spinner
+	SpinnerStyle lipgloss.Style
+
+	// Progress Bar
+	ProgressStyle lipgloss.Style
+)
+
+func init() {
+	ApplyTheme(DefaultTheme)
+}
+
+// buildStyles derives every style from the current Color* palette
+func buildStyles() {
 	BaseStyle = lipgloss.NewStyle().Padding(0, 1)
 
 	AppStyle = lipgloss.NewStyle().
-			Margin(1, 2).
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(ColorSubtleBorder)
+		Margin(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ColorSubtleBorder)
 
-	// Text Styles
 	TitleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(ColorBrightText). // Use brighter text for titles
-			MarginBottom(1)
+		Bold(true).
+		Foreground(ColorBrightText). // Use brighter text for titles
+		MarginBottom(1)
 
 	SubtitleStyle = lipgloss.NewStyle().
-			Foreground(ColorDimText).
-			MarginBottom(1)
+		Foreground(ColorDimText).
+		MarginBottom(1)
 
 	NormalTextStyle = lipgloss.NewStyle().
-			Foreground(ColorNormalText)
+		Foreground(ColorNormalText)
 
 	SelectedTextStyle = lipgloss.NewStyle().
-				Foreground(ColorAccent). // Use Frost Green for selected text
-				Bold(true)
+		Foreground(ColorAccent).
+		Bold(true)
 
 	UnselectedTextStyle = lipgloss.NewStyle().
-				Foreground(ColorDimText) // Dimmer text for unselected
+		Foreground(ColorDimText) // Dimmer text for unselected
 
 	HelpStyle = lipgloss.NewStyle().
-			Foreground(NordPolarNight4). // Darker gray for help
-			Italic(true)
+		Foreground(ColorMutedText).
+		Italic(true)
 
-	// Status Messages
 	SuccessStyle = lipgloss.NewStyle().Foreground(ColorSuccess).Bold(true)
 	WarningStyle = lipgloss.NewStyle().Foreground(ColorWarning).Bold(true)
-	ErrorStyle   = lipgloss.NewStyle().Foreground(ColorError).Bold(true)
-	InfoStyle    = lipgloss.NewStyle().Foreground(ColorAccentAlt) // Use Frost Blue for info
+	ErrorStyle = lipgloss.NewStyle().Foreground(ColorError).Bold(true)
+	InfoStyle = lipgloss.NewStyle().Foreground(ColorAccentAlt)
 
-	// Borders & Layout
 	BorderStyle = lipgloss.NewStyle().
-			Border(lipgloss.NormalBorder()).
-			BorderForeground(ColorSubtleBorder)
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(ColorSubtleBorder)
 
 	FocusedBorderStyle = lipgloss.NewStyle().
-				Border(lipgloss.NormalBorder()).
-				BorderForeground(ColorAccent)
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(ColorAccent)
 
-	// List Styles
-	ListTitleStyle = TitleStyle.Copy().Foreground(ColorAccentAlt) // Frost blue title for lists
-	ListItemStyle  = NormalTextStyle.Copy().Padding(0, 0, 0, 2)  // Indent list items
-	// Use styling on the delegate itself rather than a prefix string here
-	// ListSelectedItemStyle = SelectedTextStyle.Copy().Padding(0, 0, 0, 0).SetString("➤ ")
+	ListTitleStyle = TitleStyle.Copy().Foreground(ColorAccentAlt)
+	ListItemStyle = NormalTextStyle.Copy().Padding(0, 0, 0, 2) // Indent list items
 
-	// Help / Key Map Style for Bubble Tea list
-	KeyMapStyle = HelpStyle.Copy().Italic(false) // Reuse HelpStyle, maybe not italic
+	KeyMapStyle = HelpStyle.Copy().Italic(false)
 
-	// Specific Components
 	StepIndicatorHeaderStyle = SubtitleStyle.Copy().MarginBottom(0)
-	// Styles for individual steps - will be used in step_indicator.go View
 	StepStyle = lipgloss.NewStyle().Padding(0, 1) // Base padding for each step
 	StepCompletedStyle = StepStyle.Copy().Foreground(ColorDimText) // Dim completed steps
-	StepCurrentStyle   = StepStyle.Copy().Foreground(ColorAccent).Bold(true).Background(ColorSubtleBorder) // Highlight current step
-	StepPendingStyle   = StepStyle.Copy().Foreground(ColorNormalText)
-	StepErrorStyle     = StepStyle.Copy().Foreground(ColorBrightText).Background(ColorError).Bold(true)
+	StepCurrentStyle = StepStyle.Copy().Foreground(ColorAccent).Bold(true).Background(ColorSubtleBorder) // Highlight current step
+	StepPendingStyle = StepStyle.Copy().Foreground(ColorNormalText)
+	StepErrorStyle = StepStyle.Copy().Foreground(ColorBrightText).Background(ColorError).Bold(true)
 
-	// Spinner
 	SpinnerStyle = lipgloss.NewStyle().Foreground(ColorSpinner)
 
-	// Progress Bar
 	ProgressStyle = lipgloss.NewStyle().
-			Foreground(ColorProgressFull).
-			Background(ColorProgressEmpty)
-)
+		Foreground(ColorProgressFull).
+		Background(ColorProgressEmpty)
+}
 
 // Helper functions from your original file (can be kept if useful)
 // We might integrate these into lipgloss layouts directly.
@@ -0,0 +1,148 @@
+package styles
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ThemeEnvVar selects the UI theme when neither --theme nor settings.yaml does
+const ThemeEnvVar = "BOOTSTRAP_CLI_THEME"
+
+// Theme is the color palette every style in this package is built from
+type Theme struct {
+	Name          string
+	Background    lipgloss.CompleteColor
+	SubtleBorder  lipgloss.CompleteColor
+	NormalText    lipgloss.CompleteColor
+	DimText       lipgloss.CompleteColor
+	BrightText    lipgloss.CompleteColor
+	MutedText     lipgloss.CompleteColor // help text and pending steps
+	Accent        lipgloss.CompleteColor
+	AccentAlt     lipgloss.CompleteColor
+	Success       lipgloss.CompleteColor
+	Warning       lipgloss.CompleteColor
+	Error         lipgloss.CompleteColor
+	ProgressEmpty lipgloss.CompleteColor
+	// Gradient holds the truecolor start and end of the progress bar; when
+	// empty the bar is filled with solid Accent
+	Gradient [2]string
+}
+
+// DefaultTheme is the Nord palette on a dark background
+var DefaultTheme = Theme{
+	Name:          "default",
+	Background:    NordPolarNight1,
+	SubtleBorder:  NordPolarNight3,
+	NormalText:    NordSnowStorm2,
+	DimText:       NordSnowStorm1,
+	BrightText:    NordSnowStorm3,
+	MutedText:     NordPolarNight4,
+	Accent:        NordFrostGreen,
+	AccentAlt:     NordFrostBlue,
+	Success:       NordAuroraGreen,
+	Warning:       NordAuroraYellow,
+	Error:         NordAuroraRed,
+	ProgressEmpty: NordPolarNight3,
+	Gradient:      [2]string{"#5A56E0", "#EE6FF8"},
+}
+
+// LightTheme uses darker Nord tones that stay readable on light backgrounds
+var LightTheme = Theme{
+	Name:          "light",
+	Background:    NordSnowStorm3,
+	SubtleBorder:  NordSnowStorm1,
+	NormalText:    NordPolarNight1,
+	DimText:       NordPolarNight4,
+	BrightText:    lipgloss.CompleteColor{TrueColor: "#000000", ANSI256: "16", ANSI: "0"},
+	MutedText:     NordPolarNight4,
+	Accent:        lipgloss.CompleteColor{TrueColor: "#5E81AC", ANSI256: "67", ANSI: "4"},
+	AccentAlt:     lipgloss.CompleteColor{TrueColor: "#4C7A79", ANSI256: "66", ANSI: "6"},
+	Success:       lipgloss.CompleteColor{TrueColor: "#4F7A3A", ANSI256: "64", ANSI: "2"},
+	Warning:       lipgloss.CompleteColor{TrueColor: "#9A6700", ANSI256: "136", ANSI: "3"},
+	Error:         lipgloss.CompleteColor{TrueColor: "#A3333D", ANSI256: "124", ANSI: "1"},
+	ProgressEmpty: NordSnowStorm1,
+	Gradient:      [2]string{"#5E81AC", "#4C7A79"},
+}
+
+// HighContrastTheme uses pure, bright colors on black for low-vision users and
+// terminals where the default accents wash out
+var HighContrastTheme = Theme{
+	Name:          "high-contrast",
+	Background:    lipgloss.CompleteColor{TrueColor: "#000000", ANSI256: "16", ANSI: "0"},
+	SubtleBorder:  lipgloss.CompleteColor{TrueColor: "#FFFFFF", ANSI256: "231", ANSI: "15"},
+	NormalText:    lipgloss.CompleteColor{TrueColor: "#FFFFFF", ANSI256: "231", ANSI: "15"},
+	DimText:       lipgloss.CompleteColor{TrueColor: "#FFFFFF", ANSI256: "231", ANSI: "15"},
+	BrightText:    lipgloss.CompleteColor{TrueColor: "#FFFFFF", ANSI256: "231", ANSI: "15"},
+	MutedText:     lipgloss.CompleteColor{TrueColor: "#D0D0D0", ANSI256: "252", ANSI: "7"},
+	Accent:        lipgloss.CompleteColor{TrueColor: "#00FFFF", ANSI256: "51", ANSI: "14"},
+	AccentAlt:     lipgloss.CompleteColor{TrueColor: "#FFFF00", ANSI256: "226", ANSI: "11"},
+	Success:       lipgloss.CompleteColor{TrueColor: "#00FF00", ANSI256: "46", ANSI: "10"},
+	Warning:       lipgloss.CompleteColor{TrueColor: "#FFFF00", ANSI256: "226", ANSI: "11"},
+	Error:         lipgloss.CompleteColor{TrueColor: "#FF5555", ANSI256: "203", ANSI: "9"},
+	ProgressEmpty: lipgloss.CompleteColor{TrueColor: "#444444", ANSI256: "238", ANSI: "8"},
+}
+
+// MonochromeTheme sets no colors at all, leaving the terminal's own foreground;
+// selection and status are still conveyed by bold text and marks
+var MonochromeTheme = Theme{Name: "monochrome"}
+
+// Themes are the presets selectable with --theme
+var Themes = map[string]Theme{
+	DefaultTheme.Name:      DefaultTheme,
+	LightTheme.Name:        LightTheme,
+	HighContrastTheme.Name: HighContrastTheme,
+	MonochromeTheme.Name:   MonochromeTheme,
+}
+
+var currentTheme Theme
+
+// ThemeNames returns the preset names in sorted order
+func ThemeNames() []string {
+	names := make([]string, 0, len(Themes))
+	for name := range Themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupTheme returns the preset called name; an empty name is the default theme
+func LookupTheme(name string) (Theme, error) {
+	if name == "" {
+		return DefaultTheme, nil
+	}
+	theme, ok := Themes[strings.ToLower(name)]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q: must be one of %s", name, strings.Join(ThemeNames(), ", "))
+	}
+	return theme, nil
+}
+
+// ApplyTheme recolors every style in this package with t. Call it before the
+// UI is built; components copy styles when they are created.
+func ApplyTheme(t Theme) {
+	currentTheme = t
+	ColorBackground = t.Background
+	ColorSubtleBorder = t.SubtleBorder
+	ColorNormalText = t.NormalText
+	ColorDimText = t.DimText
+	ColorBrightText = t.BrightText
+	ColorMutedText = t.MutedText
+	ColorAccent = t.Accent
+	ColorAccentAlt = t.AccentAlt
+	ColorSuccess = t.Success
+	ColorWarning = t.Warning
+	ColorError = t.Error
+	ColorSpinner = t.Accent
+	ColorProgressEmpty = t.ProgressEmpty
+	ColorProgressFull = t.Accent
+	buildStyles()
+}
+
+// CurrentTheme returns the theme set by ApplyTheme
+func CurrentTheme() Theme {
+	return currentTheme
+}
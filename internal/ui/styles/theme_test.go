@@ -0,0 +1,34 @@
+package styles
+
+import "testing"
+
+func TestLookupTheme(t *testing.T) {
+	for _, name := range []string{"", "default", "light", "High-Contrast", "monochrome"} {
+		if _, err := LookupTheme(name); err != nil {
+			t.Errorf("LookupTheme(%q) failed: %v", name, err)
+		}
+	}
+	if _, err := LookupTheme("neon"); err == nil {
+		t.Error("expected an error for an unknown theme")
+	}
+}
+
+func TestApplyThemeRecolorsStyles(t *testing.T) {
+	defer ApplyTheme(DefaultTheme)
+
+	ApplyTheme(HighContrastTheme)
+	if ColorAccent != HighContrastTheme.Accent || ColorProgressFull != HighContrastTheme.Accent {
+		t.Errorf("expected the accent colors to follow the theme")
+	}
+	if got := SelectedTextStyle.GetForeground(); got != HighContrastTheme.Accent {
+		t.Errorf("SelectedTextStyle foreground = %v, want %v", got, HighContrastTheme.Accent)
+	}
+
+	ApplyTheme(MonochromeTheme)
+	if ColorFor(ColorAccent) != "" {
+		t.Errorf("expected monochrome to set no accent color")
+	}
+	if CurrentTheme().Name != "monochrome" {
+		t.Errorf("CurrentTheme() = %q, want monochrome", CurrentTheme().Name)
+	}
+}
@@ -0,0 +1,113 @@
+package uninstall
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+)
+
+// PrintRollback lists what rolling back the journaled entries undoes, most
+// recent first
+func PrintRollback(w io.Writer, entries []manifest.JournalEntry) {
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "Nothing to roll back")
+		return
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		fmt.Fprintf(w, "  - %s\n", entries[i])
+	}
+}
+
+// Rollback undoes the actions recorded in the journal at path, most recent
+// first: managed blocks are removed from rc files, created files and directories are deleted and
+// packages are uninstalled. The journal only records what did not exist before
+// the run, so nothing else is touched. Every action is attempted; the journal
+// keeps the ones that could not be undone and the first error is returned.
+// Rolled back packages are dropped from the installed snapshot.
+func (u *Uninstaller) Rollback(path string) error {
+	journal, err := manifest.LoadJournal(path)
+	if err != nil || journal == nil {
+		return err
+	}
+
+	var firstErr error
+	var left []manifest.JournalEntry
+	removed := make(map[string]bool)
+	for i := len(journal.Entries) - 1; i >= 0; i-- {
+		entry := journal.Entries[i]
+		if err := u.undo(entry); err != nil {
+			u.Logger.Error("%v", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			left = append([]manifest.JournalEntry{entry}, left...)
+			continue
+		}
+		if entry.Kind == manifest.JournalPackage {
+			removed[entry.Item] = true
+		}
+	}
+
+	// An item with a package left installed stays recorded
+	for _, entry := range left {
+		delete(removed, entry.Item)
+	}
+	if len(removed) > 0 && u.StatePath != "" {
+		err := manifest.UpdateInstalled(u.StatePath, func(s *manifest.Installed) {
+			for name := range removed {
+				delete(s.Tools, name)
+				delete(s.Languages, name)
+			}
+		})
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if len(left) > 0 {
+		journal.Entries = left
+		if err := journal.Save(path); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return firstErr
+	}
+	if err := manifest.RemoveJournal(path); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+// undo reverses one journaled action
+func (u *Uninstaller) undo(entry manifest.JournalEntry) error {
+	switch entry.Kind {
+	case manifest.JournalRCBlock:
+		if _, err := u.RCWriter.RemoveBlock(entry.Path, entry.Block); err != nil {
+			return fmt.Errorf("failed to remove block %s from %s: %w", entry.Block, entry.Path, err)
+		}
+		u.Logger.Info("Removed block %s from %s", entry.Block, entry.Path)
+	case manifest.JournalFile:
+		if err := os.Remove(entry.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", entry.Path, err)
+		}
+		u.Logger.Info("Removed %s", entry.Path)
+	case manifest.JournalDir:
+		if err := os.RemoveAll(entry.Path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", entry.Path, err)
+		}
+		u.Logger.Info("Removed %s", entry.Path)
+	case manifest.JournalPackage:
+		if manager := u.PackageManager.GetName(); entry.Manager != manager {
+			return fmt.Errorf("cannot remove %s: it was installed with %s, not %s", entry.Package, entry.Manager, manager)
+		}
+		var failed error
+		u.removePackages([]Item{{Name: entry.Package, Manager: entry.Manager, Package: entry.Package}}, func(err error) {
+			failed = err
+		})
+		return failed
+	default:
+		return fmt.Errorf("cannot roll back unknown action %q for %s", entry.Kind, entry.Item)
+	}
+	return nil
+}
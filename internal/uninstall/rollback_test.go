@@ -0,0 +1,109 @@
+package uninstall
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/install/installtest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+)
+
+func TestRollback(t *testing.T) {
+	home := t.TempDir()
+	bashrc := filepath.Join(home, ".bashrc")
+	rc := shell.UpsertBlock("export EDITOR=vim\n", "fd", "alias find=fd")
+	if err := os.WriteFile(bashrc, []byte(shell.UpsertBlock(rc, "prompt", `eval "$(starship init bash)"`)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	starship := filepath.Join(home, ".config", "starship.toml")
+	if err := os.MkdirAll(filepath.Dir(starship), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(starship, []byte("add_newline = false\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tpm := filepath.Join(home, ".tmux", "plugins", "tpm")
+	if err := os.MkdirAll(filepath.Join(tpm, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	pm := installtest.NewPackageManager("apt")
+	for _, pkg := range []string{"git", "fd-find"} {
+		_ = pm.Install(pkg)
+	}
+	statePath := filepath.Join(home, ".bootstrap-cli", manifest.InstalledFileName)
+	installed := manifest.NewInstalled()
+	installed.Tools["fd"] = manifest.InstalledItem{Manager: "apt", Package: "fd-find"}
+	installed.Tools["git"] = manifest.InstalledItem{Manager: "apt", PreExisting: true}
+	if err := installed.Save(statePath); err != nil {
+		t.Fatal(err)
+	}
+
+	// git, the fd block and anything else already there are not journaled
+	path := filepath.Join(home, ".bootstrap-cli", manifest.JournalFileName)
+	journal := &manifest.Journal{PackageManager: "apt", Entries: []manifest.JournalEntry{
+		{Kind: manifest.JournalPackage, Item: "fd", Manager: "apt", Package: "fd-find"},
+		{Kind: manifest.JournalPackage, Item: "bat", Manager: "brew", Package: "bat"},
+		{Kind: manifest.JournalFile, Item: "starship", Path: starship},
+		{Kind: manifest.JournalDir, Item: "tmux", Path: tpm},
+		{Kind: manifest.JournalRCBlock, Item: "starship", Path: bashrc, Block: "prompt"},
+	}}
+	if err := journal.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	PrintRollback(&buf, journal.Entries)
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 5 || !strings.Contains(lines[0], "rc block prompt") {
+		t.Errorf("Expected the most recent action listed first, got:\n%s", buf.String())
+	}
+
+	u := &Uninstaller{
+		PackageManager: pm,
+		RCWriter:       &shell.RCWriter{},
+		StatePath:      statePath,
+		Logger:         log.New(log.FatalLevel),
+	}
+	if err := u.Rollback(path); err == nil || !strings.Contains(err.Error(), "installed with brew") {
+		t.Fatalf("Expected the brew package to be left with an error, got %v", err)
+	}
+
+	if left, _ := pm.ListInstalled(); strings.Join(left, ",") != "git" {
+		t.Errorf("Expected only the pre-existing git to stay installed, got %v", left)
+	}
+	if data, _ := os.ReadFile(bashrc); string(data) != rc {
+		t.Errorf("Expected only the journaled block to be removed, got %q", data)
+	}
+	if _, err := os.Stat(starship); !os.IsNotExist(err) {
+		t.Error("Expected the created prompt config to be removed")
+	}
+	if _, err := os.Stat(tpm); !os.IsNotExist(err) {
+		t.Error("Expected the cloned directory to be removed")
+	}
+	s, err := manifest.LoadInstalled(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Tools["fd"]; ok || len(s.Tools) != 1 {
+		t.Errorf("Expected fd dropped from the snapshot, got %+v", s.Tools)
+	}
+
+	// What could not be undone stays in the journal for another attempt
+	left, err := manifest.LoadJournal(path)
+	if err != nil || left == nil || len(left.Entries) != 1 || left.Entries[0].Item != "bat" {
+		t.Fatalf("Expected only bat left in the journal, got %+v, %v", left, err)
+	}
+	pm.Name = "brew"
+	_ = pm.Install("bat")
+	if err := u.Rollback(path); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if j, _ := manifest.LoadJournal(path); j != nil {
+		t.Errorf("Expected the journal to be removed once everything was rolled back, got %+v", j)
+	}
+}
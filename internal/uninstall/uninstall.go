@@ -0,0 +1,266 @@
+// Package uninstall reverses what bootstrap-cli installed and configured: the
+// tools and languages in the installed snapshot, shell frameworks, prompt
+// configs and the managed blocks in shell rc files
+package uninstall
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/interfaces"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/system"
+)
+
+// Item is a tool or language to remove with its package manager, or a
+// release binary to delete
+type Item struct {
+	Name    string
+	Manager string
+	Package string
+	// Path is the binary of an item installed from a GitHub release
+	Path string
+}
+
+// source is what the item is removed from: its package or release binary
+func (i Item) source() string {
+	if i.Manager == manifest.ManagerGitHubRelease {
+		return i.Path
+	}
+	return i.Package
+}
+
+// RCFile is an rc file and the managed blocks to strip from it
+type RCFile struct {
+	Path   string
+	Blocks []string
+}
+
+// Plan is everything an uninstall removes, and what it deliberately leaves
+type Plan struct {
+	Tools      []Item
+	Languages  []Item
+	Frameworks []shell.InstalledFramework
+	// Prompts are the prompt config files bootstrap-cli wrote, by style
+	Prompts map[string]string
+	RCFiles []RCFile
+	// Kept are the items left in place, each with why: they were on the system
+	// before bootstrap-cli ran, or a version manager owns them
+	Kept []string
+}
+
+// Empty reports whether there is nothing to remove
+func (p *Plan) Empty() bool {
+	return len(p.Tools) == 0 && len(p.Languages) == 0 && len(p.Frameworks) == 0 && len(p.Prompts) == 0 && len(p.RCFiles) == 0
+}
+
+// NewPlan works out what to remove from the installed snapshot, the recorded
+// frameworks and the rc files under home. Pre-existing items are kept.
+func NewPlan(home string, installed *manifest.Installed, frameworks []shell.InstalledFramework) (*Plan, error) {
+	plan := &Plan{Frameworks: frameworks, Prompts: make(map[string]string)}
+	plan.Tools = items(installed.Tools, "tool", &plan.Kept)
+	plan.Languages = items(installed.Languages, "language", &plan.Kept)
+	for style, item := range installed.Prompts {
+		if item.PreExisting || item.Path == "" {
+			plan.Kept = append(plan.Kept, "prompt config "+style+preExistingReason)
+			continue
+		}
+		plan.Prompts[style] = item.Path
+	}
+	sort.Strings(plan.Kept)
+
+	for _, path := range shell.RCFiles(home) {
+		blocks, err := shell.ListManagedBlocks(path)
+		if err != nil {
+			return nil, err
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+		file := RCFile{Path: path}
+		for _, b := range blocks {
+			file.Blocks = append(file.Blocks, b.Key)
+		}
+		plan.RCFiles = append(plan.RCFiles, file)
+	}
+	return plan, nil
+}
+
+// preExistingReason is why an item that predates bootstrap-cli is kept
+const preExistingReason = " (was installed before bootstrap-cli, keeping)"
+
+// items returns the recorded items to remove sorted by name, adding the
+// pre-existing ones and those a version manager installed to kept
+func items(recorded map[string]manifest.InstalledItem, kind string, kept *[]string) []Item {
+	var remove []Item
+	for name, item := range recorded {
+		if item.PreExisting {
+			*kept = append(*kept, kind+" "+name+preExistingReason)
+			continue
+		}
+		// A version manager may hold other versions the user relies on
+		if system.IsVersionManager(item.Manager) {
+			*kept = append(*kept, fmt.Sprintf("%s %s (installed with %s, keeping; remove it with %s)", kind, name, item.Manager, item.Manager))
+			continue
+		}
+		if item.Manager == manifest.ManagerGitHubRelease {
+			remove = append(remove, Item{Name: name, Manager: item.Manager, Path: item.Path})
+			continue
+		}
+		pkg := item.Package
+		if pkg == "" {
+			pkg = name
+		}
+		remove = append(remove, Item{Name: name, Manager: item.Manager, Package: pkg})
+	}
+	sort.Slice(remove, func(i, j int) bool { return remove[i].Name < remove[j].Name })
+	return remove
+}
+
+// Print lists what the plan removes and keeps
+func (p *Plan) Print(w io.Writer) {
+	if p.Empty() {
+		fmt.Fprintln(w, "Nothing installed by bootstrap-cli to remove")
+	}
+	for _, tool := range p.Tools {
+		fmt.Fprintf(w, "  - tool %s (%s via %s)\n", tool.Name, tool.source(), tool.Manager)
+	}
+	for _, lang := range p.Languages {
+		fmt.Fprintf(w, "  - language %s (%s via %s)\n", lang.Name, lang.source(), lang.Manager)
+	}
+	for _, fw := range p.Frameworks {
+		fmt.Fprintf(w, "  - shell framework %s (%s)\n", fw.Name, fw.Dir)
+	}
+	styles := make([]string, 0, len(p.Prompts))
+	for style := range p.Prompts {
+		styles = append(styles, style)
+	}
+	sort.Strings(styles)
+	for _, style := range styles {
+		fmt.Fprintf(w, "  - prompt config %s (%s)\n", style, p.Prompts[style])
+	}
+	for _, file := range p.RCFiles {
+		for _, block := range file.Blocks {
+			fmt.Fprintf(w, "  - rc block %s in %s\n", block, file.Path)
+		}
+	}
+	for _, kept := range p.Kept {
+		fmt.Fprintf(w, "  = %s\n", kept)
+	}
+}
+
+// Uninstaller carries out a Plan
+type Uninstaller struct {
+	// PackageManager removes tools and languages; items recorded with another
+	// manager are skipped
+	PackageManager interfaces.PackageManager
+	Frameworks     *shell.FrameworkInstaller
+	RCWriter       *shell.RCWriter
+	// StatePath is the installed snapshot removed items are dropped from
+	StatePath string
+	Logger    *log.Logger
+}
+
+// Run removes everything in plan. Every item is attempted; items that could not
+// be removed stay in the snapshot and the first error is returned.
+func (u *Uninstaller) Run(plan *Plan) error {
+	var firstErr error
+	fail := func(err error) {
+		u.Logger.Error("%v", err)
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	removedTools := u.removePackages(plan.Tools, fail)
+	removedLanguages := u.removePackages(plan.Languages, fail)
+
+	for _, fw := range plan.Frameworks {
+		if err := u.Frameworks.Uninstall(fw.Name); err != nil {
+			fail(fmt.Errorf("failed to remove %s: %w", fw.Name, err))
+			continue
+		}
+		u.Logger.Info("Removed %s", fw.Name)
+	}
+
+	var removedPrompts []string
+	for style, path := range plan.Prompts {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			fail(fmt.Errorf("failed to remove %s prompt config: %w", style, err))
+			continue
+		}
+		removedPrompts = append(removedPrompts, style)
+	}
+
+	for _, file := range plan.RCFiles {
+		if _, err := u.RCWriter.StripBlocks(file.Path); err != nil {
+			fail(fmt.Errorf("failed to strip bootstrap-cli blocks from %s: %w", file.Path, err))
+			continue
+		}
+		u.Logger.Info("Removed %d block(s) from %s", len(file.Blocks), file.Path)
+	}
+
+	err := manifest.UpdateInstalled(u.StatePath, func(s *manifest.Installed) {
+		for _, name := range removedTools {
+			delete(s.Tools, name)
+		}
+		for _, name := range removedLanguages {
+			delete(s.Languages, name)
+		}
+		for _, style := range removedPrompts {
+			delete(s.Prompts, style)
+		}
+	})
+	if err != nil {
+		fail(err)
+	}
+	return firstErr
+}
+
+// removePackages uninstalls items, deleting release binaries rather than
+// going through the package manager, and returns the names of those removed
+func (u *Uninstaller) removePackages(items []Item, fail func(error)) []string {
+	var removed []string
+	for _, item := range items {
+		if item.Manager == manifest.ManagerGitHubRelease {
+			if err := removeBinary(item); err != nil {
+				fail(err)
+				continue
+			}
+			u.Logger.Info("Removed %s", item.Name)
+			removed = append(removed, item.Name)
+			continue
+		}
+		if manager := u.PackageManager.GetName(); item.Manager != "" && item.Manager != manager {
+			u.Logger.Warn("Skipping %s: it was installed with %s, not %s", item.Name, item.Manager, manager)
+			continue
+		}
+		if installed, err := u.PackageManager.IsInstalled(item.Package); err == nil && !installed {
+			u.Logger.Info("%s is already gone", item.Name)
+			removed = append(removed, item.Name)
+			continue
+		}
+		if err := u.PackageManager.Uninstall(item.Package); err != nil {
+			fail(fmt.Errorf("failed to remove %s: %w", item.Name, err))
+			continue
+		}
+		u.Logger.Info("Removed %s", item.Name)
+		removed = append(removed, item.Name)
+	}
+	return removed
+}
+
+// removeBinary deletes the release binary of item; one already gone counts as removed
+func removeBinary(item Item) error {
+	if item.Path == "" {
+		return fmt.Errorf("failed to remove %s: its release binary was not recorded", item.Name)
+	}
+	if err := os.Remove(item.Path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", item.Name, err)
+	}
+	return nil
+}
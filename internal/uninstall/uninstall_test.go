@@ -0,0 +1,127 @@
+package uninstall
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/install/installtest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/log"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/manifest"
+	"github.com/YitzhakMizrahi/bootstrap-cli/internal/shell"
+)
+
+func TestUninstall(t *testing.T) {
+	home := t.TempDir()
+	bashrc := filepath.Join(home, ".bashrc")
+	rc := "export EDITOR=vim\n" + shell.UpsertBlock("", "fd", "alias find=fd") + "\n# Added by bootstrap-cli\nexport NVM_DIR=\"$HOME/.nvm\"\n"
+	if err := os.WriteFile(bashrc, []byte(rc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	starship := filepath.Join(home, ".config", "starship.toml")
+	if err := os.MkdirAll(filepath.Dir(starship), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(starship, []byte("add_newline = false\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	omz := filepath.Join(home, ".oh-my-zsh")
+	if err := os.MkdirAll(omz, 0755); err != nil {
+		t.Fatal(err)
+	}
+	frameworks := &shell.FrameworkInstaller{HomeDir: home, StatePath: filepath.Join(home, ".bootstrap-cli", "frameworks.json")}
+	if err := os.MkdirAll(filepath.Dir(frameworks.StatePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	state, _ := json.Marshal(map[string]shell.InstalledFramework{"oh-my-zsh": {Name: "oh-my-zsh", Dir: omz}})
+	if err := os.WriteFile(frameworks.StatePath, state, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lazygit := filepath.Join(home, ".local", "bin", "lazygit")
+	if err := os.MkdirAll(filepath.Dir(lazygit), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(lazygit, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	statePath := filepath.Join(home, ".bootstrap-cli", manifest.InstalledFileName)
+	installed := manifest.NewInstalled()
+	installed.Tools["fd"] = manifest.InstalledItem{Manager: "apt", Package: "fd-find"}
+	installed.Tools["git"] = manifest.InstalledItem{Manager: "apt", PreExisting: true}
+	installed.Tools["bat"] = manifest.InstalledItem{Manager: "brew"}
+	installed.Tools["lazygit"] = manifest.InstalledItem{Manager: manifest.ManagerGitHubRelease, Path: lazygit}
+	installed.Languages["Python"] = manifest.InstalledItem{Manager: "apt", Package: "python3"}
+	installed.Languages["Node.js"] = manifest.InstalledItem{Manager: "mise", Package: "node"}
+	installed.Prompts["starship"] = manifest.InstalledItem{Path: starship}
+	if err := installed.Save(statePath); err != nil {
+		t.Fatal(err)
+	}
+
+	recorded, err := frameworks.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := NewPlan(home, installed, recorded)
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	var buf bytes.Buffer
+	plan.Print(&buf)
+	for _, want := range []string{
+		"- tool fd (fd-find via apt)",
+		"- tool lazygit (" + lazygit + " via github_release)",
+		"- language Python (python3 via apt)",
+		"- shell framework oh-my-zsh",
+		"- prompt config starship",
+		"- rc block fd in " + bashrc,
+		"= tool git (was installed before bootstrap-cli, keeping)",
+		"= language Node.js (installed with mise, keeping; remove it with mise)",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected the plan to list %q, got:\n%s", want, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "- tool git") {
+		t.Errorf("Expected git not to be removed, got:\n%s", buf.String())
+	}
+
+	pm := installtest.NewPackageManager("apt")
+	for _, pkg := range []string{"fd-find", "git", "python3"} {
+		_ = pm.Install(pkg)
+	}
+	u := &Uninstaller{
+		PackageManager: pm,
+		Frameworks:     frameworks,
+		RCWriter:       &shell.RCWriter{},
+		StatePath:      statePath,
+		Logger:         log.New(log.ErrorLevel),
+	}
+	if err := u.Run(plan); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if left, _ := pm.ListInstalled(); strings.Join(left, ",") != "git" {
+		t.Errorf("Expected only the pre-existing git to stay installed, got %v", left)
+	}
+	if data, _ := os.ReadFile(bashrc); string(data) != "export EDITOR=vim\n" {
+		t.Errorf("Expected the managed blocks to be stripped, got %q", data)
+	}
+	for _, path := range []string{starship, omz, lazygit} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed", path)
+		}
+	}
+	s, err := manifest.LoadInstalled(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// bat was installed with brew, so it is skipped and stays recorded
+	if _, ok := s.Tools["bat"]; !ok || len(s.Tools) != 2 || len(s.Languages) != 1 || len(s.Prompts) != 0 {
+		t.Errorf("Expected only git, bat and Node.js to stay in the snapshot, got %+v", s)
+	}
+}
@@ -0,0 +1,72 @@
+// Package version reports which build of bootstrap-cli is running. The values
+// are stamped at build time with -ldflags, e.g.
+//
+//	go build -ldflags "-X github.com/YitzhakMizrahi/bootstrap-cli/internal/version.Version=v1.2.0 \
+//	  -X github.com/YitzhakMizrahi/bootstrap-cli/internal/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X github.com/YitzhakMizrahi/bootstrap-cli/internal/version.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build metadata, set with -ldflags -X
+var (
+	// Version is the release version, "dev" for unstamped builds
+	Version = "dev"
+	// Commit is the git commit the binary was built from
+	Commit = ""
+	// Date is when the binary was built (RFC 3339)
+	Date = ""
+)
+
+// Info describes the running build
+type Info struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit,omitempty"`
+	Date    string `json:"date,omitempty"`
+	// ConfigVersion identifies the embedded default configs
+	ConfigVersion string `json:"config_version,omitempty"`
+	GoVersion     string `json:"go_version"`
+	Platform      string `json:"platform"`
+}
+
+// Get returns the build information. Unstamped builds fall back to the VCS
+// details Go records in the binary (go build from a checkout).
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.Date == "":
+				info.Date = setting.Value
+			}
+		}
+	}
+	if len(info.Commit) > 12 {
+		info.Commit = info.Commit[:12]
+	}
+	return info
+}
+
+// String renders e.g. "bootstrap-cli v1.2.0 (3f2a9c1, 2026-01-02T15:04:05Z)"
+func (i Info) String() string {
+	s := "bootstrap-cli " + i.Version
+	switch {
+	case i.Commit != "" && i.Date != "":
+		s += fmt.Sprintf(" (%s, %s)", i.Commit, i.Date)
+	case i.Commit != "":
+		s += fmt.Sprintf(" (%s)", i.Commit)
+	}
+	return s
+}
@@ -5,22 +5,43 @@ package main
 import (
 	"log"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
 	"github.com/YitzhakMizrahi/bootstrap-cli/cmd"
 	"github.com/YitzhakMizrahi/bootstrap-cli/internal/config"
 )
 
 func main() {
+	// Remove config dirs left behind by runs that were killed before cleaning up
+	if _, err := config.PruneTempConfigDirs(os.TempDir(), config.StaleTempDirAge); err != nil {
+		log.Printf("Failed to prune stale config directories: %v", err)
+	}
+
 	// Create a temporary directory for extracted configs
-	tempDir, err := os.MkdirTemp("", "bootstrap-cli-config-*")
+	tempDir, err := config.NewTempConfigDir()
 	if err != nil {
 		log.Fatalf("Failed to create temp directory: %v", err)
 	}
-	defer os.RemoveAll(tempDir) // Clean up on exit
+	var cleanupOnce sync.Once
+	cleanup := func() {
+		cleanupOnce.Do(func() { os.RemoveAll(tempDir) })
+	}
+
+	// Deferred calls don't run when the process is signalled, so clean up here too
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		cleanup()
+		os.Exit(128 + int(sig.(syscall.Signal)))
+	}()
 
 	// Create config loader and extract configs
 	configLoader := config.NewLoader(tempDir)
 	if err := configLoader.ExtractEmbeddedConfigs(); err != nil {
+		cleanup()
 		log.Fatalf("Failed to extract embedded configs: %v", err)
 	}
 
@@ -28,5 +49,7 @@ func main() {
 	os.Setenv("BOOTSTRAP_CLI_CONFIG", tempDir)
 
 	// Execute the root command
-	cmd.Execute()
-} 
\ No newline at end of file
+	code := cmd.Execute()
+	cleanup()
+	os.Exit(code)
+}